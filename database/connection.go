@@ -0,0 +1,120 @@
+package database
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+)
+
+// SSL modes supported by the PostgreSQL driver. lib/pq does not implement
+// libpq's allow and prefer modes.
+const (
+	SSLModeDisable    = "disable"
+	SSLModeRequire    = "require"
+	SSLModeVerifyCA   = "verify-ca"
+	SSLModeVerifyFull = "verify-full"
+)
+
+// ConnectionString returns the DSN from config with its SSL settings applied.
+// SSLMode, SSLRootCert, SSLCert and SSLKey override any matching parameters in
+// the DSN, which may be a postgres:// URL or a key=value connection string.
+// Referenced certificate and key files must exist. DSNs of other drivers are
+// returned unchanged.
+func ConnectionString(config tenant.DatabaseConfig) (string, error) {
+	if !config.IsPostgres() {
+		return config.DSN, nil
+	}
+
+	params, err := sslParams(config)
+	if err != nil {
+		return "", err
+	}
+
+	if len(params) == 0 {
+		return config.DSN, nil
+	}
+
+	if strings.HasPrefix(config.DSN, "postgres://") || strings.HasPrefix(config.DSN, "postgresql://") {
+		dsn, err := url.Parse(config.DSN)
+		if err != nil {
+			return "", fmt.Errorf("invalid database URL: %w", err)
+		}
+
+		query := dsn.Query()
+		for _, param := range params {
+			query.Set(param[0], param[1])
+		}
+		dsn.RawQuery = query.Encode()
+
+		return dsn.String(), nil
+	}
+
+	// Later keys win in key=value connection strings
+	pairs := make([]string, 0, len(params)+1)
+	if dsn := strings.TrimSpace(config.DSN); dsn != "" {
+		pairs = append(pairs, dsn)
+	}
+	for _, param := range params {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", param[0], quoteConnValue(param[1])))
+	}
+
+	return strings.Join(pairs, " "), nil
+}
+
+// sslParams validates the SSL settings in config and returns them as ordered
+// key/value pairs
+func sslParams(config tenant.DatabaseConfig) ([][2]string, error) {
+	var params [][2]string
+
+	switch config.SSLMode {
+	case "":
+	case SSLModeDisable, SSLModeRequire, SSLModeVerifyCA, SSLModeVerifyFull:
+		params = append(params, [2]string{"sslmode", config.SSLMode})
+	default:
+		return nil, fmt.Errorf("invalid sslmode %q", config.SSLMode)
+	}
+
+	if config.SSLMode == SSLModeDisable && (config.SSLRootCert != "" || config.SSLCert != "" || config.SSLKey != "") {
+		return nil, fmt.Errorf("SSL certificates configured with sslmode %q", SSLModeDisable)
+	}
+
+	if (config.SSLCert == "") != (config.SSLKey == "") {
+		return nil, fmt.Errorf("sslcert and sslkey must be set together")
+	}
+
+	files := [][2]string{
+		{"sslrootcert", config.SSLRootCert},
+		{"sslcert", config.SSLCert},
+		{"sslkey", config.SSLKey},
+	}
+	for _, file := range files {
+		if file[1] == "" {
+			continue
+		}
+
+		info, err := os.Stat(file[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s file: %w", file[0], err)
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("%s file %s is a directory", file[0], file[1])
+		}
+
+		params = append(params, file)
+	}
+
+	return params, nil
+}
+
+// quoteConnValue quotes a key=value connection string value if needed
+func quoteConnValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+
+	escaped := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+	return "'" + escaped + "'"
+}
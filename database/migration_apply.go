@@ -0,0 +1,191 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// DefaultMigrationConcurrency is how many tenants ApplyToAllTenants migrates
+// at once when ApplyOptions.Concurrency is not set
+const DefaultMigrationConcurrency = 4
+
+// ApplyOptions controls how ApplyToAllTenantsWithOptions migrates tenants
+type ApplyOptions struct {
+	Concurrency int  // Tenants migrated at once; 0 uses DefaultMigrationConcurrency
+	StopOnError bool // Start no more tenants after a failure; otherwise every tenant is attempted
+}
+
+// MigrationFailedError is returned by ApplyToAllTenants when the migration
+// failed for some tenants. The others keep the migration.
+type MigrationFailedError struct {
+	Version   string
+	Failures  tenant.TenantFailures // in the order the tenants were listed
+	Attempted int                   // tenants migrated or failed; fewer than Total after StopOnError
+	Total     int                   // tenants the migration applies to
+}
+
+// Error implements the error interface
+func (e *MigrationFailedError) Error() string {
+	return fmt.Sprintf("migration %s failed for %d of %d tenants: %s",
+		e.Version, len(e.Failures), e.Total, e.Failures)
+}
+
+// Unwrap returns the tenants' errors, for errors.Is and errors.As
+func (e *MigrationFailedError) Unwrap() []error {
+	return e.Failures.Errors()
+}
+
+// TenantIDs returns the tenants the migration failed for
+func (e *MigrationFailedError) TenantIDs() []uuid.UUID {
+	return e.Failures.TenantIDs()
+}
+
+// ApplyToAllTenantsWithOptions applies migration to every active tenant it
+// applies to, opts.Concurrency tenants at a time. Each tenant is migrated with
+// ApplyMigration, in its own transaction, so a failure leaves the other
+// tenants' migrations in place. Failures are returned together as a
+// *MigrationFailedError once the remaining tenants are done, or, with
+// opts.StopOnError, once the tenants already started are done.
+func (m *MigrationManager) ApplyToAllTenantsWithOptions(ctx context.Context, migration *tenant.Migration, opts ApplyOptions) error {
+	m.logger.Info("Applying migration to all tenants",
+		zap.String("migration_version", migration.Version),
+		zap.String("migration_name", migration.Name))
+
+	tenantIDs, err := m.migrationTargets(ctx, migration)
+	if err != nil {
+		return err
+	}
+
+	failed := forEachTenant(tenantIDs, opts, func(i int, tenantID uuid.UUID) error {
+		return m.ApplyMigration(ctx, tenantID, migration)
+	})
+	failed.Version = migration.Version
+
+	if len(failed.Failures) > 0 {
+		m.logger.Error("Migration failed for some tenants",
+			zap.String("migration_version", migration.Version),
+			zap.Int("failed", len(failed.Failures)),
+			zap.Int("attempted", failed.Attempted),
+			zap.Int("tenants", failed.Total))
+		return failed
+	}
+
+	m.logger.Info("Migration applied to all tenants successfully",
+		zap.String("migration_version", migration.Version),
+		zap.Int("tenants", failed.Total))
+
+	return nil
+}
+
+// forEachTenant calls fn for each tenant, opts.Concurrency at a time, and
+// collects the failures. With opts.StopOnError no tenant is started after a
+// failure.
+func forEachTenant(tenantIDs []uuid.UUID, opts ApplyOptions, fn func(i int, tenantID uuid.UUID) error) *MigrationFailedError {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultMigrationConcurrency
+	}
+
+	errs := make([]error, len(tenantIDs))
+	attempted := make([]bool, len(tenantIDs))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	stopped := false
+
+	for i, tenantID := range tenantIDs {
+		sem <- struct{}{}
+		mu.Lock()
+		stop := stopped
+		mu.Unlock()
+		if stop {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, tenantID uuid.UUID) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := fn(i, tenantID)
+			mu.Lock()
+			defer mu.Unlock()
+			errs[i], attempted[i] = err, true
+			if err != nil && opts.StopOnError {
+				stopped = true
+			}
+		}(i, tenantID)
+	}
+	wg.Wait()
+
+	failed := &MigrationFailedError{Total: len(tenantIDs)}
+	for i, err := range errs {
+		if attempted[i] {
+			failed.Attempted++
+		}
+		if err != nil {
+			failed.Failures = append(failed.Failures, tenant.TenantFailure{TenantID: tenantIDs[i], Err: err})
+		}
+	}
+	return failed
+}
+
+// migrationTargets lists the active tenants migration applies to. Without a
+// repository they are read from the tenants table, which scoped and templated
+// migrations cannot use.
+func (m *MigrationManager) migrationTargets(ctx context.Context, migration *tenant.Migration) ([]uuid.UUID, error) {
+	if m.repository == nil {
+		if isTemplatedMigration(migration) || migration.AppliesTo != nil {
+			return nil, errRepositoryRequired(migration)
+		}
+		return m.activeTenantIDs(ctx)
+	}
+
+	const perPage = 100
+	var tenantIDs []uuid.UUID
+	for page := 1; ; page++ {
+		tenants, total, err := m.repository.List(ctx, page, perPage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tenants: %w", err)
+		}
+
+		for _, t := range tenants {
+			if t.Status == tenant.StatusActive && migration.AppliesTo.Includes(t) {
+				tenantIDs = append(tenantIDs, t.ID)
+			}
+		}
+
+		if len(tenants) == 0 || page*perPage >= total {
+			return tenantIDs, nil
+		}
+	}
+}
+
+// activeTenantIDs reads the active tenants from the tenants table, as the
+// migration functions do
+func (m *MigrationManager) activeTenantIDs(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := m.db.QueryContext(ctx, `SELECT id FROM tenants WHERE status = $1 ORDER BY created_at, id`, tenant.StatusActive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	defer rows.Close()
+
+	var tenantIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan tenant: %w", err)
+		}
+		tenantIDs = append(tenantIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tenant rows: %w", err)
+	}
+	return tenantIDs, nil
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+// applyTestTenants returns active tenants named Tenant 0, Tenant 1, ...,
+// except those in failing, which are named Broken n
+func applyTestTenants(n int, failing ...int) []*tenant.Tenant {
+	tenants := make([]*tenant.Tenant, n)
+	for i := range tenants {
+		tenants[i] = &tenant.Tenant{ID: uuid.New(), Name: fmt.Sprintf("Tenant %d", i), Status: tenant.StatusActive}
+	}
+	for _, i := range failing {
+		tenants[i].Name = fmt.Sprintf("Broken %d", i)
+	}
+	return tenants
+}
+
+func TestMigrationManager_ApplyToAllTenants_AggregatesFailures(t *testing.T) {
+	db, recorder := newRecordingDB(t)
+	defer db.Close()
+	recorder.failOn = "Broken"
+
+	tenants := applyTestTenants(8, 2, 5)
+	suspended := &tenant.Tenant{ID: uuid.New(), Name: "Broken but suspended", Status: tenant.StatusSuspended}
+	repo := &templateTestRepository{tenants: append(tenants, suspended)}
+	mgr := NewMigrationManagerWithRepository(db, zaptest.NewLogger(t), "", repo).(*MigrationManager)
+
+	migration := &tenant.Migration{
+		Version:   "007",
+		Name:      "seed_settings",
+		SQL:       "INSERT INTO settings (display_name) VALUES ({{.Name}});",
+		Templated: true,
+	}
+
+	err := mgr.ApplyToAllTenantsWithOptions(context.Background(), migration, ApplyOptions{Concurrency: 3})
+	var failed *MigrationFailedError
+	if !errors.As(err, &failed) {
+		t.Fatalf("ApplyToAllTenantsWithOptions() error = %v, want a MigrationFailedError", err)
+	}
+	if want := []uuid.UUID{tenants[2].ID, tenants[5].ID}; !reflect.DeepEqual(failed.TenantIDs(), want) {
+		t.Errorf("failed tenants = %v, want %v", failed.TenantIDs(), want)
+	}
+	if failed.Attempted != 8 || failed.Total != 8 {
+		t.Errorf("attempted %d of %d tenants, want 8 of 8", failed.Attempted, failed.Total)
+	}
+
+	// The failures did not stop the other tenants
+	applied := recorder.appliedSQL()
+	if len(applied) != 6 {
+		t.Errorf("migrated %d tenants, want 6", len(applied))
+	}
+	for i, ten := range tenants {
+		_, ok := applied[ten.ID.String()]
+		if want := i != 2 && i != 5; ok != want {
+			t.Errorf("tenant %d migrated = %v, want %v", i, ok, want)
+		}
+	}
+}
+
+func TestMigrationManager_ApplyToAllTenants_StopOnError(t *testing.T) {
+	db, recorder := newRecordingDB(t)
+	defer db.Close()
+	recorder.failOn = "Broken"
+
+	tenants := applyTestTenants(5, 1, 3)
+	mgr := NewMigrationManagerWithRepository(db, zaptest.NewLogger(t), "", &templateTestRepository{tenants: tenants}).(*MigrationManager)
+
+	migration := &tenant.Migration{
+		Version:   "007",
+		Name:      "seed_settings",
+		SQL:       "INSERT INTO settings (display_name) VALUES ({{.Name}});",
+		Templated: true,
+	}
+
+	// One at a time, so nothing starts after the first failure
+	err := mgr.ApplyToAllTenantsWithOptions(context.Background(), migration, ApplyOptions{Concurrency: 1, StopOnError: true})
+	var failed *MigrationFailedError
+	if !errors.As(err, &failed) {
+		t.Fatalf("ApplyToAllTenantsWithOptions() error = %v, want a MigrationFailedError", err)
+	}
+	if want := []uuid.UUID{tenants[1].ID}; !reflect.DeepEqual(failed.TenantIDs(), want) {
+		t.Errorf("failed tenants = %v, want %v", failed.TenantIDs(), want)
+	}
+	if failed.Attempted != 2 || failed.Total != 5 {
+		t.Errorf("attempted %d of %d tenants, want 2 of 5", failed.Attempted, failed.Total)
+	}
+	if applied := recorder.appliedSQL(); len(applied) != 1 || applied[tenants[0].ID.String()] == "" {
+		t.Errorf("migrated %v, want only the tenant before the failure", applied)
+	}
+}
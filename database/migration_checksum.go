@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+)
+
+// CodeMigrationChecksumMismatch is the TenantError code returned when an
+// applied migration's file has changed since it was applied
+const CodeMigrationChecksumMismatch = "MIGRATION_CHECKSUM_MISMATCH"
+
+// MigrationDrift is an applied migration whose SQL no longer matches what was
+// applied to the tenant
+type MigrationDrift struct {
+	Version         string `json:"version"`
+	Name            string `json:"name"`
+	AppliedChecksum string `json:"applied_checksum"` // recorded in tenant_migrations
+	FileChecksum    string `json:"file_checksum"`    // of the migration file now
+}
+
+// VerifyMigrations compares the migrations in the migrations directory with
+// those recorded for the tenant and reports the applied ones whose SQL has
+// changed since. Migrations not applied yet, and applied ones recorded without
+// a checksum, are not reported.
+func (m *MigrationManager) VerifyMigrations(ctx context.Context, tenantID uuid.UUID) ([]MigrationDrift, error) {
+	migrations, err := m.DiscoverMigrations(DiscoverOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := m.appliedChecksums(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var drifts []MigrationDrift
+	for _, migration := range migrations {
+		checksum, ok := applied[migration.Version]
+		if !ok {
+			continue
+		}
+		if drift := checksumDrift(migration, checksum); drift != nil {
+			drifts = append(drifts, *drift)
+		}
+	}
+
+	return drifts, nil
+}
+
+// appliedChecksums returns the checksums recorded for the tenant's applied
+// migrations by version, "" where none was recorded
+func (m *MigrationManager) appliedChecksums(ctx context.Context, tenantID uuid.UUID) (map[string]string, error) {
+	applied, err := m.GetAppliedMigrations(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	checksums := make(map[string]string, len(applied))
+	for _, migration := range applied {
+		checksums[migration.Version] = ""
+		if migration.Checksum != nil {
+			checksums[migration.Version] = *migration.Checksum
+		}
+	}
+	return checksums, nil
+}
+
+// verifyChecksum returns a CodeMigrationChecksumMismatch error if migration,
+// already applied to the tenant with appliedChecksum, has changed since
+func (m *MigrationManager) verifyChecksum(tenantID uuid.UUID, migration *tenant.Migration, appliedChecksum string) error {
+	drift := checksumDrift(migration, appliedChecksum)
+	if drift == nil {
+		return nil
+	}
+	return &tenant.TenantError{
+		TenantID: tenantID,
+		Code:     CodeMigrationChecksumMismatch,
+		Message: fmt.Sprintf("migration %s_%s has changed since it was applied to tenant %s (checksum %s, applied %s)",
+			drift.Version, drift.Name, tenantID, drift.FileChecksum, drift.AppliedChecksum),
+	}
+}
+
+// checksumDrift compares the checksum of migration's file with
+// appliedChecksum, as recordedChecksum recorded it. Migrations without a
+// checksum, such as those built in code, and applied ones recorded without a
+// checksum are not compared.
+func checksumDrift(migration *tenant.Migration, appliedChecksum string) *MigrationDrift {
+	if migration.Checksum == nil || appliedChecksum == "" || *migration.Checksum == appliedChecksum {
+		return nil
+	}
+	return &MigrationDrift{
+		Version:         migration.Version,
+		Name:            migration.Name,
+		AppliedChecksum: appliedChecksum,
+		FileChecksum:    *migration.Checksum,
+	}
+}
+
+// recordedChecksum returns the checksum recorded for migration when it is
+// applied as migrationSQL. Templated migrations are recorded with the checksum
+// of their file rather than of the SQL rendered for the tenant, so that tenant
+// fields changing later are not mistaken for an edited file.
+func recordedChecksum(migration *tenant.Migration, migrationSQL string) string {
+	if isTemplatedMigration(migration) && migration.Checksum != nil {
+		return *migration.Checksum
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(migrationSQL)))
+}
+
+// applyTemplated applies a templated migration rendered as migrationSQL with
+// apply_tenant_migration, which records the checksum of the rendered SQL, and
+// replaces that with recordedChecksum in the same transaction
+func (m *MigrationManager) applyTemplated(ctx context.Context, tenantID uuid.UUID, migration *tenant.Migration, migrationSQL string, rollbackSQL sql.NullString) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT apply_tenant_migration($1, $2, $3, $4, $5)`,
+		tenantID, migration.Version, migration.Name, migrationSQL, rollbackSQL,
+	); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE public.tenant_migrations SET checksum = $3 WHERE tenant_id = $1 AND version = $2`,
+		tenantID, migration.Version, recordedChecksum(migration, migrationSQL),
+	); err != nil {
+		return fmt.Errorf("failed to record migration checksum: %w", err)
+	}
+
+	return tx.Commit()
+}
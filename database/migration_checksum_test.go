@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMigrationManager_VerifyMigrations_DetectsEditedFile(t *testing.T) {
+	dir := writeMigrationFiles(t,
+		"001_create_projects.up.sql",
+		"002_add_due_dates.up.sql",
+	)
+	db, _ := newRecordingDB(t)
+	defer db.Close()
+	mgr := NewMigrationManager(db, zaptest.NewLogger(t), dir).(*MigrationManager)
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	if err := mgr.MigrateTenant(ctx, tenantID); err != nil {
+		t.Fatalf("MigrateTenant() error = %v", err)
+	}
+	drifts, err := mgr.VerifyMigrations(ctx, tenantID)
+	if err != nil || len(drifts) != 0 {
+		t.Fatalf("VerifyMigrations() = %v, %v; want no drift right after applying", drifts, err)
+	}
+
+	// Edit an applied migration
+	edited := filepath.Join(dir, "002_add_due_dates.up.sql")
+	if err := os.WriteFile(edited, []byte("ALTER TABLE tasks ADD COLUMN due_on DATE;"), 0644); err != nil {
+		t.Fatalf("Failed to edit %s: %v", edited, err)
+	}
+
+	drifts, err = mgr.VerifyMigrations(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("VerifyMigrations() error = %v", err)
+	}
+	if len(drifts) != 1 {
+		t.Fatalf("VerifyMigrations() = %v, want the edited migration reported", drifts)
+	}
+	if drifts[0].Version != "002" || drifts[0].Name != "add_due_dates" {
+		t.Errorf("drift = %+v, want 002_add_due_dates", drifts[0])
+	}
+	if drifts[0].AppliedChecksum == drifts[0].FileChecksum {
+		t.Errorf("drift checksums are both %s, want them to differ", drifts[0].FileChecksum)
+	}
+
+	// Applying refuses the edited migration instead of skipping it
+	var tenantErr *tenant.TenantError
+	if err := mgr.MigrateTenant(ctx, tenantID); !errors.As(err, &tenantErr) || tenantErr.Code != CodeMigrationChecksumMismatch {
+		t.Errorf("MigrateTenant() error = %v, want %s", err, CodeMigrationChecksumMismatch)
+	}
+	if err := mgr.ApplyMigrationFromFile(ctx, tenantID, "002", "add_due_dates"); !errors.As(err, &tenantErr) || tenantErr.Code != CodeMigrationChecksumMismatch {
+		t.Errorf("ApplyMigrationFromFile() error = %v, want %s", err, CodeMigrationChecksumMismatch)
+	}
+
+	// Unedited migrations still apply as no-ops
+	if err := mgr.ApplyMigrationFromFile(ctx, tenantID, "001", "create_projects"); err != nil {
+		t.Errorf("ApplyMigrationFromFile() of an unchanged migration error = %v", err)
+	}
+}
+
+func TestMigrationManager_VerifyMigrations_SkipsUnrecordedChecksums(t *testing.T) {
+	dir := writeMigrationFiles(t, "001_create_projects.up.sql")
+	db, recorder := newRecordingDB(t)
+	defer db.Close()
+	mgr := NewMigrationManager(db, zaptest.NewLogger(t), dir).(*MigrationManager)
+	tenantID := uuid.New()
+
+	// Recorded without a checksum, so there is nothing to compare
+	recorder.record(tenantID, "001")
+	drifts, err := mgr.VerifyMigrations(context.Background(), tenantID)
+	if err != nil || len(drifts) != 0 {
+		t.Errorf("VerifyMigrations() = %v, %v; want no drift", drifts, err)
+	}
+}
+
+func TestMigrationManager_VerifyMigrations_Templated(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "001_seed_settings.up.sql")
+	if err := os.WriteFile(file, []byte("-- templated\nINSERT INTO settings (name) VALUES ({{.Name}});"), 0644); err != nil {
+		t.Fatalf("Failed to create %s: %v", file, err)
+	}
+	db, _ := newRecordingDB(t)
+	defer db.Close()
+	acme := &tenant.Tenant{ID: uuid.New(), Name: "Acme Corp", Status: tenant.StatusActive}
+	mgr := NewMigrationManagerWithRepository(db, zaptest.NewLogger(t), dir, &templateTestRepository{tenants: []*tenant.Tenant{acme}}).(*MigrationManager)
+	ctx := context.Background()
+
+	if err := mgr.MigrateTenant(ctx, acme.ID); err != nil {
+		t.Fatalf("MigrateTenant() error = %v", err)
+	}
+
+	// Renaming the tenant changes the rendered SQL, not the migration file
+	acme.Name = "Acme Inc"
+	drifts, err := mgr.VerifyMigrations(ctx, acme.ID)
+	if err != nil || len(drifts) != 0 {
+		t.Fatalf("VerifyMigrations() = %v, %v; want no drift after a tenant field changed", drifts, err)
+	}
+	if err := mgr.MigrateTenant(ctx, acme.ID); err != nil {
+		t.Errorf("MigrateTenant() after a tenant field changed error = %v", err)
+	}
+
+	// Editing the template is still reported
+	if err := os.WriteFile(file, []byte("-- templated\nINSERT INTO settings (name, plan) VALUES ({{.Name}}, {{.PlanType}});"), 0644); err != nil {
+		t.Fatalf("Failed to edit %s: %v", file, err)
+	}
+	drifts, err = mgr.VerifyMigrations(ctx, acme.ID)
+	if err != nil || len(drifts) != 1 {
+		t.Errorf("VerifyMigrations() = %v, %v; want the edited template reported", drifts, err)
+	}
+}
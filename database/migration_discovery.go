@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Errors returned, wrapped, by DiscoverMigrations
+var (
+	ErrMalformedMigrationVersion = errors.New("migration file name does not start with a numeric version")
+	ErrDuplicateMigrationVersion = errors.New("duplicate migration version")
+	ErrMigrationVersionGap       = errors.New("gap in migration versions")
+)
+
+// DiscoverOptions controls how DiscoverMigrations validates migration versions
+type DiscoverOptions struct {
+	RejectGaps bool // Fail on missing versions, e.g. 1, 2, 4; otherwise gaps are only logged
+}
+
+// discoveredMigration is a migration file name split into its version and name
+type discoveredMigration struct {
+	number  uint64
+	version string // as written in the file name, e.g. "001"
+	name    string
+}
+
+// DiscoverMigrations loads the migrations in the migrations directory, ordered
+// by the numeric version prefix of their file names so that 10 comes after 2.
+// Files must be named <version>_<name>.up.sql. A file name without a numeric
+// version, or two files with the same version (including 1 and 001), is an
+// error.
+func (m *MigrationManager) DiscoverMigrations(opts DiscoverOptions) ([]*tenant.Migration, error) {
+	files, err := m.ListMigrationFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	discovered, err := parseMigrationVersions(files)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 1; i < len(discovered); i++ {
+		prev, next := discovered[i-1], discovered[i]
+		if next.number == prev.number+1 {
+			continue
+		}
+		if opts.RejectGaps {
+			return nil, fmt.Errorf("%w: %s is followed by %s", ErrMigrationVersionGap, prev.version, next.version)
+		}
+		m.logger.Warn("Gap in migration versions",
+			zap.String("after", prev.version),
+			zap.String("before", next.version))
+	}
+
+	migrations := make([]*tenant.Migration, 0, len(discovered))
+	for _, d := range discovered {
+		migration, err := m.LoadMigrationFromFile(d.version, d.name)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration)
+	}
+
+	return migrations, nil
+}
+
+// MigrateTenant brings the tenant up to date with the migrations directory,
+// applying the discovered migrations not yet recorded for it in version order.
+// It stops at the first failure; the migrations applied before it stay
+// recorded, so running it again resumes from the failed one. A tenant that is
+// up to date is left untouched. An applied migration whose file has changed
+// since stops it with a CodeMigrationChecksumMismatch error.
+func (m *MigrationManager) MigrateTenant(ctx context.Context, tenantID uuid.UUID) error {
+	migrations, err := m.DiscoverMigrations(DiscoverOptions{})
+	if err != nil {
+		return err
+	}
+
+	applied, err := m.appliedChecksums(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for _, migration := range migrations {
+		if checksum, done := applied[migration.Version]; done {
+			if err := m.verifyChecksum(tenantID, migration, checksum); err != nil {
+				return err
+			}
+			continue
+		}
+		migration.TenantID = tenantID
+		if err := m.ApplyMigration(ctx, tenantID, migration); err != nil {
+			return fmt.Errorf("migration %s_%s: %w", migration.Version, migration.Name, err)
+		}
+		count++
+	}
+
+	m.logger.Info("Tenant migrated",
+		zap.String("tenant_id", tenantID.String()),
+		zap.Int("pending", count))
+
+	return nil
+}
+
+// parseMigrationVersions splits <version>_<name> base names and sorts them by
+// numeric version, rejecting malformed and duplicate versions
+func parseMigrationVersions(files []string) ([]discoveredMigration, error) {
+	discovered := make([]discoveredMigration, 0, len(files))
+	byNumber := make(map[uint64]string, len(files))
+
+	for _, file := range files {
+		version, name, ok := strings.Cut(file, "_")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%w: %s", ErrMalformedMigrationVersion, file)
+		}
+		number, err := strconv.ParseUint(version, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrMalformedMigrationVersion, file)
+		}
+		if other, exists := byNumber[number]; exists {
+			return nil, fmt.Errorf("%w %d: %s and %s", ErrDuplicateMigrationVersion, number, other, file)
+		}
+		byNumber[number] = file
+
+		discovered = append(discovered, discoveredMigration{number: number, version: version, name: name})
+	}
+
+	sort.Slice(discovered, func(i, j int) bool {
+		return discovered[i].number < discovered[j].number
+	})
+	return discovered, nil
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+// writeMigrationFiles creates empty migration files in a temporary directory
+func writeMigrationFiles(t *testing.T, files ...string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for _, file := range files {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte("-- "+file), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", file, err)
+		}
+	}
+	return dir
+}
+
+func TestMigrationManager_DiscoverMigrations(t *testing.T) {
+	dir := writeMigrationFiles(t,
+		"10_add_indexes.up.sql",
+		"1_create_users.up.sql",
+		"1_create_users.down.sql",
+		"2_create_projects.up.sql",
+		"README.txt",
+	)
+	mgr := NewMigrationManager(nil, zaptest.NewLogger(t), dir).(*MigrationManager)
+
+	// 1, 2, 10 has a gap, which is logged unless rejected
+	migrations, err := mgr.DiscoverMigrations(DiscoverOptions{})
+	if err != nil {
+		t.Fatalf("DiscoverMigrations() error = %v", err)
+	}
+
+	var versions []string
+	for _, migration := range migrations {
+		versions = append(versions, migration.Version)
+	}
+	if len(versions) != 3 || versions[0] != "1" || versions[1] != "2" || versions[2] != "10" {
+		t.Errorf("versions = %v, want [1 2 10]", versions)
+	}
+	if migrations[0].Name != "create_users" || migrations[0].RollbackSQL == nil {
+		t.Errorf("first migration = %+v, want create_users with its rollback", migrations[0])
+	}
+
+	if _, err := mgr.DiscoverMigrations(DiscoverOptions{RejectGaps: true}); !errors.Is(err, ErrMigrationVersionGap) {
+		t.Errorf("DiscoverMigrations(RejectGaps) error = %v, want ErrMigrationVersionGap", err)
+	}
+}
+
+func TestMigrationManager_DiscoverMigrations_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  error
+	}{
+		{"duplicate", []string{"1_create_users.up.sql", "2_a.up.sql", "2_b.up.sql"}, ErrDuplicateMigrationVersion},
+		{"zero-padded duplicate", []string{"1_create_users.up.sql", "001_create_projects.up.sql"}, ErrDuplicateMigrationVersion},
+		{"no version", []string{"create_users.up.sql"}, ErrMalformedMigrationVersion},
+		{"no name", []string{"3.up.sql"}, ErrMalformedMigrationVersion},
+		{"negative version", []string{"-1_create_users.up.sql"}, ErrMalformedMigrationVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeMigrationFiles(t, tt.files...)
+			mgr := NewMigrationManager(nil, zaptest.NewLogger(t), dir).(*MigrationManager)
+
+			if _, err := mgr.DiscoverMigrations(DiscoverOptions{}); !errors.Is(err, tt.want) {
+				t.Errorf("DiscoverMigrations() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrationManager_MigrateTenant(t *testing.T) {
+	dir := writeMigrationFiles(t,
+		"001_create_projects.up.sql",
+		"002_add_due_dates.up.sql",
+		"003_create_invoices.up.sql",
+	)
+	db, recorder := newRecordingDB(t)
+	defer db.Close()
+	mgr := NewMigrationManager(db, zaptest.NewLogger(t), dir).(*MigrationManager)
+	ctx := context.Background()
+
+	// The first two are already applied, so only the third runs
+	tenantID := uuid.New()
+	recorder.record(tenantID, "001", "002")
+	if err := mgr.MigrateTenant(ctx, tenantID); err != nil {
+		t.Fatalf("MigrateTenant() error = %v", err)
+	}
+	if got := recorder.recorded(tenantID); !reflect.DeepEqual(got, []string{"001", "002", "003"}) {
+		t.Errorf("recorded %v, want 003 added after 001 and 002", got)
+	}
+	if sql := recorder.appliedSQL()[tenantID.String()]; sql != "-- 003_create_invoices.up.sql" {
+		t.Errorf("ran %q, want only the third migration", sql)
+	}
+
+	// Running again is a no-op
+	if err := mgr.MigrateTenant(ctx, tenantID); err != nil {
+		t.Fatalf("second MigrateTenant() error = %v", err)
+	}
+	if got := recorder.recorded(tenantID); len(got) != 3 {
+		t.Errorf("recorded %v after a second run, want no new migrations", got)
+	}
+}
+
+func TestMigrationManager_MigrateTenant_StopsAtFailure(t *testing.T) {
+	dir := writeMigrationFiles(t,
+		"001_create_projects.up.sql",
+		"002_add_due_dates.up.sql",
+		"003_create_invoices.up.sql",
+	)
+	db, recorder := newRecordingDB(t)
+	defer db.Close()
+	mgr := NewMigrationManager(db, zaptest.NewLogger(t), dir).(*MigrationManager)
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	recorder.failOn = "002_add_due_dates"
+	err := mgr.MigrateTenant(ctx, tenantID)
+	if err == nil || !strings.Contains(err.Error(), "002_add_due_dates") {
+		t.Fatalf("MigrateTenant() error = %v, want the failed migration named", err)
+	}
+	if got := recorder.recorded(tenantID); !reflect.DeepEqual(got, []string{"001"}) {
+		t.Errorf("recorded %v, want only 001 before the failure", got)
+	}
+
+	// Once fixed, the next run resumes at the failed migration
+	recorder.failOn = ""
+	if err := mgr.MigrateTenant(ctx, tenantID); err != nil {
+		t.Fatalf("MigrateTenant() after the fix error = %v", err)
+	}
+	if got := recorder.recorded(tenantID); !reflect.DeepEqual(got, []string{"001", "002", "003"}) {
+		t.Errorf("recorded %v, want 002 and 003 added", got)
+	}
+}
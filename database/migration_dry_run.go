@@ -0,0 +1,141 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// DryRunResult is what applying a migration would do to one tenant
+type DryRunResult struct {
+	TenantID     uuid.UUID `json:"tenant_id"`
+	Version      string    `json:"version"`
+	Skipped      bool      `json:"skipped"`       // already applied or outside the migration's scope, so it would not run
+	Destructive  bool      `json:"destructive"`   // the SQL drops or deletes data, as tenant.IsDestructiveSQL reports
+	RowsAffected int64     `json:"rows_affected"` // as reported for the SQL's last statement
+	Err          error     `json:"-"`             // why applying the migration would fail
+}
+
+// DryRunMigration runs migration in the tenant's schema inside a transaction
+// that is always rolled back, to find out whether applying it would work and
+// how many rows it touches, without changing the tenant or recording the
+// migration. Migrations ApplyMigration would skip are reported as Skipped and
+// not run. SQL that cannot run in a transaction, such as CREATE INDEX
+// CONCURRENTLY, fails the dry run.
+func (m *MigrationManager) DryRunMigration(ctx context.Context, tenantID uuid.UUID, migration *tenant.Migration) *DryRunResult {
+	result := &DryRunResult{
+		TenantID:    tenantID,
+		Version:     migration.Version,
+		Destructive: tenant.IsDestructiveSQL(migration.SQL),
+	}
+	result.Skipped, result.RowsAffected, result.Err = m.dryRun(ctx, tenantID, migration)
+
+	m.logger.Info("Dry-ran migration for tenant",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("migration_version", migration.Version),
+		zap.Bool("skipped", result.Skipped),
+		zap.Bool("destructive", result.Destructive),
+		zap.Int64("rows_affected", result.RowsAffected),
+		zap.Error(result.Err))
+
+	return result
+}
+
+// dryRun implements DryRunMigration, checking what ApplyMigration checks first
+func (m *MigrationManager) dryRun(ctx context.Context, tenantID uuid.UUID, migration *tenant.Migration) (bool, int64, error) {
+	if !m.validateTenantSchema(ctx, tenantID) {
+		return false, 0, fmt.Errorf("tenant schema does not exist for tenant %s", tenantID.String())
+	}
+
+	if migration.AppliesTo != nil {
+		t, err := m.tenantFor(ctx, tenantID, migration)
+		if err != nil {
+			return false, 0, err
+		}
+		if !migration.AppliesTo.Includes(t) {
+			return true, 0, nil
+		}
+	}
+
+	applied, err := m.IsMigrationApplied(ctx, tenantID, migration.Version)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to check if migration is applied: %w", err)
+	}
+	if applied {
+		if migration.Checksum != nil {
+			checksums, err := m.appliedChecksums(ctx, tenantID)
+			if err != nil {
+				return false, 0, err
+			}
+			if err := m.verifyChecksum(tenantID, migration, checksums[migration.Version]); err != nil {
+				return false, 0, err
+			}
+		}
+		return true, 0, nil
+	}
+
+	migrationSQL := migration.SQL
+	if isTemplatedMigration(migration) {
+		migrationSQL, err = m.renderForTenant(ctx, tenantID, migration)
+		if err != nil {
+			return false, 0, err
+		}
+	}
+
+	db, err := m.schemaDB(ctx, tenantID)
+	if err != nil {
+		return false, 0, err
+	}
+	tx, err := m.beginInSchema(ctx, db, tenantID)
+	if err != nil {
+		return false, 0, err
+	}
+	defer tx.Rollback() // always; nothing is committed
+
+	res, err := tx.ExecContext(ctx, migrationSQL)
+	if err != nil {
+		return false, 0, fmt.Errorf("migration failed: %w", err)
+	}
+	// DDL has no meaningful row count
+	rows, _ := res.RowsAffected()
+
+	if err := tx.Rollback(); err != nil {
+		return false, rows, fmt.Errorf("failed to roll back dry run: %w", err)
+	}
+	return false, rows, nil
+}
+
+// DryRunAllTenants dry-runs migration, with DryRunMigration, for every tenant
+// ApplyToAllTenantsWithOptions would apply it to, and returns a result per
+// tenant. The tenants whose dry run failed are also returned together as a
+// *MigrationFailedError. With opts.StopOnError the results stop after the
+// first failure.
+func (m *MigrationManager) DryRunAllTenants(ctx context.Context, migration *tenant.Migration, opts ApplyOptions) ([]*DryRunResult, error) {
+	tenantIDs, err := m.migrationTargets(ctx, migration)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*DryRunResult, len(tenantIDs))
+	failed := forEachTenant(tenantIDs, opts, func(i int, tenantID uuid.UUID) error {
+		results[i] = m.DryRunMigration(ctx, tenantID, migration)
+		return results[i].Err
+	})
+	failed.Version = migration.Version
+
+	// Tenants not started after StopOnError have no result
+	ran := results[:0]
+	for _, result := range results {
+		if result != nil {
+			ran = append(ran, result)
+		}
+	}
+
+	if len(failed.Failures) > 0 {
+		return ran, failed
+	}
+	return ran, nil
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMigrationManager_DryRunMigration(t *testing.T) {
+	db, recorder := newRecordingDB(t)
+	defer db.Close()
+	mgr := NewMigrationManager(db, zaptest.NewLogger(t), "").(*MigrationManager)
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	migration := &tenant.Migration{Version: "008", Name: "archive_projects", SQL: "UPDATE projects SET status = 'archived'"}
+	result := mgr.DryRunMigration(ctx, tenantID, migration)
+	if result.Err != nil || result.Skipped {
+		t.Fatalf("DryRunMigration() = %+v, want a successful run", result)
+	}
+	if result.RowsAffected != 3 {
+		t.Errorf("RowsAffected = %d, want 3", result.RowsAffected)
+	}
+	if result.Destructive {
+		t.Error("an UPDATE should not be reported as destructive")
+	}
+	wantSearchPath := fmt.Sprintf(`SET LOCAL search_path TO "tenant_%s", public`, tenantID)
+	if len(recorder.executed) != 2 || recorder.executed[0] != wantSearchPath || recorder.executed[1] != migration.SQL {
+		t.Errorf("executed %q, want the migration in the tenant's schema", recorder.executed)
+	}
+	if recorder.commits != 0 || recorder.rollbacks == 0 {
+		t.Errorf("%d commits and %d rollbacks, want the dry run rolled back", recorder.commits, recorder.rollbacks)
+	}
+	if len(recorder.recorded(tenantID)) != 0 {
+		t.Error("a dry run should not record the migration")
+	}
+
+	// Invalid SQL is reported
+	recorder.failOn = "SELEC "
+	invalid := &tenant.Migration{Version: "009", Name: "broken", SQL: "SELEC * FROM projects"}
+	if result := mgr.DryRunMigration(ctx, tenantID, invalid); result.Err == nil {
+		t.Error("DryRunMigration() should report the SQL error")
+	}
+
+	// Destructive SQL is flagged
+	drop := &tenant.Migration{Version: "010", Name: "drop_documents", SQL: "DROP TABLE documents"}
+	if result := mgr.DryRunMigration(ctx, tenantID, drop); !result.Destructive {
+		t.Error("DROP TABLE should be reported as destructive")
+	}
+
+	// Applied migrations would be skipped, so they are not run
+	recorder.record(tenantID, "008")
+	executed := len(recorder.executed)
+	if result := mgr.DryRunMigration(ctx, tenantID, migration); !result.Skipped || result.Err != nil {
+		t.Errorf("DryRunMigration() of an applied migration = %+v, want it skipped", result)
+	}
+	if len(recorder.executed) != executed {
+		t.Error("an applied migration should not be run again")
+	}
+}
+
+func TestMigrationManager_DryRunAllTenants(t *testing.T) {
+	db, recorder := newRecordingDB(t)
+	defer db.Close()
+	recorder.failOn = "Broken"
+
+	tenants := applyTestTenants(4, 2)
+	mgr := NewMigrationManagerWithRepository(db, zaptest.NewLogger(t), "", &templateTestRepository{tenants: tenants}).(*MigrationManager)
+
+	migration := &tenant.Migration{
+		Version:   "011",
+		Name:      "rename_projects",
+		SQL:       "UPDATE projects SET name = {{.Name}}",
+		Templated: true,
+	}
+	results, err := mgr.DryRunAllTenants(context.Background(), migration, ApplyOptions{})
+
+	var failed *MigrationFailedError
+	if !errors.As(err, &failed) || len(failed.Failures) != 1 || failed.Failures[0].TenantID != tenants[2].ID {
+		t.Fatalf("DryRunAllTenants() error = %v, want the failing tenant reported", err)
+	}
+	if len(results) != len(tenants) {
+		t.Fatalf("got %d results, want one per tenant", len(results))
+	}
+	for i, result := range results {
+		if result.TenantID != tenants[i].ID {
+			t.Errorf("result %d is for %s, want %s", i, result.TenantID, tenants[i].ID)
+		}
+		if (result.Err != nil) != (i == 2) {
+			t.Errorf("result %d error = %v", i, result.Err)
+		}
+	}
+	if len(recorder.appliedSQL()) != 0 || recorder.commits != 0 {
+		t.Error("a dry run should not apply or commit anything")
+	}
+}
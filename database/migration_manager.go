@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/alexalmadav/go-multitenant/tenant"
@@ -20,14 +21,25 @@ type MigrationManager struct {
 	db            *sql.DB
 	logger        *zap.Logger
 	migrationsDir string
+	repository    tenant.Repository // used to resolve templated migrations, may be nil
+
+	tenantDBMu sync.RWMutex
+	tenantDB   func(ctx context.Context, tenantID uuid.UUID) (*sql.DB, error) // schema databases, nil until SetTenantDB
 }
 
 // NewMigrationManager creates a new migration manager
 func NewMigrationManager(db *sql.DB, logger *zap.Logger, migrationsDir string) tenant.MigrationManager {
+	return NewMigrationManagerWithRepository(db, logger, migrationsDir, nil)
+}
+
+// NewMigrationManagerWithRepository creates a migration manager that can expand
+// templated migration SQL (see MigrationTemplateData) using tenant records
+func NewMigrationManagerWithRepository(db *sql.DB, logger *zap.Logger, migrationsDir string, repository tenant.Repository) tenant.MigrationManager {
 	return &MigrationManager{
 		db:            db,
 		logger:        logger.Named("migration_manager"),
 		migrationsDir: migrationsDir,
+		repository:    repository,
 	}
 }
 
@@ -43,20 +55,50 @@ func (m *MigrationManager) ApplyMigration(ctx context.Context, tenantID uuid.UUI
 		return fmt.Errorf("tenant schema does not exist for tenant %s", tenantID.String())
 	}
 
+	// Skip migrations scoped to other tenants
+	if migration.AppliesTo != nil {
+		t, err := m.tenantFor(ctx, tenantID, migration)
+		if err != nil {
+			return err
+		}
+		if !migration.AppliesTo.Includes(t) {
+			m.logger.Info("Migration does not apply to tenant, skipping",
+				zap.String("tenant_id", tenantID.String()),
+				zap.String("migration_version", migration.Version))
+			return nil
+		}
+	}
+
 	// Check if migration is already applied
 	applied, err := m.IsMigrationApplied(ctx, tenantID, migration.Version)
 	if err != nil {
 		return fmt.Errorf("failed to check if migration is applied: %w", err)
 	}
 	if applied {
+		// An edited migration file is reported rather than silently skipped
+		if migration.Checksum != nil {
+			checksums, err := m.appliedChecksums(ctx, tenantID)
+			if err != nil {
+				return err
+			}
+			if err := m.verifyChecksum(tenantID, migration, checksums[migration.Version]); err != nil {
+				return err
+			}
+		}
 		m.logger.Info("Migration already applied, skipping",
 			zap.String("tenant_id", tenantID.String()),
 			zap.String("migration_version", migration.Version))
 		return nil
 	}
 
-	// Use the PostgreSQL function apply_tenant_migration
-	query := `SELECT apply_tenant_migration($1, $2, $3, $4, $5)`
+	// Expand per-tenant placeholders
+	migrationSQL := migration.SQL
+	if isTemplatedMigration(migration) {
+		migrationSQL, err = m.renderForTenant(ctx, tenantID, migration)
+		if err != nil {
+			return err
+		}
+	}
 
 	var rollbackSQL sql.NullString
 	if migration.RollbackSQL != nil {
@@ -64,13 +106,27 @@ func (m *MigrationManager) ApplyMigration(ctx context.Context, tenantID uuid.UUI
 		rollbackSQL.Valid = true
 	}
 
-	_, err = m.db.ExecContext(ctx, query,
-		tenantID,
-		migration.Version,
-		migration.Name,
-		migration.SQL,
-		rollbackSQL,
-	)
+	db, err := m.schemaDB(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case db != m.db:
+		err = m.applyInDB(ctx, db, tenantID, migration, migrationSQL, rollbackSQL)
+	case isTemplatedMigration(migration) && migration.Checksum != nil:
+		err = m.applyTemplated(ctx, tenantID, migration, migrationSQL, rollbackSQL)
+	default:
+		// Use the PostgreSQL function apply_tenant_migration
+		query := `SELECT apply_tenant_migration($1, $2, $3, $4, $5)`
+
+		_, err = m.db.ExecContext(ctx, query,
+			tenantID,
+			migration.Version,
+			migration.Name,
+			migrationSQL,
+			rollbackSQL,
+		)
+	}
 
 	if err != nil {
 		m.logger.Error("Migration failed",
@@ -87,39 +143,42 @@ func (m *MigrationManager) ApplyMigration(ctx context.Context, tenantID uuid.UUI
 	return nil
 }
 
-// ApplyToAllTenants applies migration to all active tenants using PostgreSQL function
+// ApplyToAllTenants applies migration to all active tenants it applies to,
+// DefaultMigrationConcurrency at a time, like ApplyToAllTenantsWithOptions
+// with no options
 func (m *MigrationManager) ApplyToAllTenants(ctx context.Context, migration *tenant.Migration) error {
-	m.logger.Info("Applying migration to all tenants",
-		zap.String("migration_version", migration.Version),
-		zap.String("migration_name", migration.Name))
-
-	// Use the PostgreSQL function apply_migration_to_all_tenants
-	query := `SELECT apply_migration_to_all_tenants($1, $2, $3, $4)`
+	return m.ApplyToAllTenantsWithOptions(ctx, migration, ApplyOptions{})
+}
 
-	var rollbackSQL sql.NullString
-	if migration.RollbackSQL != nil {
-		rollbackSQL.String = *migration.RollbackSQL
-		rollbackSQL.Valid = true
+// renderForTenant looks up the tenant and expands the migration SQL for it
+func (m *MigrationManager) renderForTenant(ctx context.Context, tenantID uuid.UUID, migration *tenant.Migration) (string, error) {
+	t, err := m.tenantFor(ctx, tenantID, migration)
+	if err != nil {
+		return "", err
 	}
 
-	_, err := m.db.ExecContext(ctx, query,
-		migration.Version,
-		migration.Name,
-		migration.SQL,
-		rollbackSQL,
-	)
+	return renderMigrationSQL(migration, t)
+}
 
-	if err != nil {
-		m.logger.Error("Bulk migration failed",
-			zap.String("migration_version", migration.Version),
-			zap.Error(err))
-		return fmt.Errorf("bulk migration failed: %w", err)
+// tenantFor looks up the tenant record a templated or scoped migration needs
+func (m *MigrationManager) tenantFor(ctx context.Context, tenantID uuid.UUID, migration *tenant.Migration) (*tenant.Tenant, error) {
+	if m.repository == nil {
+		return nil, errRepositoryRequired(migration)
 	}
 
-	m.logger.Info("Migration applied to all tenants successfully",
-		zap.String("migration_version", migration.Version))
+	t, err := m.repository.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant for migration: %w", err)
+	}
+	return t, nil
+}
 
-	return nil
+// errRepositoryRequired reports a migration that needs tenant records without a repository
+func errRepositoryRequired(migration *tenant.Migration) error {
+	if migration.AppliesTo != nil {
+		return fmt.Errorf("scoped migration %s requires a tenant repository", migration.Version)
+	}
+	return fmt.Errorf("templated migration %s requires a tenant repository", migration.Version)
 }
 
 // RollbackMigration rolls back a migration for a specific tenant
@@ -140,10 +199,16 @@ func (m *MigrationManager) RollbackMigration(ctx context.Context, tenantID uuid.
 		return nil
 	}
 
-	// Use the PostgreSQL function rollback_tenant_migration
-	query := `SELECT rollback_tenant_migration($1, $2)`
-
-	_, err = m.db.ExecContext(ctx, query, tenantID, version)
+	db, err := m.schemaDB(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if db != m.db {
+		err = m.rollbackInDB(ctx, db, tenantID, version)
+	} else {
+		// Use the PostgreSQL function rollback_tenant_migration
+		_, err = m.db.ExecContext(ctx, `SELECT rollback_tenant_migration($1, $2)`, tenantID, version)
+	}
 	if err != nil {
 		m.logger.Error("Rollback failed",
 			zap.String("tenant_id", tenantID.String()),
@@ -159,7 +224,8 @@ func (m *MigrationManager) RollbackMigration(ctx context.Context, tenantID uuid.
 	return nil
 }
 
-// GetAppliedMigrations returns all applied migrations for a tenant
+// GetAppliedMigrations returns all applied migrations for a tenant, as
+// recorded in the manager's database for regional tenants too
 func (m *MigrationManager) GetAppliedMigrations(ctx context.Context, tenantID uuid.UUID) ([]*tenant.Migration, error) {
 	query := `
 		SELECT migration_id, migration_version, migration_name, applied_at, checksum
@@ -241,6 +307,14 @@ func (m *MigrationManager) LoadMigrationFromFile(version, name string) (*tenant.
 	checksum := fmt.Sprintf("%x", sha256.Sum256(upSQL))
 	migration.Checksum = &checksum
 
+	// Read the tenants the migration applies to from its header
+	scope, err := parseMigrationScope(migration.SQL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid applies-to header in %s: %w", upFile, err)
+	}
+	migration.AppliesTo = scope
+	migration.Templated = hasMigrationHeader(migration.SQL, migrationTemplatedHeader)
+
 	// Read down migration if it exists
 	if downSQL, err := os.ReadFile(downFile); err == nil {
 		rollbackSQL := string(downSQL)
@@ -308,12 +382,16 @@ func (m *MigrationManager) ListMigrationFiles() ([]string, error) {
 	return migrations, nil
 }
 
-// validateTenantSchema checks if tenant schema exists
+// validateTenantSchema checks if tenant schema exists, in the database holding
+// it
 func (m *MigrationManager) validateTenantSchema(ctx context.Context, tenantID uuid.UUID) bool {
-	query := `SELECT validate_tenant_schema($1)`
-
 	var exists bool
-	err := m.db.QueryRowContext(ctx, query, tenantID).Scan(&exists)
+	db, err := m.schemaDB(ctx, tenantID)
+	if err == nil && db != m.db {
+		exists, err = m.schemaExistsIn(ctx, db, tenantID)
+	} else if err == nil {
+		err = m.db.QueryRowContext(ctx, `SELECT validate_tenant_schema($1)`, tenantID).Scan(&exists)
+	}
 	if err != nil {
 		m.logger.Error("Failed to validate tenant schema",
 			zap.String("tenant_id", tenantID.String()),
@@ -323,3 +401,79 @@ func (m *MigrationManager) validateTenantSchema(ctx context.Context, tenantID uu
 
 	return exists
 }
+
+// migrationScopeHeader starts a comment line restricting a migration file to
+// some tenants, e.g. "-- applies-to: plan=enterprise" or
+// "-- applies-to: tenant=<id>,<id>"
+const migrationScopeHeader = "-- applies-to:"
+
+// migrationTemplatedHeader is a comment line marking a migration file as
+// templated, so that its placeholders are expanded for each tenant
+const migrationTemplatedHeader = "-- templated"
+
+// hasMigrationHeader reports whether the comments at the top of migration SQL
+// include the line header
+func hasMigrationHeader(migrationSQL, header string) bool {
+	for _, line := range strings.Split(migrationSQL, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "--") {
+			return false // the header ends at the first statement
+		}
+		if line == header {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMigrationScope reads the applies-to comments at the top of migration
+// SQL. It returns nil if there are none, so the migration applies to all tenants.
+func parseMigrationScope(migrationSQL string) (*tenant.MigrationScope, error) {
+	var scope *tenant.MigrationScope
+
+	for _, line := range strings.Split(migrationSQL, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "--") {
+			break // the header ends at the first statement
+		}
+		directives, ok := strings.CutPrefix(line, migrationScopeHeader)
+		if !ok {
+			continue
+		}
+
+		if scope == nil {
+			scope = &tenant.MigrationScope{}
+		}
+		for _, directive := range strings.Fields(directives) {
+			key, values, _ := strings.Cut(directive, "=")
+			for _, value := range strings.Split(values, ",") {
+				if value == "" {
+					return nil, fmt.Errorf("empty value in %q", directive)
+				}
+				switch key {
+				case "plan":
+					scope.Plans = append(scope.Plans, value)
+				case "tenant":
+					id, err := uuid.Parse(value)
+					if err != nil {
+						return nil, fmt.Errorf("invalid tenant ID %q: %w", value, err)
+					}
+					scope.TenantIDs = append(scope.TenantIDs, id)
+				default:
+					return nil, fmt.Errorf("unknown key %q, want plan or tenant", key)
+				}
+			}
+		}
+	}
+
+	if scope != nil && len(scope.Plans) == 0 && len(scope.TenantIDs) == 0 {
+		return nil, fmt.Errorf("no plans or tenants listed")
+	}
+	return scope, nil
+}
@@ -425,3 +425,116 @@ func TestMigrationManager_Interface(t *testing.T) {
 	// Test that it implements the MigrationManager interface
 	var _ tenant.MigrationManager = mgr
 }
+
+func TestMigrationManager_ApplyToAllTenants_Scoped(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	db, recorder := newRecordingDB(t)
+	defer db.Close()
+
+	enterprise := &tenant.Tenant{ID: uuid.New(), Name: "Acme", PlanType: tenant.PlanEnterprise, Status: tenant.StatusActive}
+	basic := &tenant.Tenant{ID: uuid.New(), Name: "Globex", PlanType: tenant.PlanBasic, Status: tenant.StatusActive}
+	bespoke := &tenant.Tenant{ID: uuid.New(), Name: "Initech", PlanType: tenant.PlanPro, Status: tenant.StatusActive}
+	repo := &templateTestRepository{tenants: []*tenant.Tenant{enterprise, basic, bespoke}}
+	mgr := NewMigrationManagerWithRepository(db, logger, "", repo)
+
+	migration := &tenant.Migration{
+		Version:   "005",
+		Name:      "add_audit_exports",
+		SQL:       "CREATE TABLE audit_exports (id SERIAL PRIMARY KEY);",
+		AppliesTo: &tenant.MigrationScope{Plans: []string{tenant.PlanEnterprise}, TenantIDs: []uuid.UUID{bespoke.ID}},
+	}
+
+	if err := mgr.ApplyToAllTenants(context.Background(), migration); err != nil {
+		t.Fatalf("ApplyToAllTenants() error = %v", err)
+	}
+
+	applied := recorder.appliedSQL()
+	if len(applied) != 2 || applied[enterprise.ID.String()] == "" || applied[bespoke.ID.String()] == "" {
+		t.Errorf("applied to %v, want the enterprise tenant and the listed tenant only", applied)
+	}
+
+	// Applying directly to an excluded tenant is a no-op
+	if err := mgr.ApplyMigration(context.Background(), basic.ID, migration); err != nil {
+		t.Errorf("ApplyMigration() for an excluded tenant error = %v", err)
+	}
+	if _, ok := recorder.appliedSQL()[basic.ID.String()]; ok {
+		t.Error("ApplyMigration() should skip tenants outside the migration's scope")
+	}
+
+	// Skips are not recorded, so a tenant moving into the scope gets the migration
+	basic.PlanType = tenant.PlanEnterprise
+	if err := mgr.ApplyToAllTenants(context.Background(), migration); err != nil {
+		t.Fatalf("ApplyToAllTenants() after an upgrade error = %v", err)
+	}
+	if got := recorder.recorded(basic.ID); len(got) != 1 || got[0] != "005" {
+		t.Errorf("recorded %v for the upgraded tenant, want 005", got)
+	}
+
+	// Scoped migrations need tenant records
+	if err := NewMigrationManager(db, logger, "").ApplyToAllTenants(context.Background(), migration); err == nil {
+		t.Error("ApplyToAllTenants() should error for scoped migrations without a repository")
+	}
+}
+
+func TestMigrationManager_LoadMigrationFromFile_Scope(t *testing.T) {
+	tenantID := uuid.New()
+	dir := t.TempDir()
+	files := map[string]string{
+		"005_add_audit_exports.up.sql": "-- Audit exports for enterprise customers\n" +
+			"-- applies-to: plan=enterprise,pro\n" +
+			"-- applies-to: tenant=" + tenantID.String() + "\n" +
+			"CREATE TABLE audit_exports (id SERIAL PRIMARY KEY);\n" +
+			"-- applies-to: plan=basic\n",
+		"006_add_index.up.sql":  "CREATE INDEX ON projects (name);",
+		"007_bad_scope.up.sql":  "-- applies-to: region=eu\nSELECT 1;",
+		"008_bad_tenant.up.sql": "-- applies-to: tenant=not-a-uuid\nSELECT 1;",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", name, err)
+		}
+	}
+	mgr := NewMigrationManager(nil, zaptest.NewLogger(t), dir).(*MigrationManager)
+
+	migration, err := mgr.LoadMigrationFromFile("005", "add_audit_exports")
+	if err != nil {
+		t.Fatalf("LoadMigrationFromFile() error = %v", err)
+	}
+	scope := migration.AppliesTo
+	if scope == nil || len(scope.Plans) != 2 || scope.Plans[1] != tenant.PlanPro || len(scope.TenantIDs) != 1 || scope.TenantIDs[0] != tenantID {
+		t.Errorf("AppliesTo = %+v, want enterprise and pro plans plus one tenant", scope)
+	}
+
+	if migration, err := mgr.LoadMigrationFromFile("006", "add_index"); err != nil || migration.AppliesTo != nil {
+		t.Errorf("LoadMigrationFromFile() = %+v, %v; want a migration for all tenants", migration, err)
+	}
+
+	if _, err := mgr.LoadMigrationFromFile("007", "bad_scope"); err == nil {
+		t.Error("LoadMigrationFromFile() should reject an unknown applies-to key")
+	}
+	if _, err := mgr.LoadMigrationFromFile("008", "bad_tenant"); err == nil {
+		t.Error("LoadMigrationFromFile() should reject a malformed tenant ID")
+	}
+}
+
+func TestMigrationManager_LoadMigrationFromFile_Templated(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"009_seed_settings.up.sql": "-- Seed each tenant's settings\n-- templated\nINSERT INTO settings (name) VALUES ({{.Name}});",
+		"010_seed_grid.up.sql":     "INSERT INTO grids (cells) VALUES ('{{1,2},{3,4}}');\n-- templated\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", name, err)
+		}
+	}
+	mgr := NewMigrationManager(nil, zaptest.NewLogger(t), dir).(*MigrationManager)
+
+	if migration, err := mgr.LoadMigrationFromFile("009", "seed_settings"); err != nil || !migration.Templated {
+		t.Errorf("LoadMigrationFromFile() = %+v, %v; want a templated migration", migration, err)
+	}
+	// The header ends at the first statement
+	if migration, err := mgr.LoadMigrationFromFile("010", "seed_grid"); err != nil || migration.Templated {
+		t.Errorf("LoadMigrationFromFile() = %+v, %v; want the SQL run as written", migration, err)
+	}
+}
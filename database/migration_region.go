@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// Ensure MigrationManager migrates regional tenants in their region's database
+var _ tenant.RegionalMigrationManager = (*MigrationManager)(nil)
+
+// SetTenantDB makes the manager migrate each tenant's schema in the database
+// tenantDB returns for it, such as the regional database of a tenant with a
+// data residency region. Tenants for which it returns the manager's own
+// database are migrated with the migration functions as before. Migration
+// records stay in the manager's database, next to the tenant records, so
+// GetAppliedMigrations and IsMigrationApplied are unaffected.
+// tenant.Manager.SetRegionRouter calls it.
+func (m *MigrationManager) SetTenantDB(tenantDB func(ctx context.Context, tenantID uuid.UUID) (*sql.DB, error)) {
+	m.tenantDBMu.Lock()
+	defer m.tenantDBMu.Unlock()
+	m.tenantDB = tenantDB
+}
+
+// schemaDB returns the database holding the tenant's schema
+func (m *MigrationManager) schemaDB(ctx context.Context, tenantID uuid.UUID) (*sql.DB, error) {
+	m.tenantDBMu.RLock()
+	tenantDB := m.tenantDB
+	m.tenantDBMu.RUnlock()
+
+	if tenantDB == nil {
+		return m.db, nil
+	}
+	db, err := tenantDB(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate tenant database: %w", err)
+	}
+	return db, nil
+}
+
+// schemaName returns the tenant's schema name as the migration functions
+// resolve it. It needs get_tenant_schema_name as of migration 002, which reads
+// the name recorded on the tenant.
+func (m *MigrationManager) schemaName(ctx context.Context, tenantID uuid.UUID) (string, error) {
+	var schemaName string
+	if err := m.db.QueryRowContext(ctx, `SELECT get_tenant_schema_name($1)`, tenantID).Scan(&schemaName); err != nil {
+		return "", fmt.Errorf("failed to get tenant schema name: %w", err)
+	}
+	return schemaName, nil
+}
+
+// schemaExistsIn reports whether the tenant's schema exists in db
+func (m *MigrationManager) schemaExistsIn(ctx context.Context, db *sql.DB, tenantID uuid.UUID) (bool, error) {
+	schemaName, err := m.schemaName(ctx, tenantID)
+	if err != nil {
+		return false, err
+	}
+
+	var exists bool
+	if err := db.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM information_schema.schemata WHERE schema_name = $1)`, schemaName,
+	).Scan(&exists); err != nil {
+		return false, fmt.Errorf("error checking schema existence: %w", err)
+	}
+	return exists, nil
+}
+
+// beginInSchema starts a transaction on db with search_path set to the
+// tenant's schema
+func (m *MigrationManager) beginInSchema(ctx context.Context, db *sql.DB, tenantID uuid.UUID) (*sql.Tx, error) {
+	schemaName, err := m.schemaName(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL search_path TO %s, public", pq.QuoteIdentifier(schemaName))); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to set search path: %w", err)
+	}
+	return tx, nil
+}
+
+// applyInDB applies migrationSQL to the tenant's schema in db, a database
+// other than the manager's, and records it in the manager's database as
+// apply_tenant_migration does. The two cannot share a transaction, so the
+// migration is recorded before its transaction commits and the record is
+// removed again if the commit fails.
+func (m *MigrationManager) applyInDB(ctx context.Context, db *sql.DB, tenantID uuid.UUID, migration *tenant.Migration, migrationSQL string, rollbackSQL sql.NullString) error {
+	tx, err := m.beginInSchema(ctx, db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migrationSQL); err != nil {
+		return err
+	}
+
+	if _, err := m.db.ExecContext(ctx, `
+		INSERT INTO public.tenant_migrations (tenant_id, version, name, rollback_sql, checksum)
+		VALUES ($1, $2, $3, $4, $5)
+	`, tenantID, migration.Version, migration.Name, rollbackSQL, recordedChecksum(migration, migrationSQL)); err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		if deleteErr := m.deleteRecord(ctx, tenantID, migration.Version); deleteErr != nil {
+			m.logger.Error("Failed to remove the record of a migration that did not commit",
+				zap.String("tenant_id", tenantID.String()),
+				zap.String("migration_version", migration.Version),
+				zap.Error(deleteErr))
+		}
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+	return nil
+}
+
+// rollbackInDB rolls back the tenant's migration in db, a database other than
+// the manager's, with the rollback SQL recorded in the manager's database, and
+// then removes the record as rollback_tenant_migration does
+func (m *MigrationManager) rollbackInDB(ctx context.Context, db *sql.DB, tenantID uuid.UUID, version string) error {
+	var rollbackSQL sql.NullString
+	err := m.db.QueryRowContext(ctx,
+		`SELECT rollback_sql FROM public.tenant_migrations WHERE tenant_id = $1 AND version = $2`,
+		tenantID, version,
+	).Scan(&rollbackSQL)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get rollback SQL: %w", err)
+	}
+	if !rollbackSQL.Valid {
+		return fmt.Errorf("no rollback SQL found for migration %s and tenant %s", version, tenantID)
+	}
+
+	tx, err := m.beginInSchema(ctx, db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, rollbackSQL.String); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit rollback: %w", err)
+	}
+
+	if err := m.deleteRecord(ctx, tenantID, version); err != nil {
+		return fmt.Errorf("migration %s was rolled back but is still recorded: %w", version, err)
+	}
+	return nil
+}
+
+// deleteRecord removes the record of the tenant's migration
+func (m *MigrationManager) deleteRecord(ctx context.Context, tenantID uuid.UUID, version string) error {
+	_, err := m.db.ExecContext(ctx, `DELETE FROM public.tenant_migrations WHERE tenant_id = $1 AND version = $2`, tenantID, version)
+	return err
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMigrationManager_RegionalTenant(t *testing.T) {
+	ctx := context.Background()
+	primary, primaryRecorder := newRecordingDB(t)
+	defer primary.Close()
+	region, regionRecorder := newRecordingDB(t)
+	defer region.Close()
+
+	regional, home := uuid.New(), uuid.New()
+	mgr := NewMigrationManager(primary, zaptest.NewLogger(t), "").(*MigrationManager)
+	mgr.SetTenantDB(func(ctx context.Context, tenantID uuid.UUID) (*sql.DB, error) {
+		if tenantID == regional {
+			return region, nil
+		}
+		return primary, nil
+	})
+
+	rollbackSQL := "DROP TABLE invoices;"
+	migration := &tenant.Migration{
+		Version:     "005",
+		Name:        "create_invoices",
+		SQL:         "CREATE TABLE invoices (id UUID PRIMARY KEY);",
+		RollbackSQL: &rollbackSQL,
+	}
+
+	if err := mgr.ApplyMigration(ctx, regional, migration); err != nil {
+		t.Fatalf("ApplyMigration() error = %v", err)
+	}
+	if !containsStatement(regionRecorder.executed, migration.SQL) {
+		t.Errorf("region statements = %v, want the migration run in the region", regionRecorder.executed)
+	}
+	if !containsStatement(regionRecorder.executed, "SET LOCAL search_path") {
+		t.Errorf("region statements = %v, want the search path set to the tenant schema", regionRecorder.executed)
+	}
+	if got := primaryRecorder.recorded(regional); len(got) != 1 || got[0] != "005" {
+		t.Errorf("primary records = %v, want the migration recorded in the primary database", got)
+	}
+	if len(primaryRecorder.appliedSQL()) != 0 {
+		t.Error("the migration should not run in the primary database")
+	}
+
+	applied, err := mgr.IsMigrationApplied(ctx, regional, "005")
+	if err != nil || !applied {
+		t.Errorf("IsMigrationApplied() = %v, %v, want true", applied, err)
+	}
+
+	// Applying it again is a no-op
+	statements := len(regionRecorder.executed)
+	if err := mgr.ApplyMigration(ctx, regional, migration); err != nil {
+		t.Fatalf("ApplyMigration() again error = %v", err)
+	}
+	if len(regionRecorder.executed) != statements {
+		t.Error("an applied migration should not run again")
+	}
+
+	// Tenants in the primary database keep using the migration functions
+	if err := mgr.ApplyMigration(ctx, home, migration); err != nil {
+		t.Fatalf("ApplyMigration() home error = %v", err)
+	}
+	if primaryRecorder.appliedSQL()[home.String()] != migration.SQL {
+		t.Error("the home tenant's migration should run through apply_tenant_migration")
+	}
+
+	if err := mgr.RollbackMigration(ctx, regional, "005"); err != nil {
+		t.Fatalf("RollbackMigration() error = %v", err)
+	}
+	if !containsStatement(regionRecorder.executed, rollbackSQL) {
+		t.Errorf("region statements = %v, want the rollback run in the region", regionRecorder.executed)
+	}
+	if got := primaryRecorder.recorded(regional); len(got) != 0 {
+		t.Errorf("primary records = %v, want the record removed", got)
+	}
+}
+
+func TestMigrationManager_RegionalTenant_LocatorError(t *testing.T) {
+	primary, _ := newRecordingDB(t)
+	defer primary.Close()
+
+	mgr := NewMigrationManager(primary, zaptest.NewLogger(t), "").(*MigrationManager)
+	mgr.SetTenantDB(func(ctx context.Context, tenantID uuid.UUID) (*sql.DB, error) {
+		return nil, errors.New("unknown region")
+	})
+
+	migration := &tenant.Migration{Version: "005", Name: "create_invoices", SQL: "CREATE TABLE invoices ();"}
+	if err := mgr.ApplyMigration(context.Background(), uuid.New(), migration); err == nil {
+		t.Error("ApplyMigration() should fail when the tenant's database cannot be found")
+	}
+}
+
+func containsStatement(statements []string, want string) bool {
+	for _, statement := range statements {
+		if strings.Contains(statement, want) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,21 @@
+package database
+
+import (
+	"github.com/alexalmadav/go-multitenant/tenant"
+)
+
+// MigrationTemplateData is the data available to templated migration SQL.
+// Every field renders as a quoted SQL string literal, so {{.Name}} expands to
+// 'Acme Corp' and must not be wrapped in quotes again. Use {{ident .SchemaName}}
+// to expand a value as a quoted identifier instead.
+type MigrationTemplateData = tenant.SQLTemplateData
+
+// isTemplatedMigration reports whether migration SQL is expanded per tenant
+func isTemplatedMigration(migration *tenant.Migration) bool {
+	return migration.Templated
+}
+
+// renderMigrationSQL expands template placeholders in migration SQL using the tenant record
+func renderMigrationSQL(migration *tenant.Migration, t *tenant.Tenant) (string, error) {
+	return tenant.RenderTenantSQL("migration "+migration.Version, migration.SQL, t)
+}
@@ -0,0 +1,448 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRenderMigrationSQL(t *testing.T) {
+	tenantRecord := &tenant.Tenant{
+		ID:         uuid.MustParse("6f1c2a0e-6b1d-4f0a-9b7e-2f4d5c6b7a81"),
+		Name:       "O'Reilly Media",
+		Subdomain:  "oreilly",
+		PlanType:   tenant.PlanPro,
+		Status:     tenant.StatusActive,
+		SchemaName: "tenant_6f1c2a0e",
+	}
+
+	migration := &tenant.Migration{
+		Version: "002",
+		Name:    "seed_settings",
+		SQL:     "INSERT INTO {{ident .SchemaName}}.settings (tenant_id, name, plan) VALUES ({{.TenantID}}, {{.Name}}, {{.PlanType}});",
+	}
+
+	got, err := renderMigrationSQL(migration, tenantRecord)
+	if err != nil {
+		t.Fatalf("renderMigrationSQL() error = %v", err)
+	}
+
+	want := `INSERT INTO "tenant_6f1c2a0e".settings (tenant_id, name, plan) VALUES ('6f1c2a0e-6b1d-4f0a-9b7e-2f4d5c6b7a81', 'O''Reilly Media', 'pro');`
+	if got != want {
+		t.Errorf("renderMigrationSQL() = %v, want %v", got, want)
+	}
+}
+
+func TestRenderMigrationSQL_Errors(t *testing.T) {
+	tenantRecord := &tenant.Tenant{ID: uuid.New(), Name: "Acme"}
+
+	tests := []struct {
+		name string
+		sql  string
+	}{
+		{name: "invalid syntax", sql: "SELECT {{.Name"},
+		{name: "unknown field", sql: "SELECT {{.Unknown}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migration := &tenant.Migration{Version: "003", SQL: tt.sql}
+			if _, err := renderMigrationSQL(migration, tenantRecord); err == nil {
+				t.Error("renderMigrationSQL() should return error")
+			}
+		})
+	}
+}
+
+func TestMigrationManager_ApplyMigration_Templated(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	db, recorder := newRecordingDB(t)
+	defer db.Close()
+
+	acme := &tenant.Tenant{ID: uuid.New(), Name: "Acme Corp", Status: tenant.StatusActive}
+	globex := &tenant.Tenant{ID: uuid.New(), Name: "Globex", Status: tenant.StatusActive}
+	repo := &templateTestRepository{tenants: []*tenant.Tenant{acme, globex}}
+
+	mgr := NewMigrationManagerWithRepository(db, logger, "", repo)
+	migration := &tenant.Migration{
+		ID:        uuid.New(),
+		Version:   "002",
+		Name:      "seed_settings",
+		SQL:       "INSERT INTO settings (tenant_id, name) VALUES ({{.TenantID}}, {{.Name}});",
+		Templated: true,
+	}
+
+	if err := mgr.ApplyToAllTenants(context.Background(), migration); err != nil {
+		t.Fatalf("ApplyToAllTenants() error = %v", err)
+	}
+
+	applied := recorder.appliedSQL()
+	if len(applied) != 2 {
+		t.Fatalf("applied %d migrations, want 2", len(applied))
+	}
+
+	wantAcme := fmt.Sprintf("INSERT INTO settings (tenant_id, name) VALUES ('%s', 'Acme Corp');", acme.ID)
+	wantGlobex := fmt.Sprintf("INSERT INTO settings (tenant_id, name) VALUES ('%s', 'Globex');", globex.ID)
+	if applied[acme.ID.String()] != wantAcme {
+		t.Errorf("acme migration SQL = %v, want %v", applied[acme.ID.String()], wantAcme)
+	}
+	if applied[globex.ID.String()] != wantGlobex {
+		t.Errorf("globex migration SQL = %v, want %v", applied[globex.ID.String()], wantGlobex)
+	}
+
+	// The original migration is left untouched
+	if !strings.Contains(migration.SQL, "{{.Name}}") {
+		t.Error("ApplyMigration() should not modify the migration SQL")
+	}
+}
+
+func TestMigrationManager_ApplyMigration_TemplatedWithoutRepository(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	db, recorder := newRecordingDB(t)
+	defer db.Close()
+
+	mgr := NewMigrationManager(db, logger, "")
+	migration := &tenant.Migration{
+		Version:   "002",
+		Name:      "seed_settings",
+		SQL:       "INSERT INTO settings (name) VALUES ({{.Name}});",
+		Templated: true,
+	}
+
+	if err := mgr.ApplyMigration(context.Background(), uuid.New(), migration); err == nil {
+		t.Error("ApplyMigration() should error for templated migrations without a repository")
+	}
+	if len(recorder.appliedSQL()) != 0 {
+		t.Error("ApplyMigration() should not execute unrendered migrations")
+	}
+}
+
+func TestMigrationManager_ApplyMigration_BracesWithoutTemplating(t *testing.T) {
+	db, recorder := newRecordingDB(t)
+	defer db.Close()
+
+	// Array literals contain "{{" but are not templates
+	mgr := NewMigrationManager(db, zaptest.NewLogger(t), "")
+	migration := &tenant.Migration{
+		Version: "003",
+		Name:    "seed_grid",
+		SQL:     "INSERT INTO grids (cells) VALUES ('{{1,2},{3,4}}');",
+	}
+
+	tenantID := uuid.New()
+	if err := mgr.ApplyMigration(context.Background(), tenantID, migration); err != nil {
+		t.Fatalf("ApplyMigration() error = %v", err)
+	}
+	if got := recorder.appliedSQL()[tenantID.String()]; got != migration.SQL {
+		t.Errorf("migration SQL = %v, want it run as written", got)
+	}
+}
+
+// templateTestRepository is a minimal tenant.Repository for migration tests
+type templateTestRepository struct {
+	tenants []*tenant.Tenant
+}
+
+func (r *templateTestRepository) Create(ctx context.Context, t *tenant.Tenant) error { return nil }
+
+func (r *templateTestRepository) GetByID(ctx context.Context, id uuid.UUID) (*tenant.Tenant, error) {
+	for _, t := range r.tenants {
+		if t.ID == id {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("tenant not found")
+}
+
+func (r *templateTestRepository) GetBySubdomain(ctx context.Context, subdomain string) (*tenant.Tenant, error) {
+	return nil, fmt.Errorf("tenant not found")
+}
+
+func (r *templateTestRepository) Update(ctx context.Context, t *tenant.Tenant) error { return nil }
+
+func (r *templateTestRepository) Delete(ctx context.Context, id uuid.UUID) error { return nil }
+
+func (r *templateTestRepository) List(ctx context.Context, page, perPage int) ([]*tenant.Tenant, int, error) {
+	start := (page - 1) * perPage
+	if start >= len(r.tenants) {
+		return nil, len(r.tenants), nil
+	}
+	end := start + perPage
+	if end > len(r.tenants) {
+		end = len(r.tenants)
+	}
+	return r.tenants[start:end], len(r.tenants), nil
+}
+
+func (r *templateTestRepository) GetStats(ctx context.Context, tenantID uuid.UUID) (*tenant.Stats, error) {
+	return &tenant.Stats{TenantID: tenantID}, nil
+}
+
+// migrationRecorder captures the SQL passed to apply_tenant_migration per tenant
+type migrationRecorder struct {
+	mu          sync.Mutex
+	applied     map[string]string
+	versions    map[string][]string // versions recorded per tenant, in order
+	checksums   map[string]string   // recorded checksums by tenant ID and version
+	rollbackSQL map[string]string   // rollback SQL recorded by tenant ID and version, outside the migration functions
+	failOn      string              // migration SQL containing this fails
+	executed    []string            // other statements run, such as dry runs
+	commits     int
+	rollbacks   int
+}
+
+// record marks versions as applied to tenantID
+func (r *migrationRecorder) record(tenantID uuid.UUID, versions ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.versions[tenantID.String()] = append(r.versions[tenantID.String()], versions...)
+}
+
+func (r *migrationRecorder) recorded(tenantID uuid.UUID) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.versions[tenantID.String()]...)
+}
+
+func (r *migrationRecorder) isRecorded(tenantID, version string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, v := range r.versions[tenantID] {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *migrationRecorder) appliedSQL() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	applied := make(map[string]string, len(r.applied))
+	for k, v := range r.applied {
+		applied[k] = v
+	}
+	return applied
+}
+
+// newRecordingDB returns a database whose schema checks always pass and whose
+// migration calls are recorded instead of executed
+func newRecordingDB(t *testing.T) (*sql.DB, *migrationRecorder) {
+	recorder := &migrationRecorder{applied: make(map[string]string), versions: make(map[string][]string), checksums: make(map[string]string), rollbackSQL: make(map[string]string)}
+	return sql.OpenDB(recordingConnector{recorder: recorder}), recorder
+}
+
+type recordingConnector struct {
+	recorder *migrationRecorder
+}
+
+func (c recordingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &recordingConn{recorder: c.recorder}, nil
+}
+
+func (c recordingConnector) Driver() driver.Driver { return recordingDriver{} }
+
+type recordingDriver struct{}
+
+func (recordingDriver) Open(name string) (driver.Conn, error) {
+	return nil, fmt.Errorf("use recordingConnector")
+}
+
+type recordingConn struct {
+	recorder *migrationRecorder
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return &recordingStmt{query: query, recorder: c.recorder}, nil
+}
+
+func (c *recordingConn) Close() error { return nil }
+
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	return recordingTx{recorder: c.recorder}, nil
+}
+
+type recordingTx struct {
+	recorder *migrationRecorder
+}
+
+func (tx recordingTx) Commit() error {
+	tx.recorder.mu.Lock()
+	defer tx.recorder.mu.Unlock()
+	tx.recorder.commits++
+	return nil
+}
+
+func (tx recordingTx) Rollback() error {
+	tx.recorder.mu.Lock()
+	defer tx.recorder.mu.Unlock()
+	tx.recorder.rollbacks++
+	return nil
+}
+
+type recordingStmt struct {
+	query    string
+	recorder *migrationRecorder
+}
+
+func (s *recordingStmt) Close() error { return nil }
+
+func (s *recordingStmt) NumInput() int { return -1 }
+
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if strings.Contains(s.query, "apply_tenant_migration") {
+		s.recorder.mu.Lock()
+		defer s.recorder.mu.Unlock()
+		if s.recorder.failOn != "" && strings.Contains(fmt.Sprint(args[3]), s.recorder.failOn) {
+			return nil, fmt.Errorf("syntax error in migration")
+		}
+		tenantID := fmt.Sprint(args[0])
+		s.recorder.applied[tenantID] = fmt.Sprint(args[3])
+		s.recorder.versions[tenantID] = append(s.recorder.versions[tenantID], fmt.Sprint(args[1]))
+		s.recorder.checksums[tenantID+"/"+fmt.Sprint(args[1])] = fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprint(args[3]))))
+		return driver.RowsAffected(0), nil
+	}
+
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	if s.recorder.failOn != "" && strings.Contains(s.query, s.recorder.failOn) {
+		return nil, fmt.Errorf("syntax error in statement")
+	}
+
+	// Migrations applied outside the migration functions are recorded directly
+	switch {
+	case strings.Contains(s.query, "INSERT INTO public.tenant_migrations"):
+		tenantID, version := fmt.Sprint(args[0]), fmt.Sprint(args[1])
+		s.recorder.versions[tenantID] = append(s.recorder.versions[tenantID], version)
+		s.recorder.checksums[tenantID+"/"+version] = fmt.Sprint(args[4])
+		if args[3] != nil {
+			s.recorder.rollbackSQL[tenantID+"/"+version] = fmt.Sprint(args[3])
+		}
+		return driver.RowsAffected(1), nil
+	case strings.Contains(s.query, "UPDATE public.tenant_migrations SET checksum"):
+		s.recorder.checksums[fmt.Sprint(args[0])+"/"+fmt.Sprint(args[1])] = fmt.Sprint(args[2])
+		return driver.RowsAffected(1), nil
+	case strings.Contains(s.query, "DELETE FROM public.tenant_migrations"):
+		tenantID, version := fmt.Sprint(args[0]), fmt.Sprint(args[1])
+		kept := s.recorder.versions[tenantID][:0]
+		for _, v := range s.recorder.versions[tenantID] {
+			if v != version {
+				kept = append(kept, v)
+			}
+		}
+		s.recorder.versions[tenantID] = kept
+		return driver.RowsAffected(1), nil
+	}
+
+	s.recorder.executed = append(s.recorder.executed, s.query)
+	return driver.RowsAffected(3), nil
+}
+
+func (s *recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	switch {
+	case strings.Contains(s.query, "validate_tenant_schema"), strings.Contains(s.query, "information_schema.schemata"):
+		return &boolRows{value: true}, nil
+	case strings.Contains(s.query, "SELECT rollback_sql FROM public.tenant_migrations"):
+		s.recorder.mu.Lock()
+		defer s.recorder.mu.Unlock()
+		rollbackSQL, ok := s.recorder.rollbackSQL[fmt.Sprint(args[0])+"/"+fmt.Sprint(args[1])]
+		if !ok {
+			return &textRows{done: true}, nil
+		}
+		return &textRows{value: rollbackSQL}, nil
+	case strings.Contains(s.query, "is_tenant_migration_applied"):
+		return &boolRows{value: s.recorder.isRecorded(fmt.Sprint(args[0]), fmt.Sprint(args[1]))}, nil
+	case strings.Contains(s.query, "get_tenant_schema_name"):
+		return &textRows{value: "tenant_" + fmt.Sprint(args[0])}, nil
+	case strings.Contains(s.query, "get_tenant_applied_migrations"):
+		tenantID, err := uuid.Parse(fmt.Sprint(args[0]))
+		if err != nil {
+			return nil, err
+		}
+		rows := &appliedRows{versions: s.recorder.recorded(tenantID), checksums: map[string]string{}}
+		s.recorder.mu.Lock()
+		for _, version := range rows.versions {
+			rows.checksums[version] = s.recorder.checksums[tenantID.String()+"/"+version]
+		}
+		s.recorder.mu.Unlock()
+		return rows, nil
+	}
+	return nil, fmt.Errorf("unexpected query: %s", s.query)
+}
+
+// appliedRows lists recorded migrations as get_tenant_applied_migrations does
+type appliedRows struct {
+	versions  []string
+	checksums map[string]string // "" is recorded as NULL
+}
+
+func (r *appliedRows) Columns() []string {
+	return []string{"migration_id", "migration_version", "migration_name", "applied_at", "checksum"}
+}
+
+func (r *appliedRows) Close() error { return nil }
+
+func (r *appliedRows) Next(dest []driver.Value) error {
+	if len(r.versions) == 0 {
+		return io.EOF
+	}
+	dest[0] = uuid.New().String()
+	dest[1] = r.versions[0]
+	dest[2] = "migration_" + r.versions[0]
+	dest[3] = time.Now()
+	dest[4] = nil
+	if checksum := r.checksums[r.versions[0]]; checksum != "" {
+		dest[4] = checksum
+	}
+	r.versions = r.versions[1:]
+	return nil
+}
+
+// textRows is a single-row, single-column text result
+type textRows struct {
+	value string
+	done  bool
+}
+
+func (r *textRows) Columns() []string { return []string{"result"} }
+
+func (r *textRows) Close() error { return nil }
+
+func (r *textRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.value
+	return nil
+}
+
+// boolRows is a single-row, single-column boolean result
+type boolRows struct {
+	value bool
+	done  bool
+}
+
+func (r *boolRows) Columns() []string { return []string{"result"} }
+
+func (r *boolRows) Close() error { return nil }
+
+func (r *boolRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.value
+	return nil
+}
@@ -0,0 +1,151 @@
+-- Fix the tenant migration functions: find schemas under the name recorded on
+-- the tenant, so that renamed and prefixed schemas are migrated, and use the
+-- version and name columns of tenant_migrations
+
+-- Function to get tenant schema name, as recorded on the tenant so that
+-- renamed schemas are found
+CREATE OR REPLACE FUNCTION get_tenant_schema_name(tenant_uuid UUID)
+RETURNS TEXT AS $$
+BEGIN
+    RETURN COALESCE(
+        (SELECT t.schema_name FROM public.tenants t WHERE t.id = tenant_uuid),
+        'tenant_' || tenant_uuid::TEXT
+    );
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- Function to apply tenant migration (FIXED VERSION)
+CREATE OR REPLACE FUNCTION apply_tenant_migration(
+    tenant_uuid UUID,
+    migration_version VARCHAR(50),
+    migration_name VARCHAR(255),
+    migration_sql TEXT,
+    rollback_sql TEXT DEFAULT NULL
+) RETURNS VOID AS $$
+DECLARE
+    tenant_schema TEXT;
+    migration_checksum VARCHAR(64);
+BEGIN
+    tenant_schema := get_tenant_schema_name(tenant_uuid);
+    migration_checksum := encode(digest(migration_sql, 'sha256'), 'hex');
+    
+    -- Check if migration already applied
+    IF EXISTS (
+        SELECT 1 FROM public.tenant_migrations tm
+        WHERE tm.tenant_id = tenant_uuid AND tm.version = apply_tenant_migration.migration_version
+    ) THEN
+        RAISE NOTICE 'Migration % already applied for tenant %', migration_version, tenant_uuid;
+        RETURN;
+    END IF;
+    
+    -- Check if schema exists
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.schemata s
+        WHERE s.schema_name = tenant_schema
+    ) THEN
+        RAISE EXCEPTION 'Tenant schema % does not exist', tenant_schema;
+    END IF;
+    
+    -- Apply migration
+    EXECUTE 'SET search_path TO ' || quote_ident(tenant_schema) || ', public';
+    EXECUTE migration_sql;
+    
+    -- Record migration (explicitly reference public schema)
+    INSERT INTO public.tenant_migrations (
+        id, tenant_id, version, name, 
+        rollback_sql, checksum, applied_at
+    ) VALUES (
+        gen_random_uuid(), tenant_uuid, migration_version, migration_name, 
+        rollback_sql, migration_checksum, CURRENT_TIMESTAMP
+    );
+    
+    SET search_path TO public;
+    
+    RAISE NOTICE 'Migration % applied successfully for tenant %', migration_version, tenant_uuid;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Function to rollback tenant migration (FIXED VERSION)
+CREATE OR REPLACE FUNCTION rollback_tenant_migration(
+    tenant_uuid UUID,
+    migration_version VARCHAR(50)
+) RETURNS VOID AS $$
+DECLARE
+    tenant_schema TEXT;
+    rollback_sql_text TEXT;
+BEGIN
+    tenant_schema := get_tenant_schema_name(tenant_uuid);
+    
+    -- Get rollback SQL (explicitly reference public schema)
+    SELECT tm.rollback_sql INTO rollback_sql_text
+    FROM public.tenant_migrations tm
+    WHERE tm.tenant_id = tenant_uuid AND tm.version = rollback_tenant_migration.migration_version;
+    
+    IF rollback_sql_text IS NULL THEN
+        RAISE EXCEPTION 'No rollback SQL found for migration % and tenant %', migration_version, tenant_uuid;
+    END IF;
+    
+    -- Execute rollback
+    EXECUTE 'SET search_path TO ' || quote_ident(tenant_schema) || ', public';
+    EXECUTE rollback_sql_text;
+    
+    -- Remove migration record (explicitly reference public schema)
+    DELETE FROM public.tenant_migrations tm
+    WHERE tm.tenant_id = tenant_uuid AND tm.version = rollback_tenant_migration.migration_version;
+    
+    SET search_path TO public;
+    
+    RAISE NOTICE 'Migration % rolled back successfully for tenant %', migration_version, tenant_uuid;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Function to check if tenant migration is applied
+CREATE OR REPLACE FUNCTION is_tenant_migration_applied(
+    tenant_uuid UUID,
+    migration_version VARCHAR(50)
+) RETURNS BOOLEAN AS $$
+BEGIN
+    RETURN EXISTS (
+        SELECT 1 FROM public.tenant_migrations tm
+        WHERE tm.tenant_id = tenant_uuid AND tm.version = is_tenant_migration_applied.migration_version
+    );
+END;
+$$ LANGUAGE plpgsql;
+
+-- Function to list applied migrations for a tenant
+CREATE OR REPLACE FUNCTION get_tenant_applied_migrations(tenant_uuid UUID)
+RETURNS TABLE(
+    migration_id UUID,
+    migration_version VARCHAR(50),
+    migration_name VARCHAR(255),
+    applied_at TIMESTAMP WITH TIME ZONE,
+    checksum VARCHAR(64)
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT 
+        tm.id,
+        tm.version,
+        tm.name,
+        tm.applied_at,
+        tm.checksum
+    FROM public.tenant_migrations tm
+    WHERE tm.tenant_id = tenant_uuid
+    ORDER BY tm.applied_at;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Function to validate tenant schema exists
+CREATE OR REPLACE FUNCTION validate_tenant_schema(tenant_uuid UUID)
+RETURNS BOOLEAN AS $$
+DECLARE
+    tenant_schema TEXT;
+BEGIN
+    tenant_schema := get_tenant_schema_name(tenant_uuid);
+    
+    RETURN EXISTS (
+        SELECT 1 FROM information_schema.schemata s
+        WHERE s.schema_name = tenant_schema
+    );
+END;
+$$ LANGUAGE plpgsql;
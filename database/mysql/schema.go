@@ -0,0 +1,324 @@
+// Package mysql isolates tenants in MySQL, where each tenant gets its own
+// database rather than a PostgreSQL schema.
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// SchemaManager implements tenant.SchemaManager for MySQL. Each tenant gets a
+// database named like a PostgreSQL tenant schema, e.g. tenant_<id>, and
+// GetSchemaName returns that database name. Queries select the tenant's
+// database with USE on a dedicated connection; see GetTenantConn and
+// WithTenantTx.
+type SchemaManager struct {
+	db             *sql.DB
+	logger         *zap.Logger
+	databasePrefix string
+}
+
+// Ensure SchemaManager implements tenant.SchemaManager interface
+var _ tenant.SchemaManager = (*SchemaManager)(nil)
+
+// NewSchemaManager creates a new MySQL schema manager
+func NewSchemaManager(db *sql.DB, logger *zap.Logger, databasePrefix string) *SchemaManager {
+	if databasePrefix == "" {
+		databasePrefix = "tenant_"
+	}
+
+	return &SchemaManager{
+		db:             db,
+		logger:         logger.Named("mysql_schema"),
+		databasePrefix: databasePrefix,
+	}
+}
+
+// GetSchemaName returns the name of the tenant's database
+func (sm *SchemaManager) GetSchemaName(tenantID uuid.UUID) string {
+	return fmt.Sprintf("%s%s", sm.databasePrefix, strings.ReplaceAll(tenantID.String(), "-", "_"))
+}
+
+// CreateTenantSchema creates the tenant's database with all required tables.
+// MySQL commits DDL immediately, so if a table cannot be created the database
+// is dropped again rather than rolled back.
+func (sm *SchemaManager) CreateTenantSchema(ctx context.Context, tenantID uuid.UUID, name string) error {
+	databaseName := sm.GetSchemaName(tenantID)
+	quotedDatabase := quoteIdentifier(databaseName)
+
+	sm.logger.Info("Creating tenant database",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("database", databaseName),
+		zap.String("tenant_name", name))
+
+	createSQL := fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci", quotedDatabase)
+	if _, err := sm.db.ExecContext(ctx, createSQL); err != nil {
+		return fmt.Errorf("failed to create database %s: %w", databaseName, err)
+	}
+
+	if err := sm.createTenantTables(ctx, quotedDatabase); err != nil {
+		if dropErr := sm.DropTenantSchema(context.WithoutCancel(ctx), tenantID); dropErr != nil {
+			sm.logger.Error("Failed to drop database after table creation failed",
+				zap.String("tenant_id", tenantID.String()),
+				zap.Error(dropErr))
+		}
+		return fmt.Errorf("failed to create tenant tables: %w", err)
+	}
+
+	sm.logger.Info("Successfully created tenant database",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("database", databaseName))
+
+	return nil
+}
+
+// DropTenantSchema removes the tenant's database and all its data
+func (sm *SchemaManager) DropTenantSchema(ctx context.Context, tenantID uuid.UUID) error {
+	databaseName := sm.GetSchemaName(tenantID)
+
+	sm.logger.Warn("Dropping tenant database",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("database", databaseName))
+
+	dropSQL := fmt.Sprintf("DROP DATABASE IF EXISTS %s", quoteIdentifier(databaseName))
+	if _, err := sm.db.ExecContext(ctx, dropSQL); err != nil {
+		return fmt.Errorf("failed to drop database %s: %w", databaseName, err)
+	}
+
+	sm.logger.Info("Successfully dropped tenant database",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("database", databaseName))
+
+	return nil
+}
+
+// SchemaExists checks if the tenant's database exists
+func (sm *SchemaManager) SchemaExists(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	databaseName := sm.GetSchemaName(tenantID)
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := `SELECT EXISTS(SELECT 1 FROM information_schema.schemata WHERE schema_name = ?)`
+
+	var exists bool
+	if err := sm.db.QueryRowContext(ctx, query, databaseName).Scan(&exists); err != nil {
+		sm.logger.Error("Failed to check database existence",
+			zap.String("database", databaseName),
+			zap.String("tenant_id", tenantID.String()),
+			zap.Error(err))
+		return false, fmt.Errorf("error checking database existence: %w", err)
+	}
+
+	return exists, nil
+}
+
+// SetSearchPath selects the tenant's database with USE. It affects only the
+// pooled connection the statement happens to run on, so use GetTenantConn or
+// WithTenantTx for tenant-scoped queries.
+func (sm *SchemaManager) SetSearchPath(db *sql.DB, tenantID uuid.UUID) error {
+	query := "USE " + quoteIdentifier(sm.GetSchemaName(tenantID))
+
+	if _, err := db.Exec(query); err != nil {
+		sm.logger.Error("Failed to select tenant database",
+			zap.String("tenant_id", tenantID.String()),
+			zap.String("query", query),
+			zap.Error(err))
+		return fmt.Errorf("error selecting tenant database: %w", err)
+	}
+
+	return nil
+}
+
+// ListTenantSchemas returns all tenant databases on the server
+func (sm *SchemaManager) ListTenantSchemas(ctx context.Context) ([]string, error) {
+	query := `
+		SELECT schema_name
+		FROM information_schema.schemata
+		WHERE schema_name LIKE ?
+		ORDER BY schema_name
+	`
+
+	rows, err := sm.db.QueryContext(ctx, query, escapeLike(sm.databasePrefix)+"%")
+	if err != nil {
+		sm.logger.Error("Failed to list tenant databases", zap.Error(err))
+		return nil, fmt.Errorf("error listing tenant databases: %w", err)
+	}
+	defer rows.Close()
+
+	var databases []string
+	for rows.Next() {
+		var databaseName string
+		if err := rows.Scan(&databaseName); err != nil {
+			return nil, fmt.Errorf("error scanning tenant database name: %w", err)
+		}
+		databases = append(databases, databaseName)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tenant databases: %w", err)
+	}
+
+	return databases, nil
+}
+
+// TenantConn is a dedicated connection using a tenant's database. Close
+// selects the database the connection used before, so that it does not go
+// back to the pool scoped to the tenant; a connection that had no database
+// selected is discarded instead.
+type TenantConn struct {
+	*sql.Conn
+	previous sql.NullString
+}
+
+// Close restores the connection's previous database and returns it to the pool
+func (c *TenantConn) Close() error {
+	if c.previous.Valid {
+		if _, err := c.Conn.ExecContext(context.Background(), "USE "+quoteIdentifier(c.previous.String)); err == nil {
+			return c.Conn.Close()
+		}
+	}
+
+	// The connection cannot be unscoped, so make the pool discard it
+	c.Conn.Raw(func(driverConn interface{}) error { return driver.ErrBadConn })
+	if err := c.Conn.Close(); err != nil && !errors.Is(err, sql.ErrConnDone) {
+		return err
+	}
+	return nil
+}
+
+// GetTenantConn returns a dedicated connection with the tenant's database
+// selected, so that unqualified table names resolve to the tenant's tables.
+// The caller must Close it.
+func (sm *SchemaManager) GetTenantConn(ctx context.Context, tenantID uuid.UUID) (*TenantConn, error) {
+	conn, err := sm.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	tenantConn := &TenantConn{Conn: conn}
+	if err := conn.QueryRowContext(ctx, "SELECT DATABASE()").Scan(&tenantConn.previous); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read current database: %w", err)
+	}
+
+	databaseName := sm.GetSchemaName(tenantID)
+	if _, err := conn.ExecContext(ctx, "USE "+quoteIdentifier(databaseName)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to select tenant database: %w", err)
+	}
+
+	sm.logger.Debug("Acquired tenant connection",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("database", databaseName))
+
+	return tenantConn, nil
+}
+
+// WithTenantTx runs fn in a transaction on a connection with the tenant's
+// database selected, committing if fn succeeds and rolling back otherwise
+func (sm *SchemaManager) WithTenantTx(ctx context.Context, tenantID uuid.UUID, fn func(tx *sql.Tx) error) error {
+	conn, err := sm.GetTenantConn(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// createTenantTables creates the standard tenant tables, the MySQL
+// equivalents of those database.SchemaManager creates in PostgreSQL. Every
+// statement is qualified with the tenant's database.
+func (sm *SchemaManager) createTenantTables(ctx context.Context, quotedDatabase string) error {
+	tables := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.projects (
+			id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+			name VARCHAR(255) NOT NULL,
+			description TEXT,
+			status VARCHAR(50) NOT NULL DEFAULT 'active',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			INDEX idx_projects_status (status),
+			INDEX idx_projects_created_at (created_at)
+		)`, quotedDatabase),
+
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.tasks (
+			id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+			project_id CHAR(36) NOT NULL,
+			title VARCHAR(255) NOT NULL,
+			description TEXT,
+			status VARCHAR(50) NOT NULL DEFAULT 'pending',
+			priority VARCHAR(20) NOT NULL DEFAULT 'medium',
+			due_date TIMESTAMP NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			INDEX idx_tasks_status (status),
+			INDEX idx_tasks_due_date (due_date),
+			FOREIGN KEY (project_id) REFERENCES %s.projects(id) ON DELETE CASCADE
+		)`, quotedDatabase, quotedDatabase),
+
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.documents (
+			id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+			project_id CHAR(36) NOT NULL,
+			file_name VARCHAR(255) NOT NULL,
+			file_path VARCHAR(500) NOT NULL,
+			file_type VARCHAR(100),
+			file_size BIGINT,
+			uploaded_by CHAR(36),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (project_id) REFERENCES %s.projects(id) ON DELETE CASCADE
+		)`, quotedDatabase, quotedDatabase),
+
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.tenant_users (
+			user_id CHAR(36) NOT NULL PRIMARY KEY,
+			role VARCHAR(50) NOT NULL DEFAULT 'user',
+			permissions JSON,
+			is_active BOOLEAN DEFAULT TRUE,
+			joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			INDEX idx_tenant_users_role (role)
+		)`, quotedDatabase),
+	}
+
+	for _, tableSQL := range tables {
+		if _, err := sm.db.ExecContext(ctx, tableSQL); err != nil {
+			return fmt.Errorf("failed to create table: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// quoteIdentifier quotes a MySQL identifier with backticks
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// escapeLike escapes the LIKE wildcards in s, such as the _ in tenant_
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,222 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSchemaManager_GetSchemaName(t *testing.T) {
+	tenantID := uuid.MustParse("7c9e6679-7425-40de-944b-e07fc1f90ae7")
+
+	if got := NewSchemaManager(nil, zaptest.NewLogger(t), "").GetSchemaName(tenantID); got != "tenant_7c9e6679_7425_40de_944b_e07fc1f90ae7" {
+		t.Errorf("GetSchemaName() = %s, want the default prefix", got)
+	}
+	if got := NewSchemaManager(nil, zaptest.NewLogger(t), "acme_").GetSchemaName(tenantID); got != "acme_7c9e6679_7425_40de_944b_e07fc1f90ae7" {
+		t.Errorf("GetSchemaName() = %s, want the custom prefix", got)
+	}
+}
+
+func TestSchemaManager_CreateTenantSchema(t *testing.T) {
+	recorder := &statementRecorder{}
+	sm := NewSchemaManager(sql.OpenDB(recorder), zaptest.NewLogger(t), "tenant_")
+	ctx := context.Background()
+	tenantID := uuid.New()
+	quoted := "`" + sm.GetSchemaName(tenantID) + "`"
+
+	if err := sm.CreateTenantSchema(ctx, tenantID, "Acme"); err != nil {
+		t.Fatalf("CreateTenantSchema() error = %v", err)
+	}
+	statements := recorder.all()
+	if len(statements) != 5 || !strings.HasPrefix(statements[0], "CREATE DATABASE IF NOT EXISTS "+quoted) {
+		t.Fatalf("statements = %q, want the database then its 4 tables", statements)
+	}
+	for _, statement := range statements[1:] {
+		if !strings.Contains(statement, "CREATE TABLE IF NOT EXISTS "+quoted+".") {
+			t.Errorf("table created outside the tenant database: %s", statement)
+		}
+	}
+
+	// A failed table drops the database again
+	recorder.reset("tenant_users")
+	if err := sm.CreateTenantSchema(ctx, tenantID, "Acme"); err == nil {
+		t.Fatal("CreateTenantSchema() should fail when a table cannot be created")
+	}
+	statements = recorder.all()
+	if last := statements[len(statements)-1]; last != "DROP DATABASE IF EXISTS "+quoted {
+		t.Errorf("last statement = %q, want the database dropped", last)
+	}
+}
+
+func TestTenantConn_RestoresDatabase(t *testing.T) {
+	recorder := &statementRecorder{current: "app"}
+	sm := NewSchemaManager(sql.OpenDB(recorder), zaptest.NewLogger(t), "tenant_")
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	var used string
+	err := sm.WithTenantTx(ctx, tenantID, func(tx *sql.Tx) error {
+		used = recorder.last()
+		_, err := tx.ExecContext(ctx, "INSERT INTO projects (name) VALUES (?)", "Apollo")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithTenantTx() error = %v", err)
+	}
+	if used != "USE `"+sm.GetSchemaName(tenantID)+"`" {
+		t.Errorf("transaction ran after %q, want the tenant database selected", used)
+	}
+	if last := recorder.last(); last != "USE `app`" {
+		t.Errorf("last statement = %q, want the previous database restored", last)
+	}
+
+	if recorder.discarded != 0 {
+		t.Errorf("%d connections discarded, want the restored one kept", recorder.discarded)
+	}
+
+	// A connection with no database selected cannot be restored and is discarded
+	recorder.current = ""
+	conn, err := sm.GetTenantConn(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("GetTenantConn() error = %v", err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if recorder.discarded == 0 {
+		t.Error("Close() should discard a connection it cannot unscope")
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	if got := escapeLike(`tenant_%\`); got != `tenant\_\%\\` {
+		t.Errorf("escapeLike() = %s", got)
+	}
+}
+
+// statementRecorder is a driver.Connector whose connections record every
+// statement, fail those containing failOn and answer SELECT DATABASE() with
+// current, or NULL when it is empty
+type statementRecorder struct {
+	mu         sync.Mutex
+	statements []string
+	failOn     string
+	current    string
+	discarded  int
+}
+
+func (r *statementRecorder) Connect(ctx context.Context) (driver.Conn, error) {
+	return &recorderConn{recorder: r}, nil
+}
+
+func (r *statementRecorder) Driver() driver.Driver { return recorderDriver{} }
+
+func (r *statementRecorder) record(query string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, query)
+	if r.failOn != "" && strings.Contains(query, r.failOn) {
+		return errors.New("statement failed")
+	}
+	return nil
+}
+
+func (r *statementRecorder) all() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.statements...)
+}
+
+func (r *statementRecorder) last() string {
+	statements := r.all()
+	if len(statements) == 0 {
+		return ""
+	}
+	return statements[len(statements)-1]
+}
+
+func (r *statementRecorder) reset(failOn string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = nil
+	r.failOn = failOn
+}
+
+type recorderDriver struct{}
+
+func (recorderDriver) Open(name string) (driver.Conn, error) {
+	return nil, errors.New("use statementRecorder")
+}
+
+type recorderConn struct {
+	recorder *statementRecorder
+}
+
+func (c *recorderConn) Prepare(query string) (driver.Stmt, error) {
+	return &recorderStmt{conn: c, query: query}, nil
+}
+
+// Close is only called when the pool discards the connection
+func (c *recorderConn) Close() error {
+	c.recorder.mu.Lock()
+	c.recorder.discarded++
+	c.recorder.mu.Unlock()
+	return nil
+}
+
+func (c *recorderConn) Begin() (driver.Tx, error) { return recorderTx{}, nil }
+
+type recorderTx struct{}
+
+func (recorderTx) Commit() error   { return nil }
+func (recorderTx) Rollback() error { return nil }
+
+type recorderStmt struct {
+	conn  *recorderConn
+	query string
+}
+
+func (s *recorderStmt) Close() error { return nil }
+
+func (s *recorderStmt) NumInput() int { return -1 }
+
+func (s *recorderStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if err := s.conn.recorder.record(s.query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (s *recorderStmt) Query(args []driver.Value) (driver.Rows, error) {
+	var value driver.Value
+	if s.query == "SELECT DATABASE()" && s.conn.recorder.current != "" {
+		value = s.conn.recorder.current
+	}
+	return &singleRow{value: value}, nil
+}
+
+type singleRow struct {
+	value driver.Value
+	done  bool
+}
+
+func (r *singleRow) Columns() []string { return []string{"value"} }
+
+func (r *singleRow) Close() error { return nil }
+
+func (r *singleRow) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.value
+	return nil
+}
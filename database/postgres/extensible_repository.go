@@ -3,27 +3,70 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/alexalmadav/go-multitenant/tenant"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
+// customDomainIndex is the unique index that backs custom domain uniqueness
+const customDomainIndex = "idx_tenants_metadata_custom_domain"
+
 // ExtensibleRepository implements tenant.ExtensibleRepository for PostgreSQL
 type ExtensibleRepository struct {
-	*Repository // Embed the base repository
+	*Repository           // Embed the base repository
+	planTemplates         map[string]tenant.PlanTemplate
+	allowDuplicateDomains bool
+	metadataHooks         tenant.MetadataHooks
+	metadataLimits        tenant.MetadataLimits
 }
 
+// Ensure the repository inserts batches of tenants with their metadata
+var _ tenant.ExtendedBatchRepository = (*ExtensibleRepository)(nil)
+
 // NewExtensibleRepository creates a new extensible PostgreSQL repository
 func NewExtensibleRepository(db *sql.DB, logger *zap.Logger) *ExtensibleRepository {
 	return &ExtensibleRepository{
-		Repository: NewRepository(db, logger),
+		Repository:     NewRepository(db, logger),
+		metadataLimits: tenant.DefaultMetadataLimits,
 	}
 }
 
+// SetPlanTemplates sets the plan templates whose defaults CreateExtended merges
+// into new tenants' metadata. Explicitly provided metadata wins.
+func (r *ExtensibleRepository) SetPlanTemplates(templates map[string]tenant.PlanTemplate) {
+	r.planTemplates = templates
+}
+
+// SetAllowDuplicateCustomDomains controls whether tenants may share a custom
+// domain. By default, writing a custom_domain that another tenant already uses
+// fails with a tenant.CustomDomainConflictError, and CreateMasterTablesExtended
+// adds a unique index to enforce it in the database as well.
+func (r *ExtensibleRepository) SetAllowDuplicateCustomDomains(allow bool) {
+	r.allowDuplicateDomains = allow
+}
+
+// SetMetadataLimits sets the caps on a tenant's metadata size that
+// CreateExtended, UpdateExtended, UpdateMetadata and UpdateMetadataField
+// enforce with a tenant.MetadataTooLargeError. The default is
+// tenant.DefaultMetadataLimits; tenant.MetadataLimits{} removes the caps.
+func (r *ExtensibleRepository) SetMetadataLimits(limits tenant.MetadataLimits) {
+	r.metadataLimits = limits
+}
+
+// AddMetadataHook adds a hook that transforms or rejects metadata values
+// before CreateExtended, UpdateExtended, UpdateMetadata and UpdateMetadataField
+// store them. Hooks run in the order they were added; a rejected value fails
+// the write with a tenant.InvalidMetadataError.
+func (r *ExtensibleRepository) AddMetadataHook(hook tenant.MetadataHook) {
+	r.metadataHooks = append(r.metadataHooks, hook)
+}
+
 // CreateExtended creates a new tenant with metadata
 func (r *ExtensibleRepository) CreateExtended(ctx context.Context, t *tenant.ExtensibleTenant) error {
 	query := `
@@ -35,12 +78,12 @@ func (r *ExtensibleRepository) CreateExtended(ctx context.Context, t *tenant.Ext
 	t.CreatedAt = now
 	t.UpdatedAt = now
 
-	// Initialize metadata if nil
-	if t.Metadata == nil {
-		t.Metadata = make(tenant.TenantMetadata)
+	domain, err := r.prepareNewMetadata(ctx, t)
+	if err != nil {
+		return err
 	}
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = r.db.ExecContext(ctx, query,
 		t.ID,
 		t.Name,
 		t.Subdomain,
@@ -53,6 +96,9 @@ func (r *ExtensibleRepository) CreateExtended(ctx context.Context, t *tenant.Ext
 	)
 
 	if err != nil {
+		if conflict := customDomainConflict(err, t.ID, domain); conflict != nil {
+			return conflict
+		}
 		r.logger.Error("Failed to create extended tenant",
 			zap.String("tenant_id", t.ID.String()),
 			zap.Error(err))
@@ -68,6 +114,91 @@ func (r *ExtensibleRepository) CreateExtended(ctx context.Context, t *tenant.Ext
 	return nil
 }
 
+// CreateExtendedBatch inserts tenants with metadata in one transaction, so
+// that either all of them are created or, if any insert fails, none are. Their
+// metadata is checked as CreateExtended checks it.
+func (r *ExtensibleRepository) CreateExtendedBatch(ctx context.Context, tenants []*tenant.ExtensibleTenant) error {
+	query := `
+		INSERT INTO public.tenants (id, name, subdomain, plan_type, status, schema_name, metadata, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	domains := make([]string, len(tenants))
+	for i, t := range tenants {
+		domain, err := r.prepareNewMetadata(ctx, t)
+		if err != nil {
+			return fmt.Errorf("tenant %s: %w", t.Subdomain, err)
+		}
+		domains[i] = domain
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare tenant insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for i, t := range tenants {
+		if _, err := stmt.ExecContext(ctx, t.ID, t.Name, t.Subdomain, t.PlanType, t.Status, t.SchemaName, t.Metadata, now, now); err != nil {
+			if conflict := customDomainConflict(err, t.ID, domains[i]); conflict != nil {
+				return conflict
+			}
+			r.logger.Error("Failed to create extended tenant in batch",
+				zap.String("tenant_id", t.ID.String()),
+				zap.String("subdomain", t.Subdomain),
+				zap.Error(err))
+			return fmt.Errorf("failed to create tenant %s: %w", t.Subdomain, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, t := range tenants {
+		t.CreatedAt = now
+		t.UpdatedAt = now
+	}
+
+	r.logger.Info("Created extended tenants in batch", zap.Int("count", len(tenants)))
+	return nil
+}
+
+// prepareNewMetadata merges plan defaults into a new tenant's metadata, runs
+// the metadata hooks and checks the limits and custom domain. It returns the
+// tenant's custom domain.
+func (r *ExtensibleRepository) prepareNewMetadata(ctx context.Context, t *tenant.ExtensibleTenant) (string, error) {
+	// Initialize metadata if nil
+	if t.Metadata == nil {
+		t.Metadata = make(tenant.TenantMetadata)
+	}
+
+	// Apply plan defaults without overriding explicit metadata
+	tenant.ApplyPlanTemplate(r.planTemplates, t)
+
+	metadata, err := r.metadataHooks.Apply(ctx, t.ID, t.Metadata)
+	if err != nil {
+		return "", err
+	}
+	if err := r.metadataLimits.Check(t.ID, metadata); err != nil {
+		return "", err
+	}
+	t.Metadata = metadata
+
+	domain, _ := t.Metadata.GetString(tenant.MetadataCustomDomain)
+	if err := r.checkCustomDomain(ctx, t.ID, domain); err != nil {
+		return "", err
+	}
+	return domain, nil
+}
+
 // GetExtendedByID retrieves an extended tenant by ID
 func (r *ExtensibleRepository) GetExtendedByID(ctx context.Context, id uuid.UUID) (*tenant.ExtensibleTenant, error) {
 	query := `
@@ -94,7 +225,7 @@ func (r *ExtensibleRepository) GetExtendedByID(ctx context.Context, id uuid.UUID
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("tenant not found: %w", err)
+			return nil, fmt.Errorf("%w: %w", tenant.ErrTenantNotFound, err)
 		}
 		r.logger.Error("Failed to get extended tenant by ID",
 			zap.String("tenant_id", id.String()),
@@ -131,7 +262,7 @@ func (r *ExtensibleRepository) GetExtendedBySubdomain(ctx context.Context, subdo
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("tenant not found: %w", err)
+			return nil, fmt.Errorf("%w: %w", tenant.ErrTenantNotFound, err)
 		}
 		r.logger.Error("Failed to get extended tenant by subdomain",
 			zap.String("subdomain", subdomain),
@@ -142,11 +273,12 @@ func (r *ExtensibleRepository) GetExtendedBySubdomain(ctx context.Context, subdo
 	return t, nil
 }
 
-// UpdateExtended updates an extended tenant
+// UpdateExtended updates an extended tenant. Stored limit overrides are kept;
+// SetTenantLimitOverride changes them.
 func (r *ExtensibleRepository) UpdateExtended(ctx context.Context, t *tenant.ExtensibleTenant) error {
 	query := `
 		UPDATE public.tenants 
-		SET name = $2, subdomain = $3, plan_type = $4, status = $5, metadata = $6, updated_at = $7
+		SET name = $2, subdomain = $3, plan_type = $4, status = $5, metadata = ` + keepingLimitOverrides("$6") + `, updated_at = $7
 		WHERE id = $1
 	`
 
@@ -157,6 +289,20 @@ func (r *ExtensibleRepository) UpdateExtended(ctx context.Context, t *tenant.Ext
 		t.Metadata = make(tenant.TenantMetadata)
 	}
 
+	metadata, err := r.metadataHooks.Apply(ctx, t.ID, t.Metadata)
+	if err != nil {
+		return err
+	}
+	if err := r.metadataLimits.Check(t.ID, metadata); err != nil {
+		return err
+	}
+	t.Metadata = metadata
+
+	domain, _ := t.Metadata.GetString(tenant.MetadataCustomDomain)
+	if err := r.checkCustomDomain(ctx, t.ID, domain); err != nil {
+		return err
+	}
+
 	result, err := r.db.ExecContext(ctx, query,
 		t.ID,
 		t.Name,
@@ -168,6 +314,9 @@ func (r *ExtensibleRepository) UpdateExtended(ctx context.Context, t *tenant.Ext
 	)
 
 	if err != nil {
+		if conflict := customDomainConflict(err, t.ID, domain); conflict != nil {
+			return conflict
+		}
 		r.logger.Error("Failed to update extended tenant",
 			zap.String("tenant_id", t.ID.String()),
 			zap.Error(err))
@@ -180,7 +329,7 @@ func (r *ExtensibleRepository) UpdateExtended(ctx context.Context, t *tenant.Ext
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("tenant not found")
+		return tenant.ErrTenantNotFound
 	}
 
 	r.logger.Info("Updated extended tenant",
@@ -193,12 +342,7 @@ func (r *ExtensibleRepository) UpdateExtended(ctx context.Context, t *tenant.Ext
 
 // ListExtended retrieves extended tenants with pagination
 func (r *ExtensibleRepository) ListExtended(ctx context.Context, page, perPage int) ([]*tenant.ExtensibleTenant, int, error) {
-	if page < 1 {
-		page = 1
-	}
-	if perPage < 1 || perPage > 100 {
-		perPage = 20
-	}
+	page, perPage = tenant.NormalizePagination(page, perPage)
 
 	offset := (page - 1) * perPage
 
@@ -228,6 +372,7 @@ func (r *ExtensibleRepository) ListExtended(ctx context.Context, page, perPage i
 	defer rows.Close()
 
 	var tenants []*tenant.ExtensibleTenant
+	var scanErrs []error
 	for rows.Next() {
 		t := &tenant.ExtensibleTenant{}
 		t.Metadata = make(tenant.TenantMetadata)
@@ -244,25 +389,60 @@ func (r *ExtensibleRepository) ListExtended(ctx context.Context, page, perPage i
 			&t.UpdatedAt,
 		)
 		if err != nil {
-			r.logger.Error("Failed to scan extended tenant", zap.Error(err))
+			if err := r.handleScanError(&scanErrs, err); err != nil {
+				return nil, 0, err
+			}
 			continue
 		}
 		tenants = append(tenants, t)
 	}
 
-	return tenants, total, nil
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating tenant rows: %w", err)
+	}
+
+	return tenants, total, scanResult(scanErrs)
 }
 
-// UpdateMetadata updates only the metadata field for a tenant
+// ListExtendedPaged lists tenants like ListExtended and returns them with pagination metadata.
+// When rows are skipped, the page is returned along with the ErrInvalidRows error.
+func (r *ExtensibleRepository) ListExtendedPaged(ctx context.Context, page, perPage int) (*tenant.Page[*tenant.ExtensibleTenant], error) {
+	tenants, total, err := r.ListExtended(ctx, page, perPage)
+	if err != nil && !errors.Is(err, ErrInvalidRows) {
+		return nil, err
+	}
+
+	page, perPage = tenant.NormalizePagination(page, perPage)
+	return tenant.NewPage(tenants, total, page, perPage), err
+}
+
+// UpdateMetadata updates only the metadata field for a tenant. Stored limit
+// overrides are kept; SetTenantLimitOverride changes them.
 func (r *ExtensibleRepository) UpdateMetadata(ctx context.Context, tenantID uuid.UUID, metadata tenant.TenantMetadata) error {
 	query := `
 		UPDATE public.tenants 
-		SET metadata = $2, updated_at = $3
+		SET metadata = ` + keepingLimitOverrides("$2") + `, updated_at = $3
 		WHERE id = $1
 	`
 
+	metadata, err := r.metadataHooks.Apply(ctx, tenantID, metadata)
+	if err != nil {
+		return err
+	}
+	if err := r.metadataLimits.Check(tenantID, metadata); err != nil {
+		return err
+	}
+
+	domain, _ := metadata.GetString(tenant.MetadataCustomDomain)
+	if err := r.checkCustomDomain(ctx, tenantID, domain); err != nil {
+		return err
+	}
+
 	result, err := r.db.ExecContext(ctx, query, tenantID, metadata, time.Now())
 	if err != nil {
+		if conflict := customDomainConflict(err, tenantID, domain); conflict != nil {
+			return conflict
+		}
 		r.logger.Error("Failed to update tenant metadata",
 			zap.String("tenant_id", tenantID.String()),
 			zap.Error(err))
@@ -275,7 +455,7 @@ func (r *ExtensibleRepository) UpdateMetadata(ctx context.Context, tenantID uuid
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("tenant not found")
+		return tenant.ErrTenantNotFound
 	}
 
 	return nil
@@ -289,7 +469,7 @@ func (r *ExtensibleRepository) GetMetadata(ctx context.Context, tenantID uuid.UU
 	err := r.db.QueryRowContext(ctx, query, tenantID).Scan(&metadata)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("tenant not found: %w", err)
+			return nil, fmt.Errorf("%w: %w", tenant.ErrTenantNotFound, err)
 		}
 		return nil, fmt.Errorf("failed to get tenant metadata: %w", err)
 	}
@@ -306,8 +486,31 @@ func (r *ExtensibleRepository) UpdateMetadataField(ctx context.Context, tenantID
 		WHERE id = $1
 	`
 
+	if key == tenant.MetadataLimitOverridesKey {
+		return &tenant.InvalidMetadataError{TenantID: tenantID, Key: key, Err: tenant.ErrReservedMetadataKey}
+	}
+
+	value, err := r.metadataHooks.ApplyField(ctx, tenantID, key, value)
+	if err != nil {
+		return err
+	}
+
+	var domain string
+	if key == tenant.MetadataCustomDomain && value != nil {
+		domain = fmt.Sprintf("%v", value)
+	}
+	if err := r.checkCustomDomain(ctx, tenantID, domain); err != nil {
+		return err
+	}
+	if err := r.checkMetadataFieldLimits(ctx, tenantID, key, value); err != nil {
+		return err
+	}
+
 	result, err := r.db.ExecContext(ctx, query, tenantID, key, value, time.Now())
 	if err != nil {
+		if conflict := customDomainConflict(err, tenantID, domain); conflict != nil {
+			return conflict
+		}
 		r.logger.Error("Failed to update tenant metadata field",
 			zap.String("tenant_id", tenantID.String()),
 			zap.String("key", key),
@@ -321,7 +524,7 @@ func (r *ExtensibleRepository) UpdateMetadataField(ctx context.Context, tenantID
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("tenant not found")
+		return tenant.ErrTenantNotFound
 	}
 
 	return nil
@@ -336,6 +539,10 @@ func (r *ExtensibleRepository) RemoveMetadataField(ctx context.Context, tenantID
 		WHERE id = $1
 	`
 
+	if key == tenant.MetadataLimitOverridesKey {
+		return &tenant.InvalidMetadataError{TenantID: tenantID, Key: key, Err: tenant.ErrReservedMetadataKey}
+	}
+
 	result, err := r.db.ExecContext(ctx, query, tenantID, key, time.Now())
 	if err != nil {
 		r.logger.Error("Failed to remove tenant metadata field",
@@ -351,12 +558,104 @@ func (r *ExtensibleRepository) RemoveMetadataField(ctx context.Context, tenantID
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("tenant not found")
+		return tenant.ErrTenantNotFound
 	}
 
 	return nil
 }
 
+// SetTenantLimitOverride stores value as the tenant's override for limitName
+// under the tenant.MetadataLimitOverridesKey key, leaving its other overrides
+// and metadata alone. A nil value removes the override.
+func (r *ExtensibleRepository) SetTenantLimitOverride(ctx context.Context, tenantID uuid.UUID, limitName string, value *tenant.LimitValue) error {
+	if limitName == "" {
+		return &tenant.ValidationError{Field: "limit_name", Message: "limit name is required"}
+	}
+
+	query := `
+		UPDATE public.tenants
+		SET metadata = COALESCE(metadata, '{}') #- ARRAY[$2::text, $3::text],
+		    updated_at = $4
+		WHERE id = $1
+	`
+	args := []interface{}{tenantID, tenant.MetadataLimitOverridesKey, limitName, time.Now()}
+
+	if value != nil {
+		overrides, err := r.GetTenantLimitOverrides(ctx, tenantID)
+		if err != nil {
+			return err
+		}
+		if overrides == nil {
+			overrides = make(tenant.FlexibleLimits)
+		}
+		overrides[limitName] = value
+		if err := r.checkMetadataFieldLimits(ctx, tenantID, tenant.MetadataLimitOverridesKey, overrides); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to serialize limit override: %w", err)
+		}
+
+		query = `
+			UPDATE public.tenants
+			SET metadata = jsonb_set(
+			        COALESCE(metadata, '{}'),
+			        ARRAY[$2::text],
+			        CASE WHEN jsonb_typeof(metadata->$2::text) = 'object' THEN metadata->$2::text ELSE '{}' END
+			            || jsonb_build_object($3::text, $5::jsonb)),
+			    updated_at = $4
+			WHERE id = $1
+		`
+		args = append(args, string(data))
+	}
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to set tenant limit override",
+			zap.String("tenant_id", tenantID.String()),
+			zap.String("limit", limitName),
+			zap.Error(err))
+		return fmt.Errorf("failed to set tenant limit override: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return tenant.ErrTenantNotFound
+	}
+
+	return nil
+}
+
+// GetTenantLimitOverrides returns the limit overrides stored in the tenant's
+// metadata, or nil if there are none
+func (r *ExtensibleRepository) GetTenantLimitOverrides(ctx context.Context, tenantID uuid.UUID) (tenant.FlexibleLimits, error) {
+	query := `SELECT metadata->$2::text FROM public.tenants WHERE id = $1`
+
+	var data []byte
+	err := r.db.QueryRowContext(ctx, query, tenantID, tenant.MetadataLimitOverridesKey).Scan(&data)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %w", tenant.ErrTenantNotFound, err)
+		}
+		return nil, fmt.Errorf("failed to get tenant limit overrides: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var overrides tenant.FlexibleLimits
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse tenant limit overrides: %w", err)
+	}
+	return overrides, nil
+}
+
 // FindByMetadata finds tenants by a specific metadata key-value pair
 func (r *ExtensibleRepository) FindByMetadata(ctx context.Context, key string, value interface{}) ([]*tenant.ExtensibleTenant, error) {
 	query := `
@@ -381,6 +680,7 @@ func (r *ExtensibleRepository) FindByMetadata(ctx context.Context, key string, v
 	defer rows.Close()
 
 	var tenants []*tenant.ExtensibleTenant
+	var scanErrs []error
 	for rows.Next() {
 		t := &tenant.ExtensibleTenant{}
 		t.Metadata = make(tenant.TenantMetadata)
@@ -397,13 +697,19 @@ func (r *ExtensibleRepository) FindByMetadata(ctx context.Context, key string, v
 			&t.UpdatedAt,
 		)
 		if err != nil {
-			r.logger.Error("Failed to scan tenant", zap.Error(err))
+			if err := r.handleScanError(&scanErrs, err); err != nil {
+				return nil, err
+			}
 			continue
 		}
 		tenants = append(tenants, t)
 	}
 
-	return tenants, nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tenant rows: %w", err)
+	}
+
+	return tenants, scanResult(scanErrs)
 }
 
 // FindByMetadataKeys finds tenants that have any of the specified metadata keys
@@ -440,6 +746,7 @@ func (r *ExtensibleRepository) FindByMetadataKeys(ctx context.Context, keys []st
 	defer rows.Close()
 
 	var tenants []*tenant.ExtensibleTenant
+	var scanErrs []error
 	for rows.Next() {
 		t := &tenant.ExtensibleTenant{}
 		t.Metadata = make(tenant.TenantMetadata)
@@ -456,13 +763,19 @@ func (r *ExtensibleRepository) FindByMetadataKeys(ctx context.Context, keys []st
 			&t.UpdatedAt,
 		)
 		if err != nil {
-			r.logger.Error("Failed to scan tenant", zap.Error(err))
+			if err := r.handleScanError(&scanErrs, err); err != nil {
+				return nil, err
+			}
 			continue
 		}
 		tenants = append(tenants, t)
 	}
 
-	return tenants, nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tenant rows: %w", err)
+	}
+
+	return tenants, scanResult(scanErrs)
 }
 
 // CreateMasterTablesExtended creates the master tables with metadata support
@@ -487,6 +800,11 @@ func (r *ExtensibleRepository) CreateMasterTablesExtended(ctx context.Context) e
 		"CREATE INDEX IF NOT EXISTS idx_tenants_metadata_gin ON public.tenants USING GIN (metadata)",
 		"CREATE INDEX IF NOT EXISTS idx_tenants_metadata_stripe_customer ON public.tenants USING BTREE ((metadata->>'stripe_customer_id')) WHERE metadata ? 'stripe_customer_id'",
 	}
+	if !r.allowDuplicateDomains {
+		indexes = append(indexes, fmt.Sprintf(
+			"CREATE UNIQUE INDEX IF NOT EXISTS %s ON public.tenants ((metadata->>'custom_domain')) WHERE metadata ? 'custom_domain' AND status != 'cancelled'",
+			customDomainIndex))
+	}
 
 	for _, indexSQL := range indexes {
 		if _, err := r.db.ExecContext(ctx, indexSQL); err != nil {
@@ -498,3 +816,58 @@ func (r *ExtensibleRepository) CreateMasterTablesExtended(ctx context.Context) e
 	r.logger.Info("Created master tables with metadata support")
 	return nil
 }
+
+// keepingLimitOverrides returns the SQL for the metadata in the JSONB
+// parameter param with the tenant's stored limit overrides in place of any it
+// has, so that metadata writes neither drop nor replace them
+func keepingLimitOverrides(param string) string {
+	return fmt.Sprintf(`(COALESCE(%[1]s::jsonb, '{}') - '%[2]s') || CASE WHEN metadata ? '%[2]s' THEN jsonb_build_object('%[2]s', metadata->'%[2]s') ELSE '{}'::jsonb END`,
+		param, tenant.MetadataLimitOverridesKey)
+}
+
+// checkMetadataFieldLimits checks the metadata limits against the tenant's
+// stored metadata with key set to value
+func (r *ExtensibleRepository) checkMetadataFieldLimits(ctx context.Context, tenantID uuid.UUID, key string, value interface{}) error {
+	if r.metadataLimits == (tenant.MetadataLimits{}) {
+		return nil
+	}
+
+	metadata, err := r.GetMetadata(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	metadata[key] = value
+	return r.metadataLimits.Check(tenantID, metadata)
+}
+
+// checkCustomDomain returns a tenant.CustomDomainConflictError if a tenant
+// other than tenantID already uses domain
+func (r *ExtensibleRepository) checkCustomDomain(ctx context.Context, tenantID uuid.UUID, domain string) error {
+	if r.allowDuplicateDomains || domain == "" {
+		return nil
+	}
+
+	owners, err := r.FindByMetadata(ctx, tenant.MetadataCustomDomain, domain)
+	if err != nil {
+		return fmt.Errorf("failed to check custom domain: %w", err)
+	}
+
+	for _, owner := range owners {
+		if owner.ID != tenantID {
+			return &tenant.CustomDomainConflictError{Domain: domain, TenantID: tenantID, OwnerID: owner.ID}
+		}
+	}
+
+	return nil
+}
+
+// customDomainConflict converts a violation of the custom domain unique index,
+// e.g. from a concurrent write that passed checkCustomDomain, into a
+// tenant.CustomDomainConflictError. It returns nil for any other error.
+func customDomainConflict(err error, tenantID uuid.UUID, domain string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == customDomainIndex {
+		return &tenant.CustomDomainConflictError{Domain: domain, TenantID: tenantID}
+	}
+	return nil
+}
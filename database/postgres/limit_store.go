@@ -0,0 +1,214 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"go.uber.org/zap"
+)
+
+// LimitStore implements tenant.SchemaStore and tenant.PlanLimitStore for PostgreSQL.
+// Removals are stored as NULL rows so they override the static configuration on reload.
+type LimitStore struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+// Ensure LimitStore implements the store interfaces
+var (
+	_ tenant.SchemaStore    = (*LimitStore)(nil)
+	_ tenant.PlanLimitStore = (*LimitStore)(nil)
+)
+
+// NewLimitStore creates a new PostgreSQL limit store
+func NewLimitStore(db *sql.DB, logger *zap.Logger) *LimitStore {
+	return &LimitStore{
+		db:     db,
+		logger: logger.Named("postgres_limit_store"),
+	}
+}
+
+// CreateTables creates the tables used to persist limit definitions and plan limits
+func (s *LimitStore) CreateTables(ctx context.Context) error {
+	tables := []string{
+		`CREATE TABLE IF NOT EXISTS public.tenant_limit_definitions (
+			name VARCHAR(255) PRIMARY KEY,
+			definition JSONB,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		`CREATE TABLE IF NOT EXISTS public.tenant_plan_limits (
+			plan_type VARCHAR(50) NOT NULL,
+			limit_name VARCHAR(255) NOT NULL,
+			value JSONB,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (plan_type, limit_name)
+		)`,
+	}
+
+	for _, tableSQL := range tables {
+		if _, err := s.db.ExecContext(ctx, tableSQL); err != nil {
+			return fmt.Errorf("failed to create limit store table: %w", err)
+		}
+	}
+
+	s.logger.Info("Created limit store tables")
+	return nil
+}
+
+// LoadDefinitions returns all stored limit definitions
+func (s *LimitStore) LoadDefinitions(ctx context.Context) (map[string]*tenant.LimitDefinition, error) {
+	query := `SELECT name, definition FROM public.tenant_limit_definitions`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load limit definitions: %w", err)
+	}
+	defer rows.Close()
+
+	definitions := make(map[string]*tenant.LimitDefinition)
+	for rows.Next() {
+		var name string
+		var raw []byte
+		if err := rows.Scan(&name, &raw); err != nil {
+			return nil, fmt.Errorf("failed to scan limit definition: %w", err)
+		}
+
+		if raw == nil {
+			definitions[name] = nil
+			continue
+		}
+
+		def := &tenant.LimitDefinition{}
+		if err := json.Unmarshal(raw, def); err != nil {
+			return nil, fmt.Errorf("failed to decode limit definition %s: %w", name, err)
+		}
+		definitions[name] = def
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating limit definitions: %w", err)
+	}
+
+	return definitions, nil
+}
+
+// SaveDefinition inserts or replaces a limit definition
+func (s *LimitStore) SaveDefinition(ctx context.Context, def *tenant.LimitDefinition) error {
+	raw, err := json.Marshal(def)
+	if err != nil {
+		return fmt.Errorf("failed to encode limit definition %s: %w", def.Name, err)
+	}
+
+	return s.upsertDefinition(ctx, def.Name, raw)
+}
+
+// DeleteDefinition records that a limit definition was removed
+func (s *LimitStore) DeleteDefinition(ctx context.Context, name string) error {
+	return s.upsertDefinition(ctx, name, nil)
+}
+
+// LoadPlanLimits returns all stored plan limits
+func (s *LimitStore) LoadPlanLimits(ctx context.Context) (map[string]tenant.FlexibleLimits, error) {
+	query := `SELECT plan_type, limit_name, value FROM public.tenant_plan_limits`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plan limits: %w", err)
+	}
+	defer rows.Close()
+
+	planLimits := make(map[string]tenant.FlexibleLimits)
+	for rows.Next() {
+		var planType, limitName string
+		var raw []byte
+		if err := rows.Scan(&planType, &limitName, &raw); err != nil {
+			return nil, fmt.Errorf("failed to scan plan limit: %w", err)
+		}
+
+		if planLimits[planType] == nil {
+			planLimits[planType] = make(tenant.FlexibleLimits)
+		}
+
+		if raw == nil {
+			planLimits[planType][limitName] = nil
+			continue
+		}
+
+		value := &tenant.LimitValue{}
+		if err := json.Unmarshal(raw, value); err != nil {
+			return nil, fmt.Errorf("failed to decode limit %s for plan %s: %w", limitName, planType, err)
+		}
+		planLimits[planType][limitName] = value
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating plan limits: %w", err)
+	}
+
+	return planLimits, nil
+}
+
+// SavePlanLimit inserts or replaces a plan limit
+func (s *LimitStore) SavePlanLimit(ctx context.Context, planType, limitName string, value *tenant.LimitValue) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode limit %s for plan %s: %w", limitName, planType, err)
+	}
+
+	return s.upsertPlanLimit(ctx, planType, limitName, raw)
+}
+
+// DeletePlanLimit records that a limit was removed from a plan
+func (s *LimitStore) DeletePlanLimit(ctx context.Context, planType, limitName string) error {
+	return s.upsertPlanLimit(ctx, planType, limitName, nil)
+}
+
+// upsertDefinition writes a definition row; a nil payload marks it as removed
+func (s *LimitStore) upsertDefinition(ctx context.Context, name string, raw []byte) error {
+	query := `
+		INSERT INTO public.tenant_limit_definitions (name, definition, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET definition = EXCLUDED.definition, updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := s.db.ExecContext(ctx, query, name, nullableJSON(raw), time.Now()); err != nil {
+		s.logger.Error("Failed to save limit definition",
+			zap.String("limit", name),
+			zap.Error(err))
+		return fmt.Errorf("failed to save limit definition: %w", err)
+	}
+
+	return nil
+}
+
+// upsertPlanLimit writes a plan limit row; a nil payload marks it as removed
+func (s *LimitStore) upsertPlanLimit(ctx context.Context, planType, limitName string, raw []byte) error {
+	query := `
+		INSERT INTO public.tenant_plan_limits (plan_type, limit_name, value, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (plan_type, limit_name) DO UPDATE SET value = EXCLUDED.value, updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := s.db.ExecContext(ctx, query, planType, limitName, nullableJSON(raw), time.Now()); err != nil {
+		s.logger.Error("Failed to save plan limit",
+			zap.String("plan", planType),
+			zap.String("limit", limitName),
+			zap.Error(err))
+		return fmt.Errorf("failed to save plan limit: %w", err)
+	}
+
+	return nil
+}
+
+// nullableJSON converts a JSON payload into a value that stores NULL when empty
+func nullableJSON(raw []byte) interface{} {
+	if raw == nil {
+		return nil
+	}
+	return string(raw)
+}
@@ -9,16 +9,35 @@ import (
 
 	"github.com/alexalmadav/go-multitenant/tenant"
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
+// ErrInvalidRows is returned, wrapping the individual scan errors, by list
+// queries that skipped rows which could not be scanned
+var ErrInvalidRows = errors.New("some rows could not be scanned")
+
 // Repository implements tenant.Repository for PostgreSQL
 type Repository struct {
-	db     *sql.DB
-	logger *zap.Logger
+	db              *sql.DB
+	logger          *zap.Logger
+	skipInvalidRows bool
 }
 
+// Ensure Repository records plan history, idempotency keys and labels, checks
+// existence without loading rows, can warm the tenant cache in one query and
+// creates batches of tenants in a transaction
+var (
+	_ tenant.PlanHistoryRepository = (*Repository)(nil)
+	_ tenant.IdempotencyRepository = (*Repository)(nil)
+	_ tenant.LabelRepository       = (*Repository)(nil)
+	_ tenant.ExistenceRepository   = (*Repository)(nil)
+	_ tenant.ActiveTenantLister    = (*Repository)(nil)
+	_ tenant.BatchRepository       = (*Repository)(nil)
+	_ tenant.PurgeRepository       = (*Repository)(nil)
+	_ tenant.CancelledTenantLister = (*Repository)(nil)
+)
+
 // NewRepository creates a new PostgreSQL repository
 func NewRepository(db *sql.DB, logger *zap.Logger) *Repository {
 	return &Repository{
@@ -27,6 +46,14 @@ func NewRepository(db *sql.DB, logger *zap.Logger) *Repository {
 	}
 }
 
+// SetSkipInvalidRows controls how list queries handle rows that fail to scan.
+// By default a list query fails on the first bad row. When skip is true, bad
+// rows are left out and the valid rows are returned together with an error
+// wrapping ErrInvalidRows and every scan error.
+func (r *Repository) SetSkipInvalidRows(skip bool) {
+	r.skipInvalidRows = skip
+}
+
 // Create creates a new tenant
 func (r *Repository) Create(ctx context.Context, t *tenant.Tenant) error {
 	query := `
@@ -64,6 +91,50 @@ func (r *Repository) Create(ctx context.Context, t *tenant.Tenant) error {
 	return nil
 }
 
+// CreateBatch inserts tenants in one transaction, so that either all of them
+// are created or, if any insert fails, none are
+func (r *Repository) CreateBatch(ctx context.Context, tenants []*tenant.Tenant) error {
+	query := `
+		INSERT INTO public.tenants (id, name, subdomain, plan_type, status, schema_name, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare tenant insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, t := range tenants {
+		if _, err := stmt.ExecContext(ctx, t.ID, t.Name, t.Subdomain, t.PlanType, t.Status, t.SchemaName, now, now); err != nil {
+			r.logger.Error("Failed to create tenant in batch",
+				zap.String("tenant_id", t.ID.String()),
+				zap.String("subdomain", t.Subdomain),
+				zap.Error(err))
+			return fmt.Errorf("failed to create tenant %s: %w", t.Subdomain, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, t := range tenants {
+		t.CreatedAt = now
+		t.UpdatedAt = now
+	}
+
+	r.logger.Info("Created tenants in batch", zap.Int("count", len(tenants)))
+	return nil
+}
+
 // GetByID retrieves a tenant by ID
 func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*tenant.Tenant, error) {
 	query := `
@@ -86,7 +157,7 @@ func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*tenant.Tenant,
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("tenant not found: %w", err)
+			return nil, fmt.Errorf("%w: %w", tenant.ErrTenantNotFound, err)
 		}
 		r.logger.Error("Failed to get tenant by ID",
 			zap.String("tenant_id", id.String()),
@@ -119,7 +190,7 @@ func (r *Repository) GetBySubdomain(ctx context.Context, subdomain string) (*ten
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("tenant not found: %w", err)
+			return nil, fmt.Errorf("%w: %w", tenant.ErrTenantNotFound, err)
 		}
 		r.logger.Error("Failed to get tenant by subdomain",
 			zap.String("subdomain", subdomain),
@@ -130,6 +201,26 @@ func (r *Repository) GetBySubdomain(ctx context.Context, subdomain string) (*ten
 	return t, nil
 }
 
+// ExistsByID reports whether a tenant with the given ID exists
+func (r *Repository) ExistsByID(ctx context.Context, id uuid.UUID) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM public.tenants WHERE id = $1)`
+	if err := r.db.QueryRowContext(ctx, query, id).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check tenant existence: %w", err)
+	}
+	return exists, nil
+}
+
+// ExistsBySubdomain reports whether a tenant uses the given subdomain
+func (r *Repository) ExistsBySubdomain(ctx context.Context, subdomain string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM public.tenants WHERE subdomain = $1)`
+	if err := r.db.QueryRowContext(ctx, query, subdomain).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check subdomain existence: %w", err)
+	}
+	return exists, nil
+}
+
 // Update updates a tenant
 func (r *Repository) Update(ctx context.Context, t *tenant.Tenant) error {
 	query := `
@@ -162,7 +253,7 @@ func (r *Repository) Update(ctx context.Context, t *tenant.Tenant) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("tenant not found")
+		return tenant.ErrTenantNotFound
 	}
 
 	r.logger.Info("Updated tenant",
@@ -172,6 +263,29 @@ func (r *Repository) Update(ctx context.Context, t *tenant.Tenant) error {
 	return nil
 }
 
+// UpdateSchemaName records schemaName as the name of the tenant's schema,
+// e.g. after tenant.Manager.RenameTenantSchema renamed it
+func (r *Repository) UpdateSchemaName(ctx context.Context, id uuid.UUID, schemaName string) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE public.tenants SET schema_name = $2, updated_at = $3 WHERE id = $1`,
+		id, schemaName, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update tenant schema name: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return tenant.ErrTenantNotFound
+	}
+
+	return nil
+}
+
 // Delete soft deletes a tenant (sets status to cancelled)
 func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `
@@ -194,7 +308,7 @@ func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("tenant not found")
+		return tenant.ErrTenantNotFound
 	}
 
 	r.logger.Info("Deleted tenant",
@@ -203,15 +317,37 @@ func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-// List retrieves tenants with pagination
-func (r *Repository) List(ctx context.Context, page, perPage int) ([]*tenant.Tenant, int, error) {
-	if page < 1 {
-		page = 1
+// Purge removes a cancelled tenant's row, e.g. to undo a failed onboarding
+// after Delete. Unlike Delete it leaves nothing to restore. Tenants that are
+// not cancelled are kept and reported as tenant.ErrTenantNotFound.
+func (r *Repository) Purge(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM public.tenants WHERE id = $1 AND status = $2`, id, tenant.StatusCancelled)
+	if err != nil {
+		r.logger.Error("Failed to purge tenant",
+			zap.String("tenant_id", id.String()),
+			zap.Error(err))
+		return fmt.Errorf("failed to purge tenant: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
-	if perPage < 1 || perPage > 100 {
-		perPage = 20
+
+	if rowsAffected == 0 {
+		return tenant.ErrTenantNotFound
 	}
 
+	r.logger.Warn("Purged tenant",
+		zap.String("tenant_id", id.String()))
+
+	return nil
+}
+
+// List retrieves tenants with pagination
+func (r *Repository) List(ctx context.Context, page, perPage int) ([]*tenant.Tenant, int, error) {
+	page, perPage = tenant.NormalizePagination(page, perPage)
+
 	offset := (page - 1) * perPage
 
 	// Get total count
@@ -239,6 +375,113 @@ func (r *Repository) List(ctx context.Context, page, perPage int) ([]*tenant.Ten
 	defer rows.Close()
 
 	var tenants []*tenant.Tenant
+	var scanErrs []error
+	for rows.Next() {
+		t := &tenant.Tenant{}
+		err := rows.Scan(
+			&t.ID,
+			&t.Name,
+			&t.Subdomain,
+			&t.PlanType,
+			&t.Status,
+			&t.SchemaName,
+			&t.CreatedAt,
+			&t.UpdatedAt,
+		)
+		if err != nil {
+			if err := r.handleScanError(&scanErrs, err); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+		tenants = append(tenants, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating tenant rows: %w", err)
+	}
+
+	return tenants, total, scanResult(scanErrs)
+}
+
+// ListPaged lists tenants like List and returns them with pagination metadata.
+// When rows are skipped, the page is returned along with the ErrInvalidRows error.
+func (r *Repository) ListPaged(ctx context.Context, page, perPage int) (*tenant.Page[*tenant.Tenant], error) {
+	tenants, total, err := r.List(ctx, page, perPage)
+	if err != nil && !errors.Is(err, ErrInvalidRows) {
+		return nil, err
+	}
+
+	page, perPage = tenant.NormalizePagination(page, perPage)
+	return tenant.NewPage(tenants, total, page, perPage), err
+}
+
+// ListActive returns up to limit active tenants, most recently updated first
+func (r *Repository) ListActive(ctx context.Context, limit int) ([]*tenant.Tenant, error) {
+	query := `
+		SELECT id, name, subdomain, plan_type, status, schema_name, created_at, updated_at
+		FROM public.tenants
+		WHERE status = $1
+		ORDER BY updated_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenant.StatusActive, limit)
+	if err != nil {
+		r.logger.Error("Failed to list active tenants", zap.Error(err))
+		return nil, fmt.Errorf("failed to list active tenants: %w", err)
+	}
+	defer rows.Close()
+
+	var tenants []*tenant.Tenant
+	var scanErrs []error
+	for rows.Next() {
+		t := &tenant.Tenant{}
+		err := rows.Scan(
+			&t.ID,
+			&t.Name,
+			&t.Subdomain,
+			&t.PlanType,
+			&t.Status,
+			&t.SchemaName,
+			&t.CreatedAt,
+			&t.UpdatedAt,
+		)
+		if err != nil {
+			if err := r.handleScanError(&scanErrs, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		tenants = append(tenants, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tenant rows: %w", err)
+	}
+
+	return tenants, scanResult(scanErrs)
+}
+
+// ListCancelled returns the tenants cancelled before the given time, oldest
+// first. Delete sets updated_at when it cancels a tenant.
+func (r *Repository) ListCancelled(ctx context.Context, before time.Time) ([]*tenant.Tenant, error) {
+	query := `
+		SELECT id, name, subdomain, plan_type, status, schema_name, created_at, updated_at
+		FROM public.tenants
+		WHERE status = $1 AND updated_at < $2
+		ORDER BY updated_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenant.StatusCancelled, before)
+	if err != nil {
+		r.logger.Error("Failed to list cancelled tenants", zap.Error(err))
+		return nil, fmt.Errorf("failed to list cancelled tenants: %w", err)
+	}
+	defer rows.Close()
+
+	var tenants []*tenant.Tenant
+	var scanErrs []error
 	for rows.Next() {
 		t := &tenant.Tenant{}
 		err := rows.Scan(
@@ -252,13 +495,19 @@ func (r *Repository) List(ctx context.Context, page, perPage int) ([]*tenant.Ten
 			&t.UpdatedAt,
 		)
 		if err != nil {
-			r.logger.Error("Failed to scan tenant", zap.Error(err))
+			if err := r.handleScanError(&scanErrs, err); err != nil {
+				return nil, err
+			}
 			continue
 		}
 		tenants = append(tenants, t)
 	}
 
-	return tenants, total, nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tenant rows: %w", err)
+	}
+
+	return tenants, scanResult(scanErrs)
 }
 
 // GetStats retrieves usage statistics for a tenant
@@ -299,6 +548,251 @@ func (r *Repository) GetStats(ctx context.Context, tenantID uuid.UUID) (*tenant.
 	return stats, nil
 }
 
+// ChangePlan updates the tenant's plan and records the change in
+// public.tenant_plan_history within a single transaction
+func (r *Repository) ChangePlan(ctx context.Context, change *tenant.PlanChange) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the tenant row so concurrent changes are recorded in order
+	err = tx.QueryRowContext(ctx,
+		`SELECT plan_type FROM public.tenants WHERE id = $1 FOR UPDATE`,
+		change.TenantID,
+	).Scan(&change.OldPlan)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %w", tenant.ErrTenantNotFound, err)
+		}
+		return fmt.Errorf("failed to get tenant plan: %w", err)
+	}
+
+	if change.OldPlan == change.NewPlan {
+		return nil
+	}
+
+	change.ChangedAt = time.Now()
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE public.tenants SET plan_type = $2, updated_at = $3 WHERE id = $1`,
+		change.TenantID, change.NewPlan, change.ChangedAt,
+	); err != nil {
+		return fmt.Errorf("failed to update tenant plan: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO public.tenant_plan_history (tenant_id, old_plan, new_plan, actor, changed_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, change.TenantID, change.OldPlan, change.NewPlan, change.Actor, change.ChangedAt).Scan(&change.ID)
+	if err != nil {
+		return fmt.Errorf("failed to record plan change: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to change tenant plan",
+			zap.String("tenant_id", change.TenantID.String()),
+			zap.Error(err))
+		return fmt.Errorf("failed to commit plan change: %w", err)
+	}
+
+	r.logger.Info("Changed tenant plan",
+		zap.String("tenant_id", change.TenantID.String()),
+		zap.String("old_plan", change.OldPlan),
+		zap.String("new_plan", change.NewPlan))
+
+	return nil
+}
+
+// GetPlanHistory returns the tenant's plan changes, oldest first
+func (r *Repository) GetPlanHistory(ctx context.Context, tenantID uuid.UUID) ([]*tenant.PlanChange, error) {
+	query := `
+		SELECT id, tenant_id, old_plan, new_plan, actor, changed_at
+		FROM public.tenant_plan_history
+		WHERE tenant_id = $1
+		ORDER BY changed_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []*tenant.PlanChange
+	for rows.Next() {
+		change := &tenant.PlanChange{}
+		if err := rows.Scan(
+			&change.ID,
+			&change.TenantID,
+			&change.OldPlan,
+			&change.NewPlan,
+			&change.Actor,
+			&change.ChangedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan plan change: %w", err)
+		}
+		history = append(history, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating plan history: %w", err)
+	}
+
+	return history, nil
+}
+
+// ClaimIdempotencyKey reserves key for tenantID until expiresAt, taking over
+// the key if its previous claim has expired
+func (r *Repository) ClaimIdempotencyKey(ctx context.Context, key string, tenantID uuid.UUID, expiresAt time.Time) (uuid.UUID, bool, error) {
+	query := `
+		INSERT INTO public.idempotency_keys (key, tenant_id, created_at, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE
+		SET tenant_id = EXCLUDED.tenant_id, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+		WHERE public.idempotency_keys.expires_at <= EXCLUDED.created_at
+		RETURNING tenant_id
+	`
+
+	var claimedID uuid.UUID
+	err := r.db.QueryRowContext(ctx, query, key, tenantID, time.Now(), expiresAt).Scan(&claimedID)
+	if err == nil {
+		return claimedID, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	// The key has an unexpired claim
+	err = r.db.QueryRowContext(ctx,
+		`SELECT tenant_id FROM public.idempotency_keys WHERE key = $1`,
+		key,
+	).Scan(&claimedID)
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	return claimedID, false, nil
+}
+
+// ReleaseIdempotencyKey removes the claim on key
+func (r *Repository) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM public.idempotency_keys WHERE key = $1`, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// AddLabel attaches label to the tenant, doing nothing if it is already attached
+func (r *Repository) AddLabel(ctx context.Context, tenantID uuid.UUID, label string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO public.tenant_labels (tenant_id, label)
+		VALUES ($1, $2)
+		ON CONFLICT (tenant_id, label) DO NOTHING
+	`, tenantID, label)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return fmt.Errorf("%w: %w", tenant.ErrTenantNotFound, err)
+		}
+		return fmt.Errorf("failed to add tenant label: %w", err)
+	}
+	return nil
+}
+
+// RemoveLabel detaches label from the tenant
+func (r *Repository) RemoveLabel(ctx context.Context, tenantID uuid.UUID, label string) error {
+	if _, err := r.db.ExecContext(ctx,
+		`DELETE FROM public.tenant_labels WHERE tenant_id = $1 AND label = $2`,
+		tenantID, label,
+	); err != nil {
+		return fmt.Errorf("failed to remove tenant label: %w", err)
+	}
+	return nil
+}
+
+// GetLabels returns the tenant's labels, sorted. A missing tenant_labels
+// table is reported as tenant.ErrLabelsUnavailable.
+func (r *Repository) GetLabels(ctx context.Context, tenantID uuid.UUID) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT label FROM public.tenant_labels WHERE tenant_id = $1 ORDER BY label`,
+		tenantID,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "42P01" {
+			return nil, fmt.Errorf("%w: %w", tenant.ErrLabelsUnavailable, err)
+		}
+		return nil, fmt.Errorf("failed to get tenant labels: %w", err)
+	}
+	defer rows.Close()
+
+	var labels []string
+	for rows.Next() {
+		var label string
+		if err := rows.Scan(&label); err != nil {
+			return nil, fmt.Errorf("failed to scan tenant label: %w", err)
+		}
+		labels = append(labels, label)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tenant labels: %w", err)
+	}
+
+	return labels, nil
+}
+
+// ListByLabel returns the tenants carrying label, excluding cancelled tenants,
+// most recently created first
+func (r *Repository) ListByLabel(ctx context.Context, label string) ([]*tenant.Tenant, error) {
+	query := `
+		SELECT t.id, t.name, t.subdomain, t.plan_type, t.status, t.schema_name, t.created_at, t.updated_at
+		FROM public.tenant_labels l
+		JOIN public.tenants t ON t.id = l.tenant_id
+		WHERE l.label = $1 AND t.status != $2
+		ORDER BY t.created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, label, tenant.StatusCancelled)
+	if err != nil {
+		r.logger.Error("Failed to list tenants by label", zap.Error(err))
+		return nil, fmt.Errorf("failed to list tenants by label: %w", err)
+	}
+	defer rows.Close()
+
+	var tenants []*tenant.Tenant
+	var scanErrs []error
+	for rows.Next() {
+		t := &tenant.Tenant{}
+		err := rows.Scan(
+			&t.ID,
+			&t.Name,
+			&t.Subdomain,
+			&t.PlanType,
+			&t.Status,
+			&t.SchemaName,
+			&t.CreatedAt,
+			&t.UpdatedAt,
+		)
+		if err != nil {
+			if err := r.handleScanError(&scanErrs, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		tenants = append(tenants, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tenant rows: %w", err)
+	}
+
+	return tenants, scanResult(scanErrs)
+}
+
 // CreateMasterTables creates the master tables needed for tenant management
 func (r *Repository) CreateMasterTables(ctx context.Context) error {
 	tables := []string{
@@ -326,6 +820,31 @@ func (r *Repository) CreateMasterTables(ctx context.Context) error {
 			FOREIGN KEY (tenant_id) REFERENCES public.tenants(id) ON DELETE CASCADE,
 			UNIQUE(tenant_id, version)
 		)`,
+
+		`CREATE TABLE IF NOT EXISTS public.tenant_plan_history (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			tenant_id UUID NOT NULL,
+			old_plan VARCHAR(50) NOT NULL,
+			new_plan VARCHAR(50) NOT NULL,
+			actor VARCHAR(255) NOT NULL DEFAULT '',
+			changed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (tenant_id) REFERENCES public.tenants(id) ON DELETE CASCADE
+		)`,
+
+		`CREATE TABLE IF NOT EXISTS public.idempotency_keys (
+			key VARCHAR(255) PRIMARY KEY,
+			tenant_id UUID NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+		)`,
+
+		`CREATE TABLE IF NOT EXISTS public.tenant_labels (
+			tenant_id UUID NOT NULL,
+			label VARCHAR(100) NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (tenant_id, label),
+			FOREIGN KEY (tenant_id) REFERENCES public.tenants(id) ON DELETE CASCADE
+		)`,
 	}
 
 	indexes := []string{
@@ -333,6 +852,8 @@ func (r *Repository) CreateMasterTables(ctx context.Context) error {
 		"CREATE INDEX IF NOT EXISTS idx_tenants_status ON public.tenants(status)",
 		"CREATE INDEX IF NOT EXISTS idx_tenant_migrations_tenant_id ON public.tenant_migrations(tenant_id)",
 		"CREATE INDEX IF NOT EXISTS idx_tenant_migrations_version ON public.tenant_migrations(version)",
+		"CREATE INDEX IF NOT EXISTS idx_tenant_plan_history_tenant_id ON public.tenant_plan_history(tenant_id, changed_at)",
+		"CREATE INDEX IF NOT EXISTS idx_tenant_labels_label ON public.tenant_labels(label)",
 	}
 
 	// Create tables
@@ -352,3 +873,23 @@ func (r *Repository) CreateMasterTables(ctx context.Context) error {
 	r.logger.Info("Created master tables")
 	return nil
 }
+
+// handleScanError records a row that failed to scan in a list query. It returns
+// a non-nil error when the query should fail instead of skipping the row.
+func (r *Repository) handleScanError(scanErrs *[]error, err error) error {
+	if !r.skipInvalidRows {
+		return fmt.Errorf("failed to scan tenant: %w", err)
+	}
+
+	r.logger.Warn("Skipping tenant row that failed to scan", zap.Error(err))
+	*scanErrs = append(*scanErrs, err)
+	return nil
+}
+
+// scanResult returns the error to report for rows skipped by a list query
+func scanResult(scanErrs []error) error {
+	if len(scanErrs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w (%d rows): %w", ErrInvalidRows, len(scanErrs), errors.Join(scanErrs...))
+}
@@ -0,0 +1,555 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRepository_List_ScanErrorFailsFast(t *testing.T) {
+	db := newTenantRowsDB(validTenantRow(), invalidTenantRow(), validTenantRow())
+	defer db.Close()
+
+	repo := NewRepository(db, zaptest.NewLogger(t))
+
+	tenants, _, err := repo.List(context.Background(), 1, 20)
+	if err == nil {
+		t.Fatal("List() should return the scan error")
+	}
+	if !strings.Contains(err.Error(), "failed to scan tenant") {
+		t.Errorf("List() error = %v, want scan context", err)
+	}
+	if tenants != nil {
+		t.Errorf("List() returned %d tenants, want none on failure", len(tenants))
+	}
+}
+
+func TestRepository_List_SkipInvalidRows(t *testing.T) {
+	db := newTenantRowsDB(validTenantRow(), invalidTenantRow(), validTenantRow())
+	defer db.Close()
+
+	repo := NewRepository(db, zaptest.NewLogger(t))
+	repo.SetSkipInvalidRows(true)
+
+	tenants, total, err := repo.List(context.Background(), 1, 20)
+	if !errors.Is(err, ErrInvalidRows) {
+		t.Fatalf("List() error = %v, want ErrInvalidRows", err)
+	}
+	if len(tenants) != 2 {
+		t.Errorf("List() returned %d tenants, want 2 valid rows", len(tenants))
+	}
+	if total != 3 {
+		t.Errorf("List() total = %d, want 3", total)
+	}
+}
+
+func TestRepository_List_NoScanErrors(t *testing.T) {
+	db := newTenantRowsDB(validTenantRow(), validTenantRow())
+	defer db.Close()
+
+	repo := NewRepository(db, zaptest.NewLogger(t))
+
+	tenants, _, err := repo.List(context.Background(), 1, 20)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(tenants) != 2 {
+		t.Errorf("List() returned %d tenants, want 2", len(tenants))
+	}
+}
+
+func TestExtensibleRepository_FindByMetadata_ScanError(t *testing.T) {
+	valid := append(validTenantRow()[:6], []byte(`{"plan":"pro"}`), time.Now(), time.Now())
+	invalid := append(invalidTenantRow()[:6], []byte(`{"plan":"pro"}`), time.Now(), time.Now())
+
+	db := newTenantRowsDB(valid, invalid)
+	defer db.Close()
+
+	repo := NewExtensibleRepository(db, zaptest.NewLogger(t))
+
+	if _, err := repo.FindByMetadata(context.Background(), "plan", "pro"); err == nil {
+		t.Error("FindByMetadata() should return the scan error")
+	}
+
+	repo.SetSkipInvalidRows(true)
+	tenants, err := repo.FindByMetadata(context.Background(), "plan", "pro")
+	if !errors.Is(err, ErrInvalidRows) {
+		t.Fatalf("FindByMetadata() error = %v, want ErrInvalidRows", err)
+	}
+	if len(tenants) != 1 {
+		t.Errorf("FindByMetadata() returned %d tenants, want 1", len(tenants))
+	}
+}
+
+func TestRepository_ListPaged(t *testing.T) {
+	db := newTenantRowsDB(validTenantRow(), validTenantRow(), validTenantRow())
+	defer db.Close()
+
+	repo := NewRepository(db, zaptest.NewLogger(t))
+
+	// Out-of-range parameters are reported as the values actually used
+	page, err := repo.ListPaged(context.Background(), 0, 0)
+	if err != nil {
+		t.Fatalf("ListPaged() error = %v", err)
+	}
+	if page.Page != 1 || page.PerPage != 20 {
+		t.Errorf("ListPaged() page = %d, perPage = %d, want 1 and 20", page.Page, page.PerPage)
+	}
+	if page.Total != 3 || len(page.Items) != 3 || page.TotalPages != 1 || page.HasNext {
+		t.Errorf("ListPaged() = %+v, want a single page of 3", page)
+	}
+}
+
+func TestExtensibleRepository_ListExtendedPaged_SkipInvalidRows(t *testing.T) {
+	valid := append(validTenantRow()[:6], []byte(`{}`), time.Now(), time.Now())
+	invalid := append(invalidTenantRow()[:6], []byte(`{}`), time.Now(), time.Now())
+
+	db := newTenantRowsDB(valid, invalid, valid)
+	defer db.Close()
+
+	repo := NewExtensibleRepository(db, zaptest.NewLogger(t))
+
+	if _, err := repo.ListExtendedPaged(context.Background(), 1, 2); err == nil {
+		t.Error("ListExtendedPaged() should return the scan error")
+	}
+
+	// Skipped rows still produce a page alongside ErrInvalidRows
+	repo.SetSkipInvalidRows(true)
+	page, err := repo.ListExtendedPaged(context.Background(), 1, 2)
+	if !errors.Is(err, ErrInvalidRows) {
+		t.Fatalf("ListExtendedPaged() error = %v, want ErrInvalidRows", err)
+	}
+	if page == nil || len(page.Items) != 2 {
+		t.Fatalf("ListExtendedPaged() = %+v, want 2 valid items", page)
+	}
+	if page.Total != 3 || page.TotalPages != 2 || !page.HasNext {
+		t.Errorf("ListExtendedPaged() = %+v, want total 3 over 2 pages", page)
+	}
+}
+
+func TestRepository_PaginationClampedIdentically(t *testing.T) {
+	tests := []struct {
+		name       string
+		page       int
+		perPage    int
+		wantLimit  int64
+		wantOffset int64
+	}{
+		{name: "defaults", page: 0, perPage: 0, wantLimit: 20, wantOffset: 0},
+		{name: "negative", page: -3, perPage: -10, wantLimit: 20, wantOffset: 0},
+		{name: "huge per page", page: 2, perPage: 1_000_000, wantLimit: 100, wantOffset: 100},
+		{name: "in range", page: 3, perPage: 25, wantLimit: 25, wantOffset: 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseRecorder := &queryRecorder{}
+			baseDB := sql.OpenDB(rowsConnector{rows: [][]driver.Value{validTenantRow()}, recorder: baseRecorder})
+			defer baseDB.Close()
+
+			if _, _, err := NewRepository(baseDB, zaptest.NewLogger(t)).List(context.Background(), tt.page, tt.perPage); err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+
+			extRow := append(validTenantRow()[:6], []byte(`{}`), time.Now(), time.Now())
+			extRecorder := &queryRecorder{}
+			extDB := sql.OpenDB(rowsConnector{rows: [][]driver.Value{extRow}, recorder: extRecorder})
+			defer extDB.Close()
+
+			if _, _, err := NewExtensibleRepository(extDB, zaptest.NewLogger(t)).ListExtended(context.Background(), tt.page, tt.perPage); err != nil {
+				t.Fatalf("ListExtended() error = %v", err)
+			}
+
+			// Arguments are (status, limit, offset) for both queries
+			for name, args := range map[string][]driver.Value{"List": baseRecorder.lastArgs(), "ListExtended": extRecorder.lastArgs()} {
+				if len(args) != 3 {
+					t.Fatalf("%s query args = %v, want status, limit and offset", name, args)
+				}
+				if args[1] != tt.wantLimit || args[2] != tt.wantOffset {
+					t.Errorf("%s LIMIT/OFFSET = %v/%v, want %d/%d", name, args[1], args[2], tt.wantLimit, tt.wantOffset)
+				}
+			}
+		})
+	}
+}
+
+func TestExtensibleRepository_RejectsDuplicateCustomDomain(t *testing.T) {
+	owner := append(validTenantRow()[:6], []byte(`{"custom_domain":"app.acme.com"}`), time.Now(), time.Now())
+	ownerID := uuid.MustParse(owner[0].(string))
+
+	db := newTenantRowsDB(owner)
+	defer db.Close()
+
+	repo := NewExtensibleRepository(db, zaptest.NewLogger(t))
+	ctx := context.Background()
+	otherID := uuid.New()
+
+	writes := map[string]func() error{
+		"CreateExtended": func() error {
+			return repo.CreateExtended(ctx, &tenant.ExtensibleTenant{
+				ID:       otherID,
+				Metadata: tenant.TenantMetadata{tenant.MetadataCustomDomain: "app.acme.com"},
+			})
+		},
+		"UpdateExtended": func() error {
+			return repo.UpdateExtended(ctx, &tenant.ExtensibleTenant{
+				ID:       otherID,
+				Metadata: tenant.TenantMetadata{tenant.MetadataCustomDomain: "app.acme.com"},
+			})
+		},
+		"UpdateMetadata": func() error {
+			return repo.UpdateMetadata(ctx, otherID, tenant.TenantMetadata{tenant.MetadataCustomDomain: "app.acme.com"})
+		},
+		"UpdateMetadataField": func() error {
+			return repo.UpdateMetadataField(ctx, otherID, tenant.MetadataCustomDomain, "app.acme.com")
+		},
+	}
+
+	for name, write := range writes {
+		var conflict *tenant.CustomDomainConflictError
+		if err := write(); !errors.As(err, &conflict) {
+			t.Errorf("%s() error = %v, want CustomDomainConflictError", name, err)
+			continue
+		}
+		if conflict.Domain != "app.acme.com" || conflict.TenantID != otherID || conflict.OwnerID != ownerID {
+			t.Errorf("%s() conflict = %+v, want app.acme.com owned by %s", name, conflict, ownerID)
+		}
+	}
+
+	// The owner may rewrite its own domain; the fake database then fails the write itself
+	var conflict *tenant.CustomDomainConflictError
+	if err := repo.UpdateMetadataField(ctx, ownerID, tenant.MetadataCustomDomain, "app.acme.com"); errors.As(err, &conflict) {
+		t.Errorf("UpdateMetadataField() for the owner error = %v, want no conflict", err)
+	}
+
+	// Duplicates pass the check when allowed
+	repo.SetAllowDuplicateCustomDomains(true)
+	if err := repo.UpdateMetadataField(ctx, otherID, tenant.MetadataCustomDomain, "app.acme.com"); errors.As(err, &conflict) {
+		t.Errorf("UpdateMetadataField() with duplicates allowed error = %v, want no conflict", err)
+	}
+}
+
+func TestExtensibleRepository_MetadataHooks(t *testing.T) {
+	recorder := &queryRecorder{}
+	db := sql.OpenDB(rowsConnector{rows: [][]driver.Value{validTenantRow()}, recorder: recorder})
+	defer db.Close()
+
+	repo := NewExtensibleRepository(db, zaptest.NewLogger(t))
+	repo.SetAllowDuplicateCustomDomains(true)
+	repo.SetMetadataLimits(tenant.MetadataLimits{}) // the fake database cannot serve GetMetadata
+	repo.AddMetadataHook(func(ctx context.Context, tenantID uuid.UUID, key string, value interface{}) (interface{}, error) {
+		if s, ok := value.(string); ok {
+			return strings.TrimSpace(s), nil
+		}
+		return value, nil
+	})
+	repo.AddMetadataHook(func(ctx context.Context, tenantID uuid.UUID, key string, value interface{}) (interface{}, error) {
+		if s, ok := value.(string); ok && key == tenant.MetadataCustomDomain {
+			return strings.ToLower(s), nil
+		}
+		return value, nil
+	})
+	repo.AddMetadataHook(tenant.MetadataValidator(func(key string, value interface{}) error {
+		if s, ok := value.(string); ok && key == "billing_email" && !strings.Contains(s, "@") {
+			return errors.New("not an email address")
+		}
+		return nil
+	}))
+
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	// Hooks run in order on the value before it is written
+	repo.UpdateMetadataField(ctx, tenantID, tenant.MetadataCustomDomain, "  App.Acme.COM ")
+	if args := recorder.lastArgs(); len(args) < 3 || args[2] != "app.acme.com" {
+		t.Errorf("UpdateMetadataField() wrote %v, want the normalized domain", args)
+	}
+
+	metadata := tenant.TenantMetadata{tenant.MetadataCustomDomain: "App.Acme.com", "billing_email": " ops@acme.com"}
+	repo.UpdateMetadata(ctx, tenantID, metadata)
+	if args := recorder.lastArgs(); len(args) < 2 || !strings.Contains(fmt.Sprintf("%s", args[1]), `"custom_domain":"app.acme.com"`) {
+		t.Errorf("UpdateMetadata() wrote %v, want the normalized domain", args)
+	}
+	if metadata[tenant.MetadataCustomDomain] != "App.Acme.com" {
+		t.Errorf("UpdateMetadata() modified the caller's metadata: %v", metadata)
+	}
+
+	// A rejected value fails the write before it reaches the database
+	recorder.record(nil)
+	writes := map[string]func() error{
+		"CreateExtended": func() error {
+			return repo.CreateExtended(ctx, &tenant.ExtensibleTenant{ID: tenantID, Metadata: tenant.TenantMetadata{"billing_email": "ops"}})
+		},
+		"UpdateMetadata": func() error {
+			return repo.UpdateMetadata(ctx, tenantID, tenant.TenantMetadata{"billing_email": "ops"})
+		},
+		"UpdateMetadataField": func() error {
+			return repo.UpdateMetadataField(ctx, tenantID, "billing_email", "ops")
+		},
+	}
+	for name, write := range writes {
+		var invalid *tenant.InvalidMetadataError
+		if err := write(); !errors.As(err, &invalid) || invalid.Key != "billing_email" || invalid.TenantID != tenantID {
+			t.Errorf("%s() error = %v, want InvalidMetadataError for billing_email", name, err)
+		}
+	}
+	if args := recorder.lastArgs(); len(args) != 0 {
+		t.Errorf("rejected writes reached the database with %v", args)
+	}
+}
+
+func TestExtensibleRepository_MetadataLimits(t *testing.T) {
+	db := newTenantRowsDB([]driver.Value{[]byte(`{"plan":"pro","region":"eu"}`)})
+	defer db.Close()
+
+	repo := NewExtensibleRepository(db, zaptest.NewLogger(t))
+	repo.SetMetadataLimits(tenant.MetadataLimits{MaxBytes: 100, MaxKeys: 2})
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	tooLarge := map[string]func() error{
+		"CreateExtended too many keys": func() error {
+			return repo.CreateExtended(ctx, &tenant.ExtensibleTenant{ID: tenantID, Metadata: tenant.TenantMetadata{"a": 1, "b": 2, "c": 3}})
+		},
+		"CreateExtendedBatch too many keys": func() error {
+			return repo.CreateExtendedBatch(ctx, []*tenant.ExtensibleTenant{
+				{ID: uuid.New()},
+				{ID: tenantID, Metadata: tenant.TenantMetadata{"a": 1, "b": 2, "c": 3}},
+			})
+		},
+		"UpdateMetadata too many keys": func() error {
+			return repo.UpdateMetadata(ctx, tenantID, tenant.TenantMetadata{"a": 1, "b": 2, "c": 3})
+		},
+		"UpdateMetadata too many bytes": func() error {
+			return repo.UpdateMetadata(ctx, tenantID, tenant.TenantMetadata{"notes": strings.Repeat("x", 100)})
+		},
+		"UpdateMetadataField adds a third key": func() error {
+			return repo.UpdateMetadataField(ctx, tenantID, "tier", "gold")
+		},
+		"UpdateMetadataField too many bytes": func() error {
+			return repo.UpdateMetadataField(ctx, tenantID, "region", strings.Repeat("x", 100))
+		},
+	}
+	for name, write := range tooLarge {
+		var errTooLarge *tenant.MetadataTooLargeError
+		if err := write(); !errors.As(err, &errTooLarge) || errTooLarge.TenantID != tenantID {
+			t.Errorf("%s: error = %v, want MetadataTooLargeError", name, err)
+		}
+	}
+
+	// Replacing an existing key within the caps reaches the database, which fails the write
+	var errTooLarge *tenant.MetadataTooLargeError
+	if err := repo.UpdateMetadataField(ctx, tenantID, "region", "us"); err == nil || errors.As(err, &errTooLarge) {
+		t.Errorf("UpdateMetadataField() error = %v, want the write attempted", err)
+	}
+
+	// Without caps nothing is rejected
+	repo.SetMetadataLimits(tenant.MetadataLimits{})
+	if err := repo.UpdateMetadata(ctx, tenantID, tenant.TenantMetadata{"a": 1, "b": 2, "c": 3}); errors.As(err, &errTooLarge) {
+		t.Errorf("UpdateMetadata() without caps error = %v", err)
+	}
+}
+
+func TestExtensibleRepository_TenantLimitOverrides(t *testing.T) {
+	recorder := &queryRecorder{}
+	db := sql.OpenDB(rowsConnector{
+		rows:     [][]driver.Value{{[]byte(`{"max_users":{"type":"int","value":50}}`)}},
+		recorder: recorder,
+	})
+	defer db.Close()
+
+	repo := NewExtensibleRepository(db, zaptest.NewLogger(t))
+	repo.SetMetadataLimits(tenant.MetadataLimits{})
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	overrides, err := repo.GetTenantLimitOverrides(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("GetTenantLimitOverrides() error = %v", err)
+	}
+	if users, err := overrides[tenant.LimitNameMaxUsers].Int(); err != nil || users != 50 || len(overrides) != 1 {
+		t.Errorf("GetTenantLimitOverrides() = %v, want max_users at 50", overrides)
+	}
+
+	// The override is merged into the reserved key; the fake database fails the write
+	repo.SetTenantLimitOverride(ctx, tenantID, tenant.LimitNameMaxProjects, tenant.IntLimit(20))
+	args := recorder.lastArgs()
+	if len(args) != 5 || args[1] != tenant.MetadataLimitOverridesKey || args[2] != tenant.LimitNameMaxProjects || args[4] != `{"type":"int","value":20}` {
+		t.Errorf("SetTenantLimitOverride() wrote %v, want the max_projects override under %s", args, tenant.MetadataLimitOverridesKey)
+	}
+
+	// A nil value removes the override
+	repo.SetTenantLimitOverride(ctx, tenantID, tenant.LimitNameMaxUsers, nil)
+	if args := recorder.lastArgs(); len(args) != 4 || args[2] != tenant.LimitNameMaxUsers {
+		t.Errorf("SetTenantLimitOverride(nil) wrote %v, want the max_users override removed", args)
+	}
+
+	var validationErr *tenant.ValidationError
+	if err := repo.SetTenantLimitOverride(ctx, tenantID, "", tenant.IntLimit(1)); !errors.As(err, &validationErr) {
+		t.Errorf("SetTenantLimitOverride() without a name error = %v, want a ValidationError", err)
+	}
+
+	// The reserved key cannot be written or removed as a plain field
+	recorder.record(nil)
+	fieldWrites := map[string]func() error{
+		"UpdateMetadataField": func() error {
+			return repo.UpdateMetadataField(ctx, tenantID, tenant.MetadataLimitOverridesKey, map[string]interface{}{})
+		},
+		"RemoveMetadataField": func() error {
+			return repo.RemoveMetadataField(ctx, tenantID, tenant.MetadataLimitOverridesKey)
+		},
+	}
+	for name, write := range fieldWrites {
+		var invalid *tenant.InvalidMetadataError
+		if err := write(); !errors.As(err, &invalid) || !errors.Is(err, tenant.ErrReservedMetadataKey) {
+			t.Errorf("%s() error = %v, want InvalidMetadataError for the reserved key", name, err)
+		}
+	}
+	if args := recorder.lastArgs(); len(args) != 0 {
+		t.Errorf("writes of the reserved key reached the database with %v", args)
+	}
+}
+
+func TestCustomDomainConflict_UniqueIndexViolation(t *testing.T) {
+	tenantID := uuid.New()
+
+	err := customDomainConflict(&pq.Error{Code: "23505", Constraint: customDomainIndex}, tenantID, "app.acme.com")
+	var conflict *tenant.CustomDomainConflictError
+	if !errors.As(err, &conflict) || conflict.Domain != "app.acme.com" || conflict.TenantID != tenantID {
+		t.Errorf("customDomainConflict() = %v, want CustomDomainConflictError", err)
+	}
+
+	// Other unique violations are not custom domain conflicts
+	if err := customDomainConflict(&pq.Error{Code: "23505", Constraint: "tenants_subdomain_key"}, tenantID, "app.acme.com"); err != nil {
+		t.Errorf("customDomainConflict() = %v for the subdomain constraint, want nil", err)
+	}
+}
+
+// validTenantRow returns a tenants row in List column order
+func validTenantRow() []driver.Value {
+	return []driver.Value{uuid.New().String(), "Acme", "acme", "basic", "active", "tenant_acme", time.Now(), time.Now()}
+}
+
+// invalidTenantRow returns a tenants row whose id cannot be scanned into a UUID
+func invalidTenantRow() []driver.Value {
+	return []driver.Value{"not-a-uuid", "Broken", "broken", "basic", "active", "tenant_broken", time.Now(), time.Now()}
+}
+
+// newTenantRowsDB returns a database that answers COUNT queries with the
+// number of rows and every other query with the given rows
+func newTenantRowsDB(rows ...[]driver.Value) *sql.DB {
+	return sql.OpenDB(rowsConnector{rows: rows})
+}
+
+type rowsConnector struct {
+	rows     [][]driver.Value
+	recorder *queryRecorder // optional
+}
+
+func (c rowsConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &rowsConn{rows: c.rows, recorder: c.recorder}, nil
+}
+
+func (c rowsConnector) Driver() driver.Driver { return rowsDriver{} }
+
+type rowsDriver struct{}
+
+func (rowsDriver) Open(name string) (driver.Conn, error) {
+	return nil, fmt.Errorf("use rowsConnector")
+}
+
+type rowsConn struct {
+	rows     [][]driver.Value
+	recorder *queryRecorder
+}
+
+func (c *rowsConn) Prepare(query string) (driver.Stmt, error) {
+	return &rowsStmt{query: query, rows: c.rows, recorder: c.recorder}, nil
+}
+
+func (c *rowsConn) Close() error { return nil }
+
+func (c *rowsConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("transactions not supported")
+}
+
+type rowsStmt struct {
+	query    string
+	rows     [][]driver.Value
+	recorder *queryRecorder
+}
+
+func (s *rowsStmt) Close() error { return nil }
+
+func (s *rowsStmt) NumInput() int { return -1 }
+
+func (s *rowsStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if s.recorder != nil {
+		s.recorder.record(args)
+	}
+	return nil, fmt.Errorf("exec not supported")
+}
+
+func (s *rowsStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if strings.Contains(s.query, "COUNT(*)") {
+		return &staticRows{columns: []string{"count"}, values: [][]driver.Value{{int64(len(s.rows))}}}, nil
+	}
+	if s.recorder != nil {
+		s.recorder.record(args)
+	}
+
+	columns := make([]string, len(s.rows[0]))
+	for i := range columns {
+		columns[i] = fmt.Sprintf("col%d", i)
+	}
+	return &staticRows{columns: columns, values: s.rows}, nil
+}
+
+// queryRecorder captures the arguments of the last non-COUNT query or exec
+type queryRecorder struct {
+	mu   sync.Mutex
+	args []driver.Value
+}
+
+func (r *queryRecorder) record(args []driver.Value) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.args = append([]driver.Value(nil), args...)
+}
+
+func (r *queryRecorder) lastArgs() []driver.Value {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.args
+}
+
+type staticRows struct {
+	columns []string
+	values  [][]driver.Value
+	next    int
+}
+
+func (r *staticRows) Columns() []string { return r.columns }
+
+func (r *staticRows) Close() error { return nil }
+
+func (r *staticRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,236 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// monthlyLimitSuffix marks limits whose usage starts again each calendar month
+const monthlyLimitSuffix = "_per_month"
+
+// UsageTracker implements tenant.UsageTracker for PostgreSQL, storing usage
+// in the tenant_usage table. Usage of limits named *_per_month, such as
+// api_calls_per_month, is kept per calendar month (UTC) and so reads as zero
+// again when a month starts; usage of other limits is kept for all time.
+type UsageTracker struct {
+	db     *sql.DB
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+// Ensure UsageTracker implements the tracker interfaces
+var (
+	_ tenant.UsageTracker  = (*UsageTracker)(nil)
+	_ tenant.UsageConsumer = (*UsageTracker)(nil)
+	_ tenant.UsageLister   = (*UsageTracker)(nil)
+)
+
+// NewUsageTracker creates a new PostgreSQL usage tracker
+func NewUsageTracker(db *sql.DB, logger *zap.Logger) *UsageTracker {
+	return &UsageTracker{
+		db:     db,
+		logger: logger.Named("postgres_usage_tracker"),
+		now:    time.Now,
+	}
+}
+
+// CreateTables creates the table used to store tenant usage
+func (u *UsageTracker) CreateTables(ctx context.Context) error {
+	tables := []string{
+		`CREATE TABLE IF NOT EXISTS public.tenant_usage (
+			tenant_id UUID NOT NULL REFERENCES public.tenants(id) ON DELETE CASCADE,
+			limit_name VARCHAR(255) NOT NULL,
+			period VARCHAR(16) NOT NULL DEFAULT '',
+			usage DOUBLE PRECISION NOT NULL DEFAULT 0,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (tenant_id, limit_name, period)
+		)`,
+	}
+
+	for _, tableSQL := range tables {
+		if _, err := u.db.ExecContext(ctx, tableSQL); err != nil {
+			return fmt.Errorf("failed to create usage table: %w", err)
+		}
+	}
+
+	u.logger.Info("Created usage tracker tables")
+	return nil
+}
+
+// GetCurrentUsage returns the tenant's usage of limitName in the current
+// period as a float64, or nil if none has been recorded, so that limits
+// which are not counted, such as feature flags, are not compared
+func (u *UsageTracker) GetCurrentUsage(ctx context.Context, tenantID uuid.UUID, limitName string) (interface{}, error) {
+	query := `SELECT usage FROM public.tenant_usage WHERE tenant_id = $1 AND limit_name = $2 AND period = $3`
+
+	var usage float64
+	err := u.db.QueryRowContext(ctx, query, tenantID, limitName, u.period(limitName)).Scan(&usage)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get usage of %s: %w", limitName, err)
+	}
+
+	return usage, nil
+}
+
+// GetAllUsage returns the tenant's usage of every limit recorded in the
+// current period as float64s, in a single query. Monthly limits are recorded
+// under the month and all others under no period, so both are read.
+func (u *UsageTracker) GetAllUsage(ctx context.Context, tenantID uuid.UUID) (map[string]interface{}, error) {
+	query := `SELECT limit_name, usage FROM public.tenant_usage WHERE tenant_id = $1 AND period IN ('', $2)`
+
+	rows, err := u.db.QueryContext(ctx, query, tenantID, u.period(monthlyLimitSuffix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+	defer rows.Close()
+
+	usage := make(map[string]interface{})
+	for rows.Next() {
+		var limitName string
+		var value float64
+		if err := rows.Scan(&limitName, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan usage: %w", err)
+		}
+		usage[limitName] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+// IncrementUsage atomically adds delta to the tenant's usage of limitName in
+// the current period
+func (u *UsageTracker) IncrementUsage(ctx context.Context, tenantID uuid.UUID, limitName string, delta interface{}) error {
+	amount, err := usageDelta(delta)
+	if err != nil {
+		return err
+	}
+	return u.addUsage(ctx, tenantID, limitName, amount)
+}
+
+// DecrementUsage atomically subtracts delta from the tenant's usage of
+// limitName in the current period. Usage never goes below zero.
+func (u *UsageTracker) DecrementUsage(ctx context.Context, tenantID uuid.UUID, limitName string, delta interface{}) error {
+	amount, err := usageDelta(delta)
+	if err != nil {
+		return err
+	}
+	return u.addUsage(ctx, tenantID, limitName, -amount)
+}
+
+// ResetUsage clears the tenant's usage of limitName in the current period
+func (u *UsageTracker) ResetUsage(ctx context.Context, tenantID uuid.UUID, limitName string) error {
+	query := `DELETE FROM public.tenant_usage WHERE tenant_id = $1 AND limit_name = $2 AND period = $3`
+
+	if _, err := u.db.ExecContext(ctx, query, tenantID, limitName, u.period(limitName)); err != nil {
+		u.logger.Error("Failed to reset usage",
+			zap.String("tenant_id", tenantID.String()),
+			zap.String("limit", limitName),
+			zap.Error(err))
+		return fmt.Errorf("failed to reset usage of %s: %w", limitName, err)
+	}
+
+	return nil
+}
+
+// ConsumeUsage adds amount to the tenant's usage of limitName in the current
+// period if the total stays within limit. The check and the increment are a
+// single statement, so concurrent consumers cannot both take the last units.
+func (u *UsageTracker) ConsumeUsage(ctx context.Context, tenantID uuid.UUID, limitName string, amount, limit int) (int, bool, error) {
+	query := `
+		INSERT INTO public.tenant_usage (tenant_id, limit_name, period, usage, updated_at)
+		SELECT $1::uuid, $2::text, $3::text, $4::double precision, $6::timestamptz
+		WHERE $4::double precision <= $5::double precision
+		ON CONFLICT (tenant_id, limit_name, period) DO UPDATE
+		SET usage = tenant_usage.usage + EXCLUDED.usage,
+		    updated_at = EXCLUDED.updated_at
+		WHERE tenant_usage.usage + EXCLUDED.usage <= $5::double precision
+		RETURNING usage
+	`
+
+	var usage float64
+	err := u.db.QueryRowContext(ctx, query, tenantID, limitName, u.period(limitName), float64(amount), float64(limit), u.now()).Scan(&usage)
+	if err == nil {
+		return int(usage), true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		u.logger.Error("Failed to consume usage",
+			zap.String("tenant_id", tenantID.String()),
+			zap.String("limit", limitName),
+			zap.Error(err))
+		return 0, false, fmt.Errorf("failed to consume usage of %s: %w", limitName, err)
+	}
+
+	// Nothing was written, so amount did not fit
+	current, err := u.GetCurrentUsage(ctx, tenantID, limitName)
+	if err != nil {
+		return 0, false, err
+	}
+	if current == nil {
+		return 0, false, nil
+	}
+	return int(current.(float64)), false, nil
+}
+
+// addUsage upserts the current period's usage row, adding amount to it
+func (u *UsageTracker) addUsage(ctx context.Context, tenantID uuid.UUID, limitName string, amount float64) error {
+	query := `
+		INSERT INTO public.tenant_usage (tenant_id, limit_name, period, usage, updated_at)
+		VALUES ($1, $2, $3, GREATEST($4::double precision, 0), $5)
+		ON CONFLICT (tenant_id, limit_name, period) DO UPDATE
+		SET usage = GREATEST(tenant_usage.usage + $4::double precision, 0),
+		    updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := u.db.ExecContext(ctx, query, tenantID, limitName, u.period(limitName), amount, u.now()); err != nil {
+		u.logger.Error("Failed to update usage",
+			zap.String("tenant_id", tenantID.String()),
+			zap.String("limit", limitName),
+			zap.Error(err))
+		return fmt.Errorf("failed to update usage of %s: %w", limitName, err)
+	}
+
+	return nil
+}
+
+// period returns the key of the period usage of limitName is currently
+// recorded in: the month for monthly limits, empty for all others
+func (u *UsageTracker) period(limitName string) string {
+	if strings.HasSuffix(limitName, monthlyLimitSuffix) {
+		return u.now().UTC().Format("2006-01")
+	}
+	return ""
+}
+
+// usageDelta converts a usage change to a non-negative float64
+func usageDelta(delta interface{}) (float64, error) {
+	var amount float64
+	switch v := delta.(type) {
+	case int:
+		amount = float64(v)
+	case int64:
+		amount = float64(v)
+	case float64:
+		amount = v
+	default:
+		return 0, fmt.Errorf("cannot track usage change of type %T", delta)
+	}
+
+	if amount < 0 {
+		return 0, fmt.Errorf("usage change must not be negative, got %v", amount)
+	}
+	return amount, nil
+}
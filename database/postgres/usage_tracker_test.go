@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestUsageTracker_Periods(t *testing.T) {
+	recorder := &queryRecorder{}
+	db := sql.OpenDB(rowsConnector{recorder: recorder})
+	defer db.Close()
+
+	tracker := NewUsageTracker(db, zaptest.NewLogger(t))
+	tracker.now = func() time.Time { return time.Date(2026, time.March, 31, 23, 30, 0, 0, time.FixedZone("EST", -5*3600)) }
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	// Monthly limits are keyed by the UTC month; the fake database fails the write
+	tracker.IncrementUsage(ctx, tenantID, "api_calls_per_month", 5)
+	if args := recorder.lastArgs(); len(args) != 5 || args[1] != "api_calls_per_month" || args[2] != "2026-04" || args[3] != 5.0 {
+		t.Errorf("IncrementUsage() wrote %v, want 5 calls in 2026-04", args)
+	}
+
+	// Other limits are kept for all time
+	tracker.IncrementUsage(ctx, tenantID, "max_users", int64(1))
+	if args := recorder.lastArgs(); len(args) != 5 || args[2] != "" || args[3] != 1.0 {
+		t.Errorf("IncrementUsage() wrote %v, want 1 user with no period", args)
+	}
+
+	tracker.DecrementUsage(ctx, tenantID, "max_users", 2.5)
+	if args := recorder.lastArgs(); len(args) != 5 || args[3] != -2.5 {
+		t.Errorf("DecrementUsage() wrote %v, want -2.5", args)
+	}
+}
+
+func TestUsageTracker_InvalidDelta(t *testing.T) {
+	recorder := &queryRecorder{}
+	db := sql.OpenDB(rowsConnector{recorder: recorder})
+	defer db.Close()
+
+	tracker := NewUsageTracker(db, zaptest.NewLogger(t))
+	ctx := context.Background()
+
+	for _, delta := range []interface{}{"1", nil, -1} {
+		if err := tracker.IncrementUsage(ctx, uuid.New(), "max_users", delta); err == nil {
+			t.Errorf("IncrementUsage(%v) should fail", delta)
+		}
+		if err := tracker.DecrementUsage(ctx, uuid.New(), "max_users", delta); err == nil {
+			t.Errorf("DecrementUsage(%v) should fail", delta)
+		}
+	}
+	if args := recorder.lastArgs(); len(args) != 0 {
+		t.Errorf("invalid changes reached the database with %v", args)
+	}
+}
+
+func TestUsageTracker_GetAllUsage(t *testing.T) {
+	recorder := &queryRecorder{}
+	db := sql.OpenDB(rowsConnector{
+		rows:     [][]driver.Value{{"max_users", 7.0}, {"api_calls_per_month", 1200.0}},
+		recorder: recorder,
+	})
+	defer db.Close()
+
+	tracker := NewUsageTracker(db, zaptest.NewLogger(t))
+	tracker.now = func() time.Time { return time.Date(2026, time.April, 2, 12, 0, 0, 0, time.UTC) }
+	tenantID := uuid.New()
+
+	usage, err := tracker.GetAllUsage(context.Background(), tenantID)
+	if err != nil {
+		t.Fatalf("GetAllUsage() error = %v", err)
+	}
+	if len(usage) != 2 || usage["max_users"] != 7.0 || usage["api_calls_per_month"] != 1200.0 {
+		t.Errorf("GetAllUsage() = %v, want both limits", usage)
+	}
+	if args := recorder.lastArgs(); len(args) != 2 || args[1] != "2026-04" {
+		t.Errorf("GetAllUsage() queried %v, want the current month", args)
+	}
+}
@@ -1,40 +1,68 @@
 package database
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/alexalmadav/go-multitenant/tenant"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
+// baseTenantTables lists the tables createTenantTables creates in every tenant schema
+var baseTenantTables = []string{"projects", "tasks", "documents", "tenant_users"}
+
 // SchemaManager implements tenant.SchemaManager for database schema operations
 type SchemaManager struct {
 	db           *sql.DB
 	logger       *zap.Logger
 	schemaPrefix string
+	functions    []string // extra function DDL, see tenant.DatabaseConfig.SchemaFunctions
+	triggers     []string // extra trigger DDL, see tenant.DatabaseConfig.SchemaTriggers
+	tables       []string // sorted base table names, see ExpectedTables
 }
 
 // Ensure SchemaManager implements tenant.SchemaManager interface
 var _ tenant.SchemaManager = (*SchemaManager)(nil)
 
+// Ensure SchemaManager can count the stats of regional tenants
+var _ tenant.SchemaStatsCounter = (*SchemaManager)(nil)
+
 // NewSchemaManager creates a new schema manager
 func NewSchemaManager(db *sql.DB, logger *zap.Logger, schemaPrefix string) *SchemaManager {
 	if schemaPrefix == "" {
 		schemaPrefix = "tenant_"
 	}
 
+	tables := make([]string, len(baseTenantTables))
+	copy(tables, baseTenantTables)
+	sort.Strings(tables)
+
 	return &SchemaManager{
 		db:           db,
 		logger:       logger.Named("schema"),
 		schemaPrefix: schemaPrefix,
+		tables:       tables,
 	}
 }
 
+// NewSchemaManagerFromConfig creates a schema manager that also installs the
+// functions and triggers configured in SchemaFunctions and SchemaTriggers
+func NewSchemaManagerFromConfig(db *sql.DB, logger *zap.Logger, config tenant.DatabaseConfig) *SchemaManager {
+	sm := NewSchemaManager(db, logger, config.SchemaPrefix)
+	sm.functions = config.SchemaFunctions
+	sm.triggers = config.SchemaTriggers
+	return sm
+}
+
 // GetSchemaName generates a standardized tenant schema name from tenant ID
 func (sm *SchemaManager) GetSchemaName(tenantID uuid.UUID) string {
 	return fmt.Sprintf("%s%s", sm.schemaPrefix, strings.ReplaceAll(tenantID.String(), "-", "_"))
@@ -69,11 +97,29 @@ func (sm *SchemaManager) CreateTenantSchema(ctx context.Context, tenantID uuid.U
 		return fmt.Errorf("failed to set search path: %w", err)
 	}
 
+	// Confirm the tenant schema is the creation target before running any DDL
+	var currentSchema sql.NullString
+	if err := tx.QueryRowContext(ctx, "SELECT current_schema()").Scan(&currentSchema); err != nil {
+		return fmt.Errorf("failed to verify search path: %w", err)
+	}
+	if currentSchema.String != schemaName {
+		return fmt.Errorf("refusing to create tenant tables: current schema is %q, want %q", currentSchema.String, schemaName)
+	}
+
 	// Create tenant-specific tables with explicit schema qualification
 	if err := sm.createTenantTables(ctx, tx, quotedSchema); err != nil {
 		return fmt.Errorf("failed to create tenant tables: %w", err)
 	}
 
+	// Abort if anything created by this transaction ended up in public
+	if err := sm.checkPublicLeakage(ctx, tx); err != nil {
+		sm.logger.Error("Tenant schema creation leaked objects to public",
+			zap.String("tenant_id", tenantID.String()),
+			zap.String("schema_name", schemaName),
+			zap.Error(err))
+		return err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -169,15 +215,220 @@ func (sm *SchemaManager) ListTenantSchemas(ctx context.Context) ([]string, error
 	for rows.Next() {
 		var schemaName string
 		if err := rows.Scan(&schemaName); err != nil {
-			sm.logger.Error("Failed to scan schema name", zap.Error(err))
-			continue
+			return nil, fmt.Errorf("error scanning tenant schema name: %w", err)
 		}
 		schemas = append(schemas, schemaName)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tenant schemas: %w", err)
+	}
+
 	return schemas, nil
 }
 
+// maxSchemaNameLength is PostgreSQL's identifier limit; longer names are truncated
+const maxSchemaNameLength = 63
+
+// RenameSchema renames the schema oldName to newName, keeping its data. A
+// newName already used by another schema is rejected with a ValidationError.
+// Recording the new name on the tenant is left to the caller, see
+// tenant.Manager.RenameTenantSchema.
+func (sm *SchemaManager) RenameSchema(ctx context.Context, oldName, newName string) error {
+	for _, name := range []string{oldName, newName} {
+		if name == "" || len(name) > maxSchemaNameLength {
+			return &tenant.ValidationError{Field: "schema_name", Message: fmt.Sprintf("schema name must be 1 to %d bytes", maxSchemaNameLength)}
+		}
+	}
+	if oldName == newName {
+		return nil
+	}
+
+	var taken bool
+	if err := sm.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM information_schema.schemata WHERE schema_name = $1)`, newName).Scan(&taken); err != nil {
+		return fmt.Errorf("error checking schema existence: %w", err)
+	}
+	if taken {
+		return &tenant.ValidationError{Field: "schema_name", Message: fmt.Sprintf("schema %s already exists", newName)}
+	}
+
+	renameSQL := fmt.Sprintf("ALTER SCHEMA %s RENAME TO %s", pq.QuoteIdentifier(oldName), pq.QuoteIdentifier(newName))
+	if _, err := sm.db.ExecContext(ctx, renameSQL); err != nil {
+		// A schema created since the check above
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "42P06" {
+			return &tenant.ValidationError{Field: "schema_name", Message: fmt.Sprintf("schema %s already exists", newName)}
+		}
+		return fmt.Errorf("failed to rename schema %s: %w", oldName, err)
+	}
+
+	sm.logger.Info("Renamed schema",
+		zap.String("old_schema_name", oldName),
+		zap.String("schema_name", newName))
+
+	return nil
+}
+
+// CountStats counts the tenant's projects and active users in its schema, as
+// postgres.Repository.GetStats does in the primary database. tenant.Manager
+// uses it for the stats of tenants whose schema lives in this manager's
+// database, such as regional tenants.
+func (sm *SchemaManager) CountStats(ctx context.Context, tenantID uuid.UUID) (*tenant.Stats, error) {
+	stats := &tenant.Stats{TenantID: tenantID, LastActivity: time.Now()}
+
+	exists, err := sm.SchemaExists(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return stats, nil
+	}
+	stats.SchemaExists = true
+
+	// Schemas with custom DDL may lack the standard tables, which count as empty
+	quotedSchema := sm.quotedSchemaName(tenantID)
+	if err := sm.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s.projects`, quotedSchema)).Scan(&stats.ProjectCount); err != nil {
+		stats.ProjectCount = 0
+	}
+	if err := sm.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s.tenant_users WHERE is_active = true`, quotedSchema)).Scan(&stats.UserCount); err != nil {
+		stats.UserCount = 0
+	}
+
+	return stats, nil
+}
+
+// TableStats returns the exact row count and total size of every table in the
+// tenant's schema, sorted by name. Counting rows scans each table, so this is
+// meant for occasional reports such as tenant.Manager.DeletionImpact.
+func (sm *SchemaManager) TableStats(ctx context.Context, tenantID uuid.UUID) ([]tenant.TableStats, error) {
+	schemaName := sm.GetSchemaName(tenantID)
+
+	query := `
+		SELECT c.relname, pg_total_relation_size(c.oid)
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1
+		AND c.relkind IN ('r', 'p')
+		ORDER BY c.relname
+	`
+
+	rows, err := sm.db.QueryContext(ctx, query, schemaName)
+	if err != nil {
+		return nil, fmt.Errorf("error listing tenant tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []tenant.TableStats
+	for rows.Next() {
+		var table tenant.TableStats
+		if err := rows.Scan(&table.Name, &table.Bytes); err != nil {
+			return nil, fmt.Errorf("error scanning tenant table: %w", err)
+		}
+		tables = append(tables, table)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tenant tables: %w", err)
+	}
+	rows.Close()
+
+	for i := range tables {
+		countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s.%s", pq.QuoteIdentifier(schemaName), pq.QuoteIdentifier(tables[i].Name))
+		if err := sm.db.QueryRowContext(ctx, countQuery).Scan(&tables[i].Rows); err != nil {
+			return nil, fmt.Errorf("error counting rows in %s: %w", tables[i].Name, err)
+		}
+	}
+
+	return tables, nil
+}
+
+// ExpectedTables returns the sorted names of the base tables created in every
+// tenant schema. None of them should ever exist in the public schema.
+func (sm *SchemaManager) ExpectedTables() []string {
+	tables := make([]string, len(sm.tables))
+	copy(tables, sm.tables)
+	return tables
+}
+
+// VerifyNoLeakage returns an error if any of the ExpectedTables exists in the
+// public schema, which would mean tenant DDL ran with the wrong search_path
+func (sm *SchemaManager) VerifyNoLeakage(ctx context.Context) error {
+	query := `
+		SELECT table_name
+		FROM information_schema.tables
+		WHERE table_schema = 'public'
+		AND table_name = ANY($1)
+		ORDER BY table_name
+	`
+
+	rows, err := sm.db.QueryContext(ctx, query, pq.Array(sm.tables))
+	if err != nil {
+		return fmt.Errorf("failed to check for public schema leakage: %w", err)
+	}
+	defer rows.Close()
+
+	var leaked []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return fmt.Errorf("failed to scan leaked table: %w", err)
+		}
+		leaked = append(leaked, name)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating leaked tables: %w", err)
+	}
+
+	if len(leaked) > 0 {
+		sm.logger.Error("Tenant tables found in public schema", zap.Strings("tables", leaked))
+		return fmt.Errorf("tenant tables found in public schema: %s", strings.Join(leaked, ", "))
+	}
+
+	return nil
+}
+
+// checkPublicLeakage returns an error if the transaction created any relation
+// (table, index, sequence) or function in the public schema
+func (sm *SchemaManager) checkPublicLeakage(ctx context.Context, tx *sql.Tx) error {
+	// Objects created by this transaction carry its transaction ID as xmin
+	query := `
+		SELECT c.relname
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = 'public'
+		AND c.xmin::text::bigint = txid_current() % 4294967296
+		UNION ALL
+		SELECT p.proname
+		FROM pg_catalog.pg_proc p
+		JOIN pg_catalog.pg_namespace n ON n.oid = p.pronamespace
+		WHERE n.nspname = 'public'
+		AND p.xmin::text::bigint = txid_current() % 4294967296
+	`
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to check for public schema leakage: %w", err)
+	}
+	defer rows.Close()
+
+	var leaked []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return fmt.Errorf("failed to scan leaked object: %w", err)
+		}
+		leaked = append(leaked, name)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating leaked objects: %w", err)
+	}
+
+	if len(leaked) > 0 {
+		return fmt.Errorf("tenant objects would be created in public schema: %s", strings.Join(leaked, ", "))
+	}
+
+	return nil
+}
+
 // quotedSchemaName returns a properly quoted schema name for SQL queries
 func (sm *SchemaManager) quotedSchemaName(tenantID uuid.UUID) string {
 	schemaName := sm.GetSchemaName(tenantID)
@@ -187,7 +438,7 @@ func (sm *SchemaManager) quotedSchemaName(tenantID uuid.UUID) string {
 // createTenantTables creates the standard tenant tables with explicit schema qualification
 // This is a basic implementation - in practice, you'd want this to be configurable
 func (sm *SchemaManager) createTenantTables(ctx context.Context, tx *sql.Tx, quotedSchema string) error {
-	// Example tenant tables - customize based on your needs
+	// Example tenant tables - customize based on your needs, keeping baseTenantTables in sync
 	// All tables use explicit schema qualification to prevent accidental creation in public
 	tables := []string{
 		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.projects (
@@ -290,5 +541,42 @@ func (sm *SchemaManager) createTenantTables(ctx context.Context, tx *sql.Tx, quo
 		}
 	}
 
+	// Install user-supplied functions, then the triggers that use them
+	for _, functionDDL := range sm.functions {
+		functionSQL, err := renderSchemaDDL(functionDDL, quotedSchema)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, functionSQL); err != nil {
+			return fmt.Errorf("failed to create custom function: %w", err)
+		}
+	}
+
+	for _, triggerDDL := range sm.triggers {
+		triggerSQL, err := renderSchemaDDL(triggerDDL, quotedSchema)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, triggerSQL); err != nil {
+			return fmt.Errorf("failed to create custom trigger: %w", err)
+		}
+	}
+
 	return nil
 }
+
+// renderSchemaDDL expands {{.Schema}} in user-supplied DDL to the quoted tenant schema name.
+// DDL runs with search_path set to the tenant schema, so unqualified names also resolve there.
+func renderSchemaDDL(ddl, quotedSchema string) (string, error) {
+	tmpl, err := template.New("schema_ddl").Option("missingkey=error").Parse(ddl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse schema DDL: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct{ Schema string }{Schema: quotedSchema}); err != nil {
+		return "", fmt.Errorf("failed to render schema DDL: %w", err)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,308 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// Ensure SchemaManager can copy tenant data for CloneTenant
+var _ tenant.SchemaCloner = (*SchemaManager)(nil)
+
+// cloneColumn is a column copied by CopyTenantData
+type cloneColumn struct {
+	name      string
+	sequenced bool // filled from a sequence, as serial and identity columns are
+}
+
+// cloneReference is a single-column foreign key
+type cloneReference struct {
+	table  string
+	column string
+}
+
+// cloneTable describes how CopyTenantData copies one table
+type cloneTable struct {
+	columns    []cloneColumn
+	key        string // UUID primary key regenerated on copy, "" if none
+	keyDefault string // expression generating new values of key
+	references map[string]cloneReference
+	parents    map[string]bool // tables this one has foreign keys to
+}
+
+// CopyTenantData copies rows from the source tenant's schema into the target's
+// in one transaction, parents before the tables that reference them. tables
+// defaults to every table in the source schema. UUID primary keys that have a
+// default are regenerated, and foreign keys to them rewritten, so the copy
+// shares no row IDs with the source. Other keys are copied as is, and the
+// sequences behind serial and identity columns are moved past the copied
+// values. The target tables should be empty.
+func (sm *SchemaManager) CopyTenantData(ctx context.Context, sourceID, targetID uuid.UUID, tables []string) error {
+	source := sm.GetSchemaName(sourceID)
+	target := sm.GetSchemaName(targetID)
+
+	tx, err := sm.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	layout, err := loadCloneLayout(ctx, tx, source)
+	if err != nil {
+		return err
+	}
+
+	if len(tables) == 0 {
+		for name := range layout {
+			tables = append(tables, name)
+		}
+	}
+	for _, name := range tables {
+		if _, ok := layout[name]; !ok {
+			return &tenant.ValidationError{Field: "tables", Message: fmt.Sprintf("table %s does not exist in schema %s", name, source)}
+		}
+	}
+	ordered := orderCloneTables(layout, tables)
+
+	// Map the old primary keys of every copied table that gets new ones
+	keyMaps := make(map[string]string)
+	for i, name := range ordered {
+		table := layout[name]
+		if table.key == "" {
+			continue
+		}
+		keyMap := fmt.Sprintf("clone_keys_%d", i)
+		createMap := fmt.Sprintf(`CREATE TEMP TABLE %s (old_id UUID PRIMARY KEY, new_id UUID NOT NULL) ON COMMIT DROP`, keyMap)
+		fillMap := fmt.Sprintf(`INSERT INTO pg_temp.%s (old_id, new_id) SELECT %s, %s FROM %s.%s`,
+			keyMap, pq.QuoteIdentifier(table.key), table.keyDefault, pq.QuoteIdentifier(source), pq.QuoteIdentifier(name))
+		for _, statement := range []string{createMap, fillMap} {
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("failed to map keys of %s: %w", name, err)
+			}
+		}
+		keyMaps[name] = keyMap
+	}
+
+	for _, name := range ordered {
+		if _, err := tx.ExecContext(ctx, cloneInsertSQL(source, target, name, layout[name], layout, keyMaps)); err != nil {
+			return fmt.Errorf("failed to copy table %s: %w", name, err)
+		}
+	}
+
+	for _, name := range ordered {
+		qualified := pq.QuoteIdentifier(target) + "." + pq.QuoteIdentifier(name)
+		for _, column := range layout[name].columns {
+			if !column.sequenced {
+				continue
+			}
+			resetSQL := fmt.Sprintf(`SELECT setval(pg_get_serial_sequence($1, $2), COALESCE(MAX(%s), 0) + 1, false) FROM %s`,
+				pq.QuoteIdentifier(column.name), qualified)
+			if _, err := tx.ExecContext(ctx, resetSQL, qualified, column.name); err != nil {
+				return fmt.Errorf("failed to reset sequence of %s.%s: %w", name, column.name, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	sm.logger.Info("Copied tenant data",
+		zap.String("source_schema", source),
+		zap.String("schema_name", target),
+		zap.Strings("tables", ordered))
+
+	return nil
+}
+
+// cloneInsertSQL builds the statement copying name from source to target,
+// swapping regenerated keys and the foreign keys to them for their new values
+func cloneInsertSQL(source, target, name string, table *cloneTable, layout map[string]*cloneTable, keyMaps map[string]string) string {
+	columns := make([]string, 0, len(table.columns))
+	values := make([]string, 0, len(table.columns))
+	joins := []string{}
+
+	for _, column := range table.columns {
+		quoted := pq.QuoteIdentifier(column.name)
+		columns = append(columns, quoted)
+
+		if column.name == table.key && keyMaps[name] != "" {
+			joins = append(joins, fmt.Sprintf("JOIN pg_temp.%s k ON k.old_id = s.%s", keyMaps[name], quoted))
+			values = append(values, "k.new_id")
+			continue
+		}
+
+		ref, ok := table.references[column.name]
+		if ok && keyMaps[ref.table] != "" && layout[ref.table].key == ref.column {
+			alias := fmt.Sprintf("r%d", len(joins))
+			joins = append(joins, fmt.Sprintf("LEFT JOIN pg_temp.%s %s ON %s.old_id = s.%s", keyMaps[ref.table], alias, alias, quoted))
+			values = append(values, fmt.Sprintf("COALESCE(%s.new_id, s.%s)", alias, quoted))
+			continue
+		}
+
+		values = append(values, "s."+quoted)
+	}
+
+	return fmt.Sprintf(`INSERT INTO %s.%s (%s) OVERRIDING SYSTEM VALUE SELECT %s FROM %s.%s s %s`,
+		pq.QuoteIdentifier(target), pq.QuoteIdentifier(name), strings.Join(columns, ", "),
+		strings.Join(values, ", "), pq.QuoteIdentifier(source), pq.QuoteIdentifier(name), strings.Join(joins, " "))
+}
+
+// loadCloneLayout reads the tables of schema with their copyable columns,
+// primary keys and foreign keys
+func loadCloneLayout(ctx context.Context, tx *sql.Tx, schema string) (map[string]*cloneTable, error) {
+	layout := make(map[string]*cloneTable)
+
+	columnsQuery := `
+		SELECT c.table_name, c.column_name, c.is_generated, COALESCE(c.is_identity, 'NO'), COALESCE(c.column_default, '')
+		FROM information_schema.columns c
+		JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = $1 AND t.table_type = 'BASE TABLE'
+		ORDER BY c.table_name, c.ordinal_position
+	`
+	rows, err := tx.QueryContext(ctx, columnsQuery, schema)
+	if err != nil {
+		return nil, fmt.Errorf("error listing tenant columns: %w", err)
+	}
+	for rows.Next() {
+		var tableName, columnName, generated, identity, columnDefault string
+		if err := rows.Scan(&tableName, &columnName, &generated, &identity, &columnDefault); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error scanning tenant column: %w", err)
+		}
+		table := layout[tableName]
+		if table == nil {
+			table = &cloneTable{references: make(map[string]cloneReference), parents: make(map[string]bool)}
+			layout[tableName] = table
+		}
+		if generated == "ALWAYS" {
+			continue // computed from the other columns
+		}
+		table.columns = append(table.columns, cloneColumn{
+			name:      columnName,
+			sequenced: identity == "YES" || strings.HasPrefix(columnDefault, "nextval("),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("error iterating tenant columns: %w", err)
+	}
+	rows.Close()
+
+	keysQuery := `
+		SELECT rel.relname, att.attname, format_type(att.atttypid, att.atttypmod), COALESCE(pg_get_expr(d.adbin, d.adrelid), '')
+		FROM pg_catalog.pg_constraint c
+		JOIN pg_catalog.pg_class rel ON rel.oid = c.conrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = rel.relnamespace
+		JOIN pg_catalog.pg_attribute att ON att.attrelid = c.conrelid AND att.attnum = c.conkey[1]
+		LEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = c.conrelid AND d.adnum = c.conkey[1]
+		WHERE n.nspname = $1 AND c.contype = 'p' AND cardinality(c.conkey) = 1
+	`
+	rows, err = tx.QueryContext(ctx, keysQuery, schema)
+	if err != nil {
+		return nil, fmt.Errorf("error listing tenant primary keys: %w", err)
+	}
+	for rows.Next() {
+		var tableName, columnName, columnType, columnDefault string
+		if err := rows.Scan(&tableName, &columnName, &columnType, &columnDefault); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error scanning tenant primary key: %w", err)
+		}
+		if table := layout[tableName]; table != nil && columnType == "uuid" && columnDefault != "" {
+			table.key = columnName
+			table.keyDefault = columnDefault
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("error iterating tenant primary keys: %w", err)
+	}
+	rows.Close()
+
+	referencesQuery := `
+		SELECT rel.relname, att.attname, frel.relname, fatt.attname, cardinality(c.conkey)
+		FROM pg_catalog.pg_constraint c
+		JOIN pg_catalog.pg_class rel ON rel.oid = c.conrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = rel.relnamespace
+		JOIN pg_catalog.pg_class frel ON frel.oid = c.confrelid
+		JOIN pg_catalog.pg_namespace fn ON fn.oid = frel.relnamespace
+		JOIN pg_catalog.pg_attribute att ON att.attrelid = c.conrelid AND att.attnum = c.conkey[1]
+		JOIN pg_catalog.pg_attribute fatt ON fatt.attrelid = c.confrelid AND fatt.attnum = c.confkey[1]
+		WHERE n.nspname = $1 AND fn.nspname = $1 AND c.contype = 'f'
+	`
+	rows, err = tx.QueryContext(ctx, referencesQuery, schema)
+	if err != nil {
+		return nil, fmt.Errorf("error listing tenant foreign keys: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tableName, columnName, refTable, refColumn string
+		var keyColumns int
+		if err := rows.Scan(&tableName, &columnName, &refTable, &refColumn, &keyColumns); err != nil {
+			return nil, fmt.Errorf("error scanning tenant foreign key: %w", err)
+		}
+		table := layout[tableName]
+		if table == nil {
+			continue
+		}
+		if refTable != tableName {
+			table.parents[refTable] = true
+		}
+		if keyColumns == 1 {
+			table.references[columnName] = cloneReference{table: refTable, column: refColumn}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tenant foreign keys: %w", err)
+	}
+
+	return layout, nil
+}
+
+// orderCloneTables sorts tables so that every table comes after the tables
+// it has foreign keys to. Tables in a cycle keep their name order.
+func orderCloneTables(layout map[string]*cloneTable, tables []string) []string {
+	pending := make([]string, len(tables))
+	copy(pending, tables)
+	sort.Strings(pending)
+
+	selected := make(map[string]bool, len(pending))
+	for _, name := range pending {
+		selected[name] = true
+	}
+
+	ordered := make([]string, 0, len(pending))
+	placed := make(map[string]bool, len(pending))
+	for len(pending) > 0 {
+		var next []string
+		for _, name := range pending {
+			ready := true
+			for parent := range layout[name].parents {
+				if selected[parent] && !placed[parent] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				ordered = append(ordered, name)
+				placed[name] = true
+			} else {
+				next = append(next, name)
+			}
+		}
+		if len(next) == len(pending) {
+			// A foreign key cycle; copy the rest as they are
+			return append(ordered, next...)
+		}
+		pending = next
+	}
+
+	return ordered
+}
@@ -2,8 +2,16 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/alexalmadav/go-multitenant/tenant"
 	"github.com/google/uuid"
 	"go.uber.org/zap/zaptest"
 )
@@ -24,6 +32,45 @@ func TestNewSchemaManager(t *testing.T) {
 	}
 }
 
+func TestNewSchemaManagerFromConfig(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	config := tenant.DefaultConfig().Database
+	config.SchemaPrefix = "org_"
+	config.SchemaFunctions = []string{"CREATE FUNCTION {{.Schema}}.audit() RETURNS TRIGGER AS $$ BEGIN RETURN NEW; END; $$ LANGUAGE plpgsql"}
+	config.SchemaTriggers = []string{"CREATE TRIGGER audit_projects AFTER INSERT ON {{.Schema}}.projects FOR EACH ROW EXECUTE FUNCTION {{.Schema}}.audit()"}
+
+	sm := NewSchemaManagerFromConfig(nil, logger, config)
+	if sm.schemaPrefix != "org_" {
+		t.Errorf("NewSchemaManagerFromConfig() schemaPrefix = %v, want org_", sm.schemaPrefix)
+	}
+	if len(sm.functions) != 1 || len(sm.triggers) != 1 {
+		t.Errorf("NewSchemaManagerFromConfig() functions = %d, triggers = %d, want 1 each", len(sm.functions), len(sm.triggers))
+	}
+}
+
+func TestRenderSchemaDDL(t *testing.T) {
+	got, err := renderSchemaDDL("CREATE TRIGGER t AFTER INSERT ON {{.Schema}}.projects FOR EACH ROW EXECUTE FUNCTION {{.Schema}}.audit()", `"tenant_abc"`)
+	if err != nil {
+		t.Fatalf("renderSchemaDDL() error = %v", err)
+	}
+
+	want := `CREATE TRIGGER t AFTER INSERT ON "tenant_abc".projects FOR EACH ROW EXECUTE FUNCTION "tenant_abc".audit()`
+	if got != want {
+		t.Errorf("renderSchemaDDL() = %v, want %v", got, want)
+	}
+
+	// DDL without placeholders is passed through unchanged
+	plain := "CREATE FUNCTION audit() RETURNS TRIGGER AS $$ BEGIN RETURN NEW; END; $$ LANGUAGE plpgsql"
+	if got, err := renderSchemaDDL(plain, `"tenant_abc"`); err != nil || got != plain {
+		t.Errorf("renderSchemaDDL() = %v, %v, want unchanged DDL", got, err)
+	}
+
+	if _, err := renderSchemaDDL("{{.Unknown}}", `"tenant_abc"`); err == nil {
+		t.Error("renderSchemaDDL() should error on unknown fields")
+	}
+}
+
 func TestSchemaManager_GetSchemaName(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	sm := NewSchemaManager(nil, logger, "tenant_")
@@ -247,6 +294,147 @@ func TestSchemaManager_Implementation(t *testing.T) {
 	} = sm
 }
 
+func TestSchemaManager_ImplementsSchemaInspector(t *testing.T) {
+	var _ tenant.SchemaInspector = NewSchemaManager(nil, zaptest.NewLogger(t), "tenant_")
+}
+
+func TestSchemaManager_RenameSchema_InvalidName(t *testing.T) {
+	var _ tenant.SchemaRenamer = (*SchemaManager)(nil)
+	sm := NewSchemaManager(nil, zaptest.NewLogger(t), "tenant_")
+
+	for _, name := range []string{"", strings.Repeat("s", maxSchemaNameLength+1)} {
+		var validationErr *tenant.ValidationError
+		if err := sm.RenameSchema(context.Background(), "tenant_old", name); !errors.As(err, &validationErr) {
+			t.Errorf("RenameSchema(%q) error = %v, want a ValidationError", name, err)
+		}
+		if err := sm.RenameSchema(context.Background(), name, "tenant_new"); !errors.As(err, &validationErr) {
+			t.Errorf("RenameSchema(%q, ...) error = %v, want a ValidationError", name, err)
+		}
+	}
+}
+
+func TestSchemaManager_ExpectedTables(t *testing.T) {
+	sm := NewSchemaManager(nil, zaptest.NewLogger(t), "tenant_")
+
+	want := []string{"documents", "projects", "tasks", "tenant_users"}
+	tables := sm.ExpectedTables()
+	if !reflect.DeepEqual(tables, want) {
+		t.Errorf("ExpectedTables() = %v, want %v", tables, want)
+	}
+
+	// Callers get a copy of the cached list
+	tables[0] = "modified"
+	if got := sm.ExpectedTables(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpectedTables() after modifying result = %v, want %v", got, want)
+	}
+}
+
+func TestSchemaManager_VerifyNoLeakage(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	tests := []struct {
+		name         string
+		publicTables []string
+		wantErr      bool
+	}{
+		{name: "clean public schema", publicTables: []string{"tenants", "tenant_migrations"}},
+		{name: "leaked tenant table", publicTables: []string{"tenants", "projects"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := sql.OpenDB(publicTablesConnector{tables: tt.publicTables})
+			defer db.Close()
+
+			sm := NewSchemaManager(db, logger, "tenant_")
+			err := sm.VerifyNoLeakage(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyNoLeakage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), "projects") {
+				t.Errorf("VerifyNoLeakage() error = %v, should name the leaked table", err)
+			}
+		})
+	}
+}
+
+// publicTablesConnector simulates a public schema containing the given tables
+type publicTablesConnector struct {
+	tables []string
+}
+
+func (c publicTablesConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &publicTablesConn{tables: c.tables}, nil
+}
+
+func (c publicTablesConnector) Driver() driver.Driver { return publicTablesDriver{} }
+
+type publicTablesDriver struct{}
+
+func (publicTablesDriver) Open(name string) (driver.Conn, error) {
+	return nil, fmt.Errorf("use publicTablesConnector")
+}
+
+type publicTablesConn struct {
+	tables []string
+}
+
+func (c *publicTablesConn) Prepare(query string) (driver.Stmt, error) {
+	return &publicTablesStmt{tables: c.tables}, nil
+}
+
+func (c *publicTablesConn) Close() error { return nil }
+
+func (c *publicTablesConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("transactions not supported")
+}
+
+type publicTablesStmt struct {
+	tables []string
+}
+
+func (s *publicTablesStmt) Close() error { return nil }
+
+func (s *publicTablesStmt) NumInput() int { return 1 }
+
+func (s *publicTablesStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, fmt.Errorf("exec not supported")
+}
+
+// Query returns the public tables named in the array argument
+func (s *publicTablesStmt) Query(args []driver.Value) (driver.Rows, error) {
+	names := strings.Split(strings.Trim(fmt.Sprint(args[0]), "{}"), ",")
+
+	var matched []string
+	for _, table := range s.tables {
+		for _, name := range names {
+			if table == strings.Trim(name, `"`) {
+				matched = append(matched, table)
+			}
+		}
+	}
+
+	return &stringRows{values: matched}, nil
+}
+
+// stringRows is a single-column result of string values
+type stringRows struct {
+	values []string
+}
+
+func (r *stringRows) Columns() []string { return []string{"table_name"} }
+
+func (r *stringRows) Close() error { return nil }
+
+func (r *stringRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0] = r.values[0]
+	r.values = r.values[1:]
+	return nil
+}
+
 // Helper function for string contains check
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && s[:len(substr)] == substr ||
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+)
+
+// resetTenantSessionSQL undoes the session state GetTenantConn sets
+const resetTenantSessionSQL = "RESET " + TenantSessionSetting + "; RESET search_path"
+
+// Ensure the connections track their tenant scope
+var _ tenant.SessionScopeTracker = (*sessionResetConn)(nil)
+
+// NewSessionResetConnector wraps connector so that connections scoped to a
+// tenant for their session, as GetTenantConn does under shared isolation,
+// have TenantSessionSetting and search_path reset before the pool hands them
+// out again. Otherwise a pooled connection released by a tenant keeps that
+// tenant's row-level security scope for whoever uses it next. Connections
+// that were not scoped are reused without an extra round trip.
+func NewSessionResetConnector(connector driver.Connector) driver.Connector {
+	return sessionResetConnector{connector: connector}
+}
+
+type sessionResetConnector struct {
+	connector driver.Connector
+}
+
+func (c sessionResetConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &sessionResetConn{Conn: conn}, nil
+}
+
+func (c sessionResetConnector) Driver() driver.Driver { return c.connector.Driver() }
+
+// sessionResetConn forwards to the driver's connection, resetting the tenant
+// session state in ResetSession once MarkTenantScoped was called
+type sessionResetConn struct {
+	driver.Conn
+	scoped atomic.Bool
+}
+
+// MarkTenantScoped implements tenant.SessionScopeTracker
+func (c *sessionResetConn) MarkTenantScoped() {
+	c.scoped.Store(true)
+}
+
+// ResetSession implements driver.SessionResetter. A connection whose reset
+// fails is discarded.
+func (c *sessionResetConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		if err := resetter.ResetSession(ctx); err != nil {
+			return err
+		}
+	}
+	if !c.scoped.Load() {
+		return nil
+	}
+
+	if _, err := c.ExecContext(ctx, resetTenantSessionSQL, nil); err != nil {
+		return driver.ErrBadConn
+	}
+	c.scoped.Store(false)
+	return nil
+}
+
+// IsValid implements driver.Validator
+func (c *sessionResetConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *sessionResetConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if execer, ok := c.Conn.(driver.ExecerContext); ok {
+		return execer.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *sessionResetConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if queryer, ok := c.Conn.(driver.QueryerContext); ok {
+		return queryer.QueryContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *sessionResetConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Prepare(query)
+}
+
+func (c *sessionResetConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	if opts != (driver.TxOptions{}) {
+		return nil, errors.New("driver does not support transaction options")
+	}
+	return c.Begin()
+}
+
+func (c *sessionResetConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
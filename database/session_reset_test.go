@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+)
+
+func TestSessionResetConnector(t *testing.T) {
+	recorder := &statementRecorder{}
+	db := sql.OpenDB(NewSessionResetConnector(statementConnector{recorder: recorder}))
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+
+	// A connection scoped to a tenant is reset before it is reused
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn() error = %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, "SELECT set_config('app.current_tenant', 'acme', false)"); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+	conn.Raw(func(driverConn interface{}) error {
+		driverConn.(tenant.SessionScopeTracker).MarkTenantScoped()
+		return nil
+	})
+	conn.Close()
+
+	if _, err := db.ExecContext(ctx, "SELECT 1"); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+	want := []string{"SELECT set_config('app.current_tenant', 'acme', false)", resetTenantSessionSQL, "SELECT 1"}
+	if got := recorder.all(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+
+	// Connections that were not scoped are reused as they are
+	if _, err := db.ExecContext(ctx, "SELECT 2"); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+	if got := recorder.all(); len(got) != 4 || got[3] != "SELECT 2" {
+		t.Errorf("statements = %q, want no second reset", got)
+	}
+}
+
+// statementRecorder records the statements run on its connections
+type statementRecorder struct {
+	mu         sync.Mutex
+	statements []string
+}
+
+func (r *statementRecorder) all() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.statements...)
+}
+
+type statementConnector struct {
+	recorder *statementRecorder
+}
+
+func (c statementConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &statementConn{recorder: c.recorder}, nil
+}
+
+func (c statementConnector) Driver() driver.Driver { return recordingDriver{} }
+
+type statementConn struct {
+	recorder *statementRecorder
+}
+
+func (c *statementConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("statement connection does not prepare statements")
+}
+
+func (c *statementConn) Close() error { return nil }
+
+func (c *statementConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("statement connection does not support transactions")
+}
+
+func (c *statementConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.recorder.mu.Lock()
+	defer c.recorder.mu.Unlock()
+	c.recorder.statements = append(c.recorder.statements, query)
+	return driver.RowsAffected(0), nil
+}
@@ -0,0 +1,309 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// TenantSessionSetting is the PostgreSQL setting that holds the tenant a
+// session is scoped to in shared-schema mode. Row-level security policies
+// on the shared tables only return and accept rows of that tenant.
+const TenantSessionSetting = "app.current_tenant"
+
+// DefaultSharedSchema is the schema holding the shared tenant tables when
+// NewSharedSchemaManager is given no name
+const DefaultSharedSchema = "tenant_data"
+
+// currentTenantSQL reads TenantSessionSetting as a UUID, NULL when it is unset
+// so that a session without a tenant matches no rows
+const currentTenantSQL = "NULLIF(current_setting('" + TenantSessionSetting + "', true), '')::uuid"
+
+// SharedSchemaManager implements tenant.SchemaManager by storing every
+// tenant's rows in one set of tables with a tenant_id column, isolated by
+// row-level security policies keyed off TenantSessionSetting. It scales to
+// many more tenants than a schema each, at the cost of relying on the
+// policies: the application must connect as a role that is neither a
+// superuser nor has BYPASSRLS, as those ignore them.
+type SharedSchemaManager struct {
+	db     *sql.DB
+	logger *zap.Logger
+	schema string
+}
+
+// Ensure SharedSchemaManager implements the schema manager interfaces
+var (
+	_ tenant.SchemaManager = (*SharedSchemaManager)(nil)
+	_ tenant.SessionScoper = (*SharedSchemaManager)(nil)
+)
+
+// NewSharedSchemaManager creates a schema manager that keeps all tenants in
+// the shared tables of schema, DefaultSharedSchema if empty
+func NewSharedSchemaManager(db *sql.DB, logger *zap.Logger, schema string) *SharedSchemaManager {
+	if schema == "" {
+		schema = DefaultSharedSchema
+	}
+
+	return &SharedSchemaManager{
+		db:     db,
+		logger: logger.Named("shared_schema"),
+		schema: schema,
+	}
+}
+
+// CreateTables creates the shared schema and its tables, enables row-level
+// security on them and installs the tenant isolation policies. It is safe to
+// run again, e.g. at every startup.
+func (sm *SharedSchemaManager) CreateTables(ctx context.Context) error {
+	tx, err := sm.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, statement := range sm.tableDDL() {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to create shared tenant tables: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	sm.logger.Info("Created shared tenant tables", zap.String("schema", sm.schema))
+	return nil
+}
+
+// GetSchemaName returns the shared schema, which holds every tenant's tables
+func (sm *SharedSchemaManager) GetSchemaName(tenantID uuid.UUID) string {
+	return sm.schema
+}
+
+// CreateTenantSchema registers the tenant in the shared schema, so that
+// SchemaExists reports it as provisioned. No tables are created: the
+// tenant's rows go into the shared tables, which CreateTables creates.
+func (sm *SharedSchemaManager) CreateTenantSchema(ctx context.Context, tenantID uuid.UUID, name string) error {
+	query := fmt.Sprintf("INSERT INTO %s.tenant_registry (tenant_id) VALUES ($1) ON CONFLICT DO NOTHING", sm.quotedSchema())
+	if _, err := sm.db.ExecContext(ctx, query, tenantID); err != nil {
+		return fmt.Errorf("failed to register tenant in shared schema: %w", err)
+	}
+
+	sm.logger.Info("Registered tenant in shared tables",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("schema", sm.schema),
+		zap.String("tenant_name", name))
+
+	return nil
+}
+
+// DropTenantSchema deletes the tenant's rows from every shared table and
+// its registration
+func (sm *SharedSchemaManager) DropTenantSchema(ctx context.Context, tenantID uuid.UUID) error {
+	sm.logger.Warn("Dropping tenant data from shared tables",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("schema", sm.schema))
+
+	tx, err := sm.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := sm.ScopeSession(ctx, tx, tenantID, true); err != nil {
+		return err
+	}
+
+	// Children first, although deleting projects would cascade to them
+	for _, table := range []string{"tasks", "documents", "tenant_users", "projects"} {
+		query := fmt.Sprintf("DELETE FROM %s.%s WHERE tenant_id = $1", sm.quotedSchema(), table)
+		if _, err := tx.ExecContext(ctx, query, tenantID); err != nil {
+			return fmt.Errorf("failed to delete tenant rows from %s: %w", table, err)
+		}
+	}
+
+	unregister := fmt.Sprintf("DELETE FROM %s.tenant_registry WHERE tenant_id = $1", sm.quotedSchema())
+	if _, err := tx.ExecContext(ctx, unregister, tenantID); err != nil {
+		return fmt.Errorf("failed to unregister tenant: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	sm.logger.Info("Successfully dropped tenant data from shared tables",
+		zap.String("tenant_id", tenantID.String()))
+
+	return nil
+}
+
+// SchemaExists reports whether the tenant has been registered by
+// CreateTenantSchema
+func (sm *SharedSchemaManager) SchemaExists(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s.tenant_registry WHERE tenant_id = $1)", sm.quotedSchema())
+
+	var exists bool
+	if err := sm.db.QueryRowContext(ctx, query, tenantID).Scan(&exists); err != nil {
+		sm.logger.Error("Failed to check tenant registration",
+			zap.String("schema_name", sm.schema),
+			zap.String("tenant_id", tenantID.String()),
+			zap.Error(err))
+		return false, fmt.Errorf("error checking schema existence: %w", err)
+	}
+
+	return exists, nil
+}
+
+// SetSearchPath sets the search path to the shared schema and scopes the
+// session to the tenant. Like SchemaManager.SetSearchPath it only affects
+// one pooled connection; use GetTenantConn or WithTenantTx instead.
+func (sm *SharedSchemaManager) SetSearchPath(db *sql.DB, tenantID uuid.UUID) error {
+	// One round trip, so that both settings land on the same connection
+	query := fmt.Sprintf("SET search_path TO %s, public; SET %s = '%s'", sm.quotedSchema(), TenantSessionSetting, tenantID)
+
+	if _, err := db.Exec(query); err != nil {
+		sm.logger.Error("Failed to set search path",
+			zap.String("tenant_id", tenantID.String()),
+			zap.Error(err))
+		return fmt.Errorf("error setting search path: %w", err)
+	}
+
+	return nil
+}
+
+// ListTenantSchemas returns the shared schema if it exists
+func (sm *SharedSchemaManager) ListTenantSchemas(ctx context.Context) ([]string, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM information_schema.schemata WHERE schema_name = $1)`
+
+	var exists bool
+	if err := sm.db.QueryRowContext(ctx, query, sm.schema).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to query tenant schemas: %w", err)
+	}
+	if !exists {
+		return []string{}, nil
+	}
+	return []string{sm.schema}, nil
+}
+
+// ScopeSession sets TenantSessionSetting to the tenant on conn, for the rest
+// of the transaction when local is true and of the session otherwise
+func (sm *SharedSchemaManager) ScopeSession(ctx context.Context, conn tenant.RowQuerier, tenantID uuid.UUID, local bool) error {
+	var value string
+	err := conn.QueryRowContext(ctx, "SELECT set_config($1, $2, $3)", TenantSessionSetting, tenantID.String(), local).Scan(&value)
+	if err != nil {
+		return fmt.Errorf("failed to set %s: %w", TenantSessionSetting, err)
+	}
+	return nil
+}
+
+// quotedSchema returns the shared schema name quoted for SQL
+func (sm *SharedSchemaManager) quotedSchema() string {
+	return fmt.Sprintf(`"%s"`, sm.schema)
+}
+
+// tableDDL returns the statements creating the tenant registry and the
+// shared tables, the same tables as a tenant schema with a tenant_id column,
+// and their policies. References between tables include tenant_id, so rows
+// cannot point at another tenant's rows.
+func (sm *SharedSchemaManager) tableDDL() []string {
+	schema := sm.quotedSchema()
+	tenantColumn := "tenant_id UUID NOT NULL DEFAULT " + currentTenantSQL
+
+	statements := []string{
+		fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", schema),
+
+		// Read by SchemaExists without a tenant set, so not under a policy
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.tenant_registry (
+			tenant_id UUID PRIMARY KEY,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)`, schema),
+
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.projects (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			%s,
+			name VARCHAR(255) NOT NULL,
+			description TEXT,
+			status VARCHAR(50) NOT NULL DEFAULT 'active',
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (tenant_id, id)
+		)`, schema, tenantColumn),
+
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.tasks (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			%s,
+			project_id UUID NOT NULL,
+			title VARCHAR(255) NOT NULL,
+			description TEXT,
+			status VARCHAR(50) NOT NULL DEFAULT 'pending',
+			priority VARCHAR(20) NOT NULL DEFAULT 'medium',
+			due_date TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (tenant_id, project_id) REFERENCES %s.projects(tenant_id, id) ON DELETE CASCADE
+		)`, schema, tenantColumn, schema),
+
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.documents (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			%s,
+			project_id UUID NOT NULL,
+			file_name VARCHAR(255) NOT NULL,
+			file_path VARCHAR(500) NOT NULL,
+			file_type VARCHAR(100),
+			file_size BIGINT,
+			uploaded_by UUID,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (tenant_id, project_id) REFERENCES %s.projects(tenant_id, id) ON DELETE CASCADE
+		)`, schema, tenantColumn, schema),
+
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.tenant_users (
+			%s,
+			user_id UUID NOT NULL,
+			role VARCHAR(50) NOT NULL DEFAULT 'user',
+			permissions JSONB,
+			is_active BOOLEAN DEFAULT TRUE,
+			joined_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (tenant_id, user_id)
+		)`, schema, tenantColumn),
+
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_projects_tenant_status ON %s.projects(tenant_id, status)", schema),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_tasks_tenant_project_id ON %s.tasks(tenant_id, project_id)", schema),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_documents_tenant_project_id ON %s.documents(tenant_id, project_id)", schema),
+
+		fmt.Sprintf(`CREATE OR REPLACE FUNCTION %s.update_updated_at_column()
+		RETURNS TRIGGER AS $$
+		BEGIN
+			NEW.updated_at = CURRENT_TIMESTAMP;
+			RETURN NEW;
+		END;
+		$$ language 'plpgsql'`, schema),
+	}
+
+	for _, table := range []string{"projects", "tasks", "tenant_users"} {
+		statements = append(statements,
+			fmt.Sprintf("DROP TRIGGER IF EXISTS update_%s_updated_at ON %s.%s", table, schema, table),
+			fmt.Sprintf("CREATE TRIGGER update_%s_updated_at BEFORE UPDATE ON %s.%s FOR EACH ROW EXECUTE FUNCTION %s.update_updated_at_column()", table, schema, table, schema),
+		)
+	}
+
+	// FORCE applies the policies to the tables' owner too
+	for _, table := range baseTenantTables {
+		statements = append(statements,
+			fmt.Sprintf("ALTER TABLE %s.%s ENABLE ROW LEVEL SECURITY", schema, table),
+			fmt.Sprintf("ALTER TABLE %s.%s FORCE ROW LEVEL SECURITY", schema, table),
+			fmt.Sprintf("DROP POLICY IF EXISTS tenant_isolation ON %s.%s", schema, table),
+			fmt.Sprintf("CREATE POLICY tenant_isolation ON %s.%s USING (tenant_id = %s) WITH CHECK (tenant_id = %s)", schema, table, currentTenantSQL, currentTenantSQL),
+		)
+	}
+
+	return statements
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestNewSharedSchemaManager(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	sm := NewSharedSchemaManager(nil, logger, "")
+	if sm.schema != DefaultSharedSchema {
+		t.Errorf("NewSharedSchemaManager() schema = %v, want %v", sm.schema, DefaultSharedSchema)
+	}
+
+	sm = NewSharedSchemaManager(nil, logger, "shared")
+	if sm.schema != "shared" {
+		t.Errorf("NewSharedSchemaManager() schema = %v, want shared", sm.schema)
+	}
+}
+
+func TestSharedSchemaManager_GetSchemaName(t *testing.T) {
+	sm := NewSharedSchemaManager(nil, zaptest.NewLogger(t), "")
+
+	// Every tenant resolves to the shared tables
+	if a, b := sm.GetSchemaName(uuid.New()), sm.GetSchemaName(uuid.New()); a != DefaultSharedSchema || b != DefaultSharedSchema {
+		t.Errorf("GetSchemaName() = %v, %v, want %v for every tenant", a, b, DefaultSharedSchema)
+	}
+}
+
+func TestSharedSchemaManager_TableDDL_EnablesRowLevelSecurity(t *testing.T) {
+	sm := NewSharedSchemaManager(nil, zaptest.NewLogger(t), "")
+	ddl := strings.Join(sm.tableDDL(), ";\n")
+
+	for _, table := range baseTenantTables {
+		for _, want := range []string{
+			fmt.Sprintf(`ALTER TABLE "tenant_data".%s ENABLE ROW LEVEL SECURITY`, table),
+			fmt.Sprintf(`ALTER TABLE "tenant_data".%s FORCE ROW LEVEL SECURITY`, table),
+			fmt.Sprintf(`CREATE POLICY tenant_isolation ON "tenant_data".%s USING (tenant_id = %s) WITH CHECK (tenant_id = %s)`, table, currentTenantSQL, currentTenantSQL),
+		} {
+			if !strings.Contains(ddl, want) {
+				t.Errorf("tableDDL() is missing %q", want)
+			}
+		}
+	}
+
+	if !strings.Contains(ddl, "REFERENCES \"tenant_data\".projects(tenant_id, id)") {
+		t.Error("tableDDL() should reference projects by tenant and id")
+	}
+}
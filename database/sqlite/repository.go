@@ -0,0 +1,329 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Repository implements tenant.Repository for SQLite, keeping the master
+// tenants table in the main database
+type Repository struct {
+	db      *sql.DB
+	schemas *SchemaManager
+	logger  *zap.Logger
+}
+
+// Ensure Repository checks existence without loading rows
+var (
+	_ tenant.Repository          = (*Repository)(nil)
+	_ tenant.ExistenceRepository = (*Repository)(nil)
+)
+
+// NewRepository creates a new SQLite repository. GetStats counts tenant data
+// through schemas.
+func NewRepository(db *sql.DB, schemas *SchemaManager, logger *zap.Logger) *Repository {
+	return &Repository{
+		db:      db,
+		schemas: schemas,
+		logger:  logger.Named("sqlite_repo"),
+	}
+}
+
+// CreateMasterTables creates the tenants table in the main database
+func (r *Repository) CreateMasterTables(ctx context.Context) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS tenants (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			subdomain TEXT UNIQUE NOT NULL,
+			plan_type TEXT NOT NULL DEFAULT 'basic',
+			status TEXT NOT NULL DEFAULT 'pending',
+			schema_name TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT chk_plan_type CHECK (plan_type IN ('basic', 'pro', 'enterprise')),
+			CONSTRAINT chk_status CHECK (status IN ('active', 'suspended', 'pending', 'cancelled'))
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_tenants_status ON tenants(status)",
+	}
+
+	for _, statement := range statements {
+		if _, err := r.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to create master table: %w", err)
+		}
+	}
+
+	r.logger.Info("Created master tables")
+	return nil
+}
+
+// Create creates a new tenant
+func (r *Repository) Create(ctx context.Context, t *tenant.Tenant) error {
+	query := `
+		INSERT INTO tenants (id, name, subdomain, plan_type, status, schema_name, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	t.CreatedAt = now
+	t.UpdatedAt = now
+
+	_, err := r.db.ExecContext(ctx, query,
+		t.ID,
+		t.Name,
+		t.Subdomain,
+		t.PlanType,
+		t.Status,
+		t.SchemaName,
+		t.CreatedAt,
+		t.UpdatedAt,
+	)
+
+	if err != nil {
+		r.logger.Error("Failed to create tenant",
+			zap.String("tenant_id", t.ID.String()),
+			zap.Error(err))
+		return fmt.Errorf("failed to create tenant: %w", err)
+	}
+
+	r.logger.Info("Created tenant",
+		zap.String("tenant_id", t.ID.String()),
+		zap.String("name", t.Name),
+		zap.String("subdomain", t.Subdomain))
+
+	return nil
+}
+
+// GetByID retrieves a tenant by ID
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*tenant.Tenant, error) {
+	return r.get(ctx, "id", id)
+}
+
+// GetBySubdomain retrieves a tenant by subdomain
+func (r *Repository) GetBySubdomain(ctx context.Context, subdomain string) (*tenant.Tenant, error) {
+	return r.get(ctx, "subdomain", subdomain)
+}
+
+// get retrieves the tenant whose column equals value
+func (r *Repository) get(ctx context.Context, column string, value interface{}) (*tenant.Tenant, error) {
+	query := `
+		SELECT id, name, subdomain, plan_type, status, schema_name, created_at, updated_at
+		FROM tenants
+		WHERE ` + column + ` = ?`
+
+	t, err := scanTenant(r.db.QueryRowContext(ctx, query, value))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %w", tenant.ErrTenantNotFound, err)
+		}
+		r.logger.Error("Failed to get tenant",
+			zap.String(column, fmt.Sprint(value)),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	return t, nil
+}
+
+// ExistsByID reports whether a tenant with the given ID exists
+func (r *Repository) ExistsByID(ctx context.Context, id uuid.UUID) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM tenants WHERE id = ?)`
+	if err := r.db.QueryRowContext(ctx, query, id).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check tenant existence: %w", err)
+	}
+	return exists, nil
+}
+
+// ExistsBySubdomain reports whether a tenant uses the given subdomain
+func (r *Repository) ExistsBySubdomain(ctx context.Context, subdomain string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM tenants WHERE subdomain = ?)`
+	if err := r.db.QueryRowContext(ctx, query, subdomain).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check subdomain existence: %w", err)
+	}
+	return exists, nil
+}
+
+// Update updates a tenant
+func (r *Repository) Update(ctx context.Context, t *tenant.Tenant) error {
+	query := `
+		UPDATE tenants
+		SET name = ?, subdomain = ?, plan_type = ?, status = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	t.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		t.Name,
+		t.Subdomain,
+		t.PlanType,
+		t.Status,
+		t.UpdatedAt,
+		t.ID,
+	)
+
+	if err != nil {
+		r.logger.Error("Failed to update tenant",
+			zap.String("tenant_id", t.ID.String()),
+			zap.Error(err))
+		return fmt.Errorf("failed to update tenant: %w", err)
+	}
+
+	if err := requireRow(result); err != nil {
+		return err
+	}
+
+	r.logger.Info("Updated tenant",
+		zap.String("tenant_id", t.ID.String()),
+		zap.String("name", t.Name))
+
+	return nil
+}
+
+// Delete soft deletes a tenant (sets status to cancelled)
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE tenants SET status = ?, updated_at = ? WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, tenant.StatusCancelled, time.Now(), id)
+	if err != nil {
+		r.logger.Error("Failed to delete tenant",
+			zap.String("tenant_id", id.String()),
+			zap.Error(err))
+		return fmt.Errorf("failed to delete tenant: %w", err)
+	}
+
+	if err := requireRow(result); err != nil {
+		return err
+	}
+
+	r.logger.Info("Deleted tenant",
+		zap.String("tenant_id", id.String()))
+
+	return nil
+}
+
+// List retrieves tenants with pagination, newest first, leaving out cancelled
+// tenants
+func (r *Repository) List(ctx context.Context, page, perPage int) ([]*tenant.Tenant, int, error) {
+	page, perPage = tenant.NormalizePagination(page, perPage)
+
+	offset := (page - 1) * perPage
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM tenants WHERE status != ?`
+	if err := r.db.QueryRowContext(ctx, countQuery, tenant.StatusCancelled).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to get tenant count: %w", err)
+	}
+
+	query := `
+		SELECT id, name, subdomain, plan_type, status, schema_name, created_at, updated_at
+		FROM tenants
+		WHERE status != ?
+		ORDER BY created_at DESC
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenant.StatusCancelled, perPage, offset)
+	if err != nil {
+		r.logger.Error("Failed to list tenants", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	defer rows.Close()
+
+	var tenants []*tenant.Tenant
+	for rows.Next() {
+		t, err := scanTenant(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan tenant: %w", err)
+		}
+		tenants = append(tenants, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating tenant rows: %w", err)
+	}
+
+	return tenants, total, nil
+}
+
+// GetStats counts the tenant's projects and active users in its database.
+// SchemaExists is false, with zero counts, when the database does not exist.
+func (r *Repository) GetStats(ctx context.Context, tenantID uuid.UUID) (*tenant.Stats, error) {
+	if _, err := r.GetByID(ctx, tenantID); err != nil {
+		return nil, err
+	}
+
+	stats := &tenant.Stats{
+		TenantID:     tenantID,
+		LastActivity: time.Now(),
+	}
+
+	exists, err := r.schemas.SchemaExists(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return stats, nil
+	}
+	stats.SchemaExists = true
+
+	conn, err := r.schemas.GetTenantConn(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM projects`).Scan(&stats.ProjectCount); err != nil {
+		return nil, fmt.Errorf("failed to count projects: %w", err)
+	}
+	if err := conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM tenant_users WHERE is_active = TRUE`).Scan(&stats.UserCount); err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	return stats, nil
+}
+
+// rowScanner is a *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTenant scans the tenant columns selected by the repository's queries
+func scanTenant(row rowScanner) (*tenant.Tenant, error) {
+	t := &tenant.Tenant{}
+	err := row.Scan(
+		&t.ID,
+		&t.Name,
+		&t.Subdomain,
+		&t.PlanType,
+		&t.Status,
+		&t.SchemaName,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// requireRow returns tenant.ErrTenantNotFound if the statement matched no row
+func requireRow(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return tenant.ErrTenantNotFound
+	}
+	return nil
+}
@@ -0,0 +1,331 @@
+// Package sqlite isolates tenants in SQLite, which has no schemas, by giving
+// each tenant its own database file that is attached to a connection for the
+// duration of the tenant's queries. It suits embedded deployments and tests
+// that should not need a PostgreSQL server.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// DatabaseExtension is the file extension of tenant database files
+const DatabaseExtension = ".db"
+
+// ErrSearchPathUnsupported is returned by SetSearchPath. Attaching a tenant
+// database to a pooled connection would leave it attached for whatever query
+// uses the connection next, so tenant queries must go through GetTenantConn
+// or WithTenantTx.
+var ErrSearchPathUnsupported = errors.New("sqlite: tenant databases are attached per connection; use GetTenantConn or WithTenantTx")
+
+// SchemaManager implements tenant.SchemaManager for SQLite. Each tenant gets a
+// database file in the manager's directory, named like a PostgreSQL tenant
+// schema, e.g. tenant_<id>.db, and GetSchemaName returns the name it is
+// attached as. Queries reach the tenant's tables through a dedicated
+// connection with only that tenant's database attached; see GetTenantConn and
+// WithTenantTx.
+//
+// The main database, the one db was opened on, holds the master tables and
+// must not contain tables named like the tenant tables: SQLite resolves
+// unqualified table names in the main database before attached ones.
+type SchemaManager struct {
+	db             *sql.DB
+	logger         *zap.Logger
+	dir            string
+	databasePrefix string
+}
+
+// Ensure SchemaManager implements tenant.SchemaManager interface
+var _ tenant.SchemaManager = (*SchemaManager)(nil)
+
+// NewSchemaManager creates a new SQLite schema manager that keeps tenant
+// databases in dir
+func NewSchemaManager(db *sql.DB, logger *zap.Logger, dir, databasePrefix string) *SchemaManager {
+	if databasePrefix == "" {
+		databasePrefix = "tenant_"
+	}
+
+	return &SchemaManager{
+		db:             db,
+		logger:         logger.Named("sqlite_schema"),
+		dir:            dir,
+		databasePrefix: databasePrefix,
+	}
+}
+
+// GetSchemaName returns the name the tenant's database is attached as, which
+// is also the base name of its file
+func (sm *SchemaManager) GetSchemaName(tenantID uuid.UUID) string {
+	return fmt.Sprintf("%s%s", sm.databasePrefix, strings.ReplaceAll(tenantID.String(), "-", "_"))
+}
+
+// DatabasePath returns the path of the tenant's database file
+func (sm *SchemaManager) DatabasePath(tenantID uuid.UUID) string {
+	return filepath.Join(sm.dir, sm.GetSchemaName(tenantID)+DatabaseExtension)
+}
+
+// CreateTenantSchema creates the tenant's database file with all required
+// tables. If a table cannot be created the file is removed again.
+func (sm *SchemaManager) CreateTenantSchema(ctx context.Context, tenantID uuid.UUID, name string) error {
+	databaseName := sm.GetSchemaName(tenantID)
+
+	sm.logger.Info("Creating tenant database",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("database", databaseName),
+		zap.String("tenant_name", name))
+
+	conn, err := sm.attach(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to create database %s: %w", databaseName, err)
+	}
+
+	err = sm.createTenantTables(ctx, conn, quoteIdentifier(databaseName))
+	if closeErr := conn.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to detach database %s: %w", databaseName, closeErr)
+	}
+	if err != nil {
+		if dropErr := sm.DropTenantSchema(context.WithoutCancel(ctx), tenantID); dropErr != nil {
+			sm.logger.Error("Failed to drop database after table creation failed",
+				zap.String("tenant_id", tenantID.String()),
+				zap.Error(dropErr))
+		}
+		return fmt.Errorf("failed to create tenant tables: %w", err)
+	}
+
+	sm.logger.Info("Successfully created tenant database",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("database", databaseName))
+
+	return nil
+}
+
+// DropTenantSchema removes the tenant's database file, with its journal, and
+// all its data
+func (sm *SchemaManager) DropTenantSchema(ctx context.Context, tenantID uuid.UUID) error {
+	databaseName := sm.GetSchemaName(tenantID)
+	path := sm.DatabasePath(tenantID)
+
+	sm.logger.Warn("Dropping tenant database",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("database", databaseName))
+
+	for _, file := range []string{path, path + "-journal", path + "-wal", path + "-shm"} {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to drop database %s: %w", databaseName, err)
+		}
+	}
+
+	sm.logger.Info("Successfully dropped tenant database",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("database", databaseName))
+
+	return nil
+}
+
+// SchemaExists checks if the tenant's database file exists
+func (sm *SchemaManager) SchemaExists(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	_, err := os.Stat(sm.DatabasePath(tenantID))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		sm.logger.Error("Failed to check database existence",
+			zap.String("tenant_id", tenantID.String()),
+			zap.Error(err))
+		return false, fmt.Errorf("error checking database existence: %w", err)
+	}
+	return true, nil
+}
+
+// SetSearchPath returns ErrSearchPathUnsupported; use GetTenantConn or
+// WithTenantTx for tenant-scoped queries
+func (sm *SchemaManager) SetSearchPath(db *sql.DB, tenantID uuid.UUID) error {
+	return ErrSearchPathUnsupported
+}
+
+// ListTenantSchemas returns the names of all tenant databases in the
+// manager's directory
+func (sm *SchemaManager) ListTenantSchemas(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(sm.dir)
+	if err != nil {
+		sm.logger.Error("Failed to list tenant databases", zap.Error(err))
+		return nil, fmt.Errorf("error listing tenant databases: %w", err)
+	}
+
+	var databases []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && strings.HasPrefix(name, sm.databasePrefix) && strings.HasSuffix(name, DatabaseExtension) {
+			databases = append(databases, strings.TrimSuffix(name, DatabaseExtension))
+		}
+	}
+
+	return databases, nil
+}
+
+// TenantConn is a dedicated connection with a tenant's database attached.
+// Close detaches it, so that the connection does not go back to the pool
+// scoped to the tenant; a connection that cannot be detached is discarded
+// instead.
+type TenantConn struct {
+	*sql.Conn
+	databaseName string
+}
+
+// Close detaches the tenant's database and returns the connection to the pool
+func (c *TenantConn) Close() error {
+	if _, err := c.Conn.ExecContext(context.Background(), "DETACH DATABASE "+quoteIdentifier(c.databaseName)); err == nil {
+		return c.Conn.Close()
+	}
+
+	// The connection cannot be unscoped, so make the pool discard it
+	c.Conn.Raw(func(driverConn interface{}) error { return driver.ErrBadConn })
+	if err := c.Conn.Close(); err != nil && !errors.Is(err, sql.ErrConnDone) {
+		return err
+	}
+	return nil
+}
+
+// GetTenantConn returns a dedicated connection with the tenant's database
+// attached, so that unqualified table names resolve to the tenant's tables.
+// The caller must Close it.
+func (sm *SchemaManager) GetTenantConn(ctx context.Context, tenantID uuid.UUID) (*TenantConn, error) {
+	exists, err := sm.SchemaExists(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("tenant database %s does not exist", sm.GetSchemaName(tenantID))
+	}
+
+	conn, err := sm.attach(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	sm.logger.Debug("Acquired tenant connection",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("database", conn.databaseName))
+
+	return conn, nil
+}
+
+// WithTenantTx runs fn in a transaction on a connection with the tenant's
+// database attached, committing if fn succeeds and rolling back otherwise
+func (sm *SchemaManager) WithTenantTx(ctx context.Context, tenantID uuid.UUID, fn func(tx *sql.Tx) error) error {
+	conn, err := sm.GetTenantConn(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// attach acquires a dedicated connection and attaches the tenant's database
+// file to it, creating the file if it does not exist
+func (sm *SchemaManager) attach(ctx context.Context, tenantID uuid.UUID) (*TenantConn, error) {
+	conn, err := sm.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	databaseName := sm.GetSchemaName(tenantID)
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS "+quoteIdentifier(databaseName), sm.DatabasePath(tenantID)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to attach tenant database: %w", err)
+	}
+
+	return &TenantConn{Conn: conn, databaseName: databaseName}, nil
+}
+
+// createTenantTables creates the standard tenant tables, the SQLite
+// equivalents of those database.SchemaManager creates in PostgreSQL, in the
+// attached tenant database. SQLite qualifies an index by its own name, and
+// the table it indexes or references must be in the same database.
+func (sm *SchemaManager) createTenantTables(ctx context.Context, conn *TenantConn, quotedDatabase string) error {
+	tables := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.projects (
+			id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(16)))),
+			name TEXT NOT NULL,
+			description TEXT,
+			status TEXT NOT NULL DEFAULT 'active',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`, quotedDatabase),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s.idx_projects_status ON projects(status)`, quotedDatabase),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s.idx_projects_created_at ON projects(created_at)`, quotedDatabase),
+
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.tasks (
+			id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(16)))),
+			project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			title TEXT NOT NULL,
+			description TEXT,
+			status TEXT NOT NULL DEFAULT 'pending',
+			priority TEXT NOT NULL DEFAULT 'medium',
+			due_date TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`, quotedDatabase),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s.idx_tasks_status ON tasks(status)`, quotedDatabase),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s.idx_tasks_due_date ON tasks(due_date)`, quotedDatabase),
+
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.documents (
+			id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(16)))),
+			project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			file_name TEXT NOT NULL,
+			file_path TEXT NOT NULL,
+			file_type TEXT,
+			file_size INTEGER,
+			uploaded_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`, quotedDatabase),
+
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.tenant_users (
+			user_id TEXT NOT NULL PRIMARY KEY,
+			role TEXT NOT NULL DEFAULT 'user',
+			permissions TEXT,
+			is_active BOOLEAN DEFAULT TRUE,
+			joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`, quotedDatabase),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s.idx_tenant_users_role ON tenant_users(role)`, quotedDatabase),
+	}
+
+	for _, tableSQL := range tables {
+		if _, err := conn.ExecContext(ctx, tableSQL); err != nil {
+			return fmt.Errorf("failed to create table: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// quoteIdentifier quotes a SQLite identifier with double quotes
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,56 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSchemaManager_GetSchemaName(t *testing.T) {
+	tenantID := uuid.MustParse("7c9e6679-7425-40de-944b-e07fc1f90ae7")
+
+	sm := NewSchemaManager(nil, zaptest.NewLogger(t), "/var/lib/app", "")
+	if got := sm.GetSchemaName(tenantID); got != "tenant_7c9e6679_7425_40de_944b_e07fc1f90ae7" {
+		t.Errorf("GetSchemaName() = %s, want the default prefix", got)
+	}
+	if got := sm.DatabasePath(tenantID); got != "/var/lib/app/tenant_7c9e6679_7425_40de_944b_e07fc1f90ae7.db" {
+		t.Errorf("DatabasePath() = %s, want the database file in the directory", got)
+	}
+	if got := NewSchemaManager(nil, zaptest.NewLogger(t), "", "acme_").GetSchemaName(tenantID); got != "acme_7c9e6679_7425_40de_944b_e07fc1f90ae7" {
+		t.Errorf("GetSchemaName() = %s, want the custom prefix", got)
+	}
+}
+
+func TestSchemaManager_ListTenantSchemas(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"tenant_b.db", "tenant_a.db", "tenant_a.db-journal", "main.db", "tenant_notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "tenant_dir.db"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	sm := NewSchemaManager(nil, zaptest.NewLogger(t), dir, "tenant_")
+	databases, err := sm.ListTenantSchemas(context.Background())
+	if err != nil {
+		t.Fatalf("ListTenantSchemas() error = %v", err)
+	}
+	if want := []string{"tenant_a", "tenant_b"}; !reflect.DeepEqual(databases, want) {
+		t.Errorf("ListTenantSchemas() = %v, want %v", databases, want)
+	}
+}
+
+func TestSchemaManager_SetSearchPathUnsupported(t *testing.T) {
+	sm := NewSchemaManager(nil, zaptest.NewLogger(t), t.TempDir(), "")
+	if err := sm.SetSearchPath(nil, uuid.New()); !errors.Is(err, ErrSearchPathUnsupported) {
+		t.Errorf("SetSearchPath() error = %v, want ErrSearchPathUnsupported", err)
+	}
+}
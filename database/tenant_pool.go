@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+)
+
+// NewTenantPoolOpener returns a tenant.TenantPoolOpener that opens pools to
+// the database in config with search_path sent as a startup parameter, so
+// every connection is scoped to the tenant's schema without a SET. Each pool
+// holds up to config.TenantPoolConns connections.
+func NewTenantPoolOpener(config tenant.DatabaseConfig) tenant.TenantPoolOpener {
+	size := tenantPoolSize(config)
+
+	return func(ctx context.Context, tenantID uuid.UUID, schemaName string) (*sql.DB, error) {
+		dsn, err := tenantPoolDSN(config, schemaName)
+		if err != nil {
+			return nil, err
+		}
+
+		db, err := sql.Open(config.Driver, dsn)
+		if err != nil {
+			return nil, err
+		}
+
+		db.SetMaxOpenConns(size)
+		db.SetMaxIdleConns(size)
+		db.SetConnMaxLifetime(config.ConnMaxLifetime)
+		db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+
+		if err := db.PingContext(ctx); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to connect tenant %s pool: %w", tenantID, err)
+		}
+		return db, nil
+	}
+}
+
+// tenantPoolSize returns the connections each tenant pool holds
+func tenantPoolSize(config tenant.DatabaseConfig) int {
+	if config.TenantPoolConns <= 0 {
+		return tenant.DefaultTenantPoolConns
+	}
+	return config.TenantPoolConns
+}
+
+// CheckTenantPoolCapacity returns a ValidationError if config.MaxTenantPools
+// full tenant pools, together with the shared pool's config.MaxOpenConns,
+// could open more connections than the server at db accepts from users that
+// are not superusers. Without pooled mode there is nothing to check.
+func CheckTenantPoolCapacity(ctx context.Context, db *sql.DB, config tenant.DatabaseConfig) error {
+	if config.MaxTenantPools <= 0 {
+		return nil
+	}
+
+	var available int
+	query := `SELECT current_setting('max_connections')::int - current_setting('superuser_reserved_connections')::int`
+	if err := db.QueryRowContext(ctx, query).Scan(&available); err != nil {
+		return fmt.Errorf("failed to get the server's connection limit: %w", err)
+	}
+	return tenantPoolCapacity(config, available)
+}
+
+// tenantPoolCapacity checks the connections pooled mode may open against the
+// available connections
+func tenantPoolCapacity(config tenant.DatabaseConfig, available int) error {
+	size := tenantPoolSize(config)
+	shared := max(config.MaxOpenConns, 0)
+	if needed := config.MaxTenantPools*size + shared; needed > available {
+		return &tenant.ValidationError{
+			Field: "database.max_tenant_pools",
+			Message: fmt.Sprintf("%d tenant pools of %d connections and %d shared connections need up to %d connections, but the server accepts %d",
+				config.MaxTenantPools, size, shared, needed, available),
+		}
+	}
+	return nil
+}
+
+// tenantPoolDSN returns the connection string from config with search_path
+// set to the schema, then public
+func tenantPoolDSN(config tenant.DatabaseConfig, schemaName string) (string, error) {
+	dsn, err := ConnectionString(config)
+	if err != nil {
+		return "", err
+	}
+
+	searchPath := fmt.Sprintf(`"%s",public`, schemaName)
+
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		parsed, err := url.Parse(dsn)
+		if err != nil {
+			return "", fmt.Errorf("invalid database URL: %w", err)
+		}
+
+		query := parsed.Query()
+		query.Set("search_path", searchPath)
+		parsed.RawQuery = query.Encode()
+
+		return parsed.String(), nil
+	}
+
+	pairs := make([]string, 0, 2)
+	if dsn = strings.TrimSpace(dsn); dsn != "" {
+		pairs = append(pairs, dsn)
+	}
+	pairs = append(pairs, "search_path="+quoteConnValue(searchPath))
+
+	return strings.Join(pairs, " "), nil
+}
@@ -3,14 +3,22 @@ package multitenant
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/alexalmadav/go-multitenant/database"
+	pgrepo "github.com/alexalmadav/go-multitenant/database/postgres"
+	ginmiddleware "github.com/alexalmadav/go-multitenant/middleware/gin"
 	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 	"github.com/testcontainers/testcontainers-go"
@@ -287,7 +295,7 @@ func TestDatabase_SchemaCreation_TablesOnlyInTenantSchema(t *testing.T) {
 	}
 
 	// Expected tenant tables
-	expectedTables := []string{"documents", "projects", "tasks", "tenant_users"}
+	expectedTables := sm.ExpectedTables()
 
 	// Verify all expected tables exist in tenant schema
 	for _, expected := range expectedTables {
@@ -394,6 +402,96 @@ func TestDatabase_SchemaCreation_SearchPathIsolation(t *testing.T) {
 	}
 }
 
+func TestDatabase_SchemaCreation_PublicSearchPathNoLeakage(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.close()
+
+	ctx := context.Background()
+	tenantID := uuid.New()
+	schemaPrefix := "tenant_"
+
+	defer tdb.cleanupSchema(tenantID, schemaPrefix)
+	tdb.cleanupSchema(tenantID, schemaPrefix)
+
+	// Pin the pool to a single connection whose search_path points at public,
+	// as if a previous request had left it there
+	tdb.db.SetMaxOpenConns(1)
+	defer tdb.db.SetMaxOpenConns(0)
+	if _, err := tdb.db.Exec("SET search_path TO public"); err != nil {
+		t.Fatalf("Failed to set search path: %v", err)
+	}
+
+	publicTablesBefore, err := tdb.listTablesInSchema("public")
+	if err != nil {
+		t.Fatalf("Failed to list public tables before test: %v", err)
+	}
+	publicTablesBeforeMap := make(map[string]bool)
+	for _, table := range publicTablesBefore {
+		publicTablesBeforeMap[table] = true
+	}
+
+	sm := database.NewSchemaManager(tdb.db, tdb.logger, schemaPrefix)
+	if err := sm.CreateTenantSchema(ctx, tenantID, "Test Tenant"); err != nil {
+		t.Fatalf("Failed to create tenant schema: %v", err)
+	}
+
+	schemaName := sm.GetSchemaName(tenantID)
+	for _, table := range []string{"documents", "projects", "tasks", "tenant_users"} {
+		exists, err := tdb.tableExistsInSchema(schemaName, table)
+		if err != nil {
+			t.Fatalf("Failed to check table %s: %v", table, err)
+		}
+		if !exists {
+			t.Errorf("Expected table %s to exist in tenant schema %s", table, schemaName)
+		}
+	}
+
+	publicTablesAfter, err := tdb.listTablesInSchema("public")
+	if err != nil {
+		t.Fatalf("Failed to list public tables after test: %v", err)
+	}
+	for _, table := range publicTablesAfter {
+		if !publicTablesBeforeMap[table] {
+			t.Errorf("SCHEMA LEAKAGE: table %s was created in public schema", table)
+		}
+	}
+
+	// The connection's own search_path is left untouched by SET LOCAL
+	searchPath, err := tdb.getCurrentSearchPath()
+	if err != nil {
+		t.Fatalf("Failed to get search path: %v", err)
+	}
+	if searchPath != "public" {
+		t.Errorf("Search path after provisioning = %s, want public", searchPath)
+	}
+}
+
+func TestDatabase_VerifyNoLeakage_DetectsLeakedTable(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.close()
+
+	ctx := context.Background()
+	sm := database.NewSchemaManager(tdb.db, tdb.logger, "tenant_")
+
+	if err := sm.VerifyNoLeakage(ctx); err != nil {
+		t.Fatalf("VerifyNoLeakage() on clean database error = %v", err)
+	}
+
+	// Deliberately leak a tenant table into public
+	if _, err := tdb.db.Exec("CREATE TABLE public.tasks (id UUID PRIMARY KEY)"); err != nil {
+		t.Fatalf("Failed to create leaked table: %v", err)
+	}
+	defer tdb.db.Exec("DROP TABLE IF EXISTS public.tasks")
+
+	err := sm.VerifyNoLeakage(ctx)
+	if err == nil {
+		t.Fatal("VerifyNoLeakage() should detect the leaked tasks table")
+	}
+	if !strings.Contains(err.Error(), "tasks") {
+		t.Errorf("VerifyNoLeakage() error = %v, should name the leaked table", err)
+	}
+}
+
 func TestDatabase_MultiTenant_DataIsolation(t *testing.T) {
 	tdb := newTestDB(t)
 	defer tdb.close()
@@ -603,6 +701,87 @@ func TestDatabase_SchemaCreation_FunctionsInCorrectSchema(t *testing.T) {
 	t.Logf("update_updated_at_column correctly exists only in tenant schema %s", schemaName)
 }
 
+func TestDatabase_SchemaCreation_CustomTriggers(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.close()
+
+	ctx := context.Background()
+	tenantID := uuid.New()
+	schemaPrefix := "tenant_"
+
+	defer tdb.cleanupSchema(tenantID, schemaPrefix)
+	tdb.cleanupSchema(tenantID, schemaPrefix)
+
+	config := tenant.DefaultConfig().Database
+	config.SchemaPrefix = schemaPrefix
+	config.SchemaFunctions = []string{
+		`CREATE FUNCTION {{.Schema}}.stamp_description()
+			RETURNS TRIGGER AS $$
+			BEGIN
+				NEW.description = 'stamped by ' || TG_TABLE_SCHEMA;
+				RETURN NEW;
+			END;
+			$$ LANGUAGE plpgsql`,
+	}
+	config.SchemaTriggers = []string{
+		`CREATE TRIGGER stamp_projects_description BEFORE INSERT ON {{.Schema}}.projects FOR EACH ROW EXECUTE FUNCTION {{.Schema}}.stamp_description()`,
+	}
+
+	sm := database.NewSchemaManagerFromConfig(tdb.db, tdb.logger, config)
+	if err := sm.CreateTenantSchema(ctx, tenantID, "Test Tenant"); err != nil {
+		t.Fatalf("Failed to create tenant schema: %v", err)
+	}
+
+	schemaName := sm.GetSchemaName(tenantID)
+
+	// The custom function must exist only in the tenant schema
+	var existsInPublic bool
+	err := tdb.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM pg_proc p
+			JOIN pg_namespace n ON p.pronamespace = n.oid
+			WHERE n.nspname = 'public' AND p.proname = 'stamp_description'
+		)
+	`).Scan(&existsInPublic)
+	if err != nil {
+		t.Fatalf("Failed to check function in public: %v", err)
+	}
+	if existsInPublic {
+		t.Errorf("FUNCTION LEAKAGE: stamp_description was created in public schema instead of only in %s", schemaName)
+	}
+
+	// The custom trigger must be attached only to tenant tables
+	var triggerSchema string
+	err = tdb.db.QueryRow(`
+		SELECT n.nspname
+		FROM pg_trigger tg
+		JOIN pg_class c ON tg.tgrelid = c.oid
+		JOIN pg_namespace n ON c.relnamespace = n.oid
+		WHERE tg.tgname = 'stamp_projects_description' AND n.nspname IN ('public', $1)
+	`, schemaName).Scan(&triggerSchema)
+	if err != nil {
+		t.Fatalf("Failed to find custom trigger: %v", err)
+	}
+	if triggerSchema != schemaName {
+		t.Errorf("Custom trigger attached in schema %s, want %s", triggerSchema, schemaName)
+	}
+
+	// Inserting into the tenant table fires the trigger
+	var description string
+	err = tdb.db.QueryRow(fmt.Sprintf(`
+		INSERT INTO "%s".projects (name, description)
+		VALUES ($1, $2)
+		RETURNING description
+	`, schemaName), "Custom Trigger", "original").Scan(&description)
+	if err != nil {
+		t.Fatalf("Failed to insert project: %v", err)
+	}
+
+	if want := "stamped by " + schemaName; description != want {
+		t.Errorf("Custom trigger description = %q, want %q", description, want)
+	}
+}
+
 func TestDatabase_SchemaCreation_TriggersWork(t *testing.T) {
 	tdb := newTestDB(t)
 	defer tdb.close()
@@ -729,6 +908,7 @@ func TestDatabase_FullLifecycle_WithMultiTenant(t *testing.T) {
 	}
 
 	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
 	config.Database.DSN = connStr
 	config.Database.MigrationsDir = ""
 
@@ -864,6 +1044,7 @@ func TestDatabase_ConcurrentTenantCreation_NoSchemaLeakage(t *testing.T) {
 	}
 
 	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
 	config.Database.DSN = connStr
 
 	mt, err := New(config)
@@ -959,6 +1140,7 @@ func TestDatabase_GetTenantConn_SearchPath(t *testing.T) {
 	}
 
 	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
 	config.Database.DSN = connStr
 
 	mt, err := New(config)
@@ -1019,6 +1201,7 @@ func TestDatabase_GetTenantConn_Isolation(t *testing.T) {
 	}
 
 	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
 	config.Database.DSN = connStr
 
 	mt, err := New(config)
@@ -1124,6 +1307,122 @@ func TestDatabase_GetTenantConn_Isolation(t *testing.T) {
 	t.Log("GetTenantConn correctly isolates data between tenants")
 }
 
+func TestDatabase_GinWithTenantConn_Isolation(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.close()
+
+	connStr := tdb.getConnectionString()
+	if connStr == "" {
+		t.Skip("No connection string available")
+	}
+
+	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
+	config.Database.DSN = connStr
+
+	mt, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create MultiTenant: %v", err)
+	}
+	defer mt.Close()
+
+	ctx := context.Background()
+	tenantIDs := []uuid.UUID{uuid.New(), uuid.New()}
+	subdomains := make([]string, len(tenantIDs))
+
+	defer cleanupTestData(tdb.db, tenantIDs)
+
+	for i, id := range tenantIDs {
+		subdomains[i] = fmt.Sprintf("gin-conn-%d-%s", i+1, id.String()[:8])
+		tnt := &tenant.Tenant{
+			ID:        id,
+			Name:      fmt.Sprintf("Gin Conn Test Tenant %d", i+1),
+			Subdomain: subdomains[i],
+			PlanType:  tenant.PlanBasic,
+		}
+		if err := mt.Manager.CreateTenant(ctx, tnt); err != nil {
+			t.Fatalf("CreateTenant %d failed: %v", i+1, err)
+		}
+		if err := mt.Manager.ProvisionTenant(ctx, id); err != nil {
+			t.Fatalf("ProvisionTenant %d failed: %v", i+1, err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(mt.GinMiddleware.ResolveTenant(), mt.GinMiddleware.WithTenantConn())
+	r.POST("/projects", func(c *gin.Context) {
+		conn, ok := ginmiddleware.GetTenantConnFromContext(c)
+		if !ok {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		if _, err := conn.ExecContext(c.Request.Context(), "INSERT INTO projects (name) VALUES ($1)", c.Query("name")); err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.Status(http.StatusCreated)
+	})
+	r.GET("/projects", func(c *gin.Context) {
+		// The connection is also bound to the request context
+		conn, ok := tenant.GetTenantConnFromContext(c.Request.Context())
+		if !ok {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		rows, err := conn.QueryContext(c.Request.Context(), "SELECT name FROM projects ORDER BY name")
+		if err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		defer rows.Close()
+
+		var names []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				c.String(http.StatusInternalServerError, err.Error())
+				return
+			}
+			names = append(names, name)
+		}
+		c.String(http.StatusOK, strings.Join(names, ","))
+	})
+
+	serve := func(method, subdomain, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.Host = subdomain + ".example.com"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Interleave requests so reused pool connections would leak a stale search_path
+	for i := 0; i < 3; i++ {
+		for j, subdomain := range subdomains {
+			if w := serve(http.MethodPost, subdomain, fmt.Sprintf("/projects?name=tenant%d-project%d", j+1, i+1)); w.Code != http.StatusCreated {
+				t.Fatalf("POST for tenant %d = %d %s", j+1, w.Code, w.Body.String())
+			}
+		}
+	}
+
+	for j, subdomain := range subdomains {
+		w := serve(http.MethodGet, subdomain, "/projects")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET for tenant %d = %d %s", j+1, w.Code, w.Body.String())
+		}
+		want := fmt.Sprintf("tenant%[1]d-project1,tenant%[1]d-project2,tenant%[1]d-project3", j+1)
+		if w.Body.String() != want {
+			t.Errorf("DATA LEAKAGE: tenant %d sees projects %q, want %q", j+1, w.Body.String(), want)
+		}
+	}
+
+	// Every request's connection went back to the pool
+	if inUse := mt.GetDatabase().Stats().InUse; inUse != 0 {
+		t.Errorf("%d connections still in use after the requests completed", inUse)
+	}
+}
+
 func TestDatabase_WithTenantTx_Rollback(t *testing.T) {
 	tdb := newTestDB(t)
 	defer tdb.close()
@@ -1134,6 +1433,7 @@ func TestDatabase_WithTenantTx_Rollback(t *testing.T) {
 	}
 
 	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
 	config.Database.DSN = connStr
 
 	mt, err := New(config)
@@ -1236,6 +1536,7 @@ func TestDatabase_GetTenantConn_ConcurrentIsolation(t *testing.T) {
 	}
 
 	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
 	config.Database.DSN = connStr
 
 	mt, err := New(config)
@@ -1370,6 +1671,7 @@ func TestDatabase_WithTenantTx_ConcurrentIsolation(t *testing.T) {
 	}
 
 	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
 	config.Database.DSN = connStr
 
 	mt, err := New(config)
@@ -1488,3 +1790,999 @@ func TestDatabase_WithTenantTx_ConcurrentIsolation(t *testing.T) {
 		t.Logf("All %d tenants correctly isolated during concurrent WithTenantTx operations", numTenants)
 	}
 }
+
+func TestDatabase_ChangePlan_RecordsHistory(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.close()
+
+	connStr := tdb.getConnectionString()
+	if connStr == "" {
+		t.Skip("No connection string available")
+	}
+
+	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
+	config.Database.DSN = connStr
+	config.Database.MigrationsDir = ""
+
+	mt, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create MultiTenant: %v", err)
+	}
+	defer mt.Close()
+
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	defer cleanupTestData(tdb.db, []uuid.UUID{tenantID})
+
+	testTenant := &tenant.Tenant{
+		ID:        tenantID,
+		Name:      "Plan History Test Tenant",
+		Subdomain: fmt.Sprintf("plan-history-%s", tenantID.String()[:8]),
+		PlanType:  tenant.PlanBasic,
+	}
+	if err := mt.Manager.CreateTenant(ctx, testTenant); err != nil {
+		t.Fatalf("CreateTenant failed: %v", err)
+	}
+
+	if err := mt.Manager.ChangePlan(ctx, tenantID, tenant.PlanPro, "user-42"); err != nil {
+		t.Fatalf("ChangePlan failed: %v", err)
+	}
+
+	var rows int
+	if err := tdb.db.QueryRow(
+		`SELECT COUNT(*) FROM public.tenant_plan_history WHERE tenant_id = $1 AND old_plan = 'basic' AND new_plan = 'pro' AND actor = 'user-42'`,
+		tenantID,
+	).Scan(&rows); err != nil {
+		t.Fatalf("Failed to query plan history: %v", err)
+	}
+	if rows != 1 {
+		t.Errorf("tenant_plan_history has %d matching rows, want 1", rows)
+	}
+
+	history, err := mt.Manager.GetPlanHistory(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("GetPlanHistory failed: %v", err)
+	}
+	if len(history) != 1 || history[0].NewPlan != tenant.PlanPro {
+		t.Errorf("GetPlanHistory() = %+v, want one change to pro", history)
+	}
+}
+
+func TestDatabase_Repository_Exists(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.close()
+
+	connStr := tdb.getConnectionString()
+	if connStr == "" {
+		t.Skip("No connection string available")
+	}
+
+	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
+	config.Database.DSN = connStr
+	config.Database.MigrationsDir = ""
+
+	mt, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create MultiTenant: %v", err)
+	}
+	defer mt.Close()
+
+	ctx := context.Background()
+	tenantID := uuid.New()
+	subdomain := fmt.Sprintf("exists-%s", tenantID.String()[:8])
+
+	defer cleanupTestData(tdb.db, []uuid.UUID{tenantID})
+
+	if err := mt.Manager.CreateTenant(ctx, &tenant.Tenant{ID: tenantID, Name: "Exists Test Tenant", Subdomain: subdomain}); err != nil {
+		t.Fatalf("CreateTenant failed: %v", err)
+	}
+
+	repo := pgrepo.NewRepository(tdb.db, zaptest.NewLogger(t))
+	if exists, err := repo.ExistsByID(ctx, tenantID); err != nil || !exists {
+		t.Errorf("ExistsByID() = %v, %v, want true", exists, err)
+	}
+	if exists, err := repo.ExistsByID(ctx, uuid.New()); err != nil || exists {
+		t.Errorf("ExistsByID() = %v, %v for an unknown ID, want false", exists, err)
+	}
+	if exists, err := repo.ExistsBySubdomain(ctx, subdomain); err != nil || !exists {
+		t.Errorf("ExistsBySubdomain() = %v, %v, want true", exists, err)
+	}
+
+	available, err := mt.Manager.IsSubdomainAvailable(ctx, subdomain)
+	if err != nil || available {
+		t.Errorf("IsSubdomainAvailable() = %v, %v for a taken subdomain, want false", available, err)
+	}
+}
+
+func TestDatabase_Repository_Labels(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.close()
+
+	connStr := tdb.getConnectionString()
+	if connStr == "" {
+		t.Skip("No connection string available")
+	}
+
+	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
+	config.Database.DSN = connStr
+	config.Database.MigrationsDir = ""
+
+	mt, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create MultiTenant: %v", err)
+	}
+	defer mt.Close()
+
+	ctx := context.Background()
+	euID, usID := uuid.New(), uuid.New()
+
+	defer cleanupTestData(tdb.db, []uuid.UUID{euID, usID})
+
+	for _, id := range []uuid.UUID{euID, usID} {
+		subdomain := fmt.Sprintf("labels-%s", id.String()[:8])
+		if err := mt.Manager.CreateTenant(ctx, &tenant.Tenant{ID: id, Name: "Labels Test Tenant", Subdomain: subdomain}); err != nil {
+			t.Fatalf("CreateTenant failed: %v", err)
+		}
+	}
+
+	if err := mt.Manager.AddLabel(ctx, euID, "market:eu"); err != nil {
+		t.Fatalf("AddLabel failed: %v", err)
+	}
+	if err := mt.Manager.AddLabel(ctx, euID, "market:eu"); err != nil {
+		t.Errorf("AddLabel twice failed: %v", err)
+	}
+	if err := mt.Manager.AddLabel(ctx, usID, "market:us"); err != nil {
+		t.Fatalf("AddLabel failed: %v", err)
+	}
+	if err := mt.Manager.AddLabel(ctx, uuid.New(), "market:eu"); !errors.Is(err, tenant.ErrTenantNotFound) {
+		t.Errorf("AddLabel for an unknown tenant error = %v, want ErrTenantNotFound", err)
+	}
+
+	eu, err := mt.Manager.ListByLabel(ctx, "market:eu")
+	if err != nil {
+		t.Fatalf("ListByLabel failed: %v", err)
+	}
+	if len(eu) != 1 || eu[0].ID != euID {
+		t.Errorf("ListByLabel(market:eu) = %v, want only the EU tenant", eu)
+	}
+
+	if err := mt.Manager.RemoveLabel(ctx, euID, "market:eu"); err != nil {
+		t.Fatalf("RemoveLabel failed: %v", err)
+	}
+	labels, err := mt.Manager.GetLabels(ctx, euID)
+	if err != nil || len(labels) != 0 {
+		t.Errorf("GetLabels() after removal = %v, %v, want none", labels, err)
+	}
+}
+
+func TestDatabase_RenameTenantSchema_PreservesData(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.close()
+
+	connStr := tdb.getConnectionString()
+	if connStr == "" {
+		t.Skip("No connection string available")
+	}
+
+	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
+	config.Database.DSN = connStr
+	config.Database.MigrationsDir = ""
+
+	mt, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create MultiTenant: %v", err)
+	}
+	defer mt.Close()
+
+	ctx := context.Background()
+	tenantID, otherID := uuid.New(), uuid.New()
+	current := database.NewSchemaManager(tdb.db, tdb.logger, config.Database.SchemaPrefix)
+	moved := database.NewSchemaManager(tdb.db, tdb.logger, "moved_")
+	newName := moved.GetSchemaName(tenantID)
+
+	defer cleanupTestData(tdb.db, []uuid.UUID{tenantID, otherID})
+	defer tdb.db.Exec(fmt.Sprintf(`DROP SCHEMA IF EXISTS "%s" CASCADE`, newName))
+
+	for _, id := range []uuid.UUID{tenantID, otherID} {
+		subdomain := fmt.Sprintf("rename-%s", id.String()[:8])
+		if err := mt.Manager.CreateTenant(ctx, &tenant.Tenant{ID: id, Name: "Rename Test Tenant", Subdomain: subdomain}); err != nil {
+			t.Fatalf("CreateTenant failed: %v", err)
+		}
+		if err := mt.Manager.ProvisionTenant(ctx, id); err != nil {
+			t.Fatalf("ProvisionTenant failed: %v", err)
+		}
+	}
+
+	err = mt.Manager.WithTenantTx(ctx, tenantID, func(tx *sql.Tx) error {
+		_, err := tx.Exec("INSERT INTO projects (name) VALUES ($1)", "Kept Across Rename")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithTenantTx failed: %v", err)
+	}
+	oldName := current.GetSchemaName(tenantID)
+
+	// Renames run from a manager using the new prefix, which reaches the data
+	movedConfig := config
+	movedConfig.Database.SchemaPrefix = "moved_"
+	movedMT, err := New(movedConfig)
+	if err != nil {
+		t.Fatalf("Failed to create MultiTenant with the new prefix: %v", err)
+	}
+	defer movedMT.Close()
+
+	var validationErr *tenant.ValidationError
+	if err := movedMT.Manager.RenameTenantSchema(ctx, tenantID, "elsewhere"); !errors.As(err, &validationErr) {
+		t.Errorf("RenameTenantSchema to a name outside the prefix error = %v, want a ValidationError", err)
+	}
+
+	if err := movedMT.Manager.RenameTenantSchema(ctx, tenantID, newName); err != nil {
+		t.Fatalf("RenameTenantSchema failed: %v", err)
+	}
+
+	if exists, _ := tdb.schemaExists(oldName); exists {
+		t.Errorf("schema %s should not exist after the rename", oldName)
+	}
+	stored, err := movedMT.Manager.GetTenant(ctx, tenantID)
+	if err != nil || stored.SchemaName != newName {
+		t.Errorf("GetTenant() = %+v, %v; want schema_name %s", stored, err, newName)
+	}
+
+	var name string
+	err = movedMT.Manager.WithTenantTx(ctx, tenantID, func(tx *sql.Tx) error {
+		return tx.QueryRow("SELECT name FROM projects").Scan(&name)
+	})
+	if err != nil || name != "Kept Across Rename" {
+		t.Errorf("project after rename = %q, %v; want the original row", name, err)
+	}
+
+	// The new name is taken already
+	takenName := moved.GetSchemaName(otherID)
+	if _, err := tdb.db.Exec(fmt.Sprintf(`CREATE SCHEMA "%s"`, takenName)); err != nil {
+		t.Fatalf("Failed to create schema %s: %v", takenName, err)
+	}
+	defer tdb.db.Exec(fmt.Sprintf(`DROP SCHEMA IF EXISTS "%s" CASCADE`, takenName))
+	if err := movedMT.Manager.RenameTenantSchema(ctx, otherID, takenName); !errors.As(err, &validationErr) {
+		t.Errorf("RenameTenantSchema onto a taken name error = %v, want a ValidationError", err)
+	}
+	if exists, _ := tdb.schemaExists(current.GetSchemaName(otherID)); !exists {
+		t.Error("a rejected rename should leave the schema in place")
+	}
+}
+
+func TestDatabase_CloneTenant_CopiesDataIsolated(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.close()
+
+	connStr := tdb.getConnectionString()
+	if connStr == "" {
+		t.Skip("No connection string available")
+	}
+
+	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
+	config.Database.DSN = connStr
+	config.Database.MigrationsDir = ""
+
+	mt, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create MultiTenant: %v", err)
+	}
+	defer mt.Close()
+
+	ctx := context.Background()
+	sourceID, cloneID := uuid.New(), uuid.New()
+	defer cleanupTestData(tdb.db, []uuid.UUID{sourceID, cloneID})
+
+	source := &tenant.Tenant{ID: sourceID, Name: "Template Agency", Subdomain: fmt.Sprintf("template-%s", sourceID.String()[:8]), PlanType: tenant.PlanPro}
+	if err := mt.Manager.CreateTenant(ctx, source); err != nil {
+		t.Fatalf("CreateTenant failed: %v", err)
+	}
+	if err := mt.Manager.ProvisionTenant(ctx, sourceID); err != nil {
+		t.Fatalf("ProvisionTenant failed: %v", err)
+	}
+
+	// Seed projects with tasks in the source
+	sourceProjects := map[string]uuid.UUID{}
+	err = mt.Manager.WithTenantTx(ctx, sourceID, func(tx *sql.Tx) error {
+		for _, name := range []string{"Onboarding", "Launch"} {
+			var id uuid.UUID
+			if err := tx.QueryRow("INSERT INTO projects (name) VALUES ($1) RETURNING id", name).Scan(&id); err != nil {
+				return err
+			}
+			sourceProjects[name] = id
+			if _, err := tx.Exec("INSERT INTO tasks (project_id, title) VALUES ($1, $2)", id, name+" checklist"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("seeding source failed: %v", err)
+	}
+
+	clone := &tenant.Tenant{ID: cloneID, Name: "Client Agency", Subdomain: fmt.Sprintf("client-%s", cloneID.String()[:8])}
+	if err := mt.Manager.CloneTenant(ctx, sourceID, clone, "projects", "tasks"); err != nil {
+		t.Fatalf("CloneTenant failed: %v", err)
+	}
+	stored, err := mt.Manager.GetTenant(ctx, cloneID)
+	if err != nil {
+		t.Fatalf("GetTenant failed: %v", err)
+	}
+	if exists, _ := tdb.schemaExists(stored.SchemaName); !exists {
+		t.Fatalf("clone schema %s should exist", stored.SchemaName)
+	}
+	if clone.PlanType != tenant.PlanPro {
+		t.Errorf("clone plan = %s, want the source's %s", clone.PlanType, tenant.PlanPro)
+	}
+
+	// The clone has the same projects under new IDs, with tasks pointing at them
+	err = mt.Manager.WithTenantTx(ctx, cloneID, func(tx *sql.Tx) error {
+		rows, err := tx.Query(`
+			SELECT p.id, p.name, COUNT(t.id)
+			FROM projects p LEFT JOIN tasks t ON t.project_id = p.id
+			GROUP BY p.id, p.name`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		found := 0
+		for rows.Next() {
+			var id uuid.UUID
+			var name string
+			var tasks int
+			if err := rows.Scan(&id, &name, &tasks); err != nil {
+				return err
+			}
+			found++
+			sourceProjectID, ok := sourceProjects[name]
+			if !ok {
+				t.Errorf("unexpected project %q in the clone", name)
+				continue
+			}
+			if id == sourceProjectID {
+				t.Errorf("project %q kept its source ID %s", name, id)
+			}
+			if tasks != 1 {
+				t.Errorf("project %q has %d tasks in the clone, want 1", name, tasks)
+			}
+		}
+		if found != len(sourceProjects) {
+			t.Errorf("clone has %d projects, want %d", found, len(sourceProjects))
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		t.Fatalf("reading clone failed: %v", err)
+	}
+
+	// Changes to the clone do not reach the source
+	err = mt.Manager.WithTenantTx(ctx, cloneID, func(tx *sql.Tx) error {
+		if _, err := tx.Exec("INSERT INTO projects (name) VALUES ($1)", "Client Only"); err != nil {
+			return err
+		}
+		_, err := tx.Exec("DELETE FROM tasks")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("changing clone failed: %v", err)
+	}
+
+	var projects, tasks int
+	err = mt.Manager.WithTenantTx(ctx, sourceID, func(tx *sql.Tx) error {
+		if err := tx.QueryRow("SELECT COUNT(*) FROM projects").Scan(&projects); err != nil {
+			return err
+		}
+		return tx.QueryRow("SELECT COUNT(*) FROM tasks").Scan(&tasks)
+	})
+	if err != nil {
+		t.Fatalf("reading source failed: %v", err)
+	}
+	if projects != 2 || tasks != 2 {
+		t.Errorf("source has %d projects and %d tasks after changing the clone, want 2 and 2", projects, tasks)
+	}
+}
+
+func TestDatabase_DryRunMigration_PersistsNothing(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.close()
+
+	connStr := tdb.getConnectionString()
+	if connStr == "" {
+		t.Skip("No connection string available")
+	}
+
+	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
+	config.Database.DSN = connStr
+	config.Database.MigrationsDir = ""
+
+	mt, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create MultiTenant: %v", err)
+	}
+	defer mt.Close()
+
+	// The migration manager works through the tenant migration functions
+	for _, file := range []string{
+		"database/migrations/001_create_tenant_migration_functions.up.sql",
+		"database/migrations/002_fix_tenant_migration_functions.up.sql",
+	} {
+		functions, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read migration functions: %v", err)
+		}
+		if _, err := tdb.db.Exec(string(functions)); err != nil {
+			t.Fatalf("Failed to create migration functions from %s: %v", file, err)
+		}
+	}
+
+	ctx := context.Background()
+	tenantID := uuid.New()
+	defer cleanupTestData(tdb.db, []uuid.UUID{tenantID})
+
+	if err := mt.Manager.CreateTenant(ctx, &tenant.Tenant{ID: tenantID, Name: "Dry Run Tenant", Subdomain: fmt.Sprintf("dryrun-%s", tenantID.String()[:8])}); err != nil {
+		t.Fatalf("CreateTenant failed: %v", err)
+	}
+	if err := mt.Manager.ProvisionTenant(ctx, tenantID); err != nil {
+		t.Fatalf("ProvisionTenant failed: %v", err)
+	}
+
+	mgr := database.NewMigrationManager(tdb.db, tdb.logger, "").(*database.MigrationManager)
+
+	valid := &tenant.Migration{
+		Version: "100",
+		Name:    "seed_projects",
+		SQL:     "CREATE TABLE dry_run_marker (id INT); INSERT INTO projects (name) VALUES ('Dry Run A'), ('Dry Run B')",
+	}
+	result := mgr.DryRunMigration(ctx, tenantID, valid)
+	if result.Err != nil || result.Skipped {
+		t.Fatalf("DryRunMigration() of a valid migration = %+v, %v", result, result.Err)
+	}
+	if result.RowsAffected != 2 {
+		t.Errorf("RowsAffected = %d, want 2", result.RowsAffected)
+	}
+
+	invalid := &tenant.Migration{
+		Version: "101",
+		Name:    "broken",
+		SQL:     "INSERT INTO projects (name) VALUES ('Dry Run C'); ALTER TABLE no_such_table ADD COLUMN x INT",
+	}
+	if result := mgr.DryRunMigration(ctx, tenantID, invalid); result.Err == nil {
+		t.Error("DryRunMigration() of an invalid migration should report the error")
+	}
+
+	// Neither migration left anything behind
+	schemaName := database.NewSchemaManager(tdb.db, tdb.logger, config.Database.SchemaPrefix).GetSchemaName(tenantID)
+	if exists, err := tdb.tableExistsInSchema(schemaName, "dry_run_marker"); err != nil || exists {
+		t.Errorf("dry_run_marker exists = %v, %v; want the table rolled back", exists, err)
+	}
+	var projects, recorded int
+	err = mt.Manager.WithTenantTx(ctx, tenantID, func(tx *sql.Tx) error {
+		return tx.QueryRow("SELECT COUNT(*) FROM projects").Scan(&projects)
+	})
+	if err != nil || projects != 0 {
+		t.Errorf("projects after dry runs = %d, %v; want 0", projects, err)
+	}
+	if err := tdb.db.QueryRow("SELECT COUNT(*) FROM tenant_migrations WHERE tenant_id = $1", tenantID).Scan(&recorded); err != nil || recorded != 0 {
+		t.Errorf("recorded migrations after dry runs = %d, %v; want 0", recorded, err)
+	}
+}
+
+func TestDatabase_UsageTracker_EnforcesLimits(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.close()
+
+	connStr := tdb.getConnectionString()
+	if connStr == "" {
+		t.Skip("No connection string available")
+	}
+
+	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
+	config.Database.DSN = connStr
+	config.Database.MigrationsDir = ""
+	config.Limits.TrackUsage = true
+
+	mt, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create MultiTenant: %v", err)
+	}
+	defer mt.Close()
+
+	ctx := context.Background()
+	tenantID := uuid.New()
+	subdomain := fmt.Sprintf("usage-%s", tenantID.String()[:8])
+
+	defer cleanupTestData(tdb.db, []uuid.UUID{tenantID})
+
+	if err := mt.Manager.CreateTenant(ctx, &tenant.Tenant{ID: tenantID, Name: "Usage Test Tenant", Subdomain: subdomain, PlanType: tenant.PlanBasic}); err != nil {
+		t.Fatalf("CreateTenant failed: %v", err)
+	}
+
+	tracker := mt.LimitChecker.GetUsageTracker()
+	if tracker == nil {
+		t.Fatal("TrackUsage should set a usage tracker")
+	}
+
+	// Nothing recorded yet, so every limit passes
+	if err := mt.LimitChecker.CheckAllLimits(ctx, tenantID); err != nil {
+		t.Errorf("CheckAllLimits() without usage error = %v", err)
+	}
+
+	// Concurrent increments are not lost
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := tracker.IncrementUsage(ctx, tenantID, tenant.LimitNameMaxUsers, 1); err != nil {
+				t.Errorf("IncrementUsage failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if usage, err := tracker.GetCurrentUsage(ctx, tenantID, tenant.LimitNameMaxUsers); err != nil || usage != 5.0 {
+		t.Errorf("GetCurrentUsage() = %v, %v, want 5", usage, err)
+	}
+	if err := mt.LimitChecker.CheckLimit(ctx, tenantID, tenant.LimitNameMaxUsers, nil); err != nil {
+		t.Errorf("CheckLimit() at the limit error = %v, want nil", err)
+	}
+
+	// Going past the basic plan's 5 users is rejected
+	if err := tracker.IncrementUsage(ctx, tenantID, tenant.LimitNameMaxUsers, 1); err != nil {
+		t.Fatalf("IncrementUsage failed: %v", err)
+	}
+	var limitErr *tenant.LimitExceededError
+	if err := mt.LimitChecker.CheckLimit(ctx, tenantID, tenant.LimitNameMaxUsers, nil); !errors.As(err, &limitErr) || limitErr.Current != 6 {
+		t.Errorf("CheckLimit() past the limit error = %v, want a LimitExceededError at 6", err)
+	}
+	if err := mt.LimitChecker.CheckAllLimits(ctx, tenantID); !errors.As(err, &limitErr) {
+		t.Errorf("CheckAllLimits() past the limit error = %v, want a LimitExceededError", err)
+	}
+
+	// Monthly limits are tracked separately
+	if err := tracker.IncrementUsage(ctx, tenantID, "api_calls_per_month", 10001); err != nil {
+		t.Fatalf("IncrementUsage failed: %v", err)
+	}
+	if err := mt.LimitChecker.CheckLimit(ctx, tenantID, "api_calls_per_month", nil); !errors.As(err, &limitErr) {
+		t.Errorf("CheckLimit() past the monthly limit error = %v, want a LimitExceededError", err)
+	}
+
+	// Decrements never go below zero, and a reset clears the usage
+	if err := tracker.DecrementUsage(ctx, tenantID, tenant.LimitNameMaxUsers, 10); err != nil {
+		t.Fatalf("DecrementUsage failed: %v", err)
+	}
+	if usage, err := tracker.GetCurrentUsage(ctx, tenantID, tenant.LimitNameMaxUsers); err != nil || usage != 0.0 {
+		t.Errorf("GetCurrentUsage() after decrement = %v, %v, want 0", usage, err)
+	}
+	if err := tracker.ResetUsage(ctx, tenantID, "api_calls_per_month"); err != nil {
+		t.Fatalf("ResetUsage failed: %v", err)
+	}
+	if err := mt.LimitChecker.CheckAllLimits(ctx, tenantID); err != nil {
+		t.Errorf("CheckAllLimits() after reset error = %v", err)
+	}
+}
+
+func TestDatabase_CheckLimitAndConsume_Concurrent(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.close()
+
+	connStr := tdb.getConnectionString()
+	if connStr == "" {
+		t.Skip("No connection string available")
+	}
+
+	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
+	config.Database.DSN = connStr
+	config.Database.MigrationsDir = ""
+	config.Limits.TrackUsage = true
+
+	mt, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create MultiTenant: %v", err)
+	}
+	defer mt.Close()
+
+	ctx := context.Background()
+	tenantID := uuid.New()
+	subdomain := fmt.Sprintf("consume-%s", tenantID.String()[:8])
+
+	defer cleanupTestData(tdb.db, []uuid.UUID{tenantID})
+
+	if err := mt.Manager.CreateTenant(ctx, &tenant.Tenant{ID: tenantID, Name: "Consume Test Tenant", Subdomain: subdomain, PlanType: tenant.PlanBasic}); err != nil {
+		t.Fatalf("CreateTenant failed: %v", err)
+	}
+
+	// The basic plan allows 10 projects
+	const consumers = 20
+	var succeeded, exceeded atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < consumers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := mt.LimitChecker.CheckLimitAndConsume(ctx, tenantID, tenant.LimitNameMaxProjects, 1)
+			var limitErr *tenant.LimitExceededError
+			switch {
+			case err == nil:
+				succeeded.Add(1)
+			case errors.As(err, &limitErr):
+				exceeded.Add(1)
+			default:
+				t.Errorf("CheckLimitAndConsume failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded.Load() != consumers/2 || exceeded.Load() != consumers/2 {
+		t.Errorf("%d consumed and %d exceeded, want %d each", succeeded.Load(), exceeded.Load(), consumers/2)
+	}
+	usage, err := mt.LimitChecker.GetUsageTracker().GetCurrentUsage(ctx, tenantID, tenant.LimitNameMaxProjects)
+	if err != nil || usage != float64(consumers/2) {
+		t.Errorf("GetCurrentUsage() = %v, %v, want %d", usage, err, consumers/2)
+	}
+}
+
+// createRLSRole creates a role that, unlike the test superuser, is subject to
+// row-level security, with access to the tables in schema
+func createRLSRole(t *testing.T, db *sql.DB, schema string) string {
+	t.Helper()
+
+	role := "rls_app_" + strings.ReplaceAll(uuid.New().String()[:8], "-", "")
+	statements := []string{
+		fmt.Sprintf("CREATE ROLE %s NOLOGIN NOSUPERUSER NOBYPASSRLS", role),
+		fmt.Sprintf(`GRANT USAGE ON SCHEMA "%s" TO %s`, schema, role),
+		fmt.Sprintf(`GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA "%s" TO %s`, schema, role),
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("Failed to set up RLS role: %v", err)
+		}
+	}
+
+	t.Cleanup(func() {
+		db.Exec(fmt.Sprintf("DROP OWNED BY %s", role))
+		db.Exec(fmt.Sprintf("DROP ROLE IF EXISTS %s", role))
+	})
+	return role
+}
+
+func TestDatabase_SharedSchema_RowLevelSecurity(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.close()
+
+	ctx := context.Background()
+	schema := "rls_test_" + strings.ReplaceAll(uuid.New().String()[:8], "-", "")
+	sm := database.NewSharedSchemaManager(tdb.db, tdb.logger, schema)
+	if err := sm.CreateTables(ctx); err != nil {
+		t.Fatalf("CreateTables failed: %v", err)
+	}
+	defer tdb.db.Exec(fmt.Sprintf(`DROP SCHEMA IF EXISTS "%s" CASCADE`, schema))
+
+	// Running it again must not fail on the existing policies and triggers
+	if err := sm.CreateTables(ctx); err != nil {
+		t.Fatalf("CreateTables should be repeatable: %v", err)
+	}
+
+	role := createRLSRole(t, tdb.db, schema)
+	tenantA, tenantB := uuid.New(), uuid.New()
+
+	// One connection, switched between tenants by the session variable
+	conn, err := tdb.db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Failed to acquire connection: %v", err)
+	}
+	defer func() {
+		conn.ExecContext(ctx, "RESET ROLE")
+		conn.Close()
+	}()
+
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf(`SET ROLE %s; SET search_path TO "%s"`, role, schema)); err != nil {
+		t.Fatalf("Failed to switch role: %v", err)
+	}
+
+	// Without a tenant the session sees and may write nothing
+	var count int
+	if err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM projects").Scan(&count); err != nil {
+		t.Fatalf("Failed to count projects: %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, "INSERT INTO projects (name) VALUES ('No Tenant')"); err == nil {
+		t.Error("Insert without a tenant set should fail")
+	}
+
+	for _, tc := range []struct {
+		id   uuid.UUID
+		name string
+	}{{tenantA, "TenantA Secret Project"}, {tenantB, "TenantB Secret Project"}} {
+		if err := sm.ScopeSession(ctx, conn, tc.id, false); err != nil {
+			t.Fatalf("ScopeSession failed: %v", err)
+		}
+		if _, err := conn.ExecContext(ctx, "INSERT INTO projects (name) VALUES ($1)", tc.name); err != nil {
+			t.Fatalf("Failed to insert project: %v", err)
+		}
+	}
+
+	// Still scoped to tenant B: tenant A's row is invisible and untouchable
+	var name string
+	if err := conn.QueryRowContext(ctx, "SELECT COUNT(*), MAX(name) FROM projects").Scan(&count, &name); err != nil {
+		t.Fatalf("Failed to count projects: %v", err)
+	}
+	if count != 1 || name != "TenantB Secret Project" {
+		t.Errorf("DATA LEAKAGE: tenant B sees %d projects (%s), want only its own", count, name)
+	}
+
+	result, err := conn.ExecContext(ctx, "UPDATE projects SET status = 'archived'")
+	if err != nil {
+		t.Fatalf("Failed to update projects: %v", err)
+	}
+	if updated, _ := result.RowsAffected(); updated != 1 {
+		t.Errorf("DATA LEAKAGE: tenant B updated %d projects, want 1", updated)
+	}
+
+	if _, err := conn.ExecContext(ctx, "INSERT INTO projects (tenant_id, name) VALUES ($1, 'Planted')", tenantA); err == nil {
+		t.Error("DATA LEAKAGE: tenant B inserted a row for tenant A")
+	}
+
+	// Switching back shows tenant A its own row, unchanged
+	if err := sm.ScopeSession(ctx, conn, tenantA, false); err != nil {
+		t.Fatalf("ScopeSession failed: %v", err)
+	}
+	var status string
+	if err := conn.QueryRowContext(ctx, "SELECT COUNT(*), MAX(name), MAX(status) FROM projects").Scan(&count, &name, &status); err != nil {
+		t.Fatalf("Failed to count projects: %v", err)
+	}
+	if count != 1 || name != "TenantA Secret Project" || status != "active" {
+		t.Errorf("tenant A sees %d projects (%s, %s), want only its own, still active", count, name, status)
+	}
+
+	// Dropping tenant A leaves tenant B's rows
+	if err := sm.DropTenantSchema(ctx, tenantA); err != nil {
+		t.Fatalf("DropTenantSchema failed: %v", err)
+	}
+	var remaining int
+	tdb.db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM "%s".projects WHERE tenant_id = $1`, schema), tenantB).Scan(&remaining)
+	if remaining != 1 {
+		t.Errorf("DropTenantSchema removed other tenants' rows, %d left for tenant B", remaining)
+	}
+}
+
+func TestDatabase_SharedSchema_ManagerIsolation(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.close()
+
+	connStr := tdb.getConnectionString()
+	if connStr == "" {
+		t.Skip("No connection string available")
+	}
+
+	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
+	config.Database.DSN = connStr
+	config.Database.MigrationsDir = ""
+	config.Database.Isolation = IsolationShared
+
+	mt, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create MultiTenant: %v", err)
+	}
+	defer mt.Close()
+
+	ctx := context.Background()
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+	defer cleanupTestData(tdb.db, ids)
+
+	role := createRLSRole(t, tdb.db, database.DefaultSharedSchema)
+	shared := database.NewSharedSchemaManager(tdb.db, tdb.logger, "")
+
+	for i, id := range ids {
+		tnt := &tenant.Tenant{
+			ID:        id,
+			Name:      fmt.Sprintf("Shared Tenant %d", i+1),
+			Subdomain: fmt.Sprintf("shared-test-%d-%s", i+1, id.String()[:8]),
+			PlanType:  tenant.PlanBasic,
+		}
+		if err := mt.Manager.CreateTenant(ctx, tnt); err != nil {
+			t.Fatalf("CreateTenant %d failed: %v", i+1, err)
+		}
+		if err := mt.Manager.ProvisionTenant(ctx, id); err != nil {
+			t.Fatalf("ProvisionTenant %d failed: %v", i+1, err)
+		}
+		defer shared.DropTenantSchema(ctx, id)
+
+		// Transactions are scoped to the tenant; the role makes policies apply
+		err := mt.Manager.WithTenantTx(ctx, id, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, "SET LOCAL ROLE "+role); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, "INSERT INTO projects (name) VALUES ($1)", fmt.Sprintf("Shared%d Project", i+1))
+			return err
+		})
+		if err != nil {
+			t.Fatalf("WithTenantTx %d failed: %v", i+1, err)
+		}
+	}
+
+	for i, id := range ids {
+		conn, err := mt.Manager.GetTenantConn(ctx, id)
+		if err != nil {
+			t.Fatalf("GetTenantConn failed: %v", err)
+		}
+		if _, err := conn.ExecContext(ctx, "SET ROLE "+role); err != nil {
+			conn.Close()
+			t.Fatalf("Failed to switch role: %v", err)
+		}
+
+		var count int
+		var name string
+		err = conn.QueryRowContext(ctx, "SELECT COUNT(*), MAX(name) FROM projects").Scan(&count, &name)
+		conn.ExecContext(ctx, "RESET ROLE")
+		conn.Close()
+		if err != nil {
+			t.Fatalf("Failed to count projects: %v", err)
+		}
+		if want := fmt.Sprintf("Shared%d Project", i+1); count != 1 || name != want {
+			t.Errorf("DATA LEAKAGE: tenant %d sees %d projects (%s), want only %q", i+1, count, name, want)
+		}
+	}
+}
+
+func TestDatabase_BulkCreateTenants_RollsBack(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.close()
+
+	connStr := tdb.getConnectionString()
+	if connStr == "" {
+		t.Skip("No connection string available")
+	}
+
+	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
+	config.Database.DSN = connStr
+	config.Database.MigrationsDir = ""
+
+	mt, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create MultiTenant: %v", err)
+	}
+	defer mt.Close()
+
+	ctx := context.Background()
+	suffix := uuid.New().String()[:8]
+	batch := func(names ...string) []*tenant.Tenant {
+		tenants := make([]*tenant.Tenant, len(names))
+		for i, name := range names {
+			tenants[i] = &tenant.Tenant{Name: "Bulk " + name, Subdomain: fmt.Sprintf("bulk-%s-%s", name, suffix)}
+		}
+		return tenants
+	}
+
+	ids, err := mt.Manager.BulkCreateTenants(ctx, batch("one", "two"))
+	if err != nil {
+		t.Fatalf("BulkCreateTenants failed: %v", err)
+	}
+	defer cleanupTestData(tdb.db, ids)
+
+	for _, id := range ids {
+		created, err := mt.Manager.GetTenant(ctx, id)
+		if err != nil || created.Status != tenant.StatusPending {
+			t.Errorf("bulk created tenant %s = %+v, %v, want pending", id, created, err)
+		}
+	}
+
+	// The database rejects the third insert, undoing the two before it
+	repo := pgrepo.NewRepository(tdb.db, tdb.logger)
+	failing := batch("three", "four", "one")
+	for _, record := range failing {
+		record.ID = uuid.New()
+		record.SchemaName = "tenant_" + record.ID.String()
+		record.Status = tenant.StatusPending
+		record.PlanType = tenant.PlanBasic
+	}
+	defer cleanupTestData(tdb.db, []uuid.UUID{failing[0].ID, failing[1].ID})
+
+	if err := repo.CreateBatch(ctx, failing); err == nil {
+		t.Fatal("CreateBatch should fail on a duplicate subdomain")
+	}
+	for _, partial := range failing[:2] {
+		if _, err := repo.GetByID(ctx, partial.ID); !tenant.IsNotFound(err) {
+			t.Errorf("tenant %s from the failed batch exists, error = %v", partial.Subdomain, err)
+		}
+	}
+}
+
+func TestDatabase_PurgeTenant_DropsSchema(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.close()
+
+	connStr := tdb.getConnectionString()
+	if connStr == "" {
+		t.Skip("No connection string available")
+	}
+
+	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
+	config.Database.DSN = connStr
+	config.Database.MigrationsDir = ""
+
+	mt, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create MultiTenant: %v", err)
+	}
+	defer mt.Close()
+
+	ctx := context.Background()
+	suffix := uuid.New().String()[:8]
+	createTenant := func(name string) *tenant.Tenant {
+		t.Helper()
+		created := &tenant.Tenant{Name: "Purge " + name, Subdomain: fmt.Sprintf("purge-%s-%s", name, suffix)}
+		if err := mt.Manager.CreateTenant(ctx, created); err != nil {
+			t.Fatalf("CreateTenant failed: %v", err)
+		}
+		if err := mt.Manager.ProvisionTenant(ctx, created.ID); err != nil {
+			t.Fatalf("ProvisionTenant failed: %v", err)
+		}
+		return created
+	}
+
+	active := createTenant("active")
+	cancelled := createTenant("cancelled")
+	expired := createTenant("expired")
+	defer cleanupTestData(tdb.db, []uuid.UUID{active.ID, cancelled.ID, expired.ID})
+
+	// Active tenants are refused
+	if err := mt.Manager.PurgeTenant(ctx, active.ID); err == nil {
+		t.Fatal("PurgeTenant should refuse an active tenant")
+	}
+	if exists, err := tdb.schemaExists(active.SchemaName); err != nil || !exists {
+		t.Errorf("active tenant schema exists = %v, %v, want true", exists, err)
+	}
+	if err := pgrepo.NewRepository(tdb.db, tdb.logger).Purge(ctx, active.ID); !errors.Is(err, tenant.ErrTenantNotFound) {
+		t.Errorf("Repository.Purge of an active tenant error = %v, want ErrTenantNotFound", err)
+	}
+
+	if err := mt.Manager.DeleteTenant(ctx, cancelled.ID); err != nil {
+		t.Fatalf("DeleteTenant failed: %v", err)
+	}
+	if err := mt.Manager.PurgeTenant(ctx, cancelled.ID); err != nil {
+		t.Fatalf("PurgeTenant failed: %v", err)
+	}
+	if exists, err := tdb.schemaExists(cancelled.SchemaName); err != nil || exists {
+		t.Errorf("purged tenant schema exists = %v, %v, want false", exists, err)
+	}
+	if _, err := mt.Manager.GetTenant(ctx, cancelled.ID); !tenant.IsNotFound(err) {
+		t.Errorf("GetTenant after purge error = %v, want not found", err)
+	}
+
+	// Tenants cancelled within the retention window are kept
+	if err := mt.Manager.DeleteTenant(ctx, expired.ID); err != nil {
+		t.Fatalf("DeleteTenant failed: %v", err)
+	}
+	if _, err := mt.Manager.PurgeExpiredTenants(ctx, time.Hour); err != nil {
+		t.Fatalf("PurgeExpiredTenants failed: %v", err)
+	}
+	if exists, err := tdb.schemaExists(expired.SchemaName); err != nil || !exists {
+		t.Errorf("tenant cancelled within the window was purged: exists = %v, %v", exists, err)
+	}
+
+	// A zero retention window purges every cancelled tenant
+	purged, err := mt.Manager.PurgeExpiredTenants(ctx, 0)
+	if err != nil {
+		t.Fatalf("PurgeExpiredTenants failed: %v", err)
+	}
+	if purged < 1 {
+		t.Errorf("PurgeExpiredTenants() = %d, want at least 1", purged)
+	}
+	if exists, err := tdb.schemaExists(expired.SchemaName); err != nil || exists {
+		t.Errorf("expired tenant schema exists = %v, %v, want false", exists, err)
+	}
+}
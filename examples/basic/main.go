@@ -51,12 +51,8 @@ func main() {
 	// Multi-tenant API routes
 	api := r.Group("/api")
 	{
-		// Apply multi-tenant middleware
-		api.Use(mt.GinMiddleware.ResolveTenant())
-		api.Use(mt.GinMiddleware.ValidateTenant())
-		api.Use(mt.GinMiddleware.EnforceLimits())
-		api.Use(mt.GinMiddleware.SetTenantDB())
-		api.Use(mt.GinMiddleware.LogAccess())
+		// Apply multi-tenant middleware in the documented order
+		api.Use(mt.GinMiddleware.TenantMiddleware()...)
 
 		// Tenant-specific routes
 		api.GET("/info", getTenantInfo)
@@ -71,6 +67,7 @@ func main() {
 		admin.GET("/tenants", listTenants(mt))
 		admin.POST("/tenants", createTenant(mt))
 		admin.POST("/tenants/:id/provision", provisionTenant(mt))
+		admin.GET("/tenants/:id/status", getTenantStatus(mt))
 	}
 
 	fmt.Println("Starting server on :8080")
@@ -278,3 +275,39 @@ func provisionTenant(mt *multitenant.MultiTenant) gin.HandlerFunc {
 		})
 	}
 }
+
+func getTenantStatus(mt *multitenant.MultiTenant) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantIDStr := c.Param("id")
+		tenantID, err := uuid.Parse(tenantIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+			return
+		}
+
+		ctx := c.Request.Context()
+		tenant, err := mt.Manager.GetTenant(ctx, tenantID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tenant not found"})
+			return
+		}
+
+		stats, err := mt.Manager.GetStats(ctx, tenantID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		migrations, err := mt.Manager.GetAppliedMigrations(ctx, tenantID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"tenant":     tenant,
+			"stats":      stats,
+			"migrations": migrations,
+		})
+	}
+}
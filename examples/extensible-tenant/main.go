@@ -63,9 +63,7 @@ func main() {
 	// Multi-tenant API routes
 	api := r.Group("/api")
 	{
-		api.Use(mt.GinMiddleware.ResolveTenant())
-		api.Use(mt.GinMiddleware.ValidateTenant())
-		api.Use(mt.GinMiddleware.EnforceLimits())
+		api.Use(mt.GinMiddleware.Chain().Resolve().Validate().EnforceLimits().MustBuild()...)
 
 		api.GET("/tenant-info", getExtendedTenantInfo(mt))
 		api.GET("/branding", getTenantBranding(mt))
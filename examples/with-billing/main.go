@@ -44,6 +44,22 @@ func main() {
 		},
 	}
 
+	// Configure plan prices, shared by the plan list, upgrades and billing
+	config.PlanTemplates = map[string]multitenant.PlanTemplate{
+		multitenant.PlanBasic: {
+			Price:       &multitenant.Money{Amount: 2900, Currency: "USD"},
+			LocalPrices: []multitenant.Money{{Amount: 2700, Currency: "EUR"}},
+		},
+		multitenant.PlanPro: {
+			Price:       &multitenant.Money{Amount: 9900, Currency: "USD"},
+			LocalPrices: []multitenant.Money{{Amount: 8900, Currency: "EUR"}},
+		},
+		multitenant.PlanEnterprise: {
+			Price:       &multitenant.Money{Amount: 29900, Currency: "USD"},
+			LocalPrices: []multitenant.Money{{Amount: 27900, Currency: "EUR"}},
+		},
+	}
+
 	// Initialize multi-tenant system
 	mt, err := multitenant.New(config)
 	if err != nil {
@@ -97,7 +113,7 @@ func main() {
 		admin.Use(mw.ResolveTenant())
 		admin.Use(mw.RequireAdmin())
 
-		admin.GET("/analytics", getAdminAnalytics)
+		admin.GET("/analytics", getAdminAnalytics(mt))
 		admin.PUT("/plan", upgradePlan(mt))
 		admin.POST("/suspend", suspendTenant(mt))
 		admin.POST("/activate", activateTenant(mt))
@@ -106,7 +122,7 @@ func main() {
 	// Billing routes (bypass tenant resolution for billing management)
 	billing := r.Group("/billing")
 	{
-		billing.GET("/plans", getAvailablePlans)
+		billing.GET("/plans", getAvailablePlans(mt))
 		billing.POST("/upgrade", requestPlanUpgrade(mt))
 		billing.GET("/usage/:tenant_id", getBillingUsage(mt))
 	}
@@ -294,24 +310,27 @@ func getUsage(mt *multitenant.MultiTenant) gin.HandlerFunc {
 	}
 }
 
-func getAdminAnalytics(c *gin.Context) {
-	tenant, _ := ginmiddleware.GetTenantFromGinContext(c)
-
-	analytics := gin.H{
-		"tenant": tenant,
-		"metrics": gin.H{
-			"active_users":    25,
-			"monthly_revenue": fmt.Sprintf("$%d", getPlanPrice(tenant.PlanType)),
-			"storage_usage":   "15.2GB",
-			"api_calls":       142350,
-			"last_login":      time.Now().Add(-2 * time.Hour),
-		},
-		"alerts": []gin.H{
-			{"type": "info", "message": "Usage within normal limits"},
-		},
-	}
+func getAdminAnalytics(mt *multitenant.MultiTenant) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenant, _ := ginmiddleware.GetTenantFromGinContext(c)
+		price, _ := mt.Manager.GetPlanPrice(tenant.PlanType)
+
+		analytics := gin.H{
+			"tenant": tenant,
+			"metrics": gin.H{
+				"active_users":    25,
+				"monthly_revenue": price.String(),
+				"storage_usage":   "15.2GB",
+				"api_calls":       142350,
+				"last_login":      time.Now().Add(-2 * time.Hour),
+			},
+			"alerts": []gin.H{
+				{"type": "info", "message": "Usage within normal limits"},
+			},
+		}
 
-	c.JSON(http.StatusOK, analytics)
+		c.JSON(http.StatusOK, analytics)
+	}
 }
 
 func upgradePlan(mt *multitenant.MultiTenant) gin.HandlerFunc {
@@ -343,15 +362,15 @@ func upgradePlan(mt *multitenant.MultiTenant) gin.HandlerFunc {
 		}
 
 		// Update tenant plan
-		tenant.PlanType = req.NewPlan
-		if err := mt.Manager.UpdateTenant(c.Request.Context(), tenant); err != nil {
+		oldPlan, err := mt.Manager.ChangeTenantPlan(c.Request.Context(), tenantID, req.NewPlan)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
 		c.JSON(http.StatusOK, gin.H{
 			"message":        "Plan upgraded successfully",
-			"old_plan":       tenant.PlanType,
+			"old_plan":       oldPlan,
 			"new_plan":       req.NewPlan,
 			"effective_date": time.Now(),
 		})
@@ -390,43 +409,28 @@ func activateTenant(mt *multitenant.MultiTenant) gin.HandlerFunc {
 
 // Billing-specific handlers
 
-func getAvailablePlans(c *gin.Context) {
-	plans := gin.H{
-		"plans": []gin.H{
-			{
-				"name":     multitenant.PlanBasic,
-				"price":    29,
-				"features": getFeaturesByPlan(multitenant.PlanBasic),
-				"limits": gin.H{
-					"users":    2,
-					"projects": 3,
-					"storage":  "1GB",
-				},
-			},
-			{
-				"name":     multitenant.PlanPro,
-				"price":    99,
-				"features": getFeaturesByPlan(multitenant.PlanPro),
-				"limits": gin.H{
-					"users":    10,
-					"projects": 25,
-					"storage":  "5GB",
-				},
-			},
-			{
-				"name":     multitenant.PlanEnterprise,
-				"price":    299,
-				"features": getFeaturesByPlan(multitenant.PlanEnterprise),
-				"limits": gin.H{
-					"users":    "unlimited",
-					"projects": "unlimited",
-					"storage":  "50GB",
-				},
-			},
-		},
-	}
+func getAvailablePlans(mt *multitenant.MultiTenant) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		currency := c.DefaultQuery("currency", "USD")
+
+		plans := []gin.H{}
+		for _, plan := range []string{multitenant.PlanBasic, multitenant.PlanPro, multitenant.PlanEnterprise} {
+			price, err := mt.Manager.GetPlanPriceIn(plan, currency)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			plans = append(plans, gin.H{
+				"name":     plan,
+				"price":    price,
+				"features": getFeaturesByPlan(plan),
+				"limits":   mt.LimitChecker.GetLimitsForPlan(plan),
+			})
+		}
 
-	c.JSON(http.StatusOK, plans)
+		c.JSON(http.StatusOK, gin.H{"plans": plans})
+	}
 }
 
 func requestPlanUpgrade(mt *multitenant.MultiTenant) gin.HandlerFunc {
@@ -447,12 +451,19 @@ func requestPlanUpgrade(mt *multitenant.MultiTenant) gin.HandlerFunc {
 			return
 		}
 
+		price, err := mt.Manager.GetPlanPrice(req.NewPlan)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
 		// In a real application, this would handle payment processing
 		// For now, we'll just simulate the upgrade request
 		c.JSON(http.StatusAccepted, gin.H{
 			"message":    "Plan upgrade requested",
-			"tenant_id":  req.TenantID,
+			"tenant_id":  tenantID,
 			"new_plan":   req.NewPlan,
+			"price":      price,
 			"status":     "pending_payment",
 			"next_steps": "Complete payment to activate new plan",
 		})
@@ -474,12 +485,21 @@ func getBillingUsage(mt *multitenant.MultiTenant) gin.HandlerFunc {
 			return
 		}
 
-		stats, err := mt.Manager.GetStats(c.Request.Context(), tenantID)
+		// Billing needs current numbers, not the cached dashboard stats
+		stats, err := mt.Manager.GetStatsFresh(c.Request.Context(), tenantID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
+		basePrice, err := mt.Manager.GetPlanPrice(tenant.PlanType)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		overages := multitenant.Money{Currency: basePrice.Currency} // Calculate based on usage
+		total, _ := basePrice.Add(overages)
+
 		billing := gin.H{
 			"tenant": gin.H{
 				"id":   tenant.ID,
@@ -488,9 +508,9 @@ func getBillingUsage(mt *multitenant.MultiTenant) gin.HandlerFunc {
 			},
 			"usage": stats,
 			"charges": gin.H{
-				"base_plan": getPlanPrice(tenant.PlanType),
-				"overages":  0, // Calculate based on usage
-				"total":     getPlanPrice(tenant.PlanType),
+				"base_plan": basePrice,
+				"overages":  overages,
+				"total":     total,
 			},
 			"billing_period": gin.H{
 				"start": time.Now().AddDate(0, -1, 0).Format("2006-01-02"),
@@ -549,19 +569,6 @@ func getFeaturesByPlan(planType string) []string {
 	}
 }
 
-func getPlanPrice(planType string) int {
-	switch planType {
-	case multitenant.PlanBasic:
-		return 29
-	case multitenant.PlanPro:
-		return 99
-	case multitenant.PlanEnterprise:
-		return 299
-	default:
-		return 0
-	}
-}
-
 func calculatePercentage(current, limit int) string {
 	if limit <= 0 {
 		return "unlimited"
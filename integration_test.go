@@ -61,6 +61,7 @@ func TestIntegration_FullTenantLifecycle(t *testing.T) {
 	defer db.Close()
 
 	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
 	config.Database.DSN = getTestDatabaseURL()
 	config.Database.MigrationsDir = "" // No migrations for this test
 
@@ -225,6 +226,7 @@ func TestIntegration_TenantResolver(t *testing.T) {
 	defer db.Close()
 
 	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
 	config.Database.DSN = getTestDatabaseURL()
 
 	mt, err := New(config)
@@ -297,6 +299,7 @@ func TestIntegration_ConcurrentTenantOperations(t *testing.T) {
 	defer db.Close()
 
 	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
 	config.Database.DSN = getTestDatabaseURL()
 
 	mt, err := New(config)
@@ -385,6 +388,7 @@ func TestIntegration_TenantSchemaIsolation(t *testing.T) {
 	defer db.Close()
 
 	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
 	config.Database.DSN = getTestDatabaseURL()
 
 	mt, err := New(config)
@@ -486,6 +490,7 @@ func TestIntegration_MasterTablesCreation(t *testing.T) {
 	defer db.Close()
 
 	config := tenant.DefaultConfig()
+	config.Resolver.Domain = "example.com"
 	config.Database.DSN = getTestDatabaseURL()
 
 	// Test that master tables are created automatically
@@ -0,0 +1,101 @@
+package prometheus
+
+import (
+	"time"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Namespace prefixes every metric the collector exports
+const Namespace = "multitenant"
+
+// Collector implements tenant.MetricsCollector by exporting Prometheus metrics:
+//
+//	multitenant_tenants_created_total{plan}
+//	multitenant_tenants_suspended_total{plan}
+//	multitenant_tenants_deleted_total
+//	multitenant_provision_duration_seconds{result}
+//	multitenant_limit_exceeded_total{plan,limit}
+//
+// result is "success" or "error".
+type Collector struct {
+	created       *prometheus.CounterVec
+	suspended     *prometheus.CounterVec
+	deleted       prometheus.Counter
+	provisionTime *prometheus.HistogramVec
+	limitExceeded *prometheus.CounterVec
+}
+
+var _ tenant.MetricsCollector = (*Collector)(nil)
+
+// NewCollector creates a Collector and registers its metrics with registerer,
+// e.g. prometheus.DefaultRegisterer. It fails if the metrics are already
+// registered.
+func NewCollector(registerer prometheus.Registerer) (*Collector, error) {
+	c := &Collector{
+		created: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "tenants_created_total",
+			Help:      "Tenants created, by plan.",
+		}, []string{"plan"}),
+		suspended: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "tenants_suspended_total",
+			Help:      "Tenants suspended, by plan.",
+		}, []string{"plan"}),
+		deleted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "tenants_deleted_total",
+			Help:      "Tenants soft deleted.",
+		}),
+		provisionTime: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "provision_duration_seconds",
+			Help:      "Time taken by ProvisionTenant, by result.",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"result"}),
+		limitExceeded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "limit_exceeded_total",
+			Help:      "Rejected limit checks, by plan and limit.",
+		}, []string{"plan", "limit"}),
+	}
+
+	for _, metric := range []prometheus.Collector{c.created, c.suspended, c.deleted, c.provisionTime, c.limitExceeded} {
+		if err := registerer.Register(metric); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// TenantCreated implements tenant.MetricsCollector
+func (c *Collector) TenantCreated(plan string) {
+	c.created.WithLabelValues(plan).Inc()
+}
+
+// TenantSuspended implements tenant.MetricsCollector
+func (c *Collector) TenantSuspended(plan string) {
+	c.suspended.WithLabelValues(plan).Inc()
+}
+
+// TenantDeleted implements tenant.MetricsCollector
+func (c *Collector) TenantDeleted() {
+	c.deleted.Inc()
+}
+
+// ProvisionCompleted implements tenant.MetricsCollector
+func (c *Collector) ProvisionCompleted(duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	c.provisionTime.WithLabelValues(result).Observe(duration.Seconds())
+}
+
+// LimitExceeded implements tenant.MetricsCollector
+func (c *Collector) LimitExceeded(plan, limit string) {
+	c.limitExceeded.WithLabelValues(plan, limit).Inc()
+}
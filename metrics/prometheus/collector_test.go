@@ -0,0 +1,81 @@
+package prometheus
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector_ExportsMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	c, err := NewCollector(registry)
+	if err != nil {
+		t.Fatalf("NewCollector() error = %v", err)
+	}
+
+	c.TenantCreated("basic")
+	c.TenantCreated("basic")
+	c.TenantCreated("pro")
+	c.TenantSuspended("pro")
+	c.TenantDeleted()
+	c.ProvisionCompleted(300*time.Millisecond, nil)
+	c.ProvisionCompleted(2*time.Second, errors.New("schema exists"))
+	c.LimitExceeded("basic", "max_users")
+	c.LimitExceeded("basic", "max_users")
+	c.LimitExceeded("pro", "max_projects")
+
+	if got := testutil.ToFloat64(c.created.WithLabelValues("basic")); got != 2 {
+		t.Errorf("tenants_created_total{plan=basic} = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(c.suspended.WithLabelValues("pro")); got != 1 {
+		t.Errorf("tenants_suspended_total{plan=pro} = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(c.deleted); got != 1 {
+		t.Errorf("tenants_deleted_total = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(c.limitExceeded.WithLabelValues("basic", "max_users")); got != 2 {
+		t.Errorf("limit_exceeded_total{plan=basic,limit=max_users} = %v, want 2", got)
+	}
+
+	expected := `
+# HELP multitenant_limit_exceeded_total Rejected limit checks, by plan and limit.
+# TYPE multitenant_limit_exceeded_total counter
+multitenant_limit_exceeded_total{limit="max_projects",plan="pro"} 1
+multitenant_limit_exceeded_total{limit="max_users",plan="basic"} 2
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "multitenant_limit_exceeded_total"); err != nil {
+		t.Error(err)
+	}
+
+	if n := testutil.CollectAndCount(c.provisionTime); n != 2 {
+		t.Errorf("provision_duration_seconds series = %d, want one per result", n)
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "multitenant_provision_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if count := metric.GetHistogram().GetSampleCount(); count != 1 {
+				t.Errorf("provision histogram %v has %d samples, want 1", metric.GetLabel(), count)
+			}
+		}
+	}
+}
+
+func TestNewCollector_DuplicateRegistration(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	if _, err := NewCollector(registry); err != nil {
+		t.Fatalf("NewCollector() error = %v", err)
+	}
+	if _, err := NewCollector(registry); err == nil {
+		t.Error("NewCollector() should fail when the metrics are already registered")
+	}
+}
@@ -0,0 +1,152 @@
+package gin
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Stage identifies a tenant middleware in a Chain. Stages are declared in the
+// order they must run.
+type Stage int
+
+const (
+	StageResolve Stage = iota
+	StageValidate
+	StageEnforceLimits
+	StageSetTenantDB
+	StageLogAccess
+)
+
+// String returns the middleware name for the stage
+func (s Stage) String() string {
+	switch s {
+	case StageResolve:
+		return "ResolveTenant"
+	case StageValidate:
+		return "ValidateTenant"
+	case StageEnforceLimits:
+		return "EnforceLimits"
+	case StageSetTenantDB:
+		return "SetTenantDB"
+	case StageLogAccess:
+		return "LogAccess"
+	default:
+		return fmt.Sprintf("Stage(%d)", int(s))
+	}
+}
+
+// Chain builds the tenant middlewares in the documented order:
+// ResolveTenant → ValidateTenant → EnforceLimits → SetTenantDB → LogAccess.
+// Stages may be added in any order; Build always returns them in this order.
+//
+// Example:
+//
+//	api.Use(mw.Chain().Resolve().Validate().EnforceLimits().MustBuild()...)
+type Chain struct {
+	middleware *Middleware
+	stages     []Stage
+}
+
+// Chain returns a new, empty middleware chain builder
+func (m *Middleware) Chain() *Chain {
+	return &Chain{middleware: m}
+}
+
+// TenantMiddleware returns the full middleware chain in the documented order
+func (m *Middleware) TenantMiddleware() []gin.HandlerFunc {
+	return m.Chain().Resolve().Validate().EnforceLimits().SetTenantDB().LogAccess().MustBuild()
+}
+
+// Resolve adds ResolveTenant to the chain
+func (c *Chain) Resolve() *Chain {
+	return c.add(StageResolve)
+}
+
+// Validate adds ValidateTenant to the chain
+func (c *Chain) Validate() *Chain {
+	return c.add(StageValidate)
+}
+
+// EnforceLimits adds EnforceLimits to the chain
+func (c *Chain) EnforceLimits() *Chain {
+	return c.add(StageEnforceLimits)
+}
+
+// SetTenantDB adds SetTenantDB to the chain
+func (c *Chain) SetTenantDB() *Chain {
+	return c.add(StageSetTenantDB)
+}
+
+// LogAccess adds LogAccess to the chain
+func (c *Chain) LogAccess() *Chain {
+	return c.add(StageLogAccess)
+}
+
+// Stages returns the stages in the order Build will return them
+func (c *Chain) Stages() []Stage {
+	stages := make([]Stage, len(c.stages))
+	copy(stages, c.stages)
+	sort.Slice(stages, func(i, j int) bool { return stages[i] < stages[j] })
+	return stages
+}
+
+// Build validates the chain and returns its middlewares in the documented order.
+// It returns an error if a stage is added twice or if any stage is used without
+// ResolveTenant, which every other stage depends on.
+func (c *Chain) Build() ([]gin.HandlerFunc, error) {
+	stages := c.Stages()
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("middleware chain is empty")
+	}
+
+	for i := 1; i < len(stages); i++ {
+		if stages[i] == stages[i-1] {
+			return nil, fmt.Errorf("middleware %s added more than once", stages[i])
+		}
+	}
+
+	if stages[0] != StageResolve {
+		return nil, fmt.Errorf("middleware %s requires ResolveTenant", stages[0])
+	}
+
+	handlers := make([]gin.HandlerFunc, 0, len(stages))
+	for _, stage := range stages {
+		handlers = append(handlers, c.handler(stage))
+	}
+
+	return handlers, nil
+}
+
+// MustBuild is like Build but panics if the chain is invalid. It is intended for
+// route setup, where a misconfigured chain should fail at startup.
+func (c *Chain) MustBuild() []gin.HandlerFunc {
+	handlers, err := c.Build()
+	if err != nil {
+		panic(fmt.Sprintf("invalid tenant middleware chain: %v", err))
+	}
+	return handlers
+}
+
+// add appends a stage to the chain
+func (c *Chain) add(stage Stage) *Chain {
+	c.stages = append(c.stages, stage)
+	return c
+}
+
+// handler returns the middleware for a stage
+func (c *Chain) handler(stage Stage) gin.HandlerFunc {
+	switch stage {
+	case StageResolve:
+		return c.middleware.ResolveTenant()
+	case StageValidate:
+		return c.middleware.ValidateTenant()
+	case StageEnforceLimits:
+		return c.middleware.EnforceLimits()
+	case StageSetTenantDB:
+		return c.middleware.SetTenantDB()
+	default:
+		return c.middleware.LogAccess()
+	}
+}
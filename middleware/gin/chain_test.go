@@ -0,0 +1,144 @@
+package gin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestChain_StagesInDocumentedOrder(t *testing.T) {
+	mw := NewMiddleware(nil, nil, zaptest.NewLogger(t), Config{})
+
+	// Stages added out of order are returned in the documented order
+	chain := mw.Chain().LogAccess().EnforceLimits().Resolve().Validate()
+
+	want := []Stage{StageResolve, StageValidate, StageEnforceLimits, StageLogAccess}
+	if got := chain.Stages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Stages() = %v, want %v", got, want)
+	}
+
+	handlers, err := chain.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if len(handlers) != len(want) {
+		t.Errorf("Build() returned %d handlers, want %d", len(handlers), len(want))
+	}
+
+	if got := len(mw.TenantMiddleware()); got != 5 {
+		t.Errorf("TenantMiddleware() returned %d handlers, want 5", got)
+	}
+}
+
+func TestChain_InvalidChains(t *testing.T) {
+	mw := NewMiddleware(nil, nil, zaptest.NewLogger(t), Config{})
+
+	tests := []struct {
+		name  string
+		chain *Chain
+	}{
+		{name: "empty chain", chain: mw.Chain()},
+		{name: "enforce limits without resolve", chain: mw.Chain().EnforceLimits()},
+		{name: "validate without resolve", chain: mw.Chain().Validate().SetTenantDB()},
+		{name: "duplicate stage", chain: mw.Chain().Resolve().Validate().Validate()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.chain.Build(); err == nil {
+				t.Error("Build() should return error")
+			}
+
+			defer func() {
+				if recover() == nil {
+					t.Error("MustBuild() should panic")
+				}
+			}()
+			tt.chain.MustBuild()
+		})
+	}
+}
+
+func TestChain_ComposedChainServesRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tenantID := uuid.New()
+	manager := &chainTestManager{
+		tenant: &tenant.Tenant{ID: tenantID, Subdomain: "acme", Status: tenant.StatusActive, PlanType: tenant.PlanBasic},
+	}
+	resolver := &chainTestResolver{tenantID: tenantID}
+	mw := NewMiddleware(manager, resolver, zaptest.NewLogger(t), Config{})
+
+	r := gin.New()
+	r.Use(mw.Chain().Resolve().Validate().EnforceLimits().LogAccess().MustBuild()...)
+	r.GET("/projects", func(c *gin.Context) {
+		tenantCtx, ok := GetTenantFromContext(c)
+		if !ok || tenantCtx.TenantID != tenantID {
+			t.Error("handler should see the resolved tenant")
+		}
+		if _, ok := GetTenantLimitsFromContext(c); !ok {
+			t.Error("handler should see the plan limits")
+		}
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/projects", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	// Suspended tenants are rejected by ValidateTenant before limits are checked
+	manager.tenant.Status = tenant.StatusSuspended
+	manager.limitChecks = 0
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/projects", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if manager.limitChecks != 0 {
+		t.Error("EnforceLimits should not run after ValidateTenant rejects the request")
+	}
+}
+
+// chainTestManager implements the parts of tenant.Manager used by the chain
+type chainTestManager struct {
+	tenant.Manager
+	tenant      *tenant.Tenant
+	limitChecks int
+}
+
+func (m *chainTestManager) GetTenant(ctx context.Context, id uuid.UUID) (*tenant.Tenant, error) {
+	return m.tenant, nil
+}
+
+func (m *chainTestManager) CheckLimits(ctx context.Context, tenantID uuid.UUID) (*tenant.Limits, error) {
+	m.limitChecks++
+	return &tenant.Limits{}, nil
+}
+
+func (m *chainTestManager) GetTenantLimits(ctx context.Context, tenantID uuid.UUID) (tenant.FlexibleLimits, error) {
+	return nil, nil
+}
+
+func (m *chainTestManager) WithTenantContext(ctx context.Context, tenantID uuid.UUID) context.Context {
+	return ctx
+}
+
+// chainTestResolver always resolves to a fixed tenant
+type chainTestResolver struct {
+	tenant.Resolver
+	tenantID uuid.UUID
+}
+
+func (r *chainTestResolver) ResolveTenant(ctx context.Context, req *http.Request) (uuid.UUID, error) {
+	return r.tenantID, nil
+}
@@ -0,0 +1,55 @@
+package gin
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ErrorBody describes an error response independently of how it is serialized
+type ErrorBody struct {
+	Code     string                 // Machine-readable code, e.g. "TENANT_NOT_FOUND"
+	Message  string                 // Human-readable message
+	Field    string                 // Invalid field, for validation errors
+	TenantID uuid.UUID              // uuid.Nil when the tenant is unknown
+	Details  map[string]interface{} // Extra fields, e.g. the limit for PLAN_LIMIT_EXCEEDED
+}
+
+// ErrorResponder writes error responses. The middleware aborts the request
+// after RespondError returns.
+type ErrorResponder interface {
+	RespondError(c *gin.Context, statusCode int, body *ErrorBody)
+}
+
+// ErrorResponderFunc adapts a function to ErrorResponder
+type ErrorResponderFunc func(c *gin.Context, statusCode int, body *ErrorBody)
+
+// RespondError calls f(c, statusCode, body)
+func (f ErrorResponderFunc) RespondError(c *gin.Context, statusCode int, body *ErrorBody) {
+	f(c, statusCode, body)
+}
+
+// JSONErrorResponder writes errors as
+// {"error": {"code": ..., "message": ...}, "tenant_id": ...}, with the field
+// and any details added to the error object
+type JSONErrorResponder struct{}
+
+// RespondError writes body as JSON
+func (JSONErrorResponder) RespondError(c *gin.Context, statusCode int, body *ErrorBody) {
+	details := gin.H{
+		"code":    body.Code,
+		"message": body.Message,
+	}
+	if body.Field != "" {
+		details["field"] = body.Field
+	}
+	for key, value := range body.Details {
+		details[key] = value
+	}
+
+	response := gin.H{"error": details}
+	if body.TenantID != uuid.Nil {
+		response["tenant_id"] = body.TenantID.String()
+	}
+
+	c.JSON(statusCode, response)
+}
@@ -0,0 +1,112 @@
+package gin
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// DefaultLimitHeaderPrefix is the prefix of the limit headers set by
+// EnforceLimits when Config.LimitHeaderPrefix is not set. Plan limits are
+// quotas rather than request rates, so the X-RateLimit headers RateLimit
+// clients may expect are not reused.
+const DefaultLimitHeaderPrefix = "X-Tenant-Quota"
+
+// HeaderTenantPlan is the response header EnforceLimits sets to the tenant's plan
+const HeaderTenantPlan = "X-Tenant-Plan"
+
+// setLimitHeaders sets the plan header and, for the tenant's numeric limit
+// with the least remaining according to the usage tracker, the prefixed
+// -Limit, -Remaining and -Resource headers. Unlimited limits, and limits
+// whose usage cannot be read, e.g. because no usage tracker is set, are left
+// out. The limits and all usage are read once each.
+func (m *Middleware) setLimitHeaders(c *gin.Context, tenantCtx *tenant.Context) {
+	c.Header(HeaderTenantPlan, tenantCtx.PlanType)
+
+	limits, err := m.manager.GetTenantLimits(c.Request.Context(), tenantCtx.TenantID)
+	if err != nil {
+		m.logger.Debug("Failed to get tenant limits for limit headers",
+			zap.String("tenant_id", tenantCtx.TenantID.String()),
+			zap.Error(err))
+		return
+	}
+	usage, err := m.manager.GetAllUsage(c.Request.Context(), tenantCtx.TenantID)
+	if err != nil {
+		m.logger.Debug("Failed to get tenant usage for limit headers",
+			zap.String("tenant_id", tenantCtx.TenantID.String()),
+			zap.Error(err))
+		return
+	}
+
+	names := make([]string, 0, len(limits))
+	for name := range limits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var tightest string
+	var tightestLimit, tightestRemaining float64
+	for _, name := range names {
+		limit := limits[name]
+		if limit.IsUnlimited() {
+			continue
+		}
+		value, ok := limitNumber(limit.Value)
+		if !ok || (limit.Type != tenant.LimitTypeInt && limit.Type != tenant.LimitTypeFloat) {
+			continue
+		}
+
+		current, ok := limitNumber(usage[name])
+		if !ok {
+			continue
+		}
+
+		remaining := math.Max(value-current, 0)
+		if tightest == "" || remaining < tightestRemaining {
+			tightest, tightestLimit, tightestRemaining = name, value, remaining
+		}
+	}
+
+	if tightest != "" {
+		m.setLimitValueHeaders(c, tightest, tightestLimit, tightestRemaining)
+	}
+}
+
+// setExceededLimitHeaders sets the plan header and the prefixed limit headers
+// for the limit that was exceeded, with nothing remaining
+func (m *Middleware) setExceededLimitHeaders(c *gin.Context, tenantCtx *tenant.Context, err *tenant.LimitExceededError) {
+	c.Header(HeaderTenantPlan, tenantCtx.PlanType)
+
+	if value, ok := limitNumber(err.Value); ok {
+		m.setLimitValueHeaders(c, err.Limit, value, 0)
+	}
+}
+
+// setLimitValueHeaders sets the prefixed -Limit, -Remaining and -Resource
+// headers
+func (m *Middleware) setLimitValueHeaders(c *gin.Context, name string, limit, remaining float64) {
+	prefix := m.config.LimitHeaderPrefix
+	c.Header(prefix+"-Limit", strconv.FormatFloat(limit, 'f', -1, 64))
+	c.Header(prefix+"-Remaining", strconv.FormatFloat(remaining, 'f', -1, 64))
+	c.Header(prefix+"-Resource", name)
+}
+
+// limitNumber converts a limit value or tracked usage to a float64
+func limitNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case nil:
+		return 0, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
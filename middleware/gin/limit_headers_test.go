@@ -0,0 +1,134 @@
+package gin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+// limitHeadersTestManager serves configured limits and tracked usage. Without
+// usage, GetAllUsage fails as it does when no usage tracker is set.
+type limitHeadersTestManager struct {
+	tenant.Manager
+	limits   tenant.FlexibleLimits
+	usage    map[string]interface{}
+	checkErr error
+}
+
+func (m *limitHeadersTestManager) CheckLimits(ctx context.Context, tenantID uuid.UUID) (*tenant.Limits, error) {
+	if m.checkErr != nil {
+		return nil, m.checkErr
+	}
+	return &tenant.Limits{}, nil
+}
+
+func (m *limitHeadersTestManager) GetTenantLimits(ctx context.Context, tenantID uuid.UUID) (tenant.FlexibleLimits, error) {
+	return m.limits, nil
+}
+
+func (m *limitHeadersTestManager) GetAllUsage(ctx context.Context, tenantID uuid.UUID) (map[string]interface{}, error) {
+	if m.usage == nil {
+		return nil, &tenant.TenantError{TenantID: tenantID, Code: "USAGE_TRACKER_UNAVAILABLE", Message: "no usage tracker is set"}
+	}
+	return m.usage, nil
+}
+
+func TestEnforceLimits_LimitHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tenantID := uuid.New()
+	manager := &limitHeadersTestManager{
+		limits: tenant.FlexibleLimits{
+			tenant.LimitNameMaxUsers:     tenant.IntLimit(10),
+			tenant.LimitNameMaxProjects:  tenant.IntLimit(5),
+			tenant.LimitNameMaxStorageGB: tenant.UnlimitedInt(),
+			"custom_branding":            tenant.BoolLimit(true),
+		},
+		usage: map[string]interface{}{
+			tenant.LimitNameMaxUsers:     7,
+			tenant.LimitNameMaxProjects:  4,
+			tenant.LimitNameMaxStorageGB: 900,
+		},
+	}
+
+	serve := func(config Config) *httptest.ResponseRecorder {
+		mw := NewMiddleware(manager, &lookupTestResolver{}, zaptest.NewLogger(t), config)
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set("tenant", &tenant.Context{TenantID: tenantID, PlanType: tenant.PlanBasic})
+		}, mw.EnforceLimits())
+		r.POST("/projects", func(c *gin.Context) {
+			c.Status(http.StatusCreated)
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/projects", nil))
+		return w
+	}
+
+	assertHeaders := func(t *testing.T, w *httptest.ResponseRecorder, prefix string, want map[string]string) {
+		t.Helper()
+		if got := w.Header().Get("X-Tenant-Plan"); got != tenant.PlanBasic {
+			t.Errorf("X-Tenant-Plan = %q, want %q", got, tenant.PlanBasic)
+		}
+		for suffix, value := range want {
+			if got := w.Header().Get(prefix + suffix); got != value {
+				t.Errorf("%s%s = %q, want %q", prefix, suffix, got, value)
+			}
+		}
+	}
+
+	// Headers are opt-in
+	w := serve(Config{})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusCreated)
+	}
+	if got := w.Header().Get("X-Tenant-Plan") + w.Header().Get("X-Tenant-Quota-Limit"); got != "" {
+		t.Errorf("limit headers set without LimitHeaders: %v", w.Header())
+	}
+
+	// The headers describe the limit with the least headroom left
+	enabled := Config{LimitHeaders: true}
+	w = serve(enabled)
+	assertHeaders(t, w, "X-Tenant-Quota", map[string]string{"-Limit": "5", "-Remaining": "1", "-Resource": tenant.LimitNameMaxProjects})
+	if got := w.Header().Get("X-RateLimit-Limit"); got != "" {
+		t.Errorf("X-RateLimit-Limit = %q, want plan limits kept out of the rate limit headers", got)
+	}
+
+	manager.usage[tenant.LimitNameMaxUsers] = 10
+	assertHeaders(t, serve(enabled), "X-Tenant-Quota", map[string]string{"-Limit": "10", "-Remaining": "0", "-Resource": tenant.LimitNameMaxUsers})
+
+	// Exceeded limits still get headers on the 402, and on custom responses
+	manager.checkErr = &tenant.LimitExceededError{TenantID: tenantID, Code: "LIMIT_EXCEEDED", Limit: tenant.LimitNameMaxProjects, Value: 5, Current: 6}
+	w = serve(enabled)
+	if w.Code != http.StatusPaymentRequired {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusPaymentRequired)
+	}
+	assertHeaders(t, w, "X-Tenant-Quota", map[string]string{"-Limit": "5", "-Remaining": "0", "-Resource": tenant.LimitNameMaxProjects})
+
+	w = serve(Config{
+		LimitHeaders:      true,
+		LimitHeaderPrefix: "X-Plan-Quota",
+		OnLimitExceeded: func(c *gin.Context, err *tenant.LimitExceededError) {
+			c.Status(http.StatusTooManyRequests)
+		},
+	})
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	assertHeaders(t, w, "X-Plan-Quota", map[string]string{"-Limit": "5", "-Remaining": "0"})
+	if got := w.Header().Get("X-Tenant-Quota-Limit"); got != "" {
+		t.Errorf("X-Tenant-Quota-Limit = %q, want only the configured prefix", got)
+	}
+
+	// Without tracked usage only the plan is reported
+	manager.checkErr, manager.usage = nil, nil
+	w = serve(enabled)
+	assertHeaders(t, w, "X-Tenant-Quota", map[string]string{"-Limit": "", "-Remaining": ""})
+}
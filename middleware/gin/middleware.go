@@ -2,21 +2,33 @@ package gin
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/alexalmadav/go-multitenant/middleware/internal/httperror"
 	"github.com/alexalmadav/go-multitenant/tenant"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
+// ErrUndefinedLimit is returned, wrapped, by ValidateEnforcedLimits for limits
+// that middleware enforces but the limit schema does not define
+var ErrUndefinedLimit = errors.New("enforced limit is not defined in the limit schema")
+
 // Middleware provides Gin-specific middleware for multi-tenant applications
 type Middleware struct {
 	manager  tenant.Manager
 	resolver tenant.Resolver
 	logger   *zap.Logger
 	config   Config
+
+	mu             sync.Mutex
+	enforcedLimits []string // Limit names registered by EnforceLimit, in order
 }
 
 // Config contains configuration for the Gin middleware
@@ -25,14 +37,65 @@ type Config struct {
 	SkipPaths []string
 	// RequireAuthentication determines if authentication is required
 	RequireAuthentication bool
-	// ErrorHandler is called when an error occurs
+	// ErrorHandler is called when an error occurs. The default maps the error
+	// to a status code and body and writes them with ErrorResponder.
 	ErrorHandler func(*gin.Context, error)
+	// ErrorResponder serializes error responses, e.g. to match an API's own
+	// envelope or content type. Defaults to JSONErrorResponder.
+	ErrorResponder ErrorResponder
+	// OnTenantNotFound, if set, is called instead of ErrorHandler when no tenant
+	// matches the request, e.g. to redirect to a signup page or render a branded
+	// 404. The request is aborted after it returns.
+	OnTenantNotFound func(*gin.Context)
+	// UpgradeURL is included in responses that deny a request for exceeding a
+	// plan limit, pointing the client at where the plan can be upgraded
+	UpgradeURL string
+	// OnLimitExceeded, if set, writes the response when EnforceLimits denies a
+	// request for exceeding a plan limit, replacing the default 402 JSON body.
+	// The request is aborted after it returns.
+	OnLimitExceeded func(*gin.Context, *tenant.LimitExceededError)
+	// LimitHeaders makes EnforceLimits describe the tenant's plan and tightest
+	// limit in response headers. Allowed requests then cost two more reads,
+	// the tenant's limits and its usage.
+	LimitHeaders bool
+	// LimitHeaderPrefix prefixes the -Limit, -Remaining and -Resource headers
+	// set when LimitHeaders is on. Defaults to DefaultLimitHeaderPrefix.
+	LimitHeaderPrefix string
+	// RateLimitStore holds the token buckets used by RateLimit. Defaults to an
+	// in-memory store, which only limits requests served by this process.
+	RateLimitStore RateLimitStore
+	// RateLimitCacheTTL is how long RateLimit reuses a tenant's
+	// api_rate_per_minute before reading its limits again. Defaults to
+	// DefaultRateLimitCacheTTL; a negative value reads them on every request.
+	RateLimitCacheTTL time.Duration
+	// Clock returns the current time for RateLimit. Defaults to time.Now.
+	Clock func() time.Time
 }
 
 // NewMiddleware creates a new Gin middleware
 func NewMiddleware(manager tenant.Manager, resolver tenant.Resolver, logger *zap.Logger, config Config) *Middleware {
+	if config.ErrorResponder == nil {
+		config.ErrorResponder = JSONErrorResponder{}
+	}
+	if config.LimitHeaderPrefix == "" {
+		config.LimitHeaderPrefix = DefaultLimitHeaderPrefix
+	}
+	if config.RateLimitStore == nil {
+		config.RateLimitStore = NewMemoryRateLimitStore()
+	}
+	if config.RateLimitCacheTTL == 0 {
+		config.RateLimitCacheTTL = DefaultRateLimitCacheTTL
+	}
+	if config.Clock == nil {
+		config.Clock = time.Now
+	}
 	if config.ErrorHandler == nil {
-		config.ErrorHandler = defaultErrorHandler
+		responder := config.ErrorResponder
+		config.ErrorHandler = func(c *gin.Context, err error) {
+			statusCode, body := errorResponse(err)
+			responder.RespondError(c, statusCode, body)
+			c.Abort()
+		}
 	}
 
 	return &Middleware{
@@ -55,12 +118,30 @@ func (m *Middleware) ResolveTenant() gin.HandlerFunc {
 		// Resolve tenant from request
 		tenantID, err := m.resolver.ResolveTenant(c.Request.Context(), c.Request)
 		if err != nil {
+			if errors.Is(err, tenant.ErrInvalidToken) {
+				m.logger.Debug("Rejected tenant token",
+					zap.String("path", c.Request.URL.Path),
+					zap.Error(err))
+
+				m.config.ErrorHandler(c, httperror.InvalidToken())
+				return
+			}
+			if !tenant.IsNotFound(err) {
+				m.logger.Error("Tenant lookup failed during resolution",
+					zap.String("path", c.Request.URL.Path),
+					zap.String("host", c.Request.Host),
+					zap.Error(err))
+
+				m.config.ErrorHandler(c, httperror.LookupFailed(uuid.Nil))
+				return
+			}
+
 			m.logger.Debug("Failed to resolve tenant",
 				zap.String("path", c.Request.URL.Path),
 				zap.String("host", c.Request.Host),
 				zap.Error(err))
 
-			m.config.ErrorHandler(c, &tenant.TenantError{
+			m.tenantNotFound(c, &tenant.TenantError{
 				Code:    "TENANT_NOT_FOUND",
 				Message: "Unable to resolve tenant from request",
 			})
@@ -74,7 +155,12 @@ func (m *Middleware) ResolveTenant() gin.HandlerFunc {
 				zap.String("tenant_id", tenantID.String()),
 				zap.Error(err))
 
-			m.config.ErrorHandler(c, &tenant.TenantError{
+			if !tenant.IsNotFound(err) {
+				m.config.ErrorHandler(c, httperror.LookupFailed(tenantID))
+				return
+			}
+
+			m.tenantNotFound(c, &tenant.TenantError{
 				TenantID: tenantID,
 				Code:     "TENANT_NOT_FOUND",
 				Message:  "Tenant not found",
@@ -112,6 +198,12 @@ func (m *Middleware) ResolveTenant() gin.HandlerFunc {
 // ValidateTenant is middleware that validates tenant status and access
 func (m *Middleware) ValidateTenant() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// Skipped paths have no tenant to validate
+		if m.shouldSkipPath(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
 		tenantCtx, exists := GetTenantFromContext(c)
 		if !exists {
 			m.config.ErrorHandler(c, &tenant.TenantError{
@@ -122,36 +214,8 @@ func (m *Middleware) ValidateTenant() gin.HandlerFunc {
 		}
 
 		// Check tenant status
-		switch tenantCtx.Status {
-		case tenant.StatusActive:
-			// All good, continue
-		case tenant.StatusSuspended:
-			m.config.ErrorHandler(c, &tenant.TenantError{
-				TenantID: tenantCtx.TenantID,
-				Code:     "TENANT_SUSPENDED",
-				Message:  "Account suspended. Please contact support.",
-			})
-			return
-		case tenant.StatusPending:
-			m.config.ErrorHandler(c, &tenant.TenantError{
-				TenantID: tenantCtx.TenantID,
-				Code:     "TENANT_PENDING",
-				Message:  "Account pending verification. Please check your email.",
-			})
-			return
-		case tenant.StatusCancelled:
-			m.config.ErrorHandler(c, &tenant.TenantError{
-				TenantID: tenantCtx.TenantID,
-				Code:     "TENANT_CANCELLED",
-				Message:  "Account cancelled.",
-			})
-			return
-		default:
-			m.config.ErrorHandler(c, &tenant.TenantError{
-				TenantID: tenantCtx.TenantID,
-				Code:     "TENANT_INVALID_STATUS",
-				Message:  "Account status invalid.",
-			})
+		if err := httperror.TenantStatus(tenantCtx); err != nil {
+			m.config.ErrorHandler(c, err)
 			return
 		}
 
@@ -199,6 +263,11 @@ func (m *Middleware) ValidateTenant() gin.HandlerFunc {
 // EnforceLimits is middleware that enforces plan limits
 func (m *Middleware) EnforceLimits() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if m.shouldSkipPath(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
 		tenantCtx, exists := GetTenantFromContext(c)
 		if !exists {
 			m.config.ErrorHandler(c, &tenant.TenantError{
@@ -216,7 +285,13 @@ func (m *Middleware) EnforceLimits() gin.HandlerFunc {
 				zap.Error(err))
 
 			// Determine error type and response
-			if strings.Contains(err.Error(), "limit exceeded") {
+			var limitErr *tenant.LimitExceededError
+			if errors.As(err, &limitErr) {
+				if m.config.LimitHeaders {
+					m.setExceededLimitHeaders(c, tenantCtx, limitErr)
+				}
+				m.limitExceeded(c, limitErr)
+			} else if strings.Contains(err.Error(), "limit exceeded") {
 				m.config.ErrorHandler(c, &tenant.TenantError{
 					TenantID: tenantCtx.TenantID,
 					Code:     "PLAN_LIMIT_EXCEEDED",
@@ -232,12 +307,126 @@ func (m *Middleware) EnforceLimits() gin.HandlerFunc {
 			return
 		}
 
+		if m.config.LimitHeaders {
+			m.setLimitHeaders(c, tenantCtx)
+		}
+
 		// Set limits in context for use in handlers
 		c.Set("plan_limits", limits)
 		c.Next()
 	}
 }
 
+// EnforceLimit is middleware that denies requests once the tenant's usage of a
+// single plan limit, as reported by the usage tracker, exceeds the plan's value.
+// The limit is registered with RegisterEnforcedLimit.
+func (m *Middleware) EnforceLimit(limitName string) gin.HandlerFunc {
+	m.RegisterEnforcedLimit(limitName)
+
+	return func(c *gin.Context) {
+		tenantCtx, exists := GetTenantFromContext(c)
+		if !exists {
+			m.config.ErrorHandler(c, &tenant.TenantError{
+				Code:    "TENANT_CONTEXT_MISSING",
+				Message: "Tenant context not found",
+			})
+			return
+		}
+
+		if err := m.manager.CheckLimit(c.Request.Context(), tenantCtx.TenantID, limitName, nil); err != nil {
+			m.logger.Error("Plan limit check failed",
+				zap.String("tenant_id", tenantCtx.TenantID.String()),
+				zap.String("limit", limitName),
+				zap.Error(err))
+
+			var limitErr *tenant.LimitExceededError
+			if errors.As(err, &limitErr) {
+				m.limitExceeded(c, limitErr)
+			} else {
+				m.config.ErrorHandler(c, &tenant.TenantError{
+					TenantID: tenantCtx.TenantID,
+					Code:     "LIMIT_CHECK_FAILED",
+					Message:  "Unable to verify plan limits",
+				})
+			}
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ReleaseLimit is middleware for routes that free a limited resource, such as
+// DELETE handlers for projects. Once the handler has succeeded it releases one
+// unit of the tenant's usage of limitName, never going below zero. Failures
+// are logged, since the response has already been written.
+func (m *Middleware) ReleaseLimit(limitName string) gin.HandlerFunc {
+	m.RegisterEnforcedLimit(limitName)
+
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.IsAborted() || c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+
+		tenantCtx, exists := GetTenantFromContext(c)
+		if !exists {
+			m.logger.Warn("Tenant context not found, usage not released",
+				zap.String("limit", limitName))
+			return
+		}
+
+		if err := m.manager.ReleaseUsage(c.Request.Context(), tenantCtx.TenantID, limitName, 1); err != nil {
+			m.logger.Error("Failed to release usage",
+				zap.String("tenant_id", tenantCtx.TenantID.String()),
+				zap.String("limit", limitName),
+				zap.Error(err))
+		}
+	}
+}
+
+// RegisterEnforcedLimit declares that a route depends on a plan limit, so that
+// ValidateEnforcedLimits can check it exists. EnforceLimit registers its limit;
+// handlers that check limits themselves should register theirs.
+func (m *Middleware) RegisterEnforcedLimit(limitName string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, name := range m.enforcedLimits {
+		if name == limitName {
+			return
+		}
+	}
+	m.enforcedLimits = append(m.enforcedLimits, limitName)
+}
+
+// ValidateEnforcedLimits checks that every registered limit is defined in the
+// limit schema and returns an error wrapping ErrUndefinedLimit naming those
+// that are not. Call it at startup, once routes are registered, so a misspelt
+// or removed limit fails fast instead of silently never being enforced.
+func (m *Middleware) ValidateEnforcedLimits() error {
+	defined := make(map[string]bool)
+	for _, desc := range m.manager.GetLimitSchema() {
+		defined[desc.Name] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var missing []string
+	for _, name := range m.enforcedLimits {
+		if !defined[name] {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrUndefinedLimit, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
 // RequireAdmin is middleware that requires tenant admin privileges
 func (m *Middleware) RequireAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -292,9 +481,18 @@ func (m *Middleware) LogAccess() gin.HandlerFunc {
 }
 
 // SetTenantDB is middleware that sets up tenant-specific database connection.
-// It acquires a dedicated connection with the tenant's search_path set and ensures
-// proper cleanup when the request completes.
+// It is WithTenantConn under its original name.
 func (m *Middleware) SetTenantDB() gin.HandlerFunc {
+	return m.WithTenantConn()
+}
+
+// WithTenantConn is middleware that acquires a dedicated connection with the
+// tenant's search_path set, using the manager's GetTenantConn, and binds it to
+// both the Gin context and the request context, so handlers can get it with
+// GetTenantConnFromContext or tenant.GetTenantConnFromContext. The connection
+// is returned to the pool when the request completes. Requests without a
+// resolved tenant pass through without a connection.
+func (m *Middleware) WithTenantConn() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tenantCtx, exists := GetTenantFromContext(c)
 		if !exists {
@@ -328,10 +526,33 @@ func (m *Middleware) SetTenantDB() gin.HandlerFunc {
 
 		// Set database connection in context
 		c.Set("tenant_conn", conn)
+		c.Request = c.Request.WithContext(tenant.WithTenantConn(c.Request.Context(), conn))
 		c.Next()
 	}
 }
 
+// SelfCheckHandler serves the manager's self-check report, with status 200 if
+// the system is healthy and 503 otherwise. Mount it on an admin-only route.
+func (m *Middleware) SelfCheckHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, err := m.manager.SelfCheck(c.Request.Context())
+		if err != nil {
+			m.logger.Error("Self-check failed", zap.Error(err))
+			m.config.ErrorHandler(c, &tenant.TenantError{
+				Code:    "SELF_CHECK_FAILED",
+				Message: "Self-check could not be completed",
+			})
+			return
+		}
+
+		statusCode := http.StatusOK
+		if !report.Healthy {
+			statusCode = http.StatusServiceUnavailable
+		}
+		c.JSON(statusCode, report)
+	}
+}
+
 // Helper functions
 
 // GetTenantFromContext extracts tenant context from Gin context
@@ -381,71 +602,74 @@ func GetTenantConnFromContext(c *gin.Context) (*sql.Conn, bool) {
 	return tc, ok
 }
 
-// shouldSkipPath checks if a path should skip tenant resolution
-func (m *Middleware) shouldSkipPath(path string) bool {
-	for _, skipPath := range m.config.SkipPaths {
-		if strings.HasPrefix(path, skipPath) {
-			return true
-		}
+// tenantNotFound responds to a request for an unknown tenant, using the
+// OnTenantNotFound hook if configured and ErrorHandler otherwise
+func (m *Middleware) tenantNotFound(c *gin.Context, err *tenant.TenantError) {
+	if m.config.OnTenantNotFound == nil {
+		m.config.ErrorHandler(c, err)
+		return
 	}
-	return false
+
+	m.config.OnTenantNotFound(c)
+	c.Abort()
 }
 
-// defaultErrorHandler is the default error handler for tenant errors
-func defaultErrorHandler(c *gin.Context, err error) {
-	var statusCode int
-	var response gin.H
-
-	switch e := err.(type) {
-	case *tenant.TenantError:
-		switch e.Code {
-		case "TENANT_NOT_FOUND":
-			statusCode = http.StatusNotFound
-		case "TENANT_SUSPENDED", "TENANT_CANCELLED", "ACCESS_DENIED":
-			statusCode = http.StatusForbidden
-		case "TENANT_PENDING":
-			statusCode = http.StatusForbidden
-		case "PLAN_LIMIT_EXCEEDED":
-			statusCode = http.StatusPaymentRequired
-		case "ADMIN_REQUIRED":
-			statusCode = http.StatusForbidden
-		case "USER_NOT_AUTHENTICATED":
-			statusCode = http.StatusUnauthorized
-		default:
-			statusCode = http.StatusInternalServerError
-		}
+// limitExceeded responds to a request denied by a plan limit, using the
+// OnLimitExceeded hook if configured and a 402 naming the limit otherwise
+func (m *Middleware) limitExceeded(c *gin.Context, err *tenant.LimitExceededError) {
+	if m.config.OnLimitExceeded != nil {
+		m.config.OnLimitExceeded(c, err)
+		c.Abort()
+		return
+	}
 
-		response = gin.H{
-			"error": gin.H{
-				"code":    e.Code,
-				"message": e.Message,
-			},
-		}
+	body := &ErrorBody{
+		Code:     "PLAN_LIMIT_EXCEEDED",
+		Message:  err.Message,
+		TenantID: err.TenantID,
+		Details: map[string]interface{}{
+			"limit":         err.Limit,
+			"limit_value":   err.Value,
+			"current_usage": err.Current,
+		},
+	}
+	if m.config.UpgradeURL != "" {
+		body.Details["upgrade_url"] = m.config.UpgradeURL
+	}
 
-		if e.TenantID != uuid.Nil {
-			response["tenant_id"] = e.TenantID.String()
-		}
+	m.config.ErrorResponder.RespondError(c, http.StatusPaymentRequired, body)
+	c.Abort()
+}
 
-	case *tenant.ValidationError:
-		statusCode = http.StatusBadRequest
-		response = gin.H{
-			"error": gin.H{
-				"code":    "VALIDATION_ERROR",
-				"message": e.Message,
-				"field":   e.Field,
-			},
-		}
+// shouldSkipPath checks if a path should skip tenant resolution
+func (m *Middleware) shouldSkipPath(path string) bool {
+	return httperror.SkipPath(m.config.SkipPaths, path)
+}
 
-	default:
-		statusCode = http.StatusInternalServerError
-		response = gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "An internal error occurred",
+// errorResponse maps an error to its status code and response body
+func errorResponse(err error) (int, *ErrorBody) {
+	statusCode := httperror.Status(err)
+
+	var limitErr *tenant.LimitExceededError
+	var tenantErr *tenant.TenantError
+	var validationErr *tenant.ValidationError
+	switch {
+	case errors.As(err, &limitErr):
+		return statusCode, &ErrorBody{
+			Code:     "PLAN_LIMIT_EXCEEDED",
+			Message:  limitErr.Message,
+			TenantID: limitErr.TenantID,
+			Details: map[string]interface{}{
+				"limit":         limitErr.Limit,
+				"limit_value":   limitErr.Value,
+				"current_usage": limitErr.Current,
 			},
 		}
+	case errors.As(err, &tenantErr):
+		return statusCode, &ErrorBody{Code: tenantErr.Code, Message: tenantErr.Message, TenantID: tenantErr.TenantID}
+	case errors.As(err, &validationErr):
+		return statusCode, &ErrorBody{Code: "VALIDATION_ERROR", Message: validationErr.Message, Field: validationErr.Field}
+	default:
+		return statusCode, &ErrorBody{Code: "INTERNAL_ERROR", Message: "An internal error occurred"}
 	}
-
-	c.JSON(statusCode, response)
-	c.Abort()
 }
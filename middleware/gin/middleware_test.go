@@ -0,0 +1,542 @@
+package gin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestResolveTenant_ErrorStatusCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tenantID := uuid.New()
+	dbErr := errors.New("dial tcp 10.0.0.5:5432: connection refused")
+
+	tests := []struct {
+		name       string
+		resolveErr error
+		getErr     error
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "unknown tenant",
+			resolveErr: fmt.Errorf("%w for subdomain: ghost", tenant.ErrTenantNotFound),
+			wantStatus: http.StatusNotFound,
+			wantCode:   "TENANT_NOT_FOUND",
+		},
+		{
+			name:       "database down during resolution",
+			resolveErr: fmt.Errorf("failed to look up tenant for subdomain acme: %w", dbErr),
+			wantStatus: http.StatusServiceUnavailable,
+			wantCode:   "TENANT_LOOKUP_FAILED",
+		},
+		{
+			name:       "invalid bearer token",
+			resolveErr: fmt.Errorf("%w: token has invalid claims: token is expired", tenant.ErrInvalidToken),
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   "INVALID_TOKEN",
+		},
+		{
+			name:       "tenant removed after resolution",
+			getErr:     fmt.Errorf("%w: sql: no rows in result set", tenant.ErrTenantNotFound),
+			wantStatus: http.StatusNotFound,
+			wantCode:   "TENANT_NOT_FOUND",
+		},
+		{
+			name:       "database down loading tenant",
+			getErr:     fmt.Errorf("failed to get tenant: %w", dbErr),
+			wantStatus: http.StatusServiceUnavailable,
+			wantCode:   "TENANT_LOOKUP_FAILED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := &lookupTestManager{err: tt.getErr}
+			resolver := &lookupTestResolver{tenantID: tenantID, err: tt.resolveErr}
+			mw := NewMiddleware(manager, resolver, zaptest.NewLogger(t), Config{})
+
+			r := gin.New()
+			r.Use(mw.ResolveTenant())
+			r.GET("/projects", func(c *gin.Context) {
+				t.Error("handler should not run when resolution fails")
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/projects", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if body := w.Body.String(); !strings.Contains(body, tt.wantCode) {
+				t.Errorf("body = %s, want error code %s", body, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestSkipPaths_BypassValidationAndLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mw := NewMiddleware(&lookupTestManager{}, &lookupTestResolver{err: tenant.ErrTenantNotFound}, zaptest.NewLogger(t), Config{SkipPaths: []string{"/health"}})
+	r := gin.New()
+	r.Use(mw.ResolveTenant(), mw.ValidateTenant(), mw.EnforceLimits())
+	r.GET("/health", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("skipped path = %d %s, want %d from the handler", w.Code, w.Body.String(), http.StatusNoContent)
+	}
+}
+
+// lookupTestManager fails GetTenant with err
+type lookupTestManager struct {
+	tenant.Manager
+	err error
+}
+
+func (m *lookupTestManager) GetTenant(ctx context.Context, id uuid.UUID) (*tenant.Tenant, error) {
+	return nil, m.err
+}
+
+// lookupTestResolver resolves to tenantID unless err is set
+type lookupTestResolver struct {
+	tenant.Resolver
+	tenantID uuid.UUID
+	err      error
+}
+
+func (r *lookupTestResolver) ResolveTenant(ctx context.Context, req *http.Request) (uuid.UUID, error) {
+	if r.err != nil {
+		return uuid.Nil, r.err
+	}
+	return r.tenantID, nil
+}
+
+func TestResolveTenant_OnTenantNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	notFound := &lookupTestResolver{err: fmt.Errorf("%w for subdomain: ghost", tenant.ErrTenantNotFound)}
+	dbDown := &lookupTestResolver{err: errors.New("connection refused")}
+
+	called := 0
+	config := Config{
+		OnTenantNotFound: func(c *gin.Context) {
+			called++
+			c.Redirect(http.StatusFound, "https://example.com/signup")
+		},
+	}
+
+	serve := func(resolver tenant.Resolver, config Config) *httptest.ResponseRecorder {
+		mw := NewMiddleware(&lookupTestManager{}, resolver, zaptest.NewLogger(t), config)
+		r := gin.New()
+		r.Use(mw.ResolveTenant())
+		r.GET("/projects", func(c *gin.Context) {
+			t.Error("handler should not run when resolution fails")
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/projects", nil))
+		return w
+	}
+
+	// The custom handler replaces the default response for unknown tenants
+	w := serve(notFound, config)
+	if called != 1 {
+		t.Errorf("OnTenantNotFound called %d times, want 1", called)
+	}
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "https://example.com/signup" {
+		t.Errorf("response = %d %s, want redirect to signup", w.Code, w.Header().Get("Location"))
+	}
+
+	// Lookup failures still use the error handler
+	w = serve(dbDown, config)
+	if called != 1 {
+		t.Error("OnTenantNotFound should not be called for lookup failures")
+	}
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+
+	// Without a hook the default JSON 404 is returned
+	w = serve(notFound, Config{})
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "TENANT_NOT_FOUND") {
+		t.Errorf("response = %d %s, want default 404", w.Code, w.Body.String())
+	}
+}
+
+func TestEnforceLimits_LimitExceeded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tenantID := uuid.New()
+	limitErr := &tenant.LimitExceededError{
+		TenantID: tenantID,
+		Code:     "LIMIT_EXCEEDED",
+		Limit:    "video_processing_minutes",
+		Value:    600,
+		Current:  750,
+		Message:  "Limit exceeded for video_processing_minutes: current=750, limit=600",
+	}
+	manager := &limitsTestManager{err: fmt.Errorf("limit check failed for video_processing_minutes: %w", limitErr)}
+
+	serve := func(config Config) *httptest.ResponseRecorder {
+		mw := NewMiddleware(manager, &lookupTestResolver{}, zaptest.NewLogger(t), config)
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set("tenant", &tenant.Context{TenantID: tenantID})
+		}, mw.EnforceLimits())
+		r.POST("/videos", func(c *gin.Context) {
+			t.Error("handler should not run when a limit is exceeded")
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/videos", nil))
+		return w
+	}
+
+	w := serve(Config{UpgradeURL: "https://example.com/billing/upgrade"})
+	if w.Code != http.StatusPaymentRequired {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusPaymentRequired)
+	}
+
+	var body struct {
+		Error struct {
+			Code         string `json:"code"`
+			Limit        string `json:"limit"`
+			LimitValue   int    `json:"limit_value"`
+			CurrentUsage int    `json:"current_usage"`
+			UpgradeURL   string `json:"upgrade_url"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	if body.Error.Code != "PLAN_LIMIT_EXCEEDED" || body.Error.Limit != "video_processing_minutes" {
+		t.Errorf("error = %+v, want PLAN_LIMIT_EXCEEDED naming video_processing_minutes", body.Error)
+	}
+	if body.Error.LimitValue != 600 || body.Error.CurrentUsage != 750 {
+		t.Errorf("error = %+v, want limit 600 and usage 750", body.Error)
+	}
+	if body.Error.UpgradeURL != "https://example.com/billing/upgrade" {
+		t.Errorf("upgrade_url = %q, want the configured URL", body.Error.UpgradeURL)
+	}
+
+	// The response can be replaced, e.g. to rate-limit instead of asking for payment
+	w = serve(Config{
+		OnLimitExceeded: func(c *gin.Context, err *tenant.LimitExceededError) {
+			c.Header("Retry-After", "60")
+			c.JSON(http.StatusTooManyRequests, gin.H{"limit": err.Limit})
+		},
+	})
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "video_processing_minutes") {
+		t.Errorf("response = %d %s, want custom 429 naming the limit", w.Code, w.Body.String())
+	}
+
+	// Other limit check failures keep the generic response
+	manager.err = errors.New("connection refused")
+	w = serve(Config{})
+	if !strings.Contains(w.Body.String(), "LIMIT_CHECK_FAILED") {
+		t.Errorf("body = %s, want LIMIT_CHECK_FAILED", w.Body.String())
+	}
+}
+
+func TestValidateEnforcedLimits(t *testing.T) {
+	manager := &limitsTestManager{schema: []tenant.LimitDescription{{Name: "max_users"}, {Name: "video_processing_minutes"}}}
+	mw := NewMiddleware(manager, &lookupTestResolver{}, zaptest.NewLogger(t), Config{})
+
+	r := gin.New()
+	r.POST("/videos", mw.EnforceLimit("video_processing_minutes"), func(c *gin.Context) {})
+	r.POST("/users", mw.EnforceLimit("max_users"), func(c *gin.Context) {})
+	if err := mw.ValidateEnforcedLimits(); err != nil {
+		t.Errorf("ValidateEnforcedLimits() error = %v, want nil when every limit is defined", err)
+	}
+
+	// A limit no plan schema defines is reported by name
+	r.POST("/exports", mw.EnforceLimit("csv_export_rows"), func(c *gin.Context) {})
+	mw.RegisterEnforcedLimit("pdf_pages")
+	err := mw.ValidateEnforcedLimits()
+	if !errors.Is(err, ErrUndefinedLimit) {
+		t.Fatalf("ValidateEnforcedLimits() error = %v, want ErrUndefinedLimit", err)
+	}
+	if !strings.Contains(err.Error(), "csv_export_rows") || !strings.Contains(err.Error(), "pdf_pages") {
+		t.Errorf("ValidateEnforcedLimits() error = %v, want both undefined limits named", err)
+	}
+	if strings.Contains(err.Error(), "max_users") {
+		t.Errorf("ValidateEnforcedLimits() error = %v, should not name defined limits", err)
+	}
+}
+
+func TestEnforceLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tenantID := uuid.New()
+	manager := &limitsTestManager{}
+	mw := NewMiddleware(manager, &lookupTestResolver{}, zaptest.NewLogger(t), Config{})
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("tenant", &tenant.Context{TenantID: tenantID})
+	})
+	r.POST("/videos", mw.EnforceLimit("video_processing_minutes"), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/videos", nil))
+		return w
+	}
+
+	if w := serve(); w.Code != http.StatusCreated {
+		t.Errorf("status = %d, want %d within the limit", w.Code, http.StatusCreated)
+	}
+	if manager.checked != "video_processing_minutes" {
+		t.Errorf("checked limit %q, want video_processing_minutes", manager.checked)
+	}
+
+	manager.err = &tenant.LimitExceededError{TenantID: tenantID, Code: "LIMIT_EXCEEDED", Limit: "video_processing_minutes", Value: 600, Current: 601}
+	w := serve()
+	if w.Code != http.StatusPaymentRequired || !strings.Contains(w.Body.String(), "video_processing_minutes") {
+		t.Errorf("response = %d %s, want 402 naming the limit", w.Code, w.Body.String())
+	}
+}
+
+// limitsTestManager fails limit checks with err
+type limitsTestManager struct {
+	tenant.Manager
+	err     error
+	schema  []tenant.LimitDescription
+	checked string // Last limit passed to CheckLimit
+}
+
+func (m *limitsTestManager) CheckLimit(ctx context.Context, tenantID uuid.UUID, limitName string, currentValue interface{}) error {
+	m.checked = limitName
+	return m.err
+}
+
+func (m *limitsTestManager) GetLimitSchema() []tenant.LimitDescription {
+	return m.schema
+}
+
+func (m *limitsTestManager) CheckLimits(ctx context.Context, tenantID uuid.UUID) (*tenant.Limits, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &tenant.Limits{}, nil
+}
+
+func TestErrorResponder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tenantID := uuid.New()
+	serve := func(config Config, resolver tenant.Resolver) *httptest.ResponseRecorder {
+		mw := NewMiddleware(&lookupTestManager{err: tenant.ErrTenantNotFound}, resolver, zaptest.NewLogger(t), config)
+		r := gin.New()
+		r.Use(mw.ResolveTenant())
+		r.GET("/projects", func(c *gin.Context) {
+			t.Error("handler should not run for an unknown tenant")
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/projects", nil))
+		return w
+	}
+
+	// A flat {code, message} envelope
+	flat := ErrorResponderFunc(func(c *gin.Context, statusCode int, body *ErrorBody) {
+		c.JSON(statusCode, gin.H{"code": body.Code, "message": body.Message, "tenant": body.TenantID.String()})
+	})
+	w := serve(Config{ErrorResponder: flat}, &lookupTestResolver{tenantID: tenantID})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	var body struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Tenant  string `json:"tenant"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	if body.Code != "TENANT_NOT_FOUND" || body.Tenant != tenantID.String() {
+		t.Errorf("body = %+v, want flat TENANT_NOT_FOUND for the tenant", body)
+	}
+
+	// XML, for a lookup failure
+	xmlResponder := ErrorResponderFunc(func(c *gin.Context, statusCode int, body *ErrorBody) {
+		c.XML(statusCode, struct {
+			XMLName struct{} `xml:"error"`
+			Code    string   `xml:"code,attr"`
+			Message string   `xml:"message"`
+		}{Code: body.Code, Message: body.Message})
+	})
+	w = serve(Config{ErrorResponder: xmlResponder}, &lookupTestResolver{err: errors.New("connection refused")})
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/xml") {
+		t.Errorf("Content-Type = %q, want application/xml", ct)
+	}
+	if !strings.Contains(w.Body.String(), `<error code="TENANT_LOOKUP_FAILED">`) {
+		t.Errorf("body = %s, want an XML TENANT_LOOKUP_FAILED error", w.Body.String())
+	}
+
+	// The default keeps the nested JSON shape
+	w = serve(Config{}, &lookupTestResolver{tenantID: tenantID})
+	if !strings.Contains(w.Body.String(), `"error":{"code":"TENANT_NOT_FOUND"`) || !strings.Contains(w.Body.String(), `"tenant_id":"`+tenantID.String()+`"`) {
+		t.Errorf("body = %s, want the default JSON envelope", w.Body.String())
+	}
+}
+
+// selfCheckTestManager returns a fixed self-check report
+type selfCheckTestManager struct {
+	tenant.Manager
+	report *tenant.SelfCheckReport
+	err    error
+}
+
+func (m *selfCheckTestManager) SelfCheck(ctx context.Context) (*tenant.SelfCheckReport, error) {
+	return m.report, m.err
+}
+
+func TestSelfCheckHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(manager tenant.Manager) *httptest.ResponseRecorder {
+		mw := NewMiddleware(manager, &lookupTestResolver{}, zaptest.NewLogger(t), Config{})
+		r := gin.New()
+		r.GET("/admin/self-check", mw.SelfCheckHandler())
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/self-check", nil))
+		return w
+	}
+
+	w := serve(&selfCheckTestManager{report: &tenant.SelfCheckReport{Healthy: true, Findings: []tenant.SelfCheckFinding{}}})
+	if w.Code != http.StatusOK {
+		t.Errorf("healthy status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	unhealthy := &tenant.SelfCheckReport{Findings: []tenant.SelfCheckFinding{{
+		Category: tenant.SelfCheckPlanLimits,
+		Severity: tenant.SeverityError,
+		Message:  "plan pro: limit 'max_users': value is not an integer",
+	}}}
+	w = serve(&selfCheckTestManager{report: unhealthy})
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("unhealthy status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	var report tenant.SelfCheckReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("response is not a report: %v", err)
+	}
+	if len(report.Findings) != 1 || report.Findings[0].Category != tenant.SelfCheckPlanLimits {
+		t.Errorf("findings = %+v, want the plan limits finding", report.Findings)
+	}
+
+	w = serve(&selfCheckTestManager{err: context.DeadlineExceeded})
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "SELF_CHECK_FAILED") {
+		t.Errorf("failed check = %d %s, want 500 SELF_CHECK_FAILED", w.Code, w.Body.String())
+	}
+}
+
+// releaseTestManager records released usage
+type releaseTestManager struct {
+	tenant.Manager
+	released map[string]int
+}
+
+func (m *releaseTestManager) ReleaseUsage(ctx context.Context, tenantID uuid.UUID, limitName string, amount int) error {
+	m.released[limitName] += amount
+	return nil
+}
+
+func TestReleaseLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	manager := &releaseTestManager{released: make(map[string]int)}
+	mw := NewMiddleware(manager, &lookupTestResolver{}, zaptest.NewLogger(t), Config{})
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("tenant", &tenant.Context{TenantID: uuid.New()})
+	})
+	r.DELETE("/projects/:id", mw.ReleaseLimit("max_projects"), func(c *gin.Context) {
+		if c.Param("id") == "missing" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	for _, path := range []string{"/projects/1", "/projects/2", "/projects/missing"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, path, nil))
+	}
+
+	if got := manager.released["max_projects"]; got != 2 {
+		t.Errorf("released = %d, want 2 for the successful deletes only", got)
+	}
+}
+
+// connTestManager fails GetTenantConn with err
+type connTestManager struct {
+	tenant.Manager
+	err error
+}
+
+func (m *connTestManager) GetTenantConn(ctx context.Context, tenantID uuid.UUID) (*sql.Conn, error) {
+	return nil, m.err
+}
+
+func TestWithTenantConn_Failures(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	manager := &connTestManager{err: errors.New("connection refused")}
+	mw := NewMiddleware(manager, &lookupTestResolver{}, zaptest.NewLogger(t), Config{})
+
+	serve := func(tenantCtx *tenant.Context) (*httptest.ResponseRecorder, bool) {
+		handled := false
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			if tenantCtx != nil {
+				c.Set("tenant", tenantCtx)
+			}
+		}, mw.WithTenantConn())
+		r.GET("/projects", func(c *gin.Context) {
+			handled = true
+			if _, ok := GetTenantConnFromContext(c); ok {
+				t.Error("no connection should be bound without a tenant")
+			}
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/projects", nil))
+		return w, handled
+	}
+
+	w, handled := serve(&tenant.Context{TenantID: uuid.New()})
+	if handled || w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "DATABASE_ERROR") {
+		t.Errorf("response = %d %s, want 500 DATABASE_ERROR without running the handler", w.Code, w.Body.String())
+	}
+
+	// Requests without a resolved tenant pass through
+	if _, handled := serve(nil); !handled {
+		t.Error("handler should run for requests without a tenant")
+	}
+}
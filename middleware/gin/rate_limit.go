@@ -0,0 +1,247 @@
+package gin
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// RateLimitStore keeps a token bucket per key for RateLimit. Implement it on
+// a shared store such as Redis to limit tenants across processes.
+type RateLimitStore interface {
+	// Take removes a token from the bucket for key, which holds up to capacity
+	// tokens and refills completely every interval. When the bucket is empty
+	// it returns false and how long until a token is available.
+	Take(ctx context.Context, key string, capacity int, interval time.Duration, now time.Time) (bool, time.Duration, error)
+}
+
+// DefaultRateLimitCacheTTL is how long RateLimit reuses a tenant's rate when
+// Config.RateLimitCacheTTL is not set
+const DefaultRateLimitCacheTTL = time.Minute
+
+// MemoryRateLimitStore is a RateLimitStore that keeps buckets in memory.
+// Buckets left idle until they are full again are dropped, so keys that stop
+// sending requests do not keep memory.
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	swept   time.Time // when idle buckets were last dropped
+}
+
+// tokenBucket is a bucket's tokens as of updated
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewMemoryRateLimitStore creates an empty in-memory rate limit store
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*tokenBucket)}
+}
+
+// Take implements RateLimitStore. New buckets start full, and a bucket whose
+// capacity changes, e.g. after a plan change, keeps its tokens up to the new
+// capacity.
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, capacity int, interval time.Duration, now time.Time) (bool, time.Duration, error) {
+	if capacity <= 0 {
+		return false, interval, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dropIdle(interval, now)
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(capacity), updated: now}
+		s.buckets[key] = bucket
+	}
+
+	perToken := interval / time.Duration(capacity)
+	if elapsed := now.Sub(bucket.updated); elapsed > 0 {
+		bucket.tokens += float64(elapsed) / float64(perToken)
+		bucket.updated = now
+	}
+	bucket.tokens = math.Min(bucket.tokens, float64(capacity))
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) * float64(perToken)), nil
+	}
+	bucket.tokens--
+	return true, 0, nil
+}
+
+// dropIdle drops the buckets untouched for an interval, at most once per
+// interval. They have refilled completely, the same as a new bucket.
+func (s *MemoryRateLimitStore) dropIdle(interval time.Duration, now time.Time) {
+	if now.Sub(s.swept) < interval {
+		return
+	}
+	for key, bucket := range s.buckets {
+		if now.Sub(bucket.updated) >= interval {
+			delete(s.buckets, key)
+		}
+	}
+	s.swept = now
+}
+
+// tenantRate is a tenant's api_rate_per_minute as read by RateLimit
+type tenantRate struct {
+	rate    int
+	limited bool // false if the limit is unlimited or not defined
+	expires time.Time
+}
+
+// rateCache holds tenants' rates for RateLimit, so that requests do not read
+// the tenant's limits each
+type rateCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[uuid.UUID]tenantRate
+	swept   time.Time // when expired entries were last dropped
+}
+
+func newRateCache(ttl time.Duration) *rateCache {
+	return &rateCache{ttl: ttl, entries: make(map[uuid.UUID]tenantRate)}
+}
+
+// get returns the tenant's rate unless it is missing or expired
+func (c *rateCache) get(tenantID uuid.UUID, now time.Time) (tenantRate, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rate, ok := c.entries[tenantID]
+	if !ok || !now.Before(rate.expires) {
+		return tenantRate{}, false
+	}
+	return rate, true
+}
+
+// put stores the tenant's rate, dropping expired entries at most once per ttl
+func (c *rateCache) put(tenantID uuid.UUID, rate tenantRate, now time.Time) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Sub(c.swept) >= c.ttl {
+		for id, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, id)
+			}
+		}
+		c.swept = now
+	}
+	rate.expires = now.Add(c.ttl)
+	c.entries[tenantID] = rate
+}
+
+// RateLimit is middleware that limits each tenant to its api_rate_per_minute
+// limit, with a token bucket per tenant in Config.RateLimitStore. Requests
+// over the limit get a 429 with a Retry-After header. Tenants whose limit is
+// unlimited, or whose plan does not define it, are not limited. Each
+// tenant's rate is read once per Config.RateLimitCacheTTL, so a plan change
+// takes effect within it. The limit is registered with RegisterEnforcedLimit.
+func (m *Middleware) RateLimit() gin.HandlerFunc {
+	m.RegisterEnforcedLimit(tenant.LimitNameAPIRatePerMinute)
+	rates := newRateCache(m.config.RateLimitCacheTTL)
+
+	return func(c *gin.Context) {
+		tenantCtx, exists := GetTenantFromContext(c)
+		if !exists {
+			m.config.ErrorHandler(c, &tenant.TenantError{
+				Code:    "TENANT_CONTEXT_MISSING",
+				Message: "Tenant context not found",
+			})
+			return
+		}
+
+		now := m.config.Clock()
+		rate, cached := rates.get(tenantCtx.TenantID, now)
+		if !cached {
+			var err error
+			rate, err = m.tenantRate(c.Request.Context(), tenantCtx.TenantID)
+			if err != nil {
+				m.rateLimitCheckFailed(c, tenantCtx, err)
+				return
+			}
+			rates.put(tenantCtx.TenantID, rate, now)
+		}
+		if !rate.limited {
+			c.Next()
+			return
+		}
+
+		allowed, retryAfter, err := m.config.RateLimitStore.Take(c.Request.Context(), tenantCtx.TenantID.String(), rate.rate, time.Minute, now)
+		if err != nil {
+			m.rateLimitCheckFailed(c, tenantCtx, err)
+			return
+		}
+		if !allowed {
+			m.rateLimited(c, tenantCtx, rate.rate, retryAfter)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// tenantRate reads the tenant's api_rate_per_minute from its limits
+func (m *Middleware) tenantRate(ctx context.Context, tenantID uuid.UUID) (tenantRate, error) {
+	limits, err := m.manager.GetTenantLimits(ctx, tenantID)
+	if err != nil {
+		return tenantRate{}, err
+	}
+	if _, defined := limits[tenant.LimitNameAPIRatePerMinute]; !defined || limits.IsUnlimited(tenant.LimitNameAPIRatePerMinute) {
+		return tenantRate{}, nil
+	}
+	rate, err := limits.GetInt(tenant.LimitNameAPIRatePerMinute)
+	if err != nil {
+		return tenantRate{}, err
+	}
+	return tenantRate{rate: rate, limited: true}, nil
+}
+
+// rateLimitCheckFailed responds to a request whose rate limit could not be
+// checked
+func (m *Middleware) rateLimitCheckFailed(c *gin.Context, tenantCtx *tenant.Context, err error) {
+	m.logger.Error("Rate limit check failed",
+		zap.String("tenant_id", tenantCtx.TenantID.String()),
+		zap.Error(err))
+
+	m.config.ErrorHandler(c, &tenant.TenantError{
+		TenantID: tenantCtx.TenantID,
+		Code:     "LIMIT_CHECK_FAILED",
+		Message:  "Unable to verify plan limits",
+	})
+}
+
+// rateLimited responds to a request over the tenant's rate limit with a 429
+// telling the client, in whole seconds, when to retry
+func (m *Middleware) rateLimited(c *gin.Context, tenantCtx *tenant.Context, rate int, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	m.config.ErrorResponder.RespondError(c, http.StatusTooManyRequests, &ErrorBody{
+		Code:     "RATE_LIMIT_EXCEEDED",
+		Message:  "Too many requests",
+		TenantID: tenantCtx.TenantID,
+		Details: map[string]interface{}{
+			"limit":       tenant.LimitNameAPIRatePerMinute,
+			"limit_value": rate,
+			"retry_after": seconds,
+		},
+	})
+	c.Abort()
+}
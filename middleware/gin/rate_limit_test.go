@@ -0,0 +1,216 @@
+package gin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limited, unlimited := uuid.New(), uuid.New()
+	manager := &rateLimitTestManager{limits: map[uuid.UUID]tenant.FlexibleLimits{
+		limited:   {tenant.LimitNameAPIRatePerMinute: tenant.IntLimit(3)},
+		unlimited: {tenant.LimitNameAPIRatePerMinute: tenant.UnlimitedInt()},
+	}}
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	mw := NewMiddleware(manager, &lookupTestResolver{}, zaptest.NewLogger(t), Config{
+		Clock: func() time.Time { return now },
+	})
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		id, _ := uuid.Parse(c.GetHeader("X-Tenant"))
+		c.Set("tenant", &tenant.Context{TenantID: id})
+	}, mw.RateLimit())
+	r.GET("/projects", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	serve := func(tenantID uuid.UUID) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/projects", nil)
+		req.Header.Set("X-Tenant", tenantID.String())
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// The bucket holds a minute's worth of requests
+	for i := 0; i < 3; i++ {
+		if w := serve(limited); w.Code != http.StatusOK {
+			t.Fatalf("request %d status = %d, want %d within the limit", i+1, w.Code, http.StatusOK)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		w := serve(limited)
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("status = %d, want %d past the limit", w.Code, http.StatusTooManyRequests)
+		}
+		if got := w.Header().Get("Retry-After"); got != "20" {
+			t.Errorf("Retry-After = %q, want 20 seconds until the next token", got)
+		}
+		if !strings.Contains(w.Body.String(), "RATE_LIMIT_EXCEEDED") {
+			t.Errorf("body = %s, want RATE_LIMIT_EXCEEDED", w.Body.String())
+		}
+	}
+
+	// A token is back after a third of a minute, the whole bucket after a minute
+	now = now.Add(15 * time.Second)
+	if w := serve(limited); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "5" {
+		t.Errorf("response = %d Retry-After %q, want 429 retrying in 5 seconds", w.Code, w.Header().Get("Retry-After"))
+	}
+	now = now.Add(5 * time.Second)
+	if w := serve(limited); w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d once a token is refilled", w.Code, http.StatusOK)
+	}
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if w := serve(limited); w.Code != http.StatusOK {
+			t.Errorf("request %d after recovery status = %d, want %d", i+1, w.Code, http.StatusOK)
+		}
+	}
+	if w := serve(limited); w.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want the refilled bucket capped at the limit", w.Code)
+	}
+
+	// Unlimited tenants are not limited
+	for i := 0; i < 10; i++ {
+		if w := serve(unlimited); w.Code != http.StatusOK {
+			t.Fatalf("unlimited tenant request %d status = %d, want %d", i+1, w.Code, http.StatusOK)
+		}
+	}
+	if len(mw.enforcedLimits) != 1 || mw.enforcedLimits[0] != tenant.LimitNameAPIRatePerMinute {
+		t.Errorf("enforced limits = %v, want %s registered", mw.enforcedLimits, tenant.LimitNameAPIRatePerMinute)
+	}
+}
+
+func TestRateLimit_Store(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tenantID := uuid.New()
+	manager := &rateLimitTestManager{limits: map[uuid.UUID]tenant.FlexibleLimits{
+		tenantID: {tenant.LimitNameAPIRatePerMinute: tenant.IntLimit(120)},
+	}}
+	store := &rateLimitTestStore{allowed: false, retryAfter: 1500 * time.Millisecond}
+	mw := NewMiddleware(manager, &lookupTestResolver{}, zaptest.NewLogger(t), Config{RateLimitStore: store})
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("tenant", &tenant.Context{TenantID: tenantID})
+	}, mw.RateLimit())
+	r.GET("/projects", func(c *gin.Context) {
+		t.Error("handler should not run when the store denies the request")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/projects", nil))
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "2" {
+		t.Errorf("response = %d Retry-After %q, want 429 rounded up to 2 seconds", w.Code, w.Header().Get("Retry-After"))
+	}
+	if store.key != tenantID.String() || store.capacity != 120 || store.interval != time.Minute {
+		t.Errorf("Take(%q, %d, %v), want the tenant's bucket of 120 per minute", store.key, store.capacity, store.interval)
+	}
+}
+
+func TestRateLimit_CachesTenantRate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tenantID := uuid.New()
+	manager := &rateLimitTestManager{limits: map[uuid.UUID]tenant.FlexibleLimits{
+		tenantID: {tenant.LimitNameAPIRatePerMinute: tenant.IntLimit(120)},
+	}}
+	store := &rateLimitTestStore{allowed: true}
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	mw := NewMiddleware(manager, &lookupTestResolver{}, zaptest.NewLogger(t), Config{
+		RateLimitStore: store,
+		Clock:          func() time.Time { return now },
+	})
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("tenant", &tenant.Context{TenantID: tenantID})
+	}, mw.RateLimit())
+	r.GET("/projects", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	serve := func() {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/projects", nil))
+	}
+
+	for i := 0; i < 5; i++ {
+		serve()
+	}
+	if manager.calls != 1 {
+		t.Errorf("GetTenantLimits() called %d times, want once within the cache TTL", manager.calls)
+	}
+
+	// A plan change takes effect once the cached rate expires
+	manager.limits[tenantID] = tenant.FlexibleLimits{tenant.LimitNameAPIRatePerMinute: tenant.IntLimit(600)}
+	now = now.Add(DefaultRateLimitCacheTTL)
+	serve()
+	if manager.calls != 2 || store.capacity != 600 {
+		t.Errorf("after the TTL GetTenantLimits() called %d times with capacity %d, want the new rate read", manager.calls, store.capacity)
+	}
+}
+
+func TestMemoryRateLimitStore_DropsIdleBuckets(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	ctx := context.Background()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	store.Take(ctx, "acme", 3, time.Minute, now)
+	store.Take(ctx, "globex", 3, time.Minute, now)
+	if len(store.buckets) != 2 {
+		t.Fatalf("store has %d buckets, want 2", len(store.buckets))
+	}
+
+	// Once refilled, idle buckets are dropped and missing ones start full
+	now = now.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		if allowed, _, _ := store.Take(ctx, "acme", 3, time.Minute, now.Add(time.Duration(i)*time.Millisecond)); !allowed {
+			t.Fatalf("Take() %d denied, want a full bucket", i+1)
+		}
+	}
+	if _, ok := store.buckets["globex"]; ok || len(store.buckets) != 1 {
+		t.Errorf("store has buckets %v, want the idle one dropped", store.buckets)
+	}
+	if allowed, _, _ := store.Take(ctx, "acme", 3, time.Minute, now.Add(3*time.Millisecond)); allowed {
+		t.Error("Take() allowed a fourth request, want the active bucket kept")
+	}
+}
+
+// rateLimitTestManager returns per-tenant limits and counts the reads
+type rateLimitTestManager struct {
+	tenant.Manager
+	limits map[uuid.UUID]tenant.FlexibleLimits
+	calls  int
+}
+
+func (m *rateLimitTestManager) GetTenantLimits(ctx context.Context, tenantID uuid.UUID) (tenant.FlexibleLimits, error) {
+	m.calls++
+	return m.limits[tenantID], nil
+}
+
+// rateLimitTestStore answers every Take the same way and records the last call
+type rateLimitTestStore struct {
+	allowed    bool
+	retryAfter time.Duration
+
+	key      string
+	capacity int
+	interval time.Duration
+}
+
+func (s *rateLimitTestStore) Take(ctx context.Context, key string, capacity int, interval time.Duration, now time.Time) (bool, time.Duration, error) {
+	s.key, s.capacity, s.interval = key, capacity, interval
+	return s.allowed, s.retryAfter, nil
+}
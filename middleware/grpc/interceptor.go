@@ -0,0 +1,193 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultMetadataKey is the metadata key carrying the tenant when
+// Config.MetadataKey is not set
+const DefaultMetadataKey = "x-tenant"
+
+// Interceptor provides gRPC server interceptors for multi-tenant services
+type Interceptor struct {
+	manager  tenant.Manager
+	resolver tenant.Resolver
+	logger   *zap.Logger
+	config   Config
+}
+
+// Config contains configuration for the gRPC interceptors
+type Config struct {
+	// MetadataKey is the metadata key holding the tenant's subdomain or ID
+	MetadataKey string
+	// SkipMethods are full method names, such as "/grpc.health.v1.Health/Check",
+	// or prefixes of them that skip tenant resolution
+	SkipMethods []string
+	// AllowInactive lets requests for suspended, pending or cancelled tenants
+	// through instead of rejecting them
+	AllowInactive bool
+}
+
+// NewInterceptor creates new gRPC interceptors
+func NewInterceptor(manager tenant.Manager, resolver tenant.Resolver, logger *zap.Logger, config Config) *Interceptor {
+	if config.MetadataKey == "" {
+		config.MetadataKey = DefaultMetadataKey
+	}
+	config.MetadataKey = strings.ToLower(config.MetadataKey)
+
+	return &Interceptor{
+		manager:  manager,
+		resolver: resolver,
+		logger:   logger.Named("grpc_interceptor"),
+		config:   config,
+	}
+}
+
+// UnaryServerInterceptor resolves and validates the tenant named in the
+// request metadata and adds it to the handler's context
+func (i *Interceptor) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := i.resolve(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor resolves and validates the tenant named in the
+// stream metadata and adds it to the stream's context
+func (i *Interceptor) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := i.resolve(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &tenantServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// GetTenantFromContext extracts the tenant context added by the interceptors
+func GetTenantFromContext(ctx context.Context) (*tenant.Context, bool) {
+	return tenant.GetTenantFromContext(ctx)
+}
+
+// resolve looks up the tenant named in the incoming metadata, by ID if the
+// value is a UUID and by subdomain otherwise, and returns a context carrying it
+func (i *Interceptor) resolve(ctx context.Context, method string) (context.Context, error) {
+	if i.shouldSkipMethod(method) {
+		return ctx, nil
+	}
+
+	value := i.metadataValue(ctx)
+	if value == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "missing tenant metadata %q", i.config.MetadataKey)
+	}
+
+	var tenantID uuid.UUID
+	var err error
+	if id, parseErr := uuid.Parse(value); parseErr == nil {
+		tenantID, err = i.resolver.ResolveByID(ctx, id)
+	} else {
+		tenantID, err = i.resolver.ResolveBySubdomain(ctx, value)
+	}
+	if err != nil {
+		return nil, i.lookupError(method, value, err)
+	}
+
+	t, err := i.manager.GetTenant(ctx, tenantID)
+	if err != nil {
+		return nil, i.lookupError(method, value, err)
+	}
+
+	if !i.config.AllowInactive {
+		if err := statusError(t); err != nil {
+			return nil, err
+		}
+	}
+
+	i.logger.Debug("Resolved tenant",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("subdomain", t.Subdomain),
+		zap.String("method", method))
+
+	return i.manager.WithTenantContext(ctx, tenantID), nil
+}
+
+// metadataValue returns the first value of the tenant metadata key
+func (i *Interceptor) metadataValue(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	values := md.Get(i.config.MetadataKey)
+	if len(values) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(values[0])
+}
+
+// lookupError converts a resolution failure to a gRPC status: NotFound for
+// unknown tenants and Unavailable when the lookup itself failed
+func (i *Interceptor) lookupError(method, value string, err error) error {
+	if tenant.IsNotFound(err) {
+		i.logger.Debug("Failed to resolve tenant",
+			zap.String("method", method),
+			zap.String("tenant", value),
+			zap.Error(err))
+		return status.Error(codes.NotFound, "tenant not found")
+	}
+
+	i.logger.Error("Tenant lookup failed during resolution",
+		zap.String("method", method),
+		zap.String("tenant", value),
+		zap.Error(err))
+	return status.Error(codes.Unavailable, "tenant lookup is temporarily unavailable")
+}
+
+// shouldSkipMethod checks if a method should skip tenant resolution
+func (i *Interceptor) shouldSkipMethod(method string) bool {
+	for _, skipMethod := range i.config.SkipMethods {
+		if strings.HasPrefix(method, skipMethod) {
+			return true
+		}
+	}
+	return false
+}
+
+// statusError rejects tenants that are not active, mirroring the Gin
+// ValidateTenant middleware
+func statusError(t *tenant.Tenant) error {
+	switch t.Status {
+	case tenant.StatusActive:
+		return nil
+	case tenant.StatusSuspended:
+		return status.Error(codes.PermissionDenied, "account suspended")
+	case tenant.StatusPending:
+		return status.Error(codes.FailedPrecondition, "account pending verification")
+	case tenant.StatusCancelled:
+		return status.Error(codes.PermissionDenied, "account cancelled")
+	default:
+		return status.Error(codes.PermissionDenied, "account status invalid")
+	}
+}
+
+// tenantServerStream overrides the context of a server stream
+type tenantServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tenantServerStream) Context() context.Context {
+	return s.ctx
+}
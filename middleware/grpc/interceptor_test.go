@@ -0,0 +1,239 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestInterceptor_Unary(t *testing.T) {
+	repo := newInterceptorTestRepository()
+	acme := repo.add("acme", tenant.StatusActive)
+	repo.add("umbrella", tenant.StatusSuspended)
+
+	client, server := newInterceptorTestServer(t, repo, Config{})
+
+	tests := []struct {
+		name   string
+		tenant string
+		code   codes.Code
+	}{
+		{"by subdomain", "acme", codes.OK},
+		{"by ID", acme.ID.String(), codes.OK},
+		{"unknown subdomain", "globex", codes.NotFound},
+		{"unknown ID", uuid.New().String(), codes.NotFound},
+		{"suspended", "umbrella", codes.PermissionDenied},
+		{"missing", "", codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server.seen = nil
+
+			ctx := context.Background()
+			if tt.tenant != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, DefaultMetadataKey, tt.tenant)
+			}
+
+			_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("Check() code = %s, want %s (err = %v)", code, tt.code, err)
+			}
+			if tt.code != codes.OK {
+				if server.seen != nil {
+					t.Error("handler should not run for a rejected request")
+				}
+				return
+			}
+			if server.seen == nil || server.seen.TenantID != acme.ID || server.seen.SchemaName != acme.SchemaName {
+				t.Errorf("handler saw tenant %+v, want %s", server.seen, acme.ID)
+			}
+		})
+	}
+}
+
+func TestInterceptor_Stream(t *testing.T) {
+	repo := newInterceptorTestRepository()
+	acme := repo.add("acme", tenant.StatusActive)
+
+	client, server := newInterceptorTestServer(t, repo, Config{MetadataKey: "X-Tenant-Subdomain"})
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-tenant-subdomain", "acme")
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Recv() error = %v", err)
+	}
+	if server.seen == nil || server.seen.TenantID != acme.ID {
+		t.Errorf("stream handler saw tenant %+v, want %s", server.seen, acme.ID)
+	}
+
+	// The default key is not consulted when a custom key is configured
+	ctx = metadata.AppendToOutgoingContext(context.Background(), DefaultMetadataKey, "acme")
+	stream, err = client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Recv() error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestInterceptor_SkipMethodsAndLookupFailure(t *testing.T) {
+	repo := newInterceptorTestRepository()
+	repo.add("acme", tenant.StatusActive)
+	repo.err = errors.New("connection refused")
+
+	client, server := newInterceptorTestServer(t, repo, Config{SkipMethods: []string{"/grpc.health.v1.Health/Watch"}})
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), DefaultMetadataKey, "acme")
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); status.Code(err) != codes.Unavailable {
+		t.Errorf("Check() error = %v, want Unavailable when the lookup fails", err)
+	}
+
+	stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Errorf("Recv() error = %v, want skipped method to run without a tenant", err)
+	}
+	if server.seen != nil {
+		t.Errorf("skipped method saw tenant %+v, want none", server.seen)
+	}
+}
+
+// healthTestServer records the tenant each call sees in its context
+type healthTestServer struct {
+	healthpb.UnimplementedHealthServer
+	seen *tenant.Context
+}
+
+func (s *healthTestServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	s.seen, _ = GetTenantFromContext(ctx)
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func (s *healthTestServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	s.seen, _ = GetTenantFromContext(stream.Context())
+	return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+}
+
+// newInterceptorTestServer serves the health service through the interceptors
+// over an in-process listener
+func newInterceptorTestServer(t *testing.T, repo *interceptorTestRepository, config Config) (healthpb.HealthClient, *healthTestServer) {
+	logger := zaptest.NewLogger(t)
+	resolver := tenant.NewResolver(tenant.ResolverConfig{Strategy: tenant.ResolverHeader}, repo, logger)
+	interceptor := NewInterceptor(&interceptorTestManager{repo: repo}, resolver, logger, config)
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(interceptor.UnaryServerInterceptor()),
+		grpc.StreamInterceptor(interceptor.StreamServerInterceptor()),
+	)
+	health := &healthTestServer{}
+	healthpb.RegisterHealthServer(server, health)
+
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("grpc.NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return healthpb.NewHealthClient(conn), health
+}
+
+// interceptorTestRepository serves tenants from memory, failing every lookup
+// with err if set
+type interceptorTestRepository struct {
+	tenant.Repository
+	tenants map[uuid.UUID]*tenant.Tenant
+	err     error
+}
+
+func newInterceptorTestRepository() *interceptorTestRepository {
+	return &interceptorTestRepository{tenants: make(map[uuid.UUID]*tenant.Tenant)}
+}
+
+func (r *interceptorTestRepository) add(subdomain, status string) *tenant.Tenant {
+	id := uuid.New()
+	t := &tenant.Tenant{
+		ID:         id,
+		Subdomain:  subdomain,
+		Status:     status,
+		PlanType:   tenant.PlanBasic,
+		SchemaName: "tenant_" + strings.ReplaceAll(id.String(), "-", "_"),
+	}
+	r.tenants[id] = t
+	return t
+}
+
+func (r *interceptorTestRepository) GetByID(ctx context.Context, id uuid.UUID) (*tenant.Tenant, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if t, ok := r.tenants[id]; ok {
+		return t, nil
+	}
+	return nil, tenant.ErrTenantNotFound
+}
+
+func (r *interceptorTestRepository) GetBySubdomain(ctx context.Context, subdomain string) (*tenant.Tenant, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	for _, t := range r.tenants {
+		if t.Subdomain == subdomain {
+			return t, nil
+		}
+	}
+	return nil, tenant.ErrTenantNotFound
+}
+
+// interceptorTestManager looks tenants up in the test repository
+type interceptorTestManager struct {
+	tenant.Manager
+	repo *interceptorTestRepository
+}
+
+func (m *interceptorTestManager) GetTenant(ctx context.Context, id uuid.UUID) (*tenant.Tenant, error) {
+	return m.repo.GetByID(ctx, id)
+}
+
+func (m *interceptorTestManager) WithTenantContext(ctx context.Context, tenantID uuid.UUID) context.Context {
+	t, err := m.repo.GetByID(ctx, tenantID)
+	if err != nil {
+		return ctx
+	}
+	ctx = context.WithValue(ctx, tenant.ContextKeyTenant, &tenant.Context{
+		TenantID:   t.ID,
+		Subdomain:  t.Subdomain,
+		SchemaName: t.SchemaName,
+		PlanType:   t.PlanType,
+		Status:     t.Status,
+	})
+	return context.WithValue(ctx, tenant.ContextKeyTenantID, tenantID)
+}
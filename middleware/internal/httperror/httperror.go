@@ -0,0 +1,95 @@
+// Package httperror holds the error handling shared by the Gin and net/http
+// middleware, so that both answer the same errors with the same status codes
+package httperror
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+)
+
+// Status returns the HTTP status code for an error raised or passed on by the
+// middleware: 402 for exceeded limits, 400 for validation errors, the code's
+// status for TenantErrors and 500 for anything else
+func Status(err error) int {
+	var limitErr *tenant.LimitExceededError
+	if errors.As(err, &limitErr) {
+		return http.StatusPaymentRequired
+	}
+
+	var validationErr *tenant.ValidationError
+	if errors.As(err, &validationErr) {
+		return http.StatusBadRequest
+	}
+
+	var tenantErr *tenant.TenantError
+	if !errors.As(err, &tenantErr) {
+		return http.StatusInternalServerError
+	}
+
+	switch tenantErr.Code {
+	case "TENANT_NOT_FOUND":
+		return http.StatusNotFound
+	case "TENANT_LOOKUP_FAILED":
+		return http.StatusServiceUnavailable
+	case "TENANT_SUSPENDED", "TENANT_CANCELLED", "TENANT_PENDING", "ACCESS_DENIED", "ADMIN_REQUIRED":
+		return http.StatusForbidden
+	case "PLAN_LIMIT_EXCEEDED":
+		return http.StatusPaymentRequired
+	case "USER_NOT_AUTHENTICATED", "INVALID_TOKEN":
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// TenantStatus returns the error rejecting a tenant that is not active, or
+// nil for active tenants
+func TenantStatus(tenantCtx *tenant.Context) error {
+	var code, message string
+	switch tenantCtx.Status {
+	case tenant.StatusActive:
+		return nil
+	case tenant.StatusSuspended:
+		code, message = "TENANT_SUSPENDED", "Account suspended. Please contact support."
+	case tenant.StatusPending:
+		code, message = "TENANT_PENDING", "Account pending verification. Please check your email."
+	case tenant.StatusCancelled:
+		code, message = "TENANT_CANCELLED", "Account cancelled."
+	default:
+		code, message = "TENANT_INVALID_STATUS", "Account status invalid."
+	}
+	return &tenant.TenantError{TenantID: tenantCtx.TenantID, Code: code, Message: message}
+}
+
+// LookupFailed is the error reported when a tenant could not be looked up for
+// reasons other than it not existing, such as the database being down
+func LookupFailed(tenantID uuid.UUID) *tenant.TenantError {
+	return &tenant.TenantError{
+		TenantID: tenantID,
+		Code:     "TENANT_LOOKUP_FAILED",
+		Message:  "Tenant lookup is temporarily unavailable",
+	}
+}
+
+// InvalidToken is the error reported when the jwt strategy rejects the
+// request's bearer token
+func InvalidToken() *tenant.TenantError {
+	return &tenant.TenantError{
+		Code:    "INVALID_TOKEN",
+		Message: "Missing or invalid bearer token",
+	}
+}
+
+// SkipPath reports whether path starts with one of skipPaths
+func SkipPath(skipPaths []string, path string) bool {
+	for _, skipPath := range skipPaths {
+		if strings.HasPrefix(path, skipPath) {
+			return true
+		}
+	}
+	return false
+}
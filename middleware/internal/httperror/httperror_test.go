@@ -0,0 +1,47 @@
+package httperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+)
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "not found", err: &tenant.TenantError{Code: "TENANT_NOT_FOUND"}, want: http.StatusNotFound},
+		{name: "lookup failed", err: LookupFailed(uuid.Nil), want: http.StatusServiceUnavailable},
+		{name: "invalid token", err: InvalidToken(), want: http.StatusUnauthorized},
+		{name: "suspended", err: &tenant.TenantError{Code: "TENANT_SUSPENDED"}, want: http.StatusForbidden},
+		{name: "invalid status", err: &tenant.TenantError{Code: "TENANT_INVALID_STATUS"}, want: http.StatusInternalServerError},
+		{name: "validation", err: &tenant.ValidationError{Field: "name", Message: "name is required"}, want: http.StatusBadRequest},
+		{name: "wrapped limit", err: fmt.Errorf("check failed: %w", &tenant.LimitExceededError{Limit: "max_users"}), want: http.StatusPaymentRequired},
+		{name: "other", err: errors.New("boom"), want: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Status(tt.err); got != tt.want {
+				t.Errorf("Status() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTenantStatus(t *testing.T) {
+	if err := TenantStatus(&tenant.Context{Status: tenant.StatusActive}); err != nil {
+		t.Errorf("TenantStatus() of an active tenant = %v, want nil", err)
+	}
+
+	var tenantErr *tenant.TenantError
+	if err := TenantStatus(&tenant.Context{Status: tenant.StatusCancelled}); !errors.As(err, &tenantErr) || tenantErr.Code != "TENANT_CANCELLED" {
+		t.Errorf("TenantStatus() of a cancelled tenant = %v, want TENANT_CANCELLED", err)
+	}
+}
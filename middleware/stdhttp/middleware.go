@@ -0,0 +1,277 @@
+package stdhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/alexalmadav/go-multitenant/middleware/internal/httperror"
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Middleware provides net/http middleware for multi-tenant applications
+type Middleware struct {
+	manager  tenant.Manager
+	resolver tenant.Resolver
+	logger   *zap.Logger
+	config   Config
+}
+
+// Config contains configuration for the net/http middleware
+type Config struct {
+	// SkipPaths are paths that should skip tenant resolution
+	SkipPaths []string
+	// ErrorHandler writes the response when a middleware rejects a request.
+	// The default maps the error to a status code and writes it as JSON in the
+	// same envelope as the Gin middleware.
+	ErrorHandler func(http.ResponseWriter, *http.Request, error)
+}
+
+// NewMiddleware creates a new net/http middleware
+func NewMiddleware(manager tenant.Manager, resolver tenant.Resolver, logger *zap.Logger, config Config) *Middleware {
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = DefaultErrorHandler
+	}
+
+	return &Middleware{
+		manager:  manager,
+		resolver: resolver,
+		logger:   logger.Named("http_middleware"),
+		config:   config,
+	}
+}
+
+// ResolveTenant is middleware that resolves the tenant from the request and
+// adds it to the request context, where GetTenantFromContext finds it
+func (m *Middleware) ResolveTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.shouldSkipPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tenantID, err := m.resolver.ResolveTenant(r.Context(), r)
+		if err != nil {
+			if errors.Is(err, tenant.ErrInvalidToken) {
+				m.logger.Debug("Rejected tenant token",
+					zap.String("path", r.URL.Path),
+					zap.Error(err))
+
+				m.config.ErrorHandler(w, r, httperror.InvalidToken())
+				return
+			}
+			if !tenant.IsNotFound(err) {
+				m.logger.Error("Tenant lookup failed during resolution",
+					zap.String("path", r.URL.Path),
+					zap.String("host", r.Host),
+					zap.Error(err))
+
+				m.config.ErrorHandler(w, r, httperror.LookupFailed(uuid.Nil))
+				return
+			}
+
+			m.logger.Debug("Failed to resolve tenant",
+				zap.String("path", r.URL.Path),
+				zap.String("host", r.Host),
+				zap.Error(err))
+
+			m.config.ErrorHandler(w, r, &tenant.TenantError{
+				Code:    "TENANT_NOT_FOUND",
+				Message: "Unable to resolve tenant from request",
+			})
+			return
+		}
+
+		t, err := m.manager.GetTenant(r.Context(), tenantID)
+		if err != nil {
+			m.logger.Error("Failed to get tenant details",
+				zap.String("tenant_id", tenantID.String()),
+				zap.Error(err))
+
+			if !tenant.IsNotFound(err) {
+				m.config.ErrorHandler(w, r, httperror.LookupFailed(tenantID))
+				return
+			}
+
+			m.config.ErrorHandler(w, r, &tenant.TenantError{
+				TenantID: tenantID,
+				Code:     "TENANT_NOT_FOUND",
+				Message:  "Tenant not found",
+			})
+			return
+		}
+
+		m.logger.Debug("Resolved tenant",
+			zap.String("tenant_id", tenantID.String()),
+			zap.String("subdomain", t.Subdomain),
+			zap.String("path", r.URL.Path))
+
+		next.ServeHTTP(w, r.WithContext(m.manager.WithTenantContext(r.Context(), tenantID)))
+	})
+}
+
+// ValidateTenant is middleware that rejects requests for tenants that are not
+// active. It must run after ResolveTenant.
+func (m *Middleware) ValidateTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Skipped paths have no tenant to validate
+		if m.shouldSkipPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tenantCtx, exists := GetTenantFromContext(r.Context())
+		if !exists {
+			m.config.ErrorHandler(w, r, &tenant.TenantError{
+				Code:    "TENANT_CONTEXT_MISSING",
+				Message: "Tenant context not found - ensure ResolveTenant middleware is applied first",
+			})
+			return
+		}
+
+		if err := httperror.TenantStatus(tenantCtx); err != nil {
+			m.config.ErrorHandler(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// EnforceLimits is middleware that rejects requests from tenants over their
+// plan limits
+func (m *Middleware) EnforceLimits(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.shouldSkipPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tenantCtx, exists := GetTenantFromContext(r.Context())
+		if !exists {
+			m.config.ErrorHandler(w, r, &tenant.TenantError{
+				Code:    "TENANT_CONTEXT_MISSING",
+				Message: "Tenant context not found",
+			})
+			return
+		}
+
+		if _, err := m.manager.CheckLimits(r.Context(), tenantCtx.TenantID); err != nil {
+			m.logger.Error("Plan limits check failed",
+				zap.String("tenant_id", tenantCtx.TenantID.String()),
+				zap.Error(err))
+
+			var limitErr *tenant.LimitExceededError
+			if errors.As(err, &limitErr) {
+				m.config.ErrorHandler(w, r, limitErr)
+			} else {
+				m.config.ErrorHandler(w, r, &tenant.TenantError{
+					TenantID: tenantCtx.TenantID,
+					Code:     "LIMIT_CHECK_FAILED",
+					Message:  "Unable to verify plan limits",
+				})
+			}
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WithTenantConn is middleware that acquires a dedicated connection with the
+// tenant's search_path set and adds it to the request context, where
+// tenant.GetTenantConnFromContext finds it. The connection is returned to the
+// pool when the request completes. Requests without a resolved tenant pass
+// through without a connection.
+func (m *Middleware) WithTenantConn(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantCtx, exists := GetTenantFromContext(r.Context())
+		if !exists {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		conn, err := m.manager.GetTenantConn(r.Context(), tenantCtx.TenantID)
+		if err != nil {
+			m.logger.Error("Failed to get tenant database connection",
+				zap.String("tenant_id", tenantCtx.TenantID.String()),
+				zap.Error(err))
+
+			m.config.ErrorHandler(w, r, &tenant.TenantError{
+				TenantID: tenantCtx.TenantID,
+				Code:     "DATABASE_ERROR",
+				Message:  "Failed to access tenant database",
+			})
+			return
+		}
+		defer func() {
+			if err := conn.Close(); err != nil {
+				m.logger.Error("Failed to close tenant database connection",
+					zap.String("tenant_id", tenantCtx.TenantID.String()),
+					zap.Error(err))
+			}
+		}()
+
+		next.ServeHTTP(w, r.WithContext(tenant.WithTenantConn(r.Context(), conn)))
+	})
+}
+
+// Handler wraps next in ResolveTenant, ValidateTenant and EnforceLimits, in
+// that order
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return m.ResolveTenant(m.ValidateTenant(m.EnforceLimits(next)))
+}
+
+// GetTenantFromContext extracts the tenant context added by ResolveTenant
+func GetTenantFromContext(ctx context.Context) (*tenant.Context, bool) {
+	return tenant.GetTenantFromContext(ctx)
+}
+
+// DefaultErrorHandler writes err as
+// {"error": {"code": ..., "message": ...}, "tenant_id": ...}
+// with the status code for its error code
+func DefaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	statusCode, details, tenantID := errorResponse(err)
+
+	response := map[string]interface{}{"error": details}
+	if tenantID != uuid.Nil {
+		response["tenant_id"] = tenantID.String()
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// errorResponse maps an error to its status code, error object and tenant
+func errorResponse(err error) (int, map[string]interface{}, uuid.UUID) {
+	statusCode := httperror.Status(err)
+
+	var limitErr *tenant.LimitExceededError
+	var tenantErr *tenant.TenantError
+	var validationErr *tenant.ValidationError
+	switch {
+	case errors.As(err, &limitErr):
+		return statusCode, map[string]interface{}{
+			"code":          "PLAN_LIMIT_EXCEEDED",
+			"message":       limitErr.Message,
+			"limit":         limitErr.Limit,
+			"limit_value":   limitErr.Value,
+			"current_usage": limitErr.Current,
+		}, limitErr.TenantID
+	case errors.As(err, &tenantErr):
+		return statusCode, map[string]interface{}{"code": tenantErr.Code, "message": tenantErr.Message}, tenantErr.TenantID
+	case errors.As(err, &validationErr):
+		return statusCode, map[string]interface{}{"code": "VALIDATION_ERROR", "message": validationErr.Message, "field": validationErr.Field}, uuid.Nil
+	default:
+		return statusCode, map[string]interface{}{"code": "INTERNAL_ERROR", "message": "An internal error occurred"}, uuid.Nil
+	}
+}
+
+// shouldSkipPath checks if a path should skip tenant resolution
+func (m *Middleware) shouldSkipPath(path string) bool {
+	return httperror.SkipPath(m.config.SkipPaths, path)
+}
@@ -0,0 +1,247 @@
+package stdhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+// testManager serves tenants from a map and fails limit checks with limitErr
+type testManager struct {
+	tenant.Manager
+	tenants  map[uuid.UUID]*tenant.Tenant
+	getErr   error
+	limitErr error
+}
+
+func (m *testManager) GetTenant(ctx context.Context, id uuid.UUID) (*tenant.Tenant, error) {
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	t, ok := m.tenants[id]
+	if !ok {
+		return nil, tenant.ErrTenantNotFound
+	}
+	return t, nil
+}
+
+func (m *testManager) WithTenantContext(ctx context.Context, tenantID uuid.UUID) context.Context {
+	t := m.tenants[tenantID]
+	return context.WithValue(ctx, tenant.ContextKeyTenant, &tenant.Context{
+		TenantID:  t.ID,
+		Subdomain: t.Subdomain,
+		PlanType:  t.PlanType,
+		Status:    t.Status,
+	})
+}
+
+func (m *testManager) CheckLimits(ctx context.Context, tenantID uuid.UUID) (*tenant.Limits, error) {
+	if m.limitErr != nil {
+		return nil, m.limitErr
+	}
+	return &tenant.Limits{}, nil
+}
+
+// testResolver resolves the X-Tenant header as a tenant ID
+type testResolver struct {
+	tenant.Resolver
+	err error
+}
+
+func (r *testResolver) ResolveTenant(ctx context.Context, req *http.Request) (uuid.UUID, error) {
+	if r.err != nil {
+		return uuid.Nil, r.err
+	}
+	id, err := uuid.Parse(req.Header.Get("X-Tenant"))
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: no tenant header", tenant.ErrTenantNotFound)
+	}
+	return id, nil
+}
+
+func newTestTenants() (active, suspended *tenant.Tenant, manager *testManager) {
+	active = &tenant.Tenant{ID: uuid.New(), Subdomain: "acme", PlanType: tenant.PlanBasic, Status: tenant.StatusActive}
+	suspended = &tenant.Tenant{ID: uuid.New(), Subdomain: "globex", PlanType: tenant.PlanBasic, Status: tenant.StatusSuspended}
+	manager = &testManager{tenants: map[uuid.UUID]*tenant.Tenant{active.ID: active, suspended.ID: suspended}}
+	return active, suspended, manager
+}
+
+// serve sends a request for tenantID, if set, through handler
+func serve(handler http.Handler, path string, tenantID uuid.UUID) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if tenantID != uuid.Nil {
+		req.Header.Set("X-Tenant", tenantID.String())
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+// errorCode decodes the error code from a JSON error response
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response %q is not JSON: %v", w.Body.String(), err)
+	}
+	return body.Error.Code
+}
+
+func TestResolveTenant(t *testing.T) {
+	active, _, manager := newTestTenants()
+	resolver := &testResolver{}
+	mw := NewMiddleware(manager, resolver, zaptest.NewLogger(t), Config{SkipPaths: []string{"/health"}})
+
+	var resolved *tenant.Context
+	handler := mw.ResolveTenant(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resolved, _ = GetTenantFromContext(r.Context())
+	}))
+
+	if w := serve(handler, "/projects", active.ID); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if resolved == nil || resolved.TenantID != active.ID {
+		t.Errorf("tenant in context = %+v, want %s", resolved, active.ID)
+	}
+
+	// Skipped paths reach the handler without a tenant
+	resolved = nil
+	if w := serve(handler, "/health", uuid.Nil); w.Code != http.StatusOK || resolved != nil {
+		t.Errorf("skipped path = %d with tenant %+v, want %d without one", w.Code, resolved, http.StatusOK)
+	}
+
+	// Unknown tenants are a 404, failed lookups a 503
+	if w := serve(handler, "/projects", uuid.New()); w.Code != http.StatusNotFound || errorCode(t, w) != "TENANT_NOT_FOUND" {
+		t.Errorf("unknown tenant = %d %s, want 404 TENANT_NOT_FOUND", w.Code, w.Body.String())
+	}
+	resolver.err = errors.New("connection refused")
+	if w := serve(handler, "/projects", active.ID); w.Code != http.StatusServiceUnavailable || errorCode(t, w) != "TENANT_LOOKUP_FAILED" {
+		t.Errorf("failed lookup = %d %s, want 503 TENANT_LOOKUP_FAILED", w.Code, w.Body.String())
+	}
+
+	// Rejected bearer tokens are a 401
+	resolver.err = fmt.Errorf("%w: token has invalid claims: token is expired", tenant.ErrInvalidToken)
+	if w := serve(handler, "/projects", active.ID); w.Code != http.StatusUnauthorized || errorCode(t, w) != "INVALID_TOKEN" {
+		t.Errorf("invalid token = %d %s, want 401 INVALID_TOKEN", w.Code, w.Body.String())
+	}
+}
+
+func TestValidateTenant_Suspended(t *testing.T) {
+	active, suspended, manager := newTestTenants()
+	mw := NewMiddleware(manager, &testResolver{}, zaptest.NewLogger(t), Config{})
+
+	handler := mw.ResolveTenant(mw.ValidateTenant(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	if w := serve(handler, "/projects", active.ID); w.Code != http.StatusNoContent {
+		t.Errorf("active tenant status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+
+	w := serve(handler, "/projects", suspended.ID)
+	if w.Code != http.StatusForbidden || errorCode(t, w) != "TENANT_SUSPENDED" {
+		t.Errorf("suspended tenant = %d %s, want 403 TENANT_SUSPENDED", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), suspended.ID.String()) {
+		t.Errorf("body = %s, want the tenant ID", w.Body.String())
+	}
+
+	// The error handler can be replaced
+	custom := NewMiddleware(manager, &testResolver{}, zaptest.NewLogger(t), Config{
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusTeapot)
+		},
+	})
+	handler = custom.ResolveTenant(custom.ValidateTenant(http.NotFoundHandler()))
+	if w := serve(handler, "/projects", suspended.ID); w.Code != http.StatusTeapot {
+		t.Errorf("status = %d, want the custom error handler's %d", w.Code, http.StatusTeapot)
+	}
+}
+
+func TestHandler_SkipPaths(t *testing.T) {
+	_, _, manager := newTestTenants()
+	mw := NewMiddleware(manager, &testResolver{}, zaptest.NewLogger(t), Config{SkipPaths: []string{"/health"}})
+
+	handled := false
+	handler := mw.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handled = true
+	}))
+
+	// Skipped paths pass every stage without a tenant
+	if w := serve(handler, "/health", uuid.Nil); w.Code != http.StatusOK || !handled {
+		t.Errorf("skipped path = %d %s, want %d from the handler", w.Code, w.Body.String(), http.StatusOK)
+	}
+}
+
+func TestDefaultErrorHandler_StatusCodes(t *testing.T) {
+	tests := []struct {
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{err: &tenant.ValidationError{Field: "subdomain", Message: "subdomain is required"}, wantStatus: http.StatusBadRequest, wantCode: "VALIDATION_ERROR"},
+		{err: &tenant.TenantError{Code: "TENANT_INVALID_STATUS"}, wantStatus: http.StatusInternalServerError, wantCode: "TENANT_INVALID_STATUS"},
+		{err: &tenant.TenantError{Code: "ADMIN_REQUIRED"}, wantStatus: http.StatusForbidden, wantCode: "ADMIN_REQUIRED"},
+		{err: errors.New("boom"), wantStatus: http.StatusInternalServerError, wantCode: "INTERNAL_ERROR"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		DefaultErrorHandler(w, httptest.NewRequest(http.MethodGet, "/", nil), tt.err)
+		if w.Code != tt.wantStatus || errorCode(t, w) != tt.wantCode {
+			t.Errorf("DefaultErrorHandler(%v) = %d %s, want %d %s", tt.err, w.Code, w.Body.String(), tt.wantStatus, tt.wantCode)
+		}
+	}
+}
+
+func TestEnforceLimits(t *testing.T) {
+	active, _, manager := newTestTenants()
+	mw := NewMiddleware(manager, &testResolver{}, zaptest.NewLogger(t), Config{})
+
+	handled := false
+	handler := mw.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handled = true
+	}))
+
+	if w := serve(handler, "/projects", active.ID); w.Code != http.StatusOK || !handled {
+		t.Fatalf("status = %d, want %d within the limits", w.Code, http.StatusOK)
+	}
+
+	handled = false
+	manager.limitErr = fmt.Errorf("limit check failed for max_projects: %w", &tenant.LimitExceededError{
+		TenantID: active.ID,
+		Code:     "LIMIT_EXCEEDED",
+		Limit:    "max_projects",
+		Value:    10,
+		Current:  10,
+		Message:  "Limit exceeded for max_projects: current=10, limit=10",
+	})
+	w := serve(handler, "/projects", active.ID)
+	if handled {
+		t.Error("handler should not run when a limit is exceeded")
+	}
+	if w.Code != http.StatusPaymentRequired || errorCode(t, w) != "PLAN_LIMIT_EXCEEDED" {
+		t.Errorf("response = %d %s, want 402 PLAN_LIMIT_EXCEEDED", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"limit":"max_projects"`) {
+		t.Errorf("body = %s, want the limit named", w.Body.String())
+	}
+
+	manager.limitErr = errors.New("connection refused")
+	if w := serve(handler, "/projects", active.ID); errorCode(t, w) != "LIMIT_CHECK_FAILED" {
+		t.Errorf("body = %s, want LIMIT_CHECK_FAILED", w.Body.String())
+	}
+}
@@ -6,12 +6,14 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sync"
 
 	"github.com/alexalmadav/go-multitenant/database"
 	"github.com/alexalmadav/go-multitenant/database/postgres"
 	ginmiddleware "github.com/alexalmadav/go-multitenant/middleware/gin"
 	"github.com/alexalmadav/go-multitenant/tenant"
-	_ "github.com/lib/pq"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
@@ -19,13 +21,25 @@ import (
 type MultiTenant struct {
 	Manager       tenant.Manager
 	Resolver      tenant.Resolver
+	LimitChecker  tenant.LimitChecker
 	GinMiddleware *ginmiddleware.Middleware
 	db            *sql.DB
+	replicas      []*sql.DB
+	webhook       *tenant.WebhookPublisher // nil unless Config.Webhook.URL is set
+	repository    tenant.Repository
 	logger        *zap.Logger
+
+	resolversMu      sync.Mutex
+	resolverReleases map[tenant.Resolver]func() // Undo NewResolver's registrations
+	domainResolvers  *customDomainResolvers     // Custom domain caches of resolvers from NewResolver
 }
 
 // New creates a new MultiTenant instance with the provided configuration
 func New(config tenant.Config) (*MultiTenant, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
 	// Setup logger
 	logger, err := setupLogger(config.Logger)
 	if err != nil {
@@ -38,29 +52,106 @@ func New(config tenant.Config) (*MultiTenant, error) {
 		return nil, fmt.Errorf("failed to setup database: %w", err)
 	}
 
-	// Create repository
-	repository := postgres.NewRepository(db, logger)
+	// Setup read replicas
+	replicas, err := setupReplicas(config.Database)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to setup read replicas: %w", err)
+	}
+
+	// Make sure pooled mode cannot exhaust the server's connections
+	if err := database.CheckTenantPoolCapacity(context.Background(), db, config.Database); err != nil {
+		closeDatabases(db, replicas)
+		return nil, fmt.Errorf("failed to setup tenant pools: %w", err)
+	}
+
+	// Create repository with metadata support, which custom domains, plan
+	// templates and stored limit overrides rely on
+	repository := postgres.NewExtensibleRepository(db, logger)
+	if config.MetadataLimits != nil {
+		repository.SetMetadataLimits(*config.MetadataLimits)
+	}
 
 	// Create master tables
-	if err := repository.CreateMasterTables(context.Background()); err != nil {
+	if err := repository.CreateMasterTablesExtended(context.Background()); err != nil {
 		logger.Warn("Failed to create master tables - they may already exist", zap.Error(err))
 	}
 
-	// Create schema manager
-	schemaManager := database.NewSchemaManager(db, logger, config.Database.SchemaPrefix)
+	// Create schema manager, or the shared tables when tenants share them
+	var schemaManager tenant.SchemaManager
+	if config.Database.Isolation == tenant.IsolationShared {
+		sharedManager := database.NewSharedSchemaManager(db, logger, "")
+		if err := sharedManager.CreateTables(context.Background()); err != nil {
+			closeDatabases(db, replicas)
+			return nil, fmt.Errorf("failed to create shared tenant tables: %w", err)
+		}
+		schemaManager = sharedManager
+	} else {
+		schemaManager = database.NewSchemaManagerFromConfig(db, logger, config.Database)
+	}
 
 	// Create migration manager using PostgreSQL functions
 	// Note: Applications should specify their own migrations directory path
-	migrationMgr := database.NewMigrationManager(db, logger, config.Database.MigrationsDir)
+	migrationMgr := database.NewMigrationManagerWithRepository(db, logger, config.Database.MigrationsDir, repository)
+
+	// Create limit checker, optionally backed by persistent storage
+	var limitChecker tenant.LimitChecker
+	if config.Limits.PersistChanges {
+		limitStore := postgres.NewLimitStore(db, logger)
+		if err := limitStore.CreateTables(context.Background()); err != nil {
+			logger.Warn("Failed to create limit store tables - they may already exist", zap.Error(err))
+		}
+
+		limitChecker, err = tenant.NewPersistentLimitChecker(context.Background(), config.Limits, repository, limitStore, limitStore, logger)
+		if err != nil {
+			closeDatabases(db, replicas)
+			return nil, fmt.Errorf("failed to setup limit checker: %w", err)
+		}
+	} else {
+		limitChecker = tenant.NewLimitChecker(config.Limits, repository, logger)
+	}
 
-	// Create limit checker
-	limitChecker := tenant.NewLimitChecker(config.Limits, repository, logger)
+	// Track usage in the database so that checks compare against it
+	if config.Limits.TrackUsage {
+		usageTracker := postgres.NewUsageTracker(db, logger)
+		if err := usageTracker.CreateTables(context.Background()); err != nil {
+			logger.Warn("Failed to create usage table - it may already exist", zap.Error(err))
+		}
+		limitChecker.SetUsageTracker(usageTracker)
+	}
 
 	// Create tenant manager
 	manager := tenant.NewManager(config, db, repository, schemaManager, migrationMgr, limitChecker, logger)
 
+	// Keep a dedicated pool per hot tenant
+	if config.Database.MaxTenantPools > 0 {
+		manager.SetTenantPoolOpener(database.NewTenantPoolOpener(config.Database))
+	}
+
+	// Serve GetTenantReadConn from the replicas
+	if len(replicas) > 0 {
+		manager.SetReadReplicas(replicas...)
+	}
+
+	// Post lifecycle events to the webhook
+	var webhook *tenant.WebhookPublisher
+	if config.Webhook.URL != "" {
+		webhook, err = tenant.NewWebhookPublisher(config.Webhook, logger)
+		if err != nil {
+			closeDatabases(db, replicas)
+			return nil, fmt.Errorf("failed to setup webhook: %w", err)
+		}
+		manager.SetEventPublisher(webhook)
+	}
+
 	// Create resolver
 	resolver := tenant.NewResolver(config.Resolver, repository, logger)
+	if invalidator, ok := resolver.(tenant.SubdomainInvalidator); ok {
+		manager.AddSubdomainInvalidator(invalidator)
+	}
+	if invalidator, ok := resolver.(tenant.CustomDomainInvalidator); ok && config.Resolver.Strategy == tenant.ResolverCustomDomain {
+		repository.AddMetadataHook(tenant.CustomDomainInvalidationHook(invalidator))
+	}
 
 	// Create Gin middleware
 	ginConfig := ginmiddleware.Config{
@@ -72,8 +163,12 @@ func New(config tenant.Config) (*MultiTenant, error) {
 	return &MultiTenant{
 		Manager:       manager,
 		Resolver:      resolver,
+		LimitChecker:  limitChecker,
 		GinMiddleware: ginMw,
 		db:            db,
+		replicas:      replicas,
+		webhook:       webhook,
+		repository:    repository,
 		logger:        logger,
 	}, nil
 }
@@ -86,6 +181,19 @@ func (mt *MultiTenant) Close() error {
 		}
 	}
 
+	// Deliver events queued while the manager was still running
+	if mt.webhook != nil {
+		if err := mt.webhook.Close(); err != nil {
+			mt.logger.Error("Failed to close webhook publisher", zap.Error(err))
+		}
+	}
+
+	for _, replica := range mt.replicas {
+		if err := replica.Close(); err != nil {
+			mt.logger.Error("Failed to close read replica", zap.Error(err))
+		}
+	}
+
 	if mt.db != nil {
 		if err := mt.db.Close(); err != nil {
 			mt.logger.Error("Failed to close database", zap.Error(err))
@@ -96,6 +204,103 @@ func (mt *MultiTenant) Close() error {
 	return nil
 }
 
+// NewResolver creates an additional resolver that shares the instance's
+// repository, for route groups that identify tenants differently, such as an
+// admin UI resolving by header while the app resolves by subdomain. Resolvers
+// with a negative or custom domain cache are registered to have it
+// invalidated; call ReleaseResolver for resolvers that are not used for the
+// life of the instance.
+func (mt *MultiTenant) NewResolver(config tenant.ResolverConfig) (tenant.Resolver, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid resolver configuration: %w", err)
+	}
+	resolver := tenant.NewResolver(config, mt.repository, mt.logger)
+
+	var releases []func()
+	if invalidator, ok := resolver.(tenant.SubdomainInvalidator); ok && config.NegativeCacheTTL > 0 {
+		releases = append(releases, mt.Manager.AddSubdomainInvalidator(invalidator))
+	}
+	if invalidator, ok := resolver.(tenant.CustomDomainInvalidator); ok && config.Strategy == tenant.ResolverCustomDomain {
+		if extRepo, ok := mt.repository.(*postgres.ExtensibleRepository); ok {
+			releases = append(releases, mt.addCustomDomainResolver(extRepo, resolver, invalidator))
+		}
+	}
+	if len(releases) == 0 {
+		return resolver, nil
+	}
+
+	mt.resolversMu.Lock()
+	defer mt.resolversMu.Unlock()
+	if mt.resolverReleases == nil {
+		mt.resolverReleases = make(map[tenant.Resolver]func())
+	}
+	mt.resolverReleases[resolver] = func() {
+		for _, release := range releases {
+			release()
+		}
+	}
+	return resolver, nil
+}
+
+// ReleaseResolver unregisters the caches of a resolver created by NewResolver,
+// so that it can be garbage collected once it is no longer used. Releasing any
+// other resolver, or releasing one twice, does nothing.
+func (mt *MultiTenant) ReleaseResolver(resolver tenant.Resolver) {
+	mt.resolversMu.Lock()
+	release := mt.resolverReleases[resolver]
+	delete(mt.resolverReleases, resolver)
+	mt.resolversMu.Unlock()
+
+	if release != nil {
+		release()
+	}
+}
+
+// addCustomDomainResolver registers the custom domain cache of a resolver
+// from NewResolver. The repository gets a single hook for all of them, so
+// that resolvers can be released again.
+func (mt *MultiTenant) addCustomDomainResolver(repository *postgres.ExtensibleRepository, resolver tenant.Resolver, invalidator tenant.CustomDomainInvalidator) func() {
+	mt.resolversMu.Lock()
+	if mt.domainResolvers == nil {
+		mt.domainResolvers = &customDomainResolvers{invalidators: make(map[tenant.Resolver]tenant.CustomDomainInvalidator)}
+		repository.AddMetadataHook(tenant.CustomDomainInvalidationHook(mt.domainResolvers))
+	}
+	domainResolvers := mt.domainResolvers
+	mt.resolversMu.Unlock()
+
+	domainResolvers.mu.Lock()
+	domainResolvers.invalidators[resolver] = invalidator
+	domainResolvers.mu.Unlock()
+
+	return func() {
+		domainResolvers.mu.Lock()
+		delete(domainResolvers.invalidators, resolver)
+		domainResolvers.mu.Unlock()
+	}
+}
+
+// customDomainResolvers tells the registered resolvers' custom domain caches
+// about a changed custom domain
+type customDomainResolvers struct {
+	mu           sync.RWMutex
+	invalidators map[tenant.Resolver]tenant.CustomDomainInvalidator
+}
+
+func (r *customDomainResolvers) InvalidateCustomDomain(tenantID uuid.UUID, domain string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, invalidator := range r.invalidators {
+		invalidator.InvalidateCustomDomain(tenantID, domain)
+	}
+}
+
+// NewGinMiddleware creates Gin middleware that resolves tenants with resolver
+// and shares the instance's manager, for use on a route group alongside
+// GinMiddleware
+func (mt *MultiTenant) NewGinMiddleware(resolver tenant.Resolver, config ginmiddleware.Config) *ginmiddleware.Middleware {
+	return ginmiddleware.NewMiddleware(mt.Manager, resolver, mt.logger, config)
+}
+
 // GetDatabase returns the database connection
 func (mt *MultiTenant) GetDatabase() *sql.DB {
 	return mt.db
@@ -141,11 +346,27 @@ func setupLogger(config tenant.LoggerConfig) (*zap.Logger, error) {
 
 // setupDatabase creates a database connection based on configuration
 func setupDatabase(config tenant.DatabaseConfig) (*sql.DB, error) {
-	db, err := sql.Open(config.Driver, config.DSN)
+	dsn, err := database.ConnectionString(config)
 	if err != nil {
 		return nil, err
 	}
 
+	var db *sql.DB
+	if config.Isolation == tenant.IsolationShared && config.IsPostgres() {
+		// Tenants share the pool's connections, so reset the row-level
+		// security scope of each before it is reused
+		connector, err := pq.NewConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		db = sql.OpenDB(database.NewSessionResetConnector(connector))
+	} else {
+		db, err = sql.Open(config.Driver, dsn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Set connection pool settings
 	db.SetMaxOpenConns(config.MaxOpenConns)
 	db.SetMaxIdleConns(config.MaxIdleConns)
@@ -154,12 +375,40 @@ func setupDatabase(config tenant.DatabaseConfig) (*sql.DB, error) {
 
 	// Test the connection
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, err
 	}
 
 	return db, nil
 }
 
+// closeDatabases closes the databases New opened when a later step fails
+func closeDatabases(db *sql.DB, replicas []*sql.DB) {
+	for _, replica := range replicas {
+		replica.Close()
+	}
+	db.Close()
+}
+
+// setupReplicas connects to each of config.ReplicaDSNs with the primary's
+// pool and TLS settings
+func setupReplicas(config tenant.DatabaseConfig) ([]*sql.DB, error) {
+	replicas := make([]*sql.DB, 0, len(config.ReplicaDSNs))
+	for i, dsn := range config.ReplicaDSNs {
+		replicaConfig := config
+		replicaConfig.DSN = dsn
+		replica, err := setupDatabase(replicaConfig)
+		if err != nil {
+			for _, opened := range replicas {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("replica %d: %w", i, err)
+		}
+		replicas = append(replicas, replica)
+	}
+	return replicas, nil
+}
+
 // Helper functions for creating components
 
 // Re-export key types and functions for convenience
@@ -172,6 +421,23 @@ type (
 	Limits    = tenant.Limits
 	Stats     = tenant.Stats
 	Migration = tenant.Migration
+	Money     = tenant.Money
+
+	PlanTemplate   = tenant.PlanTemplate
+	UsageDrift     = tenant.UsageDrift
+	ImportRecord   = tenant.ImportRecord
+	ImportReport   = tenant.ImportReport
+	DeletionReport = tenant.DeletionReport
+
+	ProvisionResult = tenant.ProvisionResult
+
+	RegionRouter   = tenant.RegionRouter
+	RegionMap      = tenant.RegionMap
+	RegionDatabase = tenant.RegionDatabase
+
+	LimitChecker     = tenant.LimitChecker
+	LimitDefinition  = tenant.LimitDefinition
+	LimitDescription = tenant.LimitDescription
 )
 
 // Re-export key constants
@@ -185,9 +451,14 @@ const (
 	PlanPro        = tenant.PlanPro
 	PlanEnterprise = tenant.PlanEnterprise
 
-	ResolverSubdomain = tenant.ResolverSubdomain
-	ResolverPath      = tenant.ResolverPath
-	ResolverHeader    = tenant.ResolverHeader
+	ResolverSubdomain    = tenant.ResolverSubdomain
+	ResolverPath         = tenant.ResolverPath
+	ResolverHeader       = tenant.ResolverHeader
+	ResolverJWT          = tenant.ResolverJWT
+	ResolverCustomDomain = tenant.ResolverCustomDomain
+
+	IsolationSchema = tenant.IsolationSchema
+	IsolationShared = tenant.IsolationShared
 )
 
 // Re-export helper functions
@@ -195,4 +466,6 @@ var (
 	DefaultConfig          = tenant.DefaultConfig
 	GetTenantFromContext   = tenant.GetTenantFromContext
 	GetTenantIDFromContext = tenant.GetTenantIDFromContext
+	WithIdempotencyKey     = tenant.WithIdempotencyKey
+	WithForceProvision     = tenant.WithForceProvision
 )
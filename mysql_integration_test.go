@@ -0,0 +1,256 @@
+package multitenant
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	mysqldb "github.com/alexalmadav/go-multitenant/database/mysql"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/testcontainers/testcontainers-go"
+	tcmysql "github.com/testcontainers/testcontainers-go/modules/mysql"
+	"go.uber.org/zap/zaptest"
+)
+
+// MySQL integration tests mirroring the PostgreSQL isolation tests, with a
+// database per tenant instead of a schema. Set TEST_MYSQL_URL to use an
+// existing server, e.g. root:mysql@tcp(localhost:3306)/test_multitenant;
+// otherwise a container is started. Requirements: Docker must be running
+
+// setupMySQLContainer starts a MySQL container and returns its DSN
+func setupMySQLContainer(ctx context.Context) (container *tcmysql.MySQLContainer, dsn string, err error) {
+	// Recover from panics that testcontainers may throw when Docker is not available
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("docker not available: %v", r)
+			container = nil
+		}
+	}()
+
+	container, err = tcmysql.Run(ctx,
+		"mysql:8.0",
+		tcmysql.WithDatabase("test_multitenant"),
+		tcmysql.WithUsername("root"),
+		tcmysql.WithPassword("mysql"),
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to start mysql container: %w", err)
+	}
+
+	dsn, err = container.ConnectionString(ctx, "parseTime=true")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get connection string: %w", err)
+	}
+	return container, dsn, nil
+}
+
+// newMySQLTestDB connects to TEST_MYSQL_URL or a MySQL container, skipping
+// the test if neither is available
+func newMySQLTestDB(t *testing.T) *sql.DB {
+	if testing.Short() {
+		t.Skip("Skipping database integration test in short mode")
+	}
+
+	ctx := context.Background()
+	dsn := os.Getenv("TEST_MYSQL_URL")
+	if dsn == "" {
+		container, containerDSN, err := setupMySQLContainer(ctx)
+		if err != nil {
+			t.Skipf("Skipping integration test - no MySQL available. Set TEST_MYSQL_URL or ensure Docker is running: %v", err)
+		}
+		t.Cleanup(func() { testcontainers.TerminateContainer(container) })
+		dsn = containerDSN
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatalf("Failed to connect to MySQL: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// The server may still be starting after its log line
+	deadline := time.Now().Add(30 * time.Second)
+	for err = db.PingContext(ctx); err != nil && time.Now().Before(deadline); err = db.PingContext(ctx) {
+		time.Sleep(500 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Failed to ping MySQL: %v", err)
+	}
+	return db
+}
+
+// newMySQLTenants creates a database for each of count new tenants, dropped
+// when the test ends
+func newMySQLTenants(t *testing.T, sm *mysqldb.SchemaManager, count int) []uuid.UUID {
+	ctx := context.Background()
+	ids := make([]uuid.UUID, count)
+	for i := range ids {
+		ids[i] = uuid.New()
+		id := ids[i]
+		t.Cleanup(func() { sm.DropTenantSchema(context.Background(), id) })
+		if err := sm.CreateTenantSchema(ctx, id, fmt.Sprintf("Tenant %d", i+1)); err != nil {
+			t.Fatalf("CreateTenantSchema failed: %v", err)
+		}
+	}
+	return ids
+}
+
+func TestMySQL_SchemaCreation_TablesInTenantDatabase(t *testing.T) {
+	db := newMySQLTestDB(t)
+	sm := mysqldb.NewSchemaManager(db, zaptest.NewLogger(t), "tenant_")
+	ctx := context.Background()
+
+	tenantID := newMySQLTenants(t, sm, 1)[0]
+	databaseName := sm.GetSchemaName(tenantID)
+
+	if exists, err := sm.SchemaExists(ctx, tenantID); err != nil || !exists {
+		t.Fatalf("SchemaExists() = %v, %v, want true", exists, err)
+	}
+
+	var tables int
+	err := db.QueryRow(`SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name IN ('projects', 'tasks', 'documents', 'tenant_users')`, databaseName).Scan(&tables)
+	if err != nil || tables != 4 {
+		t.Errorf("tenant database has %d of the 4 tenant tables, %v", tables, err)
+	}
+
+	databases, err := sm.ListTenantSchemas(ctx)
+	if err != nil {
+		t.Fatalf("ListTenantSchemas failed: %v", err)
+	}
+	found := false
+	for _, name := range databases {
+		found = found || name == databaseName
+	}
+	if !found {
+		t.Errorf("ListTenantSchemas() = %v, want %s", databases, databaseName)
+	}
+
+	if err := sm.DropTenantSchema(ctx, tenantID); err != nil {
+		t.Fatalf("DropTenantSchema failed: %v", err)
+	}
+	if exists, err := sm.SchemaExists(ctx, tenantID); err != nil || exists {
+		t.Errorf("SchemaExists() after drop = %v, %v, want false", exists, err)
+	}
+}
+
+func TestMySQL_MultiTenant_DataIsolation(t *testing.T) {
+	db := newMySQLTestDB(t)
+	sm := mysqldb.NewSchemaManager(db, zaptest.NewLogger(t), "tenant_")
+	ctx := context.Background()
+
+	ids := newMySQLTenants(t, sm, 2)
+
+	// Each tenant writes through unqualified table names
+	for i, id := range ids {
+		err := sm.WithTenantTx(ctx, id, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "INSERT INTO projects (name) VALUES (?)", fmt.Sprintf("Tenant%d Secret Project", i+1))
+			return err
+		})
+		if err != nil {
+			t.Fatalf("WithTenantTx insert failed: %v", err)
+		}
+	}
+
+	// Each tenant sees only its own project
+	for i, id := range ids {
+		conn, err := sm.GetTenantConn(ctx, id)
+		if err != nil {
+			t.Fatalf("GetTenantConn failed: %v", err)
+		}
+		var count int
+		var name string
+		err = conn.QueryRowContext(ctx, "SELECT COUNT(*), MAX(name) FROM projects").Scan(&count, &name)
+		conn.Close()
+		if err != nil {
+			t.Fatalf("Failed to count projects: %v", err)
+		}
+		if want := fmt.Sprintf("Tenant%d Secret Project", i+1); count != 1 || name != want {
+			t.Errorf("DATA LEAKAGE: tenant %d sees %d projects (%s), want only %q", i+1, count, name, want)
+		}
+	}
+}
+
+func TestMySQL_WithTenantTx_Rollback(t *testing.T) {
+	db := newMySQLTestDB(t)
+	sm := mysqldb.NewSchemaManager(db, zaptest.NewLogger(t), "tenant_")
+	ctx := context.Background()
+
+	tenantID := newMySQLTenants(t, sm, 1)[0]
+
+	err := sm.WithTenantTx(ctx, tenantID, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO projects (name) VALUES (?)", "Rolled Back"); err != nil {
+			return err
+		}
+		return fmt.Errorf("abort")
+	})
+	if err == nil {
+		t.Fatal("WithTenantTx() should return the function's error")
+	}
+
+	var count int
+	if err := db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM `%s`.projects", sm.GetSchemaName(tenantID))).Scan(&count); err != nil {
+		t.Fatalf("Failed to count projects: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("rolled back transaction left %d projects", count)
+	}
+}
+
+func TestMySQL_GetTenantConn_ConcurrentIsolation(t *testing.T) {
+	db := newMySQLTestDB(t)
+	db.SetMaxOpenConns(4) // force connection reuse across tenants
+	sm := mysqldb.NewSchemaManager(db, zaptest.NewLogger(t), "tenant_")
+	ctx := context.Background()
+
+	ids := newMySQLTenants(t, sm, 3)
+
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(id uuid.UUID) {
+				defer wg.Done()
+				err := sm.WithTenantTx(ctx, id, func(tx *sql.Tx) error {
+					var current string
+					if err := tx.QueryRowContext(ctx, "SELECT DATABASE()").Scan(&current); err != nil {
+						return err
+					}
+					if current != sm.GetSchemaName(id) {
+						return fmt.Errorf("transaction for %s ran in %s", id, current)
+					}
+					_, err := tx.ExecContext(ctx, "INSERT INTO projects (name) VALUES (?)", id.String())
+					return err
+				})
+				if err != nil {
+					t.Errorf("WithTenantTx failed: %v", err)
+				}
+			}(id)
+		}
+	}
+	wg.Wait()
+
+	for _, id := range ids {
+		var foreign int
+		query := fmt.Sprintf("SELECT COUNT(*) FROM `%s`.projects WHERE name <> ?", sm.GetSchemaName(id))
+		if err := db.QueryRow(query, id.String()).Scan(&foreign); err != nil {
+			t.Fatalf("Failed to check cross-tenant rows: %v", err)
+		}
+		if foreign != 0 {
+			t.Errorf("DATA LEAKAGE: tenant %s has %d rows written for other tenants", id, foreign)
+		}
+	}
+
+	// Released connections go back to the pool with the original database
+	var current sql.NullString
+	if err := db.QueryRow("SELECT DATABASE()").Scan(&current); err != nil {
+		t.Fatalf("Failed to read current database: %v", err)
+	}
+	if current.String != "test_multitenant" && os.Getenv("TEST_MYSQL_URL") == "" {
+		t.Errorf("pooled connection uses %q after tenant work, want test_multitenant", current.String)
+	}
+}
@@ -0,0 +1,294 @@
+package multitenant
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	sqlitedb "github.com/alexalmadav/go-multitenant/database/sqlite"
+	"github.com/alexalmadav/go-multitenant/tenant"
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+	_ "modernc.org/sqlite"
+)
+
+// SQLite integration tests mirroring the PostgreSQL isolation tests, with a
+// database file per tenant instead of a schema. They need no server and run
+// in a temporary directory.
+
+// newSQLiteTestDB opens a main database in a temporary directory and returns
+// it with a schema manager keeping tenant databases next to it
+func newSQLiteTestDB(t *testing.T) (*sql.DB, *sqlitedb.SchemaManager) {
+	if testing.Short() {
+		t.Skip("Skipping database integration test in short mode")
+	}
+
+	dir := t.TempDir()
+	dsn := "file:" + filepath.Join(dir, "main.db") + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(10000)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open SQLite database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db, sqlitedb.NewSchemaManager(db, zaptest.NewLogger(t), dir, "tenant_")
+}
+
+// newSQLiteTenants creates a database for each of count new tenants
+func newSQLiteTenants(t *testing.T, sm *sqlitedb.SchemaManager, count int) []uuid.UUID {
+	ctx := context.Background()
+	ids := make([]uuid.UUID, count)
+	for i := range ids {
+		ids[i] = uuid.New()
+		if err := sm.CreateTenantSchema(ctx, ids[i], fmt.Sprintf("Tenant %d", i+1)); err != nil {
+			t.Fatalf("CreateTenantSchema failed: %v", err)
+		}
+	}
+	return ids
+}
+
+func TestSQLite_SchemaCreation_TablesInTenantDatabase(t *testing.T) {
+	db, sm := newSQLiteTestDB(t)
+	ctx := context.Background()
+
+	tenantID := newSQLiteTenants(t, sm, 1)[0]
+	databaseName := sm.GetSchemaName(tenantID)
+
+	if exists, err := sm.SchemaExists(ctx, tenantID); err != nil || !exists {
+		t.Fatalf("SchemaExists() = %v, %v, want true", exists, err)
+	}
+
+	conn, err := sm.GetTenantConn(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("GetTenantConn failed: %v", err)
+	}
+	var tables int
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM "%s".sqlite_master WHERE type = 'table' AND name IN ('projects', 'tasks', 'documents', 'tenant_users')`, databaseName)
+	err = conn.QueryRowContext(ctx, query).Scan(&tables)
+	conn.Close()
+	if err != nil || tables != 4 {
+		t.Errorf("tenant database has %d of the 4 tenant tables, %v", tables, err)
+	}
+
+	// Nothing is created in the main database
+	var leaked int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM main.sqlite_master WHERE name IN ('projects', 'tasks', 'documents', 'tenant_users')`).Scan(&leaked); err != nil || leaked != 0 {
+		t.Errorf("main database has %d tenant tables, %v", leaked, err)
+	}
+
+	databases, err := sm.ListTenantSchemas(ctx)
+	if err != nil {
+		t.Fatalf("ListTenantSchemas failed: %v", err)
+	}
+	if len(databases) != 1 || databases[0] != databaseName {
+		t.Errorf("ListTenantSchemas() = %v, want [%s]", databases, databaseName)
+	}
+
+	if err := sm.DropTenantSchema(ctx, tenantID); err != nil {
+		t.Fatalf("DropTenantSchema failed: %v", err)
+	}
+	if exists, err := sm.SchemaExists(ctx, tenantID); err != nil || exists {
+		t.Errorf("SchemaExists() after drop = %v, %v, want false", exists, err)
+	}
+	if _, err := sm.GetTenantConn(ctx, tenantID); err == nil {
+		t.Error("GetTenantConn() should fail for a dropped tenant database")
+	}
+}
+
+func TestSQLite_MultiTenant_DataIsolation(t *testing.T) {
+	_, sm := newSQLiteTestDB(t)
+	ctx := context.Background()
+
+	ids := newSQLiteTenants(t, sm, 2)
+
+	// Each tenant writes through unqualified table names
+	for i, id := range ids {
+		err := sm.WithTenantTx(ctx, id, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "INSERT INTO projects (name) VALUES (?)", fmt.Sprintf("Tenant%d Secret Project", i+1))
+			return err
+		})
+		if err != nil {
+			t.Fatalf("WithTenantTx insert failed: %v", err)
+		}
+	}
+
+	// Each tenant sees only its own project
+	for i, id := range ids {
+		conn, err := sm.GetTenantConn(ctx, id)
+		if err != nil {
+			t.Fatalf("GetTenantConn failed: %v", err)
+		}
+		var count int
+		var name string
+		err = conn.QueryRowContext(ctx, "SELECT COUNT(*), MAX(name) FROM projects").Scan(&count, &name)
+		conn.Close()
+		if err != nil {
+			t.Fatalf("Failed to count projects: %v", err)
+		}
+		if want := fmt.Sprintf("Tenant%d Secret Project", i+1); count != 1 || name != want {
+			t.Errorf("DATA LEAKAGE: tenant %d sees %d projects (%s), want only %q", i+1, count, name, want)
+		}
+	}
+}
+
+func TestSQLite_WithTenantTx_Rollback(t *testing.T) {
+	_, sm := newSQLiteTestDB(t)
+	ctx := context.Background()
+
+	tenantID := newSQLiteTenants(t, sm, 1)[0]
+
+	err := sm.WithTenantTx(ctx, tenantID, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO projects (name) VALUES (?)", "Rolled Back"); err != nil {
+			return err
+		}
+		return fmt.Errorf("abort")
+	})
+	if err == nil {
+		t.Fatal("WithTenantTx() should return the function's error")
+	}
+
+	conn, err := sm.GetTenantConn(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("GetTenantConn failed: %v", err)
+	}
+	defer conn.Close()
+
+	var count int
+	if err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM projects").Scan(&count); err != nil {
+		t.Fatalf("Failed to count projects: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("rolled back transaction left %d projects", count)
+	}
+}
+
+func TestSQLite_GetTenantConn_ConcurrentIsolation(t *testing.T) {
+	db, sm := newSQLiteTestDB(t)
+	db.SetMaxOpenConns(4) // force connection reuse across tenants
+	ctx := context.Background()
+
+	ids := newSQLiteTenants(t, sm, 3)
+
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(id uuid.UUID) {
+				defer wg.Done()
+				err := sm.WithTenantTx(ctx, id, func(tx *sql.Tx) error {
+					var attached int
+					if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM pragma_database_list WHERE name NOT IN ('main', 'temp')").Scan(&attached); err != nil {
+						return err
+					}
+					if attached != 1 {
+						return fmt.Errorf("transaction for %s has %d tenant databases attached", id, attached)
+					}
+					_, err := tx.ExecContext(ctx, "INSERT INTO projects (name) VALUES (?)", id.String())
+					return err
+				})
+				if err != nil {
+					t.Errorf("WithTenantTx failed: %v", err)
+				}
+			}(id)
+		}
+	}
+	wg.Wait()
+
+	for _, id := range ids {
+		conn, err := sm.GetTenantConn(ctx, id)
+		if err != nil {
+			t.Fatalf("GetTenantConn failed: %v", err)
+		}
+		var own, foreign int
+		err = conn.QueryRowContext(ctx, "SELECT COUNT(*) FILTER (WHERE name = ?), COUNT(*) FILTER (WHERE name <> ?) FROM projects", id.String(), id.String()).Scan(&own, &foreign)
+		conn.Close()
+		if err != nil {
+			t.Fatalf("Failed to check cross-tenant rows: %v", err)
+		}
+		if own != 10 || foreign != 0 {
+			t.Errorf("DATA LEAKAGE: tenant %s has %d of its 10 rows and %d rows written for other tenants", id, own, foreign)
+		}
+	}
+
+	// Released connections go back to the pool with no tenant attached
+	var attached int
+	if err := db.QueryRow("SELECT COUNT(*) FROM pragma_database_list WHERE name NOT IN ('main', 'temp')").Scan(&attached); err != nil {
+		t.Fatalf("Failed to list attached databases: %v", err)
+	}
+	if attached != 0 {
+		t.Errorf("pooled connection has %d tenant databases attached after tenant work", attached)
+	}
+}
+
+func TestSQLite_Repository_Lifecycle(t *testing.T) {
+	db, sm := newSQLiteTestDB(t)
+	repo := sqlitedb.NewRepository(db, sm, zaptest.NewLogger(t))
+	ctx := context.Background()
+
+	if err := repo.CreateMasterTables(ctx); err != nil {
+		t.Fatalf("CreateMasterTables failed: %v", err)
+	}
+
+	tenantID := newSQLiteTenants(t, sm, 1)[0]
+	tnt := &tenant.Tenant{
+		ID:         tenantID,
+		Name:       "Acme Corp",
+		Subdomain:  "acme",
+		PlanType:   tenant.PlanBasic,
+		Status:     tenant.StatusActive,
+		SchemaName: sm.GetSchemaName(tenantID),
+	}
+	if err := repo.Create(ctx, tnt); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := repo.Create(ctx, &tenant.Tenant{ID: uuid.New(), Name: "Copy", Subdomain: "acme", PlanType: tenant.PlanBasic, Status: tenant.StatusActive}); err == nil {
+		t.Error("Create() should reject a duplicate subdomain")
+	}
+
+	got, err := repo.GetBySubdomain(ctx, "acme")
+	if err != nil {
+		t.Fatalf("GetBySubdomain failed: %v", err)
+	}
+	if got.ID != tenantID || got.Name != "Acme Corp" || got.SchemaName != tnt.SchemaName || got.CreatedAt.IsZero() {
+		t.Errorf("GetBySubdomain() = %+v, want the created tenant", got)
+	}
+
+	got.PlanType = tenant.PlanPro
+	if err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got, err := repo.GetByID(ctx, tenantID); err != nil || got.PlanType != tenant.PlanPro {
+		t.Errorf("GetByID() after update = %+v, %v, want plan %s", got, err, tenant.PlanPro)
+	}
+
+	err = sm.WithTenantTx(ctx, tenantID, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "INSERT INTO projects (name) VALUES (?), (?)", "One", "Two")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithTenantTx insert failed: %v", err)
+	}
+	stats, err := repo.GetStats(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if !stats.SchemaExists || stats.ProjectCount != 2 {
+		t.Errorf("GetStats() = %+v, want 2 projects in an existing database", stats)
+	}
+
+	if tenants, total, err := repo.List(ctx, 1, 10); err != nil || total != 1 || len(tenants) != 1 {
+		t.Errorf("List() = %d tenants of %d, %v, want 1", len(tenants), total, err)
+	}
+	if err := repo.Delete(ctx, tenantID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, total, err := repo.List(ctx, 1, 10); err != nil || total != 0 {
+		t.Errorf("List() after delete has %d tenants, %v, want 0", total, err)
+	}
+	if _, err := repo.GetByID(ctx, uuid.New()); !tenant.IsNotFound(err) {
+		t.Errorf("GetByID() for an unknown tenant error = %v, want not found", err)
+	}
+}
@@ -0,0 +1,163 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// BatchRepository is implemented by repositories that can insert several
+// tenants in one transaction, such as postgres.Repository. CreateBatch must
+// insert all of them or none. BulkCreateTenants requires it.
+type BatchRepository interface {
+	CreateBatch(ctx context.Context, tenants []*Tenant) error
+}
+
+// ExtendedBatchRepository is implemented by repositories that can insert
+// several tenants with metadata in one transaction, such as
+// postgres.ExtensibleRepository. CreateExtendedBatch must insert all of them
+// or none. BulkCreateTenants uses it to store plan template metadata.
+type ExtendedBatchRepository interface {
+	CreateExtendedBatch(ctx context.Context, tenants []*ExtensibleTenant) error
+}
+
+// ErrBulkCreateUnsupported is returned by BulkCreateTenants when the
+// repository is not a BatchRepository
+var ErrBulkCreateUnsupported = errors.New("repository does not support creating tenants in a batch")
+
+// BulkCreateTenants creates tenants in one transaction, e.g. for an import
+// from a CRM, and returns their IDs in order. Every tenant is validated and
+// its subdomain checked first; if any is invalid, repeats another's subdomain
+// or ID, or fails to insert, no tenant is created and the tenants passed in
+// are left unchanged; otherwise they are filled in with the stored records.
+// The tenants are created pending and are not provisioned or queued: call
+// ProvisionTenant for each. Plan template metadata is stored as CreateTenant
+// stores it when the repository is an ExtendedBatchRepository; idempotency
+// keys are not applied.
+func (m *manager) BulkCreateTenants(ctx context.Context, tenants []*Tenant) ([]uuid.UUID, error) {
+	batch, ok := m.repository.(BatchRepository)
+	if !ok {
+		return nil, ErrBulkCreateUnsupported
+	}
+	if len(tenants) == 0 {
+		return []uuid.UUID{}, nil
+	}
+
+	subdomains := make(map[string]int, len(tenants))
+	ids := make(map[uuid.UUID]int, len(tenants))
+	for i, tenant := range tenants {
+		if err := m.validateTenant(tenant); err != nil {
+			return nil, fmt.Errorf("tenant %d: validation failed: %w", i, err)
+		}
+		if first, seen := subdomains[tenant.Subdomain]; seen {
+			return nil, fmt.Errorf("tenant %d: validation failed: %w", i,
+				&ValidationError{Field: "subdomain", Message: fmt.Sprintf("subdomain %q is also used by tenant %d", tenant.Subdomain, first)})
+		}
+		subdomains[tenant.Subdomain] = i
+
+		if tenant.ID == uuid.Nil {
+			continue
+		}
+		if first, seen := ids[tenant.ID]; seen {
+			return nil, fmt.Errorf("tenant %d: validation failed: %w", i,
+				&ValidationError{Field: "id", Message: fmt.Sprintf("ID %s is also used by tenant %d", tenant.ID, first)})
+		}
+		ids[tenant.ID] = i
+	}
+
+	// Report taken subdomains as validation errors rather than constraint violations
+	for i, tenant := range tenants {
+		taken, err := m.subdomainExists(ctx, tenant.Subdomain)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, fmt.Errorf("tenant %d: validation failed: %w", i,
+				&ValidationError{Field: "subdomain", Message: "subdomain is already taken"})
+		}
+	}
+
+	if err := m.checkTenantQuota(ctx, len(tenants)); err != nil {
+		return nil, err
+	}
+
+	// Work on copies so that a failed batch leaves the caller's tenants as they were
+	pending := make([]*Tenant, len(tenants))
+	for i, tenant := range tenants {
+		copied := *tenant
+		if copied.ID == uuid.Nil {
+			copied.ID = uuid.New()
+		}
+		copied.SchemaName = m.schemaManager.GetSchemaName(copied.ID)
+		copied.Status = StatusPending
+		if copied.PlanType == "" {
+			copied.PlanType = PlanBasic
+		}
+		pending[i] = &copied
+	}
+
+	err := m.createTenantBatch(ctx, batch, pending)
+	for _, tenant := range pending {
+		m.invalidateSubdomain(tenant.Subdomain)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tenants: %w", err)
+	}
+
+	metrics := m.metricsCollector()
+	created := make([]uuid.UUID, len(pending))
+	for i, tenant := range pending {
+		*tenants[i] = *tenant
+		created[i] = tenant.ID
+		metrics.TenantCreated(tenant.PlanType)
+		m.publishEvent(ctx, EventTenantCreated, tenant, nil)
+	}
+
+	m.logger.Info("Created tenants in bulk", zap.Int("count", len(created)))
+	return created, nil
+}
+
+// createTenantBatch stores new tenants in one transaction. If any tenant's plan
+// has a template and the repository supports it, the template defaults are
+// stored too, as createTenantRecord does for a single tenant.
+func (m *manager) createTenantBatch(ctx context.Context, batch BatchRepository, tenants []*Tenant) error {
+	extBatch, isExtensible := m.repository.(ExtendedBatchRepository)
+	if !isExtensible || !m.hasPlanTemplate(tenants) {
+		return batch.CreateBatch(ctx, tenants)
+	}
+
+	extTenants := make([]*ExtensibleTenant, len(tenants))
+	for i, tenant := range tenants {
+		extTenants[i] = &ExtensibleTenant{
+			ID:         tenant.ID,
+			Name:       tenant.Name,
+			Subdomain:  tenant.Subdomain,
+			PlanType:   tenant.PlanType,
+			Status:     tenant.Status,
+			SchemaName: tenant.SchemaName,
+			Metadata:   m.config.PlanTemplates[tenant.PlanType].Apply(nil),
+		}
+	}
+	if err := extBatch.CreateExtendedBatch(ctx, extTenants); err != nil {
+		return err
+	}
+
+	for i, tenant := range tenants {
+		tenant.CreatedAt = extTenants[i].CreatedAt
+		tenant.UpdatedAt = extTenants[i].UpdatedAt
+	}
+	return nil
+}
+
+// hasPlanTemplate reports whether any of the tenants' plans has a template
+func (m *manager) hasPlanTemplate(tenants []*Tenant) bool {
+	for _, tenant := range tenants {
+		if _, ok := m.config.PlanTemplates[tenant.PlanType]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,269 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// batchRepository inserts batches all or nothing, failing the insert of
+// failSubdomain as a constraint violation would
+type batchRepository struct {
+	*MockManagerRepository
+	failSubdomain string
+}
+
+func (r *batchRepository) CreateBatch(ctx context.Context, tenants []*Tenant) error {
+	staged := &MockManagerRepository{tenants: make(map[uuid.UUID]*Tenant, len(r.tenants)+len(tenants))}
+	for id, t := range r.tenants {
+		staged.tenants[id] = t
+	}
+
+	for _, t := range tenants {
+		if t.Subdomain == r.failSubdomain {
+			return fmt.Errorf("duplicate key value violates unique constraint on subdomain %s", t.Subdomain)
+		}
+		if err := staged.Create(ctx, t); err != nil {
+			return err
+		}
+	}
+
+	r.tenants = staged.tenants
+	return nil
+}
+
+// extendedBatchRepository also inserts batches with metadata, recording the
+// metadata stored for each tenant
+type extendedBatchRepository struct {
+	*batchRepository
+	metadata map[uuid.UUID]TenantMetadata
+}
+
+func (r *extendedBatchRepository) CreateExtendedBatch(ctx context.Context, tenants []*ExtensibleTenant) error {
+	base := make([]*Tenant, len(tenants))
+	for i, t := range tenants {
+		base[i] = &Tenant{ID: t.ID, Name: t.Name, Subdomain: t.Subdomain, PlanType: t.PlanType, Status: t.Status, SchemaName: t.SchemaName}
+	}
+	if err := r.CreateBatch(ctx, base); err != nil {
+		return err
+	}
+	for _, t := range tenants {
+		r.metadata[t.ID] = t.Metadata
+	}
+	return nil
+}
+
+func bulkTenants(subdomains ...string) []*Tenant {
+	tenants := make([]*Tenant, len(subdomains))
+	for i, subdomain := range subdomains {
+		tenants[i] = &Tenant{Name: "Tenant " + subdomain, Subdomain: subdomain}
+	}
+	return tenants
+}
+
+func TestManager_BulkCreateTenants(t *testing.T) {
+	repo := &batchRepository{MockManagerRepository: NewMockRepository()}
+	m := newTestManager(t, withRepository(repo))
+
+	var events []Event
+	m.SetEventPublisher(EventPublisherFunc(func(ctx context.Context, event Event) error {
+		events = append(events, event)
+		return nil
+	}))
+
+	tenants := bulkTenants("acme", "globex", "initech")
+	ids, err := m.BulkCreateTenants(context.Background(), tenants)
+	if err != nil {
+		t.Fatalf("BulkCreateTenants() error = %v", err)
+	}
+	if len(events) != len(tenants) {
+		t.Fatalf("published %d events, want one per tenant", len(events))
+	}
+	for i, event := range events {
+		if event.Type != EventTenantCreated || event.TenantID != ids[i] {
+			t.Errorf("event %d = %+v, want %s for tenant %s", i, event, EventTenantCreated, ids[i])
+		}
+	}
+
+	if len(ids) != len(tenants) {
+		t.Fatalf("BulkCreateTenants() returned %d IDs, want %d", len(ids), len(tenants))
+	}
+	for i, id := range ids {
+		created, ok := repo.tenants[id]
+		if !ok || created.Subdomain != tenants[i].Subdomain {
+			t.Fatalf("ID %d = %s does not belong to tenant %s", i, id, tenants[i].Subdomain)
+		}
+		if created.Status != StatusPending || created.PlanType != PlanBasic || created.SchemaName == "" {
+			t.Errorf("tenant %s = %+v, want a pending basic tenant with a schema name", created.Subdomain, created)
+		}
+	}
+
+	// Provisioning stays a separate step
+	schemas := m.schemaManager.(*MockManagerSchemaManager)
+	if len(schemas.schemas) != 0 {
+		t.Errorf("BulkCreateTenants() provisioned %d schemas, want none", len(schemas.schemas))
+	}
+}
+
+func TestManager_BulkCreateTenants_DuplicateSubdomainRollsBack(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string
+		batch    []string
+	}{
+		{name: "taken by an existing tenant", existing: []string{"acme"}, batch: []string{"globex", "acme"}},
+		{name: "repeated within the batch", batch: []string{"acme", "globex", "acme"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &batchRepository{MockManagerRepository: NewMockRepository()}
+			for _, existing := range bulkTenants(tt.existing...) {
+				existing.ID = uuid.New()
+				repo.Create(context.Background(), existing)
+			}
+			m := newTestManager(t, withRepository(repo))
+
+			_, err := m.BulkCreateTenants(context.Background(), bulkTenants(tt.batch...))
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Field != "subdomain" {
+				t.Fatalf("BulkCreateTenants() error = %v, want a subdomain ValidationError", err)
+			}
+			if len(repo.tenants) != len(tt.existing) {
+				t.Errorf("repository has %d tenants after a failed batch, want %d", len(repo.tenants), len(tt.existing))
+			}
+		})
+	}
+}
+
+func TestManager_BulkCreateTenants_InsertFailureRollsBack(t *testing.T) {
+	// The subdomain is taken between the check and the insert
+	repo := &batchRepository{MockManagerRepository: NewMockRepository(), failSubdomain: "globex"}
+	m := newTestManager(t, withRepository(repo))
+
+	if _, err := m.BulkCreateTenants(context.Background(), bulkTenants("acme", "globex", "initech")); err == nil {
+		t.Fatal("BulkCreateTenants() should fail when an insert fails")
+	}
+	if len(repo.tenants) != 0 {
+		t.Errorf("repository has %d tenants after a failed batch, want none", len(repo.tenants))
+	}
+}
+
+func TestManager_BulkCreateTenants_FailureLeavesTenantsUnchanged(t *testing.T) {
+	repo := &batchRepository{MockManagerRepository: NewMockRepository(), failSubdomain: "globex"}
+	m := newTestManager(t, withRepository(repo))
+
+	tenants := bulkTenants("acme", "globex")
+	if _, err := m.BulkCreateTenants(context.Background(), tenants); err == nil {
+		t.Fatal("BulkCreateTenants() should fail when an insert fails")
+	}
+	for _, tenant := range tenants {
+		if tenant.ID != uuid.Nil || tenant.SchemaName != "" || tenant.Status != "" || tenant.PlanType != "" {
+			t.Errorf("tenant %s = %+v after a failed batch, want it unchanged", tenant.Subdomain, tenant)
+		}
+	}
+
+	// A successful retry fills them in
+	repo.failSubdomain = ""
+	ids, err := m.BulkCreateTenants(context.Background(), tenants)
+	if err != nil {
+		t.Fatalf("BulkCreateTenants() retry error = %v", err)
+	}
+	for i, tenant := range tenants {
+		if tenant.ID != ids[i] || tenant.Status != StatusPending || tenant.SchemaName == "" {
+			t.Errorf("tenant %s = %+v, want it filled in with the stored record", tenant.Subdomain, tenant)
+		}
+	}
+}
+
+func TestManager_BulkCreateTenants_Quota(t *testing.T) {
+	repo := &batchRepository{MockManagerRepository: NewMockRepository()}
+	m := newTestManager(t, withRepository(repo))
+	m.config.MaxTenants = 2
+
+	existing := bulkTenants("acme")[0]
+	existing.ID = uuid.New()
+	repo.Create(context.Background(), existing)
+
+	_, err := m.BulkCreateTenants(context.Background(), bulkTenants("globex", "initech"))
+	var quotaErr *TenantQuotaExceededError
+	if !errors.As(err, &quotaErr) || quotaErr.MaxTenants != 2 || quotaErr.Current != 1 {
+		t.Fatalf("BulkCreateTenants() error = %v, want a TenantQuotaExceededError for 1 of 2 tenants", err)
+	}
+	if len(repo.tenants) != 1 {
+		t.Errorf("repository has %d tenants after exceeding the quota, want 1", len(repo.tenants))
+	}
+
+	if _, err := m.BulkCreateTenants(context.Background(), bulkTenants("globex")); err != nil {
+		t.Errorf("BulkCreateTenants() within the quota error = %v", err)
+	}
+}
+
+func TestManager_BulkCreateTenants_PlanTemplates(t *testing.T) {
+	repo := &extendedBatchRepository{
+		batchRepository: &batchRepository{MockManagerRepository: NewMockRepository()},
+		metadata:        make(map[uuid.UUID]TenantMetadata),
+	}
+	m := newTestManager(t, withRepository(repo))
+	m.config.PlanTemplates = map[string]PlanTemplate{PlanEnterprise: {Metadata: TenantMetadata{"enterprise_features": true}}}
+
+	tenants := bulkTenants("acme", "globex")
+	tenants[1].PlanType = PlanEnterprise
+	ids, err := m.BulkCreateTenants(context.Background(), tenants)
+	if err != nil {
+		t.Fatalf("BulkCreateTenants() error = %v", err)
+	}
+	if got := repo.metadata[ids[1]]["enterprise_features"]; got != true {
+		t.Errorf("enterprise tenant metadata = %v, want the plan template applied", repo.metadata[ids[1]])
+	}
+	if len(repo.metadata[ids[0]]) != 0 {
+		t.Errorf("basic tenant metadata = %v, want none", repo.metadata[ids[0]])
+	}
+
+	// Batches without templated plans are inserted without metadata
+	created, err := m.BulkCreateTenants(context.Background(), bulkTenants("initech"))
+	if err != nil {
+		t.Fatalf("BulkCreateTenants() error = %v", err)
+	}
+	if _, ok := repo.metadata[created[0]]; ok {
+		t.Error("a batch without templated plans should not go through CreateExtendedBatch")
+	}
+}
+
+func TestManager_BulkCreateTenants_Validation(t *testing.T) {
+	repo := &batchRepository{MockManagerRepository: NewMockRepository()}
+	m := newTestManager(t, withRepository(repo))
+
+	tenants := bulkTenants("acme", "globex")
+	tenants[1].Name = ""
+	if _, err := m.BulkCreateTenants(context.Background(), tenants); err == nil {
+		t.Error("BulkCreateTenants() should reject an invalid tenant")
+	}
+
+	tenants = bulkTenants("acme", "globex")
+	tenants[1].ID = uuid.New()
+	tenants[0].ID = tenants[1].ID
+	if _, err := m.BulkCreateTenants(context.Background(), tenants); err == nil {
+		t.Error("BulkCreateTenants() should reject a repeated ID")
+	}
+
+	if len(repo.tenants) != 0 {
+		t.Errorf("repository has %d tenants after failed batches, want none", len(repo.tenants))
+	}
+
+	ids, err := m.BulkCreateTenants(context.Background(), nil)
+	if err != nil || len(ids) != 0 {
+		t.Errorf("BulkCreateTenants(nil) = %v, %v, want no IDs", ids, err)
+	}
+}
+
+func TestManager_BulkCreateTenants_Unsupported(t *testing.T) {
+	m := newTestManager(t)
+
+	if _, err := m.BulkCreateTenants(context.Background(), bulkTenants("acme")); !errors.Is(err, ErrBulkCreateUnsupported) {
+		t.Errorf("BulkCreateTenants() error = %v, want ErrBulkCreateUnsupported", err)
+	}
+}
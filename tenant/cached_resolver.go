@@ -0,0 +1,129 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultResolverCacheSize is how many subdomains a cached resolver remembers
+// when NewCachedResolver is given no maximum
+const DefaultResolverCacheSize = 10000
+
+// subdomainExtractor is implemented by resolvers that can say which subdomain
+// a request names without looking it up, as NewResolver's resolvers do for
+// the subdomain, path and header strategies
+type subdomainExtractor interface {
+	extractSubdomain(req *http.Request) (subdomain string, ok bool, err error)
+}
+
+// cachedResolver implements Resolver by caching inner's subdomain lookups
+type cachedResolver struct {
+	inner Resolver
+	cache *lookupCache
+}
+
+// NewCachedResolver wraps inner with a concurrency-safe cache of subdomain to
+// tenant ID resolutions, so that repeat requests for a tenant skip the
+// repository. Entries expire after ttl and at most maxEntries are kept, or
+// DefaultResolverCacheSize if maxEntries is not positive. Failed lookups are
+// not cached; use ResolverConfig.NegativeCacheTTL for those. A ttl that is not
+// positive disables the cache and returns inner.
+//
+// Register the result with Manager.AddSubdomainInvalidator so that creating,
+// updating, suspending, activating, restoring and deleting a tenant drops its
+// entry; a subdomain a tenant gives up keeps resolving to it until the entry
+// expires. Requests that inner does not resolve by subdomain, such as with the
+// jwt strategy or a resolver from another package, are passed through
+// uncached.
+func NewCachedResolver(inner Resolver, ttl time.Duration, maxEntries int) Resolver {
+	if ttl <= 0 {
+		return inner
+	}
+	if maxEntries <= 0 {
+		maxEntries = DefaultResolverCacheSize
+	}
+	return &cachedResolver{
+		inner: inner,
+		cache: newLookupCache(ttl, maxEntries),
+	}
+}
+
+// ResolveTenant resolves the request's subdomain from the cache, asking inner
+// on a miss
+func (c *cachedResolver) ResolveTenant(ctx context.Context, req *http.Request) (uuid.UUID, error) {
+	extractor, ok := c.inner.(subdomainExtractor)
+	if !ok {
+		return c.inner.ResolveTenant(ctx, req)
+	}
+
+	subdomain, ok, err := extractor.extractSubdomain(req)
+	if !ok {
+		return c.inner.ResolveTenant(ctx, req)
+	}
+	if err != nil {
+		return uuid.UUID{}, fmt.Errorf("%w: %w", ErrTenantNotFound, err)
+	}
+
+	return c.ResolveBySubdomain(ctx, subdomain)
+}
+
+// ResolveBySubdomain resolves subdomain from the cache, asking inner on a miss
+func (c *cachedResolver) ResolveBySubdomain(ctx context.Context, subdomain string) (uuid.UUID, error) {
+	if tenantID, ok := c.cache.get(subdomain); ok {
+		return tenantID, nil
+	}
+
+	tenantID, err := c.inner.ResolveBySubdomain(ctx, subdomain)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	c.cache.put(subdomain, tenantID)
+	return tenantID, nil
+}
+
+// ResolveByID asks inner; lookups by ID are not cached
+func (c *cachedResolver) ResolveByID(ctx context.Context, tenantID uuid.UUID) (uuid.UUID, error) {
+	return c.inner.ResolveByID(ctx, tenantID)
+}
+
+// ExtractFromSubdomain asks inner
+func (c *cachedResolver) ExtractFromSubdomain(host string) (string, error) {
+	return c.inner.ExtractFromSubdomain(host)
+}
+
+// ExtractFromPath asks inner
+func (c *cachedResolver) ExtractFromPath(path string) (string, error) {
+	return c.inner.ExtractFromPath(path)
+}
+
+// ExtractFromHeader asks inner
+func (c *cachedResolver) ExtractFromHeader(req *http.Request) (string, error) {
+	return c.inner.ExtractFromHeader(req)
+}
+
+// ValidateSubdomain asks inner
+func (c *cachedResolver) ValidateSubdomain(subdomain string) error {
+	return c.inner.ValidateSubdomain(subdomain)
+}
+
+// Invalidate drops the cached resolution of subdomain and passes the
+// invalidation on to inner. It implements SubdomainInvalidator.
+func (c *cachedResolver) Invalidate(subdomain string) {
+	c.cache.forget(uuid.Nil, subdomain)
+	if invalidator, ok := c.inner.(SubdomainInvalidator); ok {
+		invalidator.Invalidate(subdomain)
+	}
+}
+
+// InvalidateCustomDomain passes the invalidation on to inner. It implements
+// CustomDomainInvalidator.
+func (c *cachedResolver) InvalidateCustomDomain(tenantID uuid.UUID, domain string) {
+	if invalidator, ok := c.inner.(CustomDomainInvalidator); ok {
+		invalidator.InvalidateCustomDomain(tenantID, domain)
+	}
+}
@@ -0,0 +1,213 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+// activeOnlyRepository resolves only active tenants by subdomain and counts
+// the lookups
+type activeOnlyRepository struct {
+	*MockManagerRepository
+	lookups int
+}
+
+func (r *activeOnlyRepository) GetBySubdomain(ctx context.Context, subdomain string) (*Tenant, error) {
+	r.lookups++
+	t, err := r.MockManagerRepository.GetBySubdomain(ctx, subdomain)
+	if err != nil {
+		return nil, err
+	}
+	if t.Status != StatusActive {
+		return nil, fmt.Errorf("%w: tenant %s is %s", ErrTenantNotFound, subdomain, t.Status)
+	}
+	return t, nil
+}
+
+func TestCachedResolver_CachesSubdomainLookups(t *testing.T) {
+	repo := &activeOnlyRepository{MockManagerRepository: NewMockRepository()}
+	tenant := &Tenant{ID: uuid.New(), Name: "Acme", Subdomain: "acme", Status: StatusActive}
+	repo.tenants[tenant.ID] = tenant
+
+	inner := NewResolver(ResolverConfig{Strategy: ResolverSubdomain, Domain: "example.com"}, repo, zaptest.NewLogger(t))
+	cached := NewCachedResolver(inner, time.Minute, 0)
+
+	for i := 0; i < 3; i++ {
+		gotID, err := cached.ResolveTenant(context.Background(), &http.Request{Host: "acme.example.com"})
+		if err != nil || gotID != tenant.ID {
+			t.Fatalf("ResolveTenant() = %v, %v, want %v", gotID, err, tenant.ID)
+		}
+	}
+	if repo.lookups != 1 {
+		t.Errorf("repository lookups = %d, want 1", repo.lookups)
+	}
+
+	// Unknown subdomains are not cached
+	for i := 0; i < 2; i++ {
+		if _, err := cached.ResolveTenant(context.Background(), &http.Request{Host: "missing.example.com"}); !IsNotFound(err) {
+			t.Fatalf("ResolveTenant() error = %v, want not found", err)
+		}
+	}
+	if repo.lookups != 3 {
+		t.Errorf("repository lookups = %d, want 3", repo.lookups)
+	}
+}
+
+func TestCachedResolver_SuspendedTenantUnresolvableAfterInvalidation(t *testing.T) {
+	repo := &activeOnlyRepository{MockManagerRepository: NewMockRepository()}
+	m := newTestManager(t, withRepository(repo))
+
+	tenant := &Tenant{ID: uuid.New(), Name: "Acme", Subdomain: "acme", PlanType: PlanBasic, Status: StatusActive}
+	repo.tenants[tenant.ID] = tenant
+
+	inner := NewResolver(ResolverConfig{Strategy: ResolverHeader}, repo, zaptest.NewLogger(t))
+	cached := NewCachedResolver(inner, time.Hour, 0)
+	m.AddSubdomainInvalidator(cached.(SubdomainInvalidator))
+
+	req, _ := http.NewRequest("GET", "/api/projects", nil)
+	req.Header.Set(DefaultResolverHeaderName, "acme")
+	ctx := context.Background()
+
+	if gotID, err := cached.ResolveTenant(ctx, req); err != nil || gotID != tenant.ID {
+		t.Fatalf("ResolveTenant() = %v, %v, want %v", gotID, err, tenant.ID)
+	}
+
+	if err := m.SuspendTenant(ctx, tenant.ID); err != nil {
+		t.Fatalf("SuspendTenant() error = %v", err)
+	}
+	if _, err := cached.ResolveTenant(ctx, req); !IsNotFound(err) {
+		t.Errorf("ResolveTenant() after suspension error = %v, want not found", err)
+	}
+
+	if err := m.ActivateTenant(ctx, tenant.ID); err != nil {
+		t.Fatalf("ActivateTenant() error = %v", err)
+	}
+	if gotID, err := cached.ResolveTenant(ctx, req); err != nil || gotID != tenant.ID {
+		t.Errorf("ResolveTenant() after activation = %v, %v, want %v", gotID, err, tenant.ID)
+	}
+
+	if err := m.DeleteTenant(ctx, tenant.ID); err != nil {
+		t.Fatalf("DeleteTenant() error = %v", err)
+	}
+	if _, err := cached.ResolveTenant(ctx, req); !IsNotFound(err) {
+		t.Errorf("ResolveTenant() after deletion error = %v, want not found", err)
+	}
+}
+
+func TestCachedResolver_RenamedSubdomainUnresolvable(t *testing.T) {
+	repo := &activeOnlyRepository{MockManagerRepository: NewMockRepository()}
+	m := newTestManager(t, withRepository(repo))
+
+	tenant := &Tenant{ID: uuid.New(), Name: "Acme", Subdomain: "acme", PlanType: PlanBasic, Status: StatusActive}
+	repo.tenants[tenant.ID] = tenant
+
+	inner := NewResolver(ResolverConfig{Strategy: ResolverHeader}, repo, zaptest.NewLogger(t))
+	cached := NewCachedResolver(inner, time.Hour, 0)
+	m.AddSubdomainInvalidator(cached.(SubdomainInvalidator))
+
+	resolve := func(subdomain string) (uuid.UUID, error) {
+		req, _ := http.NewRequest("GET", "/api/projects", nil)
+		req.Header.Set(DefaultResolverHeaderName, subdomain)
+		return cached.ResolveTenant(context.Background(), req)
+	}
+	if gotID, err := resolve("acme"); err != nil || gotID != tenant.ID {
+		t.Fatalf("ResolveTenant() = %v, %v, want %v", gotID, err, tenant.ID)
+	}
+
+	renamed := *tenant
+	renamed.Subdomain = "acme-corp"
+	if err := m.UpdateTenant(context.Background(), &renamed); err != nil {
+		t.Fatalf("UpdateTenant() error = %v", err)
+	}
+
+	if _, err := resolve("acme"); !IsNotFound(err) {
+		t.Errorf("ResolveTenant() of the old subdomain error = %v, want not found", err)
+	}
+	if gotID, err := resolve("acme-corp"); err != nil || gotID != tenant.ID {
+		t.Errorf("ResolveTenant() of the new subdomain = %v, %v, want %v", gotID, err, tenant.ID)
+	}
+}
+
+func TestCachedResolver_BoundsEntries(t *testing.T) {
+	repo := &activeOnlyRepository{MockManagerRepository: NewMockRepository()}
+	for i := 0; i < 10; i++ {
+		record := &Tenant{ID: uuid.New(), Name: "Tenant", Subdomain: fmt.Sprintf("tenant-%d", i), Status: StatusActive}
+		repo.tenants[record.ID] = record
+	}
+
+	inner := NewResolver(ResolverConfig{Strategy: ResolverSubdomain, Domain: "example.com"}, repo, zaptest.NewLogger(t))
+	cached := NewCachedResolver(inner, time.Minute, 3).(*cachedResolver)
+
+	for i := 0; i < 10; i++ {
+		if _, err := cached.ResolveBySubdomain(context.Background(), fmt.Sprintf("tenant-%d", i)); err != nil {
+			t.Fatalf("ResolveBySubdomain() error = %v", err)
+		}
+	}
+	if n := cached.cache.len(); n > 3 {
+		t.Errorf("cache holds %d entries, want at most 3", n)
+	}
+}
+
+func TestCachedResolver_Expiry(t *testing.T) {
+	repo := &activeOnlyRepository{MockManagerRepository: NewMockRepository()}
+	tenant := &Tenant{ID: uuid.New(), Name: "Acme", Subdomain: "acme", Status: StatusActive}
+	repo.tenants[tenant.ID] = tenant
+
+	inner := NewResolver(ResolverConfig{Strategy: ResolverSubdomain, Domain: "example.com"}, repo, zaptest.NewLogger(t))
+	cached := NewCachedResolver(inner, time.Minute, 0).(*cachedResolver)
+	now := time.Now()
+	cached.cache.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	if _, err := cached.ResolveBySubdomain(ctx, "acme"); err != nil {
+		t.Fatalf("ResolveBySubdomain() error = %v", err)
+	}
+	now = now.Add(2 * time.Minute)
+	if _, err := cached.ResolveBySubdomain(ctx, "acme"); err != nil {
+		t.Fatalf("ResolveBySubdomain() error = %v", err)
+	}
+	if repo.lookups != 2 {
+		t.Errorf("repository lookups = %d, want 2 once the entry expired", repo.lookups)
+	}
+}
+
+func TestNewCachedResolver_ZeroTTLReturnsInner(t *testing.T) {
+	inner := NewResolver(ResolverConfig{Strategy: ResolverHeader}, NewMockRepository(), zaptest.NewLogger(t))
+	if NewCachedResolver(inner, 0, 0) != inner {
+		t.Error("NewCachedResolver() with a zero TTL should return inner")
+	}
+}
+
+func BenchmarkResolver_ResolveTenant(b *testing.B) {
+	repo := &activeOnlyRepository{MockManagerRepository: NewMockRepository()}
+	tenant := &Tenant{ID: uuid.New(), Name: "Acme", Subdomain: "acme", Status: StatusActive}
+	repo.tenants[tenant.ID] = tenant
+
+	inner := NewResolver(ResolverConfig{Strategy: ResolverSubdomain, Domain: "example.com"}, repo, zap.NewNop())
+	req := &http.Request{Host: "acme.example.com"}
+	ctx := context.Background()
+
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := inner.ResolveTenant(ctx, req); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		cached := NewCachedResolver(inner, time.Minute, 0)
+		for i := 0; i < b.N; i++ {
+			if _, err := cached.ResolveTenant(ctx, req); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
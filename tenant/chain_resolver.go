@@ -0,0 +1,159 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// chainResolver implements Resolver by trying several resolvers in order
+type chainResolver struct {
+	resolvers []Resolver
+}
+
+// NewChainResolver returns a resolver that tries each of resolvers in order
+// and returns the first match, so that e.g. enterprise tenants can use custom
+// domains, everyone else subdomains and internal tools a header. Put the most
+// specific resolvers first: a request that several resolvers can resolve
+// gets the tenant of the earliest.
+//
+// If every resolver fails, the errors are joined. The result is a not found
+// error only when every resolver reported not found; if any lookup itself
+// failed, only those failures are returned, so that callers such as the
+// middleware report an outage rather than an unknown tenant. Resolvers after
+// a failed one are still tried.
+//
+// The chain implements SubdomainInvalidator and CustomDomainInvalidator by
+// passing invalidations on to the resolvers that implement them.
+func NewChainResolver(resolvers ...Resolver) Resolver {
+	return &chainResolver{resolvers: resolvers}
+}
+
+// ResolveTenant returns the tenant resolved by the first resolver that
+// resolves the request
+func (c *chainResolver) ResolveTenant(ctx context.Context, req *http.Request) (uuid.UUID, error) {
+	return c.resolve(func(r Resolver) (uuid.UUID, error) {
+		return r.ResolveTenant(ctx, req)
+	})
+}
+
+// ResolveBySubdomain returns the tenant resolved by the first resolver that
+// resolves subdomain
+func (c *chainResolver) ResolveBySubdomain(ctx context.Context, subdomain string) (uuid.UUID, error) {
+	return c.resolve(func(r Resolver) (uuid.UUID, error) {
+		return r.ResolveBySubdomain(ctx, subdomain)
+	})
+}
+
+// ResolveByID returns tenantID if any resolver finds it
+func (c *chainResolver) ResolveByID(ctx context.Context, tenantID uuid.UUID) (uuid.UUID, error) {
+	return c.resolve(func(r Resolver) (uuid.UUID, error) {
+		return r.ResolveByID(ctx, tenantID)
+	})
+}
+
+// ExtractFromSubdomain returns the first subdomain a resolver extracts from host
+func (c *chainResolver) ExtractFromSubdomain(host string) (string, error) {
+	return c.extract(func(r Resolver) (string, error) {
+		return r.ExtractFromSubdomain(host)
+	})
+}
+
+// ExtractFromPath returns the first subdomain a resolver extracts from path
+func (c *chainResolver) ExtractFromPath(path string) (string, error) {
+	return c.extract(func(r Resolver) (string, error) {
+		return r.ExtractFromPath(path)
+	})
+}
+
+// ExtractFromHeader returns the first subdomain a resolver extracts from the
+// request's headers
+func (c *chainResolver) ExtractFromHeader(req *http.Request) (string, error) {
+	return c.extract(func(r Resolver) (string, error) {
+		return r.ExtractFromHeader(req)
+	})
+}
+
+// ValidateSubdomain accepts subdomain if any resolver accepts it
+func (c *chainResolver) ValidateSubdomain(subdomain string) error {
+	_, err := c.extract(func(r Resolver) (string, error) {
+		return subdomain, r.ValidateSubdomain(subdomain)
+	})
+	return err
+}
+
+// Invalidate passes the invalidation on to every resolver that caches
+// subdomain lookups. It implements SubdomainInvalidator.
+func (c *chainResolver) Invalidate(subdomain string) {
+	for _, r := range c.resolvers {
+		if invalidator, ok := r.(SubdomainInvalidator); ok {
+			invalidator.Invalidate(subdomain)
+		}
+	}
+}
+
+// InvalidateCustomDomain passes the invalidation on to every resolver that
+// caches custom domain lookups. It implements CustomDomainInvalidator.
+func (c *chainResolver) InvalidateCustomDomain(tenantID uuid.UUID, domain string) {
+	for _, r := range c.resolvers {
+		if invalidator, ok := r.(CustomDomainInvalidator); ok {
+			invalidator.InvalidateCustomDomain(tenantID, domain)
+		}
+	}
+}
+
+// resolve returns the first tenant resolved by attempt, or the chain's error
+func (c *chainResolver) resolve(attempt func(Resolver) (uuid.UUID, error)) (uuid.UUID, error) {
+	var notFound, failed []error
+	for _, r := range c.resolvers {
+		tenantID, err := attempt(r)
+		if err == nil {
+			return tenantID, nil
+		}
+		if IsNotFound(err) {
+			notFound = append(notFound, err)
+		} else {
+			failed = append(failed, err)
+		}
+	}
+	return uuid.UUID{}, chainError(notFound, failed)
+}
+
+// extract returns the first value extracted by attempt, or the joined errors
+func (c *chainResolver) extract(attempt func(Resolver) (string, error)) (string, error) {
+	errs := make([]error, 0, len(c.resolvers))
+	for _, r := range c.resolvers {
+		value, err := attempt(r)
+		if err == nil {
+			return value, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return "", errors.New("no resolvers in chain")
+	}
+	return "", joinErrors(errs)
+}
+
+// chainError combines the errors of a chain in which no resolver matched
+func chainError(notFound, failed []error) error {
+	switch {
+	case len(failed) > 0:
+		return joinErrors(failed)
+	case len(notFound) > 0:
+		return joinErrors(notFound)
+	default:
+		return fmt.Errorf("%w: no resolvers in chain", ErrTenantNotFound)
+	}
+}
+
+// joinErrors joins errs, returning a single error as it is
+func joinErrors(errs []error) error {
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,128 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+// stubResolver resolves every request to id, or fails with err
+type stubResolver struct {
+	Resolver
+	id    uuid.UUID
+	err   error
+	calls int
+}
+
+func (s *stubResolver) ResolveTenant(ctx context.Context, req *http.Request) (uuid.UUID, error) {
+	s.calls++
+	return s.id, s.err
+}
+
+func TestChainResolver_FirstMissesSecondSucceeds(t *testing.T) {
+	tenantID := uuid.New()
+	repo := &mockRepository{
+		tenants: map[uuid.UUID]*Tenant{
+			tenantID: {ID: tenantID, Name: "Test Tenant", Subdomain: "test-tenant", Status: StatusActive},
+		},
+	}
+	logger := zaptest.NewLogger(t)
+
+	subdomains := NewResolver(ResolverConfig{Strategy: ResolverSubdomain, Domain: "example.com"}, repo, logger)
+	headers := NewResolver(ResolverConfig{Strategy: ResolverHeader, HeaderName: "X-Tenant"}, repo, logger)
+	chain := NewChainResolver(subdomains, headers)
+
+	req, _ := http.NewRequest("GET", "http://localhost/api/projects", nil)
+	req.Header.Set("X-Tenant", "test-tenant")
+
+	gotID, err := chain.ResolveTenant(context.Background(), req)
+	if err != nil {
+		t.Fatalf("ResolveTenant() error = %v", err)
+	}
+	if gotID != tenantID {
+		t.Errorf("ResolveTenant() = %v, want %v", gotID, tenantID)
+	}
+
+	// The chain also resolves by subdomain when the request names one
+	gotID, err = chain.ResolveBySubdomain(context.Background(), "test-tenant")
+	if err != nil || gotID != tenantID {
+		t.Errorf("ResolveBySubdomain() = %v, %v, want %v", gotID, err, tenantID)
+	}
+}
+
+func TestChainResolver_ShortCircuitsOnFirstHit(t *testing.T) {
+	first := &stubResolver{id: uuid.New()}
+	second := &stubResolver{id: uuid.New()}
+	chain := NewChainResolver(first, second)
+
+	gotID, err := chain.ResolveTenant(context.Background(), &http.Request{})
+	if err != nil || gotID != first.id {
+		t.Fatalf("ResolveTenant() = %v, %v, want %v", gotID, err, first.id)
+	}
+	if second.calls != 0 {
+		t.Errorf("second resolver called %d times, want 0", second.calls)
+	}
+}
+
+func TestChainResolver_AllMiss(t *testing.T) {
+	missA := &stubResolver{err: fmt.Errorf("%w for subdomain: a", ErrTenantNotFound)}
+	missB := &stubResolver{err: ErrTenantNotFound}
+	lookupFailure := errors.New("connection refused")
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := NewChainResolver(missA, missB).ResolveTenant(context.Background(), &http.Request{})
+		if !IsNotFound(err) {
+			t.Errorf("ResolveTenant() error = %v, want not found", err)
+		}
+		if !strings.Contains(err.Error(), "for subdomain: a") {
+			t.Errorf("ResolveTenant() error = %v, want every resolver's error", err)
+		}
+	})
+
+	t.Run("single error is returned as is", func(t *testing.T) {
+		_, err := NewChainResolver(missB).ResolveTenant(context.Background(), &http.Request{})
+		if err != ErrTenantNotFound {
+			t.Errorf("ResolveTenant() error = %v, want ErrTenantNotFound itself", err)
+		}
+	})
+
+	t.Run("lookup failure is not reported as not found", func(t *testing.T) {
+		failing := &stubResolver{err: lookupFailure}
+		after := &stubResolver{err: ErrTenantNotFound}
+		_, err := NewChainResolver(missA, failing, after).ResolveTenant(context.Background(), &http.Request{})
+		if IsNotFound(err) || !errors.Is(err, lookupFailure) {
+			t.Errorf("ResolveTenant() error = %v, want the lookup failure", err)
+		}
+		if after.calls == 0 {
+			t.Error("resolvers after a failed lookup should still be tried")
+		}
+	})
+
+	t.Run("empty chain", func(t *testing.T) {
+		if _, err := NewChainResolver().ResolveTenant(context.Background(), &http.Request{}); !IsNotFound(err) {
+			t.Errorf("ResolveTenant() error = %v, want not found", err)
+		}
+	})
+}
+
+func TestChainResolver_PassesInvalidationOn(t *testing.T) {
+	r, repo, enterprise, _ := newCustomDomainTestResolver(t)
+	chain := NewChainResolver(r)
+	ctx := context.Background()
+
+	if _, err := chain.ResolveTenant(ctx, &http.Request{Host: "app.acme.com"}); err != nil {
+		t.Fatalf("ResolveTenant() error = %v", err)
+	}
+	delete(repo.metadata, enterprise.ID)
+
+	chain.(CustomDomainInvalidator).InvalidateCustomDomain(enterprise.ID, "")
+	if _, err := chain.ResolveTenant(ctx, &http.Request{Host: "app.acme.com"}); !IsNotFound(err) {
+		t.Errorf("ResolveTenant() error = %v, want not found after invalidation", err)
+	}
+}
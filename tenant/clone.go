@@ -0,0 +1,87 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// SchemaCloner is implemented by schema managers that can copy table data
+// between tenant schemas, such as database.SchemaManager. CloneTenant
+// requires it.
+type SchemaCloner interface {
+	// CopyTenantData copies the rows of tables, or of every table in the
+	// source schema if tables is empty, into the target's schema in a single
+	// transaction
+	CopyTenantData(ctx context.Context, sourceID, targetID uuid.UUID, tables []string) error
+}
+
+// ErrCloneUnsupported is returned by CloneTenant when the schema manager is
+// not a SchemaCloner
+var ErrCloneUnsupported = errors.New("schema manager cannot copy tenant data")
+
+// CloneTenant creates newTenant like CreateTenant, provisions its schema and
+// copies the source tenant's data into it, e.g. to start agency clients from
+// the same reference projects and roles. Only the listed tables are copied,
+// or every table in the source schema if none are given. The copy runs in one
+// transaction; if it fails, the new tenant is removed again. newTenant takes
+// the source's plan unless it names one. Tenants in a region cannot be cloned.
+func (m *manager) CloneTenant(ctx context.Context, sourceID uuid.UUID, newTenant *Tenant, tables ...string) error {
+	cloner, ok := m.schemaManager.(SchemaCloner)
+	if !ok {
+		return ErrCloneUnsupported
+	}
+	if newTenant == nil {
+		return &ValidationError{Field: "tenant", Message: "tenant is required"}
+	}
+
+	source, err := m.repository.GetByID(ctx, sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get source tenant: %w", err)
+	}
+	_, regional, err := m.tenantRegion(ctx, sourceID)
+	if err != nil {
+		return err
+	}
+	if regional {
+		return &ValidationError{Field: "source", Message: "tenants in a region cannot be cloned"}
+	}
+	exists, err := m.schemaManager.SchemaExists(ctx, sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to check schema existence: %w", err)
+	}
+	if !exists {
+		return &TenantError{
+			TenantID: sourceID,
+			Code:     "TENANT_NOT_PROVISIONED",
+			Message:  fmt.Sprintf("source tenant %s has no schema to clone", sourceID),
+		}
+	}
+
+	if newTenant.PlanType == "" {
+		newTenant.PlanType = source.PlanType
+	}
+	if err := m.CreateTenant(ctx, newTenant); err != nil {
+		return err
+	}
+	if err := m.ProvisionTenant(ctx, newTenant.ID); err != nil {
+		m.rollbackOnboarding(ctx, newTenant, true)
+		return fmt.Errorf("failed to provision cloned tenant: %w", err)
+	}
+
+	defer m.stats.invalidate(newTenant.ID)
+	if err := cloner.CopyTenantData(ctx, sourceID, newTenant.ID, tables); err != nil {
+		m.rollbackOnboarding(ctx, newTenant, true)
+		return fmt.Errorf("failed to copy tenant data: %w", err)
+	}
+
+	m.logger.Info("Cloned tenant",
+		zap.String("source_tenant_id", sourceID.String()),
+		zap.String("tenant_id", newTenant.ID.String()),
+		zap.Strings("tables", tables))
+
+	return nil
+}
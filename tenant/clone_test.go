@@ -0,0 +1,95 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+// cloningSchemaManager is a mock SchemaCloner that records copies
+type cloningSchemaManager struct {
+	*MockManagerSchemaManager
+	copied map[uuid.UUID]uuid.UUID // target to source
+	tables []string
+	err    error
+}
+
+func (s *cloningSchemaManager) CopyTenantData(ctx context.Context, sourceID, targetID uuid.UUID, tables []string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.copied[targetID] = sourceID
+	s.tables = tables
+	return nil
+}
+
+func TestManager_CloneTenant(t *testing.T) {
+	config := DefaultConfig()
+	logger := zaptest.NewLogger(t)
+	ctx := context.Background()
+
+	plain := NewManager(config, nil, NewMockRepository(), NewMockSchemaManager(config.Database.SchemaPrefix),
+		NewMockMigrationManager(), NewMockLimitChecker(config.Limits), logger)
+	if err := plain.CloneTenant(ctx, uuid.New(), &Tenant{Name: "Copy", Subdomain: "copy"}); !errors.Is(err, ErrCloneUnsupported) {
+		t.Errorf("CloneTenant() error = %v, want ErrCloneUnsupported", err)
+	}
+
+	repo := NewMockRepository()
+	schemas := &cloningSchemaManager{MockManagerSchemaManager: NewMockSchemaManager(config.Database.SchemaPrefix), copied: map[uuid.UUID]uuid.UUID{}}
+	m := NewManager(config, nil, repo, schemas,
+		NewMockMigrationManager(), NewMockLimitChecker(config.Limits), logger)
+
+	source := &Tenant{Name: "Template", Subdomain: "template", PlanType: PlanPro}
+	if err := m.CreateTenant(ctx, source); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+
+	// The source has to be provisioned before it can be cloned
+	var tenantErr *TenantError
+	if err := m.CloneTenant(ctx, source.ID, &Tenant{Name: "Early", Subdomain: "early"}); !errors.As(err, &tenantErr) || tenantErr.Code != "TENANT_NOT_PROVISIONED" {
+		t.Fatalf("CloneTenant() of an unprovisioned source error = %v, want TENANT_NOT_PROVISIONED", err)
+	}
+
+	if err := m.ProvisionTenant(ctx, source.ID); err != nil {
+		t.Fatalf("ProvisionTenant() error = %v", err)
+	}
+
+	clone := &Tenant{Name: "Client", Subdomain: "client"}
+	if err := m.CloneTenant(ctx, source.ID, clone, "projects"); err != nil {
+		t.Fatalf("CloneTenant() error = %v", err)
+	}
+	if clone.ID == uuid.Nil || clone.ID == source.ID {
+		t.Fatalf("clone ID = %s, want a new tenant", clone.ID)
+	}
+	if clone.PlanType != PlanPro {
+		t.Errorf("clone plan = %s, want the source's %s", clone.PlanType, PlanPro)
+	}
+	if clone.Status != StatusActive {
+		t.Errorf("clone status = %s, want %s", clone.Status, StatusActive)
+	}
+	if !schemas.schemas[clone.ID] {
+		t.Error("clone schema should be provisioned")
+	}
+	if schemas.copied[clone.ID] != source.ID {
+		t.Errorf("data copied from %s, want %s", schemas.copied[clone.ID], source.ID)
+	}
+	if len(schemas.tables) != 1 || schemas.tables[0] != "projects" {
+		t.Errorf("tables copied = %v, want [projects]", schemas.tables)
+	}
+
+	// A failed copy removes the new tenant again
+	schemas.err = errors.New("copy failed")
+	failed := &Tenant{Name: "Broken", Subdomain: "broken"}
+	if err := m.CloneTenant(ctx, source.ID, failed); !errors.Is(err, schemas.err) {
+		t.Fatalf("CloneTenant() error = %v, want %v", err, schemas.err)
+	}
+	if _, ok := repo.tenants[failed.ID]; ok {
+		t.Error("tenant should be removed after a failed copy")
+	}
+	if schemas.schemas[failed.ID] {
+		t.Error("schema should be dropped after a failed copy")
+	}
+}
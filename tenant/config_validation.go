@@ -0,0 +1,137 @@
+package tenant
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Defaults applied to ResolverConfig by Validate and by the resolver
+const (
+	DefaultResolverHeaderName = "X-Tenant"
+	DefaultResolverPathPrefix = "/tenant/"
+	DefaultResolverJWTClaim   = "tenant"
+)
+
+// headerName matches a valid HTTP header field name (an RFC 7230 token)
+var headerName = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+// Validate checks the configuration for mistakes that would otherwise only
+// surface when requests are handled, filling in documented defaults where one
+// is sensible. It returns a ValidationError naming the offending field.
+func (c *Config) Validate() error {
+	if c.MaxTenants < -1 {
+		return &ValidationError{Field: "max_tenants", Message: "max tenants must be -1 (unlimited) or more"}
+	}
+	if c.Limits.StatsCacheTTL < 0 {
+		return &ValidationError{Field: "limits.stats_cache_ttl", Message: "stats cache TTL cannot be negative"}
+	}
+	if c.Database.MaxConcurrentProvisions < 0 {
+		return &ValidationError{Field: "database.max_concurrent_provisions", Message: "max concurrent provisions cannot be negative"}
+	}
+	if c.Database.MaxTenantPools < 0 || c.Database.TenantPoolConns < 0 {
+		return &ValidationError{Field: "database.max_tenant_pools", Message: "tenant pool sizes cannot be negative"}
+	}
+	for _, dsn := range c.Database.ReplicaDSNs {
+		if strings.TrimSpace(dsn) == "" {
+			return &ValidationError{Field: "database.replica_dsns", Message: "replica DSNs cannot be empty"}
+		}
+	}
+	if c.Database.IsPostgres() {
+		switch c.Database.SSLMode {
+		case "", "disable", "require", "verify-ca", "verify-full":
+		default:
+			return &ValidationError{Field: "database.sslmode", Message: fmt.Sprintf("unsupported sslmode %q, want disable, require, verify-ca or verify-full", c.Database.SSLMode)}
+		}
+	}
+	switch c.Database.Isolation {
+	case "", IsolationSchema, IsolationShared:
+	default:
+		return &ValidationError{Field: "database.isolation", Message: fmt.Sprintf("unknown isolation %q, want %q or %q", c.Database.Isolation, IsolationSchema, IsolationShared)}
+	}
+	if err := c.Webhook.Validate(); err != nil {
+		return err
+	}
+	if c.MetadataLimits != nil && (c.MetadataLimits.MaxBytes < 0 || c.MetadataLimits.MaxKeys < 0) {
+		return &ValidationError{Field: "metadata_limits", Message: "metadata limits cannot be negative"}
+	}
+	for plan, template := range c.PlanTemplates {
+		if err := template.validatePrices(); err != nil {
+			return &ValidationError{Field: "plan_templates." + plan, Message: err.Error()}
+		}
+	}
+	return c.Resolver.Validate()
+}
+
+// Validate checks that the chosen strategy has the fields it needs. A missing
+// header name, path prefix or JWT claim is set to its default, and a path
+// prefix without a trailing slash gets one; a custom_domain strategy without
+// a domain, a jwt strategy without a key function, an unknown strategy or a
+// malformed value is an error. The subdomain strategy takes the first label
+// of the host, so its domain is optional.
+func (rc *ResolverConfig) Validate() error {
+	switch rc.Strategy {
+	case ResolverSubdomain, ResolverCustomDomain:
+		rc.Domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(rc.Domain), "."))
+		if rc.Domain == "" {
+			if rc.Strategy == ResolverSubdomain {
+				break
+			}
+			return &ValidationError{Field: "resolver.domain", Message: fmt.Sprintf("domain is required for the %s strategy", rc.Strategy)}
+		}
+		if strings.ContainsAny(rc.Domain, "/: ") || !strings.Contains(rc.Domain, ".") {
+			return &ValidationError{Field: "resolver.domain", Message: fmt.Sprintf("invalid domain %q, want a host name such as example.com", rc.Domain)}
+		}
+
+	case ResolverPath:
+		if rc.PathSegmentIndex != nil {
+			if *rc.PathSegmentIndex < 0 {
+				return &ValidationError{Field: "resolver.path_segment_index", Message: "path segment index cannot be negative"}
+			}
+			break
+		}
+		if rc.PathPrefix == "" {
+			rc.PathPrefix = DefaultResolverPathPrefix
+		}
+		if !strings.HasPrefix(rc.PathPrefix, "/") || rc.PathPrefix == "/" {
+			return &ValidationError{Field: "resolver.path_prefix", Message: fmt.Sprintf("invalid path prefix %q, want a path such as /tenant/", rc.PathPrefix)}
+		}
+		if !strings.HasSuffix(rc.PathPrefix, "/") {
+			rc.PathPrefix += "/"
+		}
+
+	case ResolverHeader:
+		if rc.HeaderName == "" {
+			rc.HeaderName = DefaultResolverHeaderName
+		}
+		if !headerName.MatchString(rc.HeaderName) {
+			return &ValidationError{Field: "resolver.header_name", Message: fmt.Sprintf("invalid header name %q", rc.HeaderName)}
+		}
+
+	case ResolverJWT:
+		if rc.JWTKeyFunc == nil {
+			return &ValidationError{Field: "resolver.jwt_key_func", Message: "a key function is required for the jwt strategy"}
+		}
+		if rc.JWTClaim == "" {
+			rc.JWTClaim = DefaultResolverJWTClaim
+		}
+
+	case "":
+		return &ValidationError{Field: "resolver.strategy", Message: "strategy is required"}
+
+	default:
+		return &ValidationError{Field: "resolver.strategy", Message: fmt.Sprintf("unknown strategy %q, want subdomain, path, header, jwt or custom_domain", rc.Strategy)}
+	}
+
+	if rc.CacheTTL < 0 {
+		return &ValidationError{Field: "resolver.cache_ttl", Message: "cache TTL cannot be negative"}
+	}
+	if rc.CustomDomainCacheTTL < 0 {
+		return &ValidationError{Field: "resolver.custom_domain_cache_ttl", Message: "custom domain cache TTL cannot be negative"}
+	}
+	if rc.NegativeCacheTTL < 0 {
+		return &ValidationError{Field: "resolver.negative_cache_ttl", Message: "negative cache TTL cannot be negative"}
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package tenant
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResolverConfig_Validate(t *testing.T) {
+	negative := -1
+	first := 0
+
+	tests := []struct {
+		name      string
+		config    ResolverConfig
+		wantField string // empty when the config is valid
+	}{
+		{"subdomain with domain", ResolverConfig{Strategy: ResolverSubdomain, Domain: "example.com"}, ""},
+		{"subdomain without domain", ResolverConfig{Strategy: ResolverSubdomain}, ""},
+		{"subdomain with URL as domain", ResolverConfig{Strategy: ResolverSubdomain, Domain: "https://example.com"}, "resolver.domain"},
+		{"subdomain with single-label domain", ResolverConfig{Strategy: ResolverSubdomain, Domain: "localhost"}, "resolver.domain"},
+		{"path with prefix", ResolverConfig{Strategy: ResolverPath, PathPrefix: "/org/"}, ""},
+		{"path missing prefix uses default", ResolverConfig{Strategy: ResolverPath}, ""},
+		{"path with relative prefix", ResolverConfig{Strategy: ResolverPath, PathPrefix: "tenant/"}, "resolver.path_prefix"},
+		{"path with root prefix", ResolverConfig{Strategy: ResolverPath, PathPrefix: "/"}, "resolver.path_prefix"},
+		{"path with segment index", ResolverConfig{Strategy: ResolverPath, PathSegmentIndex: &first}, ""},
+		{"path with negative segment index", ResolverConfig{Strategy: ResolverPath, PathSegmentIndex: &negative}, "resolver.path_segment_index"},
+		{"header with name", ResolverConfig{Strategy: ResolverHeader, HeaderName: "X-Tenant-ID"}, ""},
+		{"header missing name uses default", ResolverConfig{Strategy: ResolverHeader}, ""},
+		{"header with invalid name", ResolverConfig{Strategy: ResolverHeader, HeaderName: "X Tenant:"}, "resolver.header_name"},
+		{"missing strategy", ResolverConfig{}, "resolver.strategy"},
+		{"jwt with key function", ResolverConfig{Strategy: ResolverJWT, JWTKeyFunc: HMACKeyFunc([]byte("secret"))}, ""},
+		{"jwt missing key function", ResolverConfig{Strategy: ResolverJWT}, "resolver.jwt_key_func"},
+		{"custom domain with domain", ResolverConfig{Strategy: ResolverCustomDomain, Domain: "example.com"}, ""},
+		{"custom domain missing domain", ResolverConfig{Strategy: ResolverCustomDomain}, "resolver.domain"},
+		{"negative custom domain cache TTL", ResolverConfig{Strategy: ResolverCustomDomain, Domain: "example.com", CustomDomainCacheTTL: -1}, "resolver.custom_domain_cache_ttl"},
+		{"unknown strategy", ResolverConfig{Strategy: "cookie"}, "resolver.strategy"},
+		{"negative cache TTL", ResolverConfig{Strategy: ResolverHeader, CacheTTL: -1}, "resolver.cache_ttl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Validate() error = %v, want a ValidationError", err)
+			}
+			if validationErr.Field != tt.wantField {
+				t.Errorf("Validate() field = %q, want %q", validationErr.Field, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestResolverConfig_Validate_AppliesDefaults(t *testing.T) {
+	header := ResolverConfig{Strategy: ResolverHeader}
+	if err := header.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if header.HeaderName != DefaultResolverHeaderName {
+		t.Errorf("HeaderName = %q, want %q", header.HeaderName, DefaultResolverHeaderName)
+	}
+
+	path := ResolverConfig{Strategy: ResolverPath}
+	if err := path.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if path.PathPrefix != DefaultResolverPathPrefix {
+		t.Errorf("PathPrefix = %q, want %q", path.PathPrefix, DefaultResolverPathPrefix)
+	}
+
+	// A prefix without a trailing slash would also match /tenantsX/...
+	path = ResolverConfig{Strategy: ResolverPath, PathPrefix: "/org"}
+	if err := path.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if path.PathPrefix != "/org/" {
+		t.Errorf("PathPrefix = %q, want /org/", path.PathPrefix)
+	}
+
+	subdomain := ResolverConfig{Strategy: ResolverSubdomain, Domain: " Example.COM. "}
+	if err := subdomain.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if subdomain.Domain != "example.com" {
+		t.Errorf("Domain = %q, want normalized example.com", subdomain.Domain)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	config := DefaultConfig()
+	if err := config.Validate(); err != nil {
+		t.Errorf("Validate() of the default config error = %v, want nil", err)
+	}
+
+	config.Resolver.Domain = "example.com"
+	if err := config.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+
+	config.MaxTenants = -2
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() should reject a MaxTenants below -1")
+	}
+	config.MaxTenants = 0
+
+	config.Database.MaxConcurrentProvisions = -1
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() should reject a negative MaxConcurrentProvisions")
+	}
+	config.Database.MaxConcurrentProvisions = 0
+
+	config.Database.ReplicaDSNs = []string{"postgres://replica-1/app", " "}
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() should reject an empty replica DSN")
+	}
+	config.Database.ReplicaDSNs = nil
+
+	config.Webhook.URL = "hooks.example.com/tenants"
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() should reject a webhook URL without a scheme")
+	}
+	config.Webhook.URL = "https://hooks.example.com/tenants"
+	if err := config.Validate(); err != nil {
+		t.Errorf("Validate() error = %v with a webhook URL, want nil", err)
+	}
+	config.Webhook.URL = ""
+
+	config.Database.SSLMode = "verify-full"
+	if err := config.Validate(); err != nil {
+		t.Errorf("Validate() error = %v with sslmode verify-full, want nil", err)
+	}
+	for _, mode := range []string{"allow", "prefer", "strict"} {
+		config.Database.SSLMode = mode
+		if err := config.Validate(); err == nil {
+			t.Errorf("Validate() should reject sslmode %s, which lib/pq does not support", mode)
+		}
+	}
+	config.Database.Driver = "sqlite"
+	if err := config.Validate(); err != nil {
+		t.Errorf("Validate() error = %v for a sqlite driver, want the SSL settings ignored", err)
+	}
+	config.Database.Driver = "postgres"
+	config.Database.SSLMode = ""
+
+	config.Database.Isolation = IsolationShared
+	if err := config.Validate(); err != nil {
+		t.Errorf("Validate() error = %v with shared isolation, want nil", err)
+	}
+	config.Database.Isolation = "database"
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() should reject an unknown isolation")
+	}
+	config.Database.Isolation = ""
+
+	config.MetadataLimits = &MetadataLimits{MaxBytes: -1}
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() should reject negative metadata limits")
+	}
+	config.MetadataLimits = &MetadataLimits{}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Validate() error = %v with metadata limits turned off, want nil", err)
+	}
+}
@@ -0,0 +1,361 @@
+package tenant
+
+import (
+	"container/list"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// connectionCache is a concurrency-safe LRU cache of tenant-specific database
+// handles. Entries are evicted when the cache exceeds maxSize or when they have
+// not been used for idleTimeout, and evicted handles are closed. Handles taken
+// with acquire are leased: one evicted while leased is closed on its last
+// release instead.
+type connectionCache struct {
+	mu                  sync.Mutex
+	maxSize             int           // 0 means unbounded
+	idleTimeout         time.Duration // 0 means entries never expire
+	saturationThreshold time.Duration // report pools saturated this long, 0 means never
+	entries             map[uuid.UUID]*list.Element
+	order               *list.List // front is most recently used
+	logger              *zap.Logger
+	now                 func() time.Time
+	opens               lazyInit // one open per tenant at a time
+}
+
+// cacheEntry is a single cached tenant handle
+type cacheEntry struct {
+	tenantID       uuid.UUID
+	db             *sql.DB
+	lastUsed       time.Time
+	saturatedSince time.Time // zero while the pool has free connections
+	reported       bool      // saturation already reported for this period
+	leases         int       // acquisitions not yet released
+	closing        bool      // evicted while leased, closed on the last release
+	closed         bool      // handle closed, can no longer be leased
+}
+
+// PoolStats reports usage of a tenant's dedicated connection pool
+type PoolStats struct {
+	sql.DBStats
+	Saturated      bool      `json:"saturated"`       // every connection is in use
+	SaturatedSince time.Time `json:"saturated_since"` // zero unless Saturated
+}
+
+// newConnectionCache creates a new connection cache
+func newConnectionCache(maxSize int, idleTimeout time.Duration, logger *zap.Logger) *connectionCache {
+	return &connectionCache{
+		maxSize:     maxSize,
+		idleTimeout: idleTimeout,
+		entries:     make(map[uuid.UUID]*list.Element),
+		order:       list.New(),
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+// get returns the cached handle for a tenant and marks it as recently used
+func (c *connectionCache) get(tenantID uuid.UUID) (*sql.DB, bool) {
+	entry, ok := c.lookup(tenantID, false)
+	if !ok {
+		return nil, false
+	}
+	return entry.db, true
+}
+
+// getOrCreate returns the cached handle for a tenant, opening one with open if
+// none is cached. The handle is not leased, so it may be closed by eviction
+// at any time; callers about to use it should acquire it instead.
+func (c *connectionCache) getOrCreate(tenantID uuid.UUID, open func() (*sql.DB, error)) (*sql.DB, error) {
+	db, release, err := c.acquire(tenantID, open)
+	if err != nil {
+		return nil, err
+	}
+	release()
+	return db, nil
+}
+
+// acquire returns the cached handle for a tenant, opening one with open if
+// none is cached, and leases it until release is called so that eviction
+// cannot close it in the meantime. Concurrent misses for the same tenant share
+// a single call to open, which is made without holding the cache lock.
+func (c *connectionCache) acquire(tenantID uuid.UUID, open func() (*sql.DB, error)) (db *sql.DB, release func(), err error) {
+	for {
+		if entry, ok := c.lookup(tenantID, true); ok {
+			return entry.db, c.releaser(entry), nil
+		}
+
+		value, err := c.opens.do("connection", tenantID, func() (interface{}, error) {
+			// A previous open may have completed since the lookup above
+			if entry, ok := c.lookup(tenantID, false); ok {
+				return entry, nil
+			}
+
+			db, err := open()
+			if err != nil {
+				return nil, err
+			}
+
+			c.mu.Lock()
+			// A handle may also have been added with put meanwhile
+			if existing, ok := c.touchLocked(tenantID); ok {
+				c.mu.Unlock()
+				c.closeAll([]*cacheEntry{{tenantID: tenantID, db: db}})
+				return existing, nil
+			}
+			entry, evicted := c.putLocked(tenantID, db)
+			c.mu.Unlock()
+
+			c.closeAll(evicted)
+			return entry, nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// The handle may have been evicted and closed before it could be
+		// leased; open the tenant's pool again in that case
+		entry := value.(*cacheEntry)
+		c.mu.Lock()
+		leased := !entry.closed
+		if leased {
+			entry.leases++
+		}
+		c.mu.Unlock()
+		if leased {
+			return entry.db, c.releaser(entry), nil
+		}
+	}
+}
+
+// lookup returns the cached entry for a tenant, marking it as recently used
+// and leasing it if lease is set
+func (c *connectionCache) lookup(tenantID uuid.UUID, lease bool) (*cacheEntry, bool) {
+	c.mu.Lock()
+	evicted := c.evictIdleLocked()
+	entry, ok := c.touchLocked(tenantID)
+	if ok && lease {
+		entry.leases++
+	}
+	c.mu.Unlock()
+
+	c.closeAll(evicted)
+	return entry, ok
+}
+
+// releaser returns the function that ends a lease on entry, closing its
+// handle if the entry was evicted during the lease. Calls after the first are
+// ignored.
+func (c *connectionCache) releaser(entry *cacheEntry) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			entry.leases--
+			last := entry.leases == 0 && entry.closing
+			if last {
+				entry.closed = true
+			}
+			c.mu.Unlock()
+
+			if last {
+				c.closeEntry(entry)
+			}
+		})
+	}
+}
+
+// put adds or replaces the handle for a tenant
+func (c *connectionCache) put(tenantID uuid.UUID, db *sql.DB) {
+	c.mu.Lock()
+	var evicted []*cacheEntry
+	if elem, ok := c.entries[tenantID]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if entry.db != db {
+			evicted = append(evicted, entry)
+		}
+		c.order.Remove(elem)
+		delete(c.entries, tenantID)
+	}
+	_, added := c.putLocked(tenantID, db)
+	evicted = append(evicted, added...)
+	c.mu.Unlock()
+
+	c.closeAll(evicted)
+}
+
+// remove evicts and closes the handle for a tenant
+func (c *connectionCache) remove(tenantID uuid.UUID) {
+	c.mu.Lock()
+	var evicted []*cacheEntry
+	if elem, ok := c.entries[tenantID]; ok {
+		evicted = append(evicted, elem.Value.(*cacheEntry))
+		c.order.Remove(elem)
+		delete(c.entries, tenantID)
+	}
+	c.mu.Unlock()
+
+	c.closeAll(evicted)
+}
+
+// stats returns pool statistics for a tenant's cached handle without marking it as used
+func (c *connectionCache) stats(tenantID uuid.UUID) (*PoolStats, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[tenantID]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	dbStats := c.observeLocked(entry)
+	return &PoolStats{
+		DBStats:        dbStats,
+		Saturated:      !entry.saturatedSince.IsZero(),
+		SaturatedSince: entry.saturatedSince,
+	}, true
+}
+
+// len returns the number of cached handles
+func (c *connectionCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// close evicts and closes every cached handle
+func (c *connectionCache) close() {
+	c.mu.Lock()
+	evicted := make([]*cacheEntry, 0, c.order.Len())
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		evicted = append(evicted, elem.Value.(*cacheEntry))
+	}
+	c.entries = make(map[uuid.UUID]*list.Element)
+	c.order.Init()
+	c.mu.Unlock()
+
+	c.closeAll(evicted)
+}
+
+// touchLocked returns the entry for a tenant and moves it to the front
+func (c *connectionCache) touchLocked(tenantID uuid.UUID) (*cacheEntry, bool) {
+	elem, ok := c.entries[tenantID]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	entry.lastUsed = c.now()
+	c.order.MoveToFront(elem)
+	c.observeLocked(entry)
+	return entry, true
+}
+
+// observeLocked samples a handle's pool statistics and reports the pool once
+// it has been saturated for saturationThreshold
+func (c *connectionCache) observeLocked(entry *cacheEntry) sql.DBStats {
+	dbStats := entry.db.Stats()
+
+	if dbStats.MaxOpenConnections <= 0 || dbStats.InUse < dbStats.MaxOpenConnections {
+		entry.saturatedSince = time.Time{}
+		entry.reported = false
+		return dbStats
+	}
+
+	now := c.now()
+	if entry.saturatedSince.IsZero() {
+		entry.saturatedSince = now
+	}
+
+	saturatedFor := now.Sub(entry.saturatedSince)
+	if c.saturationThreshold > 0 && !entry.reported && saturatedFor >= c.saturationThreshold {
+		entry.reported = true
+		c.logger.Warn("Tenant connection pool saturated",
+			zap.String("tenant_id", entry.tenantID.String()),
+			zap.Int("in_use", dbStats.InUse),
+			zap.Int("max_open_connections", dbStats.MaxOpenConnections),
+			zap.Int64("wait_count", dbStats.WaitCount),
+			zap.Duration("saturated_for", saturatedFor))
+	}
+
+	return dbStats
+}
+
+// putLocked inserts a new entry and returns it with any entries evicted to
+// make room
+func (c *connectionCache) putLocked(tenantID uuid.UUID, db *sql.DB) (*cacheEntry, []*cacheEntry) {
+	entry := &cacheEntry{tenantID: tenantID, db: db, lastUsed: c.now()}
+	c.entries[tenantID] = c.order.PushFront(entry)
+
+	var evicted []*cacheEntry
+	for c.maxSize > 0 && c.order.Len() > c.maxSize {
+		oldest := c.order.Back()
+		evicted = append(evicted, oldest.Value.(*cacheEntry))
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).tenantID)
+	}
+
+	return entry, evicted
+}
+
+// evictIdleLocked removes entries that have been idle longer than idleTimeout
+func (c *connectionCache) evictIdleLocked() []*cacheEntry {
+	if c.idleTimeout <= 0 {
+		return nil
+	}
+
+	cutoff := c.now().Add(-c.idleTimeout)
+	var evicted []*cacheEntry
+	for elem := c.order.Back(); elem != nil; {
+		entry := elem.Value.(*cacheEntry)
+		if entry.lastUsed.After(cutoff) {
+			break
+		}
+		prev := elem.Prev()
+		evicted = append(evicted, entry)
+		c.order.Remove(elem)
+		delete(c.entries, entry.tenantID)
+		elem = prev
+	}
+
+	return evicted
+}
+
+// closeAll closes evicted handles outside the cache lock. Leased handles are
+// left open until their last release.
+func (c *connectionCache) closeAll(entries []*cacheEntry) {
+	for _, entry := range entries {
+		c.mu.Lock()
+		leased := entry.leases > 0
+		if leased {
+			entry.closing = true
+		} else {
+			entry.closed = true
+		}
+		c.mu.Unlock()
+
+		if leased {
+			c.logger.Debug("Deferring close of leased tenant connection",
+				zap.String("tenant_id", entry.tenantID.String()))
+			continue
+		}
+		c.closeEntry(entry)
+	}
+}
+
+// closeEntry closes an entry's handle
+func (c *connectionCache) closeEntry(entry *cacheEntry) {
+	if err := entry.db.Close(); err != nil {
+		c.logger.Error("Failed to close tenant connection",
+			zap.String("tenant_id", entry.tenantID.String()),
+			zap.Error(err))
+		return
+	}
+	c.logger.Debug("Closed tenant connection",
+		zap.String("tenant_id", entry.tenantID.String()))
+}
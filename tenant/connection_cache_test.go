@@ -0,0 +1,413 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestConnectionCache_GetOrCreate(t *testing.T) {
+	cache := newConnectionCache(0, 0, zaptest.NewLogger(t))
+	tenantID := uuid.New()
+
+	opens := 0
+	open := func() (*sql.DB, error) {
+		opens++
+		return newTestDB(), nil
+	}
+
+	first, err := cache.getOrCreate(tenantID, open)
+	if err != nil {
+		t.Fatalf("getOrCreate() error = %v", err)
+	}
+	second, err := cache.getOrCreate(tenantID, open)
+	if err != nil {
+		t.Fatalf("getOrCreate() error = %v", err)
+	}
+
+	if first != second {
+		t.Error("getOrCreate() should return the cached handle")
+	}
+	if opens != 1 {
+		t.Errorf("getOrCreate() opened %d handles, want 1", opens)
+	}
+
+	// Open errors are returned and nothing is cached
+	_, err = cache.getOrCreate(uuid.New(), func() (*sql.DB, error) {
+		return nil, errors.New("open failed")
+	})
+	if err == nil {
+		t.Error("getOrCreate() should return open errors")
+	}
+	if cache.len() != 1 {
+		t.Errorf("cache len = %d, want 1", cache.len())
+	}
+}
+
+func TestConnectionCache_LRUEviction(t *testing.T) {
+	cache := newConnectionCache(2, 0, zaptest.NewLogger(t))
+
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	dbA, dbB, dbC := newTestDB(), newTestDB(), newTestDB()
+
+	cache.put(a, dbA)
+	cache.put(b, dbB)
+
+	// Touch a so that b becomes least recently used
+	if _, ok := cache.get(a); !ok {
+		t.Fatal("get() should find tenant a")
+	}
+
+	cache.put(c, dbC)
+
+	if cache.len() != 2 {
+		t.Errorf("cache len = %d, want 2", cache.len())
+	}
+	if _, ok := cache.get(b); ok {
+		t.Error("least recently used tenant should be evicted")
+	}
+	if !isClosed(dbB) {
+		t.Error("evicted handle should be closed")
+	}
+	if isClosed(dbA) || isClosed(dbC) {
+		t.Error("retained handles should not be closed")
+	}
+}
+
+func TestConnectionCache_AcquireDefersEviction(t *testing.T) {
+	cache := newConnectionCache(1, 0, zaptest.NewLogger(t))
+	a, b := uuid.New(), uuid.New()
+	dbA := sql.OpenDB(poolTestConnector{})
+
+	db, release, err := cache.acquire(a, func() (*sql.DB, error) { return dbA, nil })
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	// Evicting a leased handle leaves it open for the holder
+	cache.put(b, newTestDB())
+	if _, ok := cache.get(a); ok {
+		t.Fatal("tenant a should be evicted")
+	}
+	if isClosed(db) {
+		t.Fatal("a leased handle should not be closed by eviction")
+	}
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("Conn() on the leased handle error = %v", err)
+	}
+
+	// The last release closes it; connections taken before keep working
+	release()
+	release()
+	if !isClosed(db) {
+		t.Error("an evicted handle should be closed on its last release")
+	}
+	if err := conn.Raw(func(interface{}) error { return nil }); err != nil {
+		t.Errorf("connection taken before the close error = %v", err)
+	}
+	conn.Close()
+
+	// Handles released before eviction are closed by it
+	_, release, err = cache.acquire(a, func() (*sql.DB, error) { return newTestDB(), nil })
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	dbB, _ := cache.get(b)
+	if dbB != nil {
+		t.Error("acquiring tenant a should evict tenant b")
+	}
+	release()
+	reacquired, _ := cache.get(a)
+	cache.remove(a)
+	if !isClosed(reacquired) {
+		t.Error("an unleased handle should be closed when evicted")
+	}
+}
+
+func TestConnectionCache_IdleEviction(t *testing.T) {
+	cache := newConnectionCache(0, time.Minute, zaptest.NewLogger(t))
+
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	idle, active := uuid.New(), uuid.New()
+	idleDB, activeDB := newTestDB(), newTestDB()
+
+	cache.put(idle, idleDB)
+	now = now.Add(45 * time.Second)
+	cache.put(active, activeDB)
+	now = now.Add(30 * time.Second)
+
+	if _, ok := cache.get(idle); ok {
+		t.Error("idle tenant should be evicted")
+	}
+	if !isClosed(idleDB) {
+		t.Error("idle handle should be closed")
+	}
+	if _, ok := cache.get(active); !ok {
+		t.Error("recently used tenant should be retained")
+	}
+}
+
+func TestConnectionCache_RemoveAndClose(t *testing.T) {
+	cache := newConnectionCache(0, 0, zaptest.NewLogger(t))
+
+	a, b := uuid.New(), uuid.New()
+	dbA, dbB := newTestDB(), newTestDB()
+	cache.put(a, dbA)
+	cache.put(b, dbB)
+
+	cache.remove(a)
+	if !isClosed(dbA) {
+		t.Error("remove() should close the handle")
+	}
+
+	cache.close()
+	if !isClosed(dbB) {
+		t.Error("close() should close all handles")
+	}
+	if cache.len() != 0 {
+		t.Errorf("cache len after close = %d, want 0", cache.len())
+	}
+}
+
+func TestConnectionCache_Concurrent(t *testing.T) {
+	cache := newConnectionCache(10, time.Minute, zaptest.NewLogger(t))
+
+	tenants := make([]uuid.UUID, 50)
+	for i := range tenants {
+		tenants[i] = uuid.New()
+	}
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				tenantID := tenants[(worker*7+i)%len(tenants)]
+				db, err := cache.getOrCreate(tenantID, func() (*sql.DB, error) {
+					return newTestDB(), nil
+				})
+				if err != nil {
+					t.Errorf("getOrCreate() error = %v", err)
+					return
+				}
+				db.Stats()
+				if i%25 == 0 {
+					cache.remove(tenantID)
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	if cache.len() > 10 {
+		t.Errorf("cache len = %d, want at most 10", cache.len())
+	}
+	cache.close()
+}
+
+func TestConnectionCache_ConcurrentFirstAccess(t *testing.T) {
+	cache := newConnectionCache(10, time.Minute, zaptest.NewLogger(t))
+	defer cache.close()
+	tenantID := uuid.New()
+
+	var opens atomic.Int32
+	release := make(chan struct{})
+	open := func() (*sql.DB, error) {
+		opens.Add(1)
+		<-release
+		return newTestDB(), nil
+	}
+
+	const callers = 50
+	handles := make([]*sql.DB, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			db, err := cache.getOrCreate(tenantID, open)
+			if err != nil {
+				t.Errorf("getOrCreate() error = %v", err)
+			}
+			handles[i] = db
+		}(i)
+	}
+
+	// Hold the first open until the other callers have had time to join it
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := opens.Load(); n != 1 {
+		t.Errorf("opened %d handles, want 1", n)
+	}
+	for i, db := range handles {
+		if db != handles[0] || isClosed(db) {
+			t.Errorf("caller %d got a different or closed handle", i)
+		}
+	}
+}
+
+func TestManager_Close_DrainsConnections(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	config := DefaultConfig()
+
+	m := NewManager(config, nil, NewMockRepository(), NewMockSchemaManager(config.Database.SchemaPrefix),
+		NewMockMigrationManager(), NewMockLimitChecker(config.Limits), logger).(*manager)
+
+	db := newTestDB()
+	m.connections.put(uuid.New(), db)
+
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !isClosed(db) {
+		t.Error("Close() should close cached tenant connections")
+	}
+}
+
+func TestConnectionCache_PoolSaturation(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	cache := newConnectionCache(0, 0, zap.New(core))
+	cache.saturationThreshold = time.Minute
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	tenantID := uuid.New()
+	db := sql.OpenDB(poolTestConnector{})
+	db.SetMaxOpenConns(1)
+	cache.put(tenantID, db)
+	defer cache.close()
+
+	stats, ok := cache.stats(tenantID)
+	if !ok {
+		t.Fatal("stats() should find the cached pool")
+	}
+	if stats.Saturated {
+		t.Error("idle pool should not be saturated")
+	}
+
+	// Hold the only connection
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("Conn() error = %v", err)
+	}
+
+	stats, _ = cache.stats(tenantID)
+	if !stats.Saturated || !stats.SaturatedSince.Equal(now) {
+		t.Errorf("stats() = %+v, want saturated since %v", stats, now)
+	}
+	if stats.InUse != 1 || stats.MaxOpenConnections != 1 {
+		t.Errorf("stats() InUse = %d, MaxOpenConnections = %d, want 1 and 1", stats.InUse, stats.MaxOpenConnections)
+	}
+	if logs.Len() != 0 {
+		t.Error("saturation should not be reported before the threshold")
+	}
+
+	// Sustained saturation is reported once
+	now = now.Add(time.Minute)
+	cache.get(tenantID)
+	cache.stats(tenantID)
+	if got := logs.FilterMessage("Tenant connection pool saturated").Len(); got != 1 {
+		t.Errorf("saturation reported %d times, want 1", got)
+	}
+
+	// Releasing the connection clears saturation
+	conn.Close()
+	stats, _ = cache.stats(tenantID)
+	if stats.Saturated || !stats.SaturatedSince.IsZero() {
+		t.Errorf("stats() = %+v, want not saturated", stats)
+	}
+}
+
+func TestManager_TenantPoolStats(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	config := DefaultConfig()
+
+	m := NewManager(config, nil, NewMockRepository(), NewMockSchemaManager(config.Database.SchemaPrefix),
+		NewMockMigrationManager(), NewMockLimitChecker(config.Limits), logger).(*manager)
+	defer m.Close()
+
+	tenantID := uuid.New()
+	if _, err := m.TenantPoolStats(tenantID); err == nil {
+		t.Error("TenantPoolStats() should error for tenants without a dedicated pool")
+	}
+
+	db := sql.OpenDB(poolTestConnector{})
+	db.SetMaxOpenConns(2)
+	m.connections.put(tenantID, db)
+
+	stats, err := m.TenantPoolStats(tenantID)
+	if err != nil {
+		t.Fatalf("TenantPoolStats() error = %v", err)
+	}
+	if stats.MaxOpenConnections != 2 || stats.Saturated {
+		t.Errorf("TenantPoolStats() = %+v, want unsaturated pool of 2", stats)
+	}
+}
+
+// newTestDB returns a *sql.DB backed by a connector that never connects
+func newTestDB() *sql.DB {
+	return sql.OpenDB(testConnector{})
+}
+
+// isClosed reports whether a *sql.DB has been closed
+func isClosed(db *sql.DB) bool {
+	err := db.PingContext(context.Background())
+	return err != nil && strings.Contains(err.Error(), "database is closed")
+}
+
+type testConnector struct{}
+
+func (testConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return nil, errors.New("test connector does not connect")
+}
+
+func (testConnector) Driver() driver.Driver {
+	return testDriver{}
+}
+
+type testDriver struct{}
+
+func (testDriver) Open(name string) (driver.Conn, error) {
+	return nil, errors.New("test driver does not connect")
+}
+
+// poolTestConnector opens connections that support nothing but closing, for
+// exercising pool accounting
+type poolTestConnector struct{}
+
+func (poolTestConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return poolTestConn{}, nil
+}
+
+func (poolTestConnector) Driver() driver.Driver {
+	return testDriver{}
+}
+
+type poolTestConn struct{}
+
+func (poolTestConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("pool test connection does not run queries")
+}
+
+func (poolTestConn) Close() error { return nil }
+
+func (poolTestConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("pool test connection does not run transactions")
+}
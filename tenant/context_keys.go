@@ -0,0 +1,59 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// contextKey is the type of the package's context keys. It is unexported, so
+// no other package can construct or overwrite them.
+type contextKey struct {
+	name string
+}
+
+// String returns the key's name, for debugging
+func (k contextKey) String() string {
+	return "tenant." + k.name
+}
+
+var (
+	contextKeyTenant         = contextKey{"tenant"}
+	contextKeyTenantID       = contextKey{"tenant_id"}
+	contextKeyTenantDB       = contextKey{"tenant_db"}   // deprecated tenant database pool
+	contextKeyTenantConn     = contextKey{"tenant_conn"} // dedicated tenant database connection
+	contextKeyIdempotencyKey = contextKey{"idempotency_key"}
+	contextKeyForceProvision = contextKey{"force_provision"} // lets ProvisionTenant run for suspended and cancelled tenants
+)
+
+// namespacedKey is the context key WithTenantContext uses alongside the shared
+// keys when Config.ContextNamespace is set
+type namespacedKey struct {
+	namespace string
+	key       contextKey
+}
+
+// contextKey returns the key under which this manager stores key: the shared
+// key, or one scoped to Config.ContextNamespace
+func (m *manager) contextKey(key contextKey) interface{} {
+	if m.config.ContextNamespace == "" {
+		return key
+	}
+	return namespacedKey{namespace: m.config.ContextNamespace, key: key}
+}
+
+// TenantFromContext returns the tenant context stored by this manager's
+// WithTenantContext. With Config.ContextNamespace set it ignores tenant
+// contexts stored by managers with other namespaces; without it, it is
+// GetTenantFromContext.
+func (m *manager) TenantFromContext(ctx context.Context) (*Context, bool) {
+	tenant, ok := ctx.Value(m.contextKey(contextKeyTenant)).(*Context)
+	return tenant, ok
+}
+
+// TenantIDFromContext returns the tenant ID stored by this manager's
+// WithTenantContext, like TenantFromContext
+func (m *manager) TenantIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	tenantID, ok := ctx.Value(m.contextKey(contextKeyTenantID)).(uuid.UUID)
+	return tenantID, ok
+}
@@ -0,0 +1,139 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Defaults for the custom domain cache of the custom_domain strategy
+const (
+	DefaultCustomDomainCacheTTL  = time.Minute
+	DefaultCustomDomainCacheSize = 10000
+)
+
+// CustomDomainInvalidator is implemented by resolvers that cache custom
+// domain lookups, such as one using the custom_domain strategy. The cache
+// forgets domain and every domain it mapped to tenantID, so that a changed
+// custom domain resolves at once. CustomDomainInvalidationHook calls it.
+type CustomDomainInvalidator interface {
+	InvalidateCustomDomain(tenantID uuid.UUID, domain string)
+}
+
+// CustomDomainInvalidationHook returns a MetadataHook that tells invalidator
+// whenever a tenant's custom_domain is written, e.g. by VerifyDomain. Add it
+// to the ExtensibleRepository the resolver uses. Removing the field does not
+// run hooks, so a removed domain resolves until its cache entry expires.
+func CustomDomainInvalidationHook(invalidator CustomDomainInvalidator) MetadataHook {
+	return func(ctx context.Context, tenantID uuid.UUID, key string, value interface{}) (interface{}, error) {
+		if key == MetadataCustomDomain {
+			domain, _ := value.(string)
+			invalidator.InvalidateCustomDomain(tenantID, normalizeDomain(domain))
+		}
+		return value, nil
+	}
+}
+
+// resolveFromCustomDomain resolves the tenant whose custom_domain is the
+// request's host. Hosts under the base domain, and hosts no tenant uses, are
+// resolved by subdomain instead.
+func (r *resolver) resolveFromCustomDomain(ctx context.Context, req *http.Request) (uuid.UUID, error) {
+	host := normalizeHost(req.Host)
+	if host == "" {
+		return uuid.UUID{}, fmt.Errorf("%w: empty host", ErrTenantNotFound)
+	}
+
+	if !r.isBaseDomainHost(host) {
+		tenantID, err := r.lookupCustomDomain(ctx, host)
+		if err != nil {
+			return uuid.UUID{}, err
+		}
+		if tenantID != uuid.Nil {
+			r.logger.Debug("Resolved tenant",
+				zap.String("custom_domain", host),
+				zap.String("tenant_id", tenantID.String()),
+				zap.String("strategy", r.config.Strategy))
+			return tenantID, nil
+		}
+	}
+
+	subdomain, err := r.ExtractFromSubdomain(host)
+	if err != nil {
+		return uuid.UUID{}, fmt.Errorf("%w: %w", ErrTenantNotFound, err)
+	}
+
+	tenantID, err := r.lookupSubdomain(ctx, subdomain)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	r.logger.Debug("Resolved tenant",
+		zap.String("subdomain", subdomain),
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("strategy", r.config.Strategy))
+
+	return tenantID, nil
+}
+
+// isBaseDomainHost reports whether host is the configured domain or one of
+// its subdomains
+func (r *resolver) isBaseDomainHost(host string) bool {
+	domain := r.config.Domain
+	if domain == "" {
+		return false
+	}
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// lookupCustomDomain returns the ID of the tenant using domain as its custom
+// domain, or uuid.Nil if none does. Cancelled tenants are ignored.
+func (r *resolver) lookupCustomDomain(ctx context.Context, domain string) (uuid.UUID, error) {
+	if tenantID, ok := r.domains.get(domain); ok {
+		return tenantID, nil
+	}
+
+	repo, ok := r.repository.(ExtensibleRepository)
+	if !ok {
+		return uuid.UUID{}, errors.New("the custom_domain strategy requires an ExtensibleRepository")
+	}
+
+	tenants, err := repo.FindByMetadata(ctx, MetadataCustomDomain, domain)
+	if err != nil {
+		r.logger.Error("Failed to look up tenant by custom domain",
+			zap.String("custom_domain", domain),
+			zap.Error(err))
+		return uuid.UUID{}, fmt.Errorf("failed to look up tenant for custom domain %s: %w", domain, err)
+	}
+
+	tenantID := uuid.Nil
+	for _, t := range tenants {
+		if t.Status == StatusCancelled {
+			continue
+		}
+		if tenantID != uuid.Nil {
+			r.logger.Warn("Several tenants use the same custom domain, resolving to the first",
+				zap.String("custom_domain", domain),
+				zap.String("tenant_id", tenantID.String()))
+			break
+		}
+		tenantID = t.ID
+	}
+
+	r.domains.put(domain, tenantID)
+	return tenantID, nil
+}
+
+// InvalidateCustomDomain forgets the cached lookups of domain and of the
+// tenant's other custom domains. It implements CustomDomainInvalidator.
+func (r *resolver) InvalidateCustomDomain(tenantID uuid.UUID, domain string) {
+	if r.domains == nil {
+		return
+	}
+	r.domains.forget(tenantID, normalizeDomain(domain))
+}
@@ -0,0 +1,147 @@
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+// countingExtensibleRepository counts custom domain lookups
+type countingExtensibleRepository struct {
+	*mockExtensibleRepository
+	lookups int
+}
+
+func (r *countingExtensibleRepository) FindByMetadata(ctx context.Context, key string, value interface{}) ([]*ExtensibleTenant, error) {
+	r.lookups++
+	return r.mockExtensibleRepository.FindByMetadata(ctx, key, value)
+}
+
+func newCustomDomainTestResolver(t *testing.T) (*resolver, *countingExtensibleRepository, *Tenant, *Tenant) {
+	repo := &countingExtensibleRepository{mockExtensibleRepository: &mockExtensibleRepository{
+		MockManagerRepository: NewMockRepository(),
+		metadata:              make(map[uuid.UUID]TenantMetadata),
+	}}
+
+	enterprise := &Tenant{ID: uuid.New(), Name: "Acme", Subdomain: "acme", PlanType: PlanEnterprise, Status: StatusActive}
+	basic := &Tenant{ID: uuid.New(), Name: "Globex", Subdomain: "globex", PlanType: PlanBasic, Status: StatusActive}
+	repo.tenants[enterprise.ID] = enterprise
+	repo.tenants[basic.ID] = basic
+	repo.metadata[enterprise.ID] = TenantMetadata{MetadataCustomDomain: "app.acme.com"}
+
+	config := ResolverConfig{Strategy: ResolverCustomDomain, Domain: "example.com"}
+	r := NewResolver(config, repo, zaptest.NewLogger(t)).(*resolver)
+	return r, repo, enterprise, basic
+}
+
+func TestResolver_ResolveTenant_CustomDomainAndSubdomain(t *testing.T) {
+	r, repo, enterprise, basic := newCustomDomainTestResolver(t)
+
+	tests := []struct {
+		name        string
+		host        string
+		wantID      uuid.UUID
+		wantLookups int
+	}{
+		{name: "custom domain", host: "app.acme.com", wantID: enterprise.ID, wantLookups: 1},
+		{name: "custom domain with port and mixed case", host: "App.Acme.com:443", wantID: enterprise.ID, wantLookups: 1},
+		{name: "subdomain of the base domain", host: "globex.example.com", wantID: basic.ID, wantLookups: 0},
+		{name: "unknown domain falls back to subdomain", host: "globex.partner.net", wantID: basic.ID, wantLookups: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r.domains = newLookupCache(DefaultCustomDomainCacheTTL, DefaultCustomDomainCacheSize)
+			repo.lookups = 0
+
+			gotID, err := r.ResolveTenant(context.Background(), &http.Request{Host: tt.host})
+			if err != nil {
+				t.Fatalf("ResolveTenant() error = %v", err)
+			}
+			if gotID != tt.wantID {
+				t.Errorf("ResolveTenant() = %v, want %v", gotID, tt.wantID)
+			}
+			if repo.lookups != tt.wantLookups {
+				t.Errorf("custom domain lookups = %d, want %d", repo.lookups, tt.wantLookups)
+			}
+		})
+	}
+}
+
+func TestResolver_ResolveTenant_CustomDomainNotFound(t *testing.T) {
+	r, _, _, _ := newCustomDomainTestResolver(t)
+
+	_, err := r.ResolveTenant(context.Background(), &http.Request{Host: "www.unknown.org"})
+	if !IsNotFound(err) {
+		t.Errorf("ResolveTenant() error = %v, want not found", err)
+	}
+}
+
+func TestResolver_ResolveTenant_CustomDomainIsCached(t *testing.T) {
+	r, repo, enterprise, _ := newCustomDomainTestResolver(t)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		gotID, err := r.ResolveTenant(ctx, &http.Request{Host: "app.acme.com"})
+		if err != nil || gotID != enterprise.ID {
+			t.Fatalf("ResolveTenant() = %v, %v, want %v", gotID, err, enterprise.ID)
+		}
+		if _, err := r.ResolveTenant(ctx, &http.Request{Host: "globex.partner.net"}); err != nil {
+			t.Fatalf("ResolveTenant() error = %v", err)
+		}
+	}
+
+	// One lookup for the custom domain and one for the host that has none
+	if repo.lookups != 2 {
+		t.Errorf("custom domain lookups = %d, want 2", repo.lookups)
+	}
+}
+
+func TestResolver_ResolveTenant_CustomDomainInvalidation(t *testing.T) {
+	r, repo, enterprise, _ := newCustomDomainTestResolver(t)
+	ctx := context.Background()
+	hook := CustomDomainInvalidationHook(r)
+
+	if _, err := r.ResolveTenant(ctx, &http.Request{Host: "app.acme.com"}); err != nil {
+		t.Fatalf("ResolveTenant() error = %v", err)
+	}
+	if _, err := r.ResolveTenant(ctx, &http.Request{Host: "portal.acme.com"}); !IsNotFound(err) {
+		t.Fatalf("ResolveTenant() error = %v, want not found before the domain is set", err)
+	}
+
+	// The tenant moves to a new domain; the repository runs the hook as it stores it
+	if _, err := hook(ctx, enterprise.ID, MetadataCustomDomain, "Portal.Acme.com"); err != nil {
+		t.Fatalf("hook error = %v", err)
+	}
+	repo.metadata[enterprise.ID] = TenantMetadata{MetadataCustomDomain: "portal.acme.com"}
+
+	gotID, err := r.ResolveTenant(ctx, &http.Request{Host: "portal.acme.com"})
+	if err != nil || gotID != enterprise.ID {
+		t.Errorf("ResolveTenant(new domain) = %v, %v, want %v", gotID, err, enterprise.ID)
+	}
+	if _, err := r.ResolveTenant(ctx, &http.Request{Host: "app.acme.com"}); !IsNotFound(err) {
+		t.Errorf("ResolveTenant(old domain) error = %v, want not found", err)
+	}
+}
+
+func TestResolver_ResolveTenant_CustomDomainSkipsCancelledTenants(t *testing.T) {
+	r, repo, enterprise, _ := newCustomDomainTestResolver(t)
+	repo.tenants[enterprise.ID].Status = StatusCancelled
+
+	if _, err := r.ResolveTenant(context.Background(), &http.Request{Host: "app.acme.com"}); !IsNotFound(err) {
+		t.Errorf("ResolveTenant() error = %v, want not found", err)
+	}
+}
+
+func TestResolver_ResolveTenant_CustomDomainRequiresExtensibleRepository(t *testing.T) {
+	config := ResolverConfig{Strategy: ResolverCustomDomain, Domain: "example.com"}
+	r := NewResolver(config, NewMockRepository(), zaptest.NewLogger(t))
+
+	_, err := r.ResolveTenant(context.Background(), &http.Request{Host: "app.acme.com"})
+	if err == nil || IsNotFound(err) {
+		t.Errorf("ResolveTenant() error = %v, want a lookup failure", err)
+	}
+}
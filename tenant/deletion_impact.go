@@ -0,0 +1,119 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// TableStats is the size of one table in a tenant schema
+type TableStats struct {
+	Name  string `json:"name"`
+	Rows  int64  `json:"rows"`
+	Bytes int64  `json:"bytes"` // including indexes and TOAST data
+}
+
+// SchemaInspector is implemented by schema managers that can measure the
+// tables in a tenant schema. DeletionImpact uses it when available.
+type SchemaInspector interface {
+	TableStats(ctx context.Context, tenantID uuid.UUID) ([]TableStats, error)
+}
+
+// DeletionReport describes what deleting a tenant would destroy
+type DeletionReport struct {
+	TenantID     uuid.UUID    `json:"tenant_id"`
+	Status       string       `json:"status"`
+	SchemaName   string       `json:"schema_name"`
+	SchemaExists bool         `json:"schema_exists"`
+	Tables       []TableStats `json:"tables,omitempty"`
+	TotalRows    int64        `json:"total_rows"`
+	StorageBytes int64        `json:"storage_bytes"`
+	Members      int          `json:"members"`                // active users
+	Integrations []string     `json:"integrations,omitempty"` // e.g. "stripe_subscription", "custom_domain"
+	Warnings     []string     `json:"warnings,omitempty"`
+}
+
+// DeletionImpact reports what deleting the tenant would destroy: the tables in
+// its schema with their row counts and sizes, its members and the
+// integrations recorded in its metadata. Nothing is changed. Warnings flag
+// reasons to hold off, such as an active Stripe subscription. Table sizes
+// require a SchemaInspector and integrations an ExtensibleRepository; without
+// them the report says so in a warning.
+func (m *manager) DeletionImpact(ctx context.Context, tenantID uuid.UUID) (*DeletionReport, error) {
+	tenant, err := m.repository.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	schemas, err := m.tenantSchemaManager(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DeletionReport{
+		TenantID:   tenantID,
+		Status:     tenant.Status,
+		SchemaName: schemas.GetSchemaName(tenantID),
+	}
+	if tenant.Status == StatusActive {
+		report.Warnings = append(report.Warnings, "tenant is active")
+	}
+
+	report.SchemaExists, err = schemas.SchemaExists(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check schema existence: %w", err)
+	}
+
+	if report.SchemaExists {
+		if inspector, ok := schemas.(SchemaInspector); ok {
+			report.Tables, err = inspector.TableStats(ctx, tenantID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to measure tenant tables: %w", err)
+			}
+			for _, table := range report.Tables {
+				report.TotalRows += table.Rows
+				report.StorageBytes += table.Bytes
+			}
+		} else {
+			report.Warnings = append(report.Warnings, "schema manager cannot measure tables")
+		}
+
+		stats, err := m.GetStatsFresh(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tenant stats: %w", err)
+		}
+		report.Members = stats.UserCount
+		if report.Members > 0 {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("has %d active members", report.Members))
+		}
+	}
+
+	extRepo, ok := m.repository.(ExtensibleRepository)
+	if !ok {
+		report.Warnings = append(report.Warnings, "repository does not store metadata; integrations unknown")
+		return report, nil
+	}
+
+	metadata, err := extRepo.GetMetadata(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant metadata: %w", err)
+	}
+
+	stripe := NewStripeExtension(metadata)
+	if id, _ := stripe.GetSubscriptionID(); id != "" {
+		report.Integrations = append(report.Integrations, "stripe_subscription")
+		report.Warnings = append(report.Warnings, "has active Stripe subscription")
+	} else if id, _ := stripe.GetCustomerID(); id != "" {
+		report.Integrations = append(report.Integrations, "stripe_customer")
+	}
+	if domain, _ := metadata.GetString(MetadataCustomDomain); domain != "" {
+		report.Integrations = append(report.Integrations, "custom_domain")
+		report.Warnings = append(report.Warnings, fmt.Sprintf("custom domain %s points at the tenant", domain))
+	}
+	if url, _ := metadata.GetString(MetadataWebhookURL); url != "" {
+		report.Integrations = append(report.Integrations, "webhook")
+	}
+
+	return report, nil
+}
@@ -0,0 +1,92 @@
+package tenant
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+// inspectingSchemaManager reports fixed table sizes for provisioned schemas
+type inspectingSchemaManager struct {
+	*MockManagerSchemaManager
+	tables map[uuid.UUID][]TableStats
+}
+
+func (s *inspectingSchemaManager) TableStats(ctx context.Context, tenantID uuid.UUID) ([]TableStats, error) {
+	return s.tables[tenantID], nil
+}
+
+func TestManager_DeletionImpact(t *testing.T) {
+	config := DefaultConfig()
+	repo := &mockExtensibleRepository{MockManagerRepository: NewMockRepository(), metadata: make(map[uuid.UUID]TenantMetadata)}
+	schemas := &inspectingSchemaManager{MockManagerSchemaManager: NewMockSchemaManager(config.Database.SchemaPrefix), tables: make(map[uuid.UUID][]TableStats)}
+	m := NewManager(config, nil, repo, schemas, NewMockMigrationManager(), NewMockLimitChecker(config.Limits), zaptest.NewLogger(t))
+	ctx := context.Background()
+
+	tenantID := uuid.New()
+	repo.tenants[tenantID] = &Tenant{ID: tenantID, Name: "Acme", Subdomain: "acme", PlanType: PlanPro, Status: StatusActive}
+	repo.stats[tenantID] = &Stats{TenantID: tenantID, UserCount: 12}
+	repo.metadata[tenantID] = TenantMetadata{
+		MetadataStripeCustomerID:     "cus_123",
+		MetadataStripeSubscriptionID: "sub_456",
+		MetadataCustomDomain:         "app.acme.com",
+	}
+	schemas.schemas[tenantID] = true
+	schemas.tables[tenantID] = []TableStats{
+		{Name: "projects", Rows: 40, Bytes: 81920},
+		{Name: "tasks", Rows: 300, Bytes: 262144},
+		{Name: "tenant_users", Rows: 12, Bytes: 16384},
+	}
+
+	report, err := m.DeletionImpact(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("DeletionImpact() error = %v", err)
+	}
+
+	if !report.SchemaExists || len(report.Tables) != 3 || report.TotalRows != 352 || report.StorageBytes != 360448 {
+		t.Errorf("report = %+v, want 3 tables, 352 rows and 360448 bytes", report)
+	}
+	if report.Members != 12 {
+		t.Errorf("Members = %d, want 12", report.Members)
+	}
+	if !slices.Equal(report.Integrations, []string{"stripe_subscription", "custom_domain"}) {
+		t.Errorf("Integrations = %v, want the Stripe subscription and custom domain", report.Integrations)
+	}
+	for _, want := range []string{"tenant is active", "has 12 active members", "has active Stripe subscription", "custom domain app.acme.com points at the tenant"} {
+		if !slices.Contains(report.Warnings, want) {
+			t.Errorf("Warnings = %v, want %q", report.Warnings, want)
+		}
+	}
+
+	// Nothing was deleted
+	if _, err := repo.GetByID(ctx, tenantID); err != nil || !schemas.schemas[tenantID] {
+		t.Error("DeletionImpact() should not delete the tenant or its schema")
+	}
+}
+
+func TestManager_DeletionImpact_Unprovisioned(t *testing.T) {
+	config := DefaultConfig()
+	repo := NewMockRepository()
+	m := NewManager(config, nil, repo, NewMockSchemaManager(config.Database.SchemaPrefix), NewMockMigrationManager(), NewMockLimitChecker(config.Limits), zaptest.NewLogger(t))
+
+	tenantID := uuid.New()
+	repo.tenants[tenantID] = &Tenant{ID: tenantID, Name: "Acme", Subdomain: "acme", Status: StatusCancelled}
+
+	report, err := m.DeletionImpact(context.Background(), tenantID)
+	if err != nil {
+		t.Fatalf("DeletionImpact() error = %v", err)
+	}
+	if report.SchemaExists || report.TotalRows != 0 || report.Members != 0 {
+		t.Errorf("report = %+v, want an empty report for a tenant without a schema", report)
+	}
+	if !slices.Equal(report.Warnings, []string{"repository does not store metadata; integrations unknown"}) {
+		t.Errorf("Warnings = %v, want only the missing metadata warning", report.Warnings)
+	}
+
+	if _, err := m.DeletionImpact(context.Background(), uuid.New()); err == nil {
+		t.Error("DeletionImpact() should fail for an unknown tenant")
+	}
+}
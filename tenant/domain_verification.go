@@ -0,0 +1,174 @@
+package tenant
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Metadata keys used by CustomDomainVerifier
+const (
+	MetadataCustomDomainPending    = "custom_domain_pending"
+	MetadataCustomDomainToken      = "custom_domain_verification_token"
+	MetadataCustomDomainVerified   = "custom_domain_verified"
+	MetadataCustomDomainVerifiedAt = "custom_domain_verified_at"
+)
+
+// DomainVerificationRecordPrefix is prepended to a domain to form the name of
+// the TXT record holding its verification token
+const DomainVerificationRecordPrefix = "_tenant-verification."
+
+// ErrDomainNotVerified is returned, wrapped, when a domain's TXT records do not
+// contain the expected verification token
+var ErrDomainNotVerified = errors.New("domain not verified")
+
+// TXTResolver looks up DNS TXT records. *net.Resolver implements it.
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// CustomDomainVerifier verifies tenant ownership of custom domains through DNS
+// TXT records. A tenant requests a domain with GenerateVerificationToken, which
+// stores the domain as pending; once the token is published at
+// RecordName(domain), VerifyDomain promotes it to the tenant's custom_domain.
+type CustomDomainVerifier struct {
+	repository ExtensibleRepository
+	resolver   TXTResolver
+	logger     *zap.Logger
+	now        func() time.Time
+}
+
+// NewCustomDomainVerifier creates a new custom domain verifier. A nil resolver
+// uses net.DefaultResolver.
+func NewCustomDomainVerifier(repository ExtensibleRepository, resolver TXTResolver, logger *zap.Logger) *CustomDomainVerifier {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	return &CustomDomainVerifier{
+		repository: repository,
+		resolver:   resolver,
+		logger:     logger.Named("domain_verifier"),
+		now:        time.Now,
+	}
+}
+
+// RecordName returns the name of the TXT record that must hold the
+// verification token for domain
+func (v *CustomDomainVerifier) RecordName(domain string) string {
+	return DomainVerificationRecordPrefix + normalizeDomain(domain)
+}
+
+// GenerateVerificationToken creates a verification token for the tenant's
+// request to use domain and stores both in the tenant's metadata. Generating a
+// new token replaces any pending request.
+func (v *CustomDomainVerifier) GenerateVerificationToken(ctx context.Context, tenantID uuid.UUID, domain string) (string, error) {
+	domain = normalizeDomain(domain)
+	if domain == "" {
+		return "", &ValidationError{Field: "domain", Message: "domain is required"}
+	}
+
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+
+	metadata, err := v.repository.GetMetadata(ctx, tenantID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get tenant metadata: %w", err)
+	}
+	if metadata == nil {
+		metadata = make(TenantMetadata)
+	}
+
+	metadata.SetString(MetadataCustomDomainPending, domain)
+	metadata.SetString(MetadataCustomDomainToken, token)
+	if err := v.repository.UpdateMetadata(ctx, tenantID, metadata); err != nil {
+		return "", fmt.Errorf("failed to store verification token: %w", err)
+	}
+
+	v.logger.Info("Generated custom domain verification token",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("domain", domain))
+
+	return token, nil
+}
+
+// VerifyDomain looks up the TXT records for a pending domain and, if one holds
+// the requesting tenant's token, sets the domain as the tenant's verified
+// custom_domain. It returns an error wrapping ErrDomainNotVerified if no
+// record matches and ErrTenantNotFound if no tenant requested the domain.
+func (v *CustomDomainVerifier) VerifyDomain(ctx context.Context, domain string) error {
+	domain = normalizeDomain(domain)
+
+	candidates, err := v.repository.FindByMetadata(ctx, MetadataCustomDomainPending, domain)
+	if err != nil {
+		return fmt.Errorf("failed to find pending domain: %w", err)
+	}
+	if len(candidates) == 0 {
+		return fmt.Errorf("%w: no pending verification for domain %s", ErrTenantNotFound, domain)
+	}
+
+	records, err := v.resolver.LookupTXT(ctx, v.RecordName(domain))
+	if err != nil {
+		var dnsErr *net.DNSError
+		if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+			return fmt.Errorf("failed to look up TXT records for %s: %w", domain, err)
+		}
+		records = nil
+	}
+
+	for _, candidate := range candidates {
+		token, ok := candidate.Metadata.GetString(MetadataCustomDomainToken)
+		if !ok || !containsRecord(records, token) {
+			continue
+		}
+
+		metadata := make(TenantMetadata, len(candidate.Metadata)+2)
+		for key, value := range candidate.Metadata {
+			metadata[key] = value
+		}
+		metadata.SetString(MetadataCustomDomain, domain)
+		metadata.SetBool(MetadataCustomDomainVerified, true)
+		metadata.SetString(MetadataCustomDomainVerifiedAt, v.now().UTC().Format(time.RFC3339))
+		metadata.Remove(MetadataCustomDomainPending)
+		metadata.Remove(MetadataCustomDomainToken)
+
+		if err := v.repository.UpdateMetadata(ctx, candidate.ID, metadata); err != nil {
+			return fmt.Errorf("failed to mark domain verified: %w", err)
+		}
+
+		v.logger.Info("Verified custom domain",
+			zap.String("tenant_id", candidate.ID.String()),
+			zap.String("domain", domain))
+
+		return nil
+	}
+
+	return fmt.Errorf("%w: no TXT record at %s matches the verification token", ErrDomainNotVerified, v.RecordName(domain))
+}
+
+// normalizeDomain lowercases a domain and strips surrounding space and any
+// trailing dot
+func normalizeDomain(domain string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+}
+
+// containsRecord reports whether records contains token
+func containsRecord(records []string, token string) bool {
+	for _, record := range records {
+		if strings.TrimSpace(record) == token {
+			return true
+		}
+	}
+	return false
+}
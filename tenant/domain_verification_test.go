@@ -0,0 +1,129 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestCustomDomainVerifier_VerifyDomain(t *testing.T) {
+	repo, resolver := newDomainVerifierTestDeps()
+	verifier := NewCustomDomainVerifier(repo, resolver, zaptest.NewLogger(t))
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	token, err := verifier.GenerateVerificationToken(ctx, tenantID, "App.Acme.com.")
+	if err != nil {
+		t.Fatalf("GenerateVerificationToken() error = %v", err)
+	}
+	if token == "" {
+		t.Fatal("GenerateVerificationToken() returned an empty token")
+	}
+	if pending, _ := repo.metadata[tenantID].GetString(MetadataCustomDomainPending); pending != "app.acme.com" {
+		t.Errorf("pending domain = %q, want normalized app.acme.com", pending)
+	}
+	if _, routed := repo.metadata[tenantID].GetString(MetadataCustomDomain); routed {
+		t.Error("custom_domain should not be set before verification")
+	}
+
+	// The record is published alongside unrelated TXT values
+	resolver.records["_tenant-verification.app.acme.com"] = []string{"v=spf1 -all", token}
+
+	if err := verifier.VerifyDomain(ctx, "app.acme.com"); err != nil {
+		t.Fatalf("VerifyDomain() error = %v", err)
+	}
+
+	metadata := repo.metadata[tenantID]
+	if domain, _ := metadata.GetString(MetadataCustomDomain); domain != "app.acme.com" {
+		t.Errorf("custom_domain = %q, want app.acme.com", domain)
+	}
+	if verified, _ := metadata.GetBool(MetadataCustomDomainVerified); !verified {
+		t.Error("custom_domain_verified should be true")
+	}
+	if !metadata.Has(MetadataCustomDomainVerifiedAt) {
+		t.Error("custom_domain_verified_at should be set")
+	}
+	if metadata.Has(MetadataCustomDomainPending) || metadata.Has(MetadataCustomDomainToken) {
+		t.Error("pending domain and token should be removed after verification")
+	}
+}
+
+func TestCustomDomainVerifier_VerifyDomain_Mismatch(t *testing.T) {
+	repo, resolver := newDomainVerifierTestDeps()
+	verifier := NewCustomDomainVerifier(repo, resolver, zaptest.NewLogger(t))
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	if _, err := verifier.GenerateVerificationToken(ctx, tenantID, "app.acme.com"); err != nil {
+		t.Fatalf("GenerateVerificationToken() error = %v", err)
+	}
+
+	// Missing record
+	if err := verifier.VerifyDomain(ctx, "app.acme.com"); !errors.Is(err, ErrDomainNotVerified) {
+		t.Errorf("VerifyDomain() error = %v, want ErrDomainNotVerified without a record", err)
+	}
+
+	// Record with someone else's token
+	resolver.records["_tenant-verification.app.acme.com"] = []string{"0123456789abcdef"}
+	if err := verifier.VerifyDomain(ctx, "app.acme.com"); !errors.Is(err, ErrDomainNotVerified) {
+		t.Errorf("VerifyDomain() error = %v, want ErrDomainNotVerified for a mismatching token", err)
+	}
+
+	if repo.metadata[tenantID].Has(MetadataCustomDomain) {
+		t.Error("custom_domain should not be set when verification fails")
+	}
+}
+
+func TestCustomDomainVerifier_VerifyDomain_Errors(t *testing.T) {
+	repo, resolver := newDomainVerifierTestDeps()
+	verifier := NewCustomDomainVerifier(repo, resolver, zaptest.NewLogger(t))
+	ctx := context.Background()
+
+	if err := verifier.VerifyDomain(ctx, "unknown.example.com"); !errors.Is(err, ErrTenantNotFound) {
+		t.Errorf("VerifyDomain() error = %v, want ErrTenantNotFound for a domain nobody requested", err)
+	}
+
+	if _, err := verifier.GenerateVerificationToken(ctx, uuid.New(), "app.acme.com"); err != nil {
+		t.Fatalf("GenerateVerificationToken() error = %v", err)
+	}
+
+	// DNS failures other than a missing record are reported as such
+	resolver.err = &net.DNSError{Err: "server misbehaving", Name: "_tenant-verification.app.acme.com", IsTemporary: true}
+	err := verifier.VerifyDomain(ctx, "app.acme.com")
+	if err == nil || errors.Is(err, ErrDomainNotVerified) {
+		t.Errorf("VerifyDomain() error = %v, want the lookup failure", err)
+	}
+
+	if _, err := verifier.GenerateVerificationToken(ctx, uuid.New(), "  "); err == nil {
+		t.Error("GenerateVerificationToken() should reject an empty domain")
+	}
+}
+
+// fakeTXTResolver serves TXT records from a map
+type fakeTXTResolver struct {
+	records map[string][]string
+	err     error
+}
+
+func (r *fakeTXTResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	records, ok := r.records[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return records, nil
+}
+
+func newDomainVerifierTestDeps() (*mockExtensibleRepository, *fakeTXTResolver) {
+	repo := &mockExtensibleRepository{
+		MockManagerRepository: NewMockRepository(),
+		metadata:              make(map[uuid.UUID]TenantMetadata),
+	}
+	return repo, &fakeTXTResolver{records: make(map[string][]string)}
+}
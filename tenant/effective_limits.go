@@ -0,0 +1,34 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// GetTenantLimits returns the limits enforced for the tenant: its plan limits,
+// with any limits from the LimitChecker's OverrideProvider replacing the plan
+// limits of the same name. The result is a copy the caller may modify. A
+// tenant that does not exist is reported as a TenantError with code NOT_FOUND.
+func (m *manager) GetTenantLimits(ctx context.Context, tenantID uuid.UUID) (FlexibleLimits, error) {
+	if _, err := m.cachedTenant(ctx, tenantID); err != nil {
+		var tenantErr *TenantError
+		if IsNotFound(err) || (errors.As(err, &tenantErr) && tenantErr.Code == "NOT_FOUND") {
+			return nil, &TenantError{TenantID: tenantID, Code: "NOT_FOUND", Message: "tenant not found"}
+		}
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	effective, err := m.limitChecker.GetLimitsForTenant(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant limits: %w", err)
+	}
+
+	limits := make(FlexibleLimits, len(effective))
+	for name, limit := range effective {
+		limits[name] = &LimitValue{Type: limit.Type, Value: limit.Value}
+	}
+	return limits, nil
+}
@@ -0,0 +1,75 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestManager_GetTenantLimits(t *testing.T) {
+	config := DefaultConfig()
+	basicLimits := make(FlexibleLimits)
+	basicLimits.Set(LimitNameMaxUsers, LimitTypeInt, 5)
+	basicLimits.Set(LimitNameMaxProjects, LimitTypeInt, 10)
+	basicLimits.Set("api_access", LimitTypeBool, false)
+	config.Limits.PlanLimits = map[string]FlexibleLimits{PlanBasic: basicLimits}
+
+	logger := zaptest.NewLogger(t)
+	repo := NewMockRepository()
+	limitChecker := NewLimitChecker(config.Limits, repo, logger)
+	m := NewManager(config, nil, repo, NewMockSchemaManager(config.Database.SchemaPrefix),
+		NewMockMigrationManager(), limitChecker, logger)
+	t.Cleanup(func() { m.Close() })
+	ctx := context.Background()
+
+	plain := &Tenant{Name: "Plain", Subdomain: "plain", PlanType: PlanBasic}
+	vip := &Tenant{Name: "VIP", Subdomain: "vip", PlanType: PlanBasic}
+	for _, tenant := range []*Tenant{plain, vip} {
+		if err := m.CreateTenant(ctx, tenant); err != nil {
+			t.Fatalf("CreateTenant() error = %v", err)
+		}
+	}
+
+	overrides := make(FlexibleLimits)
+	overrides.Set(LimitNameMaxUsers, LimitTypeInt, 50)
+	limitChecker.SetOverrideProvider(overrideProviderFunc(func(id uuid.UUID) (FlexibleLimits, error) {
+		if id == vip.ID {
+			return overrides, nil
+		}
+		return nil, nil
+	}))
+
+	// Plan limits only
+	limits, err := m.GetTenantLimits(ctx, plain.ID)
+	if err != nil {
+		t.Fatalf("GetTenantLimits() error = %v", err)
+	}
+	if users, _ := limits[LimitNameMaxUsers].Int(); users != 5 || len(limits) != 3 {
+		t.Errorf("GetTenantLimits() = %v, want the basic plan limits", limits)
+	}
+
+	// Overrides shadow the plan limit of the same name only
+	limits, err = m.GetTenantLimits(ctx, vip.ID)
+	if err != nil {
+		t.Fatalf("GetTenantLimits() error = %v", err)
+	}
+	users, _ := limits[LimitNameMaxUsers].Int()
+	projects, _ := limits[LimitNameMaxProjects].Int()
+	if users != 50 || projects != 10 || len(limits) != 3 {
+		t.Errorf("GetTenantLimits() = %v, want max_users overridden to 50 and the rest from the plan", limits)
+	}
+
+	// The result is a copy
+	limits.Set(LimitNameMaxProjects, LimitTypeInt, 1000)
+	if projects, _ := limitChecker.GetLimitsForPlan(PlanBasic)[LimitNameMaxProjects].Int(); projects != 10 {
+		t.Errorf("plan max_projects = %d after modifying the result, want 10", projects)
+	}
+
+	var tenantErr *TenantError
+	if _, err := m.GetTenantLimits(ctx, uuid.New()); !errors.As(err, &tenantErr) || tenantErr.Code != "NOT_FOUND" {
+		t.Errorf("GetTenantLimits() error = %v for an unknown tenant, want a NOT_FOUND TenantError", err)
+	}
+}
@@ -0,0 +1,83 @@
+package tenant
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Event types
+const (
+	EventLimitThresholdReached = "limit.threshold_reached"
+
+	// Tenant lifecycle events, published by the manager once the change is stored
+	EventTenantCreated     = "tenant.created"
+	EventTenantProvisioned = "tenant.provisioned"
+	EventTenantSuspended   = "tenant.suspended"
+	EventTenantDeleted     = "tenant.deleted"
+	EventTenantPurged      = "tenant.purged"       // the record and schema are gone for good
+	EventTenantPlanChanged = "tenant.plan_changed" // Data holds old_plan, new_plan and actor
+)
+
+// Event is a notification about a tenant, delivered to an EventPublisher
+type Event struct {
+	Type      string                 `json:"event"`
+	TenantID  uuid.UUID              `json:"tenant_id"`
+	Timestamp time.Time              `json:"timestamp"`
+	PlanType  string                 `json:"plan_type,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// EventPublisher delivers events to downstream systems such as webhooks or a
+// message bus. Publish is called on the path that raised the event, so slow
+// publishers should hand events off rather than deliver them inline.
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// EventPublisherFunc adapts a function to the EventPublisher interface
+type EventPublisherFunc func(ctx context.Context, event Event) error
+
+// Publish calls f(ctx, event)
+func (f EventPublisherFunc) Publish(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// SetEventPublisher publishes tenant lifecycle events to publisher, and passes
+// it to the limit checker for limit.threshold_reached events. Pass nil to stop
+// publishing.
+func (m *manager) SetEventPublisher(publisher EventPublisher) {
+	m.publisherMu.Lock()
+	m.publisher = publisher
+	m.publisherMu.Unlock()
+
+	m.limitChecker.SetEventPublisher(publisher)
+}
+
+// publishEvent publishes a lifecycle event for the tenant to the publisher set
+// by SetEventPublisher, if any, with optional event data. The change the event
+// reports has already been made, so a failed publish is only logged.
+func (m *manager) publishEvent(ctx context.Context, eventType string, tenant *Tenant, data map[string]interface{}) {
+	m.publisherMu.RLock()
+	publisher := m.publisher
+	m.publisherMu.RUnlock()
+	if publisher == nil {
+		return
+	}
+
+	event := Event{
+		Type:      eventType,
+		TenantID:  tenant.ID,
+		Timestamp: time.Now().UTC(),
+		PlanType:  tenant.PlanType,
+		Data:      data,
+	}
+	if err := publisher.Publish(ctx, event); err != nil {
+		m.logger.Warn("Failed to publish tenant event",
+			zap.String("tenant_id", tenant.ID.String()),
+			zap.String("event", eventType),
+			zap.Error(err))
+	}
+}
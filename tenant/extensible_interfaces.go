@@ -24,6 +24,11 @@ type ExtensibleRepository interface {
 	UpdateMetadataField(ctx context.Context, tenantID uuid.UUID, key string, value interface{}) error
 	RemoveMetadataField(ctx context.Context, tenantID uuid.UUID, key string) error
 
+	// Per-tenant limit overrides, stored under MetadataLimitOverridesKey. A nil
+	// value removes the override.
+	SetTenantLimitOverride(ctx context.Context, tenantID uuid.UUID, limitName string, value *LimitValue) error
+	GetTenantLimitOverrides(ctx context.Context, tenantID uuid.UUID) (FlexibleLimits, error)
+
 	// Query by metadata
 	FindByMetadata(ctx context.Context, key string, value interface{}) ([]*ExtensibleTenant, error)
 	FindByMetadataKeys(ctx context.Context, keys []string) ([]*ExtensibleTenant, error)
@@ -40,6 +45,7 @@ type ExtensibleManager interface {
 	GetExtendedTenantBySubdomain(ctx context.Context, subdomain string) (*ExtensibleTenant, error)
 	UpdateExtendedTenant(ctx context.Context, tenant *ExtensibleTenant) error
 	ListExtendedTenants(ctx context.Context, page, perPage int) ([]*ExtensibleTenant, int, error)
+	ListExtendedTenantsPaged(ctx context.Context, page, perPage int) (*Page[*ExtensibleTenant], error)
 
 	// Metadata operations
 	UpdateTenantMetadata(ctx context.Context, tenantID uuid.UUID, metadata TenantMetadata) error
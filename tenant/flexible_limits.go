@@ -3,6 +3,8 @@ package tenant
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -90,6 +92,8 @@ func (lv *LimitValue) Duration() (time.Duration, error) {
 	switch v := lv.Value.(type) {
 	case string:
 		return time.ParseDuration(v)
+	case time.Duration:
+		return v, nil
 	case int64:
 		return time.Duration(v), nil
 	case float64:
@@ -99,7 +103,8 @@ func (lv *LimitValue) Duration() (time.Duration, error) {
 	}
 }
 
-// IsUnlimited checks if the limit represents unlimited (-1 for ints, special values for others)
+// IsUnlimited checks if the limit represents unlimited (-1 for ints, negative
+// floats and durations, "unlimited" or empty strings, and empty durations)
 func (lv *LimitValue) IsUnlimited() bool {
 	switch lv.Type {
 	case LimitTypeInt:
@@ -114,6 +119,13 @@ func (lv *LimitValue) IsUnlimited() bool {
 		if val, err := lv.String(); err == nil && (val == "unlimited" || val == "") {
 			return true
 		}
+	case LimitTypeDuration:
+		if s, ok := lv.Value.(string); ok && s == "" {
+			return true
+		}
+		if val, err := lv.Duration(); err == nil && val < 0 {
+			return true
+		}
 	}
 	return false
 }
@@ -209,6 +221,23 @@ func (fl FlexibleLimits) Keys() []string {
 	return keys
 }
 
+// Clone returns a copy of the limits that shares no LimitValue with them
+func (fl FlexibleLimits) Clone() FlexibleLimits {
+	if fl == nil {
+		return nil
+	}
+	clone := make(FlexibleLimits, len(fl))
+	for name, limit := range fl {
+		if limit == nil {
+			clone[name] = nil
+			continue
+		}
+		copied := *limit
+		clone[name] = &copied
+	}
+	return clone
+}
+
 // Len returns the number of limits
 func (fl FlexibleLimits) Len() int {
 	return len(fl)
@@ -228,8 +257,146 @@ type LimitDefinition struct {
 	Tags         []string    `json:"tags"`
 }
 
-// LimitSchema defines available limit types for a system
+// Validate checks that the definition is well-formed and that its default,
+// min and max values are consistent with the declared type
+func (ld *LimitDefinition) Validate() error {
+	if ld.Name == "" {
+		return &ValidationError{Field: "name", Message: "limit name is required"}
+	}
+
+	if !ValidateLimitType(ld.Type) {
+		return &ValidationError{Field: "type", Message: fmt.Sprintf("invalid limit type: %s", ld.Type)}
+	}
+
+	values := []struct {
+		field string
+		value *LimitValue
+	}{
+		{"default_value", ld.DefaultValue},
+		{"min_value", ld.MinValue},
+		{"max_value", ld.MaxValue},
+	}
+
+	for _, v := range values {
+		if v.value == nil {
+			continue
+		}
+		if v.value.Type != ld.Type {
+			return &ValidationError{
+				Field:   v.field,
+				Message: fmt.Sprintf("%s for '%s' has type %s, expected %s", v.field, ld.Name, v.value.Type, ld.Type),
+			}
+		}
+		if err := v.value.check(); err != nil {
+			return &ValidationError{
+				Field:   v.field,
+				Message: fmt.Sprintf("%s for '%s' is invalid: %v", v.field, ld.Name, err),
+			}
+		}
+	}
+
+	return nil
+}
+
+// CheckRange returns a ValidationError if value is not of the definition's
+// type or lies outside its MinValue and MaxValue. Unlimited values are exempt
+// from the range. Only int, float and duration limits have a range.
+func (ld *LimitDefinition) CheckRange(value *LimitValue) error {
+	if value == nil {
+		return nil
+	}
+	if value.Type != ld.Type {
+		return &ValidationError{
+			Field:   "type",
+			Message: fmt.Sprintf("limit '%s' has type %s, expected %s", ld.Name, value.Type, ld.Type),
+		}
+	}
+	if value.IsUnlimited() {
+		return nil
+	}
+	if err := value.check(); err != nil {
+		return &ValidationError{
+			Field:   "value",
+			Message: fmt.Sprintf("limit '%s' is invalid: %v", ld.Name, err),
+		}
+	}
+	current, ok := value.number()
+	if !ok {
+		return nil
+	}
+
+	if ld.MinValue != nil {
+		if min, ok := ld.MinValue.number(); ok && current < min {
+			return &ValidationError{
+				Field:   "value",
+				Message: fmt.Sprintf("limit '%s' must be at least %v, got %v", ld.Name, ld.MinValue.Value, value.Value),
+			}
+		}
+	}
+	if ld.MaxValue != nil {
+		if max, ok := ld.MaxValue.number(); ok && current > max {
+			return &ValidationError{
+				Field:   "value",
+				Message: fmt.Sprintf("limit '%s' must be at most %v, got %v", ld.Name, ld.MaxValue.Value, value.Value),
+			}
+		}
+	}
+
+	return nil
+}
+
+// number returns an int, float or duration limit as a float64 for range
+// comparisons
+func (lv *LimitValue) number() (float64, bool) {
+	switch lv.Type {
+	case LimitTypeInt:
+		v, err := lv.Int()
+		return float64(v), err == nil
+	case LimitTypeFloat:
+		v, err := lv.Float()
+		return v, err == nil
+	case LimitTypeDuration:
+		v, err := lv.Duration()
+		return float64(v), err == nil
+	default:
+		return 0, false
+	}
+}
+
+// check verifies that the underlying value can be read as the declared type
+func (lv *LimitValue) check() error {
+	var err error
+	switch lv.Type {
+	case LimitTypeInt:
+		_, err = lv.Int()
+	case LimitTypeFloat:
+		_, err = lv.Float()
+	case LimitTypeString:
+		_, err = lv.String()
+	case LimitTypeBool:
+		_, err = lv.Bool()
+	case LimitTypeDuration:
+		_, err = lv.Duration()
+	default:
+		err = fmt.Errorf("unknown limit type: %s", lv.Type)
+	}
+	return err
+}
+
+// ValidateLimitType validates a limit type
+func ValidateLimitType(limitType LimitType) bool {
+	switch limitType {
+	case LimitTypeInt, LimitTypeFloat, LimitTypeString, LimitTypeBool, LimitTypeDuration:
+		return true
+	default:
+		return false
+	}
+}
+
+// LimitSchema defines available limit types for a system.
+// Its methods are safe for concurrent use; direct access to Definitions is not.
 type LimitSchema struct {
+	mu          sync.RWMutex
 	Definitions map[string]*LimitDefinition `json:"definitions"`
 }
 
@@ -242,22 +409,115 @@ func NewLimitSchema() *LimitSchema {
 
 // AddDefinition adds a limit definition to the schema
 func (ls *LimitSchema) AddDefinition(def *LimitDefinition) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if ls.Definitions == nil {
+		ls.Definitions = make(map[string]*LimitDefinition)
+	}
 	ls.Definitions[def.Name] = def
 }
 
+// RemoveDefinition removes a limit definition from the schema
+func (ls *LimitSchema) RemoveDefinition(name string) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	delete(ls.Definitions, name)
+}
+
 // GetDefinition gets a limit definition by name
 func (ls *LimitSchema) GetDefinition(name string) (*LimitDefinition, bool) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
 	def, exists := ls.Definitions[name]
 	return def, exists
 }
 
-// GetAllDefinitions returns all limit definitions
+// GetAllDefinitions returns a copy of the schema's definitions by name
 func (ls *LimitSchema) GetAllDefinitions() map[string]*LimitDefinition {
-	return ls.Definitions
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	definitions := make(map[string]*LimitDefinition, len(ls.Definitions))
+	for name, def := range ls.Definitions {
+		definitions[name] = def
+	}
+	return definitions
+}
+
+// LimitDescription is a serializable view of a limit definition, suitable for
+// returning directly from admin/schema endpoints
+type LimitDescription struct {
+	Name        string      `json:"name"`
+	DisplayName string      `json:"display_name"`
+	Description string      `json:"description"`
+	Type        LimitType   `json:"type"`
+	Category    string      `json:"category"`
+	Default     interface{} `json:"default,omitempty"`
+	Min         interface{} `json:"min,omitempty"`
+	Max         interface{} `json:"max,omitempty"`
+	Required    bool        `json:"required"`
+	Tags        []string    `json:"tags"`
+}
+
+// Describe returns a description of every definition in the schema,
+// ordered by category and then by name
+func (ls *LimitSchema) Describe() []LimitDescription {
+	if ls == nil {
+		return []LimitDescription{}
+	}
+
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	descriptions := make([]LimitDescription, 0, len(ls.Definitions))
+	for _, def := range ls.Definitions {
+		desc := LimitDescription{
+			Name:        def.Name,
+			DisplayName: def.DisplayName,
+			Description: def.Description,
+			Type:        def.Type,
+			Category:    def.Category,
+			Required:    def.Required,
+			Tags:        def.Tags,
+		}
+		desc.Default = describeValue(def.DefaultValue)
+		desc.Min = describeValue(def.MinValue)
+		desc.Max = describeValue(def.MaxValue)
+		if desc.Tags == nil {
+			desc.Tags = []string{}
+		}
+		descriptions = append(descriptions, desc)
+	}
+
+	sort.Slice(descriptions, func(i, j int) bool {
+		if descriptions[i].Category != descriptions[j].Category {
+			return descriptions[i].Category < descriptions[j].Category
+		}
+		return descriptions[i].Name < descriptions[j].Name
+	})
+
+	return descriptions
+}
+
+// describeValue returns the value of a LimitDescription field, with durations
+// written as duration strings as LimitValue.MarshalJSON writes them
+func describeValue(lv *LimitValue) interface{} {
+	if lv == nil {
+		return nil
+	}
+	if lv.Type == LimitTypeDuration && lv.Value != nil {
+		if d, err := lv.Duration(); err == nil {
+			return d.String()
+		}
+	}
+	return lv.Value
 }
 
 // ValidateLimits validates a set of limits against the schema
 func (ls *LimitSchema) ValidateLimits(limits FlexibleLimits) error {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
 	// Check required limits
 	for name, def := range ls.Definitions {
 		if def.Required {
@@ -287,13 +547,16 @@ func (ls *LimitSchema) ValidateLimits(limits FlexibleLimits) error {
 
 // CreateDefaultLimits creates a FlexibleLimits with default values from schema
 func (ls *LimitSchema) CreateDefaultLimits() FlexibleLimits {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
 	limits := make(FlexibleLimits)
 
 	for name, def := range ls.Definitions {
 		if def.DefaultValue != nil {
 			limits[name] = &LimitValue{
 				Type:  def.Type,
-				Value: def.DefaultValue,
+				Value: def.DefaultValue.Value,
 			}
 		}
 	}
@@ -308,6 +571,7 @@ type FlexibleLimitChecker interface {
 	// Dynamic limit checking
 	CheckLimit(ctx context.Context, tenantID uuid.UUID, limitName string, currentValue interface{}) error
 	CheckLimitByDefinition(ctx context.Context, tenantID uuid.UUID, def *LimitDefinition, currentValue interface{}) error
+	CheckLimitAndConsume(ctx context.Context, tenantID uuid.UUID, limitName string, amount int) (remaining int, err error)
 
 	// Schema management
 	GetLimitSchema() *LimitSchema
@@ -331,6 +595,10 @@ const (
 	LimitNameMaxUsers     = "max_users"
 	LimitNameMaxProjects  = "max_projects"
 	LimitNameMaxStorageGB = "max_storage_gb"
+
+	// LimitNameAPIRatePerMinute is the requests per minute allowed by the
+	// Gin RateLimit middleware
+	LimitNameAPIRatePerMinute = "api_rate_per_minute"
 )
 
 // DefaultLimitSchema returns a comprehensive schema with common limits
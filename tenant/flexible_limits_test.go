@@ -1,6 +1,7 @@
 package tenant
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 )
@@ -607,3 +608,61 @@ func TestDefaultLimitSchema(t *testing.T) {
 		t.Error("DefaultLimitSchema() should have max_projects definition")
 	}
 }
+
+func TestLimitSchema_Describe(t *testing.T) {
+	schema := DefaultLimitSchema()
+	descriptions := schema.Describe()
+
+	if len(descriptions) != len(schema.GetAllDefinitions()) {
+		t.Fatalf("Describe() returned %d descriptions, want %d", len(descriptions), len(schema.GetAllDefinitions()))
+	}
+
+	for i, desc := range descriptions {
+		if desc.Name == "" {
+			t.Errorf("Describe()[%d] has empty name", i)
+		}
+		if desc.DisplayName == "" {
+			t.Errorf("Describe() %s has empty display name", desc.Name)
+		}
+		if desc.Description == "" {
+			t.Errorf("Describe() %s has empty description", desc.Name)
+		}
+		if desc.Type == "" {
+			t.Errorf("Describe() %s has empty type", desc.Name)
+		}
+		if desc.Category == "" {
+			t.Errorf("Describe() %s has empty category", desc.Name)
+		}
+		if desc.Default == nil {
+			t.Errorf("Describe() %s has no default", desc.Name)
+		}
+		if desc.Tags == nil {
+			t.Errorf("Describe() %s has nil tags, want empty slice", desc.Name)
+		}
+
+		if i > 0 {
+			prev := descriptions[i-1]
+			if prev.Category > desc.Category || (prev.Category == desc.Category && prev.Name > desc.Name) {
+				t.Errorf("Describe() not ordered: %s/%s before %s/%s", prev.Category, prev.Name, desc.Category, desc.Name)
+			}
+		}
+	}
+
+	// Defaults should be the raw values, not nested LimitValues
+	for _, desc := range descriptions {
+		if desc.Name == "max_users" && desc.Default != 5 {
+			t.Errorf("Describe() max_users default = %v, want 5", desc.Default)
+		}
+	}
+
+	if _, err := json.Marshal(descriptions); err != nil {
+		t.Errorf("Describe() output should be JSON serializable: %v", err)
+	}
+}
+
+func TestLimitSchema_Describe_Nil(t *testing.T) {
+	var schema *LimitSchema
+	if descriptions := schema.Describe(); descriptions == nil || len(descriptions) != 0 {
+		t.Errorf("Describe() on nil schema = %v, want empty slice", descriptions)
+	}
+}
@@ -0,0 +1,141 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// TenantFilter selects the tenants ForEachTenant visits
+type TenantFilter func(t *Tenant) bool
+
+// OnPlans returns a filter selecting tenants on any of the plans
+func OnPlans(plans ...string) TenantFilter {
+	return func(t *Tenant) bool {
+		for _, plan := range plans {
+			if t.PlanType == plan {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// TenantFailure is a tenant a per-tenant operation, such as ForEachTenant's
+// function or a migration, failed for
+type TenantFailure struct {
+	TenantID uuid.UUID
+	Err      error
+}
+
+// TenantFailures lists the tenants a per-tenant operation failed for. The
+// errors reporting them, such as ForEachTenantError and
+// database.MigrationFailedError, build on it.
+type TenantFailures []TenantFailure
+
+// String lists the failed tenants with their errors
+func (f TenantFailures) String() string {
+	failures := make([]string, len(f))
+	for i, failure := range f {
+		failures[i] = fmt.Sprintf("%s: %v", failure.TenantID, failure.Err)
+	}
+	return strings.Join(failures, "; ")
+}
+
+// Errors returns the tenants' errors, for an Unwrap method
+func (f TenantFailures) Errors() []error {
+	errs := make([]error, len(f))
+	for i, failure := range f {
+		errs[i] = failure.Err
+	}
+	return errs
+}
+
+// TenantIDs returns the failed tenants
+func (f TenantFailures) TenantIDs() []uuid.UUID {
+	ids := make([]uuid.UUID, len(f))
+	for i, failure := range f {
+		ids[i] = failure.TenantID
+	}
+	return ids
+}
+
+// ForEachTenantError is returned by ForEachTenant when its function failed
+// for some tenants. The other tenants were still visited.
+type ForEachTenantError struct {
+	Failures TenantFailures // in the order the tenants were visited
+	Visited  int            // tenants the function ran for, including the failures
+}
+
+// Error implements the error interface
+func (e *ForEachTenantError) Error() string {
+	return fmt.Sprintf("failed for %d of %d tenants: %s", len(e.Failures), e.Visited, e.Failures)
+}
+
+// Unwrap returns the tenants' errors, for errors.Is and errors.As
+func (e *ForEachTenantError) Unwrap() []error {
+	return e.Failures.Errors()
+}
+
+// TenantIDs returns the tenants the function failed for
+func (e *ForEachTenantError) TenantIDs() []uuid.UUID {
+	return e.Failures.TenantIDs()
+}
+
+// ForEachTenant calls fn for every active tenant that passes all filters, one
+// at a time, with the tenant set in the context as WithTenantContext does. It
+// is the building block for scheduled per-tenant work such as usage resets or
+// reports. The active tenants are listed one page at a time as the run goes,
+// so memory does not grow with the number of tenants; fn may change or cancel
+// tenants, and each tenant is still visited at most once. A failing tenant
+// does not stop the run; failures are returned together as a
+// *ForEachTenantError. Cancelling ctx stops the run before the next tenant.
+func (m *manager) ForEachTenant(ctx context.Context, fn func(ctx context.Context, t *Tenant) error, filters ...TenantFilter) error {
+	result := &ForEachTenantError{}
+	var cancelled error
+	err := m.eachActiveTenant(ctx, func(t *Tenant) bool {
+		if !matchesFilters(t, filters) {
+			return true
+		}
+		if cancelled = ctx.Err(); cancelled != nil {
+			return false
+		}
+
+		result.Visited++
+		if err := fn(m.tenantContext(ctx, t), t); err != nil {
+			m.logger.Warn("Tenant job failed",
+				zap.String("tenant_id", t.ID.String()),
+				zap.Error(err))
+			result.Failures = append(result.Failures, TenantFailure{TenantID: t.ID, Err: err})
+		}
+		return true
+	})
+	if err == nil {
+		err = cancelled
+	}
+
+	if err != nil {
+		if len(result.Failures) > 0 {
+			return errors.Join(err, result)
+		}
+		return err
+	}
+	if len(result.Failures) > 0 {
+		return result
+	}
+	return nil
+}
+
+// matchesFilters reports whether the tenant passes every filter
+func matchesFilters(t *Tenant, filters []TenantFilter) bool {
+	for _, filter := range filters {
+		if !filter(t) {
+			return false
+		}
+	}
+	return true
+}
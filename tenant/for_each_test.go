@@ -0,0 +1,220 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// orderedRepository lists the mock repository's tenants in a stable order, so
+// that pages do not overlap
+type orderedRepository struct {
+	*MockManagerRepository
+	listCalls int
+}
+
+func (r *orderedRepository) List(ctx context.Context, page, perPage int) ([]*Tenant, int, error) {
+	r.listCalls++
+	var listed []*Tenant
+	for _, t := range r.tenants {
+		if t.Status != StatusCancelled {
+			listed = append(listed, t)
+		}
+	}
+	sort.Slice(listed, func(i, j int) bool { return listed[i].Subdomain < listed[j].Subdomain })
+
+	page, perPage = NormalizePagination(page, perPage)
+	start := min((page-1)*perPage, len(listed))
+	end := min(start+perPage, len(listed))
+	return listed[start:end], len(listed), nil
+}
+
+// newForEachTestManager returns a manager over active tenants spanning several
+// pages, alternating between the basic and enterprise plans, plus suspended and
+// cancelled tenants
+func newForEachTestManager(t *testing.T, active int) (*manager, map[uuid.UUID]*Tenant) {
+	repo := &orderedRepository{MockManagerRepository: NewMockRepository()}
+	m := newTestManager(t, withRepository(repo))
+
+	add := func(subdomain, plan, status string) *Tenant {
+		tenant := &Tenant{ID: uuid.New(), Name: subdomain, Subdomain: subdomain, PlanType: plan, Status: status}
+		repo.tenants[tenant.ID] = tenant
+		return tenant
+	}
+
+	activeTenants := make(map[uuid.UUID]*Tenant, active)
+	for i := 0; i < active; i++ {
+		plan := PlanBasic
+		if i%2 == 1 {
+			plan = PlanEnterprise
+		}
+		tenant := add(fmt.Sprintf("active-%03d", i), plan, StatusActive)
+		activeTenants[tenant.ID] = tenant
+	}
+	add("suspended", PlanEnterprise, StatusSuspended)
+	add("cancelled", PlanEnterprise, StatusCancelled)
+
+	return m, activeTenants
+}
+
+func TestManager_ForEachTenant(t *testing.T) {
+	m, active := newForEachTestManager(t, MaxPerPage*2+5)
+	ctx := context.Background()
+
+	visited := make(map[uuid.UUID]int)
+	err := m.ForEachTenant(ctx, func(ctx context.Context, tenant *Tenant) error {
+		visited[tenant.ID]++
+		if tenantCtx, ok := GetTenantFromContext(ctx); !ok || tenantCtx.TenantID != tenant.ID || tenantCtx.SchemaName != tenant.SchemaName {
+			t.Errorf("context for %s has tenant %+v", tenant.Subdomain, tenantCtx)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachTenant() error = %v", err)
+	}
+
+	if len(visited) != len(active) {
+		t.Errorf("ForEachTenant() visited %d tenants, want the %d active ones", len(visited), len(active))
+	}
+	for id, count := range visited {
+		if _, ok := active[id]; !ok {
+			t.Errorf("ForEachTenant() visited inactive tenant %s", id)
+		}
+		if count != 1 {
+			t.Errorf("ForEachTenant() visited %s %d times, want once", id, count)
+		}
+	}
+}
+
+func TestManager_ForEachTenant_ListsPageByPage(t *testing.T) {
+	m, active := newForEachTestManager(t, MaxPerPage*2+5)
+	repo := m.repository.(*orderedRepository)
+
+	visited := 0
+	err := m.ForEachTenant(context.Background(), func(ctx context.Context, tenant *Tenant) error {
+		if visited == 0 && repo.listCalls != 1 {
+			t.Errorf("listed %d pages before the first tenant, want 1", repo.listCalls)
+		}
+		visited++
+		return nil
+	})
+	if err != nil || visited != len(active) {
+		t.Fatalf("ForEachTenant() visited %d tenants, error = %v; want %d", visited, err, len(active))
+	}
+	if repo.listCalls != 3 {
+		t.Errorf("listed %d pages, want 3", repo.listCalls)
+	}
+}
+
+func TestManager_ForEachTenant_TenantsChangeDuringRun(t *testing.T) {
+	m, active := newForEachTestManager(t, MaxPerPage*2+5)
+	repo := m.repository.(*orderedRepository)
+
+	// Cancelling visited tenants shifts later ones onto pages already read, and
+	// creating tenants, which are listed first as the newest are, shifts them
+	// onto pages not read yet
+	visited := make(map[uuid.UUID]int)
+	created := 0
+	err := m.ForEachTenant(context.Background(), func(ctx context.Context, tenant *Tenant) error {
+		visited[tenant.ID]++
+		if len(visited)%3 == 0 {
+			tenant.Status = StatusCancelled
+		}
+		if len(visited)%7 == 0 {
+			created++
+			added := &Tenant{ID: uuid.New(), Subdomain: fmt.Sprintf("aaa-%03d", created), Status: StatusActive}
+			repo.tenants[added.ID] = added
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachTenant() error = %v", err)
+	}
+
+	for id := range active {
+		if visited[id] != 1 {
+			t.Errorf("ForEachTenant() visited %s %d times, want once", active[id].Subdomain, visited[id])
+		}
+	}
+	for id, count := range visited {
+		if count != 1 {
+			t.Errorf("ForEachTenant() visited %s %d times, want once", id, count)
+		}
+	}
+}
+
+func TestManager_ForEachTenant_Filter(t *testing.T) {
+	m, active := newForEachTestManager(t, 10)
+
+	var visited []string
+	err := m.ForEachTenant(context.Background(), func(ctx context.Context, tenant *Tenant) error {
+		visited = append(visited, tenant.Subdomain)
+		if tenant.PlanType != PlanEnterprise {
+			t.Errorf("visited %s on plan %s, want only %s", tenant.Subdomain, tenant.PlanType, PlanEnterprise)
+		}
+		return nil
+	}, OnPlans(PlanEnterprise))
+	if err != nil {
+		t.Fatalf("ForEachTenant() error = %v", err)
+	}
+	if len(visited) != len(active)/2 {
+		t.Errorf("ForEachTenant() visited %v, want the %d active enterprise tenants", visited, len(active)/2)
+	}
+}
+
+func TestManager_ForEachTenant_CollectsFailures(t *testing.T) {
+	m, active := newForEachTestManager(t, 6)
+	errReport := errors.New("report failed")
+
+	visited := 0
+	var failed []uuid.UUID
+	err := m.ForEachTenant(context.Background(), func(ctx context.Context, tenant *Tenant) error {
+		visited++
+		if tenant.PlanType == PlanEnterprise {
+			failed = append(failed, tenant.ID)
+			return errReport
+		}
+		return nil
+	})
+
+	if visited != len(active) {
+		t.Errorf("ForEachTenant() visited %d tenants, want all %d despite failures", visited, len(active))
+	}
+	var forEachErr *ForEachTenantError
+	if !errors.As(err, &forEachErr) {
+		t.Fatalf("ForEachTenant() error = %v, want ForEachTenantError", err)
+	}
+	if !errors.Is(err, errReport) {
+		t.Error("ForEachTenantError should unwrap to the tenants' errors")
+	}
+	if forEachErr.Visited != len(active) || len(forEachErr.TenantIDs()) != len(failed) {
+		t.Errorf("ForEachTenantError = %+v, want %d failures of %d visited", forEachErr, len(failed), len(active))
+	}
+	for i, id := range forEachErr.TenantIDs() {
+		if id != failed[i] {
+			t.Errorf("failure %d = %s, want %s", i, id, failed[i])
+		}
+	}
+}
+
+func TestManager_ForEachTenant_Cancelled(t *testing.T) {
+	m, _ := newForEachTestManager(t, 5)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	visited := 0
+	err := m.ForEachTenant(ctx, func(ctx context.Context, tenant *Tenant) error {
+		visited++
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ForEachTenant() error = %v, want context.Canceled", err)
+	}
+	if visited != 1 {
+		t.Errorf("ForEachTenant() visited %d tenants after cancellation, want 1", visited)
+	}
+}
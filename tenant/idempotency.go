@@ -0,0 +1,99 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// DefaultIdempotencyKeyTTL is how long idempotency keys are honoured when
+// Config.IdempotencyKeyTTL is not set
+const DefaultIdempotencyKeyTTL = 24 * time.Hour
+
+// IdempotencyRepository extends Repository with idempotency key storage for
+// tenant creation
+type IdempotencyRepository interface {
+	Repository
+
+	// ClaimIdempotencyKey reserves key for tenantID until expiresAt. If the key
+	// already has an unexpired claim, it returns the claimed tenant ID and false.
+	ClaimIdempotencyKey(ctx context.Context, key string, tenantID uuid.UUID, expiresAt time.Time) (uuid.UUID, bool, error)
+
+	// ReleaseIdempotencyKey removes the claim on key, e.g. after the create failed
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+}
+
+// WithIdempotencyKey returns a context that makes CreateTenant idempotent: a
+// retried create with the same key returns the tenant created by the first
+// call instead of failing or creating a duplicate.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, contextKeyIdempotencyKey, key)
+}
+
+// GetIdempotencyKeyFromContext extracts the idempotency key from a context
+func GetIdempotencyKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(contextKeyIdempotencyKey).(string)
+	return key, ok && key != ""
+}
+
+// createTenantIdempotent creates the tenant record, honouring the context's
+// idempotency key when the repository supports it. It returns false if the key
+// was already used, in which case tenant is filled in with the original tenant.
+func (m *manager) createTenantIdempotent(ctx context.Context, tenant *Tenant) (bool, error) {
+	key, hasKey := GetIdempotencyKeyFromContext(ctx)
+	if !hasKey {
+		return true, m.createTenantRecord(ctx, tenant)
+	}
+
+	repo, ok := m.repository.(IdempotencyRepository)
+	if !ok {
+		m.logger.Warn("Repository does not support idempotency keys, creating tenant without one",
+			zap.String("tenant_id", tenant.ID.String()))
+		return true, m.createTenantRecord(ctx, tenant)
+	}
+
+	ttl := m.config.IdempotencyKeyTTL
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyKeyTTL
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	claimedID, claimed, err := repo.ClaimIdempotencyKey(ctx, key, tenant.ID, expiresAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	if !claimed {
+		existing, err := m.repository.GetByID(ctx, claimedID)
+		if err != nil {
+			if IsNotFound(err) {
+				return false, &TenantError{
+					TenantID: claimedID,
+					Code:     "IDEMPOTENCY_KEY_IN_USE",
+					Message:  "a tenant creation with this idempotency key is still in progress",
+				}
+			}
+			return false, fmt.Errorf("failed to get tenant for idempotency key: %w", err)
+		}
+
+		*tenant = *existing
+		m.logger.Info("Returning tenant created with the same idempotency key",
+			zap.String("tenant_id", tenant.ID.String()))
+		return false, nil
+	}
+
+	if err := m.createTenantRecord(ctx, tenant); err != nil {
+		// Let a retry with the same key try again
+		if releaseErr := repo.ReleaseIdempotencyKey(ctx, key); releaseErr != nil {
+			m.logger.Error("Failed to release idempotency key after create failure",
+				zap.String("tenant_id", tenant.ID.String()),
+				zap.Error(releaseErr))
+		}
+		return false, err
+	}
+
+	return true, nil
+}
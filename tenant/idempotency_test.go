@@ -0,0 +1,126 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestManager_CreateTenant_IdempotencyKey(t *testing.T) {
+	m, repo := newIdempotencyTestManager(t)
+	ctx := WithIdempotencyKey(context.Background(), "create-acme-1")
+
+	first := &Tenant{Name: "Acme Corp", Subdomain: "acme", PlanType: PlanPro}
+	if err := m.CreateTenant(ctx, first); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+
+	// The retry would otherwise fail on the duplicate subdomain
+	retry := &Tenant{Name: "Acme Corp", Subdomain: "acme", PlanType: PlanPro}
+	if err := m.CreateTenant(ctx, retry); err != nil {
+		t.Fatalf("CreateTenant() retry error = %v", err)
+	}
+	if retry.ID != first.ID || retry.SchemaName != first.SchemaName || !retry.CreatedAt.Equal(first.CreatedAt) {
+		t.Errorf("retry returned %+v, want the original tenant %+v", retry, first)
+	}
+	if len(repo.tenants) != 1 {
+		t.Errorf("repository has %d tenants, want 1", len(repo.tenants))
+	}
+
+	// A different key is a different request
+	other := &Tenant{Name: "Acme Corp", Subdomain: "acme", PlanType: PlanPro}
+	if err := m.CreateTenant(WithIdempotencyKey(context.Background(), "create-acme-2"), other); err == nil {
+		t.Error("CreateTenant() with a new key should fail on the duplicate subdomain")
+	}
+}
+
+func TestManager_CreateTenant_IdempotencyKeyExpires(t *testing.T) {
+	m, repo := newIdempotencyTestManager(t)
+	m.config.IdempotencyKeyTTL = time.Hour
+	ctx := WithIdempotencyKey(context.Background(), "create-tenant")
+
+	if err := m.CreateTenant(ctx, &Tenant{Name: "Acme Corp", Subdomain: "acme"}); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+
+	// Once the window has passed the key can be reused for a new tenant
+	repo.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	second := &Tenant{Name: "Globex", Subdomain: "globex"}
+	if err := m.CreateTenant(ctx, second); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+	if second.Subdomain != "globex" || len(repo.tenants) != 2 {
+		t.Errorf("CreateTenant() after expiry = %+v with %d tenants, want a new tenant", second, len(repo.tenants))
+	}
+}
+
+func TestManager_CreateTenant_IdempotencyKeyReleasedOnFailure(t *testing.T) {
+	m, repo := newIdempotencyTestManager(t)
+	ctx := WithIdempotencyKey(context.Background(), "create-acme")
+
+	repo.createErr = errors.New("connection reset")
+	if err := m.CreateTenant(ctx, &Tenant{Name: "Acme Corp", Subdomain: "acme"}); err == nil {
+		t.Fatal("CreateTenant() should return the repository error")
+	}
+	if _, claimed := repo.keys["create-acme"]; claimed {
+		t.Error("failed create should release its idempotency key")
+	}
+
+	// The retry creates the tenant
+	repo.createErr = nil
+	retry := &Tenant{Name: "Acme Corp", Subdomain: "acme"}
+	if err := m.CreateTenant(ctx, retry); err != nil {
+		t.Fatalf("CreateTenant() retry error = %v", err)
+	}
+	if _, err := repo.GetByID(context.Background(), retry.ID); err != nil {
+		t.Errorf("retried tenant was not created: %v", err)
+	}
+}
+
+// idempotencyRepository adds idempotency key storage to the manager mock repository
+type idempotencyRepository struct {
+	*MockManagerRepository
+	keys      map[string]idempotencyClaim
+	createErr error
+	now       func() time.Time
+}
+
+type idempotencyClaim struct {
+	tenantID  uuid.UUID
+	expiresAt time.Time
+}
+
+func (r *idempotencyRepository) Create(ctx context.Context, t *Tenant) error {
+	if r.createErr != nil {
+		return r.createErr
+	}
+	return r.MockManagerRepository.Create(ctx, t)
+}
+
+func (r *idempotencyRepository) ClaimIdempotencyKey(ctx context.Context, key string, tenantID uuid.UUID, expiresAt time.Time) (uuid.UUID, bool, error) {
+	if claim, ok := r.keys[key]; ok && r.now().Before(claim.expiresAt) {
+		return claim.tenantID, false, nil
+	}
+
+	r.keys[key] = idempotencyClaim{tenantID: tenantID, expiresAt: expiresAt}
+	return tenantID, true, nil
+}
+
+func (r *idempotencyRepository) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	delete(r.keys, key)
+	return nil
+}
+
+// newIdempotencyTestManager returns a manager whose repository stores idempotency keys
+func newIdempotencyTestManager(t *testing.T) (*manager, *idempotencyRepository) {
+	repo := &idempotencyRepository{
+		MockManagerRepository: NewMockRepository(),
+		keys:                  make(map[string]idempotencyClaim),
+		now:                   time.Now,
+	}
+	return newTestManager(t, withRepository(repo)), repo
+}
@@ -0,0 +1,265 @@
+package tenant
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ImportRecord is a tenant read from an external source by ImportTenants
+type ImportRecord struct {
+	ID        uuid.UUID      `json:"id"` // optional; uuid.Nil generates one
+	Name      string         `json:"name"`
+	Subdomain string         `json:"subdomain"`
+	PlanType  string         `json:"plan_type"` // optional; empty uses the basic plan
+	Metadata  TenantMetadata `json:"metadata,omitempty"`
+}
+
+// TenantSource yields the records ImportTenants creates. Next returns io.EOF
+// once all records have been read.
+type TenantSource interface {
+	Next(ctx context.Context) (*ImportRecord, error)
+}
+
+// sliceTenantSource yields records from a slice
+type sliceTenantSource struct {
+	records []*ImportRecord
+}
+
+// NewSliceTenantSource returns a TenantSource yielding records in order
+func NewSliceTenantSource(records []*ImportRecord) TenantSource {
+	return &sliceTenantSource{records: records}
+}
+
+func (s *sliceTenantSource) Next(ctx context.Context) (*ImportRecord, error) {
+	if len(s.records) == 0 {
+		return nil, io.EOF
+	}
+	record := s.records[0]
+	s.records = s.records[1:]
+	return record, nil
+}
+
+// csvTenantSource yields records from CSV rows
+type csvTenantSource struct {
+	reader  *csv.Reader
+	columns []string
+}
+
+// NewCSVTenantSource returns a TenantSource reading CSV with a header row.
+// The columns id, name, subdomain and plan fill the record's fields (id and
+// plan may be omitted); any other column is imported as a metadata field.
+func NewCSVTenantSource(r io.Reader) (TenantSource, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := make([]string, len(header))
+	for i, column := range header {
+		columns[i] = strings.ToLower(strings.TrimSpace(column))
+	}
+	for _, required := range []string{"name", "subdomain"} {
+		if !slices.Contains(columns, required) {
+			return nil, fmt.Errorf("CSV header has no %s column", required)
+		}
+	}
+
+	return &csvTenantSource{reader: reader, columns: columns}, nil
+}
+
+func (s *csvTenantSource) Next(ctx context.Context) (*ImportRecord, error) {
+	row, err := s.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	record := &ImportRecord{}
+	for i, value := range row {
+		switch column := s.columns[i]; column {
+		case "id":
+			if value == "" {
+				continue
+			}
+			if record.ID, err = uuid.Parse(value); err != nil {
+				line, _ := s.reader.FieldPos(i)
+				return nil, fmt.Errorf("invalid tenant ID on line %d: %w", line, err)
+			}
+		case "name":
+			record.Name = value
+		case "subdomain":
+			record.Subdomain = value
+		case "plan":
+			record.PlanType = value
+		default:
+			if value == "" {
+				continue
+			}
+			if record.Metadata == nil {
+				record.Metadata = make(TenantMetadata)
+			}
+			record.Metadata[column] = value
+		}
+	}
+	return record, nil
+}
+
+// ImportOptions controls how ImportTenants creates tenants
+type ImportOptions struct {
+	Provision bool // Provision each imported tenant's schema; ignored when a provisioning queue is set
+}
+
+// ImportOutcome is what ImportTenants did with a record
+type ImportOutcome string
+
+// Import outcomes
+const (
+	ImportCreated ImportOutcome = "created" // the tenant was created
+	ImportSkipped ImportOutcome = "skipped" // a tenant with the subdomain already exists
+	ImportFailed  ImportOutcome = "failed"  // the record is invalid or could not be stored
+)
+
+// ImportResult is the outcome of importing one record
+type ImportResult struct {
+	Record    int           `json:"record"` // 1-based position in the source
+	TenantID  uuid.UUID     `json:"tenant_id,omitempty"`
+	Subdomain string        `json:"subdomain"`
+	Outcome   ImportOutcome `json:"outcome"`
+	Err       error         `json:"-"`
+}
+
+// ImportReport summarizes an ImportTenants run
+type ImportReport struct {
+	Created int             `json:"created"`
+	Skipped int             `json:"skipped"`
+	Failed  int             `json:"failed"`
+	Results []*ImportResult `json:"results"`
+}
+
+// ImportTenants creates a tenant for every record in source, e.g. to migrate
+// an existing product's customers. Records whose subdomain is already taken
+// are skipped, so an interrupted import can be re-run; with opts.Provision a
+// skipped tenant that is still pending is provisioned again. A failed record
+// does not stop the import. The returned error is set only if the source
+// fails, in which case the report covers the records read so far.
+func (m *manager) ImportTenants(ctx context.Context, source TenantSource, opts ImportOptions) (*ImportReport, error) {
+	report := &ImportReport{}
+
+	for i := 1; ; i++ {
+		record, err := source.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return report, fmt.Errorf("failed to read record %d: %w", i, err)
+		}
+
+		result := m.importTenant(ctx, record, opts)
+		result.Record = i
+		report.Results = append(report.Results, result)
+
+		switch result.Outcome {
+		case ImportCreated:
+			report.Created++
+		case ImportSkipped:
+			report.Skipped++
+		case ImportFailed:
+			report.Failed++
+			m.logger.Warn("Failed to import tenant",
+				zap.Int("record", i),
+				zap.String("subdomain", record.Subdomain),
+				zap.Error(result.Err))
+		}
+	}
+
+	m.logger.Info("Imported tenants",
+		zap.Int("created", report.Created),
+		zap.Int("skipped", report.Skipped),
+		zap.Int("failed", report.Failed))
+
+	return report, nil
+}
+
+// importTenant creates, or skips, the tenant for one record
+func (m *manager) importTenant(ctx context.Context, record *ImportRecord, opts ImportOptions) *ImportResult {
+	result := &ImportResult{Subdomain: record.Subdomain}
+	fail := func(err error) *ImportResult {
+		result.Outcome = ImportFailed
+		result.Err = err
+		return result
+	}
+
+	if record.Subdomain != "" {
+		taken, err := m.subdomainExists(ctx, record.Subdomain)
+		if err != nil {
+			return fail(err)
+		}
+		if taken {
+			existing, err := m.repository.GetBySubdomain(ctx, record.Subdomain)
+			if err != nil {
+				return fail(fmt.Errorf("failed to get existing tenant: %w", err))
+			}
+			result.TenantID = existing.ID
+			result.Outcome = ImportSkipped
+			if opts.Provision && existing.Status == StatusPending && m.provisioningQueue() == nil {
+				if err := m.ProvisionTenant(ctx, existing.ID); err != nil {
+					return fail(fmt.Errorf("failed to provision existing tenant: %w", err))
+				}
+			}
+			return result
+		}
+	}
+
+	extRepo, isExtensible := m.repository.(ExtensibleRepository)
+	if len(record.Metadata) > 0 && !isExtensible {
+		return fail(&ValidationError{Field: "metadata", Message: "repository does not store tenant metadata"})
+	}
+
+	tenant := &Tenant{
+		ID:        record.ID,
+		Name:      record.Name,
+		Subdomain: record.Subdomain,
+		PlanType:  record.PlanType,
+		Status:    StatusPending,
+	}
+	if err := m.CreateTenant(ctx, tenant); err != nil {
+		return fail(err)
+	}
+	result.TenantID = tenant.ID
+
+	if len(record.Metadata) > 0 {
+		// Merge over the plan template defaults stored by CreateTenant
+		metadata, err := extRepo.GetMetadata(ctx, tenant.ID)
+		if err != nil {
+			return fail(fmt.Errorf("failed to get metadata: %w", err))
+		}
+		if metadata == nil {
+			metadata = make(TenantMetadata)
+		}
+		for key, value := range record.Metadata {
+			metadata[key] = value
+		}
+		if err := extRepo.UpdateMetadata(ctx, tenant.ID, metadata); err != nil {
+			return fail(fmt.Errorf("failed to store metadata: %w", err))
+		}
+	}
+
+	if opts.Provision && m.provisioningQueue() == nil {
+		if err := m.ProvisionTenant(ctx, tenant.ID); err != nil {
+			return fail(fmt.Errorf("failed to provision tenant: %w", err))
+		}
+	}
+
+	result.Outcome = ImportCreated
+	return result
+}
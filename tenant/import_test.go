@@ -0,0 +1,132 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestManager_ImportTenants(t *testing.T) {
+	config := DefaultConfig()
+	repo := NewMockRepository()
+	schemas := NewMockSchemaManager(config.Database.SchemaPrefix)
+	m := NewManager(config, (*sql.DB)(nil), repo, schemas, NewMockMigrationManager(), NewMockLimitChecker(config.Limits), zaptest.NewLogger(t))
+	ctx := context.Background()
+
+	existingID := uuid.New()
+	repo.tenants[existingID] = &Tenant{ID: existingID, Name: "Existing", Subdomain: "existing", PlanType: PlanBasic, Status: StatusActive}
+
+	acmeID := uuid.New()
+	records := []*ImportRecord{
+		{ID: acmeID, Name: "Acme", Subdomain: "acme", PlanType: PlanPro},
+		{Name: "Acme again", Subdomain: "acme"},      // duplicate within the source
+		{Name: "Existing", Subdomain: "existing"},    // already in the repository
+		{Name: "", Subdomain: "nameless"},            // invalid: no name
+		{Name: "Bad", Subdomain: "Not A Subdomain!"}, // invalid subdomain
+		{Name: "Globex", Subdomain: "globex"},
+	}
+
+	report, err := m.ImportTenants(ctx, NewSliceTenantSource(records), ImportOptions{Provision: true})
+	if err != nil {
+		t.Fatalf("ImportTenants() error = %v", err)
+	}
+	if report.Created != 2 || report.Skipped != 2 || report.Failed != 2 {
+		t.Errorf("report = created %d, skipped %d, failed %d; want 2, 2, 2", report.Created, report.Skipped, report.Failed)
+	}
+
+	wantOutcomes := []ImportOutcome{ImportCreated, ImportSkipped, ImportSkipped, ImportFailed, ImportFailed, ImportCreated}
+	for i, result := range report.Results {
+		if result.Outcome != wantOutcomes[i] || result.Record != i+1 {
+			t.Errorf("result %d = %+v, want %s", i, result, wantOutcomes[i])
+		}
+		if result.Outcome == ImportFailed && result.Err == nil {
+			t.Errorf("result %d failed without an error", i)
+		}
+	}
+	if report.Results[1].TenantID != acmeID {
+		t.Errorf("duplicate skipped as %s, want the imported tenant %s", report.Results[1].TenantID, acmeID)
+	}
+
+	acme, err := repo.GetByID(ctx, acmeID)
+	if err != nil || acme.PlanType != PlanPro || acme.Status != StatusActive || !schemas.schemas[acmeID] {
+		t.Errorf("imported tenant = %+v, %v; want an active, provisioned pro tenant keeping its ID", acme, err)
+	}
+
+	// Re-running the import creates nothing new
+	report, err = m.ImportTenants(ctx, NewSliceTenantSource(records), ImportOptions{Provision: true})
+	if err != nil {
+		t.Fatalf("second ImportTenants() error = %v", err)
+	}
+	if report.Created != 0 || report.Skipped != 4 || report.Failed != 2 {
+		t.Errorf("re-run report = created %d, skipped %d, failed %d; want 0, 4, 2", report.Created, report.Skipped, report.Failed)
+	}
+	if len(repo.tenants) != 3 {
+		t.Errorf("tenants = %d, want 3", len(repo.tenants))
+	}
+}
+
+func TestManager_ImportTenants_ProvisionOnRerun(t *testing.T) {
+	config := DefaultConfig()
+	repo := NewMockRepository()
+	schemas := NewMockSchemaManager(config.Database.SchemaPrefix)
+	m := NewManager(config, (*sql.DB)(nil), repo, schemas, NewMockMigrationManager(), NewMockLimitChecker(config.Limits), zaptest.NewLogger(t))
+	ctx := context.Background()
+
+	records := []*ImportRecord{{Name: "Acme", Subdomain: "acme"}}
+
+	// Rows are imported first and provisioned by a later run
+	if _, err := m.ImportTenants(ctx, NewSliceTenantSource(records), ImportOptions{}); err != nil {
+		t.Fatalf("ImportTenants() error = %v", err)
+	}
+	acme, _ := repo.GetBySubdomain(ctx, "acme")
+	if acme.Status != StatusPending || schemas.schemas[acme.ID] {
+		t.Fatalf("tenant = %+v, want pending without a schema", acme)
+	}
+
+	report, err := m.ImportTenants(ctx, NewSliceTenantSource(records), ImportOptions{Provision: true})
+	if err != nil || report.Skipped != 1 {
+		t.Fatalf("ImportTenants() = %+v, %v; want the tenant skipped", report, err)
+	}
+	if acme, _ := repo.GetBySubdomain(ctx, "acme"); acme.Status != StatusActive || !schemas.schemas[acme.ID] {
+		t.Errorf("tenant after re-run = %+v, want it provisioned", acme)
+	}
+}
+
+func TestNewCSVTenantSource(t *testing.T) {
+	tenantID := uuid.New()
+	input := "id,name,subdomain,plan,timezone\n" +
+		tenantID.String() + ",Acme,acme,pro,Europe/Berlin\n" +
+		",Globex,globex,,\n" +
+		"not-a-uuid,Initech,initech,basic,\n"
+
+	source, err := NewCSVTenantSource(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("NewCSVTenantSource() error = %v", err)
+	}
+	ctx := context.Background()
+
+	record, err := source.Next(ctx)
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if record.ID != tenantID || record.Name != "Acme" || record.PlanType != PlanPro || record.Metadata["timezone"] != "Europe/Berlin" {
+		t.Errorf("record = %+v, want Acme on pro with a timezone", record)
+	}
+
+	record, err = source.Next(ctx)
+	if err != nil || record.ID != uuid.Nil || record.PlanType != "" || record.Metadata != nil {
+		t.Errorf("record = %+v, %v; want Globex with defaults", record, err)
+	}
+
+	if _, err := source.Next(ctx); err == nil || !strings.Contains(err.Error(), "line 4") {
+		t.Errorf("Next() error = %v, want an invalid ID on line 4", err)
+	}
+
+	if _, err := NewCSVTenantSource(strings.NewReader("name,plan\nAcme,pro\n")); err == nil {
+		t.Error("NewCSVTenantSource() should require a subdomain column")
+	}
+}
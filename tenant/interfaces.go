@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -14,19 +15,109 @@ type Manager interface {
 	CreateTenant(ctx context.Context, tenant *Tenant) error
 	GetTenant(ctx context.Context, id uuid.UUID) (*Tenant, error)
 	GetTenantBySubdomain(ctx context.Context, subdomain string) (*Tenant, error)
+	IsSubdomainAvailable(ctx context.Context, subdomain string) (bool, error)
 	UpdateTenant(ctx context.Context, tenant *Tenant) error
 	DeleteTenant(ctx context.Context, id uuid.UUID) error
+	// OnboardTenantWithOwner creates and provisions a tenant with ownerUserID as its owner, undoing everything on failure
+	OnboardTenantWithOwner(ctx context.Context, tenant *Tenant, ownerUserID uuid.UUID) error
+	// DeletionImpact reports what deleting the tenant would destroy, without deleting anything
+	DeletionImpact(ctx context.Context, tenantID uuid.UUID) (*DeletionReport, error)
+	// PurgeTenant drops a cancelled tenant's schema and removes its record; it requires a PurgeRepository
+	PurgeTenant(ctx context.Context, tenantID uuid.UUID) error
+	// PurgeExpiredTenants purges the tenants cancelled more than olderThan ago and returns how many
+	PurgeExpiredTenants(ctx context.Context, olderThan time.Duration) (int, error)
 	ListTenants(ctx context.Context, page, perPage int) ([]*Tenant, int, error)
+	ListTenantsPaged(ctx context.Context, page, perPage int) (*Page[*Tenant], error)
+	// ForEachTenant calls fn for every active tenant passing the filters, collecting failures
+	ForEachTenant(ctx context.Context, fn func(ctx context.Context, t *Tenant) error, filters ...TenantFilter) error
 
 	// Tenant operations
 	ProvisionTenant(ctx context.Context, id uuid.UUID) error
+	// ProvisionTenantDetailed provisions like ProvisionTenant and reports what was done
+	ProvisionTenantDetailed(ctx context.Context, id uuid.UUID) (*ProvisionResult, error)
 	SuspendTenant(ctx context.Context, id uuid.UUID) error
 	ActivateTenant(ctx context.Context, id uuid.UUID) error
+	RestoreTenant(ctx context.Context, id uuid.UUID) error
+
+	// Data residency. Tenants created in a region have their schema provisioned
+	// in, and their connections routed to, that region's database.
+	SetRegionRouter(router RegionRouter)
+	// SetTenantPoolOpener enables a dedicated pool per tenant, see DatabaseConfig.MaxTenantPools
+	SetTenantPoolOpener(opener TenantPoolOpener)
+	CreateTenantInRegion(ctx context.Context, tenant *Tenant, region string) error
+	GetTenantRegion(ctx context.Context, tenantID uuid.UUID) (string, error)
+	// RenameTenantSchema renames the tenant's schema to its name under the configured
+	// prefix, keeping its data; it requires a SchemaRenamer and a SchemaNameUpdater
+	RenameTenantSchema(ctx context.Context, id uuid.UUID, newName string) error
+	// CloneTenant creates and provisions newTenant with a copy of the source
+	// tenant's tables, all of them unless some are listed; it requires a SchemaCloner
+	CloneTenant(ctx context.Context, sourceID uuid.UUID, newTenant *Tenant, tables ...string) error
+
+	// Asynchronous provisioning. Once a queue is set, CreateTenant returns a
+	// pending tenant and enqueues it; ProvisionTenant runs on the queue's workers.
+	SetProvisioningQueue(queue ProvisioningQueue, hooks ProvisioningHooks) error
+	GetProvisioningJob(ctx context.Context, tenantID uuid.UUID) (*ProvisioningJob, error)
+
+	// Plan changes
+	ChangePlan(ctx context.Context, tenantID uuid.UUID, planType, actor string) error
+	ChangeTenantPlan(ctx context.Context, tenantID uuid.UUID, newPlan string) (oldPlan string, err error)
+	ValidatePlanChange(ctx context.Context, tenantID uuid.UUID, newPlan string) ([]LimitViolation, error)
+	GetPlanHistory(ctx context.Context, tenantID uuid.UUID) ([]*PlanChange, error)
+
+	// Plan prices, from Config.PlanTemplates; ErrPlanPriceNotFound if a plan has none
+	GetPlanPrice(plan string) (Money, error)
+	GetPlanPriceIn(plan, currency string) (Money, error)
+
+	// Labels group tenants for bulk operations and reporting; they require a LabelRepository
+	AddLabel(ctx context.Context, tenantID uuid.UUID, label string) error
+	RemoveLabel(ctx context.Context, tenantID uuid.UUID, label string) error
+	GetLabels(ctx context.Context, tenantID uuid.UUID) ([]string, error)
+	ListByLabel(ctx context.Context, label string) ([]*Tenant, error)
+
+	// BulkCreateTenants creates tenants in one transaction, all or none; it requires a BatchRepository
+	BulkCreateTenants(ctx context.Context, tenants []*Tenant) ([]uuid.UUID, error)
+	// ImportTenants creates tenants from an external source, skipping taken subdomains
+	ImportTenants(ctx context.Context, source TenantSource, opts ImportOptions) (*ImportReport, error)
+
+	// Read-only mode blocks writes through WithTenantTx while reads keep working;
+	// it is stored as the LabelReadOnly label
+	SetReadOnly(ctx context.Context, tenantID uuid.UUID, readOnly bool) error
+	IsReadOnly(ctx context.Context, tenantID uuid.UUID) (bool, error)
 
 	// Access and validation
 	ValidateAccess(ctx context.Context, userID, tenantID uuid.UUID) error
 	CheckLimits(ctx context.Context, tenantID uuid.UUID) (*Limits, error)
+	CheckLimit(ctx context.Context, tenantID uuid.UUID, limitName string, currentValue interface{}) error
+	// GetUsage returns the tenant's tracked usage of a limit from the usage tracker
+	GetUsage(ctx context.Context, tenantID uuid.UUID, limitName string) (interface{}, error)
+	// GetAllUsage returns the tenant's tracked usage of every limit, in one
+	// read when the usage tracker is a UsageLister
+	GetAllUsage(ctx context.Context, tenantID uuid.UUID) (map[string]interface{}, error)
+	// ReleaseUsage decrements tracked usage, e.g. on resource deletion, never below zero
+	ReleaseUsage(ctx context.Context, tenantID uuid.UUID, limitName string, amount int) error
+	// ReconcileUsage resets tracked usage to the value counted from the tenant's stats
+	ReconcileUsage(ctx context.Context, tenantID uuid.UUID, limitName string) (*UsageDrift, error)
+	// ReconcileAllUsage reconciles tracked usage for every active tenant
+	ReconcileAllUsage(ctx context.Context) ([]*UsageDrift, error)
+	// GetStats returns the tenant's stats, cached for up to Limits.StatsCacheTTL
 	GetStats(ctx context.Context, tenantID uuid.UUID) (*Stats, error)
+	// GetStatsFresh recounts the tenant's stats, bypassing the cache
+	GetStatsFresh(ctx context.Context, tenantID uuid.UUID) (*Stats, error)
+	// NextTenantSequence returns the next value of a per-tenant sequence, creating it on first use
+	NextTenantSequence(ctx context.Context, tenantID uuid.UUID, name string) (int64, error)
+
+	// GetTenantLimits returns the tenant's plan limits with its overrides applied
+	GetTenantLimits(ctx context.Context, tenantID uuid.UUID) (FlexibleLimits, error)
+
+	// GetTenantProfile aggregates the tenant's public fields, effective limits,
+	// enabled features, branding and current usage for frontends
+	GetTenantProfile(ctx context.Context, tenantID uuid.UUID) (*TenantProfile, error)
+
+	// Migrations
+	GetAppliedMigrations(ctx context.Context, tenantID uuid.UUID) ([]*Migration, error)
+
+	// Limit schema
+	GetLimitSchema() []LimitDescription
 
 	// Database operations
 	//
@@ -43,6 +134,12 @@ type Manager interface {
 	//   // use conn for queries...
 	GetTenantConn(ctx context.Context, tenantID uuid.UUID) (*sql.Conn, error)
 
+	// GetTenantReadConn returns a connection like GetTenantConn, taken from a read replica
+	// when SetReadReplicas has set any. Only read on it; the caller MUST close it.
+	GetTenantReadConn(ctx context.Context, tenantID uuid.UUID) (*sql.Conn, error)
+	// SetReadReplicas sets the replicas GetTenantReadConn uses in turn
+	SetReadReplicas(replicas ...*sql.DB)
+
 	// WithTenantTx executes a function within a transaction with the tenant's search_path set.
 	// This is the safest way to execute tenant-scoped queries.
 	// The transaction is automatically committed if fn returns nil, or rolled back on error.
@@ -53,15 +150,53 @@ type Manager interface {
 	//   })
 	WithTenantTx(ctx context.Context, tenantID uuid.UUID, fn func(tx *sql.Tx) error) error
 
+	// AssertTenantScope returns an error wrapping ErrTenantScopeMismatch unless the
+	// connection's search_path starts with the tenant's schema. conn may be a *sql.Conn
+	// or *sql.Tx. Set DatabaseConfig.VerifyTenantScope to run this check automatically
+	// in GetTenantConn and WithTenantTx.
+	AssertTenantScope(ctx context.Context, conn RowQuerier, tenantID uuid.UUID) error
+
+	// ExecInEachTenant runs a maintenance statement in every active tenant's schema with
+	// bounded concurrency, in a transaction per tenant unless opts.NoTransaction is set.
+	// Statements that drop or delete data are rejected unless opts.AllowDestructive is set.
+	ExecInEachTenant(ctx context.Context, sqlTemplate string, opts ExecOptions) ([]*TenantExecResult, error)
+
+	// TenantPoolStats returns usage statistics for the tenant's dedicated connection pool,
+	// including whether every connection is currently in use.
+	TenantPoolStats(tenantID uuid.UUID) (*PoolStats, error)
+
+	// SelfCheck verifies the database, master tables, plan limits and reserved
+	// subdomains, and optionally tenant schemas, and reports what is wrong
+	SelfCheck(ctx context.Context) (*SelfCheckReport, error)
+
+	// WarmCache preloads active tenants into the resolution cache, up to
+	// ResolverConfig.WarmCacheLimit. Call it at startup before serving traffic.
+	WarmCache(ctx context.Context) error
+	// AddSubdomainInvalidator registers a cache, such as a resolver's negative
+	// cache, to be told when a tenant takes a subdomain, and returns a
+	// function that unregisters it
+	AddSubdomainInvalidator(invalidator SubdomainInvalidator) func()
+	// SetEventPublisher publishes tenant lifecycle and limit threshold events
+	SetEventPublisher(publisher EventPublisher)
+	// SetMetricsCollector reports tenant operations and rejected limit checks
+	// to collector; see the metrics/prometheus package
+	SetMetricsCollector(collector MetricsCollector)
+
 	WithTenantContext(ctx context.Context, tenantID uuid.UUID) context.Context
+	// TenantFromContext and TenantIDFromContext read the tenant this manager's
+	// WithTenantContext stored, scoped by Config.ContextNamespace
+	TenantFromContext(ctx context.Context) (*Context, bool)
+	TenantIDFromContext(ctx context.Context) (uuid.UUID, bool)
 
 	// Close resources
 	Close() error
 }
 
-// Resolver handles tenant resolution from HTTP requests
+// Resolver handles tenant resolution from HTTP requests, subdomains and IDs
 type Resolver interface {
 	ResolveTenant(ctx context.Context, req *http.Request) (uuid.UUID, error)
+	ResolveBySubdomain(ctx context.Context, subdomain string) (uuid.UUID, error)
+	ResolveByID(ctx context.Context, tenantID uuid.UUID) (uuid.UUID, error)
 	ExtractFromSubdomain(host string) (string, error)
 	ExtractFromPath(path string) (string, error)
 	ExtractFromHeader(req *http.Request) (string, error)
@@ -109,6 +244,14 @@ type Repository interface {
 	GetStats(ctx context.Context, tenantID uuid.UUID) (*Stats, error)
 }
 
+// ExistenceRepository is implemented by repositories that can check whether a
+// tenant exists without loading its row. The manager falls back to GetByID and
+// GetBySubdomain for repositories that do not implement it.
+type ExistenceRepository interface {
+	ExistsByID(ctx context.Context, id uuid.UUID) (bool, error)
+	ExistsBySubdomain(ctx context.Context, subdomain string) (bool, error)
+}
+
 // Middleware represents HTTP middleware for tenant handling
 type Middleware interface {
 	ResolveTenant() MiddlewareFunc
@@ -121,29 +264,34 @@ type Middleware interface {
 // MiddlewareFunc represents a middleware function
 type MiddlewareFunc interface{}
 
-// ContextKey represents keys for context values
-type ContextKey string
-
-const (
-	// ContextKeyTenant is the context key for tenant information
-	ContextKeyTenant ContextKey = "tenant"
-	// ContextKeyTenantID is the context key for tenant ID
-	ContextKeyTenantID ContextKey = "tenant_id"
-	// ContextKeyTenantDB is the context key for tenant database connection (deprecated)
-	ContextKeyTenantDB ContextKey = "tenant_db"
-	// ContextKeyTenantConn is the context key for dedicated tenant database connection
-	ContextKeyTenantConn ContextKey = "tenant_conn"
+// The package's context keys, exported for compatibility only. They are
+// values of an unexported type, so other packages cannot construct a key that
+// collides with them. Read and store tenant values with the accessors below,
+// Manager.WithTenantContext and Manager.TenantFromContext instead.
+var (
+	// Deprecated: use GetTenantFromContext or Manager.WithTenantContext.
+	ContextKeyTenant = contextKeyTenant
+	// Deprecated: use GetTenantIDFromContext or Manager.WithTenantContext.
+	ContextKeyTenantID = contextKeyTenantID
+	// Deprecated: use GetTenantConnFromContext.
+	ContextKeyTenantDB = contextKeyTenantDB
+	// Deprecated: use WithTenantConn and GetTenantConnFromContext.
+	ContextKeyTenantConn = contextKeyTenantConn
+	// Deprecated: use WithIdempotencyKey.
+	ContextKeyIdempotencyKey = contextKeyIdempotencyKey
+	// Deprecated: use WithForceProvision.
+	ContextKeyForceProvision = contextKeyForceProvision
 )
 
 // GetTenantFromContext extracts tenant context from a context
 func GetTenantFromContext(ctx context.Context) (*Context, bool) {
-	tenant, ok := ctx.Value(ContextKeyTenant).(*Context)
+	tenant, ok := ctx.Value(contextKeyTenant).(*Context)
 	return tenant, ok
 }
 
 // GetTenantIDFromContext extracts tenant ID from a context
 func GetTenantIDFromContext(ctx context.Context) (uuid.UUID, bool) {
-	tenantID, ok := ctx.Value(ContextKeyTenantID).(uuid.UUID)
+	tenantID, ok := ctx.Value(contextKeyTenantID).(uuid.UUID)
 	return tenantID, ok
 }
 
@@ -151,13 +299,18 @@ func GetTenantIDFromContext(ctx context.Context) (uuid.UUID, bool) {
 //
 // Deprecated: Use GetTenantConnFromContext instead for safe tenant-scoped queries.
 func GetTenantDBFromContext(ctx context.Context) (*sql.DB, bool) {
-	db, ok := ctx.Value(ContextKeyTenantDB).(*sql.DB)
+	db, ok := ctx.Value(contextKeyTenantDB).(*sql.DB)
 	return db, ok
 }
 
 // GetTenantConnFromContext extracts the dedicated tenant database connection from context.
 // This connection has the tenant's search_path already set and is safe for tenant-scoped queries.
 func GetTenantConnFromContext(ctx context.Context) (*sql.Conn, bool) {
-	conn, ok := ctx.Value(ContextKeyTenantConn).(*sql.Conn)
+	conn, ok := ctx.Value(contextKeyTenantConn).(*sql.Conn)
 	return conn, ok
 }
+
+// WithTenantConn returns a copy of ctx carrying conn for GetTenantConnFromContext
+func WithTenantConn(ctx context.Context, conn *sql.Conn) context.Context {
+	return context.WithValue(ctx, contextKeyTenantConn, conn)
+}
@@ -0,0 +1,93 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidToken is wrapped by the errors of the jwt strategy when a request
+// has no bearer token, or one that is malformed, expired, not yet valid, has
+// a bad signature or lacks the tenant claim. The jwt package's own errors,
+// such as jwt.ErrTokenExpired, are wrapped as well. These errors are not
+// reported as not found: the middleware answers them with a 401.
+var ErrInvalidToken = errors.New("invalid tenant token")
+
+// HMACKeyFunc returns a key function for ResolverConfig.JWTKeyFunc that
+// verifies HS256, HS384 and HS512 tokens with secret and rejects tokens signed
+// with any other method
+func HMACKeyFunc(secret []byte) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+		return secret, nil
+	}
+}
+
+// resolveFromJWT resolves the tenant named by the bearer token's claim. A
+// claim that parses as a UUID is looked up by ID, anything else by subdomain.
+func (r *resolver) resolveFromJWT(ctx context.Context, req *http.Request) (uuid.UUID, error) {
+	value, err := r.extractFromJWT(req)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	var tenantID uuid.UUID
+	if id, parseErr := uuid.Parse(value); parseErr == nil {
+		tenantID, err = r.ResolveByID(ctx, id)
+	} else {
+		tenantID, err = r.ResolveBySubdomain(ctx, value)
+	}
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	r.logger.Debug("Resolved tenant",
+		zap.String("claim", value),
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("strategy", r.config.Strategy))
+
+	return tenantID, nil
+}
+
+// extractFromJWT verifies the bearer token in the Authorization header and
+// returns its tenant claim
+func (r *resolver) extractFromJWT(req *http.Request) (string, error) {
+	if r.config.JWTKeyFunc == nil {
+		return "", errors.New("no key function set for the jwt strategy")
+	}
+
+	scheme, raw, ok := strings.Cut(strings.TrimSpace(req.Header.Get("Authorization")), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(raw) == "" {
+		return "", fmt.Errorf("%w: no bearer token in Authorization header", ErrInvalidToken)
+	}
+
+	var options []jwt.ParserOption
+	if r.config.JWTRequireExpiration {
+		options = append(options, jwt.WithExpirationRequired())
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(strings.TrimSpace(raw), claims, r.config.JWTKeyFunc, options...); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	claimName := r.config.JWTClaim
+	if claimName == "" {
+		claimName = DefaultResolverJWTClaim
+	}
+
+	value, ok := claims[claimName].(string)
+	if !ok || value == "" {
+		return "", fmt.Errorf("%w: no %s claim", ErrInvalidToken, claimName)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,120 @@
+package tenant
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+var testJWTSecret = []byte("test-secret")
+
+// signTestToken signs claims with the test secret
+func signTestToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(testJWTSecret)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+func bearerRequest(token string) *http.Request {
+	req, _ := http.NewRequest("GET", "/api/projects", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func TestResolver_ResolveTenant_JWT(t *testing.T) {
+	tenantID := uuid.New()
+	repo := &mockRepository{
+		tenants: map[uuid.UUID]*Tenant{
+			tenantID: {ID: tenantID, Name: "Test Tenant", Subdomain: "test-tenant", Status: StatusActive},
+		},
+	}
+
+	future := time.Now().Add(time.Hour).Unix()
+	past := time.Now().Add(-time.Hour).Unix()
+
+	valid := signTestToken(t, jwt.MapClaims{"tenant": "test-tenant", "exp": future})
+	// Swap the payload for another tenant's, keeping the original signature
+	parts := strings.Split(valid, ".")
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"tenant":"other-tenant"}`))
+	tampered := strings.Join(parts, ".")
+
+	otherKey, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"tenant": "test-tenant"}).SignedString([]byte("other-secret"))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"tenant": "test-tenant"}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("failed to build unsigned token: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		claim         string
+		requireExpiry bool
+		req           *http.Request
+		wantID        uuid.UUID
+		wantErr       error
+	}{
+		{name: "valid token with subdomain claim", req: bearerRequest(valid), wantID: tenantID},
+		{name: "valid token with ID claim", claim: "tid", req: bearerRequest(signTestToken(t, jwt.MapClaims{"tid": tenantID.String(), "exp": future})), wantID: tenantID},
+		{name: "expired token", req: bearerRequest(signTestToken(t, jwt.MapClaims{"tenant": "test-tenant", "exp": past})), wantErr: jwt.ErrTokenExpired},
+		{name: "tampered payload", req: bearerRequest(tampered), wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "signed with another key", req: bearerRequest(otherKey), wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "unsigned token", req: bearerRequest(unsigned), wantErr: ErrInvalidToken},
+		{name: "malformed token", req: bearerRequest("not.a.token"), wantErr: jwt.ErrTokenMalformed},
+		{name: "missing claim", req: bearerRequest(signTestToken(t, jwt.MapClaims{"sub": "user-1"})), wantErr: ErrInvalidToken},
+		{name: "no authorization header", req: bearerRequest(""), wantErr: ErrInvalidToken},
+		{name: "expiry required", requireExpiry: true, req: bearerRequest(signTestToken(t, jwt.MapClaims{"tenant": "test-tenant"})), wantErr: jwt.ErrTokenRequiredClaimMissing},
+		{name: "expiry required and set", requireExpiry: true, req: bearerRequest(valid), wantID: tenantID},
+		{name: "unknown tenant", req: bearerRequest(signTestToken(t, jwt.MapClaims{"tenant": "missing-tenant"})), wantErr: ErrTenantNotFound},
+		{name: "unknown tenant ID", req: bearerRequest(signTestToken(t, jwt.MapClaims{"tenant": uuid.NewString()})), wantErr: ErrTenantNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := ResolverConfig{Strategy: ResolverJWT, JWTClaim: tt.claim, JWTKeyFunc: HMACKeyFunc(testJWTSecret), JWTRequireExpiration: tt.requireExpiry}
+			r := NewResolver(config, repo, zaptest.NewLogger(t))
+
+			gotID, err := r.ResolveTenant(context.Background(), tt.req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ResolveTenant() error = %v, want %v", err, tt.wantErr)
+				}
+				// Bad tokens are an authentication failure, not a missing tenant
+				if invalidToken := errors.Is(err, ErrInvalidToken); IsNotFound(err) == invalidToken {
+					t.Errorf("ResolveTenant() error = %v, reported as not found = %v, want %v", err, IsNotFound(err), !invalidToken)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveTenant() error = %v", err)
+			}
+			if gotID != tt.wantID {
+				t.Errorf("ResolveTenant() = %v, want %v", gotID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestHMACKeyFunc_RejectsOtherMethods(t *testing.T) {
+	keyFunc := HMACKeyFunc(testJWTSecret)
+	if _, err := keyFunc(jwt.New(jwt.SigningMethodRS256)); err == nil {
+		t.Error("HMACKeyFunc() should reject RS256 tokens")
+	}
+	if _, err := keyFunc(jwt.New(jwt.SigningMethodHS512)); err != nil {
+		t.Errorf("HMACKeyFunc() error = %v for HS512", err)
+	}
+}
@@ -0,0 +1,116 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// labelCache is a concurrency-safe TTL cache of tenant labels. It lets
+// WithTenantTx read a tenant's read-only flag without querying the repository
+// on every transaction; entries are invalidated when labels change through
+// the manager and refreshed once they expire.
+type labelCache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration // 0 disables caching
+	entries map[uuid.UUID]labelCacheEntry
+	gen     uint64 // bumped by invalidate, so that loads racing a write are not cached
+	now     func() time.Time
+}
+
+// labelCacheEntry is a cached copy of a tenant's labels
+type labelCacheEntry struct {
+	labels  []string
+	expires time.Time
+}
+
+// newLabelCache creates a new label cache
+func newLabelCache(ttl time.Duration) *labelCache {
+	return &labelCache{
+		ttl:     ttl,
+		entries: make(map[uuid.UUID]labelCacheEntry),
+		now:     time.Now,
+	}
+}
+
+// get returns the cached labels if they have not expired. The slice is shared
+// and must not be modified.
+func (c *labelCache) get(id uuid.UUID) ([]string, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mu.RLock()
+	entry, ok := c.entries[id]
+	c.mu.RUnlock()
+
+	if !ok || !c.now().Before(entry.expires) {
+		return nil, false
+	}
+	return entry.labels, true
+}
+
+// generation returns the cache's generation; take it before loading labels
+// and pass it to put
+func (c *labelCache) generation() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.gen
+}
+
+// put caches the tenant's labels loaded at generation gen, unless an entry was
+// invalidated since
+func (c *labelCache) put(id uuid.UUID, labels []string, gen uint64) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	if c.gen == gen {
+		c.entries[id] = labelCacheEntry{labels: labels, expires: c.now().Add(c.ttl)}
+	}
+	c.mu.Unlock()
+}
+
+// invalidate removes the tenant's cached labels
+func (c *labelCache) invalidate(id uuid.UUID) {
+	c.mu.Lock()
+	delete(c.entries, id)
+	c.gen++
+	c.mu.Unlock()
+}
+
+// cachedLabels returns the tenant's labels from the label cache, loading and
+// caching them from the repository on a miss. Tenants of repositories without
+// label support, or whose label storage is missing, have no labels.
+func (m *manager) cachedLabels(ctx context.Context, tenantID uuid.UUID) ([]string, error) {
+	labelRepo, ok := m.repository.(LabelRepository)
+	if !ok {
+		return nil, nil
+	}
+	if labels, ok := m.labels.get(tenantID); ok {
+		return labels, nil
+	}
+
+	value, err := m.inits.do("labels", tenantID, func() (interface{}, error) {
+		gen := m.labels.generation()
+		labels, err := labelRepo.GetLabels(ctx, tenantID)
+		if errors.Is(err, ErrLabelsUnavailable) {
+			labels, err = nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		m.labels.put(tenantID, labels, gen)
+		return labels, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant labels: %w", err)
+	}
+	return value.([]string), nil
+}
@@ -0,0 +1,164 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// MaxLabelLength is the longest label AddLabel accepts
+const MaxLabelLength = 100
+
+// validLabel matches labels such as "beta", "cohort:2024-q1" or "rep=jane.doe"
+var validLabel = regexp.MustCompile(`^[a-z0-9]([a-z0-9._:=/-]*[a-z0-9])?$`)
+
+// ErrLabelsUnavailable is returned, wrapped, by LabelRepository.GetLabels when
+// the label storage does not exist, e.g. a database whose tenant_labels table
+// was never created. The manager then treats tenants as having no labels, so
+// they are not read-only.
+var ErrLabelsUnavailable = errors.New("tenant labels are unavailable")
+
+// LabelRepository extends Repository with labels that group tenants, e.g. by
+// sales rep or cohort
+type LabelRepository interface {
+	Repository
+
+	// AddLabel attaches label to the tenant. Adding a label twice is not an error.
+	AddLabel(ctx context.Context, tenantID uuid.UUID, label string) error
+
+	// RemoveLabel detaches label from the tenant. Removing a missing label is not an error.
+	RemoveLabel(ctx context.Context, tenantID uuid.UUID, label string) error
+
+	// GetLabels returns the tenant's labels, sorted
+	GetLabels(ctx context.Context, tenantID uuid.UUID) ([]string, error)
+
+	// ListByLabel returns the tenants carrying label, excluding cancelled tenants
+	ListByLabel(ctx context.Context, label string) ([]*Tenant, error)
+}
+
+// AddLabel attaches a label to a tenant. Labels are lowercased and may contain
+// letters, digits and the separators "._:=/-", e.g. "cohort:2024-q1". The
+// reserved LabelReadOnly and LabelRegionPrefix labels are rejected with a
+// ValidationError; use SetReadOnly and CreateTenantInRegion instead.
+func (m *manager) AddLabel(ctx context.Context, tenantID uuid.UUID, label string) error {
+	labelRepo, err := m.labelRepository(tenantID)
+	if err != nil {
+		return err
+	}
+
+	label, err = normalizeUserLabel(label)
+	if err != nil {
+		return err
+	}
+
+	defer m.labels.invalidate(tenantID)
+	if err := labelRepo.AddLabel(ctx, tenantID, label); err != nil {
+		return fmt.Errorf("failed to add label: %w", err)
+	}
+
+	m.logger.Info("Added tenant label",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("label", label))
+
+	return nil
+}
+
+// RemoveLabel detaches a label from a tenant. Reserved labels are rejected
+// like in AddLabel.
+func (m *manager) RemoveLabel(ctx context.Context, tenantID uuid.UUID, label string) error {
+	labelRepo, err := m.labelRepository(tenantID)
+	if err != nil {
+		return err
+	}
+
+	label, err = normalizeUserLabel(label)
+	if err != nil {
+		return err
+	}
+
+	defer m.labels.invalidate(tenantID)
+	if err := labelRepo.RemoveLabel(ctx, tenantID, label); err != nil {
+		return fmt.Errorf("failed to remove label: %w", err)
+	}
+
+	m.logger.Info("Removed tenant label",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("label", label))
+
+	return nil
+}
+
+// GetLabels returns a tenant's labels, sorted
+func (m *manager) GetLabels(ctx context.Context, tenantID uuid.UUID) ([]string, error) {
+	labelRepo, err := m.labelRepository(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return labelRepo.GetLabels(ctx, tenantID)
+}
+
+// ListByLabel returns the tenants carrying a label
+func (m *manager) ListByLabel(ctx context.Context, label string) ([]*Tenant, error) {
+	labelRepo, err := m.labelRepository(uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+
+	label, err = normalizeLabel(label)
+	if err != nil {
+		return nil, err
+	}
+
+	return labelRepo.ListByLabel(ctx, label)
+}
+
+// labelRepository returns the repository's label support
+func (m *manager) labelRepository(tenantID uuid.UUID) (LabelRepository, error) {
+	labelRepo, ok := m.repository.(LabelRepository)
+	if !ok {
+		return nil, &TenantError{
+			TenantID: tenantID,
+			Code:     "LABELS_UNAVAILABLE",
+			Message:  "repository does not store tenant labels",
+		}
+	}
+	return labelRepo, nil
+}
+
+// normalizeLabel lowercases and trims a label and checks that it is well-formed
+func normalizeLabel(label string) (string, error) {
+	label = strings.ToLower(strings.TrimSpace(label))
+	if label == "" {
+		return "", &ValidationError{Field: "label", Message: "label is required"}
+	}
+	if len(label) > MaxLabelLength {
+		return "", &ValidationError{Field: "label", Message: fmt.Sprintf("label must be at most %d characters", MaxLabelLength)}
+	}
+	if !validLabel.MatchString(label) {
+		return "", &ValidationError{Field: "label", Message: "label may only contain lowercase letters, digits and . _ : = / -"}
+	}
+	return label, nil
+}
+
+// normalizeUserLabel normalizes a label passed to AddLabel or RemoveLabel and
+// rejects the labels the manager maintains itself, so that read-only mode and
+// data residency cannot be changed behind its back
+func normalizeUserLabel(label string) (string, error) {
+	label, err := normalizeLabel(label)
+	if err != nil {
+		return "", err
+	}
+	if label == LabelReadOnly {
+		return "", &ValidationError{Field: "label", Message: fmt.Sprintf("label %q is reserved, use SetReadOnly", label)}
+	}
+	if strings.HasPrefix(label, LabelRegionPrefix) {
+		return "", &ValidationError{Field: "label", Message: fmt.Sprintf("labels starting with %q are reserved, use CreateTenantInRegion", LabelRegionPrefix)}
+	}
+	return label, nil
+}
@@ -0,0 +1,186 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestManager_Labels(t *testing.T) {
+	m, _ := newLabelTestManager(t)
+	ctx := context.Background()
+
+	tenants := make(map[string]*Tenant)
+	for _, subdomain := range []string{"acme", "globex", "initech"} {
+		tenant := &Tenant{Name: subdomain, Subdomain: subdomain, Status: StatusActive}
+		if err := m.CreateTenant(ctx, tenant); err != nil {
+			t.Fatalf("CreateTenant() error = %v", err)
+		}
+		tenants[subdomain] = tenant
+	}
+
+	for subdomain, labels := range map[string][]string{
+		"acme":    {"market:eu", "cohort=2024-q1"},
+		"globex":  {" Market:EU "},
+		"initech": {"market:us"},
+	} {
+		for _, label := range labels {
+			if err := m.AddLabel(ctx, tenants[subdomain].ID, label); err != nil {
+				t.Fatalf("AddLabel(%q) error = %v", label, err)
+			}
+		}
+	}
+
+	// Adding a label again is not an error
+	if err := m.AddLabel(ctx, tenants["acme"].ID, "market:eu"); err != nil {
+		t.Errorf("AddLabel() twice error = %v", err)
+	}
+
+	eu, err := m.ListByLabel(ctx, "market:eu")
+	if err != nil {
+		t.Fatalf("ListByLabel() error = %v", err)
+	}
+	if got := subdomainsOf(eu); got != "acme,globex" {
+		t.Errorf("ListByLabel(market:eu) = %s, want acme,globex", got)
+	}
+
+	labels, err := m.GetLabels(ctx, tenants["acme"].ID)
+	if err != nil {
+		t.Fatalf("GetLabels() error = %v", err)
+	}
+	if got := strings.Join(labels, ","); got != "cohort=2024-q1,market:eu" {
+		t.Errorf("GetLabels() = %s, want cohort=2024-q1,market:eu", got)
+	}
+
+	if err := m.RemoveLabel(ctx, tenants["globex"].ID, "market:eu"); err != nil {
+		t.Fatalf("RemoveLabel() error = %v", err)
+	}
+	eu, err = m.ListByLabel(ctx, "MARKET:EU")
+	if err != nil {
+		t.Fatalf("ListByLabel() error = %v", err)
+	}
+	if got := subdomainsOf(eu); got != "acme" {
+		t.Errorf("ListByLabel(market:eu) after removal = %s, want acme", got)
+	}
+}
+
+func TestManager_Labels_Validation(t *testing.T) {
+	m, repo := newLabelTestManager(t)
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	for _, label := range []string{"", "  ", "has space", "-leading", "trailing:", "emoji✓", strings.Repeat("a", MaxLabelLength+1)} {
+		var validationErr *ValidationError
+		if err := m.AddLabel(ctx, tenantID, label); !errors.As(err, &validationErr) {
+			t.Errorf("AddLabel(%q) error = %v, want a ValidationError", label, err)
+		}
+	}
+	if len(repo.labels) != 0 {
+		t.Errorf("invalid labels were stored: %v", repo.labels)
+	}
+}
+
+func TestManager_Labels_Reserved(t *testing.T) {
+	m, repo := newLabelTestManager(t)
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	for _, label := range []string{LabelReadOnly, " Read-Only ", "region:eu-west", "REGION:us"} {
+		var validationErr *ValidationError
+		if err := m.AddLabel(ctx, tenantID, label); !errors.As(err, &validationErr) {
+			t.Errorf("AddLabel(%q) error = %v, want a ValidationError", label, err)
+		}
+	}
+	if len(repo.labels) != 0 {
+		t.Errorf("reserved labels were stored: %v", repo.labels)
+	}
+
+	// Reserved labels set by the manager cannot be removed either
+	repo.labels[tenantID] = map[string]bool{LabelReadOnly: true, "region:eu-west": true}
+	for _, label := range []string{LabelReadOnly, "region:eu-west"} {
+		var validationErr *ValidationError
+		if err := m.RemoveLabel(ctx, tenantID, label); !errors.As(err, &validationErr) {
+			t.Errorf("RemoveLabel(%q) error = %v, want a ValidationError", label, err)
+		}
+	}
+	if len(repo.labels[tenantID]) != 2 {
+		t.Errorf("labels = %v, want the reserved labels kept", repo.labels[tenantID])
+	}
+}
+
+func TestManager_Labels_Unsupported(t *testing.T) {
+	config := DefaultConfig()
+	m := NewManager(config, nil, NewMockRepository(), NewMockSchemaManager(config.Database.SchemaPrefix),
+		NewMockMigrationManager(), NewMockLimitChecker(config.Limits), zaptest.NewLogger(t)).(*manager)
+	t.Cleanup(func() { m.Close() })
+
+	var tenantErr *TenantError
+	if err := m.AddLabel(context.Background(), uuid.New(), "beta"); !errors.As(err, &tenantErr) || tenantErr.Code != "LABELS_UNAVAILABLE" {
+		t.Errorf("AddLabel() error = %v, want LABELS_UNAVAILABLE", err)
+	}
+	if _, err := m.ListByLabel(context.Background(), "beta"); !errors.As(err, &tenantErr) || tenantErr.Code != "LABELS_UNAVAILABLE" {
+		t.Errorf("ListByLabel() error = %v, want LABELS_UNAVAILABLE", err)
+	}
+}
+
+// labelRepository adds label storage to the manager mock repository
+type labelRepository struct {
+	*MockManagerRepository
+	labels map[uuid.UUID]map[string]bool
+}
+
+func (r *labelRepository) AddLabel(ctx context.Context, tenantID uuid.UUID, label string) error {
+	if r.labels[tenantID] == nil {
+		r.labels[tenantID] = make(map[string]bool)
+	}
+	r.labels[tenantID][label] = true
+	return nil
+}
+
+func (r *labelRepository) RemoveLabel(ctx context.Context, tenantID uuid.UUID, label string) error {
+	delete(r.labels[tenantID], label)
+	return nil
+}
+
+func (r *labelRepository) GetLabels(ctx context.Context, tenantID uuid.UUID) ([]string, error) {
+	var labels []string
+	for label := range r.labels[tenantID] {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels, nil
+}
+
+func (r *labelRepository) ListByLabel(ctx context.Context, label string) ([]*Tenant, error) {
+	var tenants []*Tenant
+	for tenantID, labels := range r.labels {
+		if labels[label] {
+			tenants = append(tenants, r.tenants[tenantID])
+		}
+	}
+	return tenants, nil
+}
+
+// newLabelTestManager returns a manager whose repository stores labels
+func newLabelTestManager(t *testing.T) (*manager, *labelRepository) {
+	repo := &labelRepository{
+		MockManagerRepository: NewMockRepository(),
+		labels:                make(map[uuid.UUID]map[string]bool),
+	}
+	return newTestManager(t, withRepository(repo)), repo
+}
+
+// subdomainsOf returns the tenants' subdomains, sorted and comma-separated
+func subdomainsOf(tenants []*Tenant) string {
+	subdomains := make([]string, 0, len(tenants))
+	for _, tenant := range tenants {
+		subdomains = append(subdomains, tenant.Subdomain)
+	}
+	sort.Strings(subdomains)
+	return strings.Join(subdomains, ",")
+}
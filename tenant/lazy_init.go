@@ -0,0 +1,24 @@
+package tenant
+
+import (
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+)
+
+// lazyInit deduplicates concurrent initialization of per-tenant resources. The
+// first caller for a tenant's resource runs the initializer while concurrent
+// callers for the same resource wait and share its result, so that a burst of
+// first requests to a tenant opens one pool or performs one lookup. Results
+// are not retained; callers cache them and check the cache again inside the
+// initializer.
+type lazyInit struct {
+	group singleflight.Group
+}
+
+// do runs init once for concurrent calls with the same resource and tenant.
+// Callers that join a running initialization share its result, including an
+// error caused by the first caller's context being cancelled.
+func (l *lazyInit) do(resource string, tenantID uuid.UUID, init func() (interface{}, error)) (interface{}, error) {
+	value, err, _ := l.group.Do(resource+":"+tenantID.String(), init)
+	return value, err
+}
@@ -2,7 +2,10 @@ package tenant
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -14,14 +17,17 @@ type LimitChecker interface {
 	CheckLimit(ctx context.Context, tenantID uuid.UUID, limitName string, currentValue interface{}) error
 	CheckLimitByDefinition(ctx context.Context, tenantID uuid.UUID, def *LimitDefinition, currentValue interface{}) error
 	CheckAllLimits(ctx context.Context, tenantID uuid.UUID) error
+	CheckLimitAndConsume(ctx context.Context, tenantID uuid.UUID, limitName string, amount int) (remaining int, err error)
 
 	// Schema management
 	GetLimitSchema() *LimitSchema
 	SetLimitSchema(schema *LimitSchema)
+	AddDefinition(def *LimitDefinition) error
+	RemoveDefinition(name string) error
 
 	// Plan limit management
 	GetLimitsForPlan(planType string) FlexibleLimits
-	SetLimitsForPlan(planType string, limits FlexibleLimits)
+	SetLimitsForPlan(planType string, limits FlexibleLimits) error
 
 	// Limit management
 	AddLimit(planType, limitName string, limitType LimitType, value interface{}) error
@@ -34,16 +40,41 @@ type LimitChecker interface {
 	// Usage integration
 	SetUsageTracker(tracker UsageTracker)
 	GetUsageTracker() UsageTracker
+
+	// Threshold events
+	SetEventPublisher(publisher EventPublisher)
+
+	// Denial records, one per rejected check; logged by default
+	SetDenialSink(sink DenialSink)
+
+	// Metrics, counting rejected checks by plan and limit
+	SetMetricsCollector(collector MetricsCollector)
+
+	// Per-tenant overrides
+	SetOverrideProvider(provider OverrideProvider)
+	SetTenantLimitOverride(ctx context.Context, tenantID uuid.UUID, limitName string, value *LimitValue) error
+	GetLimitsForTenant(ctx context.Context, tenantID uuid.UUID) (FlexibleLimits, error)
 }
 
 // limitChecker implements the LimitChecker interface
 type limitChecker struct {
+	mu           sync.RWMutex
 	config       LimitsConfig
 	repository   Repository
 	logger       *zap.Logger
 	schema       *LimitSchema
-	planLimits   map[string]FlexibleLimits
+	planLimits   map[string]FlexibleLimits // copy-on-write: a plan's limits are replaced, never modified
 	usageTracker UsageTracker
+	overrides    OverrideProvider
+	stored       *overrideCache // limit overrides stored in tenants' metadata
+	publisher    EventPublisher
+	denials      DenialSink
+	metrics      MetricsCollector
+	thresholds   *thresholdState
+	schemaStore  SchemaStore
+	planStore    PlanLimitStore
+	consumeMu    sync.Mutex // serializes CheckLimitAndConsume for trackers that are not UsageConsumers
+	writeMu      sync.Mutex // serializes schema and plan limit changes; held across store writes, so mu is not held during store I/O
 }
 
 // NewLimitChecker creates a new limit checker
@@ -53,17 +84,20 @@ func NewLimitChecker(config LimitsConfig, repository Repository, logger *zap.Log
 		repository: repository,
 		logger:     logger.Named("limits"),
 		schema:     config.LimitSchema,
-		planLimits: config.PlanLimits,
+		planLimits: make(map[string]FlexibleLimits, len(config.PlanLimits)),
+		thresholds: &thresholdState{fired: make(map[thresholdKey]time.Time)},
+		stored:     newOverrideCache(config.OverrideCacheTTL),
 	}
+	checker.denials = NewLogDenialSink(checker.logger.Named("denials"))
 
 	// Use default schema if none provided
 	if checker.schema == nil {
 		checker.schema = DefaultLimitSchema()
 	}
 
-	// Initialize plan limits if empty
-	if checker.planLimits == nil {
-		checker.planLimits = make(map[string]FlexibleLimits)
+	// Copy the plan limits, so that changes at runtime leave the config alone
+	for planType, limits := range config.PlanLimits {
+		checker.planLimits[planType] = limits.Clone()
 	}
 
 	return checker
@@ -81,17 +115,20 @@ func (lc *limitChecker) CheckLimit(ctx context.Context, tenantID uuid.UUID, limi
 		return fmt.Errorf("failed to get tenant: %w", err)
 	}
 
-	// Get plan limits
-	planLimits := lc.GetLimitsForPlan(tenant.PlanType)
+	// Get plan limits, with any tenant overrides applied
+	planLimits := lc.limitsForTenant(ctx, tenant)
 	if planLimits == nil {
 		lc.logger.Warn("No limits found for plan", zap.String("plan", tenant.PlanType))
 		return nil
 	}
 
-	// Get the specific limit
+	// Get the specific limit: an override, else the plan's, else the schema default
 	limit, exists := planLimits.Get(limitName)
 	if !exists {
-		// If limit doesn't exist in plan, it's not restricted
+		limit, exists = lc.schemaDefault(limitName)
+	}
+	if !exists {
+		// If limit doesn't exist in plan or schema, it's not restricted
 		lc.logger.Debug("Limit not defined for plan",
 			zap.String("limit", limitName),
 			zap.String("plan", tenant.PlanType))
@@ -104,8 +141,8 @@ func (lc *limitChecker) CheckLimit(ctx context.Context, tenantID uuid.UUID, limi
 	}
 
 	// Get current usage if not provided
-	if currentValue == nil && lc.usageTracker != nil {
-		currentValue, err = lc.usageTracker.GetCurrentUsage(ctx, tenantID, limitName)
+	if tracker := lc.GetUsageTracker(); currentValue == nil && tracker != nil {
+		currentValue, err = tracker.GetCurrentUsage(ctx, tenantID, limitName)
 		if err != nil {
 			lc.logger.Warn("Failed to get current usage, skipping limit check",
 				zap.String("tenant_id", tenantID.String()),
@@ -115,8 +152,15 @@ func (lc *limitChecker) CheckLimit(ctx context.Context, tenantID uuid.UUID, limi
 		}
 	}
 
-	// Perform validation
-	return lc.validateLimit(tenantID, limitName, limit, currentValue)
+	// Notify about usage approaching the limit, then validate
+	lc.checkThresholds(ctx, tenantID, limitName, limit, currentValue)
+	err = lc.validateLimit(tenantID, limitName, limit, currentValue)
+
+	var exceeded *LimitExceededError
+	if errors.As(err, &exceeded) {
+		lc.recordDenial(ctx, tenant, exceeded)
+	}
+	return err
 }
 
 // CheckLimitByDefinition checks a limit using its definition
@@ -136,8 +180,8 @@ func (lc *limitChecker) CheckAllLimits(ctx context.Context, tenantID uuid.UUID)
 		return fmt.Errorf("failed to get tenant: %w", err)
 	}
 
-	// Get plan limits
-	planLimits := lc.GetLimitsForPlan(tenant.PlanType)
+	// Get plan limits, with any tenant overrides applied
+	planLimits := lc.limitsForTenant(ctx, tenant)
 	if planLimits == nil {
 		return fmt.Errorf("no limits found for plan: %s", tenant.PlanType)
 	}
@@ -198,9 +242,12 @@ func (lc *limitChecker) validateIntLimit(tenantID uuid.UUID, limitName string, l
 	}
 
 	if current > limitVal {
-		return &TenantError{
+		return &LimitExceededError{
 			TenantID: tenantID,
 			Code:     "LIMIT_EXCEEDED",
+			Limit:    limitName,
+			Value:    limitVal,
+			Current:  current,
 			Message:  fmt.Sprintf("Limit exceeded for %s: current=%d, limit=%d", limitName, current, limitVal),
 		}
 	}
@@ -229,9 +276,12 @@ func (lc *limitChecker) validateFloatLimit(tenantID uuid.UUID, limitName string,
 	}
 
 	if current > limitVal {
-		return &TenantError{
+		return &LimitExceededError{
 			TenantID: tenantID,
 			Code:     "LIMIT_EXCEEDED",
+			Limit:    limitName,
+			Value:    limitVal,
+			Current:  current,
 			Message:  fmt.Sprintf("Limit exceeded for %s: current=%.2f, limit=%.2f", limitName, current, limitVal),
 		}
 	}
@@ -253,9 +303,12 @@ func (lc *limitChecker) validateStringLimit(tenantID uuid.UUID, limitName string
 	// String validation can be customized based on the limit name
 	// For now, implement basic length comparison
 	if len(current) > len(limitVal) && limitVal != "unlimited" && limitVal != "" {
-		return &TenantError{
+		return &LimitExceededError{
 			TenantID: tenantID,
 			Code:     "LIMIT_EXCEEDED",
+			Limit:    limitName,
+			Value:    limitVal,
+			Current:  current,
 			Message:  fmt.Sprintf("String limit exceeded for %s: current length=%d, limit length=%d", limitName, len(current), len(limitVal)),
 		}
 	}
@@ -276,9 +329,12 @@ func (lc *limitChecker) validateBoolLimit(tenantID uuid.UUID, limitName string,
 
 	// For boolean limits, if limit is false and current usage is true, it's exceeded
 	if !limitVal && current {
-		return &TenantError{
+		return &LimitExceededError{
 			TenantID: tenantID,
 			Code:     "FEATURE_NOT_ALLOWED",
+			Limit:    limitName,
+			Value:    limitVal,
+			Current:  current,
 			Message:  fmt.Sprintf("Feature not allowed: %s is disabled for this plan", limitName),
 		}
 	}
@@ -287,15 +343,38 @@ func (lc *limitChecker) validateBoolLimit(tenantID uuid.UUID, limitName string,
 }
 
 func (lc *limitChecker) validateDurationLimit(tenantID uuid.UUID, limitName string, limit *LimitValue, currentValue interface{}) error {
-	_, err := limit.Duration()
+	if limit.IsUnlimited() {
+		return nil
+	}
+
+	limitVal, err := limit.Duration()
 	if err != nil {
 		return fmt.Errorf("invalid limit value for %s: %w", limitName, err)
 	}
 
-	// Current value could be a duration or time that needs comparison
-	// Implementation depends on specific use case
-	lc.logger.Debug("Duration limit validation not fully implemented",
-		zap.String("limit", limitName))
+	var current time.Duration
+	switch v := currentValue.(type) {
+	case time.Duration:
+		current = v
+	case string:
+		current, err = time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q for %s: %w", v, limitName, err)
+		}
+	default:
+		return fmt.Errorf("cannot compare %T with duration limit for %s", currentValue, limitName)
+	}
+
+	if current > limitVal {
+		return &LimitExceededError{
+			TenantID: tenantID,
+			Code:     "LIMIT_EXCEEDED",
+			Limit:    limitName,
+			Value:    limitVal,
+			Current:  current,
+			Message:  fmt.Sprintf("Limit exceeded for %s: current=%s, limit=%s", limitName, current, limitVal),
+		}
+	}
 
 	return nil
 }
@@ -303,30 +382,145 @@ func (lc *limitChecker) validateDurationLimit(tenantID uuid.UUID, limitName stri
 // Schema management
 
 func (lc *limitChecker) GetLimitSchema() *LimitSchema {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
 	return lc.schema
 }
 
 func (lc *limitChecker) SetLimitSchema(schema *LimitSchema) {
+	lc.writeMu.Lock()
+	defer lc.writeMu.Unlock()
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
 	lc.schema = schema
 }
 
+// AddDefinition validates a limit definition and adds it to the live schema
+func (lc *limitChecker) AddDefinition(def *LimitDefinition) error {
+	if def == nil {
+		return &ValidationError{Field: "definition", Message: "limit definition is required"}
+	}
+	if err := def.Validate(); err != nil {
+		return err
+	}
+
+	lc.writeMu.Lock()
+	defer lc.writeMu.Unlock()
+
+	if _, exists := lc.schema.GetDefinition(def.Name); exists {
+		return fmt.Errorf("limit definition %s already exists", def.Name)
+	}
+
+	if err := lc.saveDefinition(def); err != nil {
+		return err
+	}
+
+	lc.schema.AddDefinition(def)
+
+	lc.logger.Info("Added limit definition",
+		zap.String("limit", def.Name),
+		zap.String("type", string(def.Type)),
+		zap.String("category", def.Category))
+
+	return nil
+}
+
+// RemoveDefinition removes a limit definition from the live schema.
+// Definitions that are still referenced by a plan cannot be removed.
+func (lc *limitChecker) RemoveDefinition(name string) error {
+	lc.writeMu.Lock()
+	defer lc.writeMu.Unlock()
+
+	if _, exists := lc.schema.GetDefinition(name); !exists {
+		return fmt.Errorf("limit definition %s not found", name)
+	}
+
+	for planType, limits := range lc.planLimits {
+		if limits.Has(name) {
+			return fmt.Errorf("limit definition %s is still used by plan %s", name, planType)
+		}
+	}
+
+	if err := lc.deleteDefinition(name); err != nil {
+		return err
+	}
+
+	lc.schema.RemoveDefinition(name)
+
+	lc.logger.Info("Removed limit definition",
+		zap.String("limit", name))
+
+	return nil
+}
+
 // Plan limit management
 
+// GetLimitsForPlan returns a copy of the plan's limits, or nil if the plan
+// has none
 func (lc *limitChecker) GetLimitsForPlan(planType string) FlexibleLimits {
+	return lc.planLimitsFor(planType).Clone()
+}
+
+// planLimitsFor returns the plan's current limits without copying them. The
+// result is shared and must not be modified.
+func (lc *limitChecker) planLimitsFor(planType string) FlexibleLimits {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
 	return lc.planLimits[planType]
 }
 
-func (lc *limitChecker) SetLimitsForPlan(planType string, limits FlexibleLimits) {
+// SetLimitsForPlan replaces the plan's limits. With a plan limit store, the
+// change is persisted first and the limits are left as they were if that
+// fails.
+func (lc *limitChecker) SetLimitsForPlan(planType string, limits FlexibleLimits) error {
+	lc.writeMu.Lock()
+	defer lc.writeMu.Unlock()
+
+	// Persist removals first so limits dropped from the plan stay dropped
+	for name := range lc.planLimits[planType] {
+		if !limits.Has(name) {
+			if err := lc.deletePlanLimit(planType, name); err != nil {
+				return err
+			}
+		}
+	}
+	for name, value := range limits {
+		if err := lc.savePlanLimit(planType, name, value); err != nil {
+			return err
+		}
+	}
+
+	lc.setPlanLimits(planType, limits.Clone())
+	return nil
+}
+
+// setPlanLimits installs new limits for the plan. Callers hold writeMu, which
+// also lets them read lc.planLimits without mu.
+func (lc *limitChecker) setPlanLimits(planType string, limits FlexibleLimits) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
 	lc.planLimits[planType] = limits
 }
 
 // Limit management
 
 func (lc *limitChecker) AddLimit(planType, limitName string, limitType LimitType, value interface{}) error {
-	// Validate limit definition exists in schema
-	if _, exists := lc.schema.GetDefinition(limitName); !exists {
-		// Add to schema if not exists
-		def := &LimitDefinition{
+	lc.writeMu.Lock()
+	defer lc.writeMu.Unlock()
+
+	limit := &LimitValue{
+		Type:  limitType,
+		Value: value,
+	}
+
+	// Check the value against the schema's definition, adding one if there is none
+	if def, exists := lc.schema.GetDefinition(limitName); exists {
+		if err := def.CheckRange(limit); err != nil {
+			return err
+		}
+	} else {
+		def = &LimitDefinition{
 			Name:        limitName,
 			DisplayName: limitName,
 			Description: fmt.Sprintf("Custom limit: %s", limitName),
@@ -334,18 +528,23 @@ func (lc *limitChecker) AddLimit(planType, limitName string, limitType LimitType
 			Required:    false,
 			Category:    "custom",
 		}
+		if err := lc.saveDefinition(def); err != nil {
+			return err
+		}
 		lc.schema.AddDefinition(def)
 	}
 
-	// Add to plan limits
-	if lc.planLimits[planType] == nil {
-		lc.planLimits[planType] = make(FlexibleLimits)
+	if err := lc.savePlanLimit(planType, limitName, limit); err != nil {
+		return err
 	}
 
-	lc.planLimits[planType][limitName] = &LimitValue{
-		Type:  limitType,
-		Value: value,
+	// Add to a copy of the plan limits, so that earlier readers keep theirs
+	updated := lc.planLimits[planType].Clone()
+	if updated == nil {
+		updated = make(FlexibleLimits)
 	}
+	updated[limitName] = limit
+	lc.setPlanLimits(planType, updated)
 
 	lc.logger.Info("Added limit to plan",
 		zap.String("plan", planType),
@@ -357,8 +556,16 @@ func (lc *limitChecker) AddLimit(planType, limitName string, limitType LimitType
 }
 
 func (lc *limitChecker) RemoveLimit(planType, limitName string) error {
+	lc.writeMu.Lock()
+	defer lc.writeMu.Unlock()
+
 	if planLimits, exists := lc.planLimits[planType]; exists {
-		delete(planLimits, limitName)
+		if err := lc.deletePlanLimit(planType, limitName); err != nil {
+			return err
+		}
+		updated := planLimits.Clone()
+		delete(updated, limitName)
+		lc.setPlanLimits(planType, updated)
 		lc.logger.Info("Removed limit from plan",
 			zap.String("plan", planType),
 			zap.String("limit", limitName))
@@ -367,6 +574,9 @@ func (lc *limitChecker) RemoveLimit(planType, limitName string) error {
 }
 
 func (lc *limitChecker) UpdateLimit(planType, limitName string, value interface{}) error {
+	lc.writeMu.Lock()
+	defer lc.writeMu.Unlock()
+
 	planLimits := lc.planLimits[planType]
 	if planLimits == nil {
 		return fmt.Errorf("plan %s not found", planType)
@@ -377,7 +587,19 @@ func (lc *limitChecker) UpdateLimit(planType, limitName string, value interface{
 		return fmt.Errorf("limit %s not found in plan %s", limitName, planType)
 	}
 
-	limit.Value = value
+	updated := &LimitValue{Type: limit.Type, Value: value}
+	if def, exists := lc.schema.GetDefinition(limitName); exists {
+		if err := def.CheckRange(updated); err != nil {
+			return err
+		}
+	}
+	if err := lc.savePlanLimit(planType, limitName, updated); err != nil {
+		return err
+	}
+
+	limits := planLimits.Clone()
+	limits[limitName] = updated
+	lc.setPlanLimits(planType, limits)
 
 	lc.logger.Info("Updated limit value",
 		zap.String("plan", planType),
@@ -390,15 +612,19 @@ func (lc *limitChecker) UpdateLimit(planType, limitName string, value interface{
 // Validation
 
 func (lc *limitChecker) ValidateLimits(planType string, limits FlexibleLimits) error {
-	return lc.schema.ValidateLimits(limits)
+	return lc.GetLimitSchema().ValidateLimits(limits)
 }
 
 // Usage tracker integration
 
 func (lc *limitChecker) SetUsageTracker(tracker UsageTracker) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
 	lc.usageTracker = tracker
 }
 
 func (lc *limitChecker) GetUsageTracker() UsageTracker {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
 	return lc.usageTracker
 }
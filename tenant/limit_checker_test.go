@@ -2,6 +2,9 @@ package tenant
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -206,6 +209,43 @@ func TestLimitChecker_CheckAllLimits(t *testing.T) {
 	}
 }
 
+func TestLimitChecker_CheckAllLimits_ReportsExceededLimit(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	proLimits := make(FlexibleLimits)
+	proLimits.Set("video_processing_minutes", LimitTypeInt, 600)
+
+	config := LimitsConfig{
+		EnforceLimits: true,
+		PlanLimits:    map[string]FlexibleLimits{PlanPro: proLimits},
+	}
+
+	tenantID := uuid.New()
+	mockRepo := &MockLimitCheckerRepository{
+		tenants: map[uuid.UUID]*Tenant{
+			tenantID: {ID: tenantID, PlanType: PlanPro, Status: StatusActive},
+		},
+	}
+
+	checker := NewLimitChecker(config, mockRepo, logger)
+	checker.SetUsageTracker(usageTrackerFunc(func(limitName string) (interface{}, error) {
+		return 750, nil
+	}))
+
+	err := checker.CheckAllLimits(context.Background(), tenantID)
+
+	var limitErr *LimitExceededError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("CheckAllLimits() error = %v, want a LimitExceededError", err)
+	}
+	if limitErr.Limit != "video_processing_minutes" || limitErr.Value != 600 || limitErr.Current != 750 {
+		t.Errorf("LimitExceededError = %+v, want video_processing_minutes at 750 of 600", limitErr)
+	}
+	if limitErr.TenantID != tenantID || limitErr.Code != "LIMIT_EXCEEDED" {
+		t.Errorf("LimitExceededError = %+v, want LIMIT_EXCEEDED for the tenant", limitErr)
+	}
+}
+
 func TestLimitChecker_PlanLimitManagement(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	config := LimitsConfig{
@@ -226,7 +266,9 @@ func TestLimitChecker_PlanLimitManagement(t *testing.T) {
 	// Test setting plan limits
 	testLimits := make(FlexibleLimits)
 	testLimits.Set("max_users", LimitTypeInt, 10)
-	checker.SetLimitsForPlan(PlanBasic, testLimits)
+	if err := checker.SetLimitsForPlan(PlanBasic, testLimits); err != nil {
+		t.Fatalf("SetLimitsForPlan() error = %v", err)
+	}
 
 	// Test getting plan limits
 	retrievedLimits := checker.GetLimitsForPlan(PlanBasic)
@@ -273,6 +315,8 @@ func TestLimitChecker_LimitManagement(t *testing.T) {
 		t.Errorf("UpdateLimit() error = %v, want nil", err)
 	}
 
+	// GetLimitsForPlan returns a copy, so fetch the plan's limits again
+	limits = checker.GetLimitsForPlan(PlanBasic)
 	if val, err := limits.GetInt("test_limit"); err != nil || val != 10 {
 		t.Errorf("Updated limit incorrect: got %v, want 10", val)
 	}
@@ -283,7 +327,7 @@ func TestLimitChecker_LimitManagement(t *testing.T) {
 		t.Errorf("RemoveLimit() error = %v, want nil", err)
 	}
 
-	if limits.Has("test_limit") {
+	if checker.GetLimitsForPlan(PlanBasic).Has("test_limit") {
 		t.Error("Limit should be removed after RemoveLimit()")
 	}
 
@@ -294,6 +338,115 @@ func TestLimitChecker_LimitManagement(t *testing.T) {
 	}
 }
 
+func TestLimitChecker_LimitManagement_EnforcesSchemaRange(t *testing.T) {
+	schema := DefaultLimitSchema()
+	schema.AddDefinition(&LimitDefinition{
+		Name:     "max_seats",
+		Type:     LimitTypeInt,
+		MinValue: &LimitValue{Type: LimitTypeInt, Value: 1},
+		MaxValue: &LimitValue{Type: LimitTypeInt, Value: 500},
+	})
+	schema.AddDefinition(&LimitDefinition{
+		Name:     "max_request_time",
+		Type:     LimitTypeDuration,
+		MaxValue: &LimitValue{Type: LimitTypeDuration, Value: "30s"},
+	})
+
+	config := LimitsConfig{
+		EnforceLimits: true,
+		PlanLimits:    make(map[string]FlexibleLimits),
+		LimitSchema:   schema,
+	}
+	checker := NewLimitChecker(config, &MockLimitCheckerRepository{tenants: make(map[uuid.UUID]*Tenant)}, zaptest.NewLogger(t))
+
+	tests := []struct {
+		name      string
+		limitName string
+		limitType LimitType
+		value     interface{}
+		wantErr   bool
+	}{
+		{name: "in range", limitName: "max_seats", limitType: LimitTypeInt, value: 25},
+		{name: "at the bounds", limitName: "max_seats", limitType: LimitTypeInt, value: 500},
+		{name: "below min", limitName: "max_seats", limitType: LimitTypeInt, value: -5, wantErr: true},
+		{name: "above max", limitName: "max_seats", limitType: LimitTypeInt, value: 501, wantErr: true},
+		{name: "unlimited is exempt from min", limitName: "max_seats", limitType: LimitTypeInt, value: -1},
+		{name: "duration in range", limitName: "max_request_time", limitType: LimitTypeDuration, value: "10s"},
+		{name: "duration above max", limitName: "max_request_time", limitType: LimitTypeDuration, value: "1m", wantErr: true},
+		{name: "limit without a range", limitName: "custom_limit", limitType: LimitTypeInt, value: -5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checker.AddLimit(PlanPro, tt.limitName, tt.limitType, tt.value)
+			assertRangeError(t, "AddLimit()", err, tt.wantErr)
+
+			// UpdateLimit checks the same range
+			if err := checker.AddLimit(PlanBasic, tt.limitName, tt.limitType, schemaRangeSeed(tt.limitType)); err != nil {
+				t.Fatalf("AddLimit() seed error = %v", err)
+			}
+			err = checker.UpdateLimit(PlanBasic, tt.limitName, tt.value)
+			assertRangeError(t, "UpdateLimit()", err, tt.wantErr)
+
+			limit, _ := checker.GetLimitsForPlan(PlanBasic).Get(tt.limitName)
+			if tt.wantErr && limit.Value != schemaRangeSeed(tt.limitType) {
+				t.Errorf("rejected UpdateLimit() changed the value to %v", limit.Value)
+			}
+		})
+	}
+}
+
+func TestLimitChecker_LimitManagement_RejectsSchemaTypeMismatch(t *testing.T) {
+	schema := DefaultLimitSchema()
+	schema.AddDefinition(&LimitDefinition{
+		Name:     "max_seats",
+		Type:     LimitTypeInt,
+		MinValue: &LimitValue{Type: LimitTypeInt, Value: 1},
+	})
+	config := LimitsConfig{
+		EnforceLimits: true,
+		PlanLimits:    make(map[string]FlexibleLimits),
+		LimitSchema:   schema,
+	}
+	checker := NewLimitChecker(config, &MockLimitCheckerRepository{tenants: make(map[uuid.UUID]*Tenant)}, zaptest.NewLogger(t))
+
+	err := checker.AddLimit(PlanBasic, "max_seats", LimitTypeFloat, -5.0)
+	assertRangeError(t, "AddLimit() with the wrong type", err, true)
+	err = checker.AddLimit(PlanBasic, "max_seats", LimitTypeInt, "many")
+	assertRangeError(t, "AddLimit() with an unreadable value", err, true)
+	if checker.GetLimitsForPlan(PlanBasic).Has("max_seats") {
+		t.Error("rejected AddLimit() should not add the limit")
+	}
+
+	if err := checker.AddLimit(PlanBasic, "max_seats", LimitTypeInt, 10); err != nil {
+		t.Fatalf("AddLimit() error = %v", err)
+	}
+	err = checker.UpdateLimit(PlanBasic, "max_seats", "many")
+	assertRangeError(t, "UpdateLimit() with an unreadable value", err, true)
+}
+
+// schemaRangeSeed is a value within every range used by the schema range test
+func schemaRangeSeed(limitType LimitType) interface{} {
+	if limitType == LimitTypeDuration {
+		return "5s"
+	}
+	return 10
+}
+
+func assertRangeError(t *testing.T, call string, err error, wantErr bool) {
+	t.Helper()
+	if !wantErr {
+		if err != nil {
+			t.Errorf("%s error = %v, want nil", call, err)
+		}
+		return
+	}
+	var validation *ValidationError
+	if !errors.As(err, &validation) {
+		t.Errorf("%s error = %v, want a ValidationError", call, err)
+	}
+}
+
 func TestLimitChecker_SchemaManagement(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	schema := DefaultLimitSchema()
@@ -323,6 +476,198 @@ func TestLimitChecker_SchemaManagement(t *testing.T) {
 	}
 }
 
+func TestLimitChecker_AddDefinition(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	config := LimitsConfig{
+		EnforceLimits: true,
+		DefaultPlan:   PlanBasic,
+		PlanLimits:    make(map[string]FlexibleLimits),
+		LimitSchema:   DefaultLimitSchema(),
+	}
+
+	tenantID := uuid.New()
+	mockRepo := &MockLimitCheckerRepository{
+		tenants: map[uuid.UUID]*Tenant{
+			tenantID: {ID: tenantID, PlanType: PlanBasic, Status: StatusActive},
+		},
+	}
+	checker := NewLimitChecker(config, mockRepo, logger)
+
+	def := &LimitDefinition{
+		Name:         "video_minutes",
+		DisplayName:  "Video Minutes",
+		Description:  "Monthly video processing minutes",
+		Type:         LimitTypeInt,
+		DefaultValue: IntLimit(60),
+		Category:     "media",
+	}
+
+	if err := checker.AddDefinition(def); err != nil {
+		t.Fatalf("AddDefinition() error = %v, want nil", err)
+	}
+
+	if _, exists := checker.GetLimitSchema().GetDefinition("video_minutes"); !exists {
+		t.Error("AddDefinition() should add the definition to the live schema")
+	}
+
+	// Adding the same definition twice should fail
+	if err := checker.AddDefinition(def); err == nil {
+		t.Error("AddDefinition() should error for duplicate definition")
+	}
+
+	// The new limit can be assigned to a plan and checked
+	if err := checker.AddLimit(PlanBasic, "video_minutes", LimitTypeInt, 30); err != nil {
+		t.Fatalf("AddLimit() error = %v, want nil", err)
+	}
+	if err := checker.CheckLimit(context.Background(), tenantID, "video_minutes", 20); err != nil {
+		t.Errorf("CheckLimit() error = %v, want nil", err)
+	}
+	if err := checker.CheckLimit(context.Background(), tenantID, "video_minutes", 45); err == nil {
+		t.Error("CheckLimit() should error when new limit is exceeded")
+	}
+
+	// Definitions still used by a plan cannot be removed
+	if err := checker.RemoveDefinition("video_minutes"); err == nil {
+		t.Error("RemoveDefinition() should error while a plan uses the limit")
+	}
+
+	if err := checker.RemoveLimit(PlanBasic, "video_minutes"); err != nil {
+		t.Fatalf("RemoveLimit() error = %v, want nil", err)
+	}
+	if err := checker.RemoveDefinition("video_minutes"); err != nil {
+		t.Errorf("RemoveDefinition() error = %v, want nil", err)
+	}
+	if _, exists := checker.GetLimitSchema().GetDefinition("video_minutes"); exists {
+		t.Error("RemoveDefinition() should remove the definition from the schema")
+	}
+
+	if err := checker.RemoveDefinition("video_minutes"); err == nil {
+		t.Error("RemoveDefinition() should error for unknown definition")
+	}
+}
+
+func TestLimitChecker_AddDefinition_Invalid(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	config := LimitsConfig{
+		EnforceLimits: true,
+		PlanLimits:    make(map[string]FlexibleLimits),
+		LimitSchema:   DefaultLimitSchema(),
+	}
+
+	mockRepo := &MockLimitCheckerRepository{tenants: make(map[uuid.UUID]*Tenant)}
+	checker := NewLimitChecker(config, mockRepo, logger)
+
+	tests := []struct {
+		name string
+		def  *LimitDefinition
+	}{
+		{
+			name: "nil definition",
+			def:  nil,
+		},
+		{
+			name: "missing name",
+			def:  &LimitDefinition{Type: LimitTypeInt},
+		},
+		{
+			name: "unknown type",
+			def:  &LimitDefinition{Name: "bad_type", Type: LimitType("decimal")},
+		},
+		{
+			name: "default type mismatch",
+			def:  &LimitDefinition{Name: "mismatch", Type: LimitTypeInt, DefaultValue: BoolLimit(true)},
+		},
+		{
+			name: "default value not convertible",
+			def:  &LimitDefinition{Name: "bad_value", Type: LimitTypeInt, DefaultValue: &LimitValue{Type: LimitTypeInt, Value: "ten"}},
+		},
+		{
+			name: "max type mismatch",
+			def:  &LimitDefinition{Name: "bad_max", Type: LimitTypeFloat, MaxValue: IntLimit(10)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checker.AddDefinition(tt.def); err == nil {
+				t.Error("AddDefinition() should error for invalid definition")
+			}
+		})
+	}
+}
+
+func TestLimitChecker_ConcurrentAccess(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	config := LimitsConfig{
+		EnforceLimits: true,
+		PlanLimits:    make(map[string]FlexibleLimits),
+		LimitSchema:   DefaultLimitSchema(),
+	}
+
+	mockRepo := &MockLimitCheckerRepository{tenants: make(map[uuid.UUID]*Tenant)}
+	checker := NewLimitChecker(config, mockRepo, logger)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("concurrent_%d", i)
+			if err := checker.AddDefinition(&LimitDefinition{Name: name, Type: LimitTypeInt}); err != nil {
+				t.Errorf("AddDefinition() error = %v", err)
+			}
+			checker.GetLimitsForPlan(PlanBasic)
+			checker.GetLimitSchema().Describe()
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestLimitChecker_GetLimitsForPlanReturnsCopy(t *testing.T) {
+	basicLimits := make(FlexibleLimits)
+	basicLimits.Set("max_users", LimitTypeInt, 10)
+	config := LimitsConfig{
+		EnforceLimits: true,
+		PlanLimits:    map[string]FlexibleLimits{PlanBasic: basicLimits},
+		LimitSchema:   DefaultLimitSchema(),
+	}
+	checker := NewLimitChecker(config, &MockLimitCheckerRepository{tenants: make(map[uuid.UUID]*Tenant)}, zaptest.NewLogger(t))
+
+	limits := checker.GetLimitsForPlan(PlanBasic)
+	limits["max_users"].Value = 99
+	limits.Set("max_projects", LimitTypeInt, 1)
+
+	if got, _ := checker.GetLimitsForPlan(PlanBasic).GetInt("max_users"); got != 10 {
+		t.Errorf("max_users = %d after changing the returned limits, want 10", got)
+	}
+	if checker.GetLimitsForPlan(PlanBasic).Has("max_projects") {
+		t.Error("adding to the returned limits should not change the plan")
+	}
+
+	// Readers ranging over returned limits race with no writer
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if err := checker.UpdateLimit(PlanBasic, "max_users", 10+i); err != nil {
+				t.Errorf("UpdateLimit() error = %v", err)
+			}
+			if err := checker.AddLimit(PlanBasic, fmt.Sprintf("custom_%d", i), LimitTypeInt, i); err != nil {
+				t.Errorf("AddLimit() error = %v", err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for _, limit := range checker.GetLimitsForPlan(PlanBasic) {
+				_ = limit.Value
+			}
+			checker.GetLimitSchema().GetAllDefinitions()
+		}()
+	}
+	wg.Wait()
+}
+
 func TestLimitChecker_ValidateLimits(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	config := LimitsConfig{
@@ -520,10 +865,65 @@ func TestLimitChecker_validateDurationLimit(t *testing.T) {
 	tenantID := uuid.New()
 	limit := &LimitValue{Type: LimitTypeDuration, Value: "5m"}
 
-	// Duration validation is not fully implemented, so it should not error
-	err := checker.validateLimit(tenantID, "timeout", limit, time.Minute)
-	if err != nil {
-		t.Errorf("validateDurationLimit() error = %v, want nil (not implemented)", err)
+	tests := []struct {
+		name         string
+		limit        *LimitValue
+		currentValue interface{}
+		wantErr      bool
+		wantExceeded bool
+	}{
+		{"duration within limit", limit, time.Minute, false, false},
+		{"duration at limit", limit, 5 * time.Minute, false, false},
+		{"duration over limit", limit, 5*time.Minute + time.Second, true, true},
+		{"string within limit", limit, "90s", false, false},
+		{"string over limit", limit, "1h", true, true},
+		{"limit held as time.Duration", &LimitValue{Type: LimitTypeDuration, Value: time.Hour}, 2 * time.Hour, true, true},
+		{"negative limit is unlimited", &LimitValue{Type: LimitTypeDuration, Value: "-1s"}, 24 * time.Hour, false, false},
+		{"empty limit is unlimited", &LimitValue{Type: LimitTypeDuration, Value: ""}, 24 * time.Hour, false, false},
+		{"unparseable string", limit, "soon", true, false},
+		{"invalid type", limit, 300, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checker.validateLimit(tenantID, "session_timeout", tt.limit, tt.currentValue)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateDurationLimit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var exceeded *LimitExceededError
+			if errors.As(err, &exceeded) != tt.wantExceeded {
+				t.Errorf("validateDurationLimit() error = %v, want LimitExceededError %v", err, tt.wantExceeded)
+			}
+		})
+	}
+}
+
+func TestLimitChecker_CheckLimit_Duration(t *testing.T) {
+	tenantID := uuid.New()
+	mockRepo := &MockLimitCheckerRepository{
+		tenants: map[uuid.UUID]*Tenant{
+			tenantID: {ID: tenantID, PlanType: PlanPro, Status: StatusActive},
+		},
+	}
+	proLimits := make(FlexibleLimits)
+	proLimits["session_timeout"] = DurationLimit(30 * time.Minute)
+	proLimits["export_window"] = &LimitValue{Type: LimitTypeDuration, Value: ""}
+
+	checker := NewLimitChecker(LimitsConfig{
+		EnforceLimits: true,
+		PlanLimits:    map[string]FlexibleLimits{PlanPro: proLimits},
+	}, mockRepo, zaptest.NewLogger(t))
+	ctx := context.Background()
+
+	if err := checker.CheckLimit(ctx, tenantID, "session_timeout", 20*time.Minute); err != nil {
+		t.Errorf("CheckLimit() error = %v for a session under the limit", err)
+	}
+	var exceeded *LimitExceededError
+	if err := checker.CheckLimit(ctx, tenantID, "session_timeout", 2*time.Hour); !errors.As(err, &exceeded) {
+		t.Errorf("CheckLimit() error = %v, want limit exceeded", err)
+	}
+	if err := checker.CheckLimit(ctx, tenantID, "export_window", 72*time.Hour); err != nil {
+		t.Errorf("CheckLimit() error = %v for an unlimited duration", err)
 	}
 }
 
@@ -577,6 +977,25 @@ func (m *MockLimitCheckerRepository) GetStats(ctx context.Context, tenantID uuid
 	return &Stats{TenantID: tenantID}, nil
 }
 
+// usageTrackerFunc reports the usage returned by the function for every limit
+type usageTrackerFunc func(limitName string) (interface{}, error)
+
+func (f usageTrackerFunc) GetCurrentUsage(ctx context.Context, tenantID uuid.UUID, limitName string) (interface{}, error) {
+	return f(limitName)
+}
+
+func (f usageTrackerFunc) IncrementUsage(ctx context.Context, tenantID uuid.UUID, limitName string, delta interface{}) error {
+	return nil
+}
+
+func (f usageTrackerFunc) DecrementUsage(ctx context.Context, tenantID uuid.UUID, limitName string, delta interface{}) error {
+	return nil
+}
+
+func (f usageTrackerFunc) ResetUsage(ctx context.Context, tenantID uuid.UUID, limitName string) error {
+	return nil
+}
+
 type MockUsageTracker struct{}
 
 func (m *MockUsageTracker) GetCurrentUsage(ctx context.Context, tenantID uuid.UUID, limitName string) (interface{}, error) {
@@ -0,0 +1,130 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// UsageConsumer is implemented by usage trackers that can check and record
+// usage in one atomic step, such as the PostgreSQL tracker. ConsumeUsage adds
+// amount to the tenant's usage of limitName only if the total stays within
+// limit, and returns the usage afterwards and whether amount was added.
+type UsageConsumer interface {
+	ConsumeUsage(ctx context.Context, tenantID uuid.UUID, limitName string, amount, limit int) (usage int, consumed bool, err error)
+}
+
+// CheckLimitAndConsume records amount of usage of an int limit if it fits
+// within the tenant's effective limit, and returns how much of the limit
+// remains, or -1 if the limit is unlimited, undefined or not enforced. If
+// amount does not fit nothing is recorded and a LimitExceededError with code
+// PLAN_LIMIT_EXCEEDED is returned.
+//
+// The check and the increment are atomic when the usage tracker is a
+// UsageConsumer. Other trackers are serialized by the limit checker, which
+// only protects against consumers in the same process.
+func (lc *limitChecker) CheckLimitAndConsume(ctx context.Context, tenantID uuid.UUID, limitName string, amount int) (int, error) {
+	if amount <= 0 {
+		return 0, &ValidationError{Field: "amount", Message: "amount must be positive"}
+	}
+
+	tracker := lc.GetUsageTracker()
+	if tracker == nil {
+		return 0, &TenantError{
+			TenantID: tenantID,
+			Code:     "USAGE_TRACKER_UNAVAILABLE",
+			Message:  "no usage tracker is set",
+		}
+	}
+
+	tenant, err := lc.repository.GetByID(ctx, tenantID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	limit, exists := lc.limitsForTenant(ctx, tenant).Get(limitName)
+	if !exists {
+		limit, exists = lc.schemaDefault(limitName)
+	}
+	if !lc.config.EnforceLimits || !exists || limit.IsUnlimited() {
+		if err := tracker.IncrementUsage(ctx, tenantID, limitName, amount); err != nil {
+			return 0, fmt.Errorf("failed to record usage: %w", err)
+		}
+		return -1, nil
+	}
+
+	limitVal, err := limit.Int()
+	if err != nil {
+		return 0, fmt.Errorf("invalid limit value for %s: %w", limitName, err)
+	}
+
+	var usage int
+	var consumed bool
+	if consumer, ok := tracker.(UsageConsumer); ok {
+		usage, consumed, err = consumer.ConsumeUsage(ctx, tenantID, limitName, amount, limitVal)
+	} else {
+		usage, consumed, err = lc.consumeSerialized(ctx, tracker, tenantID, limitName, amount, limitVal)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to consume usage: %w", err)
+	}
+
+	if !consumed {
+		// Current is the usage the consumption would have led to
+		current := usage + amount
+		exceeded := &LimitExceededError{
+			TenantID: tenantID,
+			Code:     "PLAN_LIMIT_EXCEEDED",
+			Limit:    limitName,
+			Value:    limitVal,
+			Current:  current,
+			Message:  fmt.Sprintf("Limit exceeded for %s: current=%d after %d requested, limit=%d", limitName, current, amount, limitVal),
+		}
+		lc.recordDenial(ctx, tenant, exceeded)
+		return max(limitVal-usage, 0), exceeded
+	}
+
+	lc.checkThresholds(ctx, tenantID, limitName, limit, usage)
+	return limitVal - usage, nil
+}
+
+// consumeSerialized checks and records usage with a tracker that cannot do
+// both atomically, holding consumeMu so that consumers in this process do
+// not both see room for the same units
+func (lc *limitChecker) consumeSerialized(ctx context.Context, tracker UsageTracker, tenantID uuid.UUID, limitName string, amount, limit int) (int, bool, error) {
+	lc.consumeMu.Lock()
+	defer lc.consumeMu.Unlock()
+
+	current, err := tracker.GetCurrentUsage(ctx, tenantID, limitName)
+	if err != nil {
+		return 0, false, err
+	}
+
+	var usage int
+	switch v := current.(type) {
+	case nil:
+	case int:
+		usage = v
+	case int64:
+		usage = int(v)
+	case float64:
+		usage = int(v)
+	default:
+		return 0, false, fmt.Errorf("usage of %s is %T, not a count", limitName, current)
+	}
+
+	if usage+amount > limit {
+		lc.logger.Debug("Not enough of the limit left to consume",
+			zap.String("tenant_id", tenantID.String()),
+			zap.String("limit", limitName),
+			zap.Int("usage", usage),
+			zap.Int("amount", amount))
+		return usage, false, nil
+	}
+	if err := tracker.IncrementUsage(ctx, tenantID, limitName, amount); err != nil {
+		return usage, false, err
+	}
+	return usage + amount, true, nil
+}
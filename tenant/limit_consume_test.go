@@ -0,0 +1,140 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func newConsumeTestChecker(t *testing.T, limit int) (LimitChecker, uuid.UUID) {
+	limits := make(FlexibleLimits)
+	limits.Set(LimitNameMaxProjects, LimitTypeInt, limit)
+	limits.Set(LimitNameMaxUsers, LimitTypeInt, -1)
+	config := LimitsConfig{
+		EnforceLimits: true,
+		PlanLimits:    map[string]FlexibleLimits{PlanBasic: limits},
+	}
+
+	tenantID := uuid.New()
+	repo := &MockLimitCheckerRepository{
+		tenants: map[uuid.UUID]*Tenant{
+			tenantID: {ID: tenantID, PlanType: PlanBasic, Status: StatusActive},
+		},
+	}
+	return NewLimitChecker(config, repo, zaptest.NewLogger(t)), tenantID
+}
+
+func TestLimitChecker_CheckLimitAndConsume_Concurrent(t *testing.T) {
+	const consumers = 20
+	checker, tenantID := newConsumeTestChecker(t, consumers/2)
+	tracker := newCounterUsageTracker()
+	checker.SetUsageTracker(tracker)
+	ctx := context.Background()
+
+	var succeeded, exceeded atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < consumers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := checker.CheckLimitAndConsume(ctx, tenantID, LimitNameMaxProjects, 1)
+			var limitErr *LimitExceededError
+			switch {
+			case err == nil:
+				succeeded.Add(1)
+			case errors.As(err, &limitErr) && limitErr.Code == "PLAN_LIMIT_EXCEEDED":
+				exceeded.Add(1)
+			default:
+				t.Errorf("CheckLimitAndConsume() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded.Load() != consumers/2 || exceeded.Load() != consumers/2 {
+		t.Errorf("%d consumed and %d exceeded, want %d each", succeeded.Load(), exceeded.Load(), consumers/2)
+	}
+	if usage, _ := tracker.GetCurrentUsage(ctx, tenantID, LimitNameMaxProjects); usage != consumers/2 {
+		t.Errorf("usage = %v, want %d", usage, consumers/2)
+	}
+}
+
+func TestLimitChecker_CheckLimitAndConsume(t *testing.T) {
+	checker, tenantID := newConsumeTestChecker(t, 10)
+	ctx := context.Background()
+
+	var tenantErr *TenantError
+	if _, err := checker.CheckLimitAndConsume(ctx, tenantID, LimitNameMaxProjects, 1); !errors.As(err, &tenantErr) || tenantErr.Code != "USAGE_TRACKER_UNAVAILABLE" {
+		t.Errorf("CheckLimitAndConsume() without a tracker error = %v, want USAGE_TRACKER_UNAVAILABLE", err)
+	}
+
+	tracker := newCounterUsageTracker()
+	checker.SetUsageTracker(tracker)
+
+	remaining, err := checker.CheckLimitAndConsume(ctx, tenantID, LimitNameMaxProjects, 7)
+	if err != nil || remaining != 3 {
+		t.Errorf("CheckLimitAndConsume() = %d, %v, want 3 remaining", remaining, err)
+	}
+
+	// An amount that does not fit is rejected whole and not recorded
+	var limitErr *LimitExceededError
+	remaining, err = checker.CheckLimitAndConsume(ctx, tenantID, LimitNameMaxProjects, 4)
+	if !errors.As(err, &limitErr) || limitErr.Current != 11 || limitErr.Value != 10 || remaining != 3 {
+		t.Errorf("CheckLimitAndConsume() = %d, %v, want PLAN_LIMIT_EXCEEDED at 11 of 10 with 3 remaining", remaining, err)
+	}
+	if limitErr != nil && !strings.Contains(limitErr.Message, "current=11 ") {
+		t.Errorf("message = %q, want it to report the same current usage as Current", limitErr.Message)
+	}
+	if usage, _ := tracker.GetCurrentUsage(ctx, tenantID, LimitNameMaxProjects); usage != 7 {
+		t.Errorf("usage = %v after a rejected consume, want 7", usage)
+	}
+
+	// Unlimited limits are recorded without a check
+	if remaining, err := checker.CheckLimitAndConsume(ctx, tenantID, LimitNameMaxUsers, 1000); err != nil || remaining != -1 {
+		t.Errorf("CheckLimitAndConsume() unlimited = %d, %v, want -1", remaining, err)
+	}
+
+	var validationErr *ValidationError
+	if _, err := checker.CheckLimitAndConsume(ctx, tenantID, LimitNameMaxProjects, 0); !errors.As(err, &validationErr) {
+		t.Errorf("CheckLimitAndConsume(0) error = %v, want a ValidationError", err)
+	}
+}
+
+// consumingUsageTracker records the ConsumeUsage calls it serves
+type consumingUsageTracker struct {
+	*counterUsageTracker
+	calls int
+}
+
+func (c *consumingUsageTracker) ConsumeUsage(ctx context.Context, tenantID uuid.UUID, limitName string, amount, limit int) (int, bool, error) {
+	c.calls++
+	usage, _ := c.GetCurrentUsage(ctx, tenantID, limitName)
+	if usage.(int)+amount > limit {
+		return usage.(int), false, nil
+	}
+	c.IncrementUsage(ctx, tenantID, limitName, amount)
+	return usage.(int) + amount, true, nil
+}
+
+func TestLimitChecker_CheckLimitAndConsume_UsageConsumer(t *testing.T) {
+	checker, tenantID := newConsumeTestChecker(t, 5)
+	tracker := &consumingUsageTracker{counterUsageTracker: newCounterUsageTracker()}
+	checker.SetUsageTracker(tracker)
+	ctx := context.Background()
+
+	if remaining, err := checker.CheckLimitAndConsume(ctx, tenantID, LimitNameMaxProjects, 5); err != nil || remaining != 0 {
+		t.Errorf("CheckLimitAndConsume() = %d, %v, want 0 remaining", remaining, err)
+	}
+	if _, err := checker.CheckLimitAndConsume(ctx, tenantID, LimitNameMaxProjects, 1); err == nil {
+		t.Error("CheckLimitAndConsume() should fail once the limit is used up")
+	}
+	if tracker.calls != 2 {
+		t.Errorf("ConsumeUsage called %d times, want the tracker to do the check", tracker.calls)
+	}
+}
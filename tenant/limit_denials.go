@@ -0,0 +1,101 @@
+package tenant
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// LimitDenial records one limit check that the LimitChecker rejected, for
+// analytics such as which plans' customers keep running into which limits
+type LimitDenial struct {
+	TenantID   uuid.UUID   `json:"tenant_id"`
+	Plan       string      `json:"plan"`
+	Limit      string      `json:"limit"`
+	Code       string      `json:"code"` // LIMIT_EXCEEDED or FEATURE_NOT_ALLOWED
+	Usage      interface{} `json:"usage"`
+	LimitValue interface{} `json:"limit_value"`
+	Timestamp  time.Time   `json:"timestamp"`
+}
+
+// DenialSink receives a LimitDenial for every rejected limit check, e.g. to
+// write it to a table or a Kafka topic. Unlike metrics, every denial is a
+// separate record. RecordDenial is called on the request path, so slow sinks
+// should hand denials off rather than deliver them inline. Errors are logged
+// and do not change the outcome of the check.
+type DenialSink interface {
+	RecordDenial(ctx context.Context, denial LimitDenial) error
+}
+
+// DenialSinkFunc adapts a function to the DenialSink interface
+type DenialSinkFunc func(ctx context.Context, denial LimitDenial) error
+
+// RecordDenial calls f(ctx, denial)
+func (f DenialSinkFunc) RecordDenial(ctx context.Context, denial LimitDenial) error {
+	return f(ctx, denial)
+}
+
+// logDenialSink writes denials as structured log entries
+type logDenialSink struct {
+	logger *zap.Logger
+}
+
+// NewLogDenialSink returns a DenialSink that logs each denial at info level
+// with its fields as structured fields. It is the LimitChecker's default sink.
+func NewLogDenialSink(logger *zap.Logger) DenialSink {
+	return &logDenialSink{logger: logger}
+}
+
+// RecordDenial implements DenialSink
+func (s *logDenialSink) RecordDenial(ctx context.Context, denial LimitDenial) error {
+	s.logger.Info("Limit denied",
+		zap.String("tenant_id", denial.TenantID.String()),
+		zap.String("plan", denial.Plan),
+		zap.String("limit", denial.Limit),
+		zap.String("code", denial.Code),
+		zap.Any("usage", denial.Usage),
+		zap.Any("limit_value", denial.LimitValue),
+		zap.Time("timestamp", denial.Timestamp))
+	return nil
+}
+
+// SetDenialSink sets the sink that receives a LimitDenial for every rejected
+// check, replacing the default log sink. Pass nil to stop recording denials.
+func (lc *limitChecker) SetDenialSink(sink DenialSink) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.denials = sink
+}
+
+// recordDenial sends a rejected check to the denial sink and the metrics
+// collector
+func (lc *limitChecker) recordDenial(ctx context.Context, tenant *Tenant, exceeded *LimitExceededError) {
+	lc.mu.RLock()
+	sink := lc.denials
+	metrics := lc.metrics
+	lc.mu.RUnlock()
+	if metrics != nil {
+		metrics.LimitExceeded(tenant.PlanType, exceeded.Limit)
+	}
+	if sink == nil {
+		return
+	}
+
+	denial := LimitDenial{
+		TenantID:   tenant.ID,
+		Plan:       tenant.PlanType,
+		Limit:      exceeded.Limit,
+		Code:       exceeded.Code,
+		Usage:      exceeded.Current,
+		LimitValue: exceeded.Value,
+		Timestamp:  time.Now(),
+	}
+	if err := sink.RecordDenial(ctx, denial); err != nil {
+		lc.logger.Warn("Failed to record limit denial",
+			zap.String("tenant_id", tenant.ID.String()),
+			zap.String("limit", exceeded.Limit),
+			zap.Error(err))
+	}
+}
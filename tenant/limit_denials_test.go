@@ -0,0 +1,104 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLimitChecker_DenialSink(t *testing.T) {
+	tenantID := uuid.New()
+	mockRepo := &MockLimitCheckerRepository{
+		tenants: map[uuid.UUID]*Tenant{
+			tenantID: {ID: tenantID, PlanType: PlanPro, Status: StatusActive},
+		},
+	}
+	proLimits := make(FlexibleLimits)
+	proLimits.Set(LimitNameMaxProjects, LimitTypeInt, 100)
+	proLimits.Set("custom_domain", LimitTypeBool, false)
+
+	checker := NewLimitChecker(LimitsConfig{
+		EnforceLimits: true,
+		PlanLimits:    map[string]FlexibleLimits{PlanPro: proLimits},
+	}, mockRepo, zaptest.NewLogger(t))
+
+	var denials []LimitDenial
+	checker.SetDenialSink(DenialSinkFunc(func(ctx context.Context, denial LimitDenial) error {
+		denials = append(denials, denial)
+		return nil
+	}))
+	ctx := context.Background()
+
+	// Allowed checks are not recorded
+	if err := checker.CheckLimit(ctx, tenantID, LimitNameMaxProjects, 100); err != nil {
+		t.Fatalf("CheckLimit() error = %v", err)
+	}
+	if len(denials) != 0 {
+		t.Fatalf("denials = %v, want none for an allowed check", denials)
+	}
+
+	before := time.Now()
+	if err := checker.CheckLimit(ctx, tenantID, LimitNameMaxProjects, 101); err == nil {
+		t.Fatal("CheckLimit() should deny usage over the limit")
+	}
+	if len(denials) != 1 {
+		t.Fatalf("denials = %d, want one record", len(denials))
+	}
+	got := denials[0]
+	if got.TenantID != tenantID || got.Plan != PlanPro || got.Limit != LimitNameMaxProjects || got.Code != "LIMIT_EXCEEDED" {
+		t.Errorf("denial = %+v, want the tenant, plan, limit and code", got)
+	}
+	if got.Usage != 101 || got.LimitValue != 100 {
+		t.Errorf("denial usage = %v, limit = %v; want 101 and 100", got.Usage, got.LimitValue)
+	}
+	if got.Timestamp.Before(before) || got.Timestamp.After(time.Now()) {
+		t.Errorf("denial timestamp = %v, want the time of the check", got.Timestamp)
+	}
+
+	// Feature denials are recorded too, and sink failures do not change the outcome
+	checker.SetDenialSink(DenialSinkFunc(func(ctx context.Context, denial LimitDenial) error {
+		denials = append(denials, denial)
+		return errors.New("kafka unavailable")
+	}))
+	var exceeded *LimitExceededError
+	if err := checker.CheckLimit(ctx, tenantID, "custom_domain", true); !errors.As(err, &exceeded) {
+		t.Errorf("CheckLimit() error = %v, want a LimitExceededError", err)
+	}
+	if len(denials) != 2 || denials[1].Code != "FEATURE_NOT_ALLOWED" {
+		t.Errorf("denials = %+v, want the feature denial recorded", denials)
+	}
+}
+
+func TestLogDenialSink(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	sink := NewLogDenialSink(zap.New(core))
+	tenantID := uuid.New()
+
+	err := sink.RecordDenial(context.Background(), LimitDenial{
+		TenantID:   tenantID,
+		Plan:       PlanBasic,
+		Limit:      LimitNameMaxUsers,
+		Code:       "LIMIT_EXCEEDED",
+		Usage:      6,
+		LimitValue: 5,
+		Timestamp:  time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("RecordDenial() error = %v", err)
+	}
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["tenant_id"] != tenantID.String() || fields["plan"] != PlanBasic || fields["limit"] != LimitNameMaxUsers {
+		t.Errorf("logged fields = %v, want the denial's fields", fields)
+	}
+}
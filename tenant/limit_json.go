@@ -0,0 +1,207 @@
+package tenant
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// limitValueJSON is the JSON form of a LimitValue
+type limitValueJSON struct {
+	Type  LimitType       `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// MarshalJSON encodes the limit as {"type": ..., "value": ...}. Duration values
+// are written as duration strings such as "30s", whatever form they are held in.
+func (lv LimitValue) MarshalJSON() ([]byte, error) {
+	value := lv.Value
+	if lv.Type == LimitTypeDuration && value != nil {
+		d, err := lv.Duration()
+		if err != nil {
+			return nil, fmt.Errorf("limit value: %w", err)
+		}
+		value = d.String()
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(limitValueJSON{Type: lv.Type, Value: raw})
+}
+
+// UnmarshalJSON decodes a limit written by MarshalJSON and converts the value
+// to the Go type of its declared Type: int, float64, string or bool, and a
+// duration string for durations. JSON numbers are also accepted for durations,
+// as nanoseconds. A value that does not match its type is an error; values of
+// unknown types are decoded as is.
+func (lv *LimitValue) UnmarshalJSON(data []byte) error {
+	var decoded limitValueJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	value, err := decodeLimitValue(decoded.Type, decoded.Value)
+	if err != nil {
+		return fmt.Errorf("limit value of type %s: %w", decoded.Type, err)
+	}
+
+	lv.Type = decoded.Type
+	lv.Value = value
+	return nil
+}
+
+// decodeLimitValue decodes raw as a value of limitType
+func decodeLimitValue(limitType LimitType, raw json.RawMessage) (interface{}, error) {
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+
+	switch limitType {
+	case LimitTypeInt:
+		var v int
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("%s is not an integer", raw)
+		}
+		return v, nil
+	case LimitTypeFloat:
+		var v float64
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	case LimitTypeString:
+		var v string
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	case LimitTypeBool:
+		var v bool
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	case LimitTypeDuration:
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return nil, err
+			}
+			return d.String(), nil
+		}
+		var v int64
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("%s is neither a duration string nor whole nanoseconds", raw)
+		}
+		return time.Duration(v).String(), nil
+	default:
+		var v interface{}
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	}
+}
+
+// MarshalJSON encodes the limits as an object keyed by limit name
+func (fl FlexibleLimits) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]*LimitValue(fl))
+}
+
+// UnmarshalJSON decodes limits written by MarshalJSON, converting each value
+// to the Go type of its declared Type as LimitValue.UnmarshalJSON does
+func (fl *FlexibleLimits) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	limits := make(FlexibleLimits, len(raw))
+	for name, value := range raw {
+		if bytes.Equal(value, []byte("null")) {
+			limits[name] = nil
+			continue
+		}
+		limit := &LimitValue{}
+		if err := json.Unmarshal(value, limit); err != nil {
+			return fmt.Errorf("limit '%s': %w", name, err)
+		}
+		limits[name] = limit
+	}
+
+	*fl = limits
+	return nil
+}
+
+// limitDescriptionJSON decodes a LimitDescription, keeping its values raw
+// until its type is known
+type limitDescriptionJSON struct {
+	LimitDescription
+	Default json.RawMessage `json:"default,omitempty"`
+	Min     json.RawMessage `json:"min,omitempty"`
+	Max     json.RawMessage `json:"max,omitempty"`
+}
+
+// ToJSON encodes every definition in the schema, with its type, category,
+// tags and default, min and max values, as the list of LimitDescriptions
+// Describe and Manager.GetLimitSchema return
+func (ls *LimitSchema) ToJSON() ([]byte, error) {
+	return json.Marshal(ls.Describe())
+}
+
+// FromJSON replaces the schema's definitions with those decoded from data, a
+// list of LimitDescriptions as written by ToJSON. Each definition is
+// validated, and its values are converted to the Go type of its Type as
+// LimitValue.UnmarshalJSON does. The schema is left unchanged on error.
+func (ls *LimitSchema) FromJSON(data []byte) error {
+	var decoded []limitDescriptionJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return fmt.Errorf("failed to decode limit schema: %w", err)
+	}
+
+	definitions := make(map[string]*LimitDefinition, len(decoded))
+	for _, desc := range decoded {
+		def := &LimitDefinition{
+			Name:        desc.Name,
+			DisplayName: desc.DisplayName,
+			Description: desc.Description,
+			Type:        desc.Type,
+			Required:    desc.Required,
+			Category:    desc.Category,
+			Tags:        desc.Tags,
+		}
+		if len(def.Tags) == 0 {
+			def.Tags = nil // Describe lists missing tags as empty
+		}
+		if _, exists := definitions[def.Name]; exists {
+			return fmt.Errorf("limit definition '%s' is repeated", def.Name)
+		}
+
+		for _, field := range []struct {
+			raw   json.RawMessage
+			value **LimitValue
+		}{
+			{desc.Default, &def.DefaultValue},
+			{desc.Min, &def.MinValue},
+			{desc.Max, &def.MaxValue},
+		} {
+			value, err := decodeLimitValue(desc.Type, field.raw)
+			if err != nil {
+				return fmt.Errorf("limit definition '%s': %w", def.Name, err)
+			}
+			if value != nil {
+				*field.value = &LimitValue{Type: desc.Type, Value: value}
+			}
+		}
+
+		if err := def.Validate(); err != nil {
+			return fmt.Errorf("limit definition '%s': %w", def.Name, err)
+		}
+		definitions[def.Name] = def
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.Definitions = definitions
+	return nil
+}
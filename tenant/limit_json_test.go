@@ -0,0 +1,243 @@
+package tenant
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLimitValue_JSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit *LimitValue
+		want  interface{}
+	}{
+		{name: "int", limit: IntLimit(25), want: 25},
+		{name: "unlimited int", limit: UnlimitedInt(), want: -1},
+		{name: "float", limit: FloatLimit(2.5), want: 2.5},
+		{name: "whole float", limit: FloatLimit(10), want: 10.0},
+		{name: "string", limit: StringLimit("premium"), want: "premium"},
+		{name: "bool", limit: BoolLimit(true), want: true},
+		{name: "duration", limit: DurationLimit(90 * time.Second), want: "1m30s"},
+		{name: "duration held as time.Duration", limit: &LimitValue{Type: LimitTypeDuration, Value: 2 * time.Hour}, want: "2h0m0s"},
+		{name: "duration held as nanoseconds", limit: &LimitValue{Type: LimitTypeDuration, Value: int64(time.Second)}, want: "1s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.limit)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+
+			var got LimitValue
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal(%s) error = %v", data, err)
+			}
+			if got.Type != tt.limit.Type {
+				t.Errorf("Type = %s, want %s", got.Type, tt.limit.Type)
+			}
+			if got.Value != tt.want {
+				t.Errorf("Value = %#v (%T), want %#v (%T)", got.Value, got.Value, tt.want, tt.want)
+			}
+			if err := got.check(); err != nil {
+				t.Errorf("decoded value does not read as its type: %v", err)
+			}
+		})
+	}
+}
+
+func TestLimitValue_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "int from a JSON number", data: `{"type":"int","value":100}`, want: 100},
+		{name: "duration from nanoseconds", data: `{"type":"duration","value":1500000000}`, want: "1.5s"},
+		{name: "null value", data: `{"type":"int","value":null}`, want: nil},
+		{name: "fractional int", data: `{"type":"int","value":1.5}`, wantErr: true},
+		{name: "string for an int", data: `{"type":"int","value":"10"}`, wantErr: true},
+		{name: "invalid duration", data: `{"type":"duration","value":"soon"}`, wantErr: true},
+		{name: "number for a bool", data: `{"type":"bool","value":1}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got LimitValue
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.Value != tt.want {
+				t.Errorf("Value = %#v, want %#v", got.Value, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlexibleLimits_JSONRoundTrip(t *testing.T) {
+	limits := FlexibleLimits{
+		LimitNameMaxUsers:     IntLimit(10),
+		LimitNameMaxStorageGB: IntLimit(-1),
+		"overage_rate":        FloatLimit(0.25),
+		"support_tier":        StringLimit("priority"),
+		"custom_domain":       BoolLimit(false),
+		"session_timeout":     DurationLimit(30 * time.Minute),
+		"removed_limit":       nil,
+	}
+
+	data, err := json.Marshal(limits)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var got FlexibleLimits
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal(%s) error = %v", data, err)
+	}
+	if !reflect.DeepEqual(got, limits) {
+		t.Errorf("round trip = %s, want %s", mustMarshal(t, got), data)
+	}
+
+	// Typed accessors work on the decoded limits without float64 surprises
+	if users, err := got.GetInt(LimitNameMaxUsers); err != nil || users != 10 {
+		t.Errorf("GetInt(max_users) = %d, %v; want 10", users, err)
+	}
+	if timeout, err := got["session_timeout"].Duration(); err != nil || timeout != 30*time.Minute {
+		t.Errorf("Duration(session_timeout) = %v, %v; want 30m", timeout, err)
+	}
+
+	// Encoding is stable
+	if again := mustMarshal(t, got); string(again) != string(data) {
+		t.Errorf("second Marshal() = %s, want %s", again, data)
+	}
+}
+
+func TestFlexibleLimits_UnmarshalJSONNamesBadLimit(t *testing.T) {
+	var limits FlexibleLimits
+	err := json.Unmarshal([]byte(`{"max_users":{"type":"int","value":"ten"}}`), &limits)
+	if err == nil {
+		t.Fatal("Unmarshal() should reject a value that does not match its type")
+	}
+	if want := "limit 'max_users'"; !strings.Contains(err.Error(), want) {
+		t.Errorf("Unmarshal() error = %v, want it to name %s", err, want)
+	}
+}
+
+func TestLimitSchema_CreateDefaultLimitsJSON(t *testing.T) {
+	schema := NewLimitSchema()
+	schema.AddDefinition(&LimitDefinition{Name: "max_seats", Type: LimitTypeInt, DefaultValue: IntLimit(5)})
+
+	defaults := schema.CreateDefaultLimits()
+	data, err := json.Marshal(defaults)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := `{"max_seats":{"type":"int","value":5}}`; string(data) != want {
+		t.Errorf("Marshal(CreateDefaultLimits()) = %s, want %s", data, want)
+	}
+}
+
+func TestLimitSchema_JSONRoundTrip(t *testing.T) {
+	schema := DefaultLimitSchema()
+	schema.AddDefinition(&LimitDefinition{
+		Name:         "video_minutes",
+		DisplayName:  "Video Minutes",
+		Type:         LimitTypeInt,
+		DefaultValue: IntLimit(60),
+		MinValue:     IntLimit(0),
+		MaxValue:     IntLimit(10000),
+		Category:     "usage",
+		Tags:         []string{"media", "billable"},
+	})
+	data, err := schema.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+
+	// The schema is written in the same format the manager describes it in
+	if described := mustMarshal(t, schema.Describe()); string(described) != string(data) {
+		t.Errorf("ToJSON() = %s, want the descriptions %s", data, described)
+	}
+
+	reloaded := NewLimitSchema()
+	if err := reloaded.FromJSON(data); err != nil {
+		t.Fatalf("FromJSON(%s) error = %v", data, err)
+	}
+
+	want := schema.GetAllDefinitions()
+	got := reloaded.GetAllDefinitions()
+	if len(got) != len(want) {
+		t.Fatalf("FromJSON() loaded %d definitions, want %d", len(got), len(want))
+	}
+	for name, def := range want {
+		loaded, ok := got[name]
+		if !ok {
+			t.Errorf("definition %s was not reloaded", name)
+			continue
+		}
+		if loaded.Name != def.Name || loaded.DisplayName != def.DisplayName || loaded.Description != def.Description ||
+			loaded.Type != def.Type || loaded.Category != def.Category || loaded.Required != def.Required {
+			t.Errorf("definition %s = %+v, want %+v", name, loaded, def)
+		}
+		if !reflect.DeepEqual(loaded.Tags, def.Tags) {
+			t.Errorf("definition %s tags = %v, want %v", name, loaded.Tags, def.Tags)
+		}
+		// Values compare by their JSON form, since durations reload as strings
+		for field, values := range map[string][2]*LimitValue{
+			"default": {loaded.DefaultValue, def.DefaultValue},
+			"min":     {loaded.MinValue, def.MinValue},
+			"max":     {loaded.MaxValue, def.MaxValue},
+		} {
+			if g, w := mustMarshal(t, values[0]), mustMarshal(t, values[1]); string(g) != string(w) {
+				t.Errorf("definition %s %s value = %s, want %s", name, field, g, w)
+			}
+		}
+	}
+
+	again, err := reloaded.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON() of the reloaded schema error = %v", err)
+	}
+	if string(again) != string(data) {
+		t.Errorf("ToJSON() after reloading = %s, want %s", again, data)
+	}
+}
+
+func TestLimitSchema_FromJSONRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "malformed", data: `[{"name": "max_users"`},
+		{name: "repeated name", data: `[{"name": "max_users", "type": "int"}, {"name": "max_users", "type": "int"}]`},
+		{name: "invalid definition", data: `[{"name": "max_users", "type": "uuid"}]`},
+		{name: "value of the wrong type", data: `[{"name": "max_users", "type": "int", "default": "many"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := DefaultLimitSchema()
+			count := len(schema.GetAllDefinitions())
+			if err := schema.FromJSON([]byte(tt.data)); err == nil {
+				t.Errorf("FromJSON(%s) should fail", tt.data)
+			}
+			if len(schema.GetAllDefinitions()) != count {
+				t.Error("FromJSON() should leave the schema unchanged on error")
+			}
+		})
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	return data
+}
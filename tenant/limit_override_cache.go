@@ -0,0 +1,89 @@
+package tenant
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultOverrideCacheTTL is how long the limit checker serves a tenant's
+// stored limit overrides before reading its metadata again, unless
+// LimitsConfig.OverrideCacheTTL is set
+const DefaultOverrideCacheTTL = time.Minute
+
+// overrideCache is a concurrency-safe TTL cache of the limit overrides stored
+// in tenants' metadata, so that every limit check does not read the metadata
+type overrideCache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration // 0 or less disables caching
+	entries map[uuid.UUID]overrideCacheEntry
+	gen     uint64 // bumped by invalidate, so that reads racing a write are not cached
+	now     func() time.Time
+}
+
+// overrideCacheEntry is a tenant's cached overrides, nil if it has none
+type overrideCacheEntry struct {
+	overrides FlexibleLimits
+	expires   time.Time
+}
+
+// newOverrideCache creates a new override cache. A ttl of 0 uses
+// DefaultOverrideCacheTTL and a negative ttl disables caching.
+func newOverrideCache(ttl time.Duration) *overrideCache {
+	if ttl == 0 {
+		ttl = DefaultOverrideCacheTTL
+	}
+	return &overrideCache{
+		ttl:     ttl,
+		entries: make(map[uuid.UUID]overrideCacheEntry),
+		now:     time.Now,
+	}
+}
+
+// get returns the tenant's cached overrides if they have not expired. They
+// must not be modified.
+func (c *overrideCache) get(id uuid.UUID) (FlexibleLimits, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mu.RLock()
+	entry, ok := c.entries[id]
+	c.mu.RUnlock()
+
+	if !ok || !c.now().Before(entry.expires) {
+		return nil, false
+	}
+	return entry.overrides, true
+}
+
+// generation returns the cache's generation; take it before reading the
+// overrides and pass it to put
+func (c *overrideCache) generation() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.gen
+}
+
+// put caches the tenant's overrides read at generation gen, unless an entry
+// was invalidated since
+func (c *overrideCache) put(id uuid.UUID, overrides FlexibleLimits, gen uint64) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	if c.gen == gen {
+		c.entries[id] = overrideCacheEntry{overrides: overrides.Clone(), expires: c.now().Add(c.ttl)}
+	}
+	c.mu.Unlock()
+}
+
+// invalidate removes the tenant's cached overrides
+func (c *overrideCache) invalidate(id uuid.UUID) {
+	c.mu.Lock()
+	delete(c.entries, id)
+	c.gen++
+	c.mu.Unlock()
+}
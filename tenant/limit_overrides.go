@@ -0,0 +1,264 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// OverrideProvider supplies per-tenant limits that replace the tenant's plan
+// limits of the same name. It is an operational escape hatch consulted by the
+// LimitChecker after the plan limits are looked up and before they are
+// enforced.
+type OverrideProvider interface {
+	// LimitOverrides returns the tenant's overridden limits, or nil if there are none
+	LimitOverrides(ctx context.Context, tenantID uuid.UUID) (FlexibleLimits, error)
+}
+
+// ErrLimitOverridesUnsupported is returned by SetTenantLimitOverride when the
+// limit checker's repository cannot store metadata
+var ErrLimitOverridesUnsupported = errors.New("limit overrides need an ExtensibleRepository")
+
+// Environment variable prefix and separator read by NewEnvOverrideProvider
+const (
+	envOverridePrefix    = "TENANT_"
+	envOverrideSeparator = "_LIMIT_"
+)
+
+// envOverrideProvider serves limit overrides parsed from the environment
+type envOverrideProvider struct {
+	overrides map[uuid.UUID]FlexibleLimits
+}
+
+// NewEnvOverrideProvider reads limit overrides from environment variables named
+// TENANT_<id>_LIMIT_<name>, e.g. TENANT_7c9e6679-7425-40de-944b-e07fc1f90ae7_LIMIT_MAX_USERS=50.
+// The tenant ID may use underscores in place of hyphens and the limit name is
+// matched case-insensitively. Values are parsed as the type the schema declares
+// for the limit; "unlimited" sets an int or float limit to -1. The environment
+// is read once, so overrides change with a restart. Malformed variables and
+// limits missing from the schema are reported as errors.
+func NewEnvOverrideProvider(schema *LimitSchema) (OverrideProvider, error) {
+	return parseEnvOverrides(schema, os.Environ())
+}
+
+func parseEnvOverrides(schema *LimitSchema, environ []string) (*envOverrideProvider, error) {
+	provider := &envOverrideProvider{overrides: make(map[uuid.UUID]FlexibleLimits)}
+
+	for _, entry := range environ {
+		key, raw, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(key, envOverridePrefix) {
+			continue
+		}
+		idPart, name, ok := strings.Cut(strings.TrimPrefix(key, envOverridePrefix), envOverrideSeparator)
+		if !ok {
+			continue
+		}
+
+		tenantID, err := uuid.Parse(strings.ReplaceAll(idPart, "_", "-"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid tenant ID in %s: %w", key, err)
+		}
+
+		name = strings.ToLower(name)
+		def, exists := schema.GetDefinition(name)
+		if !exists {
+			return nil, fmt.Errorf("%s overrides unknown limit %s", key, name)
+		}
+
+		value, err := parseLimitValue(def.Type, raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+
+		if provider.overrides[tenantID] == nil {
+			provider.overrides[tenantID] = make(FlexibleLimits)
+		}
+		provider.overrides[tenantID][name] = value
+	}
+
+	return provider, nil
+}
+
+func (p *envOverrideProvider) LimitOverrides(ctx context.Context, tenantID uuid.UUID) (FlexibleLimits, error) {
+	return p.overrides[tenantID], nil
+}
+
+// parseLimitValue parses raw as a limit of type limitType
+func parseLimitValue(limitType LimitType, raw string) (*LimitValue, error) {
+	raw = strings.TrimSpace(raw)
+
+	switch limitType {
+	case LimitTypeInt:
+		if raw == "unlimited" {
+			return UnlimitedInt(), nil
+		}
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", raw)
+		}
+		return IntLimit(v), nil
+	case LimitTypeFloat:
+		if raw == "unlimited" {
+			return FloatLimit(-1), nil
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", raw)
+		}
+		return FloatLimit(v), nil
+	case LimitTypeBool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", raw)
+		}
+		return BoolLimit(v), nil
+	case LimitTypeDuration:
+		v, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a duration", raw)
+		}
+		return DurationLimit(v), nil
+	case LimitTypeString:
+		return StringLimit(raw), nil
+	default:
+		return nil, fmt.Errorf("unsupported limit type %s", limitType)
+	}
+}
+
+// SetOverrideProvider sets the provider consulted for per-tenant limit
+// overrides. Pass nil to remove it.
+func (lc *limitChecker) SetOverrideProvider(provider OverrideProvider) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.overrides = provider
+}
+
+// GetLimitsForTenant returns the tenant's effective limits: its plan limits
+// with any overrides applied. Schema defaults are not included; CheckLimit
+// falls back to them only for limits missing from the result.
+func (lc *limitChecker) GetLimitsForTenant(ctx context.Context, tenantID uuid.UUID) (FlexibleLimits, error) {
+	tenant, err := lc.repository.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+	return lc.limitsForTenant(ctx, tenant).Clone(), nil
+}
+
+// limitsForTenant overlays the tenant's overrides on its plan limits. Each
+// layer replaces limits of the same name from the one before it:
+//
+//  1. the tenant's plan limits
+//  2. overrides stored in the tenant's metadata, if the repository is an
+//     ExtensibleRepository
+//  3. the override provider's limits
+//
+// An override layer that fails to load is logged and skipped. The result may
+// share the plan's limits and must not be modified.
+func (lc *limitChecker) limitsForTenant(ctx context.Context, tenant *Tenant) FlexibleLimits {
+	planLimits := lc.planLimitsFor(tenant.PlanType)
+
+	var layers []FlexibleLimits
+	if extensible, ok := lc.repository.(ExtensibleRepository); ok {
+		layers = append(layers, lc.storedOverrides(ctx, extensible, tenant.ID))
+	}
+
+	lc.mu.RLock()
+	provider := lc.overrides
+	lc.mu.RUnlock()
+	if provider != nil {
+		overrides, err := provider.LimitOverrides(ctx, tenant.ID)
+		if err != nil {
+			lc.logger.Warn("Failed to get limit overrides, using plan limits",
+				zap.String("tenant_id", tenant.ID.String()),
+				zap.Error(err))
+		}
+		layers = append(layers, overrides)
+	}
+
+	limits := planLimits
+	for _, layer := range layers {
+		if len(layer) == 0 {
+			continue
+		}
+		merged := make(FlexibleLimits, len(limits)+len(layer))
+		for name, value := range limits {
+			merged[name] = value
+		}
+		for name, value := range layer {
+			merged[name] = value
+		}
+		limits = merged
+	}
+	return limits
+}
+
+// storedOverrides returns the limit overrides stored in the tenant's metadata,
+// served from the cache for up to LimitsConfig.OverrideCacheTTL. Overrides
+// that fail to load are logged and skipped. The result must not be modified.
+func (lc *limitChecker) storedOverrides(ctx context.Context, repository ExtensibleRepository, tenantID uuid.UUID) FlexibleLimits {
+	if overrides, ok := lc.stored.get(tenantID); ok {
+		return overrides
+	}
+
+	gen := lc.stored.generation()
+	overrides, err := repository.GetTenantLimitOverrides(ctx, tenantID)
+	if err != nil {
+		lc.logger.Warn("Failed to get stored limit overrides, ignoring them",
+			zap.String("tenant_id", tenantID.String()),
+			zap.Error(err))
+		return nil
+	}
+	lc.stored.put(tenantID, overrides, gen)
+	return overrides
+}
+
+// SetTenantLimitOverride stores value as the tenant's override for limitName
+// in its metadata, where it outlives a restart, and takes effect in this
+// checker at once. Other checkers sharing the database see it within their
+// LimitsConfig.OverrideCacheTTL. A nil value removes the override. The value
+// is checked against the limit's definition if the schema has one, and the
+// repository must be an ExtensibleRepository.
+func (lc *limitChecker) SetTenantLimitOverride(ctx context.Context, tenantID uuid.UUID, limitName string, value *LimitValue) error {
+	extensible, ok := lc.repository.(ExtensibleRepository)
+	if !ok {
+		return ErrLimitOverridesUnsupported
+	}
+
+	if value != nil {
+		lc.mu.RLock()
+		def, exists := lc.schema.GetDefinition(limitName)
+		lc.mu.RUnlock()
+		if exists {
+			if err := def.CheckRange(value); err != nil {
+				return err
+			}
+		}
+	}
+
+	defer lc.stored.invalidate(tenantID)
+	return extensible.SetTenantLimitOverride(ctx, tenantID, limitName, value)
+}
+
+// schemaDefault returns the schema's default value for limitName, the last
+// layer CheckLimit consults after the plan and any overrides
+func (lc *limitChecker) schemaDefault(limitName string) (*LimitValue, bool) {
+	lc.mu.RLock()
+	schema := lc.schema
+	lc.mu.RUnlock()
+	if schema == nil {
+		return nil, false
+	}
+
+	def, exists := schema.GetDefinition(limitName)
+	if !exists || def.DefaultValue == nil {
+		return nil, false
+	}
+	return &LimitValue{Type: def.Type, Value: def.DefaultValue.Value}, true
+}
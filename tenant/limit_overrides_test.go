@@ -0,0 +1,292 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestNewEnvOverrideProvider(t *testing.T) {
+	tenantID := uuid.New()
+	other := uuid.New()
+	envID := strings.ToUpper(strings.ReplaceAll(tenantID.String(), "-", "_"))
+
+	t.Setenv("TENANT_"+tenantID.String()+"_LIMIT_MAX_USERS", "50")
+	t.Setenv("TENANT_"+envID+"_LIMIT_max_projects", "unlimited")
+	t.Setenv("TENANT_"+tenantID.String()+"_LIMIT_ADVANCED_FEATURES", "true")
+	t.Setenv("TENANT_"+other.String()+"_LIMIT_MAX_STORAGE_GB", " 20 ")
+	t.Setenv("TENANT_NAME", "ignored")
+
+	provider, err := NewEnvOverrideProvider(DefaultLimitSchema())
+	if err != nil {
+		t.Fatalf("NewEnvOverrideProvider() error = %v", err)
+	}
+
+	overrides, err := provider.LimitOverrides(context.Background(), tenantID)
+	if err != nil {
+		t.Fatalf("LimitOverrides() error = %v", err)
+	}
+	if overrides.Len() != 3 {
+		t.Errorf("overrides = %v, want 3", overrides.Keys())
+	}
+	if v, err := overrides.GetInt(LimitNameMaxUsers); err != nil || v != 50 {
+		t.Errorf("max_users = %d, %v; want 50", v, err)
+	}
+	if !overrides.IsUnlimited(LimitNameMaxProjects) {
+		t.Error("max_projects should be unlimited")
+	}
+	if v, err := overrides.GetBool("advanced_features"); err != nil || !v {
+		t.Errorf("advanced_features = %v, %v; want true", v, err)
+	}
+
+	otherOverrides, _ := provider.LimitOverrides(context.Background(), other)
+	if v, err := otherOverrides.GetInt("max_storage_gb"); err != nil || v != 20 {
+		t.Errorf("other tenant max_storage_gb = %d, %v; want 20", v, err)
+	}
+	if none, _ := provider.LimitOverrides(context.Background(), uuid.New()); none != nil {
+		t.Errorf("overrides for a tenant without variables = %v, want nil", none)
+	}
+}
+
+func TestParseEnvOverrides_Invalid(t *testing.T) {
+	schema := DefaultLimitSchema()
+	schema.AddDefinition(&LimitDefinition{Name: "session_timeout", Type: LimitTypeDuration})
+	tenantID := uuid.New().String()
+
+	tests := []struct {
+		name  string
+		entry string
+	}{
+		{"bad tenant ID", "TENANT_acme_LIMIT_MAX_USERS=5"},
+		{"unknown limit", "TENANT_" + tenantID + "_LIMIT_MAX_WIDGETS=5"},
+		{"not an integer", "TENANT_" + tenantID + "_LIMIT_MAX_USERS=lots"},
+		{"not a boolean", "TENANT_" + tenantID + "_LIMIT_ADVANCED_FEATURES=maybe"},
+		{"not a duration", "TENANT_" + tenantID + "_LIMIT_SESSION_TIMEOUT=forever"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseEnvOverrides(schema, []string{tt.entry}); err == nil {
+				t.Errorf("parseEnvOverrides(%q) should fail", tt.entry)
+			}
+		})
+	}
+
+	provider, err := parseEnvOverrides(schema, []string{"TENANT_" + tenantID + "_LIMIT_SESSION_TIMEOUT=90m"})
+	if err != nil {
+		t.Fatalf("parseEnvOverrides() error = %v", err)
+	}
+	overrides, _ := provider.LimitOverrides(context.Background(), uuid.MustParse(tenantID))
+	if d, err := overrides.GetDuration("session_timeout"); err != nil || d != 90*time.Minute {
+		t.Errorf("session_timeout = %v, %v; want 90m", d, err)
+	}
+}
+
+// overrideProviderFunc serves the overrides returned by the function
+type overrideProviderFunc func(tenantID uuid.UUID) (FlexibleLimits, error)
+
+func (f overrideProviderFunc) LimitOverrides(ctx context.Context, tenantID uuid.UUID) (FlexibleLimits, error) {
+	return f(tenantID)
+}
+
+func TestLimitChecker_OverrideProvider(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	basicLimits := make(FlexibleLimits)
+	basicLimits.Set(LimitNameMaxUsers, LimitTypeInt, 5)
+	basicLimits.Set(LimitNameMaxProjects, LimitTypeInt, 10)
+	config := LimitsConfig{
+		EnforceLimits: true,
+		PlanLimits:    map[string]FlexibleLimits{PlanBasic: basicLimits},
+	}
+
+	tenantID := uuid.New()
+	otherID := uuid.New()
+	mockRepo := &MockLimitCheckerRepository{
+		tenants: map[uuid.UUID]*Tenant{
+			tenantID: {ID: tenantID, PlanType: PlanBasic, Status: StatusActive},
+			otherID:  {ID: otherID, PlanType: PlanBasic, Status: StatusActive},
+		},
+	}
+
+	checker := NewLimitChecker(config, mockRepo, logger)
+	ctx := context.Background()
+
+	if err := checker.CheckLimit(ctx, tenantID, LimitNameMaxUsers, 20); err == nil {
+		t.Fatal("CheckLimit() should fail at the plan limit before overrides are set")
+	}
+
+	failing := false
+	checker.SetOverrideProvider(overrideProviderFunc(func(id uuid.UUID) (FlexibleLimits, error) {
+		if failing {
+			return nil, errors.New("provider unavailable")
+		}
+		if id != tenantID {
+			return nil, nil
+		}
+		return FlexibleLimits{LimitNameMaxUsers: IntLimit(50)}, nil
+	}))
+
+	if err := checker.CheckLimit(ctx, tenantID, LimitNameMaxUsers, 20); err != nil {
+		t.Errorf("CheckLimit() with an override error = %v, want nil", err)
+	}
+	if err := checker.CheckLimit(ctx, otherID, LimitNameMaxUsers, 20); err == nil {
+		t.Error("CheckLimit() should keep the plan limit for tenants without overrides")
+	}
+
+	limits, err := checker.GetLimitsForTenant(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("GetLimitsForTenant() error = %v", err)
+	}
+	if v, _ := limits.GetInt(LimitNameMaxUsers); v != 50 {
+		t.Errorf("effective max_users = %d, want 50", v)
+	}
+	if v, _ := limits.GetInt(LimitNameMaxProjects); v != 10 {
+		t.Errorf("effective max_projects = %d, want the plan's 10", v)
+	}
+	if v, _ := checker.GetLimitsForPlan(PlanBasic).GetInt(LimitNameMaxUsers); v != 5 {
+		t.Errorf("plan max_users = %d, want 5; overrides must not change the plan", v)
+	}
+
+	// A failing provider falls back to the plan limits
+	failing = true
+	if err := checker.CheckLimit(ctx, tenantID, LimitNameMaxUsers, 20); err == nil {
+		t.Error("CheckLimit() should enforce the plan limit when the provider fails")
+	}
+}
+
+func TestLimitChecker_StoredOverrides(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	ctx := context.Background()
+
+	schema := NewLimitSchema()
+	schema.AddDefinition(&LimitDefinition{Name: LimitNameMaxUsers, Type: LimitTypeInt, DefaultValue: IntLimit(1)})
+	schema.AddDefinition(&LimitDefinition{Name: LimitNameMaxProjects, Type: LimitTypeInt, DefaultValue: IntLimit(3)})
+
+	basicLimits := make(FlexibleLimits)
+	basicLimits.Set(LimitNameMaxUsers, LimitTypeInt, 5)
+	config := LimitsConfig{
+		EnforceLimits: true,
+		LimitSchema:   schema,
+		PlanLimits:    map[string]FlexibleLimits{PlanBasic: basicLimits},
+	}
+
+	repo := &mockExtensibleRepository{MockManagerRepository: NewMockRepository(), metadata: make(map[uuid.UUID]TenantMetadata)}
+	tenant := &Tenant{ID: uuid.New(), Subdomain: "acme", PlanType: PlanBasic, Status: StatusActive}
+	other := &Tenant{ID: uuid.New(), Subdomain: "globex", PlanType: PlanBasic, Status: StatusActive}
+	for _, record := range []*Tenant{tenant, other} {
+		repo.Create(ctx, record)
+	}
+	checker := NewLimitChecker(config, repo, logger)
+
+	// The schema default applies to limits the plan leaves out
+	if err := checker.CheckLimit(ctx, tenant.ID, LimitNameMaxProjects, 4); err == nil {
+		t.Error("CheckLimit() should enforce the schema default of 3 projects")
+	}
+	// The plan limit shadows the schema default
+	if err := checker.CheckLimit(ctx, tenant.ID, LimitNameMaxUsers, 4); err != nil {
+		t.Errorf("CheckLimit() error = %v, want the plan limit of 5 users", err)
+	}
+
+	// A stored override shadows both, for its tenant only
+	for name, value := range map[string]*LimitValue{LimitNameMaxUsers: IntLimit(50), LimitNameMaxProjects: IntLimit(10)} {
+		if err := checker.SetTenantLimitOverride(ctx, tenant.ID, name, value); err != nil {
+			t.Fatalf("SetTenantLimitOverride(%s) error = %v", name, err)
+		}
+	}
+	if err := checker.CheckLimit(ctx, tenant.ID, LimitNameMaxUsers, 20); err != nil {
+		t.Errorf("CheckLimit() with a stored override error = %v, want nil", err)
+	}
+	if err := checker.CheckLimit(ctx, tenant.ID, LimitNameMaxProjects, 4); err != nil {
+		t.Errorf("CheckLimit() with a stored override error = %v, want nil", err)
+	}
+	if err := checker.CheckLimit(ctx, other.ID, LimitNameMaxUsers, 20); err == nil {
+		t.Error("CheckLimit() should keep the plan limit for tenants without overrides")
+	}
+
+	// The override provider shadows stored overrides
+	checker.SetOverrideProvider(overrideProviderFunc(func(id uuid.UUID) (FlexibleLimits, error) {
+		return FlexibleLimits{LimitNameMaxUsers: IntLimit(8)}, nil
+	}))
+	if err := checker.CheckLimit(ctx, tenant.ID, LimitNameMaxUsers, 20); err == nil {
+		t.Error("CheckLimit() should prefer the override provider's limit of 8 users")
+	}
+	checker.SetOverrideProvider(nil)
+
+	limits, err := checker.GetLimitsForTenant(ctx, tenant.ID)
+	if err != nil {
+		t.Fatalf("GetLimitsForTenant() error = %v", err)
+	}
+	if users, _ := limits[LimitNameMaxUsers].Int(); users != 50 || len(limits) != 2 {
+		t.Errorf("GetLimitsForTenant() = %v, want the plan limits with stored overrides applied", limits)
+	}
+	if users, _ := basicLimits[LimitNameMaxUsers].Int(); users != 5 {
+		t.Errorf("stored overrides modified the plan limits: max_users = %d", users)
+	}
+
+	// Removing the override restores the plan limit
+	if err := checker.SetTenantLimitOverride(ctx, tenant.ID, LimitNameMaxUsers, nil); err != nil {
+		t.Fatalf("SetTenantLimitOverride(nil) error = %v", err)
+	}
+	if err := checker.CheckLimit(ctx, tenant.ID, LimitNameMaxUsers, 20); err == nil {
+		t.Error("CheckLimit() should enforce the plan limit once the override is removed")
+	}
+}
+
+// countingOverrideRepository counts reads of stored limit overrides
+type countingOverrideRepository struct {
+	*mockExtensibleRepository
+	reads int
+}
+
+func (r *countingOverrideRepository) GetTenantLimitOverrides(ctx context.Context, tenantID uuid.UUID) (FlexibleLimits, error) {
+	r.reads++
+	return r.mockExtensibleRepository.GetTenantLimitOverrides(ctx, tenantID)
+}
+
+func TestLimitChecker_StoredOverridesCached(t *testing.T) {
+	ctx := context.Background()
+	basicLimits := make(FlexibleLimits)
+	basicLimits.Set(LimitNameMaxUsers, LimitTypeInt, 5)
+	config := LimitsConfig{EnforceLimits: true, PlanLimits: map[string]FlexibleLimits{PlanBasic: basicLimits}}
+
+	repo := &countingOverrideRepository{mockExtensibleRepository: &mockExtensibleRepository{
+		MockManagerRepository: NewMockRepository(),
+		metadata:              make(map[uuid.UUID]TenantMetadata),
+	}}
+	tenant := &Tenant{ID: uuid.New(), Subdomain: "acme", PlanType: PlanBasic, Status: StatusActive}
+	repo.Create(ctx, tenant)
+	checker := NewLimitChecker(config, repo, zaptest.NewLogger(t))
+
+	for i := 0; i < 3; i++ {
+		checker.CheckLimit(ctx, tenant.ID, LimitNameMaxUsers, 1)
+	}
+	if repo.reads != 1 {
+		t.Errorf("stored overrides read %d times for 3 checks, want 1", repo.reads)
+	}
+
+	// Overrides set through the checker take effect at once
+	if err := checker.SetTenantLimitOverride(ctx, tenant.ID, LimitNameMaxUsers, IntLimit(50)); err != nil {
+		t.Fatalf("SetTenantLimitOverride() error = %v", err)
+	}
+	if err := checker.CheckLimit(ctx, tenant.ID, LimitNameMaxUsers, 20); err != nil {
+		t.Errorf("CheckLimit() right after SetTenantLimitOverride() error = %v, want the override applied", err)
+	}
+
+	// Values must match the limit's definition
+	var validationErr *ValidationError
+	if err := checker.SetTenantLimitOverride(ctx, tenant.ID, LimitNameMaxUsers, StringLimit("many")); !errors.As(err, &validationErr) {
+		t.Errorf("SetTenantLimitOverride() with the wrong type error = %v, want a ValidationError", err)
+	}
+
+	// Without metadata storage there is nowhere to keep them
+	plain := NewLimitChecker(config, NewMockRepository(), zaptest.NewLogger(t))
+	if err := plain.SetTenantLimitOverride(ctx, tenant.ID, LimitNameMaxUsers, IntLimit(50)); !errors.Is(err, ErrLimitOverridesUnsupported) {
+		t.Errorf("SetTenantLimitOverride() without an ExtensibleRepository error = %v, want ErrLimitOverridesUnsupported", err)
+	}
+}
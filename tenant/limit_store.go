@@ -0,0 +1,158 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// storeTimeout bounds store writes triggered by runtime limit changes
+const storeTimeout = 10 * time.Second
+
+// SchemaStore persists runtime changes to the limit schema
+type SchemaStore interface {
+	// LoadDefinitions returns all stored definitions. A nil definition for a
+	// name means it was removed at runtime.
+	LoadDefinitions(ctx context.Context) (map[string]*LimitDefinition, error)
+	SaveDefinition(ctx context.Context, def *LimitDefinition) error
+	DeleteDefinition(ctx context.Context, name string) error
+}
+
+// PlanLimitStore persists runtime changes to plan limits
+type PlanLimitStore interface {
+	// LoadPlanLimits returns all stored plan limits. A nil value for a limit
+	// means it was removed from the plan at runtime.
+	LoadPlanLimits(ctx context.Context) (map[string]FlexibleLimits, error)
+	SavePlanLimit(ctx context.Context, planType, limitName string, value *LimitValue) error
+	DeletePlanLimit(ctx context.Context, planType, limitName string) error
+}
+
+// NewPersistentLimitChecker creates a limit checker that overlays the stored
+// schema and plan limits on top of the configured ones, and writes every
+// runtime change back to the stores so it survives restarts.
+func NewPersistentLimitChecker(ctx context.Context, config LimitsConfig, repository Repository, schemaStore SchemaStore, planStore PlanLimitStore, logger *zap.Logger) (LimitChecker, error) {
+	checker := NewLimitChecker(config, repository, logger).(*limitChecker)
+	checker.schemaStore = schemaStore
+	checker.planStore = planStore
+
+	if err := checker.loadFromStores(ctx); err != nil {
+		return nil, err
+	}
+
+	return checker, nil
+}
+
+// loadFromStores applies stored definitions and plan limits to the checker.
+// It runs before the checker is shared, so it takes no locks.
+func (lc *limitChecker) loadFromStores(ctx context.Context) error {
+	if lc.schemaStore != nil {
+		definitions, err := lc.schemaStore.LoadDefinitions(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load limit definitions: %w", err)
+		}
+
+		// Overlay a copy, so that the configured schema is left alone
+		schema := NewLimitSchema()
+		for name, def := range lc.schema.GetAllDefinitions() {
+			schema.Definitions[name] = def
+		}
+		for name, def := range definitions {
+			if def == nil {
+				schema.RemoveDefinition(name)
+				continue
+			}
+			schema.AddDefinition(def)
+		}
+		lc.schema = schema
+	}
+
+	if lc.planStore != nil {
+		planLimits, err := lc.planStore.LoadPlanLimits(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load plan limits: %w", err)
+		}
+
+		for planType, limits := range planLimits {
+			merged := lc.planLimits[planType].Clone()
+			if merged == nil {
+				merged = make(FlexibleLimits)
+			}
+			for name, value := range limits {
+				if value == nil {
+					delete(merged, name)
+					continue
+				}
+				merged[name] = value
+			}
+			lc.planLimits[planType] = merged
+		}
+	}
+
+	lc.logger.Info("Loaded persisted limit configuration",
+		zap.Int("definitions", len(lc.schema.Definitions)),
+		zap.Int("plans", len(lc.planLimits)))
+
+	return nil
+}
+
+// saveDefinition writes a definition to the schema store, if configured
+func (lc *limitChecker) saveDefinition(def *LimitDefinition) error {
+	if lc.schemaStore == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	if err := lc.schemaStore.SaveDefinition(ctx, def); err != nil {
+		return fmt.Errorf("failed to persist limit definition %s: %w", def.Name, err)
+	}
+	return nil
+}
+
+// deleteDefinition removes a definition from the schema store, if configured
+func (lc *limitChecker) deleteDefinition(name string) error {
+	if lc.schemaStore == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	if err := lc.schemaStore.DeleteDefinition(ctx, name); err != nil {
+		return fmt.Errorf("failed to persist removal of limit definition %s: %w", name, err)
+	}
+	return nil
+}
+
+// savePlanLimit writes a plan limit to the plan limit store, if configured
+func (lc *limitChecker) savePlanLimit(planType, limitName string, value *LimitValue) error {
+	if lc.planStore == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	if err := lc.planStore.SavePlanLimit(ctx, planType, limitName, value); err != nil {
+		return fmt.Errorf("failed to persist limit %s for plan %s: %w", limitName, planType, err)
+	}
+	return nil
+}
+
+// deletePlanLimit removes a plan limit from the plan limit store, if configured
+func (lc *limitChecker) deletePlanLimit(planType, limitName string) error {
+	if lc.planStore == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	if err := lc.planStore.DeletePlanLimit(ctx, planType, limitName); err != nil {
+		return fmt.Errorf("failed to persist removal of limit %s for plan %s: %w", limitName, planType, err)
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestNewPersistentLimitChecker_PersistsAcrossReload(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	store := NewMockLimitStore()
+
+	tenantID := uuid.New()
+	mockRepo := &MockLimitCheckerRepository{
+		tenants: map[uuid.UUID]*Tenant{
+			tenantID: {ID: tenantID, PlanType: PlanBasic, Status: StatusActive},
+		},
+	}
+
+	checker, err := NewPersistentLimitChecker(context.Background(), DefaultConfig().Limits, mockRepo, store, store, logger)
+	if err != nil {
+		t.Fatalf("NewPersistentLimitChecker() error = %v", err)
+	}
+
+	// Make runtime changes
+	if err := checker.AddDefinition(&LimitDefinition{
+		Name:         "video_minutes",
+		DisplayName:  "Video Minutes",
+		Type:         LimitTypeInt,
+		DefaultValue: IntLimit(60),
+		Category:     "media",
+	}); err != nil {
+		t.Fatalf("AddDefinition() error = %v", err)
+	}
+	if err := checker.AddLimit(PlanBasic, "video_minutes", LimitTypeInt, 30); err != nil {
+		t.Fatalf("AddLimit() error = %v", err)
+	}
+	if err := checker.UpdateLimit(PlanBasic, "max_users", 8); err != nil {
+		t.Fatalf("UpdateLimit() error = %v", err)
+	}
+	if err := checker.RemoveLimit(PlanBasic, "advanced_features"); err != nil {
+		t.Fatalf("RemoveLimit() error = %v", err)
+	}
+
+	// Simulate a restart with fresh static configuration and the same store
+	reloaded, err := NewPersistentLimitChecker(context.Background(), DefaultConfig().Limits, mockRepo, store, store, logger)
+	if err != nil {
+		t.Fatalf("NewPersistentLimitChecker() reload error = %v", err)
+	}
+
+	if _, exists := reloaded.GetLimitSchema().GetDefinition("video_minutes"); !exists {
+		t.Error("Runtime definition should survive reload")
+	}
+
+	limits := reloaded.GetLimitsForPlan(PlanBasic)
+	if val, err := limits.GetInt("video_minutes"); err != nil || val != 30 {
+		t.Errorf("Runtime plan limit after reload = %v (err %v), want 30", val, err)
+	}
+	if val, err := limits.GetInt("max_users"); err != nil || val != 8 {
+		t.Errorf("Updated plan limit after reload = %v (err %v), want 8", val, err)
+	}
+	if limits.Has("advanced_features") {
+		t.Error("Removed plan limit should stay removed after reload")
+	}
+
+	if err := reloaded.CheckLimit(context.Background(), tenantID, "video_minutes", 45); err == nil {
+		t.Error("CheckLimit() should enforce the persisted limit after reload")
+	}
+
+	// Removing the definition should also persist
+	if err := reloaded.RemoveLimit(PlanBasic, "video_minutes"); err != nil {
+		t.Fatalf("RemoveLimit() error = %v", err)
+	}
+	if err := reloaded.RemoveDefinition("video_minutes"); err != nil {
+		t.Fatalf("RemoveDefinition() error = %v", err)
+	}
+
+	again, err := NewPersistentLimitChecker(context.Background(), DefaultConfig().Limits, mockRepo, store, store, logger)
+	if err != nil {
+		t.Fatalf("NewPersistentLimitChecker() second reload error = %v", err)
+	}
+	if _, exists := again.GetLimitSchema().GetDefinition("video_minutes"); exists {
+		t.Error("Removed definition should stay removed after reload")
+	}
+}
+
+func TestNewPersistentLimitChecker_StoreErrors(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	mockRepo := &MockLimitCheckerRepository{tenants: make(map[uuid.UUID]*Tenant)}
+
+	// Load failures prevent construction
+	failing := NewMockLimitStore()
+	failing.err = errors.New("store unavailable")
+	if _, err := NewPersistentLimitChecker(context.Background(), DefaultConfig().Limits, mockRepo, failing, failing, logger); err == nil {
+		t.Error("NewPersistentLimitChecker() should error when the store cannot be loaded")
+	}
+
+	// Write failures leave the in-memory state untouched
+	store := NewMockLimitStore()
+	checker, err := NewPersistentLimitChecker(context.Background(), DefaultConfig().Limits, mockRepo, store, store, logger)
+	if err != nil {
+		t.Fatalf("NewPersistentLimitChecker() error = %v", err)
+	}
+	store.err = errors.New("write failed")
+
+	if err := checker.AddLimit(PlanBasic, "new_limit", LimitTypeInt, 1); err == nil {
+		t.Error("AddLimit() should error when the store write fails")
+	}
+	if checker.GetLimitsForPlan(PlanBasic).Has("new_limit") {
+		t.Error("AddLimit() should not change plan limits when the store write fails")
+	}
+
+	replacement := FlexibleLimits{"max_users": IntLimit(1)}
+	if err := checker.SetLimitsForPlan(PlanBasic, replacement); err == nil {
+		t.Error("SetLimitsForPlan() should error when the store write fails")
+	}
+	if !checker.GetLimitsForPlan(PlanBasic).Has("max_projects") {
+		t.Error("SetLimitsForPlan() should not change plan limits when the store write fails")
+	}
+}
+
+func TestNewPersistentLimitChecker_LeavesConfigAlone(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	mockRepo := &MockLimitCheckerRepository{tenants: make(map[uuid.UUID]*Tenant)}
+
+	store := NewMockLimitStore()
+	writer, err := NewPersistentLimitChecker(context.Background(), DefaultConfig().Limits, mockRepo, store, store, logger)
+	if err != nil {
+		t.Fatalf("NewPersistentLimitChecker() error = %v", err)
+	}
+	if err := writer.AddLimit(PlanBasic, "video_minutes", LimitTypeInt, 30); err != nil {
+		t.Fatalf("AddLimit() error = %v", err)
+	}
+
+	config := DefaultConfig().Limits
+	checker, err := NewPersistentLimitChecker(context.Background(), config, mockRepo, store, store, logger)
+	if err != nil {
+		t.Fatalf("NewPersistentLimitChecker() error = %v", err)
+	}
+	if !checker.GetLimitsForPlan(PlanBasic).Has("video_minutes") {
+		t.Error("stored plan limit should be loaded")
+	}
+	if err := checker.UpdateLimit(PlanBasic, "max_users", 8); err != nil {
+		t.Fatalf("UpdateLimit() error = %v", err)
+	}
+
+	if config.PlanLimits[PlanBasic].Has("video_minutes") {
+		t.Error("loading stored limits should not change the configured plan limits")
+	}
+	if _, exists := config.LimitSchema.GetDefinition("video_minutes"); exists {
+		t.Error("loading stored definitions should not change the configured schema")
+	}
+	if got, _ := config.PlanLimits[PlanBasic].GetInt("max_users"); got == 8 {
+		t.Error("UpdateLimit() should not change the configured plan limits")
+	}
+}
+
+func TestNewLimitChecker_InMemoryByDefault(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	mockRepo := &MockLimitCheckerRepository{tenants: make(map[uuid.UUID]*Tenant)}
+
+	checker := NewLimitChecker(DefaultConfig().Limits, mockRepo, logger).(*limitChecker)
+	if checker.schemaStore != nil || checker.planStore != nil {
+		t.Error("NewLimitChecker() should not configure persistent stores")
+	}
+
+	if err := checker.AddLimit(PlanBasic, "in_memory", LimitTypeInt, 1); err != nil {
+		t.Errorf("AddLimit() error = %v, want nil", err)
+	}
+}
+
+// MockLimitStore implements SchemaStore and PlanLimitStore in memory,
+// round-tripping values through JSON like a database would
+type MockLimitStore struct {
+	definitions map[string][]byte
+	planLimits  map[string]map[string][]byte
+	err         error
+}
+
+func NewMockLimitStore() *MockLimitStore {
+	return &MockLimitStore{
+		definitions: make(map[string][]byte),
+		planLimits:  make(map[string]map[string][]byte),
+	}
+}
+
+func (m *MockLimitStore) LoadDefinitions(ctx context.Context) (map[string]*LimitDefinition, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	definitions := make(map[string]*LimitDefinition)
+	for name, raw := range m.definitions {
+		if raw == nil {
+			definitions[name] = nil
+			continue
+		}
+		def := &LimitDefinition{}
+		if err := json.Unmarshal(raw, def); err != nil {
+			return nil, err
+		}
+		definitions[name] = def
+	}
+	return definitions, nil
+}
+
+func (m *MockLimitStore) SaveDefinition(ctx context.Context, def *LimitDefinition) error {
+	if m.err != nil {
+		return m.err
+	}
+	raw, err := json.Marshal(def)
+	if err != nil {
+		return err
+	}
+	m.definitions[def.Name] = raw
+	return nil
+}
+
+func (m *MockLimitStore) DeleteDefinition(ctx context.Context, name string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.definitions[name] = nil
+	return nil
+}
+
+func (m *MockLimitStore) LoadPlanLimits(ctx context.Context) (map[string]FlexibleLimits, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	planLimits := make(map[string]FlexibleLimits)
+	for planType, limits := range m.planLimits {
+		planLimits[planType] = make(FlexibleLimits)
+		for name, raw := range limits {
+			if raw == nil {
+				planLimits[planType][name] = nil
+				continue
+			}
+			value := &LimitValue{}
+			if err := json.Unmarshal(raw, value); err != nil {
+				return nil, err
+			}
+			planLimits[planType][name] = value
+		}
+	}
+	return planLimits, nil
+}
+
+func (m *MockLimitStore) SavePlanLimit(ctx context.Context, planType, limitName string, value *LimitValue) error {
+	if m.err != nil {
+		return m.err
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if m.planLimits[planType] == nil {
+		m.planLimits[planType] = make(map[string][]byte)
+	}
+	m.planLimits[planType][limitName] = raw
+	return nil
+}
+
+func (m *MockLimitStore) DeletePlanLimit(ctx context.Context, planType, limitName string) error {
+	if m.err != nil {
+		return m.err
+	}
+	if m.planLimits[planType] == nil {
+		m.planLimits[planType] = make(map[string][]byte)
+	}
+	m.planLimits[planType][limitName] = nil
+	return nil
+}
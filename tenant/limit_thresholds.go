@@ -0,0 +1,145 @@
+package tenant
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// DefaultThresholdPeriod is how often each threshold can fire for a tenant's
+// limit when LimitsConfig.ThresholdPeriod is not set
+const DefaultThresholdPeriod = 24 * time.Hour
+
+// thresholdKey identifies one threshold of one tenant's limit
+type thresholdKey struct {
+	tenantID uuid.UUID
+	limit    string
+	percent  int
+}
+
+// thresholdState remembers when each threshold last fired
+type thresholdState struct {
+	mu    sync.Mutex
+	fired map[thresholdKey]time.Time
+}
+
+// claim reports whether the threshold may fire at now, recording it if so
+func (s *thresholdState) claim(key thresholdKey, now time.Time, period time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last, ok := s.fired[key]; ok && now.Sub(last) < period {
+		return false
+	}
+	s.fired[key] = now
+	return true
+}
+
+// release forgets a claim, e.g. after the event could not be delivered
+func (s *thresholdState) release(key thresholdKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.fired, key)
+}
+
+// SetEventPublisher sets the publisher that receives limit.threshold_reached
+// events. Pass nil to stop publishing.
+func (lc *limitChecker) SetEventPublisher(publisher EventPublisher) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.publisher = publisher
+}
+
+// thresholdsFor returns the usage percentages configured for a limit, sorted
+func (lc *limitChecker) thresholdsFor(limitName string) []int {
+	thresholds, ok := lc.config.LimitThresholds[limitName]
+	if !ok {
+		thresholds = lc.config.Thresholds
+	}
+
+	sorted := append([]int(nil), thresholds...)
+	sort.Ints(sorted)
+	return sorted
+}
+
+// checkThresholds publishes a limit.threshold_reached event for every
+// configured threshold that the usage has reached and that has not fired for
+// the tenant's limit within the threshold period. Only int and float limits
+// have thresholds.
+func (lc *limitChecker) checkThresholds(ctx context.Context, tenantID uuid.UUID, limitName string, limit *LimitValue, currentValue interface{}) {
+	lc.mu.RLock()
+	publisher := lc.publisher
+	lc.mu.RUnlock()
+	if publisher == nil || currentValue == nil || limit.IsUnlimited() {
+		return
+	}
+
+	thresholds := lc.thresholdsFor(limitName)
+	if len(thresholds) == 0 {
+		return
+	}
+
+	limitValue, err := limit.Float()
+	if limit.Type == LimitTypeInt {
+		var v int
+		v, err = limit.Int()
+		limitValue = float64(v)
+	}
+	if err != nil || limitValue <= 0 {
+		return
+	}
+
+	var usage float64
+	switch v := currentValue.(type) {
+	case int:
+		usage = float64(v)
+	case int64:
+		usage = float64(v)
+	case float64:
+		usage = v
+	default:
+		return
+	}
+
+	period := lc.config.ThresholdPeriod
+	if period <= 0 {
+		period = DefaultThresholdPeriod
+	}
+
+	percentage := usage / limitValue * 100
+	now := time.Now()
+	for _, threshold := range thresholds {
+		if threshold <= 0 || percentage < float64(threshold) {
+			continue
+		}
+		key := thresholdKey{tenantID: tenantID, limit: limitName, percent: threshold}
+		if !lc.thresholds.claim(key, now, period) {
+			continue
+		}
+
+		event := Event{
+			Type:      EventLimitThresholdReached,
+			TenantID:  tenantID,
+			Timestamp: now,
+			Data: map[string]interface{}{
+				"limit":       limitName,
+				"usage":       currentValue,
+				"limit_value": limit.Value,
+				"percentage":  percentage,
+				"threshold":   threshold,
+			},
+		}
+		if err := publisher.Publish(ctx, event); err != nil {
+			lc.thresholds.release(key)
+			lc.logger.Warn("Failed to publish limit threshold event",
+				zap.String("tenant_id", tenantID.String()),
+				zap.String("limit", limitName),
+				zap.Int("threshold", threshold),
+				zap.Error(err))
+		}
+	}
+}
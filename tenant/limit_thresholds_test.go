@@ -0,0 +1,136 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func newThresholdTestChecker(t *testing.T, config LimitsConfig) (LimitChecker, uuid.UUID, *[]Event) {
+	tenantID := uuid.New()
+	mockRepo := &MockLimitCheckerRepository{
+		tenants: map[uuid.UUID]*Tenant{
+			tenantID: {ID: tenantID, PlanType: PlanPro, Status: StatusActive},
+		},
+	}
+
+	checker := NewLimitChecker(config, mockRepo, zaptest.NewLogger(t))
+	var events []Event
+	checker.SetEventPublisher(EventPublisherFunc(func(ctx context.Context, event Event) error {
+		events = append(events, event)
+		return nil
+	}))
+
+	return checker, tenantID, &events
+}
+
+func TestLimitChecker_Thresholds(t *testing.T) {
+	proLimits := make(FlexibleLimits)
+	proLimits.Set(LimitNameMaxProjects, LimitTypeInt, 100)
+	proLimits.Set(LimitNameMaxUsers, LimitTypeInt, 10)
+	proLimits.Set("api_calls_per_month", LimitTypeInt, -1)
+
+	checker, tenantID, events := newThresholdTestChecker(t, LimitsConfig{
+		EnforceLimits: true,
+		PlanLimits:    map[string]FlexibleLimits{PlanPro: proLimits},
+		Thresholds:    []int{80},
+	})
+	ctx := context.Background()
+
+	// Below the threshold nothing is published
+	if err := checker.CheckLimit(ctx, tenantID, LimitNameMaxProjects, 79); err != nil {
+		t.Fatalf("CheckLimit() error = %v", err)
+	}
+	if len(*events) != 0 {
+		t.Fatalf("events below the threshold = %v, want none", *events)
+	}
+
+	// Crossing it publishes once, however often the limit is checked afterwards
+	for _, usage := range []int{80, 85, 95} {
+		if err := checker.CheckLimit(ctx, tenantID, LimitNameMaxProjects, usage); err != nil {
+			t.Fatalf("CheckLimit(%d) error = %v", usage, err)
+		}
+	}
+	if len(*events) != 1 {
+		t.Fatalf("events = %d, want a single event", len(*events))
+	}
+
+	event := (*events)[0]
+	if event.Type != EventLimitThresholdReached || event.TenantID != tenantID {
+		t.Errorf("event = %+v, want limit.threshold_reached for the tenant", event)
+	}
+	if event.Data["limit"] != LimitNameMaxProjects || event.Data["usage"] != 80 || event.Data["percentage"] != 80.0 || event.Data["threshold"] != 80 {
+		t.Errorf("event data = %v, want max_projects at 80 of 100", event.Data)
+	}
+
+	// Each limit has its own thresholds; unlimited limits have none
+	if err := checker.CheckLimit(ctx, tenantID, LimitNameMaxUsers, 9); err != nil {
+		t.Fatalf("CheckLimit() error = %v", err)
+	}
+	if err := checker.CheckLimit(ctx, tenantID, "api_calls_per_month", 1000000); err != nil {
+		t.Fatalf("CheckLimit() error = %v", err)
+	}
+	if len(*events) != 2 || (*events)[1].Data["limit"] != LimitNameMaxUsers {
+		t.Errorf("events = %v, want a second event for max_users only", *events)
+	}
+}
+
+func TestLimitChecker_Thresholds_PerLimitAndPeriod(t *testing.T) {
+	proLimits := make(FlexibleLimits)
+	proLimits.Set(LimitNameMaxStorageGB, LimitTypeInt, 10)
+	proLimits.Set(LimitNameMaxProjects, LimitTypeInt, 10)
+
+	checker, tenantID, events := newThresholdTestChecker(t, LimitsConfig{
+		EnforceLimits:   true,
+		PlanLimits:      map[string]FlexibleLimits{PlanPro: proLimits},
+		Thresholds:      []int{80},
+		LimitThresholds: map[string][]int{LimitNameMaxStorageGB: {90, 50}},
+		ThresholdPeriod: time.Hour,
+	})
+	ctx := context.Background()
+
+	// Jumping past both storage thresholds fires each of them
+	if err := checker.CheckLimit(ctx, tenantID, LimitNameMaxStorageGB, 9); err != nil {
+		t.Fatalf("CheckLimit() error = %v", err)
+	}
+	if len(*events) != 2 || (*events)[0].Data["threshold"] != 50 || (*events)[1].Data["threshold"] != 90 {
+		t.Fatalf("events = %v, want the 50%% and 90%% thresholds", *events)
+	}
+
+	// Once the period has passed a threshold can fire again
+	lc := checker.(*limitChecker)
+	lc.thresholds.mu.Lock()
+	for key, firedAt := range lc.thresholds.fired {
+		lc.thresholds.fired[key] = firedAt.Add(-2 * time.Hour)
+	}
+	lc.thresholds.mu.Unlock()
+
+	if err := checker.CheckLimit(ctx, tenantID, LimitNameMaxStorageGB, 6); err != nil {
+		t.Fatalf("CheckLimit() error = %v", err)
+	}
+	if len(*events) != 3 || (*events)[2].Data["threshold"] != 50 {
+		t.Errorf("events = %v, want the 50%% threshold again after the period", *events)
+	}
+
+	// A failed delivery is retried on the next check
+	var attempts int
+	checker.SetEventPublisher(EventPublisherFunc(func(ctx context.Context, event Event) error {
+		attempts++
+		if attempts == 1 {
+			return errors.New("webhook unavailable")
+		}
+		return nil
+	}))
+	for i := 0; i < 3; i++ {
+		if err := checker.CheckLimit(ctx, tenantID, LimitNameMaxProjects, 8); err != nil {
+			t.Fatalf("CheckLimit() error = %v", err)
+		}
+	}
+	if attempts != 2 {
+		t.Errorf("publish attempts = %d, want a failure and one retry", attempts)
+	}
+}
@@ -0,0 +1,93 @@
+package tenant
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// lookupCache is a concurrency-safe TTL cache of tenant IDs by lookup key,
+// such as a subdomain or custom domain. uuid.Nil may be cached to remember
+// that a key has no tenant. It holds at most max entries; when full, expired
+// entries are swept and, if that frees nothing, an arbitrary entry is dropped.
+type lookupCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	max     int
+	entries map[string]lookupCacheEntry
+	now     func() time.Time
+}
+
+type lookupCacheEntry struct {
+	tenantID uuid.UUID
+	expires  time.Time
+}
+
+func newLookupCache(ttl time.Duration, max int) *lookupCache {
+	return &lookupCache{
+		ttl:     ttl,
+		max:     max,
+		entries: make(map[string]lookupCacheEntry),
+		now:     time.Now,
+	}
+}
+
+// get returns the cached tenant of key and whether key was cached
+func (c *lookupCache) get(key string) (uuid.UUID, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if ok && !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		return uuid.Nil, false
+	}
+	return entry.tenantID, ok
+}
+
+// put caches the tenant of key
+func (c *lookupCache) put(key string, tenantID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.max {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		for k := range c.entries {
+			if len(c.entries) < c.max {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = lookupCacheEntry{tenantID: tenantID, expires: now.Add(c.ttl)}
+}
+
+// forget removes key and, unless tenantID is uuid.Nil, every key cached for
+// tenantID
+func (c *lookupCache) forget(tenantID uuid.UUID, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+	if tenantID == uuid.Nil {
+		return
+	}
+	for k, entry := range c.entries {
+		if entry.tenantID == tenantID {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// len returns the number of cached entries, including expired ones
+func (c *lookupCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
@@ -0,0 +1,217 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// DefaultExecConcurrency is how many tenants ExecInEachTenant works on at once
+// when ExecOptions.Concurrency is not set
+const DefaultExecConcurrency = 4
+
+// ErrDestructiveStatement is returned by ExecInEachTenant for statements that
+// drop or delete data unless ExecOptions.AllowDestructive is set
+var ErrDestructiveStatement = errors.New("destructive statement requires AllowDestructive")
+
+// destructiveStatement matches statements that drop or delete data: those
+// starting with DROP, TRUNCATE or DELETE, ALTER TABLE statements that drop
+// something and WITH queries that DELETE FROM a table. Keywords elsewhere,
+// such as ON DELETE CASCADE, do not match.
+var destructiveStatement = regexp.MustCompile(`(?is)(?:^|;)\s*(?:(?:DROP|TRUNCATE|DELETE)\b|ALTER\s+TABLE\b[^;]*\bDROP\b|WITH\b[^;]*\bDELETE\s+FROM\b)`)
+
+// sqlComment matches SQL line and block comments
+var sqlComment = regexp.MustCompile(`(?s)--[^\n]*|/\*.*?\*/`)
+
+// IsDestructiveSQL reports whether SQL has a statement starting with DROP,
+// TRUNCATE or DELETE, an ALTER TABLE statement that drops something such as a
+// column, or a WITH query that deletes rows. Comments are ignored.
+func IsDestructiveSQL(sql string) bool {
+	return destructiveStatement.MatchString(sqlComment.ReplaceAllString(sql, " "))
+}
+
+// execer is implemented by *sql.Conn and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// ExecOptions controls how ExecInEachTenant runs a statement
+type ExecOptions struct {
+	Concurrency      int  // Tenants processed at once; 0 uses DefaultExecConcurrency
+	NoTransaction    bool // Run outside a transaction, for statements such as VACUUM
+	AllowDestructive bool // Confirms statements containing DROP, TRUNCATE or DELETE
+	Templated        bool // Expand the migration template placeholders per tenant
+}
+
+// TenantExecResult is the outcome of running a statement in one tenant's schema
+type TenantExecResult struct {
+	TenantID     uuid.UUID     `json:"tenant_id"`
+	SchemaName   string        `json:"schema_name"`
+	RowsAffected int64         `json:"rows_affected"`
+	Duration     time.Duration `json:"duration"`
+	Err          error         `json:"-"`
+}
+
+// ExecInEachTenant runs a statement in the schema of every active tenant and
+// reports the result for each. The statement runs with search_path set to the
+// tenant's schema, in its own transaction unless opts.NoTransaction is set.
+// With opts.Templated the migration template placeholders, such as
+// {{ident .SchemaName}}, are expanded for each tenant.
+// A failure in one tenant does not stop the others; the returned error reports
+// how many failed.
+func (m *manager) ExecInEachTenant(ctx context.Context, sqlTemplate string, opts ExecOptions) ([]*TenantExecResult, error) {
+	if IsDestructiveSQL(sqlTemplate) && !opts.AllowDestructive {
+		return nil, ErrDestructiveStatement
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultExecConcurrency
+	}
+
+	tenants, err := m.activeTenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*TenantExecResult, len(tenants))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, t := range tenants {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, t *Tenant) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = m.execInTenant(ctx, t, sqlTemplate, opts)
+		}(i, t)
+	}
+	wg.Wait()
+
+	var failed int
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			m.logger.Error("Maintenance statement failed for tenant",
+				zap.String("tenant_id", result.TenantID.String()),
+				zap.Error(result.Err))
+		}
+	}
+
+	m.logger.Info("Ran maintenance statement in tenant schemas",
+		zap.Int("tenants", len(results)),
+		zap.Int("failed", failed))
+
+	if failed > 0 {
+		return results, fmt.Errorf("statement failed for %d of %d tenants", failed, len(results))
+	}
+	return results, nil
+}
+
+// execInTenant runs a statement in one tenant's schema
+func (m *manager) execInTenant(ctx context.Context, t *Tenant, sqlTemplate string, opts ExecOptions) *TenantExecResult {
+	result := &TenantExecResult{TenantID: t.ID, SchemaName: m.schemaManager.GetSchemaName(t.ID)}
+	start := time.Now()
+	defer func() { result.Duration = time.Since(start) }()
+
+	statement := sqlTemplate
+	if opts.Templated {
+		rendered, err := RenderTenantSQL("statement", sqlTemplate, t)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		statement = rendered
+	}
+
+	exec := func(e execer) error {
+		res, err := e.ExecContext(ctx, statement)
+		if err != nil {
+			return err
+		}
+		// DDL has no meaningful row count
+		result.RowsAffected, _ = res.RowsAffected()
+		return nil
+	}
+
+	if opts.NoTransaction {
+		conn, err := m.GetTenantConn(ctx, t.ID)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		defer conn.Close()
+
+		result.Err = exec(conn)
+		return result
+	}
+
+	result.Err = m.WithTenantTx(ctx, t.ID, func(tx *sql.Tx) error {
+		return exec(tx)
+	})
+	return result
+}
+
+// activeTenants returns every active tenant, reading the repository page by
+// page
+func (m *manager) activeTenants(ctx context.Context) ([]*Tenant, error) {
+	var active []*Tenant
+	err := m.eachActiveTenant(ctx, func(t *Tenant) bool {
+		active = append(active, t)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return active, nil
+}
+
+// eachActiveTenant calls fn for every active tenant, reading the repository
+// one page at a time, until fn returns false. Each tenant is visited at most
+// once. Tenants removed from the list while the run is in progress, e.g.
+// cancelled by fn, shift later tenants onto pages already read, so the pages
+// they shifted onto are read again. Repositories list the newest tenants
+// first, so tenants created during the run are normally not visited.
+func (m *manager) eachActiveTenant(ctx context.Context, fn func(t *Tenant) bool) error {
+	seen := make(map[uuid.UUID]struct{})
+	listed := -1
+	for page := 1; ; page++ {
+		tenants, total, err := m.repository.List(ctx, page, MaxPerPage)
+		if err != nil {
+			return fmt.Errorf("failed to list tenants: %w", err)
+		}
+
+		if listed >= 0 && total < listed && page > 1 {
+			back := (listed - total + MaxPerPage - 1) / MaxPerPage
+			listed = total
+			page = max(page-back, 1) - 1
+			continue
+		}
+		listed = total
+
+		for _, t := range tenants {
+			if t.Status != StatusActive {
+				continue
+			}
+			if _, ok := seen[t.ID]; ok {
+				continue
+			}
+			seen[t.ID] = struct{}{}
+			if !fn(t) {
+				return nil
+			}
+		}
+
+		if len(tenants) == 0 || page*MaxPerPage >= total {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestManager_ExecInEachTenant(t *testing.T) {
+	recorder := &execRecorder{}
+	m := newExecTestManager(t, recorder)
+	ctx := context.Background()
+
+	var active []*Tenant
+	for _, subdomain := range []string{"acme", "globex", "initech"} {
+		tenant := &Tenant{Name: subdomain, Subdomain: subdomain, Status: StatusActive}
+		if err := m.CreateTenant(ctx, tenant); err != nil {
+			t.Fatalf("CreateTenant() error = %v", err)
+		}
+		active = append(active, tenant)
+	}
+	suspended := &Tenant{Name: "Umbrella", Subdomain: "umbrella", Status: StatusSuspended}
+	if err := m.CreateTenant(ctx, suspended); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+
+	results, err := m.ExecInEachTenant(ctx, "ANALYZE {{ident .SchemaName}}.projects", ExecOptions{Concurrency: 2, Templated: true})
+	if err != nil {
+		t.Fatalf("ExecInEachTenant() error = %v", err)
+	}
+	if len(results) != len(active) {
+		t.Fatalf("ExecInEachTenant() returned %d results, want %d active tenants", len(results), len(active))
+	}
+
+	for _, tenant := range active {
+		schema := m.schemaManager.GetSchemaName(tenant.ID)
+		want := []string{
+			fmt.Sprintf(`SET LOCAL search_path TO "%s", public`, schema),
+			fmt.Sprintf(`ANALYZE "%s".projects`, schema),
+		}
+		if got := recorder.statementsFor(schema); strings.Join(got, "; ") != strings.Join(want, "; ") {
+			t.Errorf("statements for %s = %q, want %q", tenant.Subdomain, got, want)
+		}
+	}
+	if got := recorder.statementsFor(m.schemaManager.GetSchemaName(suspended.ID)); len(got) != 0 {
+		t.Errorf("suspended tenant ran %q, want nothing", got)
+	}
+
+	for _, result := range results {
+		if result.Err != nil || result.SchemaName == "" {
+			t.Errorf("result = %+v, want success with schema name", result)
+		}
+	}
+	if recorder.commits != len(active) {
+		t.Errorf("committed %d transactions, want one per tenant (%d)", recorder.commits, len(active))
+	}
+}
+
+func TestManager_ExecInEachTenant_ReportsFailures(t *testing.T) {
+	recorder := &execRecorder{failOn: "tenant_fail"}
+	m := newExecTestManager(t, recorder)
+	ctx := context.Background()
+
+	for _, subdomain := range []string{"acme", "globex"} {
+		if err := m.CreateTenant(ctx, &Tenant{Name: subdomain, Subdomain: subdomain, Status: StatusActive}); err != nil {
+			t.Fatalf("CreateTenant() error = %v", err)
+		}
+	}
+
+	// Fails in every tenant whose name contains the marker
+	failing := &Tenant{Name: "tenant_fail", Subdomain: "failing", Status: StatusActive}
+	if err := m.CreateTenant(ctx, failing); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+
+	results, err := m.ExecInEachTenant(ctx, "COMMENT ON SCHEMA {{ident .SchemaName}} IS {{.Name}}", ExecOptions{Templated: true})
+	if err == nil {
+		t.Fatal("ExecInEachTenant() should report the failed tenant")
+	}
+	if len(results) != 3 {
+		t.Fatalf("ExecInEachTenant() returned %d results, want 3", len(results))
+	}
+
+	var failed int
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			if result.TenantID != failing.ID {
+				t.Errorf("tenant %s failed, want only %s", result.TenantID, failing.ID)
+			}
+		}
+	}
+	if failed != 1 {
+		t.Errorf("%d tenants failed, want 1", failed)
+	}
+	if recorder.rollbacks != 1 {
+		t.Errorf("rolled back %d transactions, want 1", recorder.rollbacks)
+	}
+}
+
+func TestManager_ExecInEachTenant_NoTransaction(t *testing.T) {
+	recorder := &execRecorder{}
+	m := newExecTestManager(t, recorder)
+	ctx := context.Background()
+
+	tenant := &Tenant{Name: "Acme", Subdomain: "acme", Status: StatusActive}
+	if err := m.CreateTenant(ctx, tenant); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+
+	if _, err := m.ExecInEachTenant(ctx, "VACUUM projects", ExecOptions{NoTransaction: true}); err != nil {
+		t.Fatalf("ExecInEachTenant() error = %v", err)
+	}
+
+	schema := m.schemaManager.GetSchemaName(tenant.ID)
+	want := []string{fmt.Sprintf(`SET search_path TO "%s", public`, schema), "VACUUM projects"}
+	if got := recorder.statementsFor(schema); strings.Join(got, "; ") != strings.Join(want, "; ") {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+	if recorder.commits != 0 {
+		t.Errorf("committed %d transactions, want none", recorder.commits)
+	}
+}
+
+func TestManager_ExecInEachTenant_WithoutTemplating(t *testing.T) {
+	recorder := &execRecorder{}
+	m := newExecTestManager(t, recorder)
+	ctx := context.Background()
+
+	tenant := &Tenant{Name: "Acme", Subdomain: "acme", Status: StatusActive}
+	if err := m.CreateTenant(ctx, tenant); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+
+	// Array literals contain "{{" but only templated statements are expanded
+	statement := "UPDATE grids SET cells = '{{1,2},{3,4}}'"
+	if _, err := m.ExecInEachTenant(ctx, statement, ExecOptions{}); err != nil {
+		t.Fatalf("ExecInEachTenant() error = %v", err)
+	}
+
+	got := recorder.statementsFor(m.schemaManager.GetSchemaName(tenant.ID))
+	if len(got) != 2 || got[1] != statement {
+		t.Errorf("statements = %q, want %q run as written", got, statement)
+	}
+}
+
+func TestIsDestructiveSQL(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want bool
+	}{
+		{"DROP TABLE projects", true},
+		{"  truncate tasks", true},
+		{"DELETE FROM documents WHERE archived", true},
+		{"ALTER TABLE projects DROP COLUMN legacy", true},
+		{"ALTER TABLE projects DROP CONSTRAINT projects_owner_fkey", true},
+		{"CREATE INDEX tasks_due ON tasks (due); DROP INDEX tasks_old", true},
+		{"-- remove stale rows\nDELETE FROM sessions", true},
+		{"WITH stale AS (DELETE FROM sessions RETURNING id) SELECT count(*) FROM stale", true},
+		{"CREATE TABLE tasks (project_id UUID REFERENCES projects (id) ON DELETE CASCADE)", false},
+		{"ALTER TABLE tasks ADD CONSTRAINT tasks_project_fkey FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE", false},
+		{"CREATE TRIGGER audit AFTER DELETE ON tasks FOR EACH ROW EXECUTE FUNCTION audit()", false},
+		{"UPDATE tasks SET status = 'deleted' WHERE status = 'drop'", false},
+		{"-- DROP TABLE legacy once migrated\nCREATE INDEX tasks_due ON tasks (due)", false},
+		{"/* was: TRUNCATE tasks */ VACUUM ANALYZE tasks", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsDestructiveSQL(tt.sql); got != tt.want {
+			t.Errorf("IsDestructiveSQL(%q) = %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestManager_ExecInEachTenant_DestructiveRequiresConfirmation(t *testing.T) {
+	recorder := &execRecorder{}
+	m := newExecTestManager(t, recorder)
+	ctx := context.Background()
+
+	if err := m.CreateTenant(ctx, &Tenant{Name: "Acme", Subdomain: "acme", Status: StatusActive}); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+
+	for _, statement := range []string{
+		"DROP TABLE projects",
+		"truncate tasks",
+		"DELETE FROM documents",
+		"ALTER TABLE projects DROP COLUMN legacy",
+	} {
+		if _, err := m.ExecInEachTenant(ctx, statement, ExecOptions{}); !errors.Is(err, ErrDestructiveStatement) {
+			t.Errorf("ExecInEachTenant(%q) error = %v, want ErrDestructiveStatement", statement, err)
+		}
+	}
+	if len(recorder.connections) != 0 {
+		t.Error("destructive statements should not run without confirmation")
+	}
+
+	if _, err := m.ExecInEachTenant(ctx, "ALTER TABLE projects DROP COLUMN legacy", ExecOptions{AllowDestructive: true}); err != nil {
+		t.Errorf("ExecInEachTenant() with AllowDestructive error = %v", err)
+	}
+}
+
+// newExecTestManager returns a manager whose database records executed statements
+func newExecTestManager(t *testing.T, recorder *execRecorder) *manager {
+	db := sql.OpenDB(execTestConnector{recorder: recorder})
+	t.Cleanup(func() { db.Close() })
+
+	return newTestManager(t, withDB(db))
+}
+
+// execRecorder records the statements run on each connection, keyed by the
+// schema the connection's search_path was set to
+type execRecorder struct {
+	mu          sync.Mutex
+	failOn      string // statements containing this fail
+	connections []*execTestConn
+	commits     int
+	rollbacks   int
+}
+
+func (r *execRecorder) statementsFor(schema string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var statements []string
+	for _, conn := range r.connections {
+		if len(conn.statements) > 0 && strings.Contains(conn.statements[0], `"`+schema+`"`) {
+			statements = append(statements, conn.statements...)
+		}
+	}
+	return statements
+}
+
+type execTestConnector struct {
+	recorder *execRecorder
+}
+
+func (c execTestConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn := &execTestConn{recorder: c.recorder}
+	c.recorder.mu.Lock()
+	c.recorder.connections = append(c.recorder.connections, conn)
+	c.recorder.mu.Unlock()
+	return conn, nil
+}
+
+func (execTestConnector) Driver() driver.Driver {
+	return testDriver{}
+}
+
+// writeStatement matches statements a READ ONLY transaction rejects
+var writeStatement = regexp.MustCompile(`(?i)^\s*(INSERT|UPDATE|DELETE|CREATE|ALTER|DROP|TRUNCATE)\b`)
+
+type execTestConn struct {
+	recorder   *execRecorder
+	statements []string
+	searchPath string // as set by the last SET [LOCAL] search_path
+	readOnly   bool   // inside a READ ONLY transaction
+}
+
+func (c *execTestConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("exec test connection does not prepare statements")
+}
+
+func (c *execTestConn) Close() error { return nil }
+
+func (c *execTestConn) Begin() (driver.Tx, error) {
+	return execTestTx{recorder: c.recorder, conn: c}, nil
+}
+
+func (c *execTestConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.recorder.mu.Lock()
+	c.readOnly = opts.ReadOnly
+	c.recorder.mu.Unlock()
+	return execTestTx{recorder: c.recorder, conn: c}, nil
+}
+
+func (c *execTestConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.recorder.mu.Lock()
+	defer c.recorder.mu.Unlock()
+
+	// Connections are reused across tenants; each search_path starts a new record
+	if strings.Contains(query, "search_path") && len(c.statements) > 0 {
+		c.recorder.connections = append(c.recorder.connections, &execTestConn{recorder: c.recorder, statements: c.statements})
+		c.statements = nil
+	}
+	c.statements = append(c.statements, query)
+	for _, prefix := range []string{"SET search_path TO ", "SET LOCAL search_path TO "} {
+		if strings.HasPrefix(query, prefix) {
+			c.searchPath = strings.TrimPrefix(query, prefix)
+		}
+	}
+
+	if c.readOnly && writeStatement.MatchString(query) {
+		return nil, errors.New("cannot execute statement in a read-only transaction")
+	}
+	if c.recorder.failOn != "" && strings.Contains(query, c.recorder.failOn) {
+		return nil, errors.New("statement failed")
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c *execTestConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if query != "SHOW search_path" {
+		return nil, fmt.Errorf("exec test connection cannot query %q", query)
+	}
+
+	c.recorder.mu.Lock()
+	searchPath := c.searchPath
+	c.recorder.mu.Unlock()
+	if searchPath == "" {
+		searchPath = `"$user", public`
+	}
+	return &searchPathRows{value: searchPath}, nil
+}
+
+// searchPathRows is the single-row result of SHOW search_path
+type searchPathRows struct {
+	value string
+	done  bool
+}
+
+func (r *searchPathRows) Columns() []string { return []string{"search_path"} }
+
+func (r *searchPathRows) Close() error { return nil }
+
+func (r *searchPathRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	dest[0] = r.value
+	r.done = true
+	return nil
+}
+
+type execTestTx struct {
+	recorder *execRecorder
+	conn     *execTestConn
+}
+
+func (tx execTestTx) Commit() error {
+	tx.recorder.mu.Lock()
+	tx.conn.readOnly = false
+	tx.recorder.commits++
+	tx.recorder.mu.Unlock()
+	return nil
+}
+
+func (tx execTestTx) Rollback() error {
+	tx.recorder.mu.Lock()
+	tx.conn.readOnly = false
+	tx.recorder.rollbacks++
+	tx.recorder.mu.Unlock()
+	return nil
+}
@@ -3,11 +3,15 @@ package tenant
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -20,25 +24,105 @@ type manager struct {
 	migrationMgr  MigrationManager
 	limitChecker  LimitChecker
 	logger        *zap.Logger
-	connections   map[uuid.UUID]*sql.DB // Tenant-specific connections
+	tracer        trace.Tracer     // No-op unless Config.Tracing is enabled
+	connections   *connectionCache // Tenant-specific connections
+	tenants       *tenantCache     // Tenant records for the request path
+	stats         *statsCache      // Tenant stats served by GetStats
+	labels        *labelCache      // Tenant labels read on the request path
+	inits         lazyInit         // Deduplicates concurrent loads of per-tenant resources
+	provisions    chan struct{}    // Slots for concurrent schema creation, nil if unbounded
+	sequences     sync.Map         // Tenant sequences known to exist, keyed by tenant ID and name
+
+	provisioningMu    sync.RWMutex
+	provisioning      ProvisioningQueue // Asynchronous provisioning, nil until SetProvisioningQueue
+	provisioningHooks ProvisioningHooks
+
+	invalidatorsMu sync.RWMutex
+	invalidators   []SubdomainInvalidator // Told when a tenant takes a subdomain
+
+	regionMu sync.RWMutex
+	regions  RegionRouter // Regional tenant databases, nil until SetRegionRouter
+
+	poolOpenerMu sync.RWMutex
+	poolOpener   TenantPoolOpener // Opens per-tenant pools in pooled mode, nil until SetTenantPoolOpener
+
+	metricsMu sync.RWMutex
+	metrics   MetricsCollector // Operation metrics, nil until SetMetricsCollector
+
+	publisherMu sync.RWMutex
+	publisher   EventPublisher // Receives lifecycle events, nil until SetEventPublisher
+
+	replicasMu  sync.RWMutex
+	replicas    []*sql.DB     // Serve GetTenantReadConn, empty until SetReadReplicas
+	nextReplica atomic.Uint64 // Round-robin position in replicas
+
+	reconcileStop chan struct{} // Stops the usage reconciliation loop, nil if it is not running
+	reconcileDone chan struct{}
+	closeOnce     sync.Once
 }
 
 // NewManager creates a new tenant manager
 func NewManager(config Config, db *sql.DB, repository Repository, schemaManager SchemaManager, migrationMgr MigrationManager, limitChecker LimitChecker, logger *zap.Logger) Manager {
-	return &manager{
+	logger = logger.Named("tenant_manager")
+
+	// In pooled mode the cache holds a pool per tenant, bounded by MaxTenantPools
+	maxConnections := config.Database.MaxTenantConnections
+	if config.Database.MaxTenantPools > 0 {
+		maxConnections = config.Database.MaxTenantPools
+	}
+	connections := newConnectionCache(maxConnections, config.Database.TenantConnIdleTimeout, logger)
+	connections.saturationThreshold = config.Database.PoolSaturationAlert
+
+	m := &manager{
 		config:        config,
 		db:            db,
 		repository:    repository,
 		schemaManager: schemaManager,
 		migrationMgr:  migrationMgr,
 		limitChecker:  limitChecker,
-		logger:        logger.Named("tenant_manager"),
-		connections:   make(map[uuid.UUID]*sql.DB),
+		logger:        logger,
+		tracer:        newTracer(config.Tracing),
+		connections:   connections,
+		tenants:       newTenantCache(config.Resolver.CacheTTL),
+		stats:         newStatsCache(config.Limits.StatsCacheTTL),
+		labels:        newLabelCache(config.Resolver.CacheTTL),
+	}
+
+	if n := config.Database.MaxConcurrentProvisions; n > 0 {
+		m.provisions = make(chan struct{}, n)
+	}
+
+	// Plan templates are stored as metadata, which only an ExtensibleRepository can hold
+	if _, ok := repository.(ExtensibleRepository); !ok && len(config.PlanTemplates) > 0 {
+		logger.Warn("Plan templates are ignored because the repository does not support metadata",
+			zap.Int("plan_templates", len(config.PlanTemplates)))
 	}
+
+	if interval := config.Limits.ReconcileInterval; interval > 0 {
+		m.reconcileStop = make(chan struct{})
+		m.reconcileDone = make(chan struct{})
+		go m.reconcileLoop(interval, m.reconcileStop, m.reconcileDone)
+	}
+
+	return m
 }
 
-// CreateTenant creates a new tenant
+// CreateTenant creates a new tenant. With a provisioning queue set, an error
+// wrapping ErrProvisioningNotQueued means the pending tenant was stored but
+// not enqueued.
 func (m *manager) CreateTenant(ctx context.Context, tenant *Tenant) error {
+	ctx, span := m.startSpan(ctx, "tenant.CreateTenant", uuid.Nil)
+	err := m.createTenant(ctx, tenant, "")
+	if tenant != nil && tenant.ID != uuid.Nil {
+		span.SetAttributes(AttributeTenantID.String(tenant.ID.String()))
+	}
+	endSpan(span, err)
+	return err
+}
+
+// createTenant creates the tenant, attaching regionLabel, if set, before
+// provisioning is queued
+func (m *manager) createTenant(ctx context.Context, tenant *Tenant, regionLabel string) error {
 	// Validate tenant data
 	if err := m.validateTenant(tenant); err != nil {
 		return fmt.Errorf("validation failed: %w", err)
@@ -52,49 +136,188 @@ func (m *manager) CreateTenant(ctx context.Context, tenant *Tenant) error {
 	// Generate schema name
 	tenant.SchemaName = m.schemaManager.GetSchemaName(tenant.ID)
 
-	// Set default values
-	if tenant.Status == "" {
+	// Set default values. Tenants provisioned asynchronously start pending.
+	queue := m.provisioningQueue()
+	if tenant.Status == "" || queue != nil {
 		tenant.Status = StatusPending
 	}
 	if tenant.PlanType == "" {
 		tenant.PlanType = PlanBasic
 	}
 
-	// Create tenant record
-	if err := m.repository.Create(ctx, tenant); err != nil {
+	// Create tenant record, with plan template metadata when the repository supports it.
+	// A retry with the same idempotency key returns the original tenant.
+	created, err := m.createTenantIdempotent(ctx, tenant)
+	if err != nil {
 		return fmt.Errorf("failed to create tenant: %w", err)
 	}
+	if !created {
+		return nil
+	}
+
+	if regionLabel != "" {
+		defer m.labels.invalidate(tenant.ID)
+		if err := m.repository.(LabelRepository).AddLabel(ctx, tenant.ID, regionLabel); err != nil {
+			m.rollbackOnboarding(ctx, tenant, false)
+			return fmt.Errorf("failed to record tenant region: %w", err)
+		}
+	}
 
 	m.logger.Info("Created tenant",
 		zap.String("tenant_id", tenant.ID.String()),
 		zap.String("name", tenant.Name),
 		zap.String("subdomain", tenant.Subdomain))
+	m.metricsCollector().TenantCreated(tenant.PlanType)
+	m.publishEvent(ctx, EventTenantCreated, tenant, nil)
+
+	if queue != nil {
+		if err := queue.Enqueue(ctx, tenant.ID); err != nil {
+			return fmt.Errorf("%w: %w", ErrProvisioningNotQueued, err)
+		}
+	}
+
+	return nil
+}
+
+// createTenantRecord stores a new tenant. If the tenant's plan has a template
+// and the repository supports metadata, the template defaults are stored too.
+func (m *manager) createTenantRecord(ctx context.Context, tenant *Tenant) error {
+	// Report a taken subdomain as a validation error rather than a constraint violation
+	taken, err := m.subdomainExists(ctx, tenant.Subdomain)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return &ValidationError{Field: "subdomain", Message: "subdomain is already taken"}
+	}
+	defer m.invalidateSubdomain(tenant.Subdomain)
+
+	if err := m.checkTenantQuota(ctx, 1); err != nil {
+		return err
+	}
+
+	template, hasTemplate := m.config.PlanTemplates[tenant.PlanType]
+	extRepo, isExtensible := m.repository.(ExtensibleRepository)
+	if !hasTemplate || !isExtensible {
+		return m.repository.Create(ctx, tenant)
+	}
+
+	extTenant := &ExtensibleTenant{
+		ID:         tenant.ID,
+		Name:       tenant.Name,
+		Subdomain:  tenant.Subdomain,
+		PlanType:   tenant.PlanType,
+		Status:     tenant.Status,
+		SchemaName: tenant.SchemaName,
+		Metadata:   template.Apply(nil),
+	}
+	if err := extRepo.CreateExtended(ctx, extTenant); err != nil {
+		return err
+	}
+
+	tenant.CreatedAt = extTenant.CreatedAt
+	tenant.UpdatedAt = extTenant.UpdatedAt
+	return nil
+}
+
+// checkTenantQuota returns a TenantQuotaExceededError if adding more
+// tenants would exceed Config.MaxTenants. Cancelled tenants do not count. The
+// count and the insert are not atomic, so concurrent creates can overshoot the
+// cap slightly.
+func (m *manager) checkTenantQuota(ctx context.Context, adding int) error {
+	if m.config.MaxTenants <= 0 {
+		return nil
+	}
 
+	_, total, err := m.repository.List(ctx, 1, 1)
+	if err != nil {
+		return fmt.Errorf("failed to count tenants: %w", err)
+	}
+	if total+adding > m.config.MaxTenants {
+		return &TenantQuotaExceededError{MaxTenants: m.config.MaxTenants, Current: total}
+	}
 	return nil
 }
 
-// GetTenant retrieves a tenant by ID
+// GetTenant retrieves a tenant by ID, served from the resolution cache when fresh
 func (m *manager) GetTenant(ctx context.Context, id uuid.UUID) (*Tenant, error) {
-	return m.repository.GetByID(ctx, id)
+	return m.cachedTenant(ctx, id)
 }
 
-// GetTenantBySubdomain retrieves a tenant by subdomain
+// GetTenantBySubdomain retrieves a tenant by subdomain, served from the
+// resolution cache when fresh
 func (m *manager) GetTenantBySubdomain(ctx context.Context, subdomain string) (*Tenant, error) {
-	return m.repository.GetBySubdomain(ctx, subdomain)
+	if tenant, ok := m.tenants.getBySubdomain(subdomain); ok {
+		return tenant, nil
+	}
+
+	gen := m.tenants.generation()
+	tenant, err := m.repository.GetBySubdomain(ctx, subdomain)
+	if err != nil {
+		return nil, err
+	}
+	m.tenants.put(tenant, gen)
+	return tenant, nil
 }
 
-// UpdateTenant updates a tenant
+// IsSubdomainAvailable reports whether a new tenant could use subdomain. It
+// returns a ValidationError for subdomains that are malformed or reserved.
+func (m *manager) IsSubdomainAvailable(ctx context.Context, subdomain string) (bool, error) {
+	if err := m.validateSubdomain(subdomain); err != nil {
+		return false, &ValidationError{Field: "subdomain", Message: err.Error()}
+	}
+
+	taken, err := m.subdomainExists(ctx, subdomain)
+	if err != nil {
+		return false, err
+	}
+	return !taken, nil
+}
+
+// UpdateTenant updates a tenant. Plans are changed with ChangePlan, which
+// checks the new plan and records the change, so an update that changes the
+// tenant's plan is rejected with a *ValidationError.
 func (m *manager) UpdateTenant(ctx context.Context, tenant *Tenant) error {
 	if err := m.validateTenant(tenant); err != nil {
 		return fmt.Errorf("validation failed: %w", err)
 	}
 
+	stored, err := m.repository.GetByID(ctx, tenant.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get tenant: %w", err)
+	}
+	if stored.PlanType != tenant.PlanType {
+		return &ValidationError{Field: "plan_type", Message: "use ChangePlan to change a tenant's plan"}
+	}
+
+	defer m.tenants.invalidate(tenant.ID)
+	defer m.invalidateSubdomain(tenant.Subdomain)
+	if oldSubdomain := stored.Subdomain; oldSubdomain != tenant.Subdomain {
+		// The old subdomain must stop resolving to the tenant
+		defer m.invalidateSubdomain(oldSubdomain)
+	}
 	return m.repository.Update(ctx, tenant)
 }
 
 // DeleteTenant soft deletes a tenant
 func (m *manager) DeleteTenant(ctx context.Context, id uuid.UUID) error {
-	return m.repository.Delete(ctx, id)
+	defer m.tenants.invalidate(id)
+	defer m.stats.invalidate(id)
+	defer m.connections.remove(id)
+
+	// Looked up first for the event's plan; the delete reports a missing tenant
+	deleted := &Tenant{ID: id}
+	if t, err := m.cachedTenant(ctx, id); err == nil {
+		deleted = t
+	}
+
+	if err := m.repository.Delete(ctx, id); err != nil {
+		return err
+	}
+	m.invalidateTenantSubdomain(ctx, id)
+	m.metricsCollector().TenantDeleted()
+	m.publishEvent(ctx, EventTenantDeleted, deleted, nil)
+	return nil
 }
 
 // ListTenants lists tenants with pagination
@@ -102,48 +325,148 @@ func (m *manager) ListTenants(ctx context.Context, page, perPage int) ([]*Tenant
 	return m.repository.List(ctx, page, perPage)
 }
 
-// ProvisionTenant creates the tenant schema and activates the tenant
+// ListTenantsPaged lists tenants with pagination metadata
+func (m *manager) ListTenantsPaged(ctx context.Context, page, perPage int) (*Page[*Tenant], error) {
+	page, perPage = NormalizePagination(page, perPage)
+	tenants, total, err := m.repository.List(ctx, page, perPage)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPage(tenants, total, page, perPage), nil
+}
+
+// WithForceProvision returns a context that lets ProvisionTenant provision
+// suspended and cancelled tenants, e.g. to rebuild a dropped schema for a
+// tenant before restoring it
+func WithForceProvision(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKeyForceProvision, true)
+}
+
+// ProvisionResult reports what ProvisionTenantDetailed did
+type ProvisionResult struct {
+	TenantID          uuid.UUID     `json:"tenant_id"`
+	SchemaName        string        `json:"schema_name"`
+	SchemaCreated     bool          `json:"schema_created"`     // false if the schema already existed
+	MigrationsApplied int           `json:"migrations_applied"` // recorded in the new schema
+	Duration          time.Duration `json:"duration"`
+}
+
+// ProvisionTenant creates the tenant schema and activates the tenant. It
+// returns a ProvisionStatusError for suspended and cancelled tenants unless
+// the context comes from WithForceProvision.
 func (m *manager) ProvisionTenant(ctx context.Context, id uuid.UUID) error {
+	_, err := m.ProvisionTenantDetailed(ctx, id)
+	return err
+}
+
+// ProvisionTenantDetailed provisions the tenant like ProvisionTenant and
+// reports whether the schema was created or already existed, how many
+// migrations the new schema has and how long it took
+func (m *manager) ProvisionTenantDetailed(ctx context.Context, id uuid.UUID) (result *ProvisionResult, err error) {
+	start := time.Now()
+	ctx, span := m.startSpan(ctx, "tenant.ProvisionTenant", id)
+	defer func() {
+		m.metricsCollector().ProvisionCompleted(time.Since(start), err)
+		endSpan(span, err)
+	}()
+	result = &ProvisionResult{TenantID: id, SchemaName: m.schemaManager.GetSchemaName(id)}
+
 	// Get tenant
 	tenant, err := m.repository.GetByID(ctx, id)
 	if err != nil {
-		return fmt.Errorf("failed to get tenant: %w", err)
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	// Refuse to recreate a schema for a soft-deleted or suspended tenant
+	if tenant.Status == StatusCancelled || tenant.Status == StatusSuspended {
+		if force, _ := ctx.Value(contextKeyForceProvision).(bool); !force {
+			return nil, &ProvisionStatusError{TenantID: id, Status: tenant.Status}
+		}
+		m.logger.Warn("Force provisioning tenant",
+			zap.String("tenant_id", id.String()),
+			zap.String("status", tenant.Status))
+	}
+
+	// Regional tenants are provisioned in their region's database
+	schemas, err := m.tenantSchemaManager(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Wait for a schema creation slot so onboarding spikes do not overwhelm the database
+	release, err := m.acquireProvisionSlot(ctx)
+	if err != nil {
+		return nil, err
 	}
+	defer release()
 
 	// Check if already provisioned
-	exists, err := m.schemaManager.SchemaExists(ctx, id)
+	exists, err := schemas.SchemaExists(ctx, id)
 	if err != nil {
-		return fmt.Errorf("failed to check schema existence: %w", err)
+		return nil, fmt.Errorf("failed to check schema existence: %w", err)
 	}
 
 	if exists {
 		m.logger.Info("Tenant schema already exists",
 			zap.String("tenant_id", id.String()))
-		return nil
+		result.Duration = time.Since(start)
+		return result, nil
 	}
 
 	// Create tenant schema
-	if err := m.schemaManager.CreateTenantSchema(ctx, id, tenant.Name); err != nil {
-		return fmt.Errorf("failed to create tenant schema: %w", err)
+	if err := schemas.CreateTenantSchema(ctx, id, tenant.Name); err != nil {
+		return nil, fmt.Errorf("failed to create tenant schema: %w", err)
 	}
+	defer m.stats.invalidate(id)
+	result.SchemaCreated = true
 
 	// Update tenant status to active
 	tenant.Status = StatusActive
+	defer m.tenants.invalidate(id)
 	if err := m.repository.Update(ctx, tenant); err != nil {
 		// Try to clean up schema if update fails
-		if dropErr := m.schemaManager.DropTenantSchema(ctx, id); dropErr != nil {
+		if dropErr := schemas.DropTenantSchema(ctx, id); dropErr != nil {
 			m.logger.Error("Failed to cleanup schema after provisioning failure",
 				zap.String("tenant_id", id.String()),
 				zap.Error(dropErr))
 		}
-		return fmt.Errorf("failed to update tenant status: %w", err)
+		return nil, fmt.Errorf("failed to update tenant status: %w", err)
+	}
+
+	// The schema is provisioned either way, so a failed count is only logged
+	if applied, err := m.migrationMgr.GetAppliedMigrations(ctx, id); err != nil {
+		m.logger.Warn("Failed to count migrations of provisioned tenant",
+			zap.String("tenant_id", id.String()),
+			zap.Error(err))
+	} else {
+		result.MigrationsApplied = len(applied)
 	}
+	result.Duration = time.Since(start)
 
 	m.logger.Info("Successfully provisioned tenant",
 		zap.String("tenant_id", id.String()),
-		zap.String("name", tenant.Name))
+		zap.String("name", tenant.Name),
+		zap.Int("migrations", result.MigrationsApplied),
+		zap.Duration("duration", result.Duration))
+	m.publishEvent(ctx, EventTenantProvisioned, tenant, nil)
 
-	return nil
+	return result, nil
+}
+
+// acquireProvisionSlot waits for one of the DatabaseConfig.MaxConcurrentProvisions
+// schema creation slots and returns the function that frees it
+func (m *manager) acquireProvisionSlot(ctx context.Context) (func(), error) {
+	if m.provisions == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case m.provisions <- struct{}{}:
+		return func() { <-m.provisions }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting to provision: %w", ctx.Err())
+	}
 }
 
 // SuspendTenant suspends a tenant
@@ -154,12 +477,54 @@ func (m *manager) SuspendTenant(ctx context.Context, id uuid.UUID) error {
 	}
 
 	tenant.Status = StatusSuspended
+	defer m.tenants.invalidate(id)
+	defer m.invalidateSubdomain(tenant.Subdomain)
 	if err := m.repository.Update(ctx, tenant); err != nil {
 		return fmt.Errorf("failed to suspend tenant: %w", err)
 	}
 
 	m.logger.Info("Suspended tenant",
 		zap.String("tenant_id", id.String()))
+	m.metricsCollector().TenantSuspended(tenant.PlanType)
+	m.publishEvent(ctx, EventTenantSuspended, tenant, nil)
+
+	return nil
+}
+
+// RestoreTenant undoes DeleteTenant. The tenant becomes active if its schema
+// still exists and pending otherwise, so that it can be provisioned again.
+// Restoring a tenant that is not cancelled is a no-op.
+func (m *manager) RestoreTenant(ctx context.Context, id uuid.UUID) error {
+	tenant, err := m.repository.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get tenant: %w", err)
+	}
+	if tenant.Status != StatusCancelled {
+		return nil
+	}
+
+	schemaManager, err := m.tenantSchemaManager(ctx, id)
+	if err != nil {
+		return err
+	}
+	exists, err := schemaManager.SchemaExists(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to check schema existence: %w", err)
+	}
+
+	tenant.Status = StatusPending
+	if exists {
+		tenant.Status = StatusActive
+	}
+	defer m.tenants.invalidate(id)
+	defer m.invalidateSubdomain(tenant.Subdomain)
+	if err := m.repository.Update(ctx, tenant); err != nil {
+		return fmt.Errorf("failed to restore tenant: %w", err)
+	}
+
+	m.logger.Info("Restored tenant",
+		zap.String("tenant_id", id.String()),
+		zap.String("status", tenant.Status))
 
 	return nil
 }
@@ -172,6 +537,8 @@ func (m *manager) ActivateTenant(ctx context.Context, id uuid.UUID) error {
 	}
 
 	tenant.Status = StatusActive
+	defer m.tenants.invalidate(id)
+	defer m.invalidateSubdomain(tenant.Subdomain)
 	if err := m.repository.Update(ctx, tenant); err != nil {
 		return fmt.Errorf("failed to activate tenant: %w", err)
 	}
@@ -185,7 +552,7 @@ func (m *manager) ActivateTenant(ctx context.Context, id uuid.UUID) error {
 // ValidateAccess validates if a user has access to a tenant
 func (m *manager) ValidateAccess(ctx context.Context, userID, tenantID uuid.UUID) error {
 	// Basic implementation - in practice you'd check user-tenant relationships
-	tenant, err := m.repository.GetByID(ctx, tenantID)
+	tenant, err := m.cachedTenant(ctx, tenantID)
 	if err != nil {
 		return fmt.Errorf("failed to get tenant: %w", err)
 	}
@@ -202,7 +569,7 @@ func (m *manager) ValidateAccess(ctx context.Context, userID, tenantID uuid.UUID
 
 // CheckLimits validates tenant against plan limits
 func (m *manager) CheckLimits(ctx context.Context, tenantID uuid.UUID) (*Limits, error) {
-	tenant, err := m.repository.GetByID(ctx, tenantID)
+	tenant, err := m.cachedTenant(ctx, tenantID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tenant: %w", err)
 	}
@@ -233,17 +600,36 @@ func (m *manager) CheckLimits(ctx context.Context, tenantID uuid.UUID) (*Limits,
 	return limits, nil
 }
 
-// GetStats retrieves tenant usage statistics
-func (m *manager) GetStats(ctx context.Context, tenantID uuid.UUID) (*Stats, error) {
-	return m.repository.GetStats(ctx, tenantID)
+// CheckLimit checks a single plan limit for a tenant. A nil currentValue is
+// read from the limit checker's usage tracker.
+func (m *manager) CheckLimit(ctx context.Context, tenantID uuid.UUID, limitName string, currentValue interface{}) error {
+	return m.limitChecker.CheckLimit(ctx, tenantID, limitName, currentValue)
+}
+
+// GetAppliedMigrations returns the migrations applied to a tenant's schema
+func (m *manager) GetAppliedMigrations(ctx context.Context, tenantID uuid.UUID) ([]*Migration, error) {
+	return m.migrationMgr.GetAppliedMigrations(ctx, tenantID)
 }
 
+// GetLimitSchema returns a serializable description of the active limit schema
+func (m *manager) GetLimitSchema() []LimitDescription {
+	return m.limitChecker.GetLimitSchema().Describe()
+}
+
+// ErrUnsafeTenantDBDisabled is returned by GetTenantDB when
+// DatabaseConfig.DisableUnsafeTenantDB is set
+var ErrUnsafeTenantDBDisabled = errors.New("GetTenantDB is disabled because it is unsafe with connection pools; use GetTenantConn or WithTenantTx")
+
 // GetTenantDB returns a database connection with tenant context set.
 //
 // Deprecated: This method is unsafe with connection pools. The search_path is set on
 // one connection, but subsequent queries may use different connections from the pool.
 // Use GetTenantConn or WithTenantTx instead for safe tenant-scoped queries.
 func (m *manager) GetTenantDB(ctx context.Context, tenantID uuid.UUID) (*sql.DB, error) {
+	if m.config.Database.DisableUnsafeTenantDB {
+		return nil, ErrUnsafeTenantDBDisabled
+	}
+
 	m.logger.Warn("GetTenantDB is deprecated and unsafe with connection pools. Use GetTenantConn or WithTenantTx instead.",
 		zap.String("tenant_id", tenantID.String()))
 
@@ -255,55 +641,123 @@ func (m *manager) GetTenantDB(ctx context.Context, tenantID uuid.UUID) (*sql.DB,
 	return m.db, nil
 }
 
+// ErrTenantScopeMismatch is returned, wrapped, by AssertTenantScope when a
+// connection's search_path does not start with the tenant's schema
+var ErrTenantScopeMismatch = errors.New("connection is not scoped to the tenant schema")
+
+// RowQuerier is implemented by *sql.DB, *sql.Conn and *sql.Tx
+type RowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 // GetTenantConn returns a dedicated database connection with search_path set to the tenant's schema.
 // The caller MUST close the connection when done to return it to the pool.
 func (m *manager) GetTenantConn(ctx context.Context, tenantID uuid.UUID) (*sql.Conn, error) {
+	ctx, span := m.startSpan(ctx, "tenant.GetTenantConn", tenantID)
+	conn, err := m.getTenantConn(ctx, tenantID)
+	endSpan(span, err)
+	return conn, err
+}
+
+// getTenantConn implements GetTenantConn
+func (m *manager) getTenantConn(ctx context.Context, tenantID uuid.UUID) (*sql.Conn, error) {
 	// Get a dedicated connection from the pool
-	conn, err := m.db.Conn(ctx)
+	conn, pooled, err := m.tenantConn(ctx, tenantID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+		return nil, err
 	}
 
-	// Set search_path on this specific connection using PostgreSQL identifier quoting
-	schemaName := m.schemaManager.GetSchemaName(tenantID)
-	quotedSchema := fmt.Sprintf(`"%s"`, schemaName)
-	query := fmt.Sprintf("SET search_path TO %s, public", quotedSchema)
-	if _, err := conn.ExecContext(ctx, query); err != nil {
+	// The tenant's own pool sets search_path on connect
+	if err := m.scopeTenantConn(ctx, conn, tenantID, !pooled); err != nil {
 		conn.Close() // Release connection on error
-		return nil, fmt.Errorf("failed to set search path: %w", err)
+		return nil, err
 	}
 
 	m.logger.Debug("Acquired tenant connection",
 		zap.String("tenant_id", tenantID.String()),
-		zap.String("schema", schemaName))
+		zap.String("schema", m.schemaManager.GetSchemaName(tenantID)))
 
 	return conn, nil
 }
 
+// scopeTenantConn scopes conn to the tenant, first setting its search_path if
+// setSearchPath is true, and verifies the scope if VerifyTenantScope is set
+func (m *manager) scopeTenantConn(ctx context.Context, conn *sql.Conn, tenantID uuid.UUID, setSearchPath bool) error {
+	// Set search_path on this specific connection using PostgreSQL identifier quoting
+	if setSearchPath {
+		quotedSchema := fmt.Sprintf(`"%s"`, m.schemaManager.GetSchemaName(tenantID))
+		query := fmt.Sprintf("SET search_path TO %s, public", quotedSchema)
+		if _, err := conn.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to set search path: %w", err)
+		}
+	}
+	if err := m.markSessionScoped(conn); err != nil {
+		return err
+	}
+	if err := m.scopeSession(ctx, conn, tenantID, false); err != nil {
+		return err
+	}
+
+	if m.config.Database.VerifyTenantScope {
+		if err := m.AssertTenantScope(ctx, conn, tenantID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // WithTenantTx executes a function within a transaction with the tenant's search_path set.
-// This is the safest way to execute tenant-scoped queries.
+// This is the safest way to execute tenant-scoped queries. The transaction is READ ONLY
+// while the tenant is in read-only mode, so writes in fn fail.
 func (m *manager) WithTenantTx(ctx context.Context, tenantID uuid.UUID, fn func(tx *sql.Tx) error) error {
+	ctx, span := m.startSpan(ctx, "tenant.WithTenantTx", tenantID)
+	err := m.withTenantTx(ctx, tenantID, fn)
+	endSpan(span, err)
+	return err
+}
+
+// withTenantTx implements WithTenantTx
+func (m *manager) withTenantTx(ctx context.Context, tenantID uuid.UUID, fn func(tx *sql.Tx) error) error {
+	readOnly, err := m.IsReadOnly(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to check read-only mode: %w", err)
+	}
+
 	// Get a dedicated connection
-	conn, err := m.db.Conn(ctx)
+	conn, pooled, err := m.tenantConn(ctx, tenantID)
 	if err != nil {
-		return fmt.Errorf("failed to acquire connection: %w", err)
+		return err
 	}
 	defer conn.Close()
 
 	// Start transaction
-	tx, err := conn.BeginTx(ctx, nil)
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: readOnly})
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
 	// Set search_path within the transaction using SET LOCAL (scoped to transaction)
-	// Using PostgreSQL identifier quoting for the schema name
-	schemaName := m.schemaManager.GetSchemaName(tenantID)
-	quotedSchema := fmt.Sprintf(`"%s"`, schemaName)
-	query := fmt.Sprintf("SET LOCAL search_path TO %s, public", quotedSchema)
-	if _, err := tx.ExecContext(ctx, query); err != nil {
+	// Using PostgreSQL identifier quoting for the schema name. Connections from the
+	// tenant's own pool already have it.
+	if !pooled {
+		schemaName := m.schemaManager.GetSchemaName(tenantID)
+		quotedSchema := fmt.Sprintf(`"%s"`, schemaName)
+		query := fmt.Sprintf("SET LOCAL search_path TO %s, public", quotedSchema)
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to set search path: %w", err)
+		}
+	}
+	if err := m.scopeSession(ctx, tx, tenantID, true); err != nil {
 		tx.Rollback()
-		return fmt.Errorf("failed to set search path: %w", err)
+		return err
+	}
+
+	if m.config.Database.VerifyTenantScope {
+		if err := m.AssertTenantScope(ctx, tx, tenantID); err != nil {
+			tx.Rollback()
+			return err
+		}
 	}
 
 	// Execute the user function
@@ -320,9 +774,50 @@ func (m *manager) WithTenantTx(ctx context.Context, tenantID uuid.UUID, fn func(
 	return nil
 }
 
-// WithTenantContext adds tenant information to the context
+// AssertTenantScope checks that the connection's search_path starts with the
+// tenant's schema, so unqualified queries on it resolve to the tenant's tables
+func (m *manager) AssertTenantScope(ctx context.Context, conn RowQuerier, tenantID uuid.UUID) error {
+	var searchPath string
+	if err := conn.QueryRowContext(ctx, "SHOW search_path").Scan(&searchPath); err != nil {
+		return fmt.Errorf("failed to read search_path: %w", err)
+	}
+
+	schemaName := m.schemaManager.GetSchemaName(tenantID)
+	first := strings.TrimSpace(strings.SplitN(searchPath, ",", 2)[0])
+	if len(first) >= 2 && strings.HasPrefix(first, `"`) && strings.HasSuffix(first, `"`) {
+		first = strings.ReplaceAll(first[1:len(first)-1], `""`, `"`)
+	}
+
+	if first != schemaName {
+		m.logger.Error("Connection is not scoped to tenant schema",
+			zap.String("tenant_id", tenantID.String()),
+			zap.String("schema", schemaName),
+			zap.String("search_path", searchPath))
+		return fmt.Errorf("%w: search_path is %q, want %s first", ErrTenantScopeMismatch, searchPath, schemaName)
+	}
+
+	return nil
+}
+
+// TenantPoolStats returns usage statistics for the tenant's dedicated connection pool
+func (m *manager) TenantPoolStats(tenantID uuid.UUID) (*PoolStats, error) {
+	stats, ok := m.connections.stats(tenantID)
+	if !ok {
+		return nil, &TenantError{
+			TenantID: tenantID,
+			Code:     "NO_TENANT_POOL",
+			Message:  fmt.Sprintf("no dedicated connection pool for tenant %s", tenantID),
+		}
+	}
+
+	return stats, nil
+}
+
+// WithTenantContext adds tenant information to the context under the shared
+// keys read by GetTenantFromContext and, with Config.ContextNamespace set,
+// under keys only this manager's TenantFromContext reads
 func (m *manager) WithTenantContext(ctx context.Context, tenantID uuid.UUID) context.Context {
-	tenant, err := m.repository.GetByID(ctx, tenantID)
+	tenant, err := m.cachedTenant(ctx, tenantID)
 	if err != nil {
 		m.logger.Error("Failed to get tenant for context",
 			zap.String("tenant_id", tenantID.String()),
@@ -330,6 +825,11 @@ func (m *manager) WithTenantContext(ctx context.Context, tenantID uuid.UUID) con
 		return ctx
 	}
 
+	return m.tenantContext(ctx, tenant)
+}
+
+// tenantContext implements WithTenantContext for a tenant already loaded
+func (m *manager) tenantContext(ctx context.Context, tenant *Tenant) context.Context {
 	tenantCtx := &Context{
 		TenantID:   tenant.ID,
 		Subdomain:  tenant.Subdomain,
@@ -338,24 +838,84 @@ func (m *manager) WithTenantContext(ctx context.Context, tenantID uuid.UUID) con
 		Status:     tenant.Status,
 	}
 
-	ctx = context.WithValue(ctx, ContextKeyTenant, tenantCtx)
-	ctx = context.WithValue(ctx, ContextKeyTenantID, tenantID)
+	ctx = context.WithValue(ctx, contextKeyTenant, tenantCtx)
+	ctx = context.WithValue(ctx, contextKeyTenantID, tenant.ID)
+
+	// Also store the tenant under this manager's own keys, so that a second
+	// manager wrapping the context does not hide it
+	if m.config.ContextNamespace != "" {
+		ctx = context.WithValue(ctx, m.contextKey(contextKeyTenant), tenantCtx)
+		ctx = context.WithValue(ctx, m.contextKey(contextKeyTenantID), tenant.ID)
+	}
 
 	return ctx
 }
 
 // Close closes all resources
 func (m *manager) Close() error {
+	// Stop background usage reconciliation
+	if m.reconcileStop != nil {
+		m.closeOnce.Do(func() { close(m.reconcileStop) })
+		<-m.reconcileDone
+	}
+
+	// Stop asynchronous provisioning before closing the connections it uses
+	var err error
+	if queue := m.provisioningQueue(); queue != nil {
+		err = queue.Close()
+	}
+
 	// Close any tenant-specific connections
-	for tenantID, conn := range m.connections {
-		if err := conn.Close(); err != nil {
-			m.logger.Error("Failed to close tenant connection",
-				zap.String("tenant_id", tenantID.String()),
-				zap.Error(err))
+	m.connections.close()
+
+	return err
+}
+
+// cachedTenant returns the tenant from the resolution cache, loading and
+// caching it from the repository on a miss or after the entry expires.
+// Concurrent misses for the same tenant share a single repository lookup.
+func (m *manager) cachedTenant(ctx context.Context, id uuid.UUID) (*Tenant, error) {
+	if tenant, ok := m.tenants.get(id); ok {
+		return tenant, nil
+	}
+
+	value, err := m.inits.do("tenant", id, func() (interface{}, error) {
+		gen := m.tenants.generation()
+		tenant, err := m.repository.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
 		}
+
+		m.tenants.put(tenant, gen)
+		return tenant, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	// Callers sharing the lookup each get their own copy
+	tenant := *value.(*Tenant)
+	return &tenant, nil
+}
+
+// subdomainExists reports whether any tenant, including a cancelled one, uses
+// subdomain, without loading the row when the repository supports it
+func (m *manager) subdomainExists(ctx context.Context, subdomain string) (bool, error) {
+	if repo, ok := m.repository.(ExistenceRepository); ok {
+		exists, err := repo.ExistsBySubdomain(ctx, subdomain)
+		if err != nil {
+			return false, fmt.Errorf("failed to check subdomain: %w", err)
+		}
+		return exists, nil
+	}
+
+	if _, err := m.repository.GetBySubdomain(ctx, subdomain); err != nil {
+		if IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check subdomain: %w", err)
+	}
+	return true, nil
 }
 
 // validateTenant validates tenant data
@@ -390,7 +950,6 @@ func (m *manager) validateSubdomain(subdomain string) error {
 	}
 
 	// Check for valid characters (alphanumeric and hyphens only)
-	validSubdomain := regexp.MustCompile(`^[a-z0-9][a-z0-9-]*[a-z0-9]$`)
 	if !validSubdomain.MatchString(subdomain) {
 		return fmt.Errorf("subdomain must contain only lowercase letters, numbers, and hyphens, and cannot start or end with a hyphen")
 	}
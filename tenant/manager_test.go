@@ -3,6 +3,8 @@ package tenant
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -159,6 +161,56 @@ func TestManager_CreateTenant_PreservesID(t *testing.T) {
 	}
 }
 
+func TestManager_CreateTenant_MaxTenants(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	config := DefaultConfig()
+	config.MaxTenants = 2
+
+	mockRepo := NewMockRepository()
+	manager := NewManager(config, (*sql.DB)(nil), mockRepo, NewMockSchemaManager(""), NewMockMigrationManager(), NewMockLimitChecker(config.Limits), logger)
+	ctx := context.Background()
+
+	create := func(subdomain string) (*Tenant, error) {
+		tenant := &Tenant{Name: "Tenant " + subdomain, Subdomain: subdomain, PlanType: PlanBasic}
+		return tenant, manager.CreateTenant(ctx, tenant)
+	}
+
+	first, err := create("first")
+	if err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+	if _, err := create("second"); err != nil {
+		t.Fatalf("CreateTenant() at the cap error = %v", err)
+	}
+
+	_, err = create("third")
+	var quotaErr *TenantQuotaExceededError
+	if !errors.As(err, &quotaErr) || quotaErr.MaxTenants != 2 || quotaErr.Current != 2 {
+		t.Fatalf("CreateTenant() past the cap error = %v, want TenantQuotaExceededError", err)
+	}
+	if len(mockRepo.tenants) != 2 {
+		t.Errorf("stored tenants = %d, want 2", len(mockRepo.tenants))
+	}
+
+	// Cancelled tenants free their slot
+	if err := manager.DeleteTenant(ctx, first.ID); err != nil {
+		t.Fatalf("DeleteTenant() error = %v", err)
+	}
+	if _, err := create("third"); err != nil {
+		t.Errorf("CreateTenant() after a delete error = %v", err)
+	}
+
+	// 0 and -1 are unlimited
+	for max, subdomain := range map[int]string{0: "unlimited", -1: "negative"} {
+		config.MaxTenants = max
+		unlimited := NewManager(config, (*sql.DB)(nil), mockRepo, NewMockSchemaManager(""), NewMockMigrationManager(), NewMockLimitChecker(config.Limits), logger)
+		tenant := &Tenant{Name: "Unlimited", Subdomain: subdomain, PlanType: PlanBasic}
+		if err := unlimited.CreateTenant(ctx, tenant); err != nil {
+			t.Errorf("CreateTenant() with MaxTenants %d error = %v", max, err)
+		}
+	}
+}
+
 func TestManager_GetTenant(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	config := DefaultConfig()
@@ -279,6 +331,19 @@ func TestManager_UpdateTenant(t *testing.T) {
 	if err == nil {
 		t.Error("UpdateTenant() should return error for invalid tenant")
 	}
+
+	// Plan changes go through ChangePlan
+	changed := *tenant
+	changed.Name = "Updated Tenant"
+	changed.PlanType = PlanPro
+	err = manager.UpdateTenant(context.Background(), &changed)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "plan_type" {
+		t.Errorf("UpdateTenant() changing the plan error = %v, want a plan_type ValidationError", err)
+	}
+	if mockRepo.tenants[tenantID].PlanType != PlanBasic {
+		t.Errorf("plan = %s after a rejected update, want %s", mockRepo.tenants[tenantID].PlanType, PlanBasic)
+	}
 }
 
 func TestManager_DeleteTenant(t *testing.T) {
@@ -412,6 +477,151 @@ func TestManager_ProvisionTenant(t *testing.T) {
 	}
 }
 
+func TestManager_ProvisionTenant_Cancelled(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	config := DefaultConfig()
+
+	mockRepo := NewMockRepository()
+	mockSchema := NewMockSchemaManager(config.Database.SchemaPrefix)
+	manager := NewManager(config, (*sql.DB)(nil), mockRepo, mockSchema, NewMockMigrationManager(), NewMockLimitChecker(config.Limits), logger)
+
+	for _, status := range []string{StatusCancelled, StatusSuspended} {
+		tenantID := uuid.New()
+		mockRepo.tenants[tenantID] = &Tenant{
+			ID:        tenantID,
+			Name:      "Test Tenant",
+			Subdomain: "test-" + status,
+			PlanType:  PlanBasic,
+			Status:    status,
+		}
+
+		err := manager.ProvisionTenant(context.Background(), tenantID)
+		var statusErr *ProvisionStatusError
+		if !errors.As(err, &statusErr) || statusErr.Status != status || statusErr.TenantID != tenantID {
+			t.Errorf("ProvisionTenant(%s) error = %v, want ProvisionStatusError", status, err)
+		}
+		if mockSchema.schemas[tenantID] {
+			t.Errorf("ProvisionTenant(%s) should not create a schema", status)
+		}
+	}
+
+	// Forcing provisions a cancelled tenant anyway
+	tenantID := uuid.New()
+	mockRepo.tenants[tenantID] = &Tenant{ID: tenantID, Name: "Forced", Subdomain: "forced", PlanType: PlanBasic, Status: StatusCancelled}
+	if err := manager.ProvisionTenant(WithForceProvision(context.Background()), tenantID); err != nil {
+		t.Fatalf("ProvisionTenant() with WithForceProvision error = %v", err)
+	}
+	if !mockSchema.schemas[tenantID] {
+		t.Error("forced ProvisionTenant() should create the schema")
+	}
+}
+
+// migratingSchemaManager applies baseline migrations as part of creating a schema
+type migratingSchemaManager struct {
+	*MockManagerSchemaManager
+	migrations *MockManagerMigrationManager
+	baseline   []*Migration
+}
+
+func (sm *migratingSchemaManager) CreateTenantSchema(ctx context.Context, tenantID uuid.UUID, name string) error {
+	if err := sm.MockManagerSchemaManager.CreateTenantSchema(ctx, tenantID, name); err != nil {
+		return err
+	}
+	for _, migration := range sm.baseline {
+		if err := sm.migrations.ApplyMigration(ctx, tenantID, migration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestManager_ProvisionTenantDetailed(t *testing.T) {
+	config := DefaultConfig()
+	mockRepo := NewMockRepository()
+	mockMigration := NewMockMigrationManager()
+	schema := &migratingSchemaManager{
+		MockManagerSchemaManager: NewMockSchemaManager(config.Database.SchemaPrefix),
+		migrations:               mockMigration,
+		baseline:                 []*Migration{{Version: "001", Name: "init"}, {Version: "002", Name: "settings"}},
+	}
+
+	manager := NewManager(config, (*sql.DB)(nil), mockRepo, schema, mockMigration, NewMockLimitChecker(config.Limits), zaptest.NewLogger(t))
+	ctx := context.Background()
+
+	tenantID := uuid.New()
+	mockRepo.tenants[tenantID] = &Tenant{ID: tenantID, Name: "Acme", Subdomain: "acme", PlanType: PlanBasic, Status: StatusPending}
+
+	result, err := manager.ProvisionTenantDetailed(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("ProvisionTenantDetailed() error = %v", err)
+	}
+	if !result.SchemaCreated || result.MigrationsApplied != 2 || result.TenantID != tenantID {
+		t.Errorf("result = %+v, want a created schema with 2 migrations", result)
+	}
+	if result.SchemaName != schema.GetSchemaName(tenantID) || result.Duration <= 0 {
+		t.Errorf("result = %+v, want the schema name and a duration", result)
+	}
+
+	// Provisioning again finds the schema and does nothing
+	result, err = manager.ProvisionTenantDetailed(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("ProvisionTenantDetailed() error = %v for a provisioned tenant", err)
+	}
+	if result.SchemaCreated || result.MigrationsApplied != 0 {
+		t.Errorf("result = %+v, want the existing schema reported with no migrations", result)
+	}
+
+	// Errors come without a result, as from ProvisionTenant
+	cancelled := uuid.New()
+	mockRepo.tenants[cancelled] = &Tenant{ID: cancelled, Name: "Gone", Subdomain: "gone", PlanType: PlanBasic, Status: StatusCancelled}
+	if result, err := manager.ProvisionTenantDetailed(ctx, cancelled); err == nil || result != nil {
+		t.Errorf("ProvisionTenantDetailed() = %+v, %v; want a ProvisionStatusError", result, err)
+	}
+}
+
+func TestManager_RestoreTenant(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	config := DefaultConfig()
+
+	mockRepo := NewMockRepository()
+	mockSchema := NewMockSchemaManager(config.Database.SchemaPrefix)
+	manager := NewManager(config, (*sql.DB)(nil), mockRepo, mockSchema, NewMockMigrationManager(), NewMockLimitChecker(config.Limits), logger)
+	ctx := context.Background()
+
+	// Without a schema the restored tenant is pending and can be provisioned
+	tenantID := uuid.New()
+	tenant := &Tenant{ID: tenantID, Name: "Test Tenant", Subdomain: "test-tenant", PlanType: PlanBasic, Status: StatusCancelled}
+	mockRepo.tenants[tenantID] = tenant
+
+	if err := manager.RestoreTenant(ctx, tenantID); err != nil {
+		t.Fatalf("RestoreTenant() error = %v", err)
+	}
+	if tenant.Status != StatusPending {
+		t.Errorf("status after RestoreTenant() = %s, want %s", tenant.Status, StatusPending)
+	}
+	if err := manager.ProvisionTenant(ctx, tenantID); err != nil {
+		t.Fatalf("ProvisionTenant() after RestoreTenant() error = %v", err)
+	}
+	if tenant.Status != StatusActive {
+		t.Errorf("status after provisioning = %s, want %s", tenant.Status, StatusActive)
+	}
+
+	// With the schema still in place it comes back active
+	tenant.Status = StatusCancelled
+	if err := manager.RestoreTenant(ctx, tenantID); err != nil {
+		t.Fatalf("RestoreTenant() error = %v", err)
+	}
+	if tenant.Status != StatusActive {
+		t.Errorf("status after RestoreTenant() = %s, want %s", tenant.Status, StatusActive)
+	}
+
+	// Tenants that are not cancelled are left alone
+	tenant.Status = StatusSuspended
+	if err := manager.RestoreTenant(ctx, tenantID); err != nil || tenant.Status != StatusSuspended {
+		t.Errorf("RestoreTenant() of a suspended tenant = %v, status %s; want no change", err, tenant.Status)
+	}
+}
+
 func TestManager_SuspendTenant(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	config := DefaultConfig()
@@ -601,6 +811,78 @@ func TestManager_GetStats(t *testing.T) {
 	}
 }
 
+func TestManager_GetLimitSchema(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	config := DefaultConfig()
+
+	mockRepo := NewMockRepository()
+	mockSchema := NewMockSchemaManager(config.Database.SchemaPrefix)
+	mockMigration := NewMockMigrationManager()
+	mockLimits := NewMockLimitChecker(config.Limits)
+
+	manager := NewManager(config, (*sql.DB)(nil), mockRepo, mockSchema, mockMigration, mockLimits, logger)
+
+	descriptions := manager.GetLimitSchema()
+	if len(descriptions) != len(config.Limits.LimitSchema.Definitions) {
+		t.Errorf("GetLimitSchema() returned %d descriptions, want %d", len(descriptions), len(config.Limits.LimitSchema.Definitions))
+	}
+}
+
+func TestManager_GetAppliedMigrations(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	config := DefaultConfig()
+
+	mockRepo := NewMockRepository()
+	mockSchema := NewMockSchemaManager(config.Database.SchemaPrefix)
+	mockMigration := NewMockMigrationManager()
+	mockLimits := NewMockLimitChecker(config.Limits)
+
+	manager := NewManager(config, (*sql.DB)(nil), mockRepo, mockSchema, mockMigration, mockLimits, logger)
+
+	tenantID := uuid.New()
+	ctx := context.Background()
+
+	// No migrations applied yet
+	migrations, err := manager.GetAppliedMigrations(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("GetAppliedMigrations() error = %v", err)
+	}
+	if len(migrations) != 0 {
+		t.Errorf("GetAppliedMigrations() returned %d migrations, want 0", len(migrations))
+	}
+
+	for _, version := range []string{"001", "002"} {
+		if err := mockMigration.ApplyMigration(ctx, tenantID, &Migration{ID: uuid.New(), Version: version, Name: "migration_" + version}); err != nil {
+			t.Fatalf("ApplyMigration() error = %v", err)
+		}
+	}
+
+	migrations, err = manager.GetAppliedMigrations(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("GetAppliedMigrations() error = %v", err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("GetAppliedMigrations() returned %d migrations, want 2", len(migrations))
+	}
+
+	versions := map[string]bool{}
+	for _, migration := range migrations {
+		versions[migration.Version] = true
+	}
+	if !versions["001"] || !versions["002"] {
+		t.Errorf("GetAppliedMigrations() versions = %v, want 001 and 002", versions)
+	}
+
+	// Other tenants are unaffected
+	migrations, err = manager.GetAppliedMigrations(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("GetAppliedMigrations() error = %v", err)
+	}
+	if len(migrations) != 0 {
+		t.Errorf("GetAppliedMigrations() for other tenant returned %d migrations, want 0", len(migrations))
+	}
+}
+
 func TestManager_WithTenantContext(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	config := DefaultConfig()
@@ -649,6 +931,180 @@ func TestManager_WithTenantContext(t *testing.T) {
 	}
 }
 
+func TestManager_WithTenantContext_Namespaced(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	newNamespacedManager := func(namespace string, tenant *Tenant) Manager {
+		config := DefaultConfig()
+		config.ContextNamespace = namespace
+		repo := NewMockRepository()
+		repo.tenants[tenant.ID] = tenant
+		return NewManager(config, (*sql.DB)(nil), repo, NewMockSchemaManager(""), NewMockMigrationManager(), NewMockLimitChecker(config.Limits), logger)
+	}
+
+	acme := &Tenant{ID: uuid.New(), Name: "Acme", Subdomain: "acme", PlanType: PlanBasic, Status: StatusActive}
+	globex := &Tenant{ID: uuid.New(), Name: "Globex", Subdomain: "globex", PlanType: PlanPro, Status: StatusActive}
+	billing := newNamespacedManager("billing", acme)
+	analytics := newNamespacedManager("analytics", globex)
+
+	// Both managers store their tenant on the same base context
+	ctx := billing.WithTenantContext(context.Background(), acme.ID)
+	ctx = analytics.WithTenantContext(ctx, globex.ID)
+
+	if tenantCtx, ok := billing.TenantFromContext(ctx); !ok || tenantCtx.TenantID != acme.ID {
+		t.Errorf("billing TenantFromContext() = %+v, %v; want acme", tenantCtx, ok)
+	}
+	if id, ok := billing.TenantIDFromContext(ctx); !ok || id != acme.ID {
+		t.Errorf("billing TenantIDFromContext() = %s, %v; want acme", id, ok)
+	}
+	if tenantCtx, ok := analytics.TenantFromContext(ctx); !ok || tenantCtx.TenantID != globex.ID {
+		t.Errorf("analytics TenantFromContext() = %+v, %v; want globex", tenantCtx, ok)
+	}
+
+	// The shared keys hold the innermost tenant, as before
+	if id, ok := GetTenantIDFromContext(ctx); !ok || id != globex.ID {
+		t.Errorf("GetTenantIDFromContext() = %s, %v; want globex", id, ok)
+	}
+
+	// A manager without a namespace reads the shared keys
+	shared := newNamespacedManager("", acme)
+	if id, ok := shared.TenantIDFromContext(ctx); !ok || id != globex.ID {
+		t.Errorf("shared TenantIDFromContext() = %s, %v; want globex", id, ok)
+	}
+	if _, ok := billing.TenantFromContext(shared.WithTenantContext(context.Background(), acme.ID)); ok {
+		t.Error("billing TenantFromContext() should not see a tenant stored by another manager")
+	}
+
+	// Keys of the same name from another package do not collide
+	ctx = context.WithValue(context.Background(), "tenant_id", "not a tenant")
+	if _, ok := GetTenantIDFromContext(ctx); ok {
+		t.Error("GetTenantIDFromContext() should not read a plain string key")
+	}
+}
+
+func TestManager_GetTenantDB_Disabled(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	config := DefaultConfig()
+	config.Database.DisableUnsafeTenantDB = true
+
+	mockRepo := NewMockRepository()
+	mockSchema := NewMockSchemaManager(config.Database.SchemaPrefix)
+	mockMigration := NewMockMigrationManager()
+	mockLimits := NewMockLimitChecker(config.Limits)
+
+	manager := NewManager(config, (*sql.DB)(nil), mockRepo, mockSchema, mockMigration, mockLimits, logger)
+
+	db, err := manager.GetTenantDB(context.Background(), uuid.New())
+	if !errors.Is(err, ErrUnsafeTenantDBDisabled) {
+		t.Errorf("GetTenantDB() error = %v, want ErrUnsafeTenantDBDisabled", err)
+	}
+	if db != nil {
+		t.Error("GetTenantDB() should not return a database when disabled")
+	}
+	if !strings.Contains(err.Error(), "GetTenantConn") || !strings.Contains(err.Error(), "WithTenantTx") {
+		t.Errorf("GetTenantDB() error = %q, want it to point to GetTenantConn and WithTenantTx", err)
+	}
+}
+
+func TestManager_AssertTenantScope(t *testing.T) {
+	m := newExecTestManager(t, &execRecorder{})
+	ctx := context.Background()
+	tenantID := uuid.New()
+	otherID := uuid.New()
+
+	// Correctly scoped connection
+	conn, err := m.GetTenantConn(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("GetTenantConn() error = %v", err)
+	}
+	defer conn.Close()
+
+	if err := m.AssertTenantScope(ctx, conn, tenantID); err != nil {
+		t.Errorf("AssertTenantScope() error = %v for a correctly scoped connection", err)
+	}
+
+	// The same connection checked against another tenant
+	if err := m.AssertTenantScope(ctx, conn, otherID); !errors.Is(err, ErrTenantScopeMismatch) {
+		t.Errorf("AssertTenantScope() error = %v, want ErrTenantScopeMismatch for another tenant", err)
+	}
+
+	// A pooled connection with the default search_path
+	raw, err := m.db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn() error = %v", err)
+	}
+	defer raw.Close()
+
+	if err := m.AssertTenantScope(ctx, raw, tenantID); !errors.Is(err, ErrTenantScopeMismatch) {
+		t.Errorf("AssertTenantScope() error = %v, want ErrTenantScopeMismatch for an unscoped connection", err)
+	}
+
+	// Transactions are checked the same way
+	err = m.WithTenantTx(ctx, tenantID, func(tx *sql.Tx) error {
+		return m.AssertTenantScope(ctx, tx, tenantID)
+	})
+	if err != nil {
+		t.Errorf("AssertTenantScope() in WithTenantTx error = %v", err)
+	}
+}
+
+func TestManager_VerifyTenantScope(t *testing.T) {
+	m := newExecTestManager(t, &execRecorder{})
+	m.config.Database.VerifyTenantScope = true
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	ran := false
+	if err := m.WithTenantTx(ctx, tenantID, func(tx *sql.Tx) error {
+		ran = true
+		return nil
+	}); err != nil {
+		t.Fatalf("WithTenantTx() error = %v", err)
+	}
+	if !ran {
+		t.Error("WithTenantTx() should run fn once the scope is verified")
+	}
+
+	conn, err := m.GetTenantConn(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("GetTenantConn() error = %v", err)
+	}
+	conn.Close()
+}
+
+func TestManager_IsSubdomainAvailable(t *testing.T) {
+	config := DefaultConfig()
+	repo := NewMockRepository()
+	m := NewManager(config, nil, repo, NewMockSchemaManager(config.Database.SchemaPrefix),
+		NewMockMigrationManager(), NewMockLimitChecker(config.Limits), zaptest.NewLogger(t))
+	ctx := context.Background()
+
+	if err := m.CreateTenant(ctx, &Tenant{Name: "Acme Corp", Subdomain: "acme"}); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+
+	if available, err := m.IsSubdomainAvailable(ctx, "acme"); err != nil || available {
+		t.Errorf("IsSubdomainAvailable(acme) = %v, %v, want false for a taken subdomain", available, err)
+	}
+	if available, err := m.IsSubdomainAvailable(ctx, "globex"); err != nil || !available {
+		t.Errorf("IsSubdomainAvailable(globex) = %v, %v, want true", available, err)
+	}
+
+	for _, subdomain := range []string{"www", "-bad", "ab"} {
+		available, err := m.IsSubdomainAvailable(ctx, subdomain)
+		var validationErr *ValidationError
+		if available || !errors.As(err, &validationErr) {
+			t.Errorf("IsSubdomainAvailable(%q) = %v, %v, want a validation error", subdomain, available, err)
+		}
+	}
+
+	// Creating a tenant on a taken subdomain is a validation error
+	err := m.CreateTenant(ctx, &Tenant{Name: "Acme Again", Subdomain: "acme"})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "subdomain" {
+		t.Errorf("CreateTenant() error = %v, want a subdomain ValidationError", err)
+	}
+}
+
 func TestManager_Close(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	config := DefaultConfig()
@@ -669,6 +1125,79 @@ func TestManager_Close(t *testing.T) {
 
 // Helper mock implementations for manager tests
 
+// testManagerSetup holds what newTestManager builds a manager from
+type testManagerSetup struct {
+	config      Config
+	db          *sql.DB
+	repo        Repository
+	schemas     SchemaManager
+	checker     LimitChecker
+	realChecker bool
+}
+
+// testManagerOption replaces part of the mocks newTestManager uses
+type testManagerOption func(*testManagerSetup)
+
+// withConfig adjusts the DefaultConfig the manager is built with
+func withConfig(adjust func(config *Config)) testManagerOption {
+	return func(s *testManagerSetup) { adjust(&s.config) }
+}
+
+// withDB gives the manager db instead of no database
+func withDB(db *sql.DB) testManagerOption {
+	return func(s *testManagerSetup) { s.db = db }
+}
+
+// withRepository replaces the mock repository
+func withRepository(repo Repository) testManagerOption {
+	return func(s *testManagerSetup) { s.repo = repo }
+}
+
+// withSchemaManager replaces the mock schema manager
+func withSchemaManager(schemas SchemaManager) testManagerOption {
+	return func(s *testManagerSetup) { s.schemas = schemas }
+}
+
+// withLimitChecker replaces the mock limit checker
+func withLimitChecker(checker LimitChecker) testManagerOption {
+	return func(s *testManagerSetup) { s.checker = checker }
+}
+
+// withRealLimitChecker uses NewLimitChecker over the manager's repository
+// instead of the mock limit checker
+func withRealLimitChecker() testManagerOption {
+	return func(s *testManagerSetup) { s.realChecker = true }
+}
+
+// newTestManager returns a manager over DefaultConfig, NewMockRepository,
+// NewMockSchemaManager, NewMockMigrationManager and NewMockLimitChecker, as
+// replaced by opts. It is closed when the test ends.
+func newTestManager(t *testing.T, opts ...testManagerOption) *manager {
+	t.Helper()
+	logger := zaptest.NewLogger(t)
+
+	setup := testManagerSetup{config: DefaultConfig()}
+	for _, opt := range opts {
+		opt(&setup)
+	}
+	if setup.repo == nil {
+		setup.repo = NewMockRepository()
+	}
+	if setup.schemas == nil {
+		setup.schemas = NewMockSchemaManager(setup.config.Database.SchemaPrefix)
+	}
+	if setup.realChecker {
+		setup.checker = NewLimitChecker(setup.config.Limits, setup.repo, logger)
+	}
+	if setup.checker == nil {
+		setup.checker = NewMockLimitChecker(setup.config.Limits)
+	}
+
+	m := NewManager(setup.config, setup.db, setup.repo, setup.schemas, NewMockMigrationManager(), setup.checker, logger).(*manager)
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
 // NewMockRepository creates a mock repository for testing
 func NewMockRepository() *MockManagerRepository {
 	return &MockManagerRepository{
@@ -713,7 +1242,21 @@ func (m *MockManagerRepository) GetBySubdomain(ctx context.Context, subdomain st
 			return t, nil
 		}
 	}
-	return nil, &TenantError{Code: "NOT_FOUND", Message: "tenant not found"}
+	return nil, ErrTenantNotFound
+}
+
+func (m *MockManagerRepository) ExistsByID(ctx context.Context, id uuid.UUID) (bool, error) {
+	_, exists := m.tenants[id]
+	return exists, nil
+}
+
+func (m *MockManagerRepository) ExistsBySubdomain(ctx context.Context, subdomain string) (bool, error) {
+	for _, t := range m.tenants {
+		if t.Subdomain == subdomain {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 func (m *MockManagerRepository) Update(ctx context.Context, t *Tenant) error {
@@ -745,6 +1288,14 @@ func (m *MockManagerRepository) Delete(ctx context.Context, id uuid.UUID) error
 	return nil
 }
 
+func (m *MockManagerRepository) Purge(ctx context.Context, id uuid.UUID) error {
+	if t, exists := m.tenants[id]; !exists || t.Status != StatusCancelled {
+		return &TenantError{TenantID: id, Code: "NOT_FOUND", Message: "tenant not found"}
+	}
+	delete(m.tenants, id)
+	return nil
+}
+
 func (m *MockManagerRepository) List(ctx context.Context, page, perPage int) ([]*Tenant, int, error) {
 	var activeTenants []*Tenant
 	for _, t := range m.tenants {
@@ -753,6 +1304,7 @@ func (m *MockManagerRepository) List(ctx context.Context, page, perPage int) ([]
 		}
 	}
 
+	page, perPage = NormalizePagination(page, perPage)
 	total := len(activeTenants)
 	start := (page - 1) * perPage
 	end := start + perPage
@@ -932,6 +1484,10 @@ func (m *MockManagerLimitChecker) CheckAllLimits(ctx context.Context, tenantID u
 	return nil
 }
 
+func (m *MockManagerLimitChecker) CheckLimitAndConsume(ctx context.Context, tenantID uuid.UUID, limitName string, amount int) (int, error) {
+	return -1, nil
+}
+
 func (m *MockManagerLimitChecker) GetLimitSchema() *LimitSchema {
 	return m.config.LimitSchema
 }
@@ -940,12 +1496,23 @@ func (m *MockManagerLimitChecker) SetLimitSchema(schema *LimitSchema) {
 	m.config.LimitSchema = schema
 }
 
+func (m *MockManagerLimitChecker) AddDefinition(def *LimitDefinition) error {
+	m.config.LimitSchema.AddDefinition(def)
+	return nil
+}
+
+func (m *MockManagerLimitChecker) RemoveDefinition(name string) error {
+	m.config.LimitSchema.RemoveDefinition(name)
+	return nil
+}
+
 func (m *MockManagerLimitChecker) GetLimitsForPlan(planType string) FlexibleLimits {
 	return m.planLimits[planType]
 }
 
-func (m *MockManagerLimitChecker) SetLimitsForPlan(planType string, limits FlexibleLimits) {
+func (m *MockManagerLimitChecker) SetLimitsForPlan(planType string, limits FlexibleLimits) error {
 	m.planLimits[planType] = limits
+	return nil
 }
 
 func (m *MockManagerLimitChecker) AddLimit(planType, limitName string, limitType LimitType, value interface{}) error {
@@ -975,6 +1542,30 @@ func (m *MockManagerLimitChecker) ValidateLimits(planType string, limits Flexibl
 	return nil
 }
 
+func (m *MockManagerLimitChecker) SetEventPublisher(publisher EventPublisher) {
+	// Mock implementation
+}
+
+func (m *MockManagerLimitChecker) SetDenialSink(sink DenialSink) {
+	// Mock implementation
+}
+
+func (m *MockManagerLimitChecker) SetMetricsCollector(collector MetricsCollector) {
+	// Mock implementation
+}
+
+func (m *MockManagerLimitChecker) SetOverrideProvider(provider OverrideProvider) {
+	// Mock implementation
+}
+
+func (m *MockManagerLimitChecker) SetTenantLimitOverride(ctx context.Context, tenantID uuid.UUID, limitName string, value *LimitValue) error {
+	return nil
+}
+
+func (m *MockManagerLimitChecker) GetLimitsForTenant(ctx context.Context, tenantID uuid.UUID) (FlexibleLimits, error) {
+	return nil, nil
+}
+
 func (m *MockManagerLimitChecker) SetUsageTracker(tracker UsageTracker) {
 	// Mock implementation
 }
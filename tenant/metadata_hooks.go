@@ -0,0 +1,75 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// MetadataHook is called with each metadata value before it is stored. It
+// returns the value to store, which lets it normalize values such as
+// lowercasing a domain, or an error to reject the write.
+type MetadataHook func(ctx context.Context, tenantID uuid.UUID, key string, value interface{}) (interface{}, error)
+
+// MetadataValidator returns a MetadataHook that rejects values validate
+// returns an error for and stores the others unchanged
+func MetadataValidator(validate func(key string, value interface{}) error) MetadataHook {
+	return func(ctx context.Context, tenantID uuid.UUID, key string, value interface{}) (interface{}, error) {
+		if err := validate(key, value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+}
+
+// InvalidMetadataError is returned when a MetadataHook rejects a value
+type InvalidMetadataError struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	Key      string    `json:"key"`
+	Err      error     `json:"-"`
+}
+
+// Error implements the error interface
+func (e *InvalidMetadataError) Error() string {
+	return fmt.Sprintf("invalid metadata %s: %v", e.Key, e.Err)
+}
+
+// Unwrap returns the hook's error
+func (e *InvalidMetadataError) Unwrap() error {
+	return e.Err
+}
+
+// MetadataHooks is a chain of hooks run in order, each receiving the value
+// returned by the one before
+type MetadataHooks []MetadataHook
+
+// ApplyField runs the hooks on one value and returns the value to store
+func (h MetadataHooks) ApplyField(ctx context.Context, tenantID uuid.UUID, key string, value interface{}) (interface{}, error) {
+	for _, hook := range h {
+		var err error
+		value, err = hook(ctx, tenantID, key, value)
+		if err != nil {
+			return nil, &InvalidMetadataError{TenantID: tenantID, Key: key, Err: err}
+		}
+	}
+	return value, nil
+}
+
+// Apply runs the hooks on every value in metadata and returns the metadata to
+// store. metadata itself is not modified.
+func (h MetadataHooks) Apply(ctx context.Context, tenantID uuid.UUID, metadata TenantMetadata) (TenantMetadata, error) {
+	if len(h) == 0 {
+		return metadata, nil
+	}
+
+	result := make(TenantMetadata, len(metadata))
+	for key, value := range metadata {
+		stored, err := h.ApplyField(ctx, tenantID, key, value)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = stored
+	}
+	return result, nil
+}
@@ -0,0 +1,59 @@
+package tenant
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// MetadataLimits caps the size of a tenant's metadata, keeping the tenants
+// table and its metadata index small. A zero field leaves that cap off.
+type MetadataLimits struct {
+	MaxBytes int `json:"max_bytes"` // serialized JSON size
+	MaxKeys  int `json:"max_keys"`  // top-level keys
+}
+
+// DefaultMetadataLimits are generous enough for integration IDs, settings and
+// plan defaults while stopping metadata from being used as bulk storage
+var DefaultMetadataLimits = MetadataLimits{MaxBytes: 64 * 1024, MaxKeys: 256}
+
+// MetadataTooLargeError is returned when a write would take a tenant's
+// metadata past its MetadataLimits
+type MetadataTooLargeError struct {
+	TenantID uuid.UUID      `json:"tenant_id"`
+	Bytes    int            `json:"bytes"`
+	Keys     int            `json:"keys"`
+	Limits   MetadataLimits `json:"limits"`
+}
+
+// Error implements the error interface
+func (e *MetadataTooLargeError) Error() string {
+	if e.Limits.MaxKeys > 0 && e.Keys > e.Limits.MaxKeys {
+		return fmt.Sprintf("tenant %s metadata has %d keys, more than the limit of %d", e.TenantID, e.Keys, e.Limits.MaxKeys)
+	}
+	return fmt.Sprintf("tenant %s metadata is %d bytes, more than the limit of %d", e.TenantID, e.Bytes, e.Limits.MaxBytes)
+}
+
+// Check returns a MetadataTooLargeError if metadata exceeds the limits
+func (l MetadataLimits) Check(tenantID uuid.UUID, metadata TenantMetadata) error {
+	if l.MaxBytes <= 0 && l.MaxKeys <= 0 {
+		return nil
+	}
+
+	tooLarge := &MetadataTooLargeError{TenantID: tenantID, Keys: len(metadata), Limits: l}
+	if l.MaxKeys > 0 && tooLarge.Keys > l.MaxKeys {
+		return tooLarge
+	}
+	if l.MaxBytes > 0 {
+		data, err := json.Marshal(metadata)
+		if err != nil {
+			return fmt.Errorf("failed to serialize metadata: %w", err)
+		}
+		tooLarge.Bytes = len(data)
+		if tooLarge.Bytes > l.MaxBytes {
+			return tooLarge
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package tenant
+
+import "time"
+
+// MetricsCollector records counts and timings of tenant operations for a
+// monitoring system. The metrics/prometheus package exports them to
+// Prometheus. Methods are called on the path of the operation, so they must
+// be cheap and safe for concurrent use.
+type MetricsCollector interface {
+	// TenantCreated is called once a tenant record is stored
+	TenantCreated(plan string)
+	// TenantSuspended is called once a tenant is suspended
+	TenantSuspended(plan string)
+	// TenantDeleted is called once a tenant is soft deleted
+	TenantDeleted()
+	// ProvisionCompleted is called when ProvisionTenant returns, with how long
+	// it took and its error, if any
+	ProvisionCompleted(duration time.Duration, err error)
+	// LimitExceeded is called for every rejected limit check
+	LimitExceeded(plan, limit string)
+}
+
+// noopMetrics is the MetricsCollector used until one is set
+type noopMetrics struct{}
+
+func (noopMetrics) TenantCreated(plan string)                            {}
+func (noopMetrics) TenantSuspended(plan string)                          {}
+func (noopMetrics) TenantDeleted()                                       {}
+func (noopMetrics) ProvisionCompleted(duration time.Duration, err error) {}
+func (noopMetrics) LimitExceeded(plan, limit string)                     {}
+
+// SetMetricsCollector reports tenant operations to collector, and passes it
+// to the limit checker so that rejected checks are counted too. Pass nil to
+// stop reporting.
+func (m *manager) SetMetricsCollector(collector MetricsCollector) {
+	m.metricsMu.Lock()
+	m.metrics = collector
+	m.metricsMu.Unlock()
+
+	m.limitChecker.SetMetricsCollector(collector)
+}
+
+// metricsCollector returns the collector set by SetMetricsCollector, or a
+// no-op collector
+func (m *manager) metricsCollector() MetricsCollector {
+	m.metricsMu.RLock()
+	defer m.metricsMu.RUnlock()
+	if m.metrics == nil {
+		return noopMetrics{}
+	}
+	return m.metrics
+}
+
+// SetMetricsCollector reports every rejected check to collector. Pass nil to
+// stop reporting.
+func (lc *limitChecker) SetMetricsCollector(collector MetricsCollector) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.metrics = collector
+}
@@ -0,0 +1,135 @@
+package tenant
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+// recordingMetrics records the calls it receives
+type recordingMetrics struct {
+	mu         sync.Mutex
+	created    []string
+	suspended  []string
+	deleted    int
+	provisions []error
+	exceeded   []string
+}
+
+func (r *recordingMetrics) TenantCreated(plan string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.created = append(r.created, plan)
+}
+
+func (r *recordingMetrics) TenantSuspended(plan string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.suspended = append(r.suspended, plan)
+}
+
+func (r *recordingMetrics) TenantDeleted() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleted++
+}
+
+func (r *recordingMetrics) ProvisionCompleted(duration time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.provisions = append(r.provisions, err)
+}
+
+func (r *recordingMetrics) LimitExceeded(plan, limit string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exceeded = append(r.exceeded, plan+"/"+limit)
+}
+
+func TestManager_MetricsCollector(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	// Without a collector, operations report to the no-op collector
+	if _, ok := m.metricsCollector().(noopMetrics); !ok {
+		t.Fatalf("metricsCollector() = %T, want noopMetrics", m.metricsCollector())
+	}
+
+	metrics := &recordingMetrics{}
+	m.SetMetricsCollector(metrics)
+
+	tenant := &Tenant{Name: "Acme", Subdomain: "acme", PlanType: PlanPro}
+	if err := m.CreateTenant(ctx, tenant); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+	if err := m.ProvisionTenant(ctx, tenant.ID); err != nil {
+		t.Fatalf("ProvisionTenant() error = %v", err)
+	}
+	if err := m.ProvisionTenant(ctx, uuid.New()); err == nil {
+		t.Fatal("ProvisionTenant() of an unknown tenant should fail")
+	}
+	if err := m.SuspendTenant(ctx, tenant.ID); err != nil {
+		t.Fatalf("SuspendTenant() error = %v", err)
+	}
+	if err := m.DeleteTenant(ctx, tenant.ID); err != nil {
+		t.Fatalf("DeleteTenant() error = %v", err)
+	}
+
+	if len(metrics.created) != 1 || metrics.created[0] != PlanPro {
+		t.Errorf("created = %v, want [%s]", metrics.created, PlanPro)
+	}
+	if len(metrics.suspended) != 1 || metrics.suspended[0] != PlanPro {
+		t.Errorf("suspended = %v, want [%s]", metrics.suspended, PlanPro)
+	}
+	if metrics.deleted != 1 {
+		t.Errorf("deleted = %d, want 1", metrics.deleted)
+	}
+	if len(metrics.provisions) != 2 || metrics.provisions[0] != nil || metrics.provisions[1] == nil {
+		t.Errorf("provisions = %v, want a success then a failure", metrics.provisions)
+	}
+
+	// A failed create is not counted
+	if err := m.CreateTenant(ctx, &Tenant{Name: "Acme again", Subdomain: "acme"}); err == nil {
+		t.Fatal("CreateTenant() with a taken subdomain should fail")
+	}
+	if len(metrics.created) != 1 {
+		t.Errorf("created = %v after a failed create, want one tenant", metrics.created)
+	}
+}
+
+func TestLimitChecker_MetricsCollector(t *testing.T) {
+	tenantID := uuid.New()
+	mockRepo := &MockLimitCheckerRepository{
+		tenants: map[uuid.UUID]*Tenant{
+			tenantID: {ID: tenantID, PlanType: PlanPro, Status: StatusActive},
+		},
+	}
+	proLimits := make(FlexibleLimits)
+	proLimits.Set(LimitNameMaxProjects, LimitTypeInt, 100)
+
+	checker := NewLimitChecker(LimitsConfig{
+		EnforceLimits: true,
+		PlanLimits:    map[string]FlexibleLimits{PlanPro: proLimits},
+	}, mockRepo, zaptest.NewLogger(t))
+	metrics := &recordingMetrics{}
+	checker.SetMetricsCollector(metrics)
+	// Metrics do not depend on the denial sink
+	checker.SetDenialSink(nil)
+	ctx := context.Background()
+
+	if err := checker.CheckLimit(ctx, tenantID, LimitNameMaxProjects, 100); err != nil {
+		t.Fatalf("CheckLimit() error = %v", err)
+	}
+	if err := checker.CheckLimit(ctx, tenantID, LimitNameMaxProjects, 101); err == nil {
+		t.Fatal("CheckLimit() should deny usage over the limit")
+	}
+
+	want := PlanPro + "/" + LimitNameMaxProjects
+	if len(metrics.exceeded) != 1 || metrics.exceeded[0] != want {
+		t.Errorf("exceeded = %v, want [%s]", metrics.exceeded, want)
+	}
+}
@@ -0,0 +1,26 @@
+package tenant
+
+import (
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// MigrationScope restricts a migration to some tenants, e.g. a schema add-on
+// for enterprise tenants. A tenant is included if its plan is one of Plans or
+// its ID is one of TenantIDs. Tenants outside the scope are skipped without
+// recording the migration, so a tenant that later moves into the scope gets
+// it the next time the migration is applied.
+type MigrationScope struct {
+	Plans     []string    `json:"plans,omitempty"`
+	TenantIDs []uuid.UUID `json:"tenant_ids,omitempty"`
+}
+
+// Includes reports whether the migration applies to the tenant. A nil scope
+// includes every tenant.
+func (s *MigrationScope) Includes(tenant *Tenant) bool {
+	if s == nil {
+		return true
+	}
+	return slices.Contains(s.Plans, tenant.PlanType) || slices.Contains(s.TenantIDs, tenant.ID)
+}
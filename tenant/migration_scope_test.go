@@ -0,0 +1,27 @@
+package tenant
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestMigrationScope_Includes(t *testing.T) {
+	enterprise := &Tenant{ID: uuid.New(), PlanType: PlanEnterprise}
+	basic := &Tenant{ID: uuid.New(), PlanType: PlanBasic}
+
+	var all *MigrationScope
+	if !all.Includes(enterprise) || !all.Includes(basic) {
+		t.Error("a nil scope should include every tenant")
+	}
+
+	byPlan := &MigrationScope{Plans: []string{PlanEnterprise}}
+	if !byPlan.Includes(enterprise) || byPlan.Includes(basic) {
+		t.Error("a plan scope should include only tenants on its plans")
+	}
+
+	byTenant := &MigrationScope{TenantIDs: []uuid.UUID{basic.ID}}
+	if byTenant.Includes(enterprise) || !byTenant.Includes(basic) {
+		t.Error("a tenant scope should include only the listed tenants")
+	}
+}
@@ -1,9 +1,14 @@
 package tenant
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Tenant represents a tenant in the multi-tenant system
@@ -55,44 +60,104 @@ type Migration struct {
 	RollbackSQL *string   `json:"rollback_sql,omitempty"`
 	AppliedAt   time.Time `json:"applied_at"`
 	Checksum    *string   `json:"checksum,omitempty"`
+
+	// AppliesTo limits the migration to some tenants; nil applies it to all
+	AppliesTo *MigrationScope `json:"applies_to,omitempty"`
+
+	// Templated expands text/template placeholders in SQL for each tenant,
+	// see SQLTemplateData. Without it SQL runs as written, braces included.
+	Templated bool `json:"templated,omitempty"`
 }
 
 // Config represents configuration for the multi-tenant system
 type Config struct {
-	Database DatabaseConfig `json:"database"`
-	Resolver ResolverConfig `json:"resolver"`
-	Limits   LimitsConfig   `json:"limits"`
-	Logger   LoggerConfig   `json:"logger"`
+	Database      DatabaseConfig          `json:"database"`
+	Resolver      ResolverConfig          `json:"resolver"`
+	Limits        LimitsConfig            `json:"limits"`
+	Logger        LoggerConfig            `json:"logger"`
+	Tracing       TracingConfig           `json:"tracing"`
+	Webhook       WebhookConfig           `json:"webhook"`                  // lifecycle event webhook, off unless URL is set
+	PlanTemplates map[string]PlanTemplate `json:"plan_templates,omitempty"` // defaults for new tenants, by plan
+
+	MetadataLimits *MetadataLimits `json:"metadata_limits,omitempty"` // caps on tenant metadata enforced by the extensible repository; nil = DefaultMetadataLimits
+
+	IdempotencyKeyTTL      time.Duration `json:"idempotency_key_ttl"`       // how long CreateTenant idempotency keys are honoured; 0 uses DefaultIdempotencyKeyTTL
+	SelfCheckTenantSchemas bool          `json:"self_check_tenant_schemas"` // SelfCheck also verifies every active tenant has a schema
+	MaxTenants             int           `json:"max_tenants"`               // cap on tenants that are not cancelled, 0 or -1 = unlimited
+	ContextNamespace       string        `json:"context_namespace"`         // scopes the manager's context keys when several managers share a process
 }
 
 // DatabaseConfig contains database-specific configuration
 type DatabaseConfig struct {
-	Driver          string        `json:"driver"`
-	DSN             string        `json:"dsn"`
-	MaxOpenConns    int           `json:"max_open_conns"`
-	MaxIdleConns    int           `json:"max_idle_conns"`
-	ConnMaxLifetime time.Duration `json:"conn_max_lifetime"`
-	ConnMaxIdleTime time.Duration `json:"conn_max_idle_time"`
-	SchemaPrefix    string        `json:"schema_prefix"`
-	MigrationsTable string        `json:"migrations_table"`
-	MigrationsDir   string        `json:"migrations_dir"`
+	Driver                  string        `json:"driver"`
+	DSN                     string        `json:"dsn"`
+	MaxOpenConns            int           `json:"max_open_conns"`
+	MaxIdleConns            int           `json:"max_idle_conns"`
+	ConnMaxLifetime         time.Duration `json:"conn_max_lifetime"`
+	ConnMaxIdleTime         time.Duration `json:"conn_max_idle_time"`
+	SchemaPrefix            string        `json:"schema_prefix"`
+	MigrationsTable         string        `json:"migrations_table"`
+	MigrationsDir           string        `json:"migrations_dir"`
+	MaxTenantConnections    int           `json:"max_tenant_connections"`    // cached tenant connections, 0 = unbounded
+	TenantConnIdleTimeout   time.Duration `json:"tenant_conn_idle_timeout"`  // evict cached connections idle this long, 0 = never
+	PoolSaturationAlert     time.Duration `json:"pool_saturation_alert"`     // warn when a tenant pool stays saturated this long, 0 = never
+	SchemaFunctions         []string      `json:"schema_functions"`          // extra function DDL run in each new tenant schema
+	SchemaTriggers          []string      `json:"schema_triggers"`           // extra trigger DDL run after SchemaFunctions
+	SSLMode                 string        `json:"sslmode"`                   // disable, require, verify-ca or verify-full
+	SSLRootCert             string        `json:"sslrootcert"`               // CA certificate file used to verify the server
+	SSLCert                 string        `json:"sslcert"`                   // client certificate file for mutual TLS
+	SSLKey                  string        `json:"sslkey"`                    // client private key file for mutual TLS
+	DisableUnsafeTenantDB   bool          `json:"disable_unsafe_tenant_db"`  // make the deprecated GetTenantDB return an error
+	VerifyTenantScope       bool          `json:"verify_tenant_scope"`       // check search_path before handing out tenant connections; debug aid
+	MaxConcurrentProvisions int           `json:"max_concurrent_provisions"` // tenant schemas created at once, 0 = unbounded
+	Isolation               string        `json:"isolation"`                 // "schema" (default) or "shared" tables with row-level security
+	MaxTenantPools          int           `json:"max_tenant_pools"`          // dedicated per-tenant pools kept by GetTenantConn, 0 = no pooled mode
+	TenantPoolConns         int           `json:"tenant_pool_conns"`         // connections per tenant pool, 0 = DefaultTenantPoolConns
+	ReplicaDSNs             []string      `json:"replica_dsns"`              // read replicas serving GetTenantReadConn, none = reads use DSN
+}
+
+// IsPostgres reports whether the configuration uses the lib/pq driver, which
+// the SSL settings apply to. An unset driver counts as postgres, the default.
+func (dc DatabaseConfig) IsPostgres() bool {
+	return dc.Driver == "" || dc.Driver == "postgres"
 }
 
 // ResolverConfig contains tenant resolution configuration
 type ResolverConfig struct {
-	Strategy          string   `json:"strategy"` // "subdomain", "path", "header"
-	Domain            string   `json:"domain"`
-	HeaderName        string   `json:"header_name"`
-	PathPrefix        string   `json:"path_prefix"`
-	ReservedSubdomain []string `json:"reserved_subdomains"`
+	Strategy             string        `json:"strategy"` // "subdomain", "path", "header", "jwt", "custom_domain"
+	Domain               string        `json:"domain"`
+	HeaderName           string        `json:"header_name"`
+	PathPrefix           string        `json:"path_prefix"`
+	PathSegmentIndex     *int          `json:"path_segment_index,omitempty"` // use the Nth path segment instead of PathPrefix
+	ReservedSubdomain    []string      `json:"reserved_subdomains"`
+	CacheTTL             time.Duration `json:"cache_ttl"`               // cache resolved tenants this long, 0 = disabled
+	WarmCacheLimit       int           `json:"warm_cache_limit"`        // max tenants loaded by WarmCache, 0 = DefaultWarmCacheLimit
+	NegativeCacheTTL     time.Duration `json:"negative_cache_ttl"`      // remember subdomains with no tenant this long, 0 = disabled
+	NegativeCacheSize    int           `json:"negative_cache_size"`     // max subdomains remembered, 0 = DefaultNegativeCacheSize
+	JWTClaim             string        `json:"jwt_claim"`               // claim holding the tenant's subdomain or ID, "" = DefaultResolverJWTClaim
+	JWTKeyFunc           jwt.Keyfunc   `json:"-"`                       // returns the key that verifies a bearer token, e.g. HMACKeyFunc
+	JWTRequireExpiration bool          `json:"jwt_require_expiration"`  // reject bearer tokens without an exp claim
+	CustomDomainCacheTTL time.Duration `json:"custom_domain_cache_ttl"` // cache custom domain lookups this long, 0 = DefaultCustomDomainCacheTTL
 }
 
 // LimitsConfig contains limit enforcement configuration
 type LimitsConfig struct {
-	EnforceLimits bool                      `json:"enforce_limits"`
-	PlanLimits    map[string]FlexibleLimits `json:"plan_limits"`
-	LimitSchema   *LimitSchema              `json:"limit_schema,omitempty"`
-	DefaultPlan   string                    `json:"default_plan"`
+	EnforceLimits  bool                      `json:"enforce_limits"`
+	PlanLimits     map[string]FlexibleLimits `json:"plan_limits"`
+	LimitSchema    *LimitSchema              `json:"limit_schema,omitempty"`
+	DefaultPlan    string                    `json:"default_plan"`
+	PersistChanges bool                      `json:"persist_changes"` // persist runtime schema/plan limit changes to the database
+	TrackUsage     bool                      `json:"track_usage"`     // store usage in the database and enforce limits against it
+
+	Thresholds      []int            `json:"thresholds,omitempty"`       // usage percentages of every limit that emit limit.threshold_reached
+	LimitThresholds map[string][]int `json:"limit_thresholds,omitempty"` // thresholds by limit name, replacing Thresholds for that limit
+	ThresholdPeriod time.Duration    `json:"threshold_period"`           // each threshold fires once per tenant and limit per period; 0 = DefaultThresholdPeriod
+
+	ReconcileInterval time.Duration `json:"reconcile_interval"` // how often tracked usage is reconciled against tenant stats; 0 = never
+	StatsCacheTTL     time.Duration `json:"stats_cache_ttl"`    // serve GetStats results this long before recounting; 0 = disabled
+	OverrideCacheTTL  time.Duration `json:"override_cache_ttl"` // serve limit overrides stored in metadata this long before rereading; 0 = DefaultOverrideCacheTTL, negative = never cache
+
+	RejectOverLimitPlanChanges bool `json:"reject_over_limit_plan_changes"` // ChangePlan fails if current usage exceeds the new plan's limits
 }
 
 // LoggerConfig contains logging configuration
@@ -101,6 +166,12 @@ type LoggerConfig struct {
 	Format string `json:"format"` // "json", "console"
 }
 
+// TracingConfig contains OpenTelemetry tracing configuration
+type TracingConfig struct {
+	Enabled bool         `json:"enabled"` // wrap manager operations in spans
+	Tracer  trace.Tracer `json:"-"`       // tracer for the spans; nil uses the global provider's
+}
+
 // ValidationError represents a validation error
 type ValidationError struct {
 	Field   string `json:"field"`
@@ -108,7 +179,7 @@ type ValidationError struct {
 }
 
 // Error implements the error interface
-func (e ValidationError) Error() string {
+func (e *ValidationError) Error() string {
 	return e.Message
 }
 
@@ -120,10 +191,81 @@ type TenantError struct {
 }
 
 // Error implements the error interface
-func (e TenantError) Error() string {
+func (e *TenantError) Error() string {
 	return e.Message
 }
 
+// LimitExceededError is returned by the limit checker when a tenant's usage
+// exceeds one of its plan limits or uses a feature the plan disables
+type LimitExceededError struct {
+	TenantID uuid.UUID   `json:"tenant_id"`
+	Code     string      `json:"code"` // LIMIT_EXCEEDED, FEATURE_NOT_ALLOWED, or PLAN_LIMIT_EXCEEDED from CheckLimitAndConsume
+	Limit    string      `json:"limit"`
+	Value    interface{} `json:"value"`   // Configured limit
+	Current  interface{} `json:"current"` // Usage that was checked
+	Message  string      `json:"message"`
+}
+
+// Error implements the error interface
+func (e *LimitExceededError) Error() string {
+	return e.Message
+}
+
+// CustomDomainConflictError is returned when a tenant's custom domain is
+// already used by another tenant
+type CustomDomainConflictError struct {
+	Domain   string    `json:"domain"`
+	TenantID uuid.UUID `json:"tenant_id"` // Tenant being written
+	OwnerID  uuid.UUID `json:"owner_id"`  // Tenant that has the domain; uuid.Nil if unknown
+}
+
+// Error implements the error interface
+func (e *CustomDomainConflictError) Error() string {
+	if e.OwnerID == uuid.Nil {
+		return fmt.Sprintf("custom domain %s is already in use", e.Domain)
+	}
+	return fmt.Sprintf("custom domain %s is already used by tenant %s", e.Domain, e.OwnerID)
+}
+
+// TenantQuotaExceededError is returned by CreateTenant when the system already
+// has Config.MaxTenants tenants
+type TenantQuotaExceededError struct {
+	MaxTenants int `json:"max_tenants"`
+	Current    int `json:"current"`
+}
+
+// Error implements the error interface
+func (e *TenantQuotaExceededError) Error() string {
+	return fmt.Sprintf("tenant quota exceeded: %d of %d tenants in use", e.Current, e.MaxTenants)
+}
+
+// ProvisionStatusError is returned by ProvisionTenant for tenants whose status
+// rules out provisioning: cancelled tenants must be restored with RestoreTenant
+// and suspended ones activated first.
+type ProvisionStatusError struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	Status   string    `json:"status"`
+}
+
+// Error implements the error interface
+func (e *ProvisionStatusError) Error() string {
+	if e.Status == StatusCancelled {
+		return fmt.Sprintf("tenant %s is cancelled; restore it with RestoreTenant before provisioning", e.TenantID)
+	}
+	return fmt.Sprintf("tenant %s is %s; activate it before provisioning", e.TenantID, e.Status)
+}
+
+// ErrTenantNotFound is returned, possibly wrapped, when no tenant matches a
+// lookup. Other lookup errors indicate an infrastructure problem.
+var ErrTenantNotFound = errors.New("tenant not found")
+
+// IsNotFound reports whether err means no tenant matched a lookup. Besides
+// ErrTenantNotFound it accepts sql.ErrNoRows from repositories that return it
+// unwrapped.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrTenantNotFound) || errors.Is(err, sql.ErrNoRows)
+}
+
 // Constants for tenant status
 const (
 	StatusActive    = "active"
@@ -139,11 +281,19 @@ const (
 	PlanEnterprise = "enterprise"
 )
 
+// Constants for tenant data isolation strategies
+const (
+	IsolationSchema = "schema" // a schema per tenant
+	IsolationShared = "shared" // shared tables filtered by row-level security
+)
+
 // Constants for resolver strategies
 const (
-	ResolverSubdomain = "subdomain"
-	ResolverPath      = "path"
-	ResolverHeader    = "header"
+	ResolverSubdomain    = "subdomain"
+	ResolverPath         = "path"
+	ResolverHeader       = "header"
+	ResolverJWT          = "jwt"
+	ResolverCustomDomain = "custom_domain"
 )
 
 // DefaultConfig returns a default configuration with flexible limits
@@ -178,18 +328,22 @@ func DefaultConfig() Config {
 
 	return Config{
 		Database: DatabaseConfig{
-			Driver:          "postgres",
-			MaxOpenConns:    100,
-			MaxIdleConns:    50,
-			ConnMaxLifetime: 15 * time.Minute,
-			ConnMaxIdleTime: 5 * time.Minute,
-			SchemaPrefix:    "tenant_",
-			MigrationsTable: "tenant_migrations",
-			MigrationsDir:   "", // Applications should set this
+			Driver:                "postgres",
+			MaxOpenConns:          100,
+			MaxIdleConns:          50,
+			ConnMaxLifetime:       15 * time.Minute,
+			ConnMaxIdleTime:       5 * time.Minute,
+			SchemaPrefix:          "tenant_",
+			MigrationsTable:       "tenant_migrations",
+			MigrationsDir:         "", // Applications should set this
+			MaxTenantConnections:  100,
+			TenantConnIdleTimeout: 30 * time.Minute,
+			PoolSaturationAlert:   30 * time.Second,
 		},
 		Resolver: ResolverConfig{
 			Strategy:          ResolverSubdomain,
 			ReservedSubdomain: []string{"www", "api", "admin", "mail", "ftp", "blog", "support", "help"},
+			CacheTTL:          30 * time.Second,
 		},
 		Limits: LimitsConfig{
 			EnforceLimits: true,
@@ -205,6 +359,7 @@ func DefaultConfig() Config {
 			Level:  "info",
 			Format: "json",
 		},
+		IdempotencyKeyTTL: DefaultIdempotencyKeyTTL,
 	}
 }
 
@@ -139,7 +139,7 @@ func TestTenant_Validation(t *testing.T) {
 }
 
 func TestValidationError(t *testing.T) {
-	err := ValidationError{
+	err := &ValidationError{
 		Field:   "name",
 		Message: "name is required",
 	}
@@ -152,7 +152,7 @@ func TestValidationError(t *testing.T) {
 
 func TestTenantError(t *testing.T) {
 	tenantID := uuid.New()
-	err := TenantError{
+	err := &TenantError{
 		TenantID: tenantID,
 		Code:     "LIMIT_EXCEEDED",
 		Message:  "Tenant limit exceeded",
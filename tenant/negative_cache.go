@@ -0,0 +1,175 @@
+package tenant
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// DefaultNegativeCacheSize is how many unknown subdomains a resolver remembers
+// when ResolverConfig.NegativeCacheSize is not set
+const DefaultNegativeCacheSize = 10000
+
+// SubdomainInvalidator is implemented by caches of subdomain lookups, such as
+// a resolver with ResolverConfig.NegativeCacheTTL set or one returned by
+// NewCachedResolver. Register one with Manager.AddSubdomainInvalidator and the
+// manager calls Invalidate whenever a tenant takes a subdomain or changes
+// status, so that the change is seen at once.
+type SubdomainInvalidator interface {
+	Invalidate(subdomain string)
+}
+
+// negativeCache remembers subdomains that resolved to no tenant, so that bots
+// probing random hosts do not cost a database query each. It holds at most
+// max entries; when full, expired entries are swept and, if that frees
+// nothing, an arbitrary entry is dropped.
+type negativeCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	max     int
+	entries map[string]time.Time // expiry by subdomain
+	now     func() time.Time
+}
+
+// newNegativeCache creates a negative cache, or returns nil if ttl disables it
+func newNegativeCache(ttl time.Duration, max int) *negativeCache {
+	if ttl <= 0 {
+		return nil
+	}
+	if max <= 0 {
+		max = DefaultNegativeCacheSize
+	}
+	return &negativeCache{
+		ttl:     ttl,
+		max:     max,
+		entries: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// has reports whether subdomain is known not to exist
+func (c *negativeCache) has(subdomain string) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires, ok := c.entries[subdomain]
+	if ok && !c.now().Before(expires) {
+		delete(c.entries, subdomain)
+		return false
+	}
+	return ok
+}
+
+// add remembers that subdomain does not exist
+func (c *negativeCache) add(subdomain string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if _, exists := c.entries[subdomain]; !exists && len(c.entries) >= c.max {
+		for key, expires := range c.entries {
+			if !now.Before(expires) {
+				delete(c.entries, key)
+			}
+		}
+		for key := range c.entries {
+			if len(c.entries) < c.max {
+				break
+			}
+			delete(c.entries, key)
+		}
+	}
+	c.entries[subdomain] = now.Add(c.ttl)
+}
+
+// forget removes subdomain, e.g. because a tenant now uses it
+func (c *negativeCache) forget(subdomain string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	delete(c.entries, subdomain)
+	c.mu.Unlock()
+}
+
+// AddSubdomainInvalidator registers a cache to be told when a tenant takes a
+// subdomain, by CreateTenant or by UpdateTenant changing it, and when a
+// tenant is suspended, activated, restored, deleted or purged. Registering a
+// cache that is already registered does not add it again. The returned
+// function unregisters it, e.g. once a resolver is no longer used.
+func (m *manager) AddSubdomainInvalidator(invalidator SubdomainInvalidator) func() {
+	m.invalidatorsMu.Lock()
+	defer m.invalidatorsMu.Unlock()
+	for _, registered := range m.invalidators {
+		if sameInvalidator(registered, invalidator) {
+			return func() { m.removeSubdomainInvalidator(invalidator) }
+		}
+	}
+	m.invalidators = append(m.invalidators, invalidator)
+	return func() { m.removeSubdomainInvalidator(invalidator) }
+}
+
+// removeSubdomainInvalidator unregisters invalidator, if it is registered
+func (m *manager) removeSubdomainInvalidator(invalidator SubdomainInvalidator) {
+	m.invalidatorsMu.Lock()
+	defer m.invalidatorsMu.Unlock()
+	for i, registered := range m.invalidators {
+		if sameInvalidator(registered, invalidator) {
+			m.invalidators = append(m.invalidators[:i:i], m.invalidators[i+1:]...)
+			return
+		}
+	}
+}
+
+// sameInvalidator reports whether a and b are the same cache. Caches of types
+// that cannot be compared, such as funcs, are never the same.
+func sameInvalidator(a, b SubdomainInvalidator) bool {
+	typ := reflect.TypeOf(a)
+	if typ != reflect.TypeOf(b) || !typ.Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// invalidateSubdomain tells every registered cache that subdomain changed
+func (m *manager) invalidateSubdomain(subdomain string) {
+	m.invalidatorsMu.RLock()
+	defer m.invalidatorsMu.RUnlock()
+	for _, invalidator := range m.invalidators {
+		invalidator.Invalidate(subdomain)
+	}
+}
+
+// invalidateTenantSubdomain looks up the tenant's subdomain and tells every
+// registered cache that it changed. The lookup is skipped when no cache is
+// registered, and a tenant that cannot be found is ignored.
+func (m *manager) invalidateTenantSubdomain(ctx context.Context, tenantID uuid.UUID) {
+	m.invalidatorsMu.RLock()
+	registered := len(m.invalidators) > 0
+	m.invalidatorsMu.RUnlock()
+	if !registered {
+		return
+	}
+
+	tenant, err := m.repository.GetByID(ctx, tenantID)
+	if err != nil {
+		m.logger.Debug("Failed to get tenant to invalidate its subdomain",
+			zap.String("tenant_id", tenantID.String()),
+			zap.Error(err))
+		return
+	}
+	m.invalidateSubdomain(tenant.Subdomain)
+}
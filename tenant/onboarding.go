@@ -0,0 +1,112 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// RoleOwner is the tenant_users role OnboardTenantWithOwner gives the first user
+const RoleOwner = "owner"
+
+// PurgeRepository is implemented by repositories that can remove a tenant's
+// row entirely instead of soft deleting it. OnboardTenantWithOwner uses it to
+// undo a failed onboarding, so that the subdomain can be used again.
+type PurgeRepository interface {
+	// Purge removes the record of a cancelled tenant. A tenant that does not
+	// exist or is not cancelled is reported as ErrTenantNotFound.
+	Purge(ctx context.Context, id uuid.UUID) error
+}
+
+// OnboardTenantWithOwner creates the tenant, provisions its schema and adds
+// ownerUserID to its tenant_users table with the owner role, as in a signup's
+// first run. Provisioning always happens inline, even with a provisioning
+// queue set. If any step fails the earlier ones are undone: the schema is
+// dropped and the tenant record purged, or soft deleted if the repository is
+// not a PurgeRepository. Idempotency keys are not honoured; retry a failed
+// onboarding as a new one.
+func (m *manager) OnboardTenantWithOwner(ctx context.Context, tenant *Tenant, ownerUserID uuid.UUID) error {
+	if ownerUserID == uuid.Nil {
+		return &ValidationError{Field: "owner_user_id", Message: "owner user ID is required"}
+	}
+	if err := m.validateTenant(tenant); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	if tenant.ID == uuid.Nil {
+		tenant.ID = uuid.New()
+	}
+	tenant.SchemaName = m.schemaManager.GetSchemaName(tenant.ID)
+	tenant.Status = StatusPending
+	if tenant.PlanType == "" {
+		tenant.PlanType = PlanBasic
+	}
+
+	if err := m.createTenantRecord(ctx, tenant); err != nil {
+		return fmt.Errorf("failed to create tenant: %w", err)
+	}
+	m.metricsCollector().TenantCreated(tenant.PlanType)
+	m.publishEvent(ctx, EventTenantCreated, tenant, nil)
+
+	if err := m.ProvisionTenant(ctx, tenant.ID); err != nil {
+		m.rollbackOnboarding(ctx, tenant, true)
+		return fmt.Errorf("failed to provision tenant: %w", err)
+	}
+
+	err := m.WithTenantTx(ctx, tenant.ID, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "INSERT INTO tenant_users (user_id, role) VALUES ($1, $2)", ownerUserID, RoleOwner)
+		return err
+	})
+	if err != nil {
+		m.rollbackOnboarding(ctx, tenant, true)
+		return fmt.Errorf("failed to add tenant owner: %w", err)
+	}
+	tenant.Status = StatusActive
+
+	m.logger.Info("Onboarded tenant",
+		zap.String("tenant_id", tenant.ID.String()),
+		zap.String("subdomain", tenant.Subdomain),
+		zap.String("owner_user_id", ownerUserID.String()))
+
+	return nil
+}
+
+// rollbackOnboarding drops the schema and removes the record of a tenant whose
+// onboarding failed. It runs even if ctx was cancelled, and failures are only
+// logged since the onboarding error is what the caller needs to see. Once the
+// tenant was announced with EventTenantCreated, its removal is announced too.
+func (m *manager) rollbackOnboarding(ctx context.Context, tenant *Tenant, announced bool) {
+	ctx = context.WithoutCancel(ctx)
+	tenantID := tenant.ID
+	defer m.tenants.invalidate(tenantID)
+	defer m.stats.invalidate(tenantID)
+
+	if err := m.schemaManager.DropTenantSchema(ctx, tenantID); err != nil {
+		m.logger.Error("Failed to drop schema after onboarding failure",
+			zap.String("tenant_id", tenantID.String()),
+			zap.Error(err))
+	}
+
+	// Purge only removes cancelled tenants, so the tenant is soft deleted first
+	event := EventTenantDeleted
+	err := m.repository.Delete(ctx, tenantID)
+	if purger, ok := m.repository.(PurgeRepository); !ok {
+		m.logger.Warn("Repository cannot purge tenants, soft deleting after onboarding failure",
+			zap.String("tenant_id", tenantID.String()))
+	} else if err == nil {
+		err = purger.Purge(ctx, tenantID)
+		event = EventTenantPurged
+	}
+	if err != nil {
+		m.logger.Error("Failed to remove tenant after onboarding failure",
+			zap.String("tenant_id", tenantID.String()),
+			zap.Error(err))
+		return
+	}
+	if announced {
+		m.publishEvent(ctx, event, tenant, nil)
+	}
+}
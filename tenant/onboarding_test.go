@@ -0,0 +1,101 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestManager_OnboardTenantWithOwner(t *testing.T) {
+	recorder := &execRecorder{}
+	m := newExecTestManager(t, recorder)
+	ctx := context.Background()
+	ownerID := uuid.New()
+	metrics := &recordingMetrics{}
+	m.SetMetricsCollector(metrics)
+	var events []string
+	m.SetEventPublisher(EventPublisherFunc(func(ctx context.Context, event Event) error {
+		events = append(events, event.Type)
+		return nil
+	}))
+
+	tenant := &Tenant{Name: "Acme", Subdomain: "acme", PlanType: PlanPro}
+	if err := m.OnboardTenantWithOwner(ctx, tenant, ownerID); err != nil {
+		t.Fatalf("OnboardTenantWithOwner() error = %v", err)
+	}
+	if want := []string{EventTenantCreated, EventTenantProvisioned}; fmt.Sprint(events) != fmt.Sprint(want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+	if len(metrics.created) != 1 || metrics.created[0] != PlanPro {
+		t.Errorf("created metrics = %v, want one %s tenant", metrics.created, PlanPro)
+	}
+
+	stored, err := m.GetTenant(ctx, tenant.ID)
+	if err != nil || stored.Status != StatusActive || tenant.Status != StatusActive {
+		t.Fatalf("GetTenant() = %+v, %v; want an active tenant", stored, err)
+	}
+	if exists, _ := m.schemaManager.SchemaExists(ctx, tenant.ID); !exists {
+		t.Error("OnboardTenantWithOwner() should provision the schema")
+	}
+
+	schema := m.schemaManager.GetSchemaName(tenant.ID)
+	want := []string{
+		fmt.Sprintf(`SET LOCAL search_path TO "%s", public`, schema),
+		"INSERT INTO tenant_users (user_id, role) VALUES ($1, $2)",
+	}
+	if got := recorder.statementsFor(schema); strings.Join(got, "; ") != strings.Join(want, "; ") {
+		t.Errorf("statements = %q, want the owner membership inserted in %s", got, schema)
+	}
+	if recorder.commits != 1 {
+		t.Errorf("committed %d transactions, want the membership committed", recorder.commits)
+	}
+}
+
+func TestManager_OnboardTenantWithOwner_RollsBack(t *testing.T) {
+	recorder := &execRecorder{failOn: "tenant_users"}
+	m := newExecTestManager(t, recorder)
+	repo := m.repository.(*MockManagerRepository)
+	ctx := context.Background()
+
+	var events []string
+	m.SetEventPublisher(EventPublisherFunc(func(ctx context.Context, event Event) error {
+		events = append(events, event.Type)
+		return nil
+	}))
+
+	tenant := &Tenant{Name: "Acme", Subdomain: "acme"}
+	if err := m.OnboardTenantWithOwner(ctx, tenant, uuid.New()); err == nil {
+		t.Fatal("OnboardTenantWithOwner() should fail when the owner cannot be added")
+	}
+	if len(events) == 0 || events[len(events)-1] != EventTenantPurged {
+		t.Errorf("events = %v, want the removal announced last", events)
+	}
+
+	if _, exists := repo.tenants[tenant.ID]; exists {
+		t.Error("failed onboarding left the tenant record behind")
+	}
+	if exists, _ := m.schemaManager.SchemaExists(ctx, tenant.ID); exists {
+		t.Error("failed onboarding left the schema behind")
+	}
+	if recorder.commits != 0 || recorder.rollbacks != 1 {
+		t.Errorf("commits = %d, rollbacks = %d; want the membership rolled back", recorder.commits, recorder.rollbacks)
+	}
+
+	// Nothing persists, so the subdomain can be used again
+	recorder.failOn = ""
+	if err := m.OnboardTenantWithOwner(ctx, &Tenant{Name: "Acme", Subdomain: "acme"}, uuid.New()); err != nil {
+		t.Errorf("OnboardTenantWithOwner() retry error = %v", err)
+	}
+
+	var validationErr *ValidationError
+	if err := m.OnboardTenantWithOwner(ctx, &Tenant{Name: "Globex", Subdomain: "globex"}, uuid.Nil); !errors.As(err, &validationErr) {
+		t.Errorf("OnboardTenantWithOwner() without owner error = %v, want a ValidationError", err)
+	}
+	if len(repo.tenants) != 1 {
+		t.Errorf("repository has %d tenants, want only the onboarded one", len(repo.tenants))
+	}
+}
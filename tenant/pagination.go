@@ -0,0 +1,59 @@
+package tenant
+
+// Pagination defaults applied by NormalizePagination
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+)
+
+// NormalizePagination returns the page and per-page values list queries should
+// use: page is at least 1, a perPage below 1 becomes DefaultPerPage and a
+// perPage above MaxPerPage is clamped to MaxPerPage.
+func NormalizePagination(page, perPage int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	switch {
+	case perPage < 1:
+		perPage = DefaultPerPage
+	case perPage > MaxPerPage:
+		perPage = MaxPerPage
+	}
+	return page, perPage
+}
+
+// Page is one page of a paginated list, with the metadata clients need to
+// render pagination controls
+type Page[T any] struct {
+	Items      []T  `json:"items"`
+	Total      int  `json:"total"`
+	Page       int  `json:"page"`
+	PerPage    int  `json:"per_page"`
+	TotalPages int  `json:"total_pages"`
+	HasNext    bool `json:"has_next"`
+}
+
+// NewPage builds a Page from a list query's items and total count. page is
+// 1-based; a perPage below 1 yields zero TotalPages.
+func NewPage[T any](items []T, total, page, perPage int) *Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	totalPages := 0
+	if perPage > 0 {
+		totalPages = (total + perPage - 1) / perPage
+	}
+
+	return &Page[T]{
+		Items:      items,
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: totalPages,
+		HasNext:    page < totalPages,
+	}
+}
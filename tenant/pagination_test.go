@@ -0,0 +1,137 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestNormalizePagination(t *testing.T) {
+	tests := []struct {
+		name        string
+		page        int
+		perPage     int
+		wantPage    int
+		wantPerPage int
+	}{
+		{name: "in range", page: 3, perPage: 50, wantPage: 3, wantPerPage: 50},
+		{name: "zero values", page: 0, perPage: 0, wantPage: 1, wantPerPage: DefaultPerPage},
+		{name: "negative values", page: -1, perPage: -20, wantPage: 1, wantPerPage: DefaultPerPage},
+		{name: "per page at max", page: 1, perPage: MaxPerPage, wantPage: 1, wantPerPage: MaxPerPage},
+		{name: "per page above max", page: 1, perPage: 1_000_000, wantPage: 1, wantPerPage: MaxPerPage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, perPage := NormalizePagination(tt.page, tt.perPage)
+			if page != tt.wantPage || perPage != tt.wantPerPage {
+				t.Errorf("NormalizePagination(%d, %d) = %d, %d, want %d, %d",
+					tt.page, tt.perPage, page, perPage, tt.wantPage, tt.wantPerPage)
+			}
+		})
+	}
+}
+
+func TestMockRepository_ListClampsPagination(t *testing.T) {
+	repo := NewMockRepository()
+	for i := 0; i < MaxPerPage+5; i++ {
+		tenant := &Tenant{ID: uuid.New(), Subdomain: fmt.Sprintf("tenant%d", i), Status: StatusActive}
+		if err := repo.Create(context.Background(), tenant); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	tenants, _, err := repo.List(context.Background(), 0, 1_000_000)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(tenants) != MaxPerPage {
+		t.Errorf("List() returned %d tenants, want %d", len(tenants), MaxPerPage)
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	tests := []struct {
+		name           string
+		total          int
+		page           int
+		perPage        int
+		wantPage       int
+		wantTotalPages int
+		wantHasNext    bool
+	}{
+		{name: "first of several pages", total: 45, page: 1, perPage: 20, wantPage: 1, wantTotalPages: 3, wantHasNext: true},
+		{name: "middle page", total: 45, page: 2, perPage: 20, wantPage: 2, wantTotalPages: 3, wantHasNext: true},
+		{name: "last partial page", total: 45, page: 3, perPage: 20, wantPage: 3, wantTotalPages: 3, wantHasNext: false},
+		{name: "exact multiple", total: 40, page: 2, perPage: 20, wantPage: 2, wantTotalPages: 2, wantHasNext: false},
+		{name: "past the end", total: 40, page: 5, perPage: 20, wantPage: 5, wantTotalPages: 2, wantHasNext: false},
+		{name: "empty result", total: 0, page: 1, perPage: 20, wantPage: 1, wantTotalPages: 0, wantHasNext: false},
+		{name: "page below one", total: 10, page: 0, perPage: 5, wantPage: 1, wantTotalPages: 2, wantHasNext: true},
+		{name: "invalid per page", total: 10, page: 1, perPage: 0, wantPage: 1, wantTotalPages: 0, wantHasNext: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := NewPage([]int{1, 2}, tt.total, tt.page, tt.perPage)
+
+			if page.Page != tt.wantPage {
+				t.Errorf("Page = %d, want %d", page.Page, tt.wantPage)
+			}
+			if page.TotalPages != tt.wantTotalPages {
+				t.Errorf("TotalPages = %d, want %d", page.TotalPages, tt.wantTotalPages)
+			}
+			if page.HasNext != tt.wantHasNext {
+				t.Errorf("HasNext = %v, want %v", page.HasNext, tt.wantHasNext)
+			}
+			if page.Total != tt.total || page.PerPage != tt.perPage || len(page.Items) != 2 {
+				t.Errorf("NewPage() = %+v, want items, total and perPage passed through", page)
+			}
+		})
+	}
+
+	// Nil items serialize as an empty list
+	if page := NewPage[*Tenant](nil, 0, 1, 20); page.Items == nil {
+		t.Error("NewPage() should replace nil items with an empty slice")
+	}
+}
+
+func TestManager_ListTenantsPaged(t *testing.T) {
+	config := DefaultConfig()
+	m := NewManager(config, nil, NewMockRepository(), NewMockSchemaManager(config.Database.SchemaPrefix),
+		NewMockMigrationManager(), NewMockLimitChecker(config.Limits), zaptest.NewLogger(t))
+	ctx := context.Background()
+
+	for _, subdomain := range []string{"acme", "globex", "initech"} {
+		if err := m.CreateTenant(ctx, &Tenant{Name: subdomain, Subdomain: subdomain}); err != nil {
+			t.Fatalf("CreateTenant() error = %v", err)
+		}
+	}
+
+	page, err := m.ListTenantsPaged(ctx, 1, 2)
+	if err != nil {
+		t.Fatalf("ListTenantsPaged() error = %v", err)
+	}
+	if len(page.Items) != 2 || page.Total != 3 || page.TotalPages != 2 || !page.HasNext {
+		t.Errorf("ListTenantsPaged(1, 2) = %+v, want 2 of 3 items with a next page", page)
+	}
+
+	page, err = m.ListTenantsPaged(ctx, 2, 2)
+	if err != nil {
+		t.Fatalf("ListTenantsPaged() error = %v", err)
+	}
+	if len(page.Items) != 1 || page.HasNext {
+		t.Errorf("ListTenantsPaged(2, 2) = %+v, want the last item and no next page", page)
+	}
+
+	// Reported pagination reflects the normalized parameters
+	page, err = m.ListTenantsPaged(ctx, 0, 1_000_000)
+	if err != nil {
+		t.Fatalf("ListTenantsPaged() error = %v", err)
+	}
+	if page.Page != 1 || page.PerPage != MaxPerPage {
+		t.Errorf("ListTenantsPaged(0, 1000000) page = %d, perPage = %d, want 1 and %d", page.Page, page.PerPage, MaxPerPage)
+	}
+}
@@ -0,0 +1,126 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// PlanChange records a change of a tenant's plan
+type PlanChange struct {
+	ID        uuid.UUID `json:"id"`
+	TenantID  uuid.UUID `json:"tenant_id"`
+	OldPlan   string    `json:"old_plan"`
+	NewPlan   string    `json:"new_plan"`
+	Actor     string    `json:"actor"` // Who made the change, e.g. a user ID or "billing"
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// PlanHistoryRepository extends Repository with a persisted plan change history
+type PlanHistoryRepository interface {
+	Repository
+
+	// ChangePlan sets the tenant's plan to change.NewPlan and records the change
+	// in the same transaction. OldPlan is filled in from the stored tenant; no
+	// history is recorded if the plan is unchanged.
+	ChangePlan(ctx context.Context, change *PlanChange) error
+
+	// GetPlanHistory returns the tenant's plan changes, oldest first
+	GetPlanHistory(ctx context.Context, tenantID uuid.UUID) ([]*PlanChange, error)
+}
+
+// ChangePlan moves a tenant to a new plan on behalf of actor. The change is
+// recorded in the plan history when the repository supports it. With
+// LimitsConfig.RejectOverLimitPlanChanges set, a change that would leave the
+// tenant over the new plan's limits fails with a *PlanChangeError.
+func (m *manager) ChangePlan(ctx context.Context, tenantID uuid.UUID, planType, actor string) error {
+	_, err := m.changePlan(ctx, tenantID, planType, actor)
+	return err
+}
+
+// ChangeTenantPlan moves a tenant to newPlan like ChangePlan, without an
+// actor, and returns the plan the tenant was on so callers can log the change
+// or prorate billing. Plans without configured limits are rejected with a
+// *ValidationError. On error the returned plan is empty.
+func (m *manager) ChangeTenantPlan(ctx context.Context, tenantID uuid.UUID, newPlan string) (string, error) {
+	return m.changePlan(ctx, tenantID, newPlan, "")
+}
+
+// changePlan implements ChangePlan and ChangeTenantPlan, returning the
+// tenant's previous plan, or "" on error
+func (m *manager) changePlan(ctx context.Context, tenantID uuid.UUID, planType, actor string) (string, error) {
+	if m.limitChecker.GetLimitsForPlan(planType) == nil {
+		return "", &ValidationError{Field: "plan_type", Message: fmt.Sprintf("unknown plan type %q", planType)}
+	}
+
+	tenant, err := m.repository.GetByID(ctx, tenantID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	oldPlan := tenant.PlanType
+	if oldPlan == planType {
+		return oldPlan, nil
+	}
+
+	if m.config.Limits.RejectOverLimitPlanChanges {
+		violations, err := m.ValidatePlanChange(ctx, tenantID, planType)
+		if err != nil {
+			return "", fmt.Errorf("failed to validate plan change: %w", err)
+		}
+		if len(violations) > 0 {
+			return "", &PlanChangeError{TenantID: tenantID, OldPlan: oldPlan, NewPlan: planType, Violations: violations}
+		}
+	}
+
+	defer m.tenants.invalidate(tenantID)
+
+	if historyRepo, ok := m.repository.(PlanHistoryRepository); ok {
+		change := &PlanChange{
+			TenantID: tenantID,
+			OldPlan:  oldPlan,
+			NewPlan:  planType,
+			Actor:    actor,
+		}
+		if err := historyRepo.ChangePlan(ctx, change); err != nil {
+			return "", fmt.Errorf("failed to change plan: %w", err)
+		}
+		tenant.PlanType = planType
+	} else {
+		tenant.PlanType = planType
+		if err := m.repository.Update(ctx, tenant); err != nil {
+			return "", fmt.Errorf("failed to change plan: %w", err)
+		}
+	}
+
+	m.logger.Info("Changed tenant plan",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("old_plan", oldPlan),
+		zap.String("new_plan", planType),
+		zap.String("actor", actor))
+
+	m.publishEvent(ctx, EventTenantPlanChanged, tenant, map[string]interface{}{
+		"old_plan": oldPlan,
+		"new_plan": planType,
+		"actor":    actor,
+	})
+
+	return oldPlan, nil
+}
+
+// GetPlanHistory returns a tenant's plan changes, oldest first
+func (m *manager) GetPlanHistory(ctx context.Context, tenantID uuid.UUID) ([]*PlanChange, error) {
+	historyRepo, ok := m.repository.(PlanHistoryRepository)
+	if !ok {
+		return nil, &TenantError{
+			TenantID: tenantID,
+			Code:     "PLAN_HISTORY_UNAVAILABLE",
+			Message:  "repository does not record plan history",
+		}
+	}
+
+	return historyRepo.GetPlanHistory(ctx, tenantID)
+}
@@ -0,0 +1,234 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestManager_ChangePlan_RecordsHistory(t *testing.T) {
+	m, repo := newPlanHistoryTestManager(t)
+	ctx := context.Background()
+	tenantID := createActiveTestTenant(t, m)
+
+	// Warm the tenant cache so the change has to invalidate it
+	if _, err := m.GetTenant(ctx, tenantID); err != nil {
+		t.Fatalf("GetTenant() error = %v", err)
+	}
+
+	if err := m.ChangePlan(ctx, tenantID, PlanPro, "user-42"); err != nil {
+		t.Fatalf("ChangePlan() error = %v", err)
+	}
+	if err := m.ChangePlan(ctx, tenantID, PlanEnterprise, "billing"); err != nil {
+		t.Fatalf("ChangePlan() error = %v", err)
+	}
+
+	history, err := m.GetPlanHistory(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("GetPlanHistory() error = %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("GetPlanHistory() returned %d changes, want 2", len(history))
+	}
+
+	first := history[0]
+	if first.TenantID != tenantID || first.OldPlan != PlanBasic || first.NewPlan != PlanPro || first.Actor != "user-42" {
+		t.Errorf("first change = %+v, want basic -> pro by user-42", first)
+	}
+	if first.ChangedAt.IsZero() {
+		t.Error("first change should have a timestamp")
+	}
+	if second := history[1]; second.OldPlan != PlanPro || second.NewPlan != PlanEnterprise || second.Actor != "billing" {
+		t.Errorf("second change = %+v, want pro -> enterprise by billing", second)
+	}
+
+	tenant, err := m.GetTenant(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("GetTenant() error = %v", err)
+	}
+	if tenant.PlanType != PlanEnterprise {
+		t.Errorf("GetTenant() plan = %s, want %s right after ChangePlan", tenant.PlanType, PlanEnterprise)
+	}
+
+	// Changing to the current plan records nothing
+	if err := m.ChangePlan(ctx, tenantID, PlanEnterprise, "user-42"); err != nil {
+		t.Fatalf("ChangePlan() error = %v", err)
+	}
+	if len(repo.history[tenantID]) != 2 {
+		t.Errorf("history has %d changes after a no-op change, want 2", len(repo.history[tenantID]))
+	}
+}
+
+func TestManager_ChangePlan_InvalidPlan(t *testing.T) {
+	m, repo := newPlanHistoryTestManager(t)
+	tenantID := createActiveTestTenant(t, m)
+
+	err := m.ChangePlan(context.Background(), tenantID, "platinum", "user-42")
+	if _, ok := err.(*ValidationError); !ok {
+		t.Errorf("ChangePlan() error = %v, want ValidationError", err)
+	}
+	if len(repo.history[tenantID]) != 0 {
+		t.Error("ChangePlan() should not record an invalid plan")
+	}
+}
+
+func TestManager_ChangeTenantPlan(t *testing.T) {
+	m, repo := newPlanHistoryTestManager(t)
+	ctx := context.Background()
+	tenantID := createActiveTestTenant(t, m)
+
+	var events []Event
+	m.SetEventPublisher(EventPublisherFunc(func(ctx context.Context, event Event) error {
+		events = append(events, event)
+		return nil
+	}))
+
+	oldPlan, err := m.ChangeTenantPlan(ctx, tenantID, PlanPro)
+	if err != nil {
+		t.Fatalf("ChangeTenantPlan() error = %v", err)
+	}
+	if oldPlan != PlanBasic {
+		t.Errorf("ChangeTenantPlan() = %s, want the previous plan %s", oldPlan, PlanBasic)
+	}
+	if tenant, _ := m.GetTenant(ctx, tenantID); tenant.PlanType != PlanPro {
+		t.Errorf("plan = %s, want %s", tenant.PlanType, PlanPro)
+	}
+	if len(repo.history[tenantID]) != 1 {
+		t.Errorf("history has %d changes, want 1", len(repo.history[tenantID]))
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("published %d events, want 1", len(events))
+	}
+	event := events[0]
+	if event.Type != EventTenantPlanChanged || event.TenantID != tenantID || event.PlanType != PlanPro {
+		t.Errorf("event = %+v, want %s for the tenant on %s", event, EventTenantPlanChanged, PlanPro)
+	}
+	if event.Data["old_plan"] != PlanBasic || event.Data["new_plan"] != PlanPro {
+		t.Errorf("event data = %v, want old_plan %s and new_plan %s", event.Data, PlanBasic, PlanPro)
+	}
+
+	// Staying on the same plan changes nothing and publishes nothing
+	if oldPlan, err := m.ChangeTenantPlan(ctx, tenantID, PlanPro); err != nil || oldPlan != PlanPro {
+		t.Errorf("ChangeTenantPlan() to the current plan = %s, %v, want %s, nil", oldPlan, err, PlanPro)
+	}
+	if len(events) != 1 {
+		t.Errorf("published %d events after a no-op change, want 1", len(events))
+	}
+}
+
+func TestManager_ChangeTenantPlan_UnknownPlan(t *testing.T) {
+	m, repo := newPlanHistoryTestManager(t)
+	ctx := context.Background()
+	tenantID := createActiveTestTenant(t, m)
+
+	oldPlan, err := m.ChangeTenantPlan(ctx, tenantID, "platinum")
+	if oldPlan != "" {
+		t.Errorf("ChangeTenantPlan() = %q on error, want no plan", oldPlan)
+	}
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "plan_type" {
+		t.Fatalf("ChangeTenantPlan() error = %v, want a plan_type ValidationError", err)
+	}
+	if !strings.Contains(err.Error(), "platinum") {
+		t.Errorf("ChangeTenantPlan() error = %q, want it to name the unknown plan", err)
+	}
+	if tenant, _ := m.GetTenant(ctx, tenantID); tenant.PlanType != PlanBasic {
+		t.Errorf("plan = %s, want %s after an unknown plan", tenant.PlanType, PlanBasic)
+	}
+	if len(repo.history[tenantID]) != 0 {
+		t.Error("ChangeTenantPlan() should not record an unknown plan")
+	}
+}
+
+func TestManager_ChangeTenantPlan_ConfiguredPlan(t *testing.T) {
+	m, repo := newPlanHistoryTestManager(t)
+	ctx := context.Background()
+	tenantID := createActiveTestTenant(t, m)
+
+	// Plans are whatever the limit checker has limits for, not only the built-in ones
+	if err := m.limitChecker.SetLimitsForPlan("team", FlexibleLimits{
+		LimitNameMaxUsers: {Type: LimitTypeInt, Value: 50},
+	}); err != nil {
+		t.Fatalf("SetLimitsForPlan() error = %v", err)
+	}
+
+	oldPlan, err := m.ChangeTenantPlan(ctx, tenantID, "team")
+	if err != nil || oldPlan != PlanBasic {
+		t.Fatalf("ChangeTenantPlan() to a configured plan = %s, %v, want %s, nil", oldPlan, err, PlanBasic)
+	}
+	if tenant, _ := m.GetTenant(ctx, tenantID); tenant.PlanType != "team" {
+		t.Errorf("plan = %s, want team", tenant.PlanType)
+	}
+	if len(repo.history[tenantID]) != 1 {
+		t.Errorf("history has %d changes, want 1", len(repo.history[tenantID]))
+	}
+}
+
+func TestManager_ChangePlan_WithoutHistorySupport(t *testing.T) {
+	m, _ := newCachingTestManager(t, time.Minute)
+	ctx := context.Background()
+	tenantID := createActiveTestTenant(t, m)
+
+	if err := m.ChangePlan(ctx, tenantID, PlanPro, "user-42"); err != nil {
+		t.Fatalf("ChangePlan() error = %v", err)
+	}
+
+	tenant, err := m.GetTenant(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("GetTenant() error = %v", err)
+	}
+	if tenant.PlanType != PlanPro {
+		t.Errorf("GetTenant() plan = %s, want %s", tenant.PlanType, PlanPro)
+	}
+
+	if _, err := m.GetPlanHistory(ctx, tenantID); err == nil {
+		t.Error("GetPlanHistory() should fail when the repository does not record history")
+	}
+}
+
+// historyRepository adds plan history to the manager mock repository
+type historyRepository struct {
+	*MockManagerRepository
+	history map[uuid.UUID][]*PlanChange
+}
+
+func (r *historyRepository) ChangePlan(ctx context.Context, change *PlanChange) error {
+	tenant, err := r.GetByID(ctx, change.TenantID)
+	if err != nil {
+		return err
+	}
+
+	change.OldPlan = tenant.PlanType
+	if change.OldPlan == change.NewPlan {
+		return nil
+	}
+
+	updated := *tenant
+	updated.PlanType = change.NewPlan
+	if err := r.Update(ctx, &updated); err != nil {
+		return err
+	}
+
+	change.ID = uuid.New()
+	change.ChangedAt = time.Now()
+	r.history[change.TenantID] = append(r.history[change.TenantID], change)
+	return nil
+}
+
+func (r *historyRepository) GetPlanHistory(ctx context.Context, tenantID uuid.UUID) ([]*PlanChange, error) {
+	return r.history[tenantID], nil
+}
+
+// newPlanHistoryTestManager returns a caching manager whose repository records plan history
+func newPlanHistoryTestManager(t *testing.T) (*manager, *historyRepository) {
+	repo := &historyRepository{MockManagerRepository: NewMockRepository(), history: make(map[uuid.UUID][]*PlanChange)}
+	m := newTestManager(t, withRepository(repo), withConfig(func(config *Config) {
+		config.Resolver.CacheTTL = time.Minute
+	}))
+	return m, repo
+}
@@ -0,0 +1,116 @@
+package tenant
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrPlanPriceNotFound is returned, wrapped, when a plan has no price in the
+// requested currency
+var ErrPlanPriceNotFound = errors.New("plan price not found")
+
+// validCurrency matches an ISO 4217 currency code such as "USD"
+var validCurrency = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// zeroDecimalCurrencies have no minor unit, so amounts are whole units
+var zeroDecimalCurrencies = map[string]bool{
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "ISK": true, "JPY": true,
+	"KMF": true, "KRW": true, "PYG": true, "RWF": true, "UGX": true, "VND": true,
+	"VUV": true, "XAF": true, "XOF": true, "XPF": true,
+}
+
+// Money is an amount in a currency. Amount is in the currency's minor unit,
+// e.g. cents for USD, so that prices add up without rounding errors.
+type Money struct {
+	Amount   int64  `json:"amount"`   // minor units, e.g. 2900 for USD 29.00
+	Currency string `json:"currency"` // ISO 4217 code, e.g. "USD"
+}
+
+// String formats the amount in major units, e.g. "USD 29.00"
+func (m Money) String() string {
+	if zeroDecimalCurrencies[m.Currency] {
+		return fmt.Sprintf("%s %d", m.Currency, m.Amount)
+	}
+
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s %s%d.%02d", m.Currency, sign, amount/100, amount%100)
+}
+
+// Add returns the sum of two amounts in the same currency
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("cannot add %s to %s", other.Currency, m.Currency)
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// Multiply returns the amount times quantity, e.g. a per-unit overage price
+// times the units over the limit
+func (m Money) Multiply(quantity int64) Money {
+	return Money{Amount: m.Amount * quantity, Currency: m.Currency}
+}
+
+// Validate checks that the currency is an ISO 4217 code and the amount is
+// not negative
+func (m Money) Validate() error {
+	if !validCurrency.MatchString(m.Currency) {
+		return fmt.Errorf("currency %q is not an ISO 4217 code", m.Currency)
+	}
+	if m.Amount < 0 {
+		return fmt.Errorf("amount %d is negative", m.Amount)
+	}
+	return nil
+}
+
+// validatePrices checks the template's prices and that no currency is priced twice
+func (pt PlanTemplate) validatePrices() error {
+	prices := pt.LocalPrices
+	if pt.Price != nil {
+		prices = append([]Money{*pt.Price}, prices...)
+	}
+
+	seen := make(map[string]bool, len(prices))
+	for _, price := range prices {
+		if err := price.Validate(); err != nil {
+			return fmt.Errorf("invalid price: %w", err)
+		}
+		if seen[price.Currency] {
+			return fmt.Errorf("price in %s is set more than once", price.Currency)
+		}
+		seen[price.Currency] = true
+	}
+	return nil
+}
+
+// GetPlanPrice returns the plan's price from its template's Price
+func (m *manager) GetPlanPrice(plan string) (Money, error) {
+	template, exists := m.config.PlanTemplates[plan]
+	if !exists || template.Price == nil {
+		return Money{}, fmt.Errorf("%w: plan %s", ErrPlanPriceNotFound, plan)
+	}
+	return *template.Price, nil
+}
+
+// GetPlanPriceIn returns the plan's price in currency, from its template's
+// Price or LocalPrices
+func (m *manager) GetPlanPriceIn(plan, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+
+	template := m.config.PlanTemplates[plan]
+	if template.Price != nil && template.Price.Currency == currency {
+		return *template.Price, nil
+	}
+	for _, price := range template.LocalPrices {
+		if price.Currency == currency {
+			return price, nil
+		}
+	}
+	return Money{}, fmt.Errorf("%w: plan %s in %s", ErrPlanPriceNotFound, plan, currency)
+}
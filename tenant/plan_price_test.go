@@ -0,0 +1,110 @@
+package tenant
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMoney(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{Money{Amount: 2900, Currency: "USD"}, "USD 29.00"},
+		{Money{Amount: 9905, Currency: "EUR"}, "EUR 99.05"},
+		{Money{Amount: -150, Currency: "GBP"}, "GBP -1.50"},
+		{Money{Amount: 3200, Currency: "JPY"}, "JPY 3200"},
+	}
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+
+	base := Money{Amount: 9900, Currency: "USD"}
+	overage := Money{Amount: 25, Currency: "USD"}.Multiply(400)
+	if overage.Amount != 10000 {
+		t.Errorf("Multiply() = %d, want 10000", overage.Amount)
+	}
+	total, err := base.Add(overage)
+	if err != nil || total != (Money{Amount: 19900, Currency: "USD"}) {
+		t.Errorf("Add() = %v, %v; want USD 199.00", total, err)
+	}
+	if _, err := base.Add(Money{Amount: 100, Currency: "EUR"}); err == nil {
+		t.Error("Add() should reject mixed currencies")
+	}
+
+	if err := (Money{Amount: 100, Currency: "usd"}).Validate(); err == nil {
+		t.Error("Validate() should reject a lowercase currency")
+	}
+	if err := (Money{Amount: -1, Currency: "USD"}).Validate(); err == nil {
+		t.Error("Validate() should reject a negative amount")
+	}
+}
+
+func TestManager_GetPlanPrice(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	config := DefaultConfig()
+	config.PlanTemplates = map[string]PlanTemplate{
+		PlanBasic: {Price: &Money{Amount: 2900, Currency: "USD"}},
+		PlanPro: {
+			Price:       &Money{Amount: 9900, Currency: "USD"},
+			LocalPrices: []Money{{Amount: 8900, Currency: "EUR"}, {Amount: 14000, Currency: "JPY"}},
+		},
+		PlanEnterprise: {Metadata: TenantMetadata{"support": "dedicated"}},
+	}
+
+	manager := NewManager(config, (*sql.DB)(nil), NewMockRepository(), NewMockSchemaManager(""), NewMockMigrationManager(), NewMockLimitChecker(config.Limits), logger)
+
+	price, err := manager.GetPlanPrice(PlanPro)
+	if err != nil || price != (Money{Amount: 9900, Currency: "USD"}) {
+		t.Errorf("GetPlanPrice(pro) = %v, %v; want USD 99.00", price, err)
+	}
+
+	price, err = manager.GetPlanPriceIn(PlanPro, "eur")
+	if err != nil || price != (Money{Amount: 8900, Currency: "EUR"}) {
+		t.Errorf("GetPlanPriceIn(pro, eur) = %v, %v; want EUR 89.00", price, err)
+	}
+	price, err = manager.GetPlanPriceIn(PlanBasic, "USD")
+	if err != nil || price.Amount != 2900 {
+		t.Errorf("GetPlanPriceIn(basic, USD) = %v, %v; want the primary price", price, err)
+	}
+
+	for _, plan := range []string{PlanEnterprise, "custom"} {
+		if _, err := manager.GetPlanPrice(plan); !errors.Is(err, ErrPlanPriceNotFound) {
+			t.Errorf("GetPlanPrice(%s) error = %v, want ErrPlanPriceNotFound", plan, err)
+		}
+	}
+	if _, err := manager.GetPlanPriceIn(PlanBasic, "EUR"); !errors.Is(err, ErrPlanPriceNotFound) {
+		t.Errorf("GetPlanPriceIn(basic, EUR) error = %v, want ErrPlanPriceNotFound", err)
+	}
+}
+
+func TestConfig_Validate_PlanPrices(t *testing.T) {
+	config := DefaultConfig()
+	config.Resolver.Domain = "example.com"
+
+	config.PlanTemplates = map[string]PlanTemplate{
+		PlanPro: {Price: &Money{Amount: 9900, Currency: "USD"}, LocalPrices: []Money{{Amount: 8900, Currency: "EUR"}}},
+	}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+
+	config.PlanTemplates[PlanPro] = PlanTemplate{Price: &Money{Amount: 9900, Currency: "dollars"}}
+	var validationErr *ValidationError
+	if err := config.Validate(); !errors.As(err, &validationErr) || validationErr.Field != "plan_templates.pro" {
+		t.Errorf("Validate() error = %v, want a ValidationError for plan_templates.pro", err)
+	}
+
+	config.PlanTemplates[PlanPro] = PlanTemplate{
+		Price:       &Money{Amount: 9900, Currency: "USD"},
+		LocalPrices: []Money{{Amount: 9800, Currency: "USD"}},
+	}
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() should reject two prices in the same currency")
+	}
+}
@@ -0,0 +1,80 @@
+package tenant
+
+import "errors"
+
+// MetadataLimitOverridesKey is the reserved metadata key under which
+// per-tenant limit overrides are stored. Only plan templates and
+// ExtensibleRepository.SetTenantLimitOverride write it; metadata updates keep
+// the stored value.
+const MetadataLimitOverridesKey = "limit_overrides"
+
+// ErrReservedMetadataKey is wrapped in the InvalidMetadataError returned for
+// writes of a single metadata field under MetadataLimitOverridesKey
+var ErrReservedMetadataKey = errors.New("reserved for limit overrides, use SetTenantLimitOverride")
+
+// PlanTemplate holds the defaults applied to tenants created on a plan and
+// the plan's price
+type PlanTemplate struct {
+	// Metadata holds default metadata fields, e.g. timezone or feature flags
+	Metadata TenantMetadata `json:"metadata,omitempty"`
+	// LimitOverrides holds default per-tenant limit overrides, stored under
+	// MetadataLimitOverridesKey
+	LimitOverrides FlexibleLimits `json:"limit_overrides,omitempty"`
+	// Price is the plan's list price in its primary currency
+	Price *Money `json:"price,omitempty"`
+	// LocalPrices holds the plan's price in other currencies
+	LocalPrices []Money `json:"local_prices,omitempty"`
+}
+
+// Apply merges the template defaults into metadata and returns the result.
+// Values already present in metadata win over the template, including
+// individual limit overrides.
+func (pt PlanTemplate) Apply(metadata TenantMetadata) TenantMetadata {
+	if metadata == nil {
+		metadata = make(TenantMetadata)
+	}
+
+	for key, value := range pt.Metadata {
+		if _, exists := metadata[key]; !exists {
+			metadata[key] = value
+		}
+	}
+
+	if len(pt.LimitOverrides) == 0 {
+		return metadata
+	}
+
+	existing, exists := metadata[MetadataLimitOverridesKey]
+	if !exists {
+		overrides := make(FlexibleLimits, len(pt.LimitOverrides))
+		for name, value := range pt.LimitOverrides {
+			overrides[name] = value
+		}
+		metadata[MetadataLimitOverridesKey] = overrides
+		return metadata
+	}
+
+	// Explicit overrides of another shape are left untouched
+	overrides, ok := existing.(FlexibleLimits)
+	if !ok {
+		return metadata
+	}
+
+	for name, value := range pt.LimitOverrides {
+		if _, exists := overrides[name]; !exists {
+			overrides[name] = value
+		}
+	}
+
+	return metadata
+}
+
+// ApplyPlanTemplate applies the template for the tenant's plan, if any, to its metadata
+func ApplyPlanTemplate(templates map[string]PlanTemplate, tenant *ExtensibleTenant) {
+	template, exists := templates[tenant.PlanType]
+	if !exists {
+		return
+	}
+
+	tenant.Metadata = template.Apply(tenant.Metadata)
+}
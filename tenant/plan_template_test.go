@@ -0,0 +1,239 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestPlanTemplate_Apply(t *testing.T) {
+	template := PlanTemplate{
+		Metadata: TenantMetadata{
+			"timezone":            "UTC",
+			"enterprise_features": true,
+		},
+		LimitOverrides: FlexibleLimits{
+			"max_users":    IntLimit(500),
+			"max_projects": IntLimit(200),
+		},
+	}
+
+	// Explicit values win over template defaults
+	metadata := template.Apply(TenantMetadata{
+		"timezone": "Europe/Madrid",
+		MetadataLimitOverridesKey: FlexibleLimits{
+			"max_users": IntLimit(1000),
+		},
+	})
+
+	if tz, _ := metadata.GetString("timezone"); tz != "Europe/Madrid" {
+		t.Errorf("timezone = %v, want explicit Europe/Madrid", tz)
+	}
+	if enabled, _ := metadata.GetBool("enterprise_features"); !enabled {
+		t.Error("enterprise_features should default to true")
+	}
+
+	overrides, ok := metadata[MetadataLimitOverridesKey].(FlexibleLimits)
+	if !ok {
+		t.Fatalf("limit overrides type = %T, want FlexibleLimits", metadata[MetadataLimitOverridesKey])
+	}
+	if val, _ := overrides.GetInt("max_users"); val != 1000 {
+		t.Errorf("max_users override = %v, want explicit 1000", val)
+	}
+	if val, _ := overrides.GetInt("max_projects"); val != 200 {
+		t.Errorf("max_projects override = %v, want template 200", val)
+	}
+
+	// Nil metadata receives all defaults
+	metadata = template.Apply(nil)
+	if len(metadata) != 3 {
+		t.Errorf("Apply(nil) returned %d fields, want 3", len(metadata))
+	}
+
+	// Template overrides are copied, not shared
+	metadata[MetadataLimitOverridesKey].(FlexibleLimits).Delete("max_users")
+	if !template.LimitOverrides.Has("max_users") {
+		t.Error("Apply() should not share the template's limit overrides")
+	}
+}
+
+func TestApplyPlanTemplate(t *testing.T) {
+	templates := map[string]PlanTemplate{
+		PlanEnterprise: {Metadata: TenantMetadata{"enterprise_features": true}},
+	}
+
+	enterprise := &ExtensibleTenant{PlanType: PlanEnterprise}
+	ApplyPlanTemplate(templates, enterprise)
+	if enabled, _ := enterprise.Metadata.GetBool("enterprise_features"); !enabled {
+		t.Error("enterprise tenant should get enterprise_features=true")
+	}
+
+	basic := &ExtensibleTenant{PlanType: PlanBasic}
+	ApplyPlanTemplate(templates, basic)
+	if basic.Metadata != nil {
+		t.Errorf("basic tenant metadata = %v, want untouched", basic.Metadata)
+	}
+}
+
+func TestManager_CreateTenant_AppliesPlanTemplate(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	config := DefaultConfig()
+	config.PlanTemplates = map[string]PlanTemplate{
+		PlanEnterprise: {
+			Metadata:       TenantMetadata{"enterprise_features": true},
+			LimitOverrides: FlexibleLimits{"max_users": IntLimit(1000)},
+		},
+	}
+
+	repo := &mockExtensibleRepository{
+		MockManagerRepository: NewMockRepository(),
+		metadata:              make(map[uuid.UUID]TenantMetadata),
+	}
+	manager := NewManager(config, (*sql.DB)(nil), repo, NewMockSchemaManager(config.Database.SchemaPrefix),
+		NewMockMigrationManager(), NewMockLimitChecker(config.Limits), logger)
+
+	enterprise := &Tenant{Name: "Globex", Subdomain: "globex", PlanType: PlanEnterprise}
+	if err := manager.CreateTenant(context.Background(), enterprise); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+
+	metadata := repo.metadata[enterprise.ID]
+	if enabled, _ := metadata.GetBool("enterprise_features"); !enabled {
+		t.Error("enterprise tenant should be created with enterprise_features=true")
+	}
+	if overrides, ok := metadata[MetadataLimitOverridesKey].(FlexibleLimits); !ok || !overrides.Has("max_users") {
+		t.Error("enterprise tenant should be created with the template limit overrides")
+	}
+	if enterprise.CreatedAt.IsZero() {
+		t.Error("CreateTenant() should set CreatedAt")
+	}
+
+	// Plans without a template use the base repository
+	basic := &Tenant{Name: "Acme", Subdomain: "acme", PlanType: PlanBasic}
+	if err := manager.CreateTenant(context.Background(), basic); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+	if _, exists := repo.metadata[basic.ID]; exists {
+		t.Error("basic tenant should not be created with template metadata")
+	}
+	if _, err := manager.GetTenant(context.Background(), basic.ID); err != nil {
+		t.Errorf("GetTenant() error = %v", err)
+	}
+}
+
+func TestNewManager_PlanTemplatesWithoutMetadata(t *testing.T) {
+	config := DefaultConfig()
+	config.PlanTemplates = map[string]PlanTemplate{PlanEnterprise: {Metadata: TenantMetadata{"enterprise_features": true}}}
+
+	core, logs := observer.New(zap.WarnLevel)
+	NewManager(config, (*sql.DB)(nil), NewMockRepository(), NewMockSchemaManager(config.Database.SchemaPrefix),
+		NewMockMigrationManager(), NewMockLimitChecker(config.Limits), zap.New(core))
+
+	if logs.FilterMessageSnippet("Plan templates are ignored").Len() != 1 {
+		t.Errorf("NewManager() logged %v, want a warning that the plan templates are ignored", logs.All())
+	}
+}
+
+// mockExtensibleRepository adds metadata-aware creation to the manager mock repository
+type mockExtensibleRepository struct {
+	*MockManagerRepository
+	metadata map[uuid.UUID]TenantMetadata
+}
+
+func (m *mockExtensibleRepository) CreateExtended(ctx context.Context, t *ExtensibleTenant) error {
+	base := &Tenant{
+		ID:         t.ID,
+		Name:       t.Name,
+		Subdomain:  t.Subdomain,
+		PlanType:   t.PlanType,
+		Status:     t.Status,
+		SchemaName: t.SchemaName,
+	}
+	if err := m.Create(ctx, base); err != nil {
+		return err
+	}
+	t.CreatedAt = base.CreatedAt
+	t.UpdatedAt = base.UpdatedAt
+	m.metadata[t.ID] = t.Metadata
+	return nil
+}
+
+func (m *mockExtensibleRepository) GetExtendedByID(ctx context.Context, id uuid.UUID) (*ExtensibleTenant, error) {
+	return nil, &TenantError{TenantID: id, Code: "NOT_IMPLEMENTED", Message: "not implemented"}
+}
+
+func (m *mockExtensibleRepository) GetExtendedBySubdomain(ctx context.Context, subdomain string) (*ExtensibleTenant, error) {
+	return nil, &TenantError{Code: "NOT_IMPLEMENTED", Message: "not implemented"}
+}
+
+func (m *mockExtensibleRepository) UpdateExtended(ctx context.Context, t *ExtensibleTenant) error {
+	return nil
+}
+
+func (m *mockExtensibleRepository) ListExtended(ctx context.Context, page, perPage int) ([]*ExtensibleTenant, int, error) {
+	return nil, 0, nil
+}
+
+func (m *mockExtensibleRepository) UpdateMetadata(ctx context.Context, tenantID uuid.UUID, metadata TenantMetadata) error {
+	m.metadata[tenantID] = metadata
+	return nil
+}
+
+func (m *mockExtensibleRepository) GetMetadata(ctx context.Context, tenantID uuid.UUID) (TenantMetadata, error) {
+	return m.metadata[tenantID], nil
+}
+
+func (m *mockExtensibleRepository) UpdateMetadataField(ctx context.Context, tenantID uuid.UUID, key string, value interface{}) error {
+	return nil
+}
+
+func (m *mockExtensibleRepository) RemoveMetadataField(ctx context.Context, tenantID uuid.UUID, key string) error {
+	return nil
+}
+
+func (m *mockExtensibleRepository) SetTenantLimitOverride(ctx context.Context, tenantID uuid.UUID, limitName string, value *LimitValue) error {
+	if m.metadata[tenantID] == nil {
+		m.metadata[tenantID] = make(TenantMetadata)
+	}
+	overrides, _ := m.metadata[tenantID][MetadataLimitOverridesKey].(FlexibleLimits)
+	if overrides == nil {
+		overrides = make(FlexibleLimits)
+	}
+	if value == nil {
+		delete(overrides, limitName)
+	} else {
+		overrides[limitName] = value
+	}
+	m.metadata[tenantID][MetadataLimitOverridesKey] = overrides
+	return nil
+}
+
+func (m *mockExtensibleRepository) GetTenantLimitOverrides(ctx context.Context, tenantID uuid.UUID) (FlexibleLimits, error) {
+	overrides, _ := m.metadata[tenantID][MetadataLimitOverridesKey].(FlexibleLimits)
+	return overrides, nil
+}
+
+func (m *mockExtensibleRepository) FindByMetadata(ctx context.Context, key string, value interface{}) ([]*ExtensibleTenant, error) {
+	var tenants []*ExtensibleTenant
+	for id, metadata := range m.metadata {
+		if v, ok := metadata[key]; ok && fmt.Sprintf("%v", v) == fmt.Sprintf("%v", value) {
+			t := &ExtensibleTenant{ID: id}
+			if base, exists := m.tenants[id]; exists {
+				t = FromBaseTenant(base)
+			}
+			t.Metadata = metadata
+			tenants = append(tenants, t)
+		}
+	}
+	return tenants, nil
+}
+
+func (m *mockExtensibleRepository) FindByMetadataKeys(ctx context.Context, keys []string) ([]*ExtensibleTenant, error) {
+	return nil, nil
+}
@@ -0,0 +1,87 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// LimitViolation is a limit of a plan that a tenant's current usage exceeds
+type LimitViolation struct {
+	Limit   string      `json:"limit"`
+	Value   interface{} `json:"value"`   // the limit in the target plan
+	Current interface{} `json:"current"` // the tenant's current usage
+}
+
+// PlanChangeError is returned by ChangePlan when LimitsConfig.RejectOverLimitPlanChanges
+// is set and the tenant's usage exceeds limits of the plan it would move to
+type PlanChangeError struct {
+	TenantID   uuid.UUID        `json:"tenant_id"`
+	OldPlan    string           `json:"old_plan"`
+	NewPlan    string           `json:"new_plan"`
+	Violations []LimitViolation `json:"violations"`
+}
+
+// Error implements the error interface
+func (e *PlanChangeError) Error() string {
+	limits := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		limits[i] = fmt.Sprintf("%s (current=%v, limit=%v)", v.Limit, v.Current, v.Value)
+	}
+	return fmt.Sprintf("tenant %s cannot move from plan %s to %s: usage exceeds %s",
+		e.TenantID, e.OldPlan, e.NewPlan, strings.Join(limits, ", "))
+}
+
+// ValidatePlanChange compares the tenant's current usage with the numeric
+// limits of newPlan and returns the limits that usage exceeds, sorted by name,
+// so that a downgrade does not leave a tenant over its limits. Usage comes from
+// the usage tracker or, without one, from freshly counted stats for the
+// limits they count. Per-tenant limit overrides are not applied. Unlimited limits and
+// limits whose usage is unknown are not reported.
+func (m *manager) ValidatePlanChange(ctx context.Context, tenantID uuid.UUID, newPlan string) ([]LimitViolation, error) {
+	planLimits := m.limitChecker.GetLimitsForPlan(newPlan)
+	if planLimits == nil {
+		return nil, &ValidationError{Field: "plan_type", Message: fmt.Sprintf("unknown plan type %q", newPlan)}
+	}
+
+	tracker := m.limitChecker.GetUsageTracker()
+	var stats *Stats
+	if tracker == nil {
+		var err error
+		if stats, err = m.GetStatsFresh(ctx, tenantID); err != nil {
+			return nil, fmt.Errorf("failed to get tenant stats: %w", err)
+		}
+	}
+
+	var violations []LimitViolation
+	for name, limit := range planLimits {
+		if (limit.Type != LimitTypeInt && limit.Type != LimitTypeFloat) || limit.IsUnlimited() {
+			continue
+		}
+		limitValue, ok := usageFloat(limit.Value)
+		if !ok {
+			continue
+		}
+
+		var current interface{}
+		if tracker != nil {
+			usage, err := tracker.GetCurrentUsage(ctx, tenantID, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get current usage of %s: %w", name, err)
+			}
+			current = usage
+		} else if current, ok = usageFromStats(stats, name); !ok {
+			continue
+		}
+
+		if usage, ok := usageFloat(current); ok && usage > limitValue {
+			violations = append(violations, LimitViolation{Limit: name, Value: limit.Value, Current: current})
+		}
+	}
+
+	sort.Slice(violations, func(i, j int) bool { return violations[i].Limit < violations[j].Limit })
+	return violations, nil
+}
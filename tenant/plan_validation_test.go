@@ -0,0 +1,96 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func newPlanValidationTestManager(t *testing.T, reject bool) (*manager, *counterUsageTracker) {
+	m := newTestManager(t, withRealLimitChecker(), withConfig(func(config *Config) {
+		config.Limits.RejectOverLimitPlanChanges = reject
+	}))
+	tracker := newCounterUsageTracker()
+	m.limitChecker.SetUsageTracker(tracker)
+
+	return m, tracker
+}
+
+func TestManager_ValidatePlanChange_CleanDowngrade(t *testing.T) {
+	m, tracker := newPlanValidationTestManager(t, true)
+	ctx := context.Background()
+	tenantID := createActiveTestTenant(t, m)
+	if err := m.ChangePlan(ctx, tenantID, PlanPro, "billing"); err != nil {
+		t.Fatalf("ChangePlan() to pro error = %v", err)
+	}
+
+	// Within every basic limit
+	tracker.IncrementUsage(ctx, tenantID, LimitNameMaxUsers, 5)
+	tracker.IncrementUsage(ctx, tenantID, LimitNameMaxProjects, 3)
+
+	violations, err := m.ValidatePlanChange(ctx, tenantID, PlanBasic)
+	if err != nil {
+		t.Fatalf("ValidatePlanChange() error = %v", err)
+	}
+	if len(violations) != 0 {
+		t.Errorf("ValidatePlanChange() = %+v, want no violations", violations)
+	}
+
+	if err := m.ChangePlan(ctx, tenantID, PlanBasic, "billing"); err != nil {
+		t.Fatalf("ChangePlan() to basic error = %v", err)
+	}
+	if tenant, _ := m.GetTenant(ctx, tenantID); tenant.PlanType != PlanBasic {
+		t.Errorf("plan = %s, want %s", tenant.PlanType, PlanBasic)
+	}
+}
+
+func TestManager_ValidatePlanChange_BlockedByExcessUsers(t *testing.T) {
+	m, tracker := newPlanValidationTestManager(t, true)
+	ctx := context.Background()
+	tenantID := createActiveTestTenant(t, m)
+	if err := m.ChangePlan(ctx, tenantID, PlanPro, "billing"); err != nil {
+		t.Fatalf("ChangePlan() to pro error = %v", err)
+	}
+
+	tracker.IncrementUsage(ctx, tenantID, LimitNameMaxUsers, 12)
+
+	violations, err := m.ValidatePlanChange(ctx, tenantID, PlanBasic)
+	if err != nil {
+		t.Fatalf("ValidatePlanChange() error = %v", err)
+	}
+	if len(violations) != 1 || violations[0].Limit != LimitNameMaxUsers || violations[0].Value != 5 || violations[0].Current != 12 {
+		t.Fatalf("ValidatePlanChange() = %+v, want max_users at 12 over 5", violations)
+	}
+
+	err = m.ChangePlan(ctx, tenantID, PlanBasic, "billing")
+	var changeErr *PlanChangeError
+	if !errors.As(err, &changeErr) {
+		t.Fatalf("ChangePlan() error = %v, want PlanChangeError", err)
+	}
+	if changeErr.OldPlan != PlanPro || changeErr.NewPlan != PlanBasic || len(changeErr.Violations) != 1 {
+		t.Errorf("ChangePlan() error = %+v, want the max_users violation moving from pro to basic", changeErr)
+	}
+	if tenant, _ := m.GetTenant(ctx, tenantID); tenant.PlanType != PlanPro {
+		t.Errorf("plan = %s, want %s after a refused downgrade", tenant.PlanType, PlanPro)
+	}
+	if oldPlan, err := m.ChangeTenantPlan(ctx, tenantID, PlanBasic); oldPlan != "" || !errors.As(err, &changeErr) {
+		t.Errorf("ChangeTenantPlan() = %q, %v, want no plan and a PlanChangeError", oldPlan, err)
+	}
+
+	// Without the option the downgrade goes through
+	m.config.Limits.RejectOverLimitPlanChanges = false
+	if err := m.ChangePlan(ctx, tenantID, PlanBasic, "billing"); err != nil {
+		t.Errorf("ChangePlan() without RejectOverLimitPlanChanges error = %v", err)
+	}
+}
+
+func TestManager_ValidatePlanChange_UnknownPlan(t *testing.T) {
+	m, _ := newPlanValidationTestManager(t, true)
+	tenantID := createActiveTestTenant(t, m)
+
+	_, err := m.ValidatePlanChange(context.Background(), tenantID, "platinum")
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "plan_type" {
+		t.Errorf("ValidatePlanChange() error = %v, want a plan_type ValidationError", err)
+	}
+}
@@ -0,0 +1,100 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// TenantProfile is everything a frontend needs about a tenant in one
+// serializable value. It carries only public fields: the schema name and
+// metadata other than branding, such as billing IDs and API keys, are left out.
+type TenantProfile struct {
+	ID        uuid.UUID              `json:"id"`
+	Name      string                 `json:"name"`
+	Subdomain string                 `json:"subdomain"`
+	PlanType  string                 `json:"plan_type"`
+	Status    string                 `json:"status"`
+	CreatedAt time.Time              `json:"created_at"`
+	Limits    FlexibleLimits         `json:"limits"`             // effective limits for the tenant's plan
+	Features  []string               `json:"features"`           // bool limits enabled for the plan, sorted
+	Branding  *TenantBranding        `json:"branding,omitempty"` // nil unless the repository stores metadata
+	Usage     map[string]interface{} `json:"usage,omitempty"`    // current usage by limit name, from the usage tracker
+}
+
+// TenantBranding holds the branding fields of a tenant's metadata
+type TenantBranding struct {
+	LogoURL      string `json:"logo_url,omitempty"`
+	Theme        string `json:"theme,omitempty"`
+	CustomDomain string `json:"custom_domain,omitempty"`
+}
+
+// GetTenantProfile returns the tenant's public fields together with its
+// effective limits, enabled features, branding and current usage. Branding is
+// included when the repository is an ExtensibleRepository and usage when a
+// usage tracker is set; limits whose usage cannot be read are left out.
+func (m *manager) GetTenantProfile(ctx context.Context, tenantID uuid.UUID) (*TenantProfile, error) {
+	tenant, err := m.cachedTenant(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	profile := &TenantProfile{
+		ID:        tenant.ID,
+		Name:      tenant.Name,
+		Subdomain: tenant.Subdomain,
+		PlanType:  tenant.PlanType,
+		Status:    tenant.Status,
+		CreatedAt: tenant.CreatedAt,
+		Limits:    make(FlexibleLimits),
+		Features:  []string{},
+	}
+
+	tracker := m.limitChecker.GetUsageTracker()
+	for name, limit := range m.limitChecker.GetLimitsForPlan(tenant.PlanType) {
+		profile.Limits[name] = &LimitValue{Type: limit.Type, Value: limit.Value}
+
+		if limit.Type == LimitTypeBool {
+			if enabled, err := limit.Bool(); err == nil && enabled {
+				profile.Features = append(profile.Features, name)
+			}
+			continue
+		}
+
+		if tracker == nil {
+			continue
+		}
+		usage, err := tracker.GetCurrentUsage(ctx, tenantID, name)
+		if err != nil {
+			m.logger.Debug("Failed to get current usage for tenant profile",
+				zap.String("tenant_id", tenantID.String()),
+				zap.String("limit", name),
+				zap.Error(err))
+			continue
+		}
+		if profile.Usage == nil {
+			profile.Usage = make(map[string]interface{})
+		}
+		profile.Usage[name] = usage
+	}
+	sort.Strings(profile.Features)
+
+	if extRepo, ok := m.repository.(ExtensibleRepository); ok {
+		metadata, err := extRepo.GetMetadata(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tenant metadata: %w", err)
+		}
+
+		branding := NewBrandingExtension(metadata)
+		profile.Branding = &TenantBranding{}
+		profile.Branding.LogoURL, _ = branding.GetLogoURL()
+		profile.Branding.Theme, _ = branding.GetTheme()
+		profile.Branding.CustomDomain, _ = branding.GetCustomDomain()
+	}
+
+	return profile, nil
+}
@@ -0,0 +1,111 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestManager_GetTenantProfile(t *testing.T) {
+	repo := &mockExtensibleRepository{
+		MockManagerRepository: NewMockRepository(),
+		metadata:              make(map[uuid.UUID]TenantMetadata),
+	}
+	config := DefaultConfig()
+	config.Limits.EnforceLimits = true
+
+	planLimits := make(FlexibleLimits)
+	planLimits.Set("max_projects", LimitTypeInt, 10)
+	planLimits.Set("api_access", LimitTypeBool, true)
+	planLimits.Set("advanced_features", LimitTypeBool, false)
+	planLimits.Set("custom_branding", LimitTypeBool, true)
+	config.Limits.PlanLimits = map[string]FlexibleLimits{PlanPro: planLimits}
+
+	logger := zaptest.NewLogger(t)
+	limitChecker := NewLimitChecker(config.Limits, repo, logger)
+	limitChecker.SetUsageTracker(usageTrackerFunc(func(limitName string) (interface{}, error) {
+		if limitName == "max_projects" {
+			return 4, nil
+		}
+		return nil, errors.New("usage not tracked")
+	}))
+
+	m := NewManager(config, nil, repo, NewMockSchemaManager(config.Database.SchemaPrefix),
+		NewMockMigrationManager(), limitChecker, logger).(*manager)
+	t.Cleanup(func() { m.Close() })
+
+	tenant := &Tenant{Name: "Acme Corp", Subdomain: "acme", PlanType: PlanPro, Status: StatusActive}
+	if err := m.CreateTenant(context.Background(), tenant); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+	repo.metadata[tenant.ID] = TenantMetadata{
+		MetadataLogoURL:              "https://acme.com/logo.png",
+		MetadataTheme:                "blue",
+		MetadataCustomDomain:         "app.acme.com",
+		MetadataStripeCustomerID:     "cus_secret",
+		MetadataAPIKey:               "sk_live_secret",
+		MetadataCustomDomainToken:    "0123456789abcdef",
+		MetadataStripeSubscriptionID: "sub_secret",
+	}
+
+	profile, err := m.GetTenantProfile(context.Background(), tenant.ID)
+	if err != nil {
+		t.Fatalf("GetTenantProfile() error = %v", err)
+	}
+
+	if profile.ID != tenant.ID || profile.Subdomain != "acme" || profile.PlanType != PlanPro || profile.Status != StatusActive {
+		t.Errorf("profile = %+v, want the tenant's public fields", profile)
+	}
+	if max, err := profile.Limits.GetInt("max_projects"); err != nil || max != 10 {
+		t.Errorf("max_projects limit = %d, %v, want 10", max, err)
+	}
+	if got := strings.Join(profile.Features, ","); got != "api_access,custom_branding" {
+		t.Errorf("Features = %q, want the enabled bool limits", got)
+	}
+	if profile.Usage["max_projects"] != 4 || len(profile.Usage) != 1 {
+		t.Errorf("Usage = %v, want only max_projects at 4", profile.Usage)
+	}
+	want := TenantBranding{LogoURL: "https://acme.com/logo.png", Theme: "blue", CustomDomain: "app.acme.com"}
+	if profile.Branding == nil || *profile.Branding != want {
+		t.Errorf("Branding = %+v, want %+v", profile.Branding, want)
+	}
+
+	data, err := json.Marshal(profile)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	for _, private := range []string{"schema_name", tenant.SchemaName, "cus_secret", "sk_live_secret", "0123456789abcdef", "sub_secret"} {
+		if strings.Contains(string(data), private) {
+			t.Errorf("profile JSON %s contains private value %q", data, private)
+		}
+	}
+}
+
+func TestManager_GetTenantProfile_BaseRepository(t *testing.T) {
+	config := DefaultConfig()
+	m := NewManager(config, nil, NewMockRepository(), NewMockSchemaManager(config.Database.SchemaPrefix),
+		NewMockMigrationManager(), NewMockLimitChecker(config.Limits), zaptest.NewLogger(t)).(*manager)
+	t.Cleanup(func() { m.Close() })
+
+	tenant := &Tenant{Name: "Acme Corp", Subdomain: "acme", PlanType: PlanBasic, Status: StatusActive}
+	if err := m.CreateTenant(context.Background(), tenant); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+
+	profile, err := m.GetTenantProfile(context.Background(), tenant.ID)
+	if err != nil {
+		t.Fatalf("GetTenantProfile() error = %v", err)
+	}
+	if profile.Branding != nil || profile.Usage != nil {
+		t.Errorf("profile = %+v, want no branding or usage without metadata or a usage tracker", profile)
+	}
+
+	if _, err := m.GetTenantProfile(context.Background(), uuid.New()); err == nil {
+		t.Error("GetTenantProfile() should fail for an unknown tenant")
+	}
+}
@@ -0,0 +1,295 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Provisioning job statuses
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// DefaultProvisioningWorkers is the number of workers NewInProcessQueue starts
+// when workers is not positive
+const DefaultProvisioningWorkers = 4
+
+// ErrJobNotFound is returned when no provisioning job exists for a tenant
+var ErrJobNotFound = errors.New("provisioning job not found")
+
+// ErrQueueClosed is returned when enqueueing to a closed provisioning queue
+var ErrQueueClosed = errors.New("provisioning queue is closed")
+
+// ErrProvisioningNotQueued is returned, wrapping the queue's error, when
+// CreateTenant stored a pending tenant but could not enqueue its provisioning.
+// The tenant passed to CreateTenant holds the stored record, so retrying the
+// enqueue does not need a new tenant.
+var ErrProvisioningNotQueued = errors.New("tenant created but provisioning could not be queued")
+
+// ProvisioningJob is the state of a tenant's asynchronous provisioning
+type ProvisioningJob struct {
+	TenantID   uuid.UUID `json:"tenant_id"`
+	Status     string    `json:"status"`   // JobQueued, JobRunning, JobSucceeded or JobFailed
+	Attempts   int       `json:"attempts"` // times the job has started
+	Error      string    `json:"error,omitempty"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+	StartedAt  time.Time `json:"started_at,omitzero"`
+	FinishedAt time.Time `json:"finished_at,omitzero"`
+}
+
+// ProvisionFunc provisions a tenant; queues call it for every job
+type ProvisionFunc func(ctx context.Context, tenantID uuid.UUID) error
+
+// ProvisioningQueue runs tenant provisioning outside the request path. The
+// default is NewInProcessQueue; implementations backed by an external queue
+// deliver jobs to the ProvisionFunc passed to Start and record job status.
+type ProvisioningQueue interface {
+	// Start begins delivering jobs to provision. It is called once, by
+	// Manager.SetProvisioningQueue.
+	Start(provision ProvisionFunc) error
+
+	// Enqueue schedules provisioning of a tenant. Enqueueing a tenant whose job
+	// is queued or running is a no-op; a finished job is run again.
+	Enqueue(ctx context.Context, tenantID uuid.UUID) error
+
+	// Job returns the tenant's latest job, or ErrJobNotFound
+	Job(ctx context.Context, tenantID uuid.UUID) (*ProvisioningJob, error)
+
+	// Close stops delivering jobs
+	Close() error
+}
+
+// ProvisioningHooks are called when an asynchronous provisioning job finishes
+type ProvisioningHooks struct {
+	OnProvisioned func(ctx context.Context, tenant *Tenant)
+	OnFailed      func(ctx context.Context, tenantID uuid.UUID, err error)
+}
+
+// SetProvisioningQueue makes CreateTenant enqueue new tenants for provisioning
+// on queue instead of leaving ProvisionTenant to the caller. New tenants are
+// created with StatusPending and become active once their job succeeds; a
+// failed job leaves the tenant pending so it can be enqueued again. The queue
+// is closed by Close.
+func (m *manager) SetProvisioningQueue(queue ProvisioningQueue, hooks ProvisioningHooks) error {
+	m.provisioningMu.Lock()
+	defer m.provisioningMu.Unlock()
+
+	if m.provisioning != nil {
+		return errors.New("provisioning queue is already set")
+	}
+
+	m.provisioningHooks = hooks
+	if err := queue.Start(m.runProvisioningJob); err != nil {
+		return fmt.Errorf("failed to start provisioning queue: %w", err)
+	}
+	m.provisioning = queue
+
+	return nil
+}
+
+// GetProvisioningJob returns the state of the tenant's provisioning job
+func (m *manager) GetProvisioningJob(ctx context.Context, tenantID uuid.UUID) (*ProvisioningJob, error) {
+	queue := m.provisioningQueue()
+	if queue == nil {
+		return nil, &TenantError{
+			TenantID: tenantID,
+			Code:     "PROVISIONING_QUEUE_UNAVAILABLE",
+			Message:  "no provisioning queue is set",
+		}
+	}
+
+	return queue.Job(ctx, tenantID)
+}
+
+// provisioningQueue returns the provisioning queue, or nil if there is none
+func (m *manager) provisioningQueue() ProvisioningQueue {
+	m.provisioningMu.RLock()
+	defer m.provisioningMu.RUnlock()
+	return m.provisioning
+}
+
+// runProvisioningJob provisions a tenant for the queue and fires the hooks
+func (m *manager) runProvisioningJob(ctx context.Context, tenantID uuid.UUID) error {
+	m.provisioningMu.RLock()
+	hooks := m.provisioningHooks
+	m.provisioningMu.RUnlock()
+
+	err := m.ProvisionTenant(ctx, tenantID)
+	if err == nil {
+		var tenant *Tenant
+		tenant, err = m.repository.GetByID(ctx, tenantID)
+		if err == nil {
+			if hooks.OnProvisioned != nil {
+				hooks.OnProvisioned(ctx, tenant)
+			}
+			return nil
+		}
+		err = fmt.Errorf("failed to get provisioned tenant: %w", err)
+	}
+
+	m.logger.Error("Asynchronous provisioning failed",
+		zap.String("tenant_id", tenantID.String()),
+		zap.Error(err))
+	if hooks.OnFailed != nil {
+		hooks.OnFailed(ctx, tenantID, err)
+	}
+	return err
+}
+
+// inProcessQueue is the default ProvisioningQueue: a pool of goroutines fed by
+// a buffered channel, with job status kept in memory
+type inProcessQueue struct {
+	mu      sync.Mutex
+	jobs    map[uuid.UUID]*ProvisioningJob
+	pending chan uuid.UUID
+	workers int
+	logger  *zap.Logger
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+}
+
+// NewInProcessQueue creates a provisioning queue that runs jobs on workers
+// goroutines in this process. Up to buffer jobs wait without blocking Enqueue.
+// Job status is lost on restart, and Close cancels running jobs and drops
+// queued ones; use an external queue where provisioning must survive restarts.
+func NewInProcessQueue(workers, buffer int, logger *zap.Logger) ProvisioningQueue {
+	if workers <= 0 {
+		workers = DefaultProvisioningWorkers
+	}
+	if buffer < 0 {
+		buffer = 0
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &inProcessQueue{
+		jobs:    make(map[uuid.UUID]*ProvisioningJob),
+		pending: make(chan uuid.UUID, buffer),
+		workers: workers,
+		logger:  logger.Named("provisioning_queue"),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+func (q *inProcessQueue) Start(provision ProvisionFunc) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.started {
+		return errors.New("provisioning queue is already started")
+	}
+	if q.ctx.Err() != nil {
+		return ErrQueueClosed
+	}
+	q.started = true
+
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work(provision)
+	}
+	return nil
+}
+
+func (q *inProcessQueue) Enqueue(ctx context.Context, tenantID uuid.UUID) error {
+	q.mu.Lock()
+	if q.ctx.Err() != nil {
+		q.mu.Unlock()
+		return ErrQueueClosed
+	}
+	job, ok := q.jobs[tenantID]
+	if ok && (job.Status == JobQueued || job.Status == JobRunning) {
+		q.mu.Unlock()
+		return nil
+	}
+	if !ok {
+		job = &ProvisioningJob{TenantID: tenantID}
+		q.jobs[tenantID] = job
+	}
+	job.Status = JobQueued
+	job.Error = ""
+	job.EnqueuedAt = time.Now()
+	job.StartedAt = time.Time{}
+	job.FinishedAt = time.Time{}
+	q.mu.Unlock()
+
+	select {
+	case q.pending <- tenantID:
+		return nil
+	case <-ctx.Done():
+		q.finish(tenantID, ctx.Err())
+		return ctx.Err()
+	case <-q.ctx.Done():
+		q.finish(tenantID, ErrQueueClosed)
+		return ErrQueueClosed
+	}
+}
+
+func (q *inProcessQueue) Job(ctx context.Context, tenantID uuid.UUID) (*ProvisioningJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[tenantID]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	jobCopy := *job
+	return &jobCopy, nil
+}
+
+// Close cancels running jobs and waits for the workers to stop
+func (q *inProcessQueue) Close() error {
+	q.cancel()
+	q.wg.Wait()
+	return nil
+}
+
+// work runs queued jobs until the queue is closed
+func (q *inProcessQueue) work(provision ProvisionFunc) {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case tenantID := <-q.pending:
+			q.mu.Lock()
+			job := q.jobs[tenantID]
+			job.Status = JobRunning
+			job.Attempts++
+			job.StartedAt = time.Now()
+			q.mu.Unlock()
+
+			q.finish(tenantID, provision(q.ctx, tenantID))
+		}
+	}
+}
+
+// finish records the outcome of a tenant's job
+func (q *inProcessQueue) finish(tenantID uuid.UUID, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job := q.jobs[tenantID]
+	job.FinishedAt = time.Now()
+	if err != nil {
+		job.Status = JobFailed
+		job.Error = err.Error()
+		q.logger.Debug("Provisioning job failed",
+			zap.String("tenant_id", tenantID.String()),
+			zap.Int("attempts", job.Attempts),
+			zap.Error(err))
+		return
+	}
+	job.Status = JobSucceeded
+}
@@ -0,0 +1,357 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+// flakySchemaManager fails schema creation while err is set and, if gate is
+// set, holds it until gate is closed
+type flakySchemaManager struct {
+	*MockManagerSchemaManager
+	mu   sync.Mutex
+	err  error
+	gate chan struct{}
+}
+
+func (s *flakySchemaManager) CreateTenantSchema(ctx context.Context, tenantID uuid.UUID, name string) error {
+	if s.gate != nil {
+		<-s.gate
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	return s.MockManagerSchemaManager.CreateTenantSchema(ctx, tenantID, name)
+}
+
+func (s *flakySchemaManager) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// waitForJob polls until the tenant's job has status
+func waitForJob(t *testing.T, m Manager, tenantID uuid.UUID, status string) *ProvisioningJob {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := m.GetProvisioningJob(context.Background(), tenantID)
+		if err == nil && job.Status == status {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job = %+v, %v; want status %s", job, err, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func newProvisioningTestManager(t *testing.T, schemas SchemaManager) (Manager, *MockManagerRepository) {
+	repo := NewMockRepository()
+	return newTestManager(t, withRepository(repo), withSchemaManager(schemas)), repo
+}
+
+func TestManager_AsyncProvisioning(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	schemas := &flakySchemaManager{MockManagerSchemaManager: NewMockSchemaManager(""), gate: make(chan struct{})}
+	m, repo := newProvisioningTestManager(t, schemas)
+
+	provisioned := make(chan *Tenant, 1)
+	err := m.SetProvisioningQueue(NewInProcessQueue(2, 10, logger), ProvisioningHooks{
+		OnProvisioned: func(ctx context.Context, tenant *Tenant) { provisioned <- tenant },
+		OnFailed: func(ctx context.Context, tenantID uuid.UUID, err error) {
+			t.Errorf("OnFailed(%s, %v) called for a successful job", tenantID, err)
+		},
+	})
+	if err != nil {
+		t.Fatalf("SetProvisioningQueue() error = %v", err)
+	}
+
+	tenant := &Tenant{Name: "Acme", Subdomain: "acme", PlanType: PlanBasic, Status: StatusActive}
+	if err := m.CreateTenant(context.Background(), tenant); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+	if tenant.Status != StatusPending {
+		t.Errorf("CreateTenant() status = %s, want %s", tenant.Status, StatusPending)
+	}
+	if job, err := m.GetProvisioningJob(context.Background(), tenant.ID); err != nil || job.Status == JobSucceeded {
+		t.Errorf("GetProvisioningJob() = %+v, %v; want an unfinished job", job, err)
+	}
+	close(schemas.gate)
+
+	select {
+	case got := <-provisioned:
+		if got.ID != tenant.ID || got.Status != StatusActive {
+			t.Errorf("OnProvisioned tenant = %s %s, want %s active", got.ID, got.Status, tenant.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnProvisioned was not called")
+	}
+
+	job := waitForJob(t, m, tenant.ID, JobSucceeded)
+	if job.Attempts != 1 || job.Error != "" || job.FinishedAt.IsZero() {
+		t.Errorf("job = %+v, want one successful attempt", job)
+	}
+	if repo.tenants[tenant.ID].Status != StatusActive {
+		t.Errorf("stored status = %s, want %s", repo.tenants[tenant.ID].Status, StatusActive)
+	}
+	if exists, _ := schemas.SchemaExists(context.Background(), tenant.ID); !exists {
+		t.Error("provisioning should create the tenant schema")
+	}
+
+	if err := m.SetProvisioningQueue(NewInProcessQueue(1, 0, logger), ProvisioningHooks{}); err == nil {
+		t.Error("SetProvisioningQueue() should fail when a queue is already set")
+	}
+}
+
+func TestManager_AsyncProvisioning_Failure(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	schemas := &flakySchemaManager{
+		MockManagerSchemaManager: NewMockSchemaManager(""),
+		err:                      errors.New("permission denied to create schema"),
+	}
+	m, repo := newProvisioningTestManager(t, schemas)
+
+	failed := make(chan error, 1)
+	queue := NewInProcessQueue(1, 10, logger)
+	err := m.SetProvisioningQueue(queue, ProvisioningHooks{
+		OnFailed: func(ctx context.Context, tenantID uuid.UUID, err error) { failed <- err },
+	})
+	if err != nil {
+		t.Fatalf("SetProvisioningQueue() error = %v", err)
+	}
+
+	tenant := &Tenant{Name: "Acme", Subdomain: "acme", PlanType: PlanBasic}
+	if err := m.CreateTenant(context.Background(), tenant); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+
+	select {
+	case err := <-failed:
+		if err == nil {
+			t.Error("OnFailed called without an error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnFailed was not called")
+	}
+
+	job := waitForJob(t, m, tenant.ID, JobFailed)
+	if job.Attempts != 1 || job.Error == "" {
+		t.Errorf("job = %+v, want one failed attempt with an error", job)
+	}
+	if repo.tenants[tenant.ID].Status != StatusPending {
+		t.Errorf("status after failure = %s, want %s", repo.tenants[tenant.ID].Status, StatusPending)
+	}
+
+	// A failed job can be enqueued again
+	schemas.setErr(nil)
+	if err := queue.Enqueue(context.Background(), tenant.ID); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	job = waitForJob(t, m, tenant.ID, JobSucceeded)
+	if job.Attempts != 2 {
+		t.Errorf("attempts = %d, want 2", job.Attempts)
+	}
+}
+
+func TestManager_AsyncProvisioning_EnqueueFails(t *testing.T) {
+	m, repo := newProvisioningTestManager(t, NewMockSchemaManager(""))
+	queue := NewInProcessQueue(1, 10, zaptest.NewLogger(t))
+	if err := m.SetProvisioningQueue(queue, ProvisioningHooks{}); err != nil {
+		t.Fatalf("SetProvisioningQueue() error = %v", err)
+	}
+	queue.Close()
+
+	tenant := &Tenant{Name: "Acme", Subdomain: "acme", PlanType: PlanBasic}
+	err := m.CreateTenant(context.Background(), tenant)
+	if !errors.Is(err, ErrProvisioningNotQueued) || !errors.Is(err, ErrQueueClosed) {
+		t.Fatalf("CreateTenant() error = %v, want ErrProvisioningNotQueued wrapping ErrQueueClosed", err)
+	}
+
+	// The pending tenant was stored and is returned for a later enqueue
+	stored, ok := repo.tenants[tenant.ID]
+	if !ok || stored.Status != StatusPending {
+		t.Errorf("stored tenant = %+v, want the pending tenant under %s", stored, tenant.ID)
+	}
+}
+
+func TestManager_GetProvisioningJob_NoQueue(t *testing.T) {
+	m, _ := newProvisioningTestManager(t, NewMockSchemaManager(""))
+
+	_, err := m.GetProvisioningJob(context.Background(), uuid.New())
+	var tenantErr *TenantError
+	if !errors.As(err, &tenantErr) || tenantErr.Code != "PROVISIONING_QUEUE_UNAVAILABLE" {
+		t.Errorf("GetProvisioningJob() error = %v, want PROVISIONING_QUEUE_UNAVAILABLE", err)
+	}
+}
+
+func TestInProcessQueue(t *testing.T) {
+	queue := NewInProcessQueue(1, 10, zaptest.NewLogger(t))
+	ctx := context.Background()
+
+	if _, err := queue.Job(ctx, uuid.New()); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Job() error = %v, want ErrJobNotFound", err)
+	}
+
+	// Jobs wait in the buffer until the queue starts, and enqueueing twice is a no-op
+	tenantID := uuid.New()
+	for i := 0; i < 2; i++ {
+		if err := queue.Enqueue(ctx, tenantID); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+	if job, _ := queue.Job(ctx, tenantID); job.Status != JobQueued {
+		t.Errorf("status before Start = %s, want %s", job.Status, JobQueued)
+	}
+
+	var mu sync.Mutex
+	runs := 0
+	done := make(chan struct{})
+	err := queue.Start(func(ctx context.Context, id uuid.UUID) error {
+		mu.Lock()
+		runs++
+		mu.Unlock()
+		close(done)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	<-done
+
+	if err := queue.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	mu.Lock()
+	if runs != 1 {
+		t.Errorf("runs = %d, want 1 for a job enqueued twice", runs)
+	}
+	mu.Unlock()
+
+	if err := queue.Enqueue(ctx, uuid.New()); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("Enqueue() after Close error = %v, want ErrQueueClosed", err)
+	}
+}
+
+// peakSchemaManager records how many schema creations run at once
+type peakSchemaManager struct {
+	*MockManagerSchemaManager
+	mu      sync.Mutex
+	running int
+	peak    int
+}
+
+func (s *peakSchemaManager) CreateTenantSchema(ctx context.Context, tenantID uuid.UUID, name string) error {
+	s.mu.Lock()
+	s.running++
+	s.peak = max(s.peak, s.running)
+	s.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+	return s.MockManagerSchemaManager.CreateTenantSchema(ctx, tenantID, name)
+}
+
+func (s *peakSchemaManager) SchemaExists(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.MockManagerSchemaManager.SchemaExists(ctx, tenantID)
+}
+
+// lockedRepository serializes access to the mock repository for concurrent tests
+type lockedRepository struct {
+	*MockManagerRepository
+	mu sync.Mutex
+}
+
+func (r *lockedRepository) GetByID(ctx context.Context, id uuid.UUID) (*Tenant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenant, err := r.MockManagerRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	copied := *tenant
+	return &copied, nil
+}
+
+func (r *lockedRepository) Update(ctx context.Context, tenant *Tenant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *tenant
+	return r.MockManagerRepository.Update(ctx, &copied)
+}
+
+func TestManager_MaxConcurrentProvisions(t *testing.T) {
+	config := DefaultConfig()
+	config.Database.MaxConcurrentProvisions = 2
+	repo := &lockedRepository{MockManagerRepository: NewMockRepository()}
+	schemas := &peakSchemaManager{MockManagerSchemaManager: NewMockSchemaManager(config.Database.SchemaPrefix)}
+	m := NewManager(config, nil, repo, schemas, NewMockMigrationManager(), NewMockLimitChecker(config.Limits), zaptest.NewLogger(t))
+	defer m.Close()
+	ctx := context.Background()
+
+	var ids []uuid.UUID
+	for i := 0; i < 8; i++ {
+		id := uuid.New()
+		repo.tenants[id] = &Tenant{ID: id, Name: "Tenant", Subdomain: "tenant-" + id.String()[:8], Status: StatusPending}
+		ids = append(ids, id)
+	}
+
+	// Direct calls share the limit
+	var wg sync.WaitGroup
+	for _, id := range ids[:4] {
+		wg.Add(1)
+		go func(id uuid.UUID) {
+			defer wg.Done()
+			if err := m.ProvisionTenant(ctx, id); err != nil {
+				t.Errorf("ProvisionTenant() error = %v", err)
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	// So do queue workers, even when there are more of them
+	if err := m.SetProvisioningQueue(NewInProcessQueue(4, 0, zaptest.NewLogger(t)), ProvisioningHooks{}); err != nil {
+		t.Fatalf("SetProvisioningQueue() error = %v", err)
+	}
+	queue := m.(*manager).provisioningQueue()
+	for _, id := range ids[4:] {
+		if err := queue.Enqueue(ctx, id); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+	for _, id := range ids[4:] {
+		waitForJob(t, m, id, JobSucceeded)
+	}
+
+	schemas.mu.Lock()
+	defer schemas.mu.Unlock()
+	if schemas.peak != 2 {
+		t.Errorf("peak concurrent provisions = %d, want 2", schemas.peak)
+	}
+	if len(schemas.schemas) != len(ids) {
+		t.Errorf("schemas created = %d, want %d", len(schemas.schemas), len(ids))
+	}
+
+	// Waiting for a slot respects the context
+	m.(*manager).provisions <- struct{}{}
+	m.(*manager).provisions <- struct{}{}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := m.ProvisionTenant(cancelled, ids[0]); !errors.Is(err, context.Canceled) {
+		t.Errorf("ProvisionTenant() error = %v, want context.Canceled while waiting for a slot", err)
+	}
+}
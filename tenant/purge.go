@@ -0,0 +1,137 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// CancelledTenantLister is implemented by repositories that can list the
+// tenants cancelled before a time, such as postgres.Repository. A cancelled
+// tenant's UpdatedAt is when it was cancelled. PurgeExpiredTenants requires it.
+type CancelledTenantLister interface {
+	ListCancelled(ctx context.Context, before time.Time) ([]*Tenant, error)
+}
+
+// ErrPurgeUnsupported is returned by PurgeTenant and PurgeExpiredTenants when
+// the repository cannot remove tenant records or list cancelled tenants
+var ErrPurgeUnsupported = errors.New("repository does not support purging tenants")
+
+// PurgeTenant permanently removes a cancelled tenant: its schema is dropped
+// and its record deleted, so the subdomain can be taken again. It requires a
+// PurgeRepository and returns a TenantError with code TENANT_NOT_CANCELLED
+// for tenants that are not cancelled; call DeleteTenant first.
+func (m *manager) PurgeTenant(ctx context.Context, tenantID uuid.UUID) error {
+	purger, ok := m.repository.(PurgeRepository)
+	if !ok {
+		return ErrPurgeUnsupported
+	}
+
+	return m.purgeTenant(ctx, purger, tenantID)
+}
+
+// PurgeExpiredTenants purges every tenant cancelled more than olderThan ago,
+// the retention window in which RestoreTenant can still bring it back, and
+// returns how many were purged. A tenant that fails to purge is logged and
+// skipped; if any failed, an error reporting how many is returned along with
+// the count. It requires a repository that is both a PurgeRepository and a
+// CancelledTenantLister.
+func (m *manager) PurgeExpiredTenants(ctx context.Context, olderThan time.Duration) (int, error) {
+	if olderThan < 0 {
+		return 0, &ValidationError{Field: "older_than", Message: "retention window must not be negative"}
+	}
+
+	purger, ok := m.repository.(PurgeRepository)
+	if !ok {
+		return 0, ErrPurgeUnsupported
+	}
+	lister, ok := m.repository.(CancelledTenantLister)
+	if !ok {
+		return 0, ErrPurgeUnsupported
+	}
+
+	tenants, err := lister.ListCancelled(ctx, time.Now().Add(-olderThan))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list cancelled tenants: %w", err)
+	}
+
+	purged, failed := 0, 0
+	for _, tenant := range tenants {
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+		if tenant.Status != StatusCancelled {
+			continue
+		}
+		err := m.purgeTenant(ctx, purger, tenant.ID)
+		var tenantErr *TenantError
+		if errors.As(err, &tenantErr) && tenantErr.Code == "TENANT_NOT_CANCELLED" {
+			// Restored since it was listed
+			continue
+		}
+		if err != nil {
+			m.logger.Error("Failed to purge expired tenant",
+				zap.String("tenant_id", tenant.ID.String()),
+				zap.Error(err))
+			failed++
+			continue
+		}
+		purged++
+	}
+
+	m.logger.Info("Purged expired tenants",
+		zap.Duration("older_than", olderThan),
+		zap.Int("purged", purged),
+		zap.Int("failed", failed))
+
+	if failed > 0 {
+		return purged, fmt.Errorf("failed to purge %d expired tenants", failed)
+	}
+	return purged, nil
+}
+
+// purgeTenant drops the tenant's schema, in its region's database if it has
+// one, and then removes its record. The tenant is read again first and must
+// still be cancelled, so that one restored in the meantime keeps its schema.
+// The record is kept if the schema cannot be dropped, so that a later purge
+// can retry.
+func (m *manager) purgeTenant(ctx context.Context, purger PurgeRepository, tenantID uuid.UUID) error {
+	defer m.tenants.invalidate(tenantID)
+	defer m.stats.invalidate(tenantID)
+	defer m.connections.remove(tenantID)
+
+	tenant, err := m.repository.GetByID(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to get tenant: %w", err)
+	}
+	if tenant.Status != StatusCancelled {
+		return &TenantError{
+			TenantID: tenantID,
+			Code:     "TENANT_NOT_CANCELLED",
+			Message:  fmt.Sprintf("only cancelled tenants can be purged, tenant is %s", tenant.Status),
+		}
+	}
+
+	schemas, err := m.tenantSchemaManager(ctx, tenant.ID)
+	if err != nil {
+		return err
+	}
+	if err := schemas.DropTenantSchema(ctx, tenant.ID); err != nil {
+		return fmt.Errorf("failed to drop tenant schema: %w", err)
+	}
+
+	if err := purger.Purge(ctx, tenant.ID); err != nil {
+		return fmt.Errorf("failed to purge tenant: %w", err)
+	}
+	m.invalidateSubdomain(tenant.Subdomain)
+
+	m.logger.Warn("Purged tenant",
+		zap.String("tenant_id", tenant.ID.String()),
+		zap.String("subdomain", tenant.Subdomain))
+	m.publishEvent(ctx, EventTenantPurged, tenant, nil)
+	return nil
+}
@@ -0,0 +1,170 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// cancelledRepository lists cancelled tenants from the mock repository
+type cancelledRepository struct {
+	*MockManagerRepository
+}
+
+func (r *cancelledRepository) ListCancelled(ctx context.Context, before time.Time) ([]*Tenant, error) {
+	var cancelled []*Tenant
+	for _, t := range r.tenants {
+		if t.Status == StatusCancelled && t.UpdatedAt.Before(before) {
+			cancelled = append(cancelled, t)
+		}
+	}
+	return cancelled, nil
+}
+
+// addPurgeTestTenant stores a tenant with a schema and the given status,
+// last updated at updatedAt
+func addPurgeTestTenant(m *manager, repo *MockManagerRepository, status string, updatedAt time.Time) *Tenant {
+	t := &Tenant{
+		ID:        uuid.New(),
+		Name:      "Tenant",
+		Subdomain: "tenant-" + uuid.NewString()[:8],
+		PlanType:  PlanBasic,
+		Status:    status,
+		UpdatedAt: updatedAt,
+	}
+	repo.tenants[t.ID] = t
+	m.schemaManager.(*MockManagerSchemaManager).schemas[t.ID] = true
+	return t
+}
+
+func TestManager_PurgeTenant(t *testing.T) {
+	repo := NewMockRepository()
+	m := newTestManager(t, withRepository(repo))
+
+	var events []Event
+	m.SetEventPublisher(EventPublisherFunc(func(ctx context.Context, event Event) error {
+		events = append(events, event)
+		return nil
+	}))
+
+	cancelled := addPurgeTestTenant(m, repo, StatusCancelled, time.Now())
+	if err := m.PurgeTenant(context.Background(), cancelled.ID); err != nil {
+		t.Fatalf("PurgeTenant() error = %v", err)
+	}
+	if len(events) != 1 || events[0].Type != EventTenantPurged || events[0].TenantID != cancelled.ID {
+		t.Errorf("events = %+v, want %s for the tenant", events, EventTenantPurged)
+	}
+
+	if _, exists := repo.tenants[cancelled.ID]; exists {
+		t.Error("PurgeTenant() kept the tenant record")
+	}
+	if m.schemaManager.(*MockManagerSchemaManager).schemas[cancelled.ID] {
+		t.Error("PurgeTenant() kept the tenant schema")
+	}
+}
+
+func TestManager_PurgeTenant_RefusesTenantsNotCancelled(t *testing.T) {
+	for _, status := range []string{StatusActive, StatusSuspended, StatusPending} {
+		t.Run(status, func(t *testing.T) {
+			repo := NewMockRepository()
+			m := newTestManager(t, withRepository(repo))
+
+			tenant := addPurgeTestTenant(m, repo, status, time.Now())
+			err := m.PurgeTenant(context.Background(), tenant.ID)
+
+			var tenantErr *TenantError
+			if !errors.As(err, &tenantErr) || tenantErr.Code != "TENANT_NOT_CANCELLED" {
+				t.Fatalf("PurgeTenant() error = %v, want TENANT_NOT_CANCELLED", err)
+			}
+			if _, exists := repo.tenants[tenant.ID]; !exists {
+				t.Error("PurgeTenant() removed the tenant record")
+			}
+			if !m.schemaManager.(*MockManagerSchemaManager).schemas[tenant.ID] {
+				t.Error("PurgeTenant() dropped the tenant schema")
+			}
+		})
+	}
+}
+
+func TestManager_PurgeExpiredTenants(t *testing.T) {
+	repo := &cancelledRepository{MockManagerRepository: NewMockRepository()}
+	m := newTestManager(t, withRepository(repo))
+
+	now := time.Now()
+	expired := addPurgeTestTenant(m, repo.MockManagerRepository, StatusCancelled, now.Add(-48*time.Hour))
+	recent := addPurgeTestTenant(m, repo.MockManagerRepository, StatusCancelled, now.Add(-time.Hour))
+	active := addPurgeTestTenant(m, repo.MockManagerRepository, StatusActive, now.Add(-48*time.Hour))
+
+	purged, err := m.PurgeExpiredTenants(context.Background(), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("PurgeExpiredTenants() error = %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("PurgeExpiredTenants() = %d, want 1", purged)
+	}
+
+	if _, exists := repo.tenants[expired.ID]; exists {
+		t.Error("expired tenant was not purged")
+	}
+	for _, kept := range []*Tenant{recent, active} {
+		if _, exists := repo.tenants[kept.ID]; !exists {
+			t.Errorf("tenant %s (%s) was purged", kept.ID, kept.Status)
+		}
+	}
+}
+
+// restoredRepository lists cancelled tenants as they were, then restores them
+// as if RestoreTenant ran before they were purged
+type restoredRepository struct {
+	*cancelledRepository
+}
+
+func (r *restoredRepository) ListCancelled(ctx context.Context, before time.Time) ([]*Tenant, error) {
+	listed, err := r.cancelledRepository.ListCancelled(ctx, before)
+	snapshot := make([]*Tenant, 0, len(listed))
+	for _, t := range listed {
+		copied := *t
+		snapshot = append(snapshot, &copied)
+		t.Status = StatusActive
+	}
+	return snapshot, err
+}
+
+func TestManager_PurgeExpiredTenants_RestoredSinceListed(t *testing.T) {
+	repo := &restoredRepository{cancelledRepository: &cancelledRepository{MockManagerRepository: NewMockRepository()}}
+	m := newTestManager(t, withRepository(repo))
+
+	restored := addPurgeTestTenant(m, repo.MockManagerRepository, StatusCancelled, time.Now().Add(-48*time.Hour))
+
+	purged, err := m.PurgeExpiredTenants(context.Background(), 24*time.Hour)
+	if err != nil || purged != 0 {
+		t.Fatalf("PurgeExpiredTenants() = %d, %v; want nothing purged", purged, err)
+	}
+	if _, exists := repo.tenants[restored.ID]; !exists {
+		t.Error("restored tenant record was purged")
+	}
+	if !m.schemaManager.(*MockManagerSchemaManager).schemas[restored.ID] {
+		t.Error("restored tenant schema was dropped")
+	}
+}
+
+func TestManager_PurgeExpiredTenants_NegativeWindow(t *testing.T) {
+	repo := &cancelledRepository{MockManagerRepository: NewMockRepository()}
+	m := newTestManager(t, withRepository(repo))
+
+	var validationErr *ValidationError
+	if _, err := m.PurgeExpiredTenants(context.Background(), -time.Hour); !errors.As(err, &validationErr) {
+		t.Errorf("PurgeExpiredTenants() error = %v, want a ValidationError", err)
+	}
+}
+
+func TestManager_PurgeExpiredTenants_Unsupported(t *testing.T) {
+	m := newTestManager(t)
+
+	if _, err := m.PurgeExpiredTenants(context.Background(), time.Hour); !errors.Is(err, ErrPurgeUnsupported) {
+		t.Errorf("PurgeExpiredTenants() error = %v, want ErrPurgeUnsupported", err)
+	}
+}
@@ -0,0 +1,53 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// LabelReadOnly is the reserved label that puts a tenant in read-only mode
+const LabelReadOnly = "read-only"
+
+// SetReadOnly puts a tenant in or out of read-only mode, e.g. while its data
+// is exported or during a dispute hold. Unlike suspension the tenant stays
+// active: reads keep working, while transactions started by WithTenantTx are
+// READ ONLY so that the database rejects writes. The flag is stored as the
+// LabelReadOnly label, so the repository must implement LabelRepository.
+func (m *manager) SetReadOnly(ctx context.Context, tenantID uuid.UUID, readOnly bool) error {
+	labelRepo, err := m.labelRepository(tenantID)
+	if err != nil {
+		return err
+	}
+
+	defer m.labels.invalidate(tenantID)
+	if readOnly {
+		err = labelRepo.AddLabel(ctx, tenantID, LabelReadOnly)
+	} else {
+		err = labelRepo.RemoveLabel(ctx, tenantID, LabelReadOnly)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set read-only mode: %w", err)
+	}
+
+	m.logger.Info("Set tenant read-only mode",
+		zap.String("tenant_id", tenantID.String()),
+		zap.Bool("read_only", readOnly))
+
+	return nil
+}
+
+// IsReadOnly reports whether a tenant is in read-only mode. The flag is served
+// from a cache for up to Resolver.CacheTTL, so SetReadOnly on another instance
+// takes effect after at most that long. Tenants of repositories without label
+// support, or without label storage, are never read-only.
+func (m *manager) IsReadOnly(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	labels, err := m.cachedLabels(ctx, tenantID)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(labels, LabelReadOnly), nil
+}
@@ -0,0 +1,156 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestManager_SetReadOnly(t *testing.T) {
+	m := newExecTestManager(t, &execRecorder{})
+	repo := &labelRepository{
+		MockManagerRepository: NewMockRepository(),
+		labels:                make(map[uuid.UUID]map[string]bool),
+	}
+	m.repository = repo
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	write := func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "INSERT INTO projects (name) VALUES ('export')")
+		return err
+	}
+	read := func(tx *sql.Tx) error {
+		return m.AssertTenantScope(ctx, tx, tenantID)
+	}
+
+	if err := m.WithTenantTx(ctx, tenantID, write); err != nil {
+		t.Fatalf("write before read-only error = %v", err)
+	}
+
+	if err := m.SetReadOnly(ctx, tenantID, true); err != nil {
+		t.Fatalf("SetReadOnly(true) error = %v", err)
+	}
+	if readOnly, err := m.IsReadOnly(ctx, tenantID); err != nil || !readOnly {
+		t.Errorf("IsReadOnly() = %v, %v; want true", readOnly, err)
+	}
+
+	// Writes are rejected while reads, in transactions and on plain connections, still work
+	if err := m.WithTenantTx(ctx, tenantID, write); err == nil {
+		t.Error("write in read-only mode should fail")
+	}
+	if err := m.WithTenantTx(ctx, tenantID, read); err != nil {
+		t.Errorf("read in read-only mode error = %v", err)
+	}
+	conn, err := m.GetTenantConn(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("GetTenantConn() in read-only mode error = %v", err)
+	}
+	if err := m.AssertTenantScope(ctx, conn, tenantID); err != nil {
+		t.Errorf("read on tenant connection error = %v", err)
+	}
+	conn.Close()
+
+	// Other tenants are unaffected
+	if err := m.WithTenantTx(ctx, uuid.New(), write); err != nil {
+		t.Errorf("write for another tenant error = %v", err)
+	}
+
+	if err := m.SetReadOnly(ctx, tenantID, false); err != nil {
+		t.Fatalf("SetReadOnly(false) error = %v", err)
+	}
+	if err := m.WithTenantTx(ctx, tenantID, write); err != nil {
+		t.Errorf("write after leaving read-only mode error = %v", err)
+	}
+}
+
+func TestManager_SetReadOnly_NoLabelRepository(t *testing.T) {
+	m := newExecTestManager(t, &execRecorder{})
+
+	var tenantErr *TenantError
+	if err := m.SetReadOnly(context.Background(), uuid.New(), true); !errors.As(err, &tenantErr) || tenantErr.Code != "LABELS_UNAVAILABLE" {
+		t.Errorf("SetReadOnly() error = %v, want LABELS_UNAVAILABLE", err)
+	}
+	if readOnly, err := m.IsReadOnly(context.Background(), uuid.New()); err != nil || readOnly {
+		t.Errorf("IsReadOnly() = %v, %v; want false without label support", readOnly, err)
+	}
+}
+
+// countingLabelRepository counts GetLabels calls and fails them with err
+type countingLabelRepository struct {
+	*labelRepository
+	getLabelsCalls int
+	err            error
+}
+
+func (r *countingLabelRepository) GetLabels(ctx context.Context, tenantID uuid.UUID) ([]string, error) {
+	r.getLabelsCalls++
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.labelRepository.GetLabels(ctx, tenantID)
+}
+
+func newCountingLabelRepository() *countingLabelRepository {
+	return &countingLabelRepository{labelRepository: &labelRepository{
+		MockManagerRepository: NewMockRepository(),
+		labels:                make(map[uuid.UUID]map[string]bool),
+	}}
+}
+
+func TestManager_IsReadOnly_Cached(t *testing.T) {
+	m := newExecTestManager(t, &execRecorder{})
+	repo := newCountingLabelRepository()
+	m.repository = repo
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	noop := func(tx *sql.Tx) error { return nil }
+	for i := 0; i < 3; i++ {
+		if err := m.WithTenantTx(ctx, tenantID, noop); err != nil {
+			t.Fatalf("WithTenantTx() error = %v", err)
+		}
+	}
+	if repo.getLabelsCalls != 1 {
+		t.Errorf("GetLabels called %d times for 3 transactions, want 1", repo.getLabelsCalls)
+	}
+
+	// SetReadOnly takes effect right away
+	if err := m.SetReadOnly(ctx, tenantID, true); err != nil {
+		t.Fatalf("SetReadOnly(true) error = %v", err)
+	}
+	if readOnly, err := m.IsReadOnly(ctx, tenantID); err != nil || !readOnly {
+		t.Errorf("IsReadOnly() = %v, %v right after SetReadOnly(true); want true", readOnly, err)
+	}
+}
+
+func TestManager_IsReadOnly_LabelsUnavailable(t *testing.T) {
+	m := newExecTestManager(t, &execRecorder{})
+	repo := newCountingLabelRepository()
+	repo.err = fmt.Errorf("%w: relation \"public.tenant_labels\" does not exist", ErrLabelsUnavailable)
+	m.repository = repo
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	if readOnly, err := m.IsReadOnly(ctx, tenantID); err != nil || readOnly {
+		t.Errorf("IsReadOnly() = %v, %v; want false without label storage", readOnly, err)
+	}
+	write := func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "INSERT INTO projects (name) VALUES ('export')")
+		return err
+	}
+	if err := m.WithTenantTx(ctx, tenantID, write); err != nil {
+		t.Errorf("WithTenantTx() error = %v, want writes allowed without label storage", err)
+	}
+
+	// Other failures are still reported
+	m.labels.invalidate(tenantID)
+	repo.err = errors.New("connection reset")
+	if err := m.WithTenantTx(ctx, tenantID, write); err == nil {
+		t.Error("WithTenantTx() should fail when the read-only flag cannot be read")
+	}
+}
@@ -0,0 +1,175 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// LabelRegionPrefix prefixes the reserved label that records a tenant's data
+// residency region, e.g. "region:eu-west". Only CreateTenantInRegion sets it;
+// AddLabel and RemoveLabel reject it, so a tenant's region cannot be changed
+// after its schema was created.
+const LabelRegionPrefix = "region:"
+
+// ErrRegionNotConfigured is returned, wrapped, when a tenant's region has no
+// database in the RegionRouter. Its connections are refused rather than
+// served from another region.
+var ErrRegionNotConfigured = errors.New("region is not configured")
+
+// ErrMultipleRegions is returned, wrapped, for a tenant carrying more than one
+// LabelRegionPrefix label, e.g. written to the repository directly. Its
+// connections are refused rather than routed to either region.
+var ErrMultipleRegions = errors.New("tenant has more than one region")
+
+// RegionDatabase is where the schemas of one region's tenants live
+type RegionDatabase struct {
+	DB            *sql.DB       // serves GetTenantConn and WithTenantTx
+	SchemaManager SchemaManager // provisions tenant schemas in DB
+}
+
+// RegionRouter maps data residency regions to their databases. The tenant
+// records stay in the primary database; only tenant schemas are regional.
+type RegionRouter interface {
+	// Region returns the database for region, or false if it is not configured
+	Region(region string) (RegionDatabase, bool)
+}
+
+// RegionMap is a RegionRouter over a fixed set of regions
+type RegionMap map[string]RegionDatabase
+
+// Region implements RegionRouter
+func (r RegionMap) Region(region string) (RegionDatabase, bool) {
+	db, ok := r[region]
+	return db, ok && db.DB != nil && db.SchemaManager != nil
+}
+
+// RegionalMigrationManager is implemented by migration managers that can
+// migrate tenant schemas outside their own database, such as
+// database.MigrationManager. SetRegionRouter hands them tenantDB, which
+// returns the database holding a tenant's schema.
+type RegionalMigrationManager interface {
+	SetTenantDB(tenantDB func(ctx context.Context, tenantID uuid.UUID) (*sql.DB, error))
+}
+
+// SetRegionRouter routes tenants created with CreateTenantInRegion to their
+// region's database, for connections, provisioning, maintenance and stats,
+// and for migrations if the migration manager is a RegionalMigrationManager.
+// Tenants without a region keep using the primary database. Set it before
+// serving traffic.
+func (m *manager) SetRegionRouter(router RegionRouter) {
+	m.regionMu.Lock()
+	m.regions = router
+	m.regionMu.Unlock()
+
+	if regional, ok := m.migrationMgr.(RegionalMigrationManager); ok {
+		regional.SetTenantDB(m.tenantDB)
+	}
+}
+
+// regionRouter returns the router set by SetRegionRouter, or nil
+func (m *manager) regionRouter() RegionRouter {
+	m.regionMu.RLock()
+	defer m.regionMu.RUnlock()
+	return m.regions
+}
+
+// CreateTenantInRegion creates a tenant whose schema must live in region, like
+// CreateTenant. A region the RegionRouter does not know is rejected with a
+// ValidationError. The region is stored as a LabelRegionPrefix label, so the
+// repository must implement LabelRepository; ProvisionTenant then creates the
+// schema with the region's SchemaManager.
+func (m *manager) CreateTenantInRegion(ctx context.Context, tenant *Tenant, region string) error {
+	router := m.regionRouter()
+	if router == nil {
+		return &ValidationError{Field: "region", Message: "no regions are configured"}
+	}
+	if _, ok := router.Region(region); !ok {
+		return &ValidationError{Field: "region", Message: fmt.Sprintf("region %q is not configured", region)}
+	}
+
+	label, err := normalizeLabel(LabelRegionPrefix + region)
+	if err != nil || label != LabelRegionPrefix+region {
+		return &ValidationError{Field: "region", Message: "region may only contain lowercase letters, digits and . _ : = / -"}
+	}
+	if _, err := m.labelRepository(tenant.ID); err != nil {
+		return err
+	}
+
+	return m.createTenant(ctx, tenant, label)
+}
+
+// GetTenantRegion returns the tenant's data residency region, or "" if it
+// lives in the primary database. It is read from the label cache, so routing
+// a tenant's connections does not query its labels every time. A tenant with
+// more than one region label is refused with ErrMultipleRegions.
+func (m *manager) GetTenantRegion(ctx context.Context, tenantID uuid.UUID) (string, error) {
+	labels, err := m.cachedLabels(ctx, tenantID)
+	if err != nil {
+		return "", err
+	}
+
+	var region string
+	for _, label := range labels {
+		if name, ok := strings.CutPrefix(label, LabelRegionPrefix); ok {
+			if region != "" {
+				return "", fmt.Errorf("%w: tenant %s is in regions %s and %s", ErrMultipleRegions, tenantID, region, name)
+			}
+			region = name
+		}
+	}
+	return region, nil
+}
+
+// tenantRegion returns the regional database of a tenant, or false if the
+// tenant lives in the primary database
+func (m *manager) tenantRegion(ctx context.Context, tenantID uuid.UUID) (RegionDatabase, bool, error) {
+	router := m.regionRouter()
+	if router == nil {
+		return RegionDatabase{}, false, nil
+	}
+
+	region, err := m.GetTenantRegion(ctx, tenantID)
+	if err != nil || region == "" {
+		return RegionDatabase{}, false, err
+	}
+
+	db, ok := router.Region(region)
+	if !ok {
+		m.logger.Error("Tenant region is not configured",
+			zap.String("tenant_id", tenantID.String()),
+			zap.String("region", region))
+		return RegionDatabase{}, false, fmt.Errorf("%w: tenant %s is in region %s", ErrRegionNotConfigured, tenantID, region)
+	}
+	return db, true, nil
+}
+
+// tenantDB returns the pool holding the tenant's schema
+func (m *manager) tenantDB(ctx context.Context, tenantID uuid.UUID) (*sql.DB, error) {
+	region, ok, err := m.tenantRegion(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return region.DB, nil
+	}
+	return m.db, nil
+}
+
+// tenantSchemaManager returns the schema manager for the database holding the
+// tenant's schema
+func (m *manager) tenantSchemaManager(ctx context.Context, tenantID uuid.UUID) (SchemaManager, error) {
+	region, ok, err := m.tenantRegion(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return region.SchemaManager, nil
+	}
+	return m.schemaManager, nil
+}
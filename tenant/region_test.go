@@ -0,0 +1,314 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// newRegionTestManager returns a manager with label storage whose only region,
+// "eu-west", records statements in eu
+func newRegionTestManager(t *testing.T, primary, eu *execRecorder) (*manager, *MockManagerSchemaManager) {
+	primaryDB := sql.OpenDB(execTestConnector{recorder: primary})
+	t.Cleanup(func() { primaryDB.Close() })
+	m := newTestManager(t, withDB(primaryDB), withRepository(&labelRepository{
+		MockManagerRepository: NewMockRepository(),
+		labels:                make(map[uuid.UUID]map[string]bool),
+	}))
+
+	euDB := sql.OpenDB(execTestConnector{recorder: eu})
+	t.Cleanup(func() { euDB.Close() })
+	euSchemas := NewMockSchemaManager(m.config.Database.SchemaPrefix)
+	m.SetRegionRouter(RegionMap{"eu-west": {DB: euDB, SchemaManager: euSchemas}})
+
+	return m, euSchemas
+}
+
+func TestManager_RegionRouting(t *testing.T) {
+	primary, eu := &execRecorder{}, &execRecorder{}
+	m, euSchemas := newRegionTestManager(t, primary, eu)
+	ctx := context.Background()
+
+	regional := &Tenant{Name: "Acme EU", Subdomain: "acme-eu"}
+	if err := m.CreateTenantInRegion(ctx, regional, "eu-west"); err != nil {
+		t.Fatalf("CreateTenantInRegion() error = %v", err)
+	}
+	home := &Tenant{Name: "Acme", Subdomain: "acme"}
+	if err := m.CreateTenant(ctx, home); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+
+	if region, err := m.GetTenantRegion(ctx, regional.ID); err != nil || region != "eu-west" {
+		t.Errorf("GetTenantRegion() = %q, %v; want eu-west", region, err)
+	}
+	if region, err := m.GetTenantRegion(ctx, home.ID); err != nil || region != "" {
+		t.Errorf("GetTenantRegion() = %q, %v for a primary tenant; want none", region, err)
+	}
+
+	// Schemas are provisioned in the tenant's region
+	for _, tenant := range []*Tenant{regional, home} {
+		if err := m.ProvisionTenant(ctx, tenant.ID); err != nil {
+			t.Fatalf("ProvisionTenant() error = %v", err)
+		}
+	}
+	if !euSchemas.schemas[regional.ID] || euSchemas.schemas[home.ID] {
+		t.Errorf("EU schemas = %v, want only the regional tenant's", euSchemas.schemas)
+	}
+	if exists, _ := m.schemaManager.SchemaExists(ctx, regional.ID); exists {
+		t.Error("the regional tenant's schema should not be created in the primary database")
+	}
+
+	// Transactions and connections use the tenant's regional pool
+	noop := func(tx *sql.Tx) error { return nil }
+	for _, tenant := range []*Tenant{regional, home} {
+		if err := m.WithTenantTx(ctx, tenant.ID, noop); err != nil {
+			t.Fatalf("WithTenantTx() error = %v", err)
+		}
+		conn, err := m.GetTenantConn(ctx, tenant.ID)
+		if err != nil {
+			t.Fatalf("GetTenantConn() error = %v", err)
+		}
+		conn.Close()
+	}
+
+	regionalSchema := m.schemaManager.GetSchemaName(regional.ID)
+	homeSchema := m.schemaManager.GetSchemaName(home.ID)
+	if len(eu.statementsFor(regionalSchema)) != 2 || len(eu.statementsFor(homeSchema)) != 0 {
+		t.Errorf("EU statements = %q, want only the regional tenant's", eu.statementsFor(regionalSchema))
+	}
+	if len(primary.statementsFor(homeSchema)) != 2 || len(primary.statementsFor(regionalSchema)) != 0 {
+		t.Errorf("primary statements = %q, want only the primary tenant's", primary.statementsFor(homeSchema))
+	}
+
+	// A region dropped from the router is refused, not served from the primary database
+	m.SetRegionRouter(RegionMap{})
+	if err := m.WithTenantTx(ctx, regional.ID, noop); !errors.Is(err, ErrRegionNotConfigured) {
+		t.Errorf("WithTenantTx() error = %v, want ErrRegionNotConfigured", err)
+	}
+	if err := m.WithTenantTx(ctx, home.ID, noop); err != nil {
+		t.Errorf("WithTenantTx() error = %v for a primary tenant", err)
+	}
+}
+
+func TestManager_CreateTenantInRegion_Rejected(t *testing.T) {
+	m, _ := newRegionTestManager(t, &execRecorder{}, &execRecorder{})
+	repo := m.repository.(*labelRepository)
+	ctx := context.Background()
+
+	for _, region := range []string{"", "us-east", "EU-WEST"} {
+		var validationErr *ValidationError
+		if err := m.CreateTenantInRegion(ctx, &Tenant{Name: "Acme", Subdomain: "acme"}, region); !errors.As(err, &validationErr) {
+			t.Errorf("CreateTenantInRegion(%q) error = %v, want a ValidationError", region, err)
+		}
+	}
+	if len(repo.tenants) != 0 {
+		t.Errorf("repository has %d tenants, want none created in unconfigured regions", len(repo.tenants))
+	}
+
+	m.SetRegionRouter(nil)
+	var validationErr *ValidationError
+	if err := m.CreateTenantInRegion(ctx, &Tenant{Name: "Acme", Subdomain: "acme"}, "eu-west"); !errors.As(err, &validationErr) {
+		t.Errorf("CreateTenantInRegion() without a router error = %v, want a ValidationError", err)
+	}
+}
+
+func TestManager_RegionRouting_RestoreTenant(t *testing.T) {
+	m, euSchemas := newRegionTestManager(t, &execRecorder{}, &execRecorder{})
+	ctx := context.Background()
+
+	regional := &Tenant{Name: "Acme EU", Subdomain: "acme-eu"}
+	if err := m.CreateTenantInRegion(ctx, regional, "eu-west"); err != nil {
+		t.Fatalf("CreateTenantInRegion() error = %v", err)
+	}
+	if err := m.ProvisionTenant(ctx, regional.ID); err != nil {
+		t.Fatalf("ProvisionTenant() error = %v", err)
+	}
+	if err := m.DeleteTenant(ctx, regional.ID); err != nil {
+		t.Fatalf("DeleteTenant() error = %v", err)
+	}
+
+	// The schema is looked for in the tenant's region, not the primary database
+	if err := m.RestoreTenant(ctx, regional.ID); err != nil {
+		t.Fatalf("RestoreTenant() error = %v", err)
+	}
+	restored, err := m.repository.GetByID(ctx, regional.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if restored.Status != StatusActive {
+		t.Errorf("status after RestoreTenant() = %s, want %s with the schema still in the region", restored.Status, StatusActive)
+	}
+
+	// Without the regional schema the tenant comes back pending
+	if err := m.DeleteTenant(ctx, regional.ID); err != nil {
+		t.Fatalf("DeleteTenant() error = %v", err)
+	}
+	delete(euSchemas.schemas, regional.ID)
+	if err := m.RestoreTenant(ctx, regional.ID); err != nil {
+		t.Fatalf("RestoreTenant() error = %v", err)
+	}
+	if restored, _ := m.repository.GetByID(ctx, regional.ID); restored.Status != StatusPending {
+		t.Errorf("status after RestoreTenant() = %s, want %s without a schema", restored.Status, StatusPending)
+	}
+}
+
+func TestManager_GetTenantRegion_MultipleRegions(t *testing.T) {
+	m, _ := newRegionTestManager(t, &execRecorder{}, &execRecorder{})
+	repo := m.repository.(*labelRepository)
+	ctx := context.Background()
+
+	regional := &Tenant{Name: "Acme EU", Subdomain: "acme-eu"}
+	if err := m.CreateTenantInRegion(ctx, regional, "eu-west"); err != nil {
+		t.Fatalf("CreateTenantInRegion() error = %v", err)
+	}
+	repo.labels[regional.ID][LabelRegionPrefix+"us-east"] = true
+	m.labels.invalidate(regional.ID)
+
+	if region, err := m.GetTenantRegion(ctx, regional.ID); !errors.Is(err, ErrMultipleRegions) {
+		t.Errorf("GetTenantRegion() = %q, %v; want ErrMultipleRegions", region, err)
+	}
+	if err := m.WithTenantTx(ctx, regional.ID, func(tx *sql.Tx) error { return nil }); !errors.Is(err, ErrMultipleRegions) {
+		t.Errorf("WithTenantTx() error = %v, want ErrMultipleRegions", err)
+	}
+}
+
+func TestRegionMap_Region(t *testing.T) {
+	db := &sql.DB{}
+	regions := RegionMap{
+		"eu-west": {DB: db, SchemaManager: NewMockSchemaManager("")},
+		"partial": {DB: db},
+	}
+
+	if got, ok := regions.Region("eu-west"); !ok || got.DB != db {
+		t.Errorf("Region(eu-west) = %+v, %v; want the EU database", got, ok)
+	}
+	for _, region := range []string{"partial", "us-east"} {
+		if _, ok := regions.Region(region); ok {
+			t.Errorf("Region(%q) should not be configured", region)
+		}
+	}
+}
+
+func TestManager_RegionRouting_CachesRegion(t *testing.T) {
+	m, _ := newRegionTestManager(t, &execRecorder{}, &execRecorder{})
+	repo := &countingLabelRepository{labelRepository: m.repository.(*labelRepository)}
+	m.repository = repo
+	ctx := context.Background()
+
+	regional := &Tenant{Name: "Acme EU", Subdomain: "acme-eu"}
+	if err := m.CreateTenantInRegion(ctx, regional, "eu-west"); err != nil {
+		t.Fatalf("CreateTenantInRegion() error = %v", err)
+	}
+	if err := m.ProvisionTenant(ctx, regional.ID); err != nil {
+		t.Fatalf("ProvisionTenant() error = %v", err)
+	}
+
+	// Provisioning looked the region up, later calls reuse it
+	calls := repo.getLabelsCalls
+	noop := func(tx *sql.Tx) error { return nil }
+	for i := 0; i < 3; i++ {
+		if err := m.WithTenantTx(ctx, regional.ID, noop); err != nil {
+			t.Fatalf("WithTenantTx() error = %v", err)
+		}
+		conn, err := m.GetTenantConn(ctx, regional.ID)
+		if err != nil {
+			t.Fatalf("GetTenantConn() error = %v", err)
+		}
+		conn.Close()
+	}
+	if calls != 1 || repo.getLabelsCalls != calls {
+		t.Errorf("GetLabels called %d times to provision and %d times for 3 transactions and connections, want 1 and none",
+			calls, repo.getLabelsCalls-calls)
+	}
+}
+
+// statsSchemaManager is a schema manager that counts stats in its own database
+type statsSchemaManager struct {
+	*MockManagerSchemaManager
+	stats *Stats
+}
+
+func (s *statsSchemaManager) CountStats(ctx context.Context, tenantID uuid.UUID) (*Stats, error) {
+	return s.stats, nil
+}
+
+// regionalMigrationManager records the database locator it is given
+type regionalMigrationManager struct {
+	*MockManagerMigrationManager
+	tenantDB func(ctx context.Context, tenantID uuid.UUID) (*sql.DB, error)
+}
+
+func (r *regionalMigrationManager) SetTenantDB(tenantDB func(ctx context.Context, tenantID uuid.UUID) (*sql.DB, error)) {
+	r.tenantDB = tenantDB
+}
+
+func TestManager_RegionRouting_Operations(t *testing.T) {
+	m, primarySeqs := newSequenceTestManager(t)
+	m.repository = &labelRepository{
+		MockManagerRepository: NewMockRepository(),
+		labels:                make(map[uuid.UUID]map[string]bool),
+	}
+	m.config.SelfCheckTenantSchemas = true
+	migrations := &regionalMigrationManager{MockManagerMigrationManager: NewMockMigrationManager()}
+	m.migrationMgr = migrations
+
+	euSeqs := &sequenceDB{sequences: make(map[string]int64)}
+	euDB := sql.OpenDB(euSeqs)
+	t.Cleanup(func() { euDB.Close() })
+	euSchemas := &statsSchemaManager{
+		MockManagerSchemaManager: NewMockSchemaManager(m.config.Database.SchemaPrefix),
+		stats:                    &Stats{ProjectCount: 7},
+	}
+	m.SetRegionRouter(RegionMap{"eu-west": {DB: euDB, SchemaManager: euSchemas}})
+	ctx := context.Background()
+
+	regional := &Tenant{Name: "Acme EU", Subdomain: "acme-eu"}
+	if err := m.CreateTenantInRegion(ctx, regional, "eu-west"); err != nil {
+		t.Fatalf("CreateTenantInRegion() error = %v", err)
+	}
+	if err := m.ProvisionTenant(ctx, regional.ID); err != nil {
+		t.Fatalf("ProvisionTenant() error = %v", err)
+	}
+
+	// Migrations find the regional tenant's database through the manager
+	if migrations.tenantDB == nil {
+		t.Fatal("SetRegionRouter() should give the migration manager the tenant database locator")
+	}
+	if db, err := migrations.tenantDB(ctx, regional.ID); err != nil || db != euDB {
+		t.Errorf("tenant database = %v, %v; want the region's", db, err)
+	}
+
+	if _, err := m.NextTenantSequence(ctx, regional.ID, "invoice"); err != nil {
+		t.Fatalf("NextTenantSequence() error = %v", err)
+	}
+	if len(euSeqs.sequences) != 1 || len(primarySeqs.sequences) != 0 {
+		t.Errorf("EU sequences = %v, primary sequences = %v; want the sequence in the region", euSeqs.sequences, primarySeqs.sequences)
+	}
+
+	stats, err := m.GetStatsFresh(ctx, regional.ID)
+	if err != nil {
+		t.Fatalf("GetStatsFresh() error = %v", err)
+	}
+	if stats.ProjectCount != 7 {
+		t.Errorf("ProjectCount = %d, want the region's count", stats.ProjectCount)
+	}
+
+	report, err := m.DeletionImpact(ctx, regional.ID)
+	if err != nil {
+		t.Fatalf("DeletionImpact() error = %v", err)
+	}
+	if !report.SchemaExists {
+		t.Error("DeletionImpact() should find the schema in the tenant's region")
+	}
+
+	selfCheck, err := m.SelfCheck(ctx)
+	if err != nil {
+		t.Fatalf("SelfCheck() error = %v", err)
+	}
+	if findings := selfCheck.ByCategory(SelfCheckTenantSchemas); len(findings) != 0 {
+		t.Errorf("tenant schema findings = %v, want the schema found in the tenant's region", findings)
+	}
+}
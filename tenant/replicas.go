@@ -0,0 +1,71 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// SetReadReplicas makes GetTenantReadConn take connections from replicas, in
+// turn. Replicas should follow the primary database. With none, as before the
+// first call, reads use the primary. Set them before serving traffic.
+func (m *manager) SetReadReplicas(replicas ...*sql.DB) {
+	m.replicasMu.Lock()
+	defer m.replicasMu.Unlock()
+	m.replicas = append([]*sql.DB(nil), replicas...)
+}
+
+// readReplica returns the next replica in turn, or nil if none are set
+func (m *manager) readReplica() *sql.DB {
+	m.replicasMu.RLock()
+	defer m.replicasMu.RUnlock()
+	if len(m.replicas) == 0 {
+		return nil
+	}
+	next := m.nextReplica.Add(1) - 1
+	return m.replicas[next%uint64(len(m.replicas))]
+}
+
+// GetTenantReadConn returns a connection scoped to the tenant like
+// GetTenantConn, but from a read replica so that reads take load off the
+// primary. Writes on it fail; use GetTenantConn or WithTenantTx for them.
+// Replicas lag the primary, so read your own writes from the primary. Without
+// replicas, and for tenants in a region, it returns GetTenantConn's connection.
+func (m *manager) GetTenantReadConn(ctx context.Context, tenantID uuid.UUID) (*sql.Conn, error) {
+	ctx, span := m.startSpan(ctx, "tenant.GetTenantReadConn", tenantID)
+	conn, err := m.getTenantReadConn(ctx, tenantID)
+	endSpan(span, err)
+	return conn, err
+}
+
+// getTenantReadConn implements GetTenantReadConn
+func (m *manager) getTenantReadConn(ctx context.Context, tenantID uuid.UUID) (*sql.Conn, error) {
+	replica := m.readReplica()
+	if replica == nil {
+		return m.getTenantConn(ctx, tenantID)
+	}
+	// Regional schemas are not on the primary's replicas
+	if _, regional, err := m.tenantRegion(ctx, tenantID); err != nil {
+		return nil, err
+	} else if regional {
+		return m.getTenantConn(ctx, tenantID)
+	}
+
+	conn, err := replica.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire replica connection: %w", err)
+	}
+	if err := m.scopeTenantConn(ctx, conn, tenantID, true); err != nil {
+		conn.Close() // Release connection on error
+		return nil, err
+	}
+
+	m.logger.Debug("Acquired tenant read connection",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("schema", m.schemaManager.GetSchemaName(tenantID)))
+
+	return conn, nil
+}
@@ -0,0 +1,76 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// searchPathsSet counts the SET search_path statements run for schema
+func searchPathsSet(recorder *execRecorder, schema string) int {
+	want := fmt.Sprintf(`SET search_path TO "%s", public`, schema)
+	count := 0
+	for _, statement := range recorder.statementsFor(schema) {
+		if statement == want {
+			count++
+		}
+	}
+	return count
+}
+
+func TestManager_GetTenantReadConn(t *testing.T) {
+	primary := &execRecorder{}
+	m := newExecTestManager(t, primary)
+	ctx := context.Background()
+	tenantID := uuid.New()
+	schema := m.schemaManager.GetSchemaName(tenantID)
+
+	// Without replicas reads use the primary
+	conn, err := m.GetTenantReadConn(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("GetTenantReadConn() error = %v", err)
+	}
+	conn.Close()
+	if n := searchPathsSet(primary, schema); n != 1 {
+		t.Fatalf("primary set search_path %d times, want 1", n)
+	}
+
+	replicas := []*execRecorder{{}, {}}
+	var dbs []*sql.DB
+	for _, recorder := range replicas {
+		db := sql.OpenDB(execTestConnector{recorder: recorder})
+		t.Cleanup(func() { db.Close() })
+		dbs = append(dbs, db)
+	}
+	m.SetReadReplicas(dbs...)
+
+	// Reads go to the replicas in turn, each connection scoped to the tenant
+	for i := 0; i < 4; i++ {
+		conn, err := m.GetTenantReadConn(ctx, tenantID)
+		if err != nil {
+			t.Fatalf("GetTenantReadConn() error = %v", err)
+		}
+		conn.Close()
+	}
+	for i, recorder := range replicas {
+		if n := searchPathsSet(recorder, schema); n != 2 {
+			t.Errorf("replica %d set search_path %d times, want 2", i, n)
+		}
+	}
+	if n := searchPathsSet(primary, schema); n != 1 {
+		t.Errorf("primary set search_path %d times after replica reads, want still 1", n)
+	}
+
+	// Writes stay on the primary
+	conn, err = m.GetTenantConn(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("GetTenantConn() error = %v", err)
+	}
+	conn.Close()
+	if n := searchPathsSet(primary, schema); n != 2 {
+		t.Errorf("primary set search_path %d times after GetTenantConn, want 2", n)
+	}
+}
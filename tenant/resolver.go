@@ -12,24 +12,74 @@ import (
 	"go.uber.org/zap"
 )
 
-// resolver implements the Resolver interface
+// validSubdomain matches subdomains of lowercase letters, numbers and inner hyphens
+var validSubdomain = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*[a-z0-9]$`)
+
+// resolver implements the Resolver interface. It holds only its configuration
+// and shared dependencies, so several resolvers with different strategies can
+// use the same repository.
 type resolver struct {
 	config     ResolverConfig
 	repository Repository
 	logger     *zap.Logger
+	notFound   *negativeCache // Subdomains with no tenant, nil if NegativeCacheTTL is unset
+	domains    *lookupCache   // Custom domain lookups, nil unless the strategy is custom_domain
 }
 
-// NewResolver creates a new tenant resolver
+// NewResolver creates a new tenant resolver. The custom_domain strategy needs
+// an ExtensibleRepository to look up custom domains.
 func NewResolver(config ResolverConfig, repository Repository, logger *zap.Logger) Resolver {
-	return &resolver{
+	r := &resolver{
 		config:     config,
 		repository: repository,
 		logger:     logger.Named("resolver"),
+		notFound:   newNegativeCache(config.NegativeCacheTTL, config.NegativeCacheSize),
+	}
+	if config.Strategy == ResolverCustomDomain {
+		ttl := config.CustomDomainCacheTTL
+		if ttl <= 0 {
+			ttl = DefaultCustomDomainCacheTTL
+		}
+		r.domains = newLookupCache(ttl, DefaultCustomDomainCacheSize)
 	}
+	return r
 }
 
 // ResolveTenant resolves tenant from HTTP request based on configured strategy
 func (r *resolver) ResolveTenant(ctx context.Context, req *http.Request) (uuid.UUID, error) {
+	switch r.config.Strategy {
+	case ResolverJWT:
+		return r.resolveFromJWT(ctx, req)
+	case ResolverCustomDomain:
+		return r.resolveFromCustomDomain(ctx, req)
+	}
+
+	subdomain, ok, err := r.extractSubdomain(req)
+	if !ok {
+		return uuid.UUID{}, fmt.Errorf("unknown resolver strategy: %s", r.config.Strategy)
+	}
+	if err != nil {
+		// A request that names no valid tenant is reported as not found
+		return uuid.UUID{}, fmt.Errorf("%w: %w", ErrTenantNotFound, err)
+	}
+
+	tenantID, err := r.lookupSubdomain(ctx, subdomain)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	r.logger.Debug("Resolved tenant",
+		zap.String("subdomain", subdomain),
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("strategy", r.config.Strategy))
+
+	return tenantID, nil
+}
+
+// extractSubdomain returns the subdomain the request names under the
+// configured strategy. It reports false for strategies that do not name
+// tenants by subdomain, such as jwt and custom_domain.
+func (r *resolver) extractSubdomain(req *http.Request) (string, bool, error) {
 	var subdomain string
 	var err error
 
@@ -41,39 +91,98 @@ func (r *resolver) ResolveTenant(ctx context.Context, req *http.Request) (uuid.U
 	case ResolverHeader:
 		subdomain, err = r.ExtractFromHeader(req)
 	default:
-		return uuid.UUID{}, fmt.Errorf("unknown resolver strategy: %s", r.config.Strategy)
+		return "", false, nil
+	}
+	return subdomain, true, err
+}
+
+// ResolveBySubdomain resolves a tenant from its subdomain, applying the same
+// validation as ResolveTenant, for callers such as background workers that have
+// no HTTP request
+func (r *resolver) ResolveBySubdomain(ctx context.Context, subdomain string) (uuid.UUID, error) {
+	if err := r.ValidateSubdomain(subdomain); err != nil {
+		return uuid.UUID{}, fmt.Errorf("%w: invalid subdomain: %w", ErrTenantNotFound, err)
 	}
 
+	return r.lookupSubdomain(ctx, subdomain)
+}
+
+// ResolveByID checks that a tenant with the given ID exists and returns its ID
+func (r *resolver) ResolveByID(ctx context.Context, tenantID uuid.UUID) (uuid.UUID, error) {
+	if tenantID == uuid.Nil {
+		return uuid.UUID{}, fmt.Errorf("%w: empty tenant ID", ErrTenantNotFound)
+	}
+
+	exists, err := r.tenantExists(ctx, tenantID)
 	if err != nil {
-		return uuid.UUID{}, err
+		r.logger.Error("Failed to look up tenant by ID",
+			zap.String("tenant_id", tenantID.String()),
+			zap.Error(err))
+		return uuid.UUID{}, fmt.Errorf("failed to look up tenant %s: %w", tenantID, err)
+	}
+	if !exists {
+		return uuid.UUID{}, fmt.Errorf("%w for ID: %s", ErrTenantNotFound, tenantID)
+	}
+
+	return tenantID, nil
+}
+
+// tenantExists reports whether a tenant exists, without loading its row when
+// the repository supports it
+func (r *resolver) tenantExists(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	if repo, ok := r.repository.(ExistenceRepository); ok {
+		return repo.ExistsByID(ctx, tenantID)
+	}
+
+	if _, err := r.repository.GetByID(ctx, tenantID); err != nil {
+		if IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// lookupSubdomain returns the ID of the tenant with a validated subdomain
+func (r *resolver) lookupSubdomain(ctx context.Context, subdomain string) (uuid.UUID, error) {
+	if r.notFound.has(subdomain) {
+		return uuid.UUID{}, fmt.Errorf("%w for subdomain: %s", ErrTenantNotFound, subdomain)
 	}
 
-	// Get tenant by subdomain
 	tenant, err := r.repository.GetBySubdomain(ctx, subdomain)
 	if err != nil {
-		r.logger.Debug("Failed to find tenant by subdomain",
+		if IsNotFound(err) {
+			r.logger.Debug("Failed to find tenant by subdomain",
+				zap.String("subdomain", subdomain),
+				zap.Error(err))
+			r.notFound.add(subdomain)
+			return uuid.UUID{}, fmt.Errorf("%w for subdomain: %s", ErrTenantNotFound, subdomain)
+		}
+
+		r.logger.Error("Failed to look up tenant by subdomain",
 			zap.String("subdomain", subdomain),
 			zap.Error(err))
-		return uuid.UUID{}, fmt.Errorf("tenant not found for subdomain: %s", subdomain)
+		return uuid.UUID{}, fmt.Errorf("failed to look up tenant for subdomain %s: %w", subdomain, err)
 	}
 
-	r.logger.Debug("Resolved tenant",
-		zap.String("subdomain", subdomain),
-		zap.String("tenant_id", tenant.ID.String()),
-		zap.String("strategy", r.config.Strategy))
-
 	return tenant.ID, nil
 }
 
+// Invalidate forgets that subdomain had no tenant, so that the next lookup
+// queries the repository. It implements SubdomainInvalidator.
+func (r *resolver) Invalidate(subdomain string) {
+	r.notFound.forget(subdomain)
+}
+
 // ExtractFromSubdomain extracts tenant subdomain from host
 func (r *resolver) ExtractFromSubdomain(host string) (string, error) {
 	if host == "" {
 		return "", errors.New("empty host")
 	}
 
-	// Remove port if present
-	if colonIndex := strings.Index(host, ":"); colonIndex != -1 {
-		host = host[:colonIndex]
+	host = normalizeHost(host)
+	if host == "" {
+		return "", errors.New("empty host")
 	}
 
 	// Extract subdomain from host (e.g., "tenant.domain.com" -> "tenant")
@@ -101,15 +210,28 @@ func (r *resolver) ExtractFromSubdomain(host string) (string, error) {
 	return subdomain, nil
 }
 
+// normalizeHost strips the port and any trailing dot from a host and lowercases
+// it, so that "Acme.Example.com.:8080" and "acme.example.com" are the same host
+func normalizeHost(host string) string {
+	if colonIndex := strings.Index(host, ":"); colonIndex != -1 {
+		host = host[:colonIndex]
+	}
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), "."))
+}
+
 // ExtractFromPath extracts tenant subdomain from URL path
 func (r *resolver) ExtractFromPath(path string) (string, error) {
 	if path == "" {
 		return "", errors.New("empty path")
 	}
 
+	if r.config.PathSegmentIndex != nil {
+		return r.extractFromPathSegment(path, *r.config.PathSegmentIndex)
+	}
+
 	prefix := r.config.PathPrefix
 	if prefix == "" {
-		prefix = "/tenant/"
+		prefix = DefaultResolverPathPrefix
 	}
 
 	if !strings.HasPrefix(path, prefix) {
@@ -134,11 +256,33 @@ func (r *resolver) ExtractFromPath(path string) (string, error) {
 	return subdomain, nil
 }
 
+// extractFromPathSegment extracts tenant subdomain from the Nth segment of the
+// URL path (e.g., index 0 of "/acme/projects" -> "acme")
+func (r *resolver) extractFromPathSegment(path string, index int) (string, error) {
+	if index < 0 {
+		return "", fmt.Errorf("invalid path segment index: %d", index)
+	}
+
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if index >= len(parts) || parts[index] == "" {
+		return "", errors.New("no tenant found in path")
+	}
+
+	subdomain := parts[index]
+
+	// Validate subdomain format
+	if err := r.ValidateSubdomain(subdomain); err != nil {
+		return "", fmt.Errorf("invalid subdomain: %w", err)
+	}
+
+	return subdomain, nil
+}
+
 // ExtractFromHeader extracts tenant subdomain from HTTP header
 func (r *resolver) ExtractFromHeader(req *http.Request) (string, error) {
 	headerName := r.config.HeaderName
 	if headerName == "" {
-		headerName = "X-Tenant"
+		headerName = DefaultResolverHeaderName
 	}
 
 	subdomain := req.Header.Get(headerName)
@@ -161,7 +305,6 @@ func (r *resolver) ValidateSubdomain(subdomain string) error {
 	}
 
 	// Check for valid characters (alphanumeric and hyphens only)
-	validSubdomain := regexp.MustCompile(`^[a-z0-9][a-z0-9-]*[a-z0-9]$`)
 	if !validSubdomain.MatchString(subdomain) {
 		return errors.New("subdomain must contain only lowercase letters, numbers, and hyphens, and cannot start or end with a hyphen")
 	}
@@ -2,8 +2,11 @@ package tenant
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap/zaptest"
@@ -61,6 +64,18 @@ func TestResolver_ResolveTenant(t *testing.T) {
 			wantID:  tenantID,
 			wantErr: false,
 		},
+		{
+			name: "subdomain strategy with mixed-case host and trailing dot",
+			config: ResolverConfig{
+				Strategy: ResolverSubdomain,
+				Domain:   "example.com",
+			},
+			req: &http.Request{
+				Host: "Test-Tenant.Example.com.",
+			},
+			wantID:  tenantID,
+			wantErr: false,
+		},
 		{
 			name: "path strategy success",
 			config: ResolverConfig{
@@ -74,6 +89,19 @@ func TestResolver_ResolveTenant(t *testing.T) {
 			wantID:  tenantID,
 			wantErr: false,
 		},
+		{
+			name: "path segment strategy success",
+			config: ResolverConfig{
+				Strategy:         ResolverPath,
+				PathSegmentIndex: func() *int { i := 0; return &i }(),
+			},
+			req: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test-tenant/projects", nil)
+				return req
+			}(),
+			wantID:  tenantID,
+			wantErr: false,
+		},
 		{
 			name: "header strategy success",
 			config: ResolverConfig{
@@ -153,12 +181,36 @@ func TestResolver_ExtractFromSubdomain(t *testing.T) {
 			want:    "test-tenant",
 			wantErr: false,
 		},
+		{
+			name:    "trailing dot",
+			host:    "test-tenant.example.com.",
+			want:    "test-tenant",
+			wantErr: false,
+		},
+		{
+			name:    "mixed case",
+			host:    "Test-Tenant.Example.COM",
+			want:    "test-tenant",
+			wantErr: false,
+		},
+		{
+			name:    "mixed case with trailing dot and port",
+			host:    "Acme.Example.Com.:8443",
+			want:    "acme",
+			wantErr: false,
+		},
 		{
 			name:    "empty host",
 			host:    "",
 			want:    "",
 			wantErr: true,
 		},
+		{
+			name:    "only a dot",
+			host:    ".",
+			want:    "",
+			wantErr: true,
+		},
 		{
 			name:    "invalid format - only domain",
 			host:    "example.com",
@@ -177,6 +229,18 @@ func TestResolver_ExtractFromSubdomain(t *testing.T) {
 			want:    "",
 			wantErr: true,
 		},
+		{
+			name:    "reserved subdomain - mixed case with trailing dot",
+			host:    "WWW.example.com.",
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "invalid format - only domain with trailing dot",
+			host:    "example.com.",
+			want:    "",
+			wantErr: true,
+		},
 		{
 			name:    "invalid subdomain format - starts with hyphen",
 			host:    "-invalid.example.com",
@@ -319,6 +383,50 @@ func TestResolver_ExtractFromPath_DefaultPrefix(t *testing.T) {
 	}
 }
 
+func TestResolver_ExtractFromPath_SegmentIndex(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	tests := []struct {
+		name    string
+		index   int
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "first segment", index: 0, path: "/acme/projects", want: "acme"},
+		{name: "first segment only", index: 0, path: "/acme", want: "acme"},
+		{name: "first segment with trailing slash", index: 0, path: "/acme/", want: "acme"},
+		{name: "second segment", index: 1, path: "/api/acme/projects", want: "acme"},
+		{name: "root path", index: 0, path: "/", wantErr: true},
+		{name: "segment out of range", index: 2, path: "/acme/projects", wantErr: true},
+		{name: "empty segment", index: 1, path: "/api//projects", wantErr: true},
+		{name: "invalid subdomain", index: 0, path: "/Acme_Corp/projects", wantErr: true},
+		{name: "reserved subdomain", index: 0, path: "/admin/projects", wantErr: true},
+		{name: "negative index", index: -1, path: "/acme/projects", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index := tt.index
+			config := ResolverConfig{
+				Strategy:          ResolverPath,
+				PathSegmentIndex:  &index,
+				ReservedSubdomain: []string{"admin"},
+			}
+			resolver := NewResolver(config, &mockRepository{}, logger)
+
+			got, err := resolver.ExtractFromPath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExtractFromPath() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ExtractFromPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestResolver_ExtractFromHeader(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	config := ResolverConfig{
@@ -489,6 +597,257 @@ func TestResolver_ValidateSubdomain(t *testing.T) {
 	}
 }
 
+func TestResolver_ResolveTenant_NotFoundVsLookupFailure(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	config := ResolverConfig{Strategy: ResolverSubdomain, Domain: "example.com"}
+	req := &http.Request{Host: "acme.example.com"}
+
+	// Unknown tenant
+	resolver := NewResolver(config, &mockRepository{tenants: map[uuid.UUID]*Tenant{}}, logger)
+	_, err := resolver.ResolveTenant(context.Background(), req)
+	if !errors.Is(err, ErrTenantNotFound) {
+		t.Errorf("ResolveTenant() error = %v, want ErrTenantNotFound", err)
+	}
+
+	// A request that names no tenant is also not found
+	_, err = resolver.ResolveTenant(context.Background(), &http.Request{Host: "localhost"})
+	if !IsNotFound(err) {
+		t.Errorf("ResolveTenant() error = %v for invalid host, want not found", err)
+	}
+
+	// Repositories returning sql.ErrNoRows are treated as not found
+	resolver = NewResolver(config, &failingRepository{err: sql.ErrNoRows}, logger)
+	if _, err := resolver.ResolveTenant(context.Background(), req); !errors.Is(err, ErrTenantNotFound) {
+		t.Errorf("ResolveTenant() error = %v, want ErrTenantNotFound for sql.ErrNoRows", err)
+	}
+
+	// Infrastructure errors are passed through, not reported as not found
+	dbErr := errors.New("dial tcp 10.0.0.5:5432: connection refused")
+	resolver = NewResolver(config, &failingRepository{err: dbErr}, logger)
+	_, err = resolver.ResolveTenant(context.Background(), req)
+	if err == nil || IsNotFound(err) {
+		t.Errorf("ResolveTenant() error = %v, want lookup failure", err)
+	}
+	if !errors.Is(err, dbErr) {
+		t.Errorf("ResolveTenant() error = %v, should wrap the repository error", err)
+	}
+}
+
+func TestResolver_ResolveBySubdomain(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	tenantID := uuid.New()
+	mockRepo := &mockRepository{
+		tenants: map[uuid.UUID]*Tenant{
+			tenantID: {ID: tenantID, Subdomain: "acme", Status: StatusActive},
+		},
+	}
+	config := ResolverConfig{Strategy: ResolverHeader, ReservedSubdomain: []string{"www", "api"}}
+	resolver := NewResolver(config, mockRepo, logger)
+	ctx := context.Background()
+
+	got, err := resolver.ResolveBySubdomain(ctx, "acme")
+	if err != nil {
+		t.Fatalf("ResolveBySubdomain() error = %v", err)
+	}
+	if got != tenantID {
+		t.Errorf("ResolveBySubdomain() = %v, want %v", got, tenantID)
+	}
+
+	for _, subdomain := range []string{"globex", "api", "-bad-", "ab", ""} {
+		if _, err := resolver.ResolveBySubdomain(ctx, subdomain); !errors.Is(err, ErrTenantNotFound) {
+			t.Errorf("ResolveBySubdomain(%q) error = %v, want ErrTenantNotFound", subdomain, err)
+		}
+	}
+
+	// Infrastructure errors are passed through, as for ResolveTenant
+	dbErr := errors.New("connection refused")
+	resolver = NewResolver(config, &failingRepository{err: dbErr}, logger)
+	if _, err := resolver.ResolveBySubdomain(ctx, "acme"); !errors.Is(err, dbErr) || IsNotFound(err) {
+		t.Errorf("ResolveBySubdomain() error = %v, want lookup failure", err)
+	}
+}
+
+func TestResolver_ResolveByID(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	tenantID := uuid.New()
+	mockRepo := &mockRepository{
+		tenants: map[uuid.UUID]*Tenant{
+			tenantID: {ID: tenantID, Subdomain: "acme", Status: StatusActive},
+		},
+	}
+	resolver := NewResolver(ResolverConfig{Strategy: ResolverSubdomain}, mockRepo, logger)
+	ctx := context.Background()
+
+	got, err := resolver.ResolveByID(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("ResolveByID() error = %v", err)
+	}
+	if got != tenantID {
+		t.Errorf("ResolveByID() = %v, want %v", got, tenantID)
+	}
+
+	for _, id := range []uuid.UUID{uuid.New(), uuid.Nil} {
+		if _, err := resolver.ResolveByID(ctx, id); !errors.Is(err, ErrTenantNotFound) {
+			t.Errorf("ResolveByID(%v) error = %v, want ErrTenantNotFound", id, err)
+		}
+	}
+}
+
+func TestResolver_MultipleStrategiesShareRepository(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	tenantID := uuid.New()
+	repo := &mockRepository{
+		tenants: map[uuid.UUID]*Tenant{
+			tenantID: {ID: tenantID, Subdomain: "acme", Status: StatusActive},
+		},
+	}
+
+	app := NewResolver(ResolverConfig{Strategy: ResolverSubdomain, Domain: "example.com"}, repo, logger)
+	admin := NewResolver(ResolverConfig{Strategy: ResolverHeader, HeaderName: "X-Admin-Tenant"}, repo, logger)
+
+	appReq := &http.Request{Host: "acme.example.com", Header: http.Header{}}
+	adminReq := &http.Request{Host: "admin.example.com", Header: http.Header{"X-Admin-Tenant": []string{"acme"}}}
+
+	for name, tt := range map[string]struct {
+		resolver Resolver
+		req      *http.Request
+	}{
+		"subdomain": {app, appReq},
+		"header":    {admin, adminReq},
+	} {
+		got, err := tt.resolver.ResolveTenant(context.Background(), tt.req)
+		if err != nil {
+			t.Fatalf("%s ResolveTenant() error = %v", name, err)
+		}
+		if got != tenantID {
+			t.Errorf("%s ResolveTenant() = %v, want %v", name, got, tenantID)
+		}
+	}
+
+	// Each resolver only uses its own strategy
+	if _, err := app.ResolveTenant(context.Background(), adminReq); err == nil {
+		t.Error("subdomain resolver should ignore the admin header")
+	}
+	if _, err := admin.ResolveTenant(context.Background(), appReq); err == nil {
+		t.Error("header resolver should not resolve a request without the header")
+	}
+}
+
+func TestResolver_NegativeCache(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	ctx := context.Background()
+	repo := &countingRepository{MockManagerRepository: NewMockRepository()}
+	config := ResolverConfig{Strategy: ResolverSubdomain, Domain: "example.com", NegativeCacheTTL: time.Minute}
+	r := NewResolver(config, repo, logger).(*resolver)
+	now := time.Now()
+	r.notFound.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if _, err := r.ResolveBySubdomain(ctx, "missing"); !errors.Is(err, ErrTenantNotFound) {
+			t.Fatalf("ResolveBySubdomain() error = %v, want ErrTenantNotFound", err)
+		}
+	}
+	if repo.getBySubdomainCalls != 1 {
+		t.Errorf("repeated not-found lookups queried the repository %d times, want 1", repo.getBySubdomainCalls)
+	}
+
+	// Entries expire after the TTL
+	now = now.Add(time.Minute)
+	r.ResolveBySubdomain(ctx, "missing")
+	if repo.getBySubdomainCalls != 2 {
+		t.Errorf("lookup after the TTL queried the repository %d times in total, want 2", repo.getBySubdomainCalls)
+	}
+
+	// Lookup failures are not cached
+	failing := &failingRepository{err: errors.New("connection refused")}
+	r = NewResolver(config, failing, logger).(*resolver)
+	r.ResolveBySubdomain(ctx, "missing")
+	if r.notFound.has("missing") {
+		t.Error("a failed lookup should not be cached as not found")
+	}
+}
+
+func TestResolver_NegativeCache_InvalidatedByCreate(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	ctx := context.Background()
+	config := DefaultConfig()
+	config.Resolver.NegativeCacheTTL = time.Hour
+
+	repo := &countingRepository{MockManagerRepository: NewMockRepository()}
+	m := NewManager(config, nil, repo, NewMockSchemaManager(config.Database.SchemaPrefix),
+		NewMockMigrationManager(), NewMockLimitChecker(config.Limits), logger)
+	r := NewResolver(config.Resolver, repo, logger)
+	m.AddSubdomainInvalidator(r.(SubdomainInvalidator))
+
+	if _, err := r.ResolveBySubdomain(ctx, "acme"); !errors.Is(err, ErrTenantNotFound) {
+		t.Fatalf("ResolveBySubdomain() error = %v, want ErrTenantNotFound", err)
+	}
+
+	tenant := &Tenant{Name: "Acme", Subdomain: "acme"}
+	if err := m.CreateTenant(ctx, tenant); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+
+	got, err := r.ResolveBySubdomain(ctx, "acme")
+	if err != nil || got != tenant.ID {
+		t.Errorf("ResolveBySubdomain() = %v, %v after create; want %v", got, err, tenant.ID)
+	}
+
+	// Renaming a tenant onto a cached subdomain also invalidates it
+	r.ResolveBySubdomain(ctx, "acme-corp")
+	tenant.Subdomain = "acme-corp"
+	if err := m.UpdateTenant(ctx, tenant); err != nil {
+		t.Fatalf("UpdateTenant() error = %v", err)
+	}
+	if got, err := r.ResolveBySubdomain(ctx, "acme-corp"); err != nil || got != tenant.ID {
+		t.Errorf("ResolveBySubdomain() = %v, %v after update; want %v", got, err, tenant.ID)
+	}
+}
+
+func TestManager_AddSubdomainInvalidator_Unregister(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	ctx := context.Background()
+	config := DefaultConfig()
+	config.Resolver.NegativeCacheTTL = time.Hour
+
+	repo := NewMockRepository()
+	m := NewManager(config, nil, repo, NewMockSchemaManager(config.Database.SchemaPrefix),
+		NewMockMigrationManager(), NewMockLimitChecker(config.Limits), logger).(*manager)
+	r := NewResolver(config.Resolver, repo, logger)
+
+	// Registering the same cache again does not add it twice
+	unregister := m.AddSubdomainInvalidator(r.(SubdomainInvalidator))
+	m.AddSubdomainInvalidator(r.(SubdomainInvalidator))
+	if len(m.invalidators) != 1 {
+		t.Fatalf("registered %d invalidators, want 1", len(m.invalidators))
+	}
+
+	unregister()
+	unregister()
+	if len(m.invalidators) != 0 {
+		t.Fatalf("registered %d invalidators after unregistering, want none", len(m.invalidators))
+	}
+
+	// An unregistered cache is no longer told about new tenants
+	r.ResolveBySubdomain(ctx, "acme")
+	if err := m.CreateTenant(ctx, &Tenant{Name: "Acme", Subdomain: "acme"}); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+	if !r.(*resolver).notFound.has("acme") {
+		t.Error("an unregistered cache should not be invalidated")
+	}
+}
+
+// failingRepository is a resolver mock whose lookups fail with err
+type failingRepository struct {
+	mockRepository
+	err error
+}
+
+func (f *failingRepository) GetBySubdomain(ctx context.Context, subdomain string) (*Tenant, error) {
+	return nil, f.err
+}
+
 // mockRepository is a simple mock for testing resolver
 type mockRepository struct {
 	tenants map[uuid.UUID]*Tenant
@@ -537,9 +896,3 @@ func (m *mockRepository) List(ctx context.Context, page, perPage int) ([]*Tenant
 func (m *mockRepository) GetStats(ctx context.Context, tenantID uuid.UUID) (*Stats, error) {
 	return &Stats{TenantID: tenantID}, nil
 }
-
-// ErrTenantNotFound is a mock error for tenant not found
-var ErrTenantNotFound = &TenantError{
-	Code:    "TENANT_NOT_FOUND",
-	Message: "tenant not found",
-}
@@ -0,0 +1,88 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// SchemaRenamer is implemented by schema managers that can rename a schema in
+// place, such as database.SchemaManager. RenameTenantSchema requires it.
+type SchemaRenamer interface {
+	RenameSchema(ctx context.Context, oldName, newName string) error
+}
+
+// SchemaNameUpdater is implemented by repositories that can record a new name
+// for a tenant's schema, such as postgres.Repository. RenameTenantSchema
+// requires it.
+type SchemaNameUpdater interface {
+	UpdateSchemaName(ctx context.Context, id uuid.UUID, schemaName string) error
+}
+
+// ErrSchemaRenameUnsupported is returned by RenameTenantSchema when the schema
+// manager is not a SchemaRenamer or the repository is not a SchemaNameUpdater
+var ErrSchemaRenameUnsupported = errors.New("schema manager cannot rename schemas")
+
+// RenameTenantSchema moves the tenant's schema from the name recorded on the
+// tenant to newName, keeping its data, and records the new name. Connections,
+// migrations and schema operations derive schema names from the configured
+// SchemaPrefix, so newName must be the name GetSchemaName gives the tenant
+// under this manager's prefix; any other name is rejected with a
+// ValidationError. Use it to move tenants to a new SchemaPrefix without
+// exporting and importing their data: run it from a manager configured with
+// the new prefix, and keep tenants that have not been renamed yet away from
+// that manager until they are. Cached connections to the old name are closed.
+func (m *manager) RenameTenantSchema(ctx context.Context, id uuid.UUID, newName string) error {
+	schemaManager, err := m.tenantSchemaManager(ctx, id)
+	if err != nil {
+		return err
+	}
+	renamer, ok := schemaManager.(SchemaRenamer)
+	if !ok {
+		return ErrSchemaRenameUnsupported
+	}
+	updater, ok := m.repository.(SchemaNameUpdater)
+	if !ok {
+		return ErrSchemaRenameUnsupported
+	}
+
+	if want := schemaManager.GetSchemaName(id); newName != want {
+		return &ValidationError{Field: "schema_name", Message: fmt.Sprintf("schema name must be %s, the tenant's name under the configured prefix", want)}
+	}
+
+	tenant, err := m.repository.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get tenant: %w", err)
+	}
+	oldName := tenant.SchemaName
+	if oldName == newName {
+		return nil
+	}
+
+	defer m.tenants.invalidate(id)
+	if err := renamer.RenameSchema(ctx, oldName, newName); err != nil {
+		return fmt.Errorf("failed to rename schema: %w", err)
+	}
+	if err := updater.UpdateSchemaName(ctx, id, newName); err != nil {
+		// Put the schema back so that it matches the tenant record
+		if undoErr := renamer.RenameSchema(ctx, newName, oldName); undoErr != nil {
+			m.logger.Error("Failed to restore schema name after the tenant update failed",
+				zap.String("tenant_id", id.String()),
+				zap.String("schema_name", newName),
+				zap.String("recorded_schema_name", oldName),
+				zap.Error(undoErr))
+		}
+		return fmt.Errorf("failed to record schema name: %w", err)
+	}
+	m.connections.remove(id)
+
+	m.logger.Info("Renamed tenant schema",
+		zap.String("tenant_id", id.String()),
+		zap.String("old_schema_name", oldName),
+		zap.String("schema_name", newName))
+
+	return nil
+}
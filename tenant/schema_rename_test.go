@@ -0,0 +1,118 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+// renamingSchemaManager is a mock SchemaRenamer that records renames
+type renamingSchemaManager struct {
+	*MockManagerSchemaManager
+	renames [][2]string
+	err     error
+}
+
+func (s *renamingSchemaManager) RenameSchema(ctx context.Context, oldName, newName string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.renames = append(s.renames, [2]string{oldName, newName})
+	return nil
+}
+
+// schemaNameRepository is a mock SchemaNameUpdater
+type schemaNameRepository struct {
+	*MockManagerRepository
+	err error
+}
+
+func (r *schemaNameRepository) UpdateSchemaName(ctx context.Context, id uuid.UUID, schemaName string) error {
+	if r.err != nil {
+		return r.err
+	}
+	t, ok := r.tenants[id]
+	if !ok {
+		return ErrTenantNotFound
+	}
+	t.SchemaName = schemaName
+	return nil
+}
+
+func TestManager_RenameTenantSchema(t *testing.T) {
+	config := DefaultConfig()
+	config.Database.SchemaPrefix = "moved_"
+	logger := zaptest.NewLogger(t)
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	plain := NewManager(config, nil, NewMockRepository(), NewMockSchemaManager(config.Database.SchemaPrefix),
+		NewMockMigrationManager(), NewMockLimitChecker(config.Limits), logger)
+	if err := plain.RenameTenantSchema(ctx, tenantID, "renamed"); !errors.Is(err, ErrSchemaRenameUnsupported) {
+		t.Errorf("RenameTenantSchema() error = %v, want ErrSchemaRenameUnsupported", err)
+	}
+
+	schemas := &renamingSchemaManager{MockManagerSchemaManager: NewMockSchemaManager(config.Database.SchemaPrefix)}
+	repo := &schemaNameRepository{MockManagerRepository: NewMockRepository()}
+	repo.tenants[tenantID] = &Tenant{ID: tenantID, Subdomain: "rename", SchemaName: "tenant_old"}
+	m := NewManager(config, nil, repo, schemas,
+		NewMockMigrationManager(), NewMockLimitChecker(config.Limits), logger)
+	newName := schemas.GetSchemaName(tenantID)
+
+	// Only the name under the configured prefix is reachable afterwards
+	var validationErr *ValidationError
+	if err := m.RenameTenantSchema(ctx, tenantID, "renamed"); !errors.As(err, &validationErr) {
+		t.Errorf("RenameTenantSchema(other name) error = %v, want a ValidationError", err)
+	}
+	if len(schemas.renames) != 0 {
+		t.Errorf("renames = %v, want none for a rejected name", schemas.renames)
+	}
+
+	if err := m.RenameTenantSchema(ctx, tenantID, newName); err != nil {
+		t.Fatalf("RenameTenantSchema() error = %v", err)
+	}
+	if len(schemas.renames) != 1 || schemas.renames[0] != [2]string{"tenant_old", newName} {
+		t.Errorf("renames = %v, want tenant_old to %s", schemas.renames, newName)
+	}
+	if got := repo.tenants[tenantID].SchemaName; got != newName {
+		t.Errorf("recorded schema name = %q, want %q", got, newName)
+	}
+
+	// Renaming again does nothing
+	if err := m.RenameTenantSchema(ctx, tenantID, newName); err != nil || len(schemas.renames) != 1 {
+		t.Errorf("second RenameTenantSchema() = %v with renames %v, want a no-op", err, schemas.renames)
+	}
+
+	repo.tenants[tenantID].SchemaName = "tenant_old"
+	schemas.err = &ValidationError{Field: "schema_name", Message: "schema already exists"}
+	if err := m.RenameTenantSchema(ctx, tenantID, newName); !errors.As(err, &validationErr) {
+		t.Errorf("RenameTenantSchema() error = %v, want the collision reported", err)
+	}
+	if got := repo.tenants[tenantID].SchemaName; got != "tenant_old" {
+		t.Errorf("recorded schema name = %q after a failed rename, want it unchanged", got)
+	}
+}
+
+func TestManager_RenameTenantSchema_RestoresSchemaWhenRecordFails(t *testing.T) {
+	config := DefaultConfig()
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	schemas := &renamingSchemaManager{MockManagerSchemaManager: NewMockSchemaManager(config.Database.SchemaPrefix)}
+	repo := &schemaNameRepository{MockManagerRepository: NewMockRepository(), err: errors.New("connection reset")}
+	repo.tenants[tenantID] = &Tenant{ID: tenantID, Subdomain: "rename", SchemaName: "old_schema"}
+	m := NewManager(config, nil, repo, schemas,
+		NewMockMigrationManager(), NewMockLimitChecker(config.Limits), zaptest.NewLogger(t))
+	newName := schemas.GetSchemaName(tenantID)
+
+	if err := m.RenameTenantSchema(ctx, tenantID, newName); err == nil {
+		t.Fatal("RenameTenantSchema() error = nil, want the record failure")
+	}
+	want := [][2]string{{"old_schema", newName}, {newName, "old_schema"}}
+	if len(schemas.renames) != 2 || schemas.renames[0] != want[0] || schemas.renames[1] != want[1] {
+		t.Errorf("renames = %v, want %v", schemas.renames, want)
+	}
+}
@@ -0,0 +1,221 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Self-check finding categories
+const (
+	SelfCheckDatabase           = "database"
+	SelfCheckMasterTables       = "master_tables"
+	SelfCheckPlanLimits         = "plan_limits"
+	SelfCheckReservedSubdomains = "reserved_subdomains"
+	SelfCheckTenantSchemas      = "tenant_schemas"
+)
+
+// Self-check finding severities
+const (
+	SeverityError   = "error"   // the system is misconfigured or broken
+	SeverityWarning = "warning" // suspicious but not necessarily wrong
+)
+
+// masterTables are the tables in the public schema the manager relies on
+var masterTables = []string{"tenants", "tenant_migrations"}
+
+// SelfCheckFinding is a single problem found by SelfCheck
+type SelfCheckFinding struct {
+	Category string    `json:"category"`
+	Severity string    `json:"severity"`
+	Message  string    `json:"message"`
+	TenantID uuid.UUID `json:"tenant_id,omitzero"` // set for findings about one tenant
+}
+
+// SelfCheckReport is the outcome of SelfCheck
+type SelfCheckReport struct {
+	CheckedAt time.Time          `json:"checked_at"`
+	Healthy   bool               `json:"healthy"` // no error findings
+	Findings  []SelfCheckFinding `json:"findings"`
+}
+
+// ByCategory returns the findings in a category
+func (r *SelfCheckReport) ByCategory(category string) []SelfCheckFinding {
+	var findings []SelfCheckFinding
+	for _, finding := range r.Findings {
+		if finding.Category == category {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+func (r *SelfCheckReport) add(category, severity, format string, args ...interface{}) {
+	r.Findings = append(r.Findings, SelfCheckFinding{
+		Category: category,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// SelfCheck verifies the running configuration: that the database is
+// reachable and has the master tables, that every plan's limits validate
+// against the limit schema and that the reserved subdomains are well-formed
+// and unused. With Config.SelfCheckTenantSchemas set it also checks that every
+// active tenant has a schema. Problems are reported as findings; the returned
+// error is only set if the context ends before the check completes.
+func (m *manager) SelfCheck(ctx context.Context) (*SelfCheckReport, error) {
+	report := &SelfCheckReport{CheckedAt: time.Now(), Findings: []SelfCheckFinding{}}
+
+	if m.checkDatabase(ctx, report) {
+		m.checkMasterTables(ctx, report)
+	}
+	m.checkPlanLimits(report)
+	m.checkReservedSubdomains(ctx, report)
+	if m.config.SelfCheckTenantSchemas {
+		m.checkTenantSchemas(ctx, report)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	report.Healthy = true
+	for _, finding := range report.Findings {
+		if finding.Severity == SeverityError {
+			report.Healthy = false
+			break
+		}
+	}
+
+	m.logger.Info("Completed self-check",
+		zap.Bool("healthy", report.Healthy),
+		zap.Int("findings", len(report.Findings)))
+
+	return report, nil
+}
+
+// checkDatabase reports whether the database is reachable
+func (m *manager) checkDatabase(ctx context.Context, report *SelfCheckReport) bool {
+	if m.db == nil {
+		report.add(SelfCheckDatabase, SeverityWarning, "no database handle configured; database checks skipped")
+		return false
+	}
+
+	if err := m.db.PingContext(ctx); err != nil {
+		report.add(SelfCheckDatabase, SeverityError, "database is unreachable: %v", err)
+		return false
+	}
+	return true
+}
+
+// checkMasterTables reports master tables missing from the public schema
+func (m *manager) checkMasterTables(ctx context.Context, report *SelfCheckReport) {
+	for _, table := range masterTables {
+		var exists bool
+		err := m.db.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL`, "public."+table).Scan(&exists)
+		if err != nil {
+			report.add(SelfCheckMasterTables, SeverityError, "failed to check master table %s: %v", table, err)
+			continue
+		}
+		if !exists {
+			report.add(SelfCheckMasterTables, SeverityError, "master table public.%s does not exist", table)
+		}
+	}
+}
+
+// checkPlanLimits reports plans without limits and limits that do not
+// validate against the schema or cannot be read as their declared type
+func (m *manager) checkPlanLimits(report *SelfCheckReport) {
+	plans := map[string]bool{PlanBasic: true, PlanPro: true, PlanEnterprise: true}
+	for plan := range m.config.Limits.PlanLimits {
+		plans[plan] = true
+	}
+
+	names := make([]string, 0, len(plans))
+	for plan := range plans {
+		names = append(names, plan)
+	}
+	sort.Strings(names)
+
+	for _, plan := range names {
+		limits := m.limitChecker.GetLimitsForPlan(plan)
+		if len(limits) == 0 {
+			report.add(SelfCheckPlanLimits, SeverityWarning, "plan %s has no limits defined", plan)
+			continue
+		}
+
+		if err := m.limitChecker.ValidateLimits(plan, limits); err != nil {
+			report.add(SelfCheckPlanLimits, SeverityError, "plan %s: %v", plan, err)
+		}
+
+		limitNames := limits.Keys()
+		sort.Strings(limitNames)
+		for _, name := range limitNames {
+			if err := limits[name].check(); err != nil {
+				report.add(SelfCheckPlanLimits, SeverityError, "plan %s: limit '%s': %v", plan, name, err)
+			}
+		}
+	}
+}
+
+// checkReservedSubdomains reports reserved subdomains that are malformed,
+// duplicated or already used by a tenant
+func (m *manager) checkReservedSubdomains(ctx context.Context, report *SelfCheckReport) {
+	seen := make(map[string]bool)
+	for _, reserved := range m.config.Resolver.ReservedSubdomain {
+		subdomain := strings.ToLower(strings.TrimSpace(reserved))
+		if subdomain == "" {
+			report.add(SelfCheckReservedSubdomains, SeverityError, "reserved subdomain list contains an empty entry")
+			continue
+		}
+		if seen[subdomain] {
+			report.add(SelfCheckReservedSubdomains, SeverityWarning, "reserved subdomain %q is listed more than once", reserved)
+			continue
+		}
+		seen[subdomain] = true
+
+		if subdomain != reserved || !validSubdomain.MatchString(subdomain) {
+			report.add(SelfCheckReservedSubdomains, SeverityWarning, "reserved subdomain %q is not a valid lowercase subdomain", reserved)
+		}
+
+		exists, err := m.subdomainExists(ctx, subdomain)
+		if err != nil {
+			report.add(SelfCheckReservedSubdomains, SeverityError, "failed to check reserved subdomain %q: %v", subdomain, err)
+			continue
+		}
+		if exists {
+			report.add(SelfCheckReservedSubdomains, SeverityWarning, "reserved subdomain %q is used by an existing tenant", subdomain)
+		}
+	}
+}
+
+// checkTenantSchemas reports active tenants without a schema
+func (m *manager) checkTenantSchemas(ctx context.Context, report *SelfCheckReport) {
+	err := m.eachActiveTenant(ctx, func(t *Tenant) bool {
+		// Regional tenants are checked in their region's database
+		schemas, err := m.tenantSchemaManager(ctx, t.ID)
+		exists := false
+		if err == nil {
+			exists, err = schemas.SchemaExists(ctx, t.ID)
+		}
+		switch {
+		case err != nil:
+			report.add(SelfCheckTenantSchemas, SeverityError, "failed to check schema of tenant %s: %v", t.Subdomain, err)
+		case !exists:
+			report.add(SelfCheckTenantSchemas, SeverityError, "active tenant %s has no schema %s", t.Subdomain, schemas.GetSchemaName(t.ID))
+		default:
+			return true
+		}
+		report.Findings[len(report.Findings)-1].TenantID = t.ID
+		return true
+	})
+	if err != nil {
+		report.add(SelfCheckTenantSchemas, SeverityError, "%v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestManager_SelfCheck(t *testing.T) {
+	m, repo, schemas := newSelfCheckTestManager(t, DefaultConfig())
+
+	report, err := m.SelfCheck(context.Background())
+	if err != nil {
+		t.Fatalf("SelfCheck() error = %v", err)
+	}
+	if !report.Healthy {
+		t.Errorf("SelfCheck() of the default configuration = %+v, want healthy", report.Findings)
+	}
+	if findings := report.ByCategory(SelfCheckDatabase); len(findings) != 1 || findings[0].Severity != SeverityWarning {
+		t.Errorf("database findings = %+v, want a warning that checks were skipped", findings)
+	}
+
+	// Active tenants are only checked for schemas when enabled
+	m.config.SelfCheckTenantSchemas = true
+	provisioned := &Tenant{Name: "Acme", Subdomain: "acme", Status: StatusActive}
+	missing := &Tenant{Name: "Globex", Subdomain: "globex", Status: StatusActive}
+	for _, tenant := range []*Tenant{provisioned, missing} {
+		if err := m.CreateTenant(context.Background(), tenant); err != nil {
+			t.Fatalf("CreateTenant() error = %v", err)
+		}
+	}
+	schemas.schemas[provisioned.ID] = true
+	delete(schemas.schemas, missing.ID)
+
+	// A tenant created before "admin" became reserved
+	legacy := &Tenant{ID: uuid.New(), Subdomain: "admin", Status: StatusSuspended}
+	repo.tenants[legacy.ID] = legacy
+
+	report, err = m.SelfCheck(context.Background())
+	if err != nil {
+		t.Fatalf("SelfCheck() error = %v", err)
+	}
+	if report.Healthy {
+		t.Error("SelfCheck() should be unhealthy with a tenant missing its schema")
+	}
+	findings := report.ByCategory(SelfCheckTenantSchemas)
+	if len(findings) != 1 || findings[0].TenantID != missing.ID {
+		t.Errorf("tenant schema findings = %+v, want only %s", findings, missing.ID)
+	}
+	if findings := report.ByCategory(SelfCheckReservedSubdomains); len(findings) != 1 || !strings.Contains(findings[0].Message, `"admin" is used`) {
+		t.Errorf("reserved subdomain findings = %+v, want admin in use", findings)
+	}
+}
+
+func TestManager_SelfCheck_InconsistentConfig(t *testing.T) {
+	config := DefaultConfig()
+
+	inconsistent := make(FlexibleLimits)
+	for name, limit := range config.Limits.PlanLimits[PlanPro] {
+		inconsistent[name] = &LimitValue{Type: limit.Type, Value: limit.Value}
+	}
+	inconsistent.Set(LimitNameMaxProjects, LimitTypeInt, "ten") // not an int
+	inconsistent.Set("max_widgets", LimitTypeInt, 5)            // not in the schema
+	config.Limits.PlanLimits[PlanPro] = inconsistent
+	delete(config.Limits.PlanLimits, PlanEnterprise)
+
+	config.Resolver.ReservedSubdomain = []string{"www", "WWW", "", "mail_"}
+
+	m, _, _ := newSelfCheckTestManager(t, config)
+
+	report, err := m.SelfCheck(context.Background())
+	if err != nil {
+		t.Fatalf("SelfCheck() error = %v", err)
+	}
+	if report.Healthy {
+		t.Error("SelfCheck() should be unhealthy with an inconsistent plan")
+	}
+
+	var messages []string
+	for _, finding := range report.ByCategory(SelfCheckPlanLimits) {
+		messages = append(messages, finding.Severity+": "+finding.Message)
+	}
+	got := strings.Join(messages, "\n")
+	for _, want := range []string{
+		"warning: plan enterprise has no limits defined",
+		"error: plan pro: unknown limit 'max_widgets'",
+		"error: plan pro: limit 'max_projects': cannot convert string to int",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("plan limit findings:\n%s\nwant %q", got, want)
+		}
+	}
+	if strings.Contains(got, "plan basic") {
+		t.Errorf("plan limit findings:\n%s\nwant none for the basic plan", got)
+	}
+
+	if findings := report.ByCategory(SelfCheckReservedSubdomains); len(findings) != 3 {
+		t.Errorf("reserved subdomain findings = %+v, want a duplicate, an empty and an invalid entry", findings)
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if strings.Contains(string(data), `"tenant_id"`) {
+		t.Errorf("report JSON %s should omit tenant_id for findings about no tenant", data)
+	}
+}
+
+// newSelfCheckTestManager returns a manager using the real limit checker
+func newSelfCheckTestManager(t *testing.T, config Config) (*manager, *MockManagerRepository, *MockManagerSchemaManager) {
+	repo := NewMockRepository()
+	schemas := NewMockSchemaManager(config.Database.SchemaPrefix)
+	m := newTestManager(t, withRepository(repo), withSchemaManager(schemas), withRealLimitChecker(),
+		withConfig(func(c *Config) { *c = config }))
+
+	return m, repo, schemas
+}
@@ -0,0 +1,88 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidSequenceName is returned, wrapped, by NextTenantSequence for names
+// that are not lowercase identifiers
+var ErrInvalidSequenceName = errors.New("invalid sequence name")
+
+// sequencePrefix keeps tenant sequences apart from the application's tables
+// and the sequences behind its serial columns
+const sequencePrefix = "seq_"
+
+// validSequenceName matches a lowercase identifier that fits, with the prefix,
+// in PostgreSQL's 63 byte identifier limit
+var validSequenceName = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,58}$`)
+
+// NextTenantSequence returns the next value of the tenant's sequence called
+// name, e.g. for invoice numbers that start at 1 for every tenant. The
+// sequence lives in the tenant's schema as seq_<name> and is created on first
+// use. Values come from PostgreSQL's nextval, so concurrent callers never get
+// the same value, although a value taken by a transaction that rolls back is
+// not reused.
+func (m *manager) NextTenantSequence(ctx context.Context, tenantID uuid.UUID, name string) (int64, error) {
+	if !validSequenceName.MatchString(name) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSequenceName, name)
+	}
+
+	// Regional tenants keep their sequences in the region's database
+	db, schemas := m.db, m.schemaManager
+	region, regional, err := m.tenantRegion(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	if regional {
+		db, schemas = region.DB, region.SchemaManager
+	}
+
+	schemaName := schemas.GetSchemaName(tenantID)
+	qualified := fmt.Sprintf(`"%s"."%s%s"`, schemaName, sequencePrefix, name)
+
+	// Another process creating the same sequence can make CREATE SEQUENCE IF
+	// NOT EXISTS fail on the catalog's unique index although the sequence now
+	// exists, so a creation error only counts if nextval fails too
+	createErr := m.ensureSequence(ctx, db, tenantID, name, qualified)
+
+	var value int64
+	if err := db.QueryRowContext(ctx, "SELECT nextval($1::regclass)", qualified).Scan(&value); err != nil {
+		if createErr != nil {
+			return 0, fmt.Errorf("failed to create sequence %s: %w", name, createErr)
+		}
+		return 0, fmt.Errorf("failed to advance sequence %s: %w", name, err)
+	}
+	return value, nil
+}
+
+// ensureSequence creates the tenant's sequence unless this manager already has.
+// Concurrent first calls for the same sequence share one CREATE.
+func (m *manager) ensureSequence(ctx context.Context, db *sql.DB, tenantID uuid.UUID, name, qualified string) error {
+	key := tenantID.String() + "/" + name
+	if _, created := m.sequences.Load(key); created {
+		return nil
+	}
+
+	_, err := m.inits.do("sequence:"+name, tenantID, func() (interface{}, error) {
+		if _, created := m.sequences.Load(key); created {
+			return nil, nil
+		}
+		if _, err := db.ExecContext(ctx, "CREATE SEQUENCE IF NOT EXISTS "+qualified); err != nil {
+			return nil, err
+		}
+		m.sequences.Store(key, struct{}{})
+
+		m.logger.Debug("Created tenant sequence",
+			zap.String("tenant_id", tenantID.String()),
+			zap.String("sequence", name))
+		return nil, nil
+	})
+	return err
+}
@@ -0,0 +1,178 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestManager_NextTenantSequence(t *testing.T) {
+	m, db := newSequenceTestManager(t)
+	ctx := context.Background()
+	acme, globex := uuid.New(), uuid.New()
+
+	for want := int64(1); want <= 3; want++ {
+		got, err := m.NextTenantSequence(ctx, acme, "invoice_number")
+		if err != nil || got != want {
+			t.Fatalf("NextTenantSequence(acme) = %d, %v; want %d", got, err, want)
+		}
+	}
+
+	// Each tenant and each name counts on its own
+	if got, err := m.NextTenantSequence(ctx, globex, "invoice_number"); err != nil || got != 1 {
+		t.Errorf("NextTenantSequence(globex) = %d, %v; want 1", got, err)
+	}
+	if got, err := m.NextTenantSequence(ctx, acme, "order_number"); err != nil || got != 1 {
+		t.Errorf("NextTenantSequence(acme, order_number) = %d, %v; want 1", got, err)
+	}
+
+	schema := m.schemaManager.GetSchemaName(acme)
+	if _, exists := db.sequences[fmt.Sprintf(`"%s"."seq_invoice_number"`, schema)]; !exists {
+		t.Errorf("sequences = %v, want seq_invoice_number in %s", db.sequences, schema)
+	}
+	if db.creates != 3 {
+		t.Errorf("CREATE SEQUENCE ran %d times, want once per sequence", db.creates)
+	}
+
+	for _, name := range []string{"", "Invoice", "invoice-number", `x"; DROP TABLE users; --`, strings.Repeat("a", 60)} {
+		if _, err := m.NextTenantSequence(ctx, acme, name); !errors.Is(err, ErrInvalidSequenceName) {
+			t.Errorf("NextTenantSequence(%q) error = %v, want ErrInvalidSequenceName", name, err)
+		}
+	}
+}
+
+func TestManager_NextTenantSequence_Concurrent(t *testing.T) {
+	m, _ := newSequenceTestManager(t)
+	ctx := context.Background()
+	tenants := []uuid.UUID{uuid.New(), uuid.New()}
+
+	const calls = 50
+	var mu sync.Mutex
+	seen := make(map[uuid.UUID]map[int64]bool)
+	var wg sync.WaitGroup
+	for _, tenantID := range tenants {
+		seen[tenantID] = make(map[int64]bool)
+		for i := 0; i < calls; i++ {
+			wg.Add(1)
+			go func(tenantID uuid.UUID) {
+				defer wg.Done()
+				value, err := m.NextTenantSequence(ctx, tenantID, "invoice_number")
+				if err != nil {
+					t.Errorf("NextTenantSequence() error = %v", err)
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				if seen[tenantID][value] {
+					t.Errorf("value %d returned twice for tenant %s", value, tenantID)
+				}
+				seen[tenantID][value] = true
+			}(tenantID)
+		}
+	}
+	wg.Wait()
+
+	for _, tenantID := range tenants {
+		for value := int64(1); value <= calls; value++ {
+			if !seen[tenantID][value] {
+				t.Errorf("tenant %s never got %d, want 1 to %d without gaps", tenantID, value, calls)
+			}
+		}
+	}
+}
+
+// newSequenceTestManager returns a manager whose database keeps sequences in memory
+func newSequenceTestManager(t *testing.T) (*manager, *sequenceDB) {
+	seqDB := &sequenceDB{sequences: make(map[string]int64)}
+	db := sql.OpenDB(seqDB)
+	t.Cleanup(func() { db.Close() })
+
+	return newTestManager(t, withDB(db)), seqDB
+}
+
+// sequenceDB is a driver.Connector whose connections share in-memory sequences
+type sequenceDB struct {
+	mu        sync.Mutex
+	sequences map[string]int64 // last value by qualified name
+	creates   int
+}
+
+func (d *sequenceDB) Connect(ctx context.Context) (driver.Conn, error) {
+	return &sequenceConn{db: d}, nil
+}
+
+func (d *sequenceDB) Driver() driver.Driver {
+	return testDriver{}
+}
+
+type sequenceConn struct {
+	db *sequenceDB
+}
+
+func (c *sequenceConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("sequence test connection does not prepare statements")
+}
+
+func (c *sequenceConn) Close() error { return nil }
+
+func (c *sequenceConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("sequence test connection does not support transactions")
+}
+
+func (c *sequenceConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	name, ok := strings.CutPrefix(query, "CREATE SEQUENCE IF NOT EXISTS ")
+	if !ok {
+		return nil, fmt.Errorf("sequence test connection cannot execute %q", query)
+	}
+
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.creates++
+	if _, exists := c.db.sequences[name]; !exists {
+		c.db.sequences[name] = 0
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c *sequenceConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if query != "SELECT nextval($1::regclass)" || len(args) != 1 {
+		return nil, fmt.Errorf("sequence test connection cannot query %q", query)
+	}
+	name, _ := args[0].Value.(string)
+
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	last, exists := c.db.sequences[name]
+	if !exists {
+		return nil, fmt.Errorf("relation %s does not exist", name)
+	}
+	c.db.sequences[name] = last + 1
+	return &sequenceRows{value: last + 1}, nil
+}
+
+// sequenceRows is the single-row result of nextval
+type sequenceRows struct {
+	value int64
+	done  bool
+}
+
+func (r *sequenceRows) Columns() []string { return []string{"nextval"} }
+
+func (r *sequenceRows) Close() error { return nil }
+
+func (r *sequenceRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	dest[0] = r.value
+	r.done = true
+	return nil
+}
@@ -0,0 +1,54 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// SessionScoper is implemented by schema managers that scope a connection to
+// a tenant with session state besides search_path, such as the row-level
+// security setting of database.SharedSchemaManager. GetTenantConn and
+// WithTenantTx call ScopeSession after setting search_path; local is true
+// inside a transaction, where the state must end with it.
+type SessionScoper interface {
+	ScopeSession(ctx context.Context, conn RowQuerier, tenantID uuid.UUID, local bool) error
+}
+
+// SessionScopeTracker is implemented by driver connections that reset
+// session-level tenant state before the pool reuses them, such as those of
+// database.NewSessionResetConnector. GetTenantConn marks connections whose
+// session it scoped with a SessionScoper, since closing the *sql.Conn only
+// returns them to the pool.
+type SessionScopeTracker interface {
+	MarkTenantScoped()
+}
+
+// scopeSession applies the schema manager's session state, if it has any
+func (m *manager) scopeSession(ctx context.Context, conn RowQuerier, tenantID uuid.UUID, local bool) error {
+	scoper, ok := m.schemaManager.(SessionScoper)
+	if !ok {
+		return nil
+	}
+	if err := scoper.ScopeSession(ctx, conn, tenantID, local); err != nil {
+		return fmt.Errorf("failed to scope session to tenant: %w", err)
+	}
+	return nil
+}
+
+// markSessionScoped tells conn's driver connection, if it is a
+// SessionScopeTracker, that its session is scoped to a tenant. It does
+// nothing unless the schema manager is a SessionScoper.
+func (m *manager) markSessionScoped(conn *sql.Conn) error {
+	if _, ok := m.schemaManager.(SessionScoper); !ok {
+		return nil
+	}
+	return conn.Raw(func(driverConn interface{}) error {
+		if tracker, ok := driverConn.(SessionScopeTracker); ok {
+			tracker.MarkTenantScoped()
+		}
+		return nil
+	})
+}
@@ -0,0 +1,82 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// scopingSchemaManager scopes sessions as database.SharedSchemaManager does,
+// recording whether each scope was local
+type scopingSchemaManager struct {
+	*MockManagerSchemaManager
+	scopes []bool
+}
+
+func (s *scopingSchemaManager) ScopeSession(ctx context.Context, conn RowQuerier, tenantID uuid.UUID, local bool) error {
+	s.scopes = append(s.scopes, local)
+	return nil
+}
+
+// trackedConnector hands out connections that record being marked as scoped
+type trackedConnector struct {
+	execTestConnector
+	marked *int
+}
+
+func (c trackedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.execTestConnector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &trackedConn{execTestConn: conn.(*execTestConn), marked: c.marked}, nil
+}
+
+type trackedConn struct {
+	*execTestConn
+	marked *int
+}
+
+func (c *trackedConn) MarkTenantScoped() { *c.marked++ }
+
+func TestManager_GetTenantConn_MarksSessionScope(t *testing.T) {
+	m := newExecTestManager(t, &execRecorder{})
+	marked := 0
+	db := sql.OpenDB(trackedConnector{execTestConnector: execTestConnector{recorder: &execRecorder{}}, marked: &marked})
+	t.Cleanup(func() { db.Close() })
+	m.db = db
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	// Without session state there is nothing to reset
+	conn, err := m.GetTenantConn(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("GetTenantConn() error = %v", err)
+	}
+	conn.Close()
+	if marked != 0 {
+		t.Errorf("connection marked %d times without a SessionScoper, want none", marked)
+	}
+
+	schemas := &scopingSchemaManager{MockManagerSchemaManager: NewMockSchemaManager(m.config.Database.SchemaPrefix)}
+	m.schemaManager = schemas
+	conn, err = m.GetTenantConn(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("GetTenantConn() error = %v", err)
+	}
+	conn.Close()
+	if marked != 1 || len(schemas.scopes) != 1 || schemas.scopes[0] {
+		t.Errorf("connection marked %d times with session scopes %v, want one session-level scope marked once", marked, schemas.scopes)
+	}
+
+	// Transactions scope locally, so their connections are left unmarked
+	if err := m.WithTenantTx(ctx, tenantID, func(tx *sql.Tx) error { return nil }); err != nil {
+		t.Fatalf("WithTenantTx() error = %v", err)
+	}
+	if marked != 1 || len(schemas.scopes) != 2 || !schemas.scopes[1] {
+		t.Errorf("connection marked %d times with session scopes %v after a transaction, want the transaction scoped locally", marked, schemas.scopes)
+	}
+}
@@ -0,0 +1,64 @@
+package tenant
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// sqlLiteral is a tenant value that renders as a quoted SQL string literal
+type sqlLiteral string
+
+// String returns the value as an escaped SQL string literal
+func (s sqlLiteral) String() string {
+	return "'" + strings.ReplaceAll(string(s), "'", "''") + "'"
+}
+
+// SQLTemplateData is the data available to templated per-tenant SQL.
+// Every field renders as a quoted SQL string literal, so {{.Name}} expands to
+// 'Acme Corp' and must not be wrapped in quotes again. Use {{ident .SchemaName}}
+// to expand a value as a quoted identifier instead.
+type SQLTemplateData struct {
+	TenantID   sqlLiteral
+	Name       sqlLiteral
+	Subdomain  sqlLiteral
+	PlanType   sqlLiteral
+	Status     sqlLiteral
+	SchemaName sqlLiteral
+}
+
+// sqlTemplateFuncs are the helper functions available to SQL templates
+var sqlTemplateFuncs = template.FuncMap{
+	"ident": func(s sqlLiteral) string {
+		return `"` + strings.ReplaceAll(string(s), `"`, `""`) + `"`
+	},
+}
+
+// RenderTenantSQL expands template placeholders in SQL using the tenant
+// record. name identifies the SQL in error messages.
+func RenderTenantSQL(name, sql string, t *Tenant) (string, error) {
+	tmpl, err := template.New(name).
+		Funcs(sqlTemplateFuncs).
+		Option("missingkey=error").
+		Parse(sql)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s template: %w", name, err)
+	}
+
+	data := SQLTemplateData{
+		TenantID:   sqlLiteral(t.ID.String()),
+		Name:       sqlLiteral(t.Name),
+		Subdomain:  sqlLiteral(t.Subdomain),
+		PlanType:   sqlLiteral(t.PlanType),
+		Status:     sqlLiteral(t.Status),
+		SchemaName: sqlLiteral(t.SchemaName),
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,133 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// statsCache is a concurrency-safe TTL cache of tenant stats. Counting a
+// tenant's users, projects and storage can be expensive, so GetStats serves
+// recent results from here while GetStatsFresh always recounts.
+type statsCache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration // 0 disables caching
+	entries map[uuid.UUID]statsCacheEntry
+	gen     uint64 // bumped by invalidate, so that counts racing a write are not cached
+	now     func() time.Time
+}
+
+// statsCacheEntry is a cached copy of a tenant's stats
+type statsCacheEntry struct {
+	stats   Stats
+	expires time.Time
+}
+
+// newStatsCache creates a new stats cache
+func newStatsCache(ttl time.Duration) *statsCache {
+	return &statsCache{
+		ttl:     ttl,
+		entries: make(map[uuid.UUID]statsCacheEntry),
+		now:     time.Now,
+	}
+}
+
+// get returns a copy of the cached stats if they have not expired
+func (c *statsCache) get(id uuid.UUID) (*Stats, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mu.RLock()
+	entry, ok := c.entries[id]
+	c.mu.RUnlock()
+
+	if !ok || !c.now().Before(entry.expires) {
+		return nil, false
+	}
+
+	stats := entry.stats
+	return &stats, true
+}
+
+// generation returns the cache's generation; take it before counting and pass
+// it to put
+func (c *statsCache) generation() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.gen
+}
+
+// put caches a copy of the tenant's stats counted at generation gen. If an
+// entry was invalidated since, the count may predate that write and is not
+// cached.
+func (c *statsCache) put(id uuid.UUID, stats *Stats, gen uint64) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	if c.gen == gen {
+		c.entries[id] = statsCacheEntry{stats: *stats, expires: c.now().Add(c.ttl)}
+	}
+	c.mu.Unlock()
+}
+
+// invalidate removes the tenant's cached stats
+func (c *statsCache) invalidate(id uuid.UUID) {
+	c.mu.Lock()
+	delete(c.entries, id)
+	c.gen++
+	c.mu.Unlock()
+}
+
+// GetStats returns the tenant's stats, served from the cache for up to
+// Limits.StatsCacheTTL after they were last counted. Use GetStatsFresh where
+// the numbers must be current, e.g. for billing.
+func (m *manager) GetStats(ctx context.Context, tenantID uuid.UUID) (*Stats, error) {
+	if stats, ok := m.stats.get(tenantID); ok {
+		return stats, nil
+	}
+	return m.GetStatsFresh(ctx, tenantID)
+}
+
+// GetStatsFresh counts the tenant's stats, bypassing the cache, and caches the
+// result for later GetStats calls
+func (m *manager) GetStatsFresh(ctx context.Context, tenantID uuid.UUID) (*Stats, error) {
+	gen := m.stats.generation()
+	stats, err := m.countStats(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	m.stats.put(tenantID, stats, gen)
+	return stats, nil
+}
+
+// SchemaStatsCounter is implemented by schema managers that can count a
+// tenant's stats in their own database, such as database.SchemaManager. The
+// repository only reaches the primary database, so GetStats requires it of
+// the schema managers of regions.
+type SchemaStatsCounter interface {
+	CountStats(ctx context.Context, tenantID uuid.UUID) (*Stats, error)
+}
+
+// countStats counts the tenant's stats with the repository, or with the
+// region's schema manager for regional tenants
+func (m *manager) countStats(ctx context.Context, tenantID uuid.UUID) (*Stats, error) {
+	region, regional, err := m.tenantRegion(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !regional {
+		return m.repository.GetStats(ctx, tenantID)
+	}
+
+	counter, ok := region.SchemaManager.(SchemaStatsCounter)
+	if !ok {
+		return nil, fmt.Errorf("region schema manager cannot count stats of tenant %s", tenantID)
+	}
+	return counter.CountStats(ctx, tenantID)
+}
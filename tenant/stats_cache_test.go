@@ -0,0 +1,146 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// newStatsTestManager returns a manager caching stats for ttl, on a clock the
+// test controls
+func newStatsTestManager(t *testing.T, ttl time.Duration) (*manager, *MockManagerRepository, *time.Time) {
+	repo := NewMockRepository()
+	m := newTestManager(t, withRepository(repo), withConfig(func(config *Config) {
+		config.Limits.StatsCacheTTL = ttl
+	}))
+
+	now := time.Now()
+	m.stats.now = func() time.Time { return now }
+	return m, repo, &now
+}
+
+func TestManager_GetStats_Cached(t *testing.T) {
+	m, repo, now := newStatsTestManager(t, time.Minute)
+	ctx := context.Background()
+	tenantID := uuid.New()
+	repo.stats[tenantID] = &Stats{TenantID: tenantID, UserCount: 3}
+
+	if stats, err := m.GetStats(ctx, tenantID); err != nil || stats.UserCount != 3 {
+		t.Fatalf("GetStats() = %+v, %v; want 3 users", stats, err)
+	}
+
+	// Changes in the repository are not seen until the entry expires...
+	repo.stats[tenantID].UserCount = 4
+	*now = now.Add(59 * time.Second)
+	if stats, _ := m.GetStats(ctx, tenantID); stats.UserCount != 3 {
+		t.Errorf("GetStats() users = %d, want the cached 3", stats.UserCount)
+	}
+
+	// ...unless fresh stats are asked for
+	if stats, err := m.GetStatsFresh(ctx, tenantID); err != nil || stats.UserCount != 4 {
+		t.Errorf("GetStatsFresh() = %+v, %v; want 4 users", stats, err)
+	}
+	repo.stats[tenantID].UserCount = 5
+	if stats, _ := m.GetStats(ctx, tenantID); stats.UserCount != 4 {
+		t.Errorf("GetStats() users = %d, want 4 cached by GetStatsFresh", stats.UserCount)
+	}
+
+	// Once the TTL has passed the stats are recounted
+	*now = now.Add(time.Minute)
+	if stats, _ := m.GetStats(ctx, tenantID); stats.UserCount != 5 {
+		t.Errorf("GetStats() users = %d after expiry, want 5", stats.UserCount)
+	}
+
+	// Callers get copies, so changing one does not change the cache
+	stats, _ := m.GetStats(ctx, tenantID)
+	stats.UserCount = 100
+	if stats, _ := m.GetStats(ctx, tenantID); stats.UserCount != 5 {
+		t.Errorf("GetStats() users = %d, want the cache unaffected by callers", stats.UserCount)
+	}
+}
+
+func TestManager_GetStats_InvalidatedByMutations(t *testing.T) {
+	m, repo, _ := newStatsTestManager(t, time.Hour)
+	ctx := context.Background()
+
+	tenant := &Tenant{Name: "Acme", Subdomain: "acme", PlanType: PlanBasic, Status: StatusPending}
+	if err := m.CreateTenant(ctx, tenant); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+	repo.stats[tenant.ID] = &Stats{TenantID: tenant.ID, SchemaExists: false}
+	if stats, _ := m.GetStats(ctx, tenant.ID); stats.SchemaExists {
+		t.Fatal("GetStats() should report no schema before provisioning")
+	}
+
+	repo.stats[tenant.ID].SchemaExists = true
+	if err := m.ProvisionTenant(ctx, tenant.ID); err != nil {
+		t.Fatalf("ProvisionTenant() error = %v", err)
+	}
+	if stats, _ := m.GetStats(ctx, tenant.ID); !stats.SchemaExists {
+		t.Error("GetStats() should see the schema right after ProvisionTenant")
+	}
+
+	repo.stats[tenant.ID].UserCount = 0
+	if err := m.DeleteTenant(ctx, tenant.ID); err != nil {
+		t.Fatalf("DeleteTenant() error = %v", err)
+	}
+	if _, cached := m.stats.get(tenant.ID); cached {
+		t.Error("DeleteTenant() should drop the cached stats")
+	}
+}
+
+func TestManager_GetStats_CacheDisabled(t *testing.T) {
+	m, repo, _ := newStatsTestManager(t, 0)
+	ctx := context.Background()
+	tenantID := uuid.New()
+	repo.stats[tenantID] = &Stats{TenantID: tenantID, ProjectCount: 1}
+
+	m.GetStats(ctx, tenantID)
+	repo.stats[tenantID].ProjectCount = 2
+	if stats, _ := m.GetStats(ctx, tenantID); stats.ProjectCount != 2 {
+		t.Errorf("GetStats() projects = %d, want 2 with caching disabled", stats.ProjectCount)
+	}
+}
+
+func TestManager_GetStats_InvalidatedDuringCount(t *testing.T) {
+	m, repo, _ := newStatsTestManager(t, time.Hour)
+	ctx := context.Background()
+	tenantID := uuid.New()
+	repo.stats[tenantID] = &Stats{TenantID: tenantID, UserCount: 3}
+
+	// A user is added between the count and caching it
+	counting := &racingStatsRepository{MockManagerRepository: repo}
+	counting.afterCount = func() {
+		counting.afterCount = nil
+		repo.stats[tenantID] = &Stats{TenantID: tenantID, UserCount: 4}
+		m.stats.invalidate(tenantID)
+	}
+	m.repository = counting
+
+	if stats, err := m.GetStatsFresh(ctx, tenantID); err != nil || stats.UserCount != 3 {
+		t.Fatalf("GetStatsFresh() = %+v, %v; want the 3 users counted", stats, err)
+	}
+	if stats, _ := m.GetStats(ctx, tenantID); stats.UserCount != 4 {
+		t.Errorf("GetStats() users = %d, want 4 rather than the count taken before the change", stats.UserCount)
+	}
+}
+
+// racingStatsRepository runs afterCount once GetStats has counted
+type racingStatsRepository struct {
+	*MockManagerRepository
+	afterCount func()
+}
+
+func (r *racingStatsRepository) GetStats(ctx context.Context, tenantID uuid.UUID) (*Stats, error) {
+	stats, err := r.MockManagerRepository.GetStats(ctx, tenantID)
+	if err == nil {
+		copied := *stats
+		stats = &copied
+	}
+	if r.afterCount != nil {
+		r.afterCount()
+	}
+	return stats, err
+}
@@ -0,0 +1,175 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// DefaultWarmCacheLimit is how many tenants WarmCache loads when
+// ResolverConfig.WarmCacheLimit is not set
+const DefaultWarmCacheLimit = 1000
+
+// ActiveTenantLister is implemented by repositories that can load up to limit
+// active tenants in a single query. WarmCache uses it when available and
+// otherwise pages through List.
+type ActiveTenantLister interface {
+	ListActive(ctx context.Context, limit int) ([]*Tenant, error)
+}
+
+// tenantCache is a concurrency-safe TTL cache of tenant records by ID, also
+// indexed by subdomain. It lets the request path read a tenant's status without
+// a repository lookup; entries are invalidated on status transitions and
+// refreshed once they expire.
+type tenantCache struct {
+	mu          sync.RWMutex
+	ttl         time.Duration // 0 disables caching
+	entries     map[uuid.UUID]tenantCacheEntry
+	bySubdomain map[string]uuid.UUID
+	gen         uint64 // bumped by invalidate, so that loads racing a write are not cached
+	now         func() time.Time
+}
+
+// tenantCacheEntry is a cached copy of a tenant record
+type tenantCacheEntry struct {
+	tenant  Tenant
+	expires time.Time
+}
+
+// newTenantCache creates a new tenant cache
+func newTenantCache(ttl time.Duration) *tenantCache {
+	return &tenantCache{
+		ttl:         ttl,
+		entries:     make(map[uuid.UUID]tenantCacheEntry),
+		bySubdomain: make(map[string]uuid.UUID),
+		now:         time.Now,
+	}
+}
+
+// get returns a copy of the cached tenant if it has not expired
+func (c *tenantCache) get(id uuid.UUID) (*Tenant, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mu.RLock()
+	entry, ok := c.entries[id]
+	c.mu.RUnlock()
+
+	if !ok || !c.now().Before(entry.expires) {
+		return nil, false
+	}
+
+	tenant := entry.tenant
+	return &tenant, true
+}
+
+// getBySubdomain returns a copy of the cached tenant using subdomain if it has
+// not expired
+func (c *tenantCache) getBySubdomain(subdomain string) (*Tenant, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mu.RLock()
+	id, ok := c.bySubdomain[subdomain]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	tenant, ok := c.get(id)
+	if !ok || tenant.Subdomain != subdomain {
+		return nil, false
+	}
+	return tenant, true
+}
+
+// generation returns the cache's generation; take it before loading a tenant
+// and pass it to put
+func (c *tenantCache) generation() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.gen
+}
+
+// put caches a copy of the tenant loaded at generation gen. If an entry was
+// invalidated since, the tenant may predate that write and is not cached.
+// Entries are bounded by the number of tenants, so expired entries are simply
+// overwritten on the next lookup.
+func (c *tenantCache) put(tenant *Tenant, gen uint64) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	if c.gen == gen {
+		if old, ok := c.entries[tenant.ID]; ok && old.tenant.Subdomain != tenant.Subdomain {
+			delete(c.bySubdomain, old.tenant.Subdomain)
+		}
+		c.entries[tenant.ID] = tenantCacheEntry{tenant: *tenant, expires: c.now().Add(c.ttl)}
+		c.bySubdomain[tenant.Subdomain] = tenant.ID
+	}
+	c.mu.Unlock()
+}
+
+// invalidate removes the cached tenant
+func (c *tenantCache) invalidate(id uuid.UUID) {
+	c.mu.Lock()
+	if entry, ok := c.entries[id]; ok && c.bySubdomain[entry.tenant.Subdomain] == id {
+		delete(c.bySubdomain, entry.tenant.Subdomain)
+	}
+	delete(c.entries, id)
+	c.gen++
+	c.mu.Unlock()
+}
+
+// WarmCache loads active tenants into the resolution cache so that a freshly
+// started instance does not look up every tenant on its first requests, by ID
+// or by subdomain. Plan
+// limits are held in memory by the limit checker, so cached tenants are also
+// served by CheckLimits without a lookup. It does nothing if caching is
+// disabled.
+func (m *manager) WarmCache(ctx context.Context) error {
+	if m.tenants.ttl <= 0 {
+		m.logger.Debug("Tenant cache disabled, skipping warm-up")
+		return nil
+	}
+
+	limit := m.config.Resolver.WarmCacheLimit
+	if limit <= 0 {
+		limit = DefaultWarmCacheLimit
+	}
+
+	gen := m.tenants.generation()
+	warmed := 0
+	if lister, ok := m.repository.(ActiveTenantLister); ok {
+		tenants, err := lister.ListActive(ctx, limit)
+		if err != nil {
+			return fmt.Errorf("failed to list active tenants: %w", err)
+		}
+		for _, t := range tenants {
+			m.tenants.put(t, gen)
+		}
+		warmed = len(tenants)
+	} else {
+		err := m.eachActiveTenant(ctx, func(t *Tenant) bool {
+			m.tenants.put(t, gen)
+			warmed++
+			return warmed < limit
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	m.logger.Info("Warmed tenant cache",
+		zap.Int("tenants", warmed),
+		zap.Int("limit", limit))
+
+	return nil
+}
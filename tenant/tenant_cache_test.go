@@ -0,0 +1,356 @@
+package tenant
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestManager_ValidateAccess_UsesCachedStatus(t *testing.T) {
+	m, repo := newCachingTestManager(t, time.Minute)
+	ctx := context.Background()
+	tenantID := createActiveTestTenant(t, m)
+
+	repo.getByIDCalls = 0
+	for i := 0; i < 3; i++ {
+		if err := m.ValidateAccess(ctx, uuid.New(), tenantID); err != nil {
+			t.Fatalf("ValidateAccess() error = %v", err)
+		}
+	}
+	if _, err := m.GetTenant(ctx, tenantID); err != nil {
+		t.Fatalf("GetTenant() error = %v", err)
+	}
+	if repo.getByIDCalls != 1 {
+		t.Errorf("repository GetByID called %d times, want 1", repo.getByIDCalls)
+	}
+
+	// Suspending invalidates the cached status immediately
+	if err := m.SuspendTenant(ctx, tenantID); err != nil {
+		t.Fatalf("SuspendTenant() error = %v", err)
+	}
+	if err := m.ValidateAccess(ctx, uuid.New(), tenantID); err == nil {
+		t.Error("ValidateAccess() should reject a suspended tenant right after SuspendTenant")
+	}
+
+	// And activating does the same
+	if err := m.ActivateTenant(ctx, tenantID); err != nil {
+		t.Fatalf("ActivateTenant() error = %v", err)
+	}
+	if err := m.ValidateAccess(ctx, uuid.New(), tenantID); err != nil {
+		t.Errorf("ValidateAccess() error = %v right after ActivateTenant", err)
+	}
+}
+
+func TestManager_ValidateAccess_SeesExternalSuspensionAfterRefresh(t *testing.T) {
+	m, repo := newCachingTestManager(t, 5*time.Second)
+	ctx := context.Background()
+	tenantID := createActiveTestTenant(t, m)
+
+	now := time.Now()
+	m.tenants.now = func() time.Time { return now }
+
+	if err := m.ValidateAccess(ctx, uuid.New(), tenantID); err != nil {
+		t.Fatalf("ValidateAccess() error = %v", err)
+	}
+
+	// Another instance suspends the tenant directly in the repository
+	suspended := *repo.tenants[tenantID]
+	suspended.Status = StatusSuspended
+	repo.tenants[tenantID] = &suspended
+
+	// The cached status is served until the entry expires...
+	if err := m.ValidateAccess(ctx, uuid.New(), tenantID); err != nil {
+		t.Errorf("ValidateAccess() error = %v, want cached active status", err)
+	}
+
+	// ...and the suspension is seen after a short refresh
+	now = now.Add(5 * time.Second)
+	if err := m.ValidateAccess(ctx, uuid.New(), tenantID); err == nil {
+		t.Error("ValidateAccess() should see the suspension once the cache entry expires")
+	}
+}
+
+func TestManager_TenantCacheDisabled(t *testing.T) {
+	m, repo := newCachingTestManager(t, 0)
+	ctx := context.Background()
+	tenantID := createActiveTestTenant(t, m)
+
+	repo.getByIDCalls = 0
+	for i := 0; i < 3; i++ {
+		if err := m.ValidateAccess(ctx, uuid.New(), tenantID); err != nil {
+			t.Fatalf("ValidateAccess() error = %v", err)
+		}
+	}
+	if repo.getByIDCalls != 3 {
+		t.Errorf("repository GetByID called %d times, want 3 with caching disabled", repo.getByIDCalls)
+	}
+}
+
+func TestManager_WarmCache(t *testing.T) {
+	m, repo := newCachingTestManager(t, time.Minute)
+	ctx := context.Background()
+
+	var active []uuid.UUID
+	subdomains := []string{"acme", "globex", "initech"}
+	for _, subdomain := range subdomains {
+		tenant := &Tenant{ID: uuid.New(), Name: subdomain, Subdomain: subdomain, PlanType: PlanBasic, Status: StatusActive}
+		repo.MockManagerRepository.Create(ctx, tenant)
+		active = append(active, tenant.ID)
+	}
+	suspended := &Tenant{ID: uuid.New(), Name: "Umbrella", Subdomain: "umbrella", Status: StatusSuspended}
+	repo.MockManagerRepository.Create(ctx, suspended)
+
+	if err := m.WarmCache(ctx); err != nil {
+		t.Fatalf("WarmCache() error = %v", err)
+	}
+
+	// Every active tenant is now served from the cache
+	for _, id := range active {
+		if _, err := m.GetTenant(ctx, id); err != nil {
+			t.Fatalf("GetTenant() error = %v", err)
+		}
+		if _, err := m.CheckLimits(ctx, id); err != nil {
+			t.Fatalf("CheckLimits() error = %v", err)
+		}
+	}
+	if repo.getByIDCalls != 0 {
+		t.Errorf("repository GetByID called %d times after warming, want 0", repo.getByIDCalls)
+	}
+	for i, subdomain := range subdomains {
+		tenant, err := m.GetTenantBySubdomain(ctx, subdomain)
+		if err != nil {
+			t.Fatalf("GetTenantBySubdomain() error = %v", err)
+		}
+		if tenant.ID != active[i] {
+			t.Errorf("GetTenantBySubdomain(%s) = %s, want %s", subdomain, tenant.ID, active[i])
+		}
+	}
+	if repo.getBySubdomainCalls != 0 {
+		t.Errorf("repository GetBySubdomain called %d times after warming, want 0", repo.getBySubdomainCalls)
+	}
+
+	// Inactive tenants are not preloaded
+	if _, ok := m.tenants.get(suspended.ID); ok {
+		t.Error("WarmCache() should not cache suspended tenants")
+	}
+}
+
+func TestManager_GetTenantBySubdomain_Cached(t *testing.T) {
+	m, repo := newCachingTestManager(t, time.Minute)
+	ctx := context.Background()
+	tenantID := createActiveTestTenant(t, m)
+	stored, _ := m.GetTenant(ctx, tenantID)
+	oldSubdomain := stored.Subdomain
+
+	repo.getBySubdomainCalls = 0
+	for i := 0; i < 3; i++ {
+		if _, err := m.GetTenantBySubdomain(ctx, oldSubdomain); err != nil {
+			t.Fatalf("GetTenantBySubdomain() error = %v", err)
+		}
+	}
+	if repo.getBySubdomainCalls != 0 {
+		t.Errorf("repository GetBySubdomain called %d times for a cached tenant, want 0", repo.getBySubdomainCalls)
+	}
+
+	// A renamed tenant is no longer served under its old subdomain
+	stored.Subdomain = "renamed"
+	if err := m.UpdateTenant(ctx, stored); err != nil {
+		t.Fatalf("UpdateTenant() error = %v", err)
+	}
+	if _, err := m.GetTenantBySubdomain(ctx, oldSubdomain); err == nil {
+		t.Error("GetTenantBySubdomain() should not find the tenant under its old subdomain")
+	}
+	if tenant, err := m.GetTenantBySubdomain(ctx, "renamed"); err != nil || tenant.ID != tenantID {
+		t.Errorf("GetTenantBySubdomain() = %v, %v, want the renamed tenant", tenant, err)
+	}
+}
+
+func TestManager_WarmCache_Limit(t *testing.T) {
+	m, repo := newCachingTestManager(t, time.Minute)
+	m.config.Resolver.WarmCacheLimit = 2
+	ctx := context.Background()
+
+	for _, subdomain := range []string{"acme", "globex", "initech"} {
+		repo.MockManagerRepository.Create(ctx, &Tenant{ID: uuid.New(), Name: subdomain, Subdomain: subdomain, Status: StatusActive})
+	}
+
+	if err := m.WarmCache(ctx); err != nil {
+		t.Fatalf("WarmCache() error = %v", err)
+	}
+	if got := len(m.tenants.entries); got != 2 {
+		t.Errorf("cached %d tenants, want WarmCacheLimit (2)", got)
+	}
+}
+
+func TestManager_WarmCache_UsesActiveTenantLister(t *testing.T) {
+	config := DefaultConfig()
+	tenant := &Tenant{ID: uuid.New(), Name: "Acme Corp", Subdomain: "acme", Status: StatusActive}
+	repo := &listingRepository{MockManagerRepository: NewMockRepository(), active: []*Tenant{tenant}}
+
+	m := NewManager(config, nil, repo, NewMockSchemaManager(config.Database.SchemaPrefix),
+		NewMockMigrationManager(), NewMockLimitChecker(config.Limits), zaptest.NewLogger(t)).(*manager)
+	t.Cleanup(func() { m.Close() })
+
+	if err := m.WarmCache(context.Background()); err != nil {
+		t.Fatalf("WarmCache() error = %v", err)
+	}
+	if repo.limit != DefaultWarmCacheLimit {
+		t.Errorf("ListActive() limit = %d, want DefaultWarmCacheLimit", repo.limit)
+	}
+	if _, ok := m.tenants.get(tenant.ID); !ok {
+		t.Error("WarmCache() should cache the tenants returned by ListActive")
+	}
+}
+
+func TestManager_WarmCache_CacheDisabled(t *testing.T) {
+	m, repo := newCachingTestManager(t, 0)
+	ctx := context.Background()
+	repo.MockManagerRepository.Create(ctx, &Tenant{ID: uuid.New(), Name: "Acme", Subdomain: "acme", Status: StatusActive})
+
+	if err := m.WarmCache(ctx); err != nil {
+		t.Fatalf("WarmCache() error = %v", err)
+	}
+	if len(m.tenants.entries) != 0 {
+		t.Error("WarmCache() should not cache tenants when caching is disabled")
+	}
+}
+
+// listingRepository loads active tenants in one call
+type listingRepository struct {
+	*MockManagerRepository
+	active []*Tenant
+	limit  int
+}
+
+func (r *listingRepository) ListActive(ctx context.Context, limit int) ([]*Tenant, error) {
+	r.limit = limit
+	return r.active, nil
+}
+
+// countingRepository counts GetByID and GetBySubdomain calls on the manager
+// mock repository
+type countingRepository struct {
+	*MockManagerRepository
+	getByIDCalls        int
+	getBySubdomainCalls int
+}
+
+func (r *countingRepository) GetByID(ctx context.Context, id uuid.UUID) (*Tenant, error) {
+	r.getByIDCalls++
+	return r.MockManagerRepository.GetByID(ctx, id)
+}
+
+func (r *countingRepository) GetBySubdomain(ctx context.Context, subdomain string) (*Tenant, error) {
+	r.getBySubdomainCalls++
+	return r.MockManagerRepository.GetBySubdomain(ctx, subdomain)
+}
+
+// newCachingTestManager returns a manager whose tenant cache uses the given TTL
+func newCachingTestManager(t *testing.T, ttl time.Duration) (*manager, *countingRepository) {
+	repo := &countingRepository{MockManagerRepository: NewMockRepository()}
+	m := newTestManager(t, withRepository(repo), withConfig(func(config *Config) {
+		config.Resolver.CacheTTL = ttl
+	}))
+	return m, repo
+}
+
+// createActiveTestTenant creates an active tenant through the manager
+func createActiveTestTenant(t *testing.T, m *manager) uuid.UUID {
+	tenant := &Tenant{Name: "Acme Corp", Subdomain: "acme", PlanType: PlanBasic, Status: StatusActive}
+	if err := m.CreateTenant(context.Background(), tenant); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+	return tenant.ID
+}
+
+func TestManager_CachedTenant_ConcurrentFirstAccess(t *testing.T) {
+	m, _ := newCachingTestManager(t, time.Minute)
+	tenantID := createActiveTestTenant(t, m)
+
+	m.tenants.invalidate(tenantID)
+
+	repo := &blockingRepository{Repository: m.repository, release: make(chan struct{})}
+	m.repository = repo
+
+	const callers = 50
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tenant, err := m.cachedTenant(context.Background(), tenantID)
+			if err != nil || tenant.ID != tenantID {
+				t.Errorf("cachedTenant() = %v, %v, want the tenant", tenant, err)
+			}
+		}()
+	}
+
+	// Hold the first lookup until the other callers have had time to join it
+	time.Sleep(50 * time.Millisecond)
+	close(repo.release)
+	wg.Wait()
+
+	if calls := repo.calls.Load(); calls != 1 {
+		t.Errorf("repository looked up the tenant %d times, want 1", calls)
+	}
+}
+
+// blockingRepository counts GetByID calls and holds each until release is closed
+type blockingRepository struct {
+	Repository
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (r *blockingRepository) GetByID(ctx context.Context, id uuid.UUID) (*Tenant, error) {
+	r.calls.Add(1)
+	<-r.release
+	return r.Repository.GetByID(ctx, id)
+}
+
+func TestManager_CachedTenant_InvalidatedDuringLoad(t *testing.T) {
+	m, _ := newCachingTestManager(t, time.Minute)
+	ctx := context.Background()
+	tenantID := createActiveTestTenant(t, m)
+	m.tenants.invalidate(tenantID)
+
+	// The tenant is suspended between the lookup reading it and caching it
+	repo := &racingRepository{Repository: m.repository}
+	repo.afterRead = func() {
+		repo.afterRead = nil
+		if err := m.SuspendTenant(ctx, tenantID); err != nil {
+			t.Errorf("SuspendTenant() error = %v", err)
+		}
+	}
+	m.repository = repo
+
+	if tenant, err := m.cachedTenant(ctx, tenantID); err != nil || tenant.Status != StatusActive {
+		t.Fatalf("cachedTenant() = %v, %v, want the tenant as read", tenant, err)
+	}
+	if err := m.ValidateAccess(ctx, uuid.New(), tenantID); err == nil {
+		t.Error("ValidateAccess() should not be served the status read before the suspension")
+	}
+}
+
+// racingRepository runs afterRead once GetByID has read the tenant
+type racingRepository struct {
+	Repository
+	afterRead func()
+}
+
+func (r *racingRepository) GetByID(ctx context.Context, id uuid.UUID) (*Tenant, error) {
+	tenant, err := r.Repository.GetByID(ctx, id)
+	if err == nil {
+		copied := *tenant
+		tenant = &copied
+	}
+	if r.afterRead != nil {
+		r.afterRead()
+	}
+	return tenant, err
+}
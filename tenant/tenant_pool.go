@@ -0,0 +1,76 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// DefaultTenantPoolConns is the size of each tenant's dedicated pool when
+// DatabaseConfig.TenantPoolConns is not set
+const DefaultTenantPoolConns = 4
+
+// TenantPoolOpener opens a dedicated connection pool for a tenant whose
+// connections start with search_path set to schemaName, such as the opener
+// returned by database.NewTenantPoolOpener
+type TenantPoolOpener func(ctx context.Context, tenantID uuid.UUID, schemaName string) (*sql.DB, error)
+
+// SetTenantPoolOpener enables pooled mode once DatabaseConfig.MaxTenantPools
+// is set: GetTenantConn and WithTenantTx then take connections from a pool
+// per tenant, opened with opener, instead of setting search_path on a shared
+// connection every time. At most MaxTenantPools pools are kept; the least
+// recently used is closed to make room. Regional tenants are not pooled.
+func (m *manager) SetTenantPoolOpener(opener TenantPoolOpener) {
+	m.poolOpenerMu.Lock()
+	defer m.poolOpenerMu.Unlock()
+	m.poolOpener = opener
+}
+
+// tenantPoolOpener returns the opener if pooled mode is enabled, or nil
+func (m *manager) tenantPoolOpener() TenantPoolOpener {
+	if m.config.Database.MaxTenantPools <= 0 {
+		return nil
+	}
+
+	m.poolOpenerMu.RLock()
+	defer m.poolOpenerMu.RUnlock()
+	return m.poolOpener
+}
+
+// tenantConn acquires a dedicated connection for the tenant, reporting
+// whether it came from the tenant's own pool with search_path already set
+func (m *manager) tenantConn(ctx context.Context, tenantID uuid.UUID) (*sql.Conn, bool, error) {
+	region, regional, err := m.tenantRegion(ctx, tenantID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	db := m.db
+	pooled := false
+	if regional {
+		db = region.DB
+	} else if opener := m.tenantPoolOpener(); opener != nil {
+		// The lease keeps an eviction from closing the pool before a
+		// connection is taken; a taken connection outlives the pool's close
+		var release func()
+		db, release, err = m.connections.acquire(tenantID, func() (*sql.DB, error) {
+			m.logger.Debug("Opening tenant connection pool",
+				zap.String("tenant_id", tenantID.String()))
+			return opener(ctx, tenantID, m.schemaManager.GetSchemaName(tenantID))
+		})
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to open tenant connection pool: %w", err)
+		}
+		defer release()
+		pooled = true
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	return conn, pooled, nil
+}
@@ -0,0 +1,178 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+// newPooledTestManager creates a manager over a shared pool whose SET
+// statements are counted by shared, in pooled mode with room for maxPools
+func newPooledTestManager(logger *zap.Logger, shared *execConnector, maxPools int) *manager {
+	config := DefaultConfig()
+	config.Database.MaxTenantPools = maxPools
+
+	return NewManager(config, sql.OpenDB(shared), NewMockRepository(), NewMockSchemaManager(config.Database.SchemaPrefix),
+		NewMockMigrationManager(), NewMockLimitChecker(config.Limits), logger).(*manager)
+}
+
+func TestManager_GetTenantConn_PooledMode(t *testing.T) {
+	shared := &execConnector{}
+	m := newPooledTestManager(zaptest.NewLogger(t), shared, 2)
+
+	pools := make(map[uuid.UUID]*sql.DB)
+	poolConns := &execConnector{}
+	m.SetTenantPoolOpener(func(ctx context.Context, tenantID uuid.UUID, schemaName string) (*sql.DB, error) {
+		if schemaName != m.schemaManager.GetSchemaName(tenantID) {
+			t.Errorf("opener got schema %q, want the tenant's", schemaName)
+		}
+		pools[tenantID] = sql.OpenDB(poolConns)
+		return pools[tenantID], nil
+	})
+
+	ctx := context.Background()
+	tenantA, tenantB, tenantC := uuid.New(), uuid.New(), uuid.New()
+
+	for i := 0; i < 3; i++ {
+		conn, err := m.GetTenantConn(ctx, tenantA)
+		if err != nil {
+			t.Fatalf("GetTenantConn() error = %v", err)
+		}
+		conn.Close()
+	}
+	if err := m.WithTenantTx(ctx, tenantA, func(tx *sql.Tx) error { return nil }); err != nil {
+		t.Fatalf("WithTenantTx() error = %v", err)
+	}
+
+	if len(pools) != 1 {
+		t.Errorf("opened %d pools for one tenant, want 1", len(pools))
+	}
+	if execs := shared.execs.Load() + poolConns.execs.Load(); execs != 0 {
+		t.Errorf("pooled connections ran %d statements, want none", execs)
+	}
+
+	// A third tenant evicts the least recently used pool
+	for _, id := range []uuid.UUID{tenantB, tenantA, tenantC} {
+		conn, err := m.GetTenantConn(ctx, id)
+		if err != nil {
+			t.Fatalf("GetTenantConn() error = %v", err)
+		}
+		conn.Close()
+	}
+	if !isClosed(pools[tenantB]) {
+		t.Error("least recently used tenant pool should be closed on eviction")
+	}
+	if isClosed(pools[tenantA]) || isClosed(pools[tenantC]) {
+		t.Error("recently used tenant pools should stay open")
+	}
+
+	m.Close()
+	for id, pool := range pools {
+		if !isClosed(pool) {
+			t.Errorf("Close() left the pool of tenant %s open", id)
+		}
+	}
+}
+
+func TestManager_GetTenantConn_PooledModeWithoutOpener(t *testing.T) {
+	shared := &execConnector{}
+	m := newPooledTestManager(zaptest.NewLogger(t), shared, 2)
+	defer m.Close()
+
+	conn, err := m.GetTenantConn(context.Background(), uuid.New())
+	if err != nil {
+		t.Fatalf("GetTenantConn() error = %v", err)
+	}
+	conn.Close()
+
+	if execs := shared.execs.Load(); execs != 1 {
+		t.Errorf("GetTenantConn() ran %d statements on the shared pool, want SET search_path", execs)
+	}
+	if m.connections.len() != 0 {
+		t.Error("no tenant pool should be opened without an opener")
+	}
+}
+
+func BenchmarkGetTenantConn(b *testing.B) {
+	// Each statement costs a simulated round trip to the database
+	const roundTrip = 50 * time.Microsecond
+	tenantID := uuid.New()
+
+	b.Run("per-call", func(b *testing.B) {
+		m := newPooledTestManager(zap.NewNop(), &execConnector{latency: roundTrip}, 0)
+		defer m.Close()
+		benchmarkGetTenantConn(b, m, tenantID)
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		m := newPooledTestManager(zap.NewNop(), &execConnector{latency: roundTrip}, 16)
+		defer m.Close()
+		m.SetTenantPoolOpener(func(ctx context.Context, tenantID uuid.UUID, schemaName string) (*sql.DB, error) {
+			return sql.OpenDB(&execConnector{latency: roundTrip}), nil
+		})
+		benchmarkGetTenantConn(b, m, tenantID)
+	})
+}
+
+func benchmarkGetTenantConn(b *testing.B, m *manager, tenantID uuid.UUID) {
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		conn, err := m.GetTenantConn(ctx, tenantID)
+		if err != nil {
+			b.Fatalf("GetTenantConn() error = %v", err)
+		}
+		conn.Close()
+	}
+}
+
+// execConnector opens connections that accept any statement after latency,
+// counting those that change the session with SET
+type execConnector struct {
+	latency time.Duration
+	execs   atomic.Int64
+}
+
+func (c *execConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &execConn{connector: c}, nil
+}
+
+func (c *execConnector) Driver() driver.Driver {
+	return testDriver{}
+}
+
+type execConn struct {
+	connector *execConnector
+}
+
+func (c *execConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if c.connector.latency > 0 {
+		time.Sleep(c.connector.latency)
+	}
+	if strings.HasPrefix(query, "SET ") {
+		c.connector.execs.Add(1)
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c *execConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("exec connection does not prepare statements")
+}
+
+func (c *execConn) Close() error { return nil }
+
+func (c *execConn) Begin() (driver.Tx, error) { return execTx{}, nil }
+
+type execTx struct{}
+
+func (execTx) Commit() error   { return nil }
+func (execTx) Rollback() error { return nil }
@@ -0,0 +1,49 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// TracerName is the instrumentation name of the manager's spans when
+// TracingConfig.Tracer is not set
+const TracerName = "github.com/alexalmadav/go-multitenant/tenant"
+
+// AttributeTenantID is the span attribute that carries the tenant's ID
+const AttributeTenantID = attribute.Key("tenant_id")
+
+// newTracer returns the tracer for config: a no-op tracer unless tracing is
+// enabled, then config.Tracer or the global provider's
+func newTracer(config TracingConfig) trace.Tracer {
+	if !config.Enabled {
+		return noop.NewTracerProvider().Tracer(TracerName)
+	}
+	if config.Tracer != nil {
+		return config.Tracer
+	}
+	return otel.Tracer(TracerName)
+}
+
+// startSpan starts a span named name, carrying tenantID unless it is uuid.Nil
+func (m *manager) startSpan(ctx context.Context, name string, tenantID uuid.UUID) (context.Context, trace.Span) {
+	var opts []trace.SpanStartOption
+	if tenantID != uuid.Nil {
+		opts = append(opts, trace.WithAttributes(AttributeTenantID.String(tenantID.String())))
+	}
+	return m.tracer.Start(ctx, name, opts...)
+}
+
+// endSpan records err, if any, on span and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
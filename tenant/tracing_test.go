@@ -0,0 +1,139 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newTracingTestManager returns a manager over an execRecorder database whose
+// spans go to the returned recorder
+func newTracingTestManager(t *testing.T, enabled bool) (*manager, *tracetest.SpanRecorder, *execRecorder) {
+	spans := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	recorder := &execRecorder{}
+	db := sql.OpenDB(execTestConnector{recorder: recorder})
+	t.Cleanup(func() { db.Close() })
+
+	m := newTestManager(t, withDB(db), withConfig(func(config *Config) {
+		config.Tracing = TracingConfig{Enabled: enabled, Tracer: provider.Tracer("test")}
+	}))
+	return m, spans, recorder
+}
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) (string, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value.AsString(), true
+		}
+	}
+	return "", false
+}
+
+func TestManager_TracingSpans(t *testing.T) {
+	m, spans, recorder := newTracingTestManager(t, true)
+	ctx := context.Background()
+
+	tenant := &Tenant{Name: "Acme", Subdomain: "acme"}
+	if err := m.CreateTenant(ctx, tenant); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+	if err := m.ProvisionTenant(ctx, tenant.ID); err != nil {
+		t.Fatalf("ProvisionTenant() error = %v", err)
+	}
+	conn, err := m.GetTenantConn(ctx, tenant.ID)
+	if err != nil {
+		t.Fatalf("GetTenantConn() error = %v", err)
+	}
+	conn.Close()
+
+	// The transaction span is open while the search path is set and fn runs
+	var spanOpenInFn bool
+	var searchPathSetBeforeFn bool
+	err = m.WithTenantTx(ctx, tenant.ID, func(tx *sql.Tx) error {
+		started := spans.Started()
+		spanOpenInFn = started[len(started)-1].Name() == "tenant.WithTenantTx" && len(spans.Ended()) == len(started)-1
+		for _, statement := range recorder.statementsFor(m.schemaManager.GetSchemaName(tenant.ID)) {
+			searchPathSetBeforeFn = searchPathSetBeforeFn || strings.HasPrefix(statement, "SET LOCAL search_path")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTenantTx() error = %v", err)
+	}
+
+	ended := spans.Ended()
+	want := []string{"tenant.CreateTenant", "tenant.ProvisionTenant", "tenant.GetTenantConn", "tenant.WithTenantTx"}
+	if len(ended) != len(want) {
+		t.Fatalf("recorded %d spans, want %d", len(ended), len(want))
+	}
+	for i, span := range ended {
+		if span.Name() != want[i] {
+			t.Errorf("span %d = %s, want %s", i, span.Name(), want[i])
+		}
+		if got, ok := spanAttribute(span, AttributeTenantID); !ok || got != tenant.ID.String() {
+			t.Errorf("span %s tenant_id = %q, want %s", span.Name(), got, tenant.ID)
+		}
+		if span.Status().Code == codes.Error {
+			t.Errorf("span %s has error status %q", span.Name(), span.Status().Description)
+		}
+	}
+
+	if !spanOpenInFn {
+		t.Error("the WithTenantTx span should still be open while fn runs")
+	}
+	if !searchPathSetBeforeFn {
+		t.Error("SET LOCAL search_path should run inside the WithTenantTx span, before fn")
+	}
+}
+
+func TestManager_TracingRecordsErrors(t *testing.T) {
+	m, spans, _ := newTracingTestManager(t, true)
+	ctx := context.Background()
+
+	tenant := &Tenant{Name: "Acme", Subdomain: "acme"}
+	if err := m.CreateTenant(ctx, tenant); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+
+	fnErr := errors.New("insert failed")
+	if err := m.WithTenantTx(ctx, tenant.ID, func(tx *sql.Tx) error { return fnErr }); !errors.Is(err, fnErr) {
+		t.Fatalf("WithTenantTx() error = %v, want %v", err, fnErr)
+	}
+	if err := m.CreateTenant(ctx, &Tenant{Name: "", Subdomain: "globex"}); err == nil {
+		t.Fatal("CreateTenant() without a name should fail")
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 3 {
+		t.Fatalf("recorded %d spans, want 3", len(ended))
+	}
+	for _, span := range ended[1:] {
+		if span.Status().Code != codes.Error {
+			t.Errorf("span %s status = %v, want an error", span.Name(), span.Status().Code)
+		}
+		if events := span.Events(); len(events) == 0 || events[0].Name != "exception" {
+			t.Errorf("span %s events = %v, want the recorded error", span.Name(), events)
+		}
+	}
+}
+
+func TestManager_TracingDisabledByDefault(t *testing.T) {
+	m, spans, _ := newTracingTestManager(t, false)
+
+	if err := m.CreateTenant(context.Background(), &Tenant{Name: "Acme", Subdomain: "acme"}); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+	if n := len(spans.Ended()); n != 0 {
+		t.Errorf("recorded %d spans with tracing disabled, want 0", n)
+	}
+}
@@ -0,0 +1,324 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// GetUsage returns the tenant's usage of a limit as reported by the usage
+// tracker, or a USAGE_TRACKER_UNAVAILABLE error when none is set
+func (m *manager) GetUsage(ctx context.Context, tenantID uuid.UUID, limitName string) (interface{}, error) {
+	tracker := m.limitChecker.GetUsageTracker()
+	if tracker == nil {
+		return nil, &TenantError{
+			TenantID: tenantID,
+			Code:     "USAGE_TRACKER_UNAVAILABLE",
+			Message:  "no usage tracker is set",
+		}
+	}
+
+	usage, err := tracker.GetCurrentUsage(ctx, tenantID, limitName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current usage: %w", err)
+	}
+	return usage, nil
+}
+
+// UsageLister is implemented by usage trackers that can read all of a
+// tenant's current usage at once, such as the PostgreSQL tracker, so that
+// callers needing every limit's usage make one round trip instead of one per
+// limit
+type UsageLister interface {
+	// GetAllUsage returns the tenant's current usage keyed by limit name.
+	// Limits without recorded usage are left out.
+	GetAllUsage(ctx context.Context, tenantID uuid.UUID) (map[string]interface{}, error)
+}
+
+// GetAllUsage returns the tenant's tracked usage of every limit that has
+// any, keyed by limit name. Trackers that are not UsageListers are asked for
+// each numeric limit of the tenant in turn.
+func (m *manager) GetAllUsage(ctx context.Context, tenantID uuid.UUID) (map[string]interface{}, error) {
+	tracker := m.limitChecker.GetUsageTracker()
+	if tracker == nil {
+		return nil, &TenantError{
+			TenantID: tenantID,
+			Code:     "USAGE_TRACKER_UNAVAILABLE",
+			Message:  "no usage tracker is set",
+		}
+	}
+
+	if lister, ok := tracker.(UsageLister); ok {
+		usage, err := lister.GetAllUsage(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current usage: %w", err)
+		}
+		return usage, nil
+	}
+
+	limits, err := m.limitChecker.GetLimitsForTenant(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant limits: %w", err)
+	}
+
+	usage := make(map[string]interface{})
+	for name, limit := range limits {
+		if limit.Type != LimitTypeInt && limit.Type != LimitTypeFloat {
+			continue
+		}
+		current, err := tracker.GetCurrentUsage(ctx, tenantID, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current usage of %s: %w", name, err)
+		}
+		if current != nil {
+			usage[name] = current
+		}
+	}
+	return usage, nil
+}
+
+// ReleaseUsage decrements the tenant's tracked usage of a limit by amount,
+// e.g. when a project is deleted, clamping at zero so that releasing more
+// than was recorded cannot leave a negative count. The read and the
+// decrement are separate tracker calls, so concurrent changes to the same
+// counter are not clamped exactly.
+func (m *manager) ReleaseUsage(ctx context.Context, tenantID uuid.UUID, limitName string, amount int) error {
+	if amount <= 0 {
+		return &ValidationError{Field: "amount", Message: "amount must be positive"}
+	}
+
+	tracker := m.limitChecker.GetUsageTracker()
+	if tracker == nil {
+		return &TenantError{
+			TenantID: tenantID,
+			Code:     "USAGE_TRACKER_UNAVAILABLE",
+			Message:  "no usage tracker is set",
+		}
+	}
+
+	usage, err := tracker.GetCurrentUsage(ctx, tenantID, limitName)
+	if err != nil {
+		return fmt.Errorf("failed to get current usage: %w", err)
+	}
+
+	var current int
+	switch v := usage.(type) {
+	case nil:
+	case int:
+		current = v
+	case int64:
+		current = int(v)
+	case float64:
+		current = int(v)
+	default:
+		return fmt.Errorf("cannot release usage of %s: usage is %T, not a count", limitName, usage)
+	}
+
+	release := min(amount, current)
+	if release <= 0 {
+		m.logger.Debug("Usage already at zero, nothing to release",
+			zap.String("tenant_id", tenantID.String()),
+			zap.String("limit", limitName))
+		return nil
+	}
+
+	if err := tracker.DecrementUsage(ctx, tenantID, limitName, release); err != nil {
+		return fmt.Errorf("failed to release usage: %w", err)
+	}
+	m.stats.invalidate(tenantID)
+
+	m.logger.Debug("Released usage",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("limit", limitName),
+		zap.Int("amount", release))
+
+	return nil
+}
+
+// UsageDrift is the difference between a tenant's tracked usage of a limit and
+// the value counted from the tenant's data
+type UsageDrift struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	Limit    string    `json:"limit"`
+	Tracked  float64   `json:"tracked"`
+	Actual   float64   `json:"actual"`
+}
+
+// Drift returns how far the tracked usage is from the actual usage; positive
+// when the tracker over-counts
+func (d *UsageDrift) Drift() float64 {
+	return d.Tracked - d.Actual
+}
+
+// reconcilableLimits are the limits whose usage is counted in Stats
+var reconcilableLimits = []string{LimitNameMaxUsers, LimitNameMaxProjects, LimitNameMaxStorageGB}
+
+// usageFromStats returns the usage of a limit as counted in the tenant's
+// stats, and false for limits the stats do not cover
+func usageFromStats(stats *Stats, limitName string) (interface{}, bool) {
+	switch limitName {
+	case LimitNameMaxUsers:
+		return stats.UserCount, true
+	case LimitNameMaxProjects:
+		return stats.ProjectCount, true
+	case LimitNameMaxStorageGB:
+		return stats.StorageUsedGB, true
+	default:
+		return nil, false
+	}
+}
+
+// usageFloat converts a tracked usage value to a float64
+func usageFloat(usage interface{}) (float64, bool) {
+	switch v := usage.(type) {
+	case nil:
+		return 0, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// ReconcileUsage recounts the tenant's usage of a limit from its stats and
+// resets the usage tracker to match, returning the drift that was found. Only
+// limits covered by Stats (max_users, max_projects and max_storage_gb) can be
+// reconciled. The tracker is left untouched when it already matches.
+func (m *manager) ReconcileUsage(ctx context.Context, tenantID uuid.UUID, limitName string) (*UsageDrift, error) {
+	tracker := m.limitChecker.GetUsageTracker()
+	if tracker == nil {
+		return nil, &TenantError{
+			TenantID: tenantID,
+			Code:     "USAGE_TRACKER_UNAVAILABLE",
+			Message:  "no usage tracker is set",
+		}
+	}
+
+	stats, err := m.GetStatsFresh(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant stats: %w", err)
+	}
+	actual, ok := usageFromStats(stats, limitName)
+	if !ok {
+		return nil, &TenantError{
+			TenantID: tenantID,
+			Code:     "USAGE_NOT_RECONCILABLE",
+			Message:  fmt.Sprintf("usage of %s cannot be counted from tenant stats", limitName),
+		}
+	}
+
+	usage, err := tracker.GetCurrentUsage(ctx, tenantID, limitName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current usage: %w", err)
+	}
+	tracked, ok := usageFloat(usage)
+	if !ok {
+		return nil, fmt.Errorf("cannot reconcile usage of %s: usage is %T, not a count", limitName, usage)
+	}
+
+	drift := &UsageDrift{TenantID: tenantID, Limit: limitName, Tracked: tracked}
+	drift.Actual, _ = usageFloat(actual)
+	if drift.Drift() == 0 {
+		return drift, nil
+	}
+
+	if err := tracker.ResetUsage(ctx, tenantID, limitName); err != nil {
+		return nil, fmt.Errorf("failed to reset usage: %w", err)
+	}
+	if drift.Actual != 0 {
+		if err := tracker.IncrementUsage(ctx, tenantID, limitName, actual); err != nil {
+			return nil, fmt.Errorf("failed to set usage: %w", err)
+		}
+	}
+
+	m.logger.Warn("Reconciled usage drift",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("limit", limitName),
+		zap.Float64("tracked", drift.Tracked),
+		zap.Float64("actual", drift.Actual))
+
+	return drift, nil
+}
+
+// ReconcileAllUsage reconciles every reconcilable limit of every active
+// tenant and returns the drifts that were corrected. The tenants are listed
+// one page at a time as they are reconciled. A failure for one tenant does
+// not stop the others; the returned error reports how many failed.
+func (m *manager) ReconcileAllUsage(ctx context.Context) ([]*UsageDrift, error) {
+	var drifts []*UsageDrift
+	var reconciled, failed int
+	err := m.eachActiveTenant(ctx, func(t *Tenant) bool {
+		reconciled++
+		limits, err := m.limitChecker.GetLimitsForTenant(ctx, t.ID)
+		if err != nil {
+			failed++
+			m.logger.Error("Failed to get limits for usage reconciliation",
+				zap.String("tenant_id", t.ID.String()),
+				zap.Error(err))
+			return true
+		}
+
+		for _, limitName := range reconcilableLimits {
+			if _, ok := limits[limitName]; !ok {
+				continue
+			}
+			drift, err := m.ReconcileUsage(ctx, t.ID, limitName)
+			if err != nil {
+				failed++
+				m.logger.Error("Failed to reconcile usage",
+					zap.String("tenant_id", t.ID.String()),
+					zap.String("limit", limitName),
+					zap.Error(err))
+				continue
+			}
+			if drift.Drift() != 0 {
+				drifts = append(drifts, drift)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return drifts, err
+	}
+
+	m.logger.Info("Reconciled tenant usage",
+		zap.Int("tenants", reconciled),
+		zap.Int("drifted", len(drifts)),
+		zap.Int("failed", failed))
+
+	if failed > 0 {
+		return drifts, fmt.Errorf("usage reconciliation failed %d times", failed)
+	}
+	return drifts, nil
+}
+
+// reconcileLoop runs ReconcileAllUsage every interval until stop is closed.
+// Runs are skipped while no usage tracker is set.
+func (m *manager) reconcileLoop(interval time.Duration, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if m.limitChecker.GetUsageTracker() == nil {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			// Failures are logged by ReconcileAllUsage
+			m.ReconcileAllUsage(ctx)
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+// counterUsageTracker keeps integer usage counters in memory
+type counterUsageTracker struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func newCounterUsageTracker() *counterUsageTracker {
+	return &counterUsageTracker{counts: make(map[string]int)}
+}
+
+func (c *counterUsageTracker) key(tenantID uuid.UUID, limitName string) string {
+	return tenantID.String() + ":" + limitName
+}
+
+func (c *counterUsageTracker) GetCurrentUsage(ctx context.Context, tenantID uuid.UUID, limitName string) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[c.key(tenantID, limitName)], nil
+}
+
+func (c *counterUsageTracker) IncrementUsage(ctx context.Context, tenantID uuid.UUID, limitName string, delta interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[c.key(tenantID, limitName)] += delta.(int)
+	return nil
+}
+
+func (c *counterUsageTracker) DecrementUsage(ctx context.Context, tenantID uuid.UUID, limitName string, delta interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[c.key(tenantID, limitName)] -= delta.(int)
+	return nil
+}
+
+func (c *counterUsageTracker) ResetUsage(ctx context.Context, tenantID uuid.UUID, limitName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.counts, c.key(tenantID, limitName))
+	return nil
+}
+
+func newUsageTestManager(t *testing.T) (Manager, LimitChecker, *MockManagerRepository) {
+	repo := NewMockRepository()
+	m := newTestManager(t, withRepository(repo), withRealLimitChecker())
+	return m, m.limitChecker, repo
+}
+
+func TestManager_GetUsage(t *testing.T) {
+	m, checker, _ := newUsageTestManager(t)
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	var tenantErr *TenantError
+	if _, err := m.GetUsage(ctx, tenantID, LimitNameMaxProjects); !errors.As(err, &tenantErr) || tenantErr.Code != "USAGE_TRACKER_UNAVAILABLE" {
+		t.Errorf("GetUsage() without a tracker error = %v, want USAGE_TRACKER_UNAVAILABLE", err)
+	}
+
+	tracker := newCounterUsageTracker()
+	checker.SetUsageTracker(tracker)
+	tracker.IncrementUsage(ctx, tenantID, LimitNameMaxProjects, 4)
+	if usage, err := m.GetUsage(ctx, tenantID, LimitNameMaxProjects); err != nil || usage != 4 {
+		t.Errorf("GetUsage() = %v, %v; want 4", usage, err)
+	}
+}
+
+// listingUsageTracker serves all of a tenant's usage in one call, counting
+// the calls made to it
+type listingUsageTracker struct {
+	*counterUsageTracker
+	listed, reads int
+}
+
+func (l *listingUsageTracker) GetCurrentUsage(ctx context.Context, tenantID uuid.UUID, limitName string) (interface{}, error) {
+	l.reads++
+	return l.counterUsageTracker.GetCurrentUsage(ctx, tenantID, limitName)
+}
+
+func (l *listingUsageTracker) GetAllUsage(ctx context.Context, tenantID uuid.UUID) (map[string]interface{}, error) {
+	l.listed++
+	return map[string]interface{}{LimitNameMaxProjects: 4}, nil
+}
+
+func TestManager_GetAllUsage(t *testing.T) {
+	m, checker, repo := newUsageTestManager(t)
+	ctx := context.Background()
+	tenantID := uuid.New()
+	repo.tenants[tenantID] = &Tenant{ID: tenantID, PlanType: PlanBasic, Status: StatusActive}
+
+	var tenantErr *TenantError
+	if _, err := m.GetAllUsage(ctx, tenantID); !errors.As(err, &tenantErr) || tenantErr.Code != "USAGE_TRACKER_UNAVAILABLE" {
+		t.Errorf("GetAllUsage() without a tracker error = %v, want USAGE_TRACKER_UNAVAILABLE", err)
+	}
+
+	// Other trackers are read once per numeric limit
+	tracker := newCounterUsageTracker()
+	checker.SetUsageTracker(tracker)
+	tracker.IncrementUsage(ctx, tenantID, LimitNameMaxProjects, 4)
+	usage, err := m.GetAllUsage(ctx, tenantID)
+	if err != nil || usage[LimitNameMaxProjects] != 4 {
+		t.Errorf("GetAllUsage() = %v, %v; want 4 projects", usage, err)
+	}
+	if _, ok := usage[LimitNameMaxUsers]; !ok {
+		t.Errorf("GetAllUsage() = %v, want every numeric limit read", usage)
+	}
+
+	// UsageListers are asked once
+	lister := &listingUsageTracker{counterUsageTracker: newCounterUsageTracker()}
+	checker.SetUsageTracker(lister)
+	usage, err = m.GetAllUsage(ctx, tenantID)
+	if err != nil || usage[LimitNameMaxProjects] != 4 {
+		t.Errorf("GetAllUsage() = %v, %v; want 4 projects", usage, err)
+	}
+	if lister.listed != 1 || lister.reads != 0 {
+		t.Errorf("tracker listed %d times and read %d times, want one listing", lister.listed, lister.reads)
+	}
+}
+
+func TestManager_ReleaseUsage(t *testing.T) {
+	m, checker, _ := newUsageTestManager(t)
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	var tenantErr *TenantError
+	if err := m.ReleaseUsage(ctx, tenantID, LimitNameMaxProjects, 1); !errors.As(err, &tenantErr) || tenantErr.Code != "USAGE_TRACKER_UNAVAILABLE" {
+		t.Errorf("ReleaseUsage() without a tracker error = %v, want USAGE_TRACKER_UNAVAILABLE", err)
+	}
+
+	tracker := newCounterUsageTracker()
+	checker.SetUsageTracker(tracker)
+	usage := func() int {
+		v, _ := tracker.GetCurrentUsage(ctx, tenantID, LimitNameMaxProjects)
+		return v.(int)
+	}
+
+	// Increment then release returns to the baseline
+	tracker.IncrementUsage(ctx, tenantID, LimitNameMaxProjects, 3)
+	tracker.IncrementUsage(ctx, tenantID, LimitNameMaxProjects, 2)
+	if err := m.ReleaseUsage(ctx, tenantID, LimitNameMaxProjects, 2); err != nil {
+		t.Fatalf("ReleaseUsage() error = %v", err)
+	}
+	if usage() != 3 {
+		t.Errorf("usage after release = %d, want 3", usage())
+	}
+
+	// Releasing more than was used clamps at zero
+	if err := m.ReleaseUsage(ctx, tenantID, LimitNameMaxProjects, 10); err != nil {
+		t.Fatalf("ReleaseUsage() error = %v", err)
+	}
+	if usage() != 0 {
+		t.Errorf("usage after over-release = %d, want 0", usage())
+	}
+	if err := m.ReleaseUsage(ctx, tenantID, LimitNameMaxProjects, 1); err != nil {
+		t.Fatalf("ReleaseUsage() at zero error = %v", err)
+	}
+	if usage() != 0 {
+		t.Errorf("usage after releasing at zero = %d, want 0", usage())
+	}
+
+	var validationErr *ValidationError
+	if err := m.ReleaseUsage(ctx, tenantID, LimitNameMaxProjects, 0); !errors.As(err, &validationErr) {
+		t.Errorf("ReleaseUsage(0) error = %v, want a ValidationError", err)
+	}
+}
+
+func TestManager_ReconcileUsage(t *testing.T) {
+	m, checker, repo := newUsageTestManager(t)
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	tracker := newCounterUsageTracker()
+	checker.SetUsageTracker(tracker)
+
+	// The tracker missed two project deletions and a user signup
+	repo.stats[tenantID] = &Stats{TenantID: tenantID, UserCount: 4, ProjectCount: 3}
+	tracker.IncrementUsage(ctx, tenantID, LimitNameMaxProjects, 5)
+	tracker.IncrementUsage(ctx, tenantID, LimitNameMaxUsers, 3)
+
+	drift, err := m.ReconcileUsage(ctx, tenantID, LimitNameMaxProjects)
+	if err != nil {
+		t.Fatalf("ReconcileUsage() error = %v", err)
+	}
+	if drift.Tracked != 5 || drift.Actual != 3 || drift.Drift() != 2 {
+		t.Errorf("drift = %+v, want tracked 5, actual 3", drift)
+	}
+	if usage, _ := tracker.GetCurrentUsage(ctx, tenantID, LimitNameMaxProjects); usage != 3 {
+		t.Errorf("usage after reconcile = %v, want 3", usage)
+	}
+
+	// Reconciling again finds nothing to fix
+	if drift, err := m.ReconcileUsage(ctx, tenantID, LimitNameMaxProjects); err != nil || drift.Drift() != 0 {
+		t.Errorf("second ReconcileUsage() = %+v, %v; want no drift", drift, err)
+	}
+
+	var tenantErr *TenantError
+	if _, err := m.ReconcileUsage(ctx, tenantID, "api_calls_per_month"); !errors.As(err, &tenantErr) || tenantErr.Code != "USAGE_NOT_RECONCILABLE" {
+		t.Errorf("ReconcileUsage(api_calls_per_month) error = %v, want USAGE_NOT_RECONCILABLE", err)
+	}
+
+	// A full reconcile fixes the remaining drift on active tenants
+	repo.tenants[tenantID] = &Tenant{ID: tenantID, PlanType: PlanBasic, Status: StatusActive}
+	drifts, err := m.ReconcileAllUsage(ctx)
+	if err != nil {
+		t.Fatalf("ReconcileAllUsage() error = %v", err)
+	}
+	if len(drifts) != 1 || drifts[0].Limit != LimitNameMaxUsers || drifts[0].Drift() != -1 {
+		t.Errorf("drifts = %+v, want max_users under-counted by one", drifts)
+	}
+	if usage, _ := tracker.GetCurrentUsage(ctx, tenantID, LimitNameMaxUsers); usage != 4 {
+		t.Errorf("max_users usage after reconcile = %v, want 4", usage)
+	}
+}
+
+func TestManager_ReconcileInterval(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	config := DefaultConfig()
+	config.Limits.ReconcileInterval = 10 * time.Millisecond
+	repo := NewMockRepository()
+	checker := NewLimitChecker(config.Limits, repo, logger)
+
+	tenantID := uuid.New()
+	repo.tenants[tenantID] = &Tenant{ID: tenantID, PlanType: PlanBasic, Status: StatusActive}
+	repo.stats[tenantID] = &Stats{TenantID: tenantID, ProjectCount: 1}
+
+	tracker := newCounterUsageTracker()
+	tracker.IncrementUsage(context.Background(), tenantID, LimitNameMaxProjects, 7)
+	checker.SetUsageTracker(tracker)
+
+	m := NewManager(config, nil, repo, NewMockSchemaManager(""), NewMockMigrationManager(), checker, logger)
+	defer m.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		usage, _ := tracker.GetCurrentUsage(context.Background(), tenantID, LimitNameMaxProjects)
+		if usage == 1 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("usage = %v after a second, want the scheduled reconcile to set it to 1", usage)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
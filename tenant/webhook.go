@@ -0,0 +1,230 @@
+package tenant
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Defaults applied to WebhookConfig by NewWebhookPublisher
+const (
+	DefaultWebhookMaxRetries     = 3
+	DefaultWebhookInitialBackoff = time.Second
+	DefaultWebhookTimeout        = 10 * time.Second
+	DefaultWebhookQueueSize      = 100
+)
+
+// Webhook request headers
+const (
+	// WebhookSignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the
+	// request body, keyed with WebhookConfig.Secret
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	// WebhookEventHeader carries the event type
+	WebhookEventHeader = "X-Webhook-Event"
+)
+
+// ErrWebhookQueueFull is returned by WebhookPublisher.Publish when events are
+// raised faster than the webhook accepts them
+var ErrWebhookQueueFull = errors.New("webhook queue is full")
+
+// ErrWebhookPublisherClosed is returned by WebhookPublisher.Publish after Close
+var ErrWebhookPublisherClosed = errors.New("webhook publisher is closed")
+
+// WebhookConfig configures the HTTP webhook that tenant events are posted to.
+// Webhooks are off while URL is empty.
+type WebhookConfig struct {
+	URL            string        `json:"url"`
+	Secret         string        `json:"secret"`          // signs requests with HMAC-SHA256; empty sends them unsigned
+	MaxRetries     int           `json:"max_retries"`     // retries after a failed delivery, 0 uses DefaultWebhookMaxRetries, -1 = none
+	InitialBackoff time.Duration `json:"initial_backoff"` // wait before the first retry, doubled for each further one
+	Timeout        time.Duration `json:"timeout"`         // per delivery attempt
+	QueueSize      int           `json:"queue_size"`      // events waiting for delivery before Publish fails
+}
+
+// Validate checks that the webhook URL, if set, is an absolute http or https URL
+func (wc WebhookConfig) Validate() error {
+	if wc.URL == "" {
+		return nil
+	}
+	u, err := url.Parse(wc.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &ValidationError{Field: "webhook.url", Message: fmt.Sprintf("invalid webhook URL %q, want an http or https URL", wc.URL)}
+	}
+	if wc.MaxRetries < -1 || wc.InitialBackoff < 0 || wc.Timeout < 0 || wc.QueueSize < 0 {
+		return &ValidationError{Field: "webhook", Message: "webhook retries, durations and queue size cannot be negative"}
+	}
+	return nil
+}
+
+// WebhookPublisher is an EventPublisher that POSTs each event as JSON to a
+// webhook URL. Publish only queues the event; a background worker delivers
+// events in order, retrying failed deliveries with exponential backoff, so a
+// slow webhook does not hold up the operation that raised the event.
+type WebhookPublisher struct {
+	config WebhookConfig
+	client *http.Client
+	logger *zap.Logger
+
+	mu     sync.RWMutex
+	closed bool
+	events chan Event
+	done   chan struct{}
+}
+
+// NewWebhookPublisher creates a webhook publisher and starts its delivery
+// worker. Call Close to deliver the queued events and stop it.
+func NewWebhookPublisher(config WebhookConfig, logger *zap.Logger) (*WebhookPublisher, error) {
+	if config.URL == "" {
+		return nil, &ValidationError{Field: "webhook.url", Message: "webhook URL is required"}
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = DefaultWebhookMaxRetries
+	}
+	if config.InitialBackoff == 0 {
+		config.InitialBackoff = DefaultWebhookInitialBackoff
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultWebhookTimeout
+	}
+	if config.QueueSize == 0 {
+		config.QueueSize = DefaultWebhookQueueSize
+	}
+
+	p := &WebhookPublisher{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+		logger: logger.Named("webhook"),
+		events: make(chan Event, config.QueueSize),
+		done:   make(chan struct{}),
+	}
+	go p.run()
+	return p, nil
+}
+
+// Publish queues the event for delivery. It returns ErrWebhookQueueFull
+// instead of waiting when the queue is full.
+func (p *WebhookPublisher) Publish(ctx context.Context, event Event) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrWebhookPublisherClosed
+	}
+
+	select {
+	case p.events <- event:
+		return nil
+	default:
+		return ErrWebhookQueueFull
+	}
+}
+
+// Close stops accepting events and waits for the queued ones to be delivered
+// or to run out of retries
+func (p *WebhookPublisher) Close() error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.events)
+	}
+	p.mu.Unlock()
+
+	<-p.done
+	return nil
+}
+
+// run delivers queued events until the queue is closed and drained
+func (p *WebhookPublisher) run() {
+	defer close(p.done)
+	for event := range p.events {
+		if err := p.deliver(event); err != nil {
+			p.logger.Error("Failed to deliver webhook",
+				zap.String("event", event.Type),
+				zap.String("tenant_id", event.TenantID.String()),
+				zap.Error(err))
+		}
+	}
+}
+
+// deliver posts the event, retrying network errors, 429s and 5xx responses
+func (p *WebhookPublisher) deliver(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	backoff := p.config.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := p.post(event.Type, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || p.config.MaxRetries < 0 || attempt >= p.config.MaxRetries {
+			return err
+		}
+
+		p.logger.Warn("Webhook delivery failed, retrying",
+			zap.String("event", event.Type),
+			zap.String("tenant_id", event.TenantID.String()),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("backoff", backoff),
+			zap.Error(err))
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post sends one delivery attempt and reports whether a failure is worth
+// retrying
+func (p *WebhookPublisher) post(eventType string, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, p.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, eventType)
+	if p.config.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(p.config.Secret, body))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+}
+
+// SignWebhookPayload returns the WebhookSignatureHeader value for body
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature reports whether signature, the WebhookSignatureHeader
+// of a received webhook, was made for body with secret. Receivers should
+// verify the signature before trusting the payload.
+func VerifyWebhookSignature(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhookPayload(secret, body)), []byte(signature))
+}
@@ -0,0 +1,186 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+// webhookReceiver is a test webhook endpoint that records the events it gets
+type webhookReceiver struct {
+	t      *testing.T
+	secret string
+
+	mu       sync.Mutex
+	events   []Event
+	failures int // requests to answer with a 503 before accepting
+	received chan struct{}
+}
+
+func newWebhookReceiver(t *testing.T, secret string) (*webhookReceiver, *httptest.Server) {
+	receiver := &webhookReceiver{t: t, secret: secret, received: make(chan struct{}, 100)}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+	return receiver, server
+}
+
+func (rc *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if !VerifyWebhookSignature(rc.secret, body, r.Header.Get(WebhookSignatureHeader)) {
+		rc.t.Errorf("signature %q does not match the body", r.Header.Get(WebhookSignatureHeader))
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.failures > 0 {
+		rc.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		rc.t.Errorf("payload %s is not an event: %v", body, err)
+	}
+	if r.Header.Get(WebhookEventHeader) != event.Type {
+		rc.t.Errorf("%s = %q, want %q", WebhookEventHeader, r.Header.Get(WebhookEventHeader), event.Type)
+	}
+	rc.events = append(rc.events, event)
+	rc.received <- struct{}{}
+}
+
+// wait returns the first n events, failing the test if they do not arrive
+func (rc *webhookReceiver) wait(n int) []Event {
+	rc.t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-rc.received:
+		case <-time.After(5 * time.Second):
+			rc.t.Fatalf("received %d webhook events, want %d", i, n)
+		}
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return append([]Event(nil), rc.events...)
+}
+
+func TestManager_PublishesLifecycleWebhooks(t *testing.T) {
+	receiver, server := newWebhookReceiver(t, "whsec_test")
+	logger := zaptest.NewLogger(t)
+	publisher, err := NewWebhookPublisher(WebhookConfig{URL: server.URL, Secret: "whsec_test"}, logger)
+	if err != nil {
+		t.Fatalf("NewWebhookPublisher() error = %v", err)
+	}
+	defer publisher.Close()
+
+	config := DefaultConfig()
+	m := NewManager(config, (*sql.DB)(nil), NewMockRepository(), NewMockSchemaManager(""), NewMockMigrationManager(), NewMockLimitChecker(config.Limits), logger)
+	defer m.Close()
+	m.SetEventPublisher(publisher)
+
+	ctx := context.Background()
+	tnt := &Tenant{Name: "Acme", Subdomain: "acme", PlanType: PlanPro}
+	if err := m.CreateTenant(ctx, tnt); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+	if err := m.ProvisionTenant(ctx, tnt.ID); err != nil {
+		t.Fatalf("ProvisionTenant() error = %v", err)
+	}
+	if err := m.SuspendTenant(ctx, tnt.ID); err != nil {
+		t.Fatalf("SuspendTenant() error = %v", err)
+	}
+	if err := m.DeleteTenant(ctx, tnt.ID); err != nil {
+		t.Fatalf("DeleteTenant() error = %v", err)
+	}
+
+	events := receiver.wait(4)
+	want := []string{EventTenantCreated, EventTenantProvisioned, EventTenantSuspended, EventTenantDeleted}
+	for i, event := range events {
+		if event.Type != want[i] {
+			t.Errorf("event %d = %s, want %s", i, event.Type, want[i])
+		}
+		if event.TenantID != tnt.ID || event.PlanType != PlanPro || event.Timestamp.IsZero() {
+			t.Errorf("event %d = %+v, want tenant %s on plan %s with a timestamp", i, event, tnt.ID, PlanPro)
+		}
+	}
+}
+
+func TestWebhookPublisher_RetriesWithBackoff(t *testing.T) {
+	receiver, server := newWebhookReceiver(t, "whsec_test")
+	receiver.failures = 2
+
+	publisher, err := NewWebhookPublisher(WebhookConfig{
+		URL:            server.URL,
+		Secret:         "whsec_test",
+		InitialBackoff: time.Millisecond,
+	}, zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatalf("NewWebhookPublisher() error = %v", err)
+	}
+	defer publisher.Close()
+
+	event := Event{Type: EventTenantCreated, TenantID: uuid.New(), Timestamp: time.Now()}
+	if err := publisher.Publish(context.Background(), event); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if events := receiver.wait(1); len(events) != 1 || events[0].TenantID != event.TenantID {
+		t.Errorf("received %+v, want the event delivered after the failures", events)
+	}
+}
+
+func TestWebhookPublisher_DoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+
+	publisher, err := NewWebhookPublisher(WebhookConfig{URL: server.URL, QueueSize: 1, MaxRetries: -1}, zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatalf("NewWebhookPublisher() error = %v", err)
+	}
+
+	// One event is in flight and one queued; the next does not wait for them
+	start := time.Now()
+	var full error
+	for i := 0; i < 3; i++ {
+		full = publisher.Publish(context.Background(), Event{Type: EventTenantCreated, TenantID: uuid.New()})
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Publish() should not wait for a slow webhook")
+	}
+	if !errors.Is(full, ErrWebhookQueueFull) {
+		t.Errorf("Publish() with a full queue error = %v, want ErrWebhookQueueFull", full)
+	}
+
+	close(release)
+	publisher.Close()
+	if err := publisher.Publish(context.Background(), Event{Type: EventTenantCreated}); !errors.Is(err, ErrWebhookPublisherClosed) {
+		t.Errorf("Publish() after Close error = %v, want ErrWebhookPublisherClosed", err)
+	}
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"event":"tenant.created"}`)
+	signature := SignWebhookPayload("whsec_test", body)
+
+	if !VerifyWebhookSignature("whsec_test", body, signature) {
+		t.Error("signature should verify with the signing secret")
+	}
+	if VerifyWebhookSignature("other", body, signature) {
+		t.Error("signature should not verify with another secret")
+	}
+	if VerifyWebhookSignature("whsec_test", []byte(`{"event":"tenant.deleted"}`), signature) {
+		t.Error("signature should not verify for a tampered body")
+	}
+}
@@ -80,6 +80,20 @@ func (m *MockRepository) GetBySubdomain(ctx context.Context, subdomain string) (
 	return nil, sql.ErrNoRows
 }
 
+func (m *MockRepository) ExistsByID(ctx context.Context, id uuid.UUID) (bool, error) {
+	_, exists := m.tenants[id]
+	return exists, nil
+}
+
+func (m *MockRepository) ExistsBySubdomain(ctx context.Context, subdomain string) (bool, error) {
+	for _, t := range m.tenants {
+		if t.Subdomain == subdomain {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func (m *MockRepository) Update(ctx context.Context, t *tenant.Tenant) error {
 	existing, exists := m.tenants[t.ID]
 	if !exists {
@@ -117,6 +131,7 @@ func (m *MockRepository) List(ctx context.Context, page, perPage int) ([]*tenant
 		}
 	}
 
+	page, perPage = tenant.NormalizePagination(page, perPage)
 	total := len(activeTenants)
 	start := (page - 1) * perPage
 	end := start + perPage
@@ -309,6 +324,10 @@ func (m *MockLimitChecker) CheckAllLimits(ctx context.Context, tenantID uuid.UUI
 	return nil
 }
 
+func (m *MockLimitChecker) CheckLimitAndConsume(ctx context.Context, tenantID uuid.UUID, limitName string, amount int) (int, error) {
+	return -1, nil
+}
+
 func (m *MockLimitChecker) GetLimitSchema() *tenant.LimitSchema {
 	return m.config.LimitSchema
 }
@@ -317,12 +336,23 @@ func (m *MockLimitChecker) SetLimitSchema(schema *tenant.LimitSchema) {
 	m.config.LimitSchema = schema
 }
 
+func (m *MockLimitChecker) AddDefinition(def *tenant.LimitDefinition) error {
+	m.config.LimitSchema.AddDefinition(def)
+	return nil
+}
+
+func (m *MockLimitChecker) RemoveDefinition(name string) error {
+	m.config.LimitSchema.RemoveDefinition(name)
+	return nil
+}
+
 func (m *MockLimitChecker) GetLimitsForPlan(planType string) tenant.FlexibleLimits {
 	return m.planLimits[planType]
 }
 
-func (m *MockLimitChecker) SetLimitsForPlan(planType string, limits tenant.FlexibleLimits) {
+func (m *MockLimitChecker) SetLimitsForPlan(planType string, limits tenant.FlexibleLimits) error {
 	m.planLimits[planType] = limits
+	return nil
 }
 
 func (m *MockLimitChecker) AddLimit(planType, limitName string, limitType tenant.LimitType, value interface{}) error {
@@ -352,6 +382,30 @@ func (m *MockLimitChecker) ValidateLimits(planType string, limits tenant.Flexibl
 	return nil
 }
 
+func (m *MockLimitChecker) SetEventPublisher(publisher tenant.EventPublisher) {
+	// Mock implementation
+}
+
+func (m *MockLimitChecker) SetDenialSink(sink tenant.DenialSink) {
+	// Mock implementation
+}
+
+func (m *MockLimitChecker) SetMetricsCollector(collector tenant.MetricsCollector) {
+	// Mock implementation
+}
+
+func (m *MockLimitChecker) SetOverrideProvider(provider tenant.OverrideProvider) {
+	// Mock implementation
+}
+
+func (m *MockLimitChecker) SetTenantLimitOverride(ctx context.Context, tenantID uuid.UUID, limitName string, value *tenant.LimitValue) error {
+	return nil
+}
+
+func (m *MockLimitChecker) GetLimitsForTenant(ctx context.Context, tenantID uuid.UUID) (tenant.FlexibleLimits, error) {
+	return nil, nil
+}
+
 func (m *MockLimitChecker) SetUsageTracker(tracker tenant.UsageTracker) {
 	// Mock implementation
 }